@@ -16,6 +16,17 @@ func (a HAlign) AlignLeft() bool   { return a == AlignLeft }
 func (a HAlign) AlignCenter() bool { return a == AlignCenter }
 func (a HAlign) AlignRight() bool  { return a == AlignRight }
 
+func (a HAlign) Flip() HAlign {
+	switch a {
+	case AlignLeft:
+		return AlignRight
+	case AlignRight:
+		return AlignLeft
+	default:
+		return a
+	}
+}
+
 type VAlign int
 
 const (
@@ -63,6 +63,7 @@ func CreateBubbleOverlay(driver Driver, styles *StyleDefs) *BubbleOverlay {
 	result.SetPadding(math.Spacing{Left: 5, Top: 5, Right: 5, Bottom: 5})
 	result.SetPen(styles.BubbleOverlayStyle.Pen)
 	result.SetBrush(styles.BubbleOverlayStyle.Brush)
+	result.SetShadow(styles.BubbleOverlayStyle.Shadow)
 	return result
 }
 
@@ -239,6 +240,43 @@ func CreateSplitterLayout(driver Driver, styles *StyleDefs) *AppSplitterLayout {
 	return result
 }
 
+func CreateStatsGraph(driver Driver, styles *StyleDefs) *StatsGraph {
+	result := &StatsGraph{}
+	result.Init(result, driver, styles)
+
+	result.OnAttach(
+		func() {
+			result.ticker = time.NewTicker(time.Millisecond * 250)
+			result.done = make(chan struct{})
+			go func(ticker *time.Ticker, done chan struct{}) {
+				for {
+					select {
+					case <-ticker.C:
+						if !driver.Call(result.poll) {
+							return
+						}
+					case <-done:
+						return
+					}
+				}
+			}(result.ticker, result.done)
+		},
+	)
+
+	result.OnDetach(
+		func() {
+			result.ticker.Stop()
+			result.ticker = nil
+			close(result.done)
+			result.done = nil
+		},
+	)
+
+	result.SetBackgroundBrush(CreateBrush(Color{R: 0, G: 0, B: 0, A: 0.6}))
+	result.SetBorderPen(CreatePen(1, Gray40))
+	return result
+}
+
 func CreateTableLayout(driver Driver, styles *StyleDefs) *TableLayoutImpl {
 	result := &TableLayoutImpl{}
 	result.Init(result, driver)
@@ -291,6 +329,7 @@ type Style struct {
 	FontColor Color
 	Brush     Brush
 	Pen       Pen
+	Shadow    Shadow
 	VAlign    VAlign
 	HAlign    HAlign
 }
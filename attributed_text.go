@@ -0,0 +1,258 @@
+package gxui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/badu/gxui/pkg/math"
+)
+
+// TextSpan is a span of text drawn with the same attributes. The zero value of
+// each attribute keeps the default of the control drawing the span.
+type TextSpan struct {
+	Text string
+	// Font draws the span, at Size DIPs when Size is not 0.
+	Font Font
+	Size int
+	// Color draws the text, and Background fills the lines behind it unless it
+	// is transparent.
+	Color      Color
+	Background Color
+	// Underline and Strikethrough draw a line under or through the text, in
+	// its color.
+	Underline     bool
+	Strikethrough bool
+	// BaselineOffset raises the span above the baseline by that many DIPs, or
+	// lowers it when negative, as superscripts and subscripts are.
+	BaselineOffset int
+}
+
+// AttributedText is a text made of spans with their own attributes.
+type AttributedText []TextSpan
+
+// String returns the text of the spans.
+func (t AttributedText) String() string {
+	var b strings.Builder
+	for _, span := range t {
+		b.WriteString(span.Text)
+	}
+	return b.String()
+}
+
+// attributedLayout is the layout of an attributed text by a control, with the
+// fonts and colors the spans are drawn with.
+type attributedLayout struct {
+	font     Font
+	spans    AttributedText
+	fonts    []Font
+	colors   []Color
+	runes    []rune
+	styles   []int
+	runs     []TextRun
+	runSpans []int
+	offsets  []math.Point
+}
+
+// layoutAttributedText lays out the spans with font, or with their own fonts
+// resized by the driver, and draws them with color unless they have their own.
+// New lines are replaced with spaces unless multiline is true.
+func layoutAttributedText(driver Driver, font Font, color Color, spans AttributedText, multiline bool) *attributedLayout {
+	result := &attributedLayout{font: font, spans: spans}
+	for i, span := range spans {
+		text := span.Text
+		if !multiline {
+			text = strings.Replace(text, "\n", " ", -1)
+		}
+		for _, r := range text {
+			result.runes = append(result.runes, r)
+			result.styles = append(result.styles, i)
+		}
+		result.fonts = append(result.fonts, spanFont(driver, font, span))
+		if span.Color == (Color{}) {
+			result.colors = append(result.colors, color)
+		} else {
+			result.colors = append(result.colors, span.Color)
+		}
+	}
+	result.runs, result.runSpans = result.textRuns(result.styles)
+	return result
+}
+
+// spanFont returns the font drawing the span: its own or font, at its size.
+func spanFont(driver Driver, font Font, span TextSpan) Font {
+	if span.Font != nil {
+		font = span.Font
+	}
+	if span.Size != 0 && span.Size != font.Size() {
+		if resized, err := driver.CreateResizedFont(font, span.Size); err == nil {
+			font = resized
+		}
+	}
+	return font
+}
+
+// textRuns returns the runs of the runes with the styles, and the span of each
+// run.
+func (l *attributedLayout) textRuns(styles []int) ([]TextRun, []int) {
+	var runs []TextRun
+	var spans []int
+	for i, style := range styles {
+		if i > 0 && style == styles[i-1] {
+			runs[len(runs)-1].Runes++
+			continue
+		}
+		runs = append(runs, TextRun{
+			Runes:          1,
+			Font:           l.fonts[style],
+			BaselineOffset: l.spans[style].BaselineOffset,
+		})
+		spans = append(spans, style)
+	}
+	return runs, spans
+}
+
+// width returns the width of the runes with the styles.
+func (l *attributedLayout) width(runes []rune, styles []int) int {
+	runs, _ := l.textRuns(styles)
+	return l.font.Measure(&TextBlock{Runes: runes, Runs: runs}).Width
+}
+
+// fit wraps the lines of the text wider than width, then truncates those still
+// wider.
+func (l *attributedLayout) fit(width int, wrap TextWrap, truncation TextTruncation) {
+	if wrap == NoWrap && truncation == NoTruncation {
+		return
+	}
+
+	var runes []rune
+	var styles []int
+	for start := 0; start <= len(l.runes); {
+		end := start
+		for end < len(l.runes) && l.runes[end] != '\n' {
+			end++
+		}
+
+		lineRunes, lineStyles := l.runes[start:end], l.styles[start:end]
+		// The wrapped lines end where the next ones start.
+		ends := wrapRunes(lineRunes, width, wrap, func(s, e int) int {
+			return l.width(lineRunes[s:e], lineStyles[s:e])
+		})
+		ends = append(ends, len(lineRunes))
+		for i, e := range ends {
+			s := 0
+			if i > 0 {
+				s = ends[i-1]
+			}
+			// Lines wrapped after spaces end before them.
+			trimmed := e
+			for i < len(ends)-1 && trimmed > s && unicode.IsSpace(lineRunes[trimmed-1]) {
+				trimmed--
+			}
+			r, st := truncateRunes(lineRunes[s:trimmed], lineStyles[s:trimmed], width, truncation, l.width)
+			runes = append(runes, r...)
+			styles = append(styles, st...)
+			if i < len(ends)-1 {
+				runes = append(runes, '\n')
+				styles = append(styles, lineStyles[e-1])
+			}
+		}
+
+		if end < len(l.runes) {
+			runes = append(runes, '\n')
+			styles = append(styles, l.styles[end])
+		}
+		start = end + 1
+	}
+	l.runes, l.styles = runes, styles
+	l.runs, l.runSpans = l.textRuns(styles)
+}
+
+// measure returns the size of the text.
+func (l *attributedLayout) measure() math.Size {
+	return l.font.Measure(&TextBlock{Runes: l.runes, Runs: l.runs})
+}
+
+// layout lays out the text aligned in rect.
+func (l *attributedLayout) layout(rect math.Rect, horizontalAlignment HAlign, verticalAlignment VAlign) {
+	l.offsets = l.font.Layout(&TextBlock{
+		Runes:     l.runes,
+		Runs:      l.runs,
+		AlignRect: rect,
+		H:         horizontalAlignment,
+		V:         verticalAlignment,
+	})
+}
+
+// paint draws the backgrounds of the spans, then their text and lines.
+func (l *attributedLayout) paint(canvas Canvas) {
+	start := 0
+	for i, run := range l.runs {
+		if span := l.spans[l.runSpans[i]]; span.Background.A > 0 {
+			brush := CreateBrush(span.Background)
+			ascent, height := fontAscent(run.Font), run.Font.GlyphMaxSize().Height
+			for _, line := range l.lines(run.Font, start, start+run.Runes) {
+				top := line.baseline - ascent
+				canvas.DrawRect(math.CreateRect(line.left, top, line.right, top+height), brush)
+			}
+		}
+		start += run.Runes
+	}
+
+	start = 0
+	for i, run := range l.runs {
+		end := start + run.Runes
+		span, color := l.spans[l.runSpans[i]], l.colors[l.runSpans[i]]
+		canvas.DrawRunes(run.Font, l.runes[start:end], l.offsets[start:end], color)
+		if span.Underline || span.Strikethrough {
+			size := run.Font.Size()
+			thickness := max(1, size/14)
+			brush := CreateBrush(color)
+			for _, line := range l.lines(run.Font, start, end) {
+				if span.Underline {
+					y := line.baseline + max(1, size/10)
+					canvas.DrawRect(math.CreateRect(line.left, y, line.right, y+thickness), brush)
+				}
+				if span.Strikethrough {
+					y := line.baseline - size*3/10
+					canvas.DrawRect(math.CreateRect(line.left, y, line.right, y+thickness), brush)
+				}
+			}
+		}
+		start = end
+	}
+}
+
+// spanLine is the extent of the part of a span on a line.
+type spanLine struct {
+	left, right, baseline int
+}
+
+// lines returns the extents of the runes from start to end, drawn by font, on
+// each of their lines.
+func (l *attributedLayout) lines(font Font, start, end int) []spanLine {
+	var result []spanLine
+	var current *spanLine
+	for i := start; i < end; i++ {
+		r := l.runes[i]
+		if r == '\n' {
+			current = nil
+			continue
+		}
+		offset := l.offsets[i]
+		width := font.Measure(&TextBlock{Runes: []rune{r}}).Width
+		if current == nil {
+			result = append(result, spanLine{left: offset.X, right: offset.X + width, baseline: offset.Y})
+			current = &result[len(result)-1]
+			continue
+		}
+		current.left = min(current.left, offset.X)
+		current.right = max(current.right, offset.X+width)
+	}
+	return result
+}
+
+// fontAscent returns the distance from the top of the lines laid out by the
+// font to their baseline.
+func fontAscent(font Font) int {
+	return font.Layout(&TextBlock{Runes: []rune{' '}, H: AlignLeft, V: AlignTop})[0].Y
+}
@@ -13,9 +13,12 @@ type BackgroundBorderParent interface {
 }
 
 type BackgroundBorderPainter struct {
-	parent BackgroundBorderParent
-	brush  Brush
-	pen    Pen
+	parent       BackgroundBorderParent
+	brush        Brush
+	pen          Pen
+	shadow       Shadow
+	shadowCast   Shadow // The shadow painted last
+	backdropBlur float32
 }
 
 func (b *BackgroundBorderPainter) Init(parent BackgroundBorderParent) {
@@ -24,8 +27,36 @@ func (b *BackgroundBorderPainter) Init(parent BackgroundBorderParent) {
 	b.pen = DefaultPen
 }
 
+// PaintShadow paints the shadow of the background. The shadow is cast outside
+// of the bounds of the control, so it is painted by the parent of the control.
+func (b *BackgroundBorderPainter) PaintShadow(canvas Canvas, rect math.Rect) {
+	b.shadowCast = b.shadow
+	if !b.shadow.IsVisible() {
+		return
+	}
+
+	w := b.pen.Width
+	canvas.DrawShadow(rect, w, w, w, w, b.shadow)
+}
+
+// ShadowBounds returns rect grown over the shadow painted last and the shadow
+// set, so that a change of shadow redraws wherever either falls.
+func (b *BackgroundBorderPainter) ShadowBounds(rect math.Rect) math.Rect {
+	bounds := rect
+	for _, shadow := range [...]Shadow{b.shadowCast, b.shadow} {
+		if shadow.IsVisible() {
+			bounds = bounds.Union(shadow.Bounds(rect))
+		}
+	}
+	return bounds
+}
+
 func (b *BackgroundBorderPainter) PaintBackground(canvas Canvas, rect math.Rect) {
-	if b.brush.Color.A == 0 {
+	if b.backdropBlur > 0 {
+		canvas.BlurBackdrop(rect, b.backdropBlur)
+	}
+
+	if b.brush.IsTransparent() {
 		return
 	}
 
@@ -65,3 +96,31 @@ func (b *BackgroundBorderPainter) SetBorderPen(pen Pen) {
 	b.pen = pen
 	b.parent.Redraw()
 }
+
+func (b *BackgroundBorderPainter) Shadow() Shadow {
+	return b.shadow
+}
+
+func (b *BackgroundBorderPainter) SetShadow(shadow Shadow) {
+	if b.shadow == shadow {
+		return
+	}
+
+	b.shadow = shadow
+	b.parent.Redraw()
+}
+
+// BackdropBlur returns the radius of the blur of what is under the background,
+// or 0 if it is not blurred.
+func (b *BackgroundBorderPainter) BackdropBlur() float32 {
+	return b.backdropBlur
+}
+
+func (b *BackgroundBorderPainter) SetBackdropBlur(radius float32) {
+	if b.backdropBlur == radius {
+		return
+	}
+
+	b.backdropBlur = radius
+	b.parent.Redraw()
+}
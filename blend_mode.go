@@ -0,0 +1,18 @@
+package gxui
+
+// BlendMode is how a layer is composited onto what is drawn under it. The modes
+// other than BlendNormal assume that what is under the layer is opaque.
+type BlendMode int
+
+const (
+	// BlendNormal draws the layer over what is under it.
+	BlendNormal BlendMode = iota
+	// BlendMultiply multiplies the colors under the layer by the colors of the
+	// layer, darkening them.
+	BlendMultiply
+	// BlendScreen multiplies the inverted colors under the layer by the
+	// inverted colors of the layer, lightening them.
+	BlendScreen
+	// BlendAdd adds the colors of the layer to the colors under it.
+	BlendAdd
+)
@@ -11,8 +11,25 @@ var DefaultBrush = WhiteBrush
 
 type Brush struct {
 	Color Color
+	// Gradient, if not nil, is used instead of Color. Color is then a single
+	// color approximating the gradient, for painters that cannot draw it.
+	Gradient *Gradient
+	// Pattern, if not nil, is used instead of Color and Gradient. Color is
+	// then the average color of the texture.
+	Pattern *Pattern
 }
 
 func CreateBrush(color Color) Brush {
 	return Brush{Color: Color{A: color.A, R: color.R, G: color.G, B: color.B}}
 }
+
+// IsTransparent returns true if the brush paints nothing.
+func (b Brush) IsTransparent() bool {
+	if b.Pattern != nil {
+		return b.Pattern.Texture == nil
+	}
+	if b.Gradient != nil {
+		return b.Gradient.IsTransparent()
+	}
+	return b.Color.A <= 0
+}
@@ -10,12 +10,14 @@ import (
 
 type BubbleOverlay struct {
 	ContainerBase
-	parent      BaseContainerParent
-	targetPoint math.Point
-	arrowLength int
-	arrowWidth  int
-	pen         Pen
-	brush       Brush
+	parent       BaseContainerParent
+	targetPoint  math.Point
+	arrowLength  int
+	arrowWidth   int
+	pen          Pen
+	brush        Brush
+	shadow       Shadow
+	backdropBlur float32
 }
 
 func (o *BubbleOverlay) Init(parent BaseContainerParent, driver Driver) {
@@ -76,6 +78,34 @@ func (o *BubbleOverlay) SetPen(pen Pen) {
 	o.Redraw()
 }
 
+func (o *BubbleOverlay) Shadow() Shadow {
+	return o.shadow
+}
+
+func (o *BubbleOverlay) SetShadow(shadow Shadow) {
+	if o.shadow == shadow {
+		return
+	}
+
+	o.shadow = shadow
+	o.Redraw()
+}
+
+// BackdropBlur returns the radius of the blur of what is under the bubble, or 0
+// if it is not blurred.
+func (o *BubbleOverlay) BackdropBlur() float32 {
+	return o.backdropBlur
+}
+
+func (o *BubbleOverlay) SetBackdropBlur(radius float32) {
+	if o.backdropBlur == radius {
+		return
+	}
+
+	o.backdropBlur = radius
+	o.Redraw()
+}
+
 func (o *BubbleOverlay) Paint(canvas Canvas) {
 	if !o.IsVisible() {
 		return
@@ -159,6 +189,13 @@ func (o *BubbleOverlay) Paint(canvas Canvas) {
 				/*G*/ {Position: expandedBounds.BottomLeft(), RoundedRadius: 5},
 			}
 		}
+		// The arrow casts no shadow, and is not blurred under.
+		if o.shadow.IsVisible() {
+			canvas.DrawShadow(expandedBounds, 5, 5, 5, 5, o.shadow)
+		}
+		if o.backdropBlur > 0 {
+			canvas.BlurBackdrop(expandedBounds, o.backdropBlur)
+		}
 		canvas.DrawPolygon(polygon, o.pen, o.brush)
 	}
 
@@ -141,7 +141,7 @@ func (e *CodeEditor) ShowSuggestionList() {
 	child := e.AddChild(e.suggestionList)
 
 	// Position the suggestion list below the last caret
-	lineIdx := e.controller.LineIndex(caret)
+	lineIdx := e.controller.VisualLineIndex(caret)
 	// TODO: What if the last caret is not visible?
 	bounds := e.Size().Rect().Contract(e.Padding())
 	line := e.Line(lineIdx)
@@ -261,7 +261,11 @@ func (e *CodeEditor) KeyStroke(event KeyStrokeEvent) bool {
 func (e *CodeEditor) CreateLine(driver Driver, styles *StyleDefs, index int) (TextBoxLine, Control) {
 	lineNumber := CreateLabel(driver, styles)
 
-	lineNumber.SetText(fmt.Sprintf("%d", index+1)) // Displayed lines start at 1
+	lineNumber.SetText(fmt.Sprintf("%d", e.controller.VisualLineLine(index)+1)) // Displayed lines start at 1
+	if index > 0 && e.controller.VisualLineLine(index-1) == e.controller.VisualLineLine(index) {
+		// Wrapped lines keep the width of their number, without drawing it.
+		lineNumber.SetColor(Transparent)
+	}
 
 	line := &CodeEditorLine{}
 	line.Init(line, e, index)
@@ -351,8 +355,8 @@ func (e *CodeEditor) MaxLineWidth() int {
 		maxWidth.Chars = width
 	}
 
-	line, _ := e.CreateLine(e.driver, e.styles, maxWidth.Tabs)
 	lineEnd := e.controller.LineEnd(maxWidth.Tabs)
+	line, _ := e.CreateLine(e.driver, e.styles, e.controller.VisualLineIndex(lineEnd))
 	lastPos := line.PositionAt(lineEnd)
 
 	return e.lineWidthOffset() + lastPos.X
@@ -101,9 +101,9 @@ func (l *CodeEditorLine) Paint(canvas Canvas) {
 	font := l.editor.font
 	rect := l.Size().Rect().OffsetX(l.caretWidth)
 	controller := l.editor.controller
-	runes := controller.LineRunes(l.lineIndex)
-	start := controller.LineStart(l.lineIndex)
-	end := controller.LineEnd(l.lineIndex)
+	runes := controller.VisualLineRunes(l.lineIndex)
+	start := controller.VisualLineStart(l.lineIndex)
+	end := controller.VisualLineEnd(l.lineIndex)
 
 	if start != end {
 		lineSpan := interval.CreateIntData(start, end, nil)
@@ -16,6 +16,25 @@ type Parent interface {
 	Redraw()
 }
 
+// TransformingParent is a Parent drawing some of its children with a transform,
+// such as a zoomed view. Hit-testing and the coordinate functions map points
+// through the transforms of the children.
+type TransformingParent interface {
+	Parent
+	// ChildTransform returns the transform the child is drawn with, around its
+	// offset: the point p of the child is drawn at child.Offset + m(p). ok is
+	// false if the child is drawn without a transform.
+	ChildTransform(child *Child) (m math.Mat3, ok bool)
+}
+
+// DamageTrackingParent is a Parent which can redraw only the part of its area
+// where a child changed.
+type DamageTrackingParent interface {
+	Parent
+	// RedrawRect redraws the parent, which only changed within rect.
+	RedrawRect(rect math.Rect)
+}
+
 type PainterAndLayouter interface {
 	PaintChild(canvas Canvas, child *Child, idx int)
 	Paint(canvas Canvas)
@@ -164,7 +183,7 @@ func (c *ContainerPart) ContainsPoint(point math.Point) bool {
 	}
 
 	for _, v := range c.children {
-		if v.Control.ContainsPoint(point.Sub(v.Offset)) {
+		if cp, ok := ParentToChildPoint(c.parent, v, point); ok && v.Control.ContainsPoint(cp) {
 			return true
 		}
 	}
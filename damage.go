@@ -0,0 +1,46 @@
+package gxui
+
+import "github.com/badu/gxui/pkg/math"
+
+// The most rectangles a window redraws separately. Redrawing many small
+// rectangles costs more than redrawing a few larger ones covering them.
+const maxDamageRects = 8
+
+// addDamage returns the rectangles of damage along with rect. Overlapping
+// rectangles are merged into the rectangle covering them, as are the two
+// rectangles wasting the least area in their union when there are more than
+// maxDamageRects.
+func addDamage(damage []math.Rect, rect math.Rect) []math.Rect {
+	for i := 0; i < len(damage); i++ {
+		if overlaps(damage[i], rect) {
+			rect = rect.Union(damage[i])
+			damage = append(damage[:i], damage[i+1:]...)
+			// The union may overlap the rectangles already checked.
+			i = -1
+		}
+	}
+	damage = append(damage, rect)
+	if len(damage) <= maxDamageRects {
+		return damage
+	}
+
+	bestI, bestJ, bestWaste := 0, 1, -1
+	for i := range damage {
+		for j := i + 1; j < len(damage); j++ {
+			union := damage[i].Union(damage[j])
+			waste := union.Size().Area() - damage[i].Size().Area() - damage[j].Size().Area()
+			if bestWaste < 0 || waste < bestWaste {
+				bestI, bestJ, bestWaste = i, j, waste
+			}
+		}
+	}
+	union := damage[bestI].Union(damage[bestJ])
+	damage = append(damage[:bestJ], damage[bestJ+1:]...)
+	damage = append(damage[:bestI], damage[bestI+1:]...)
+	return addDamage(damage, union)
+}
+
+// overlaps returns true if a and b have an area in common.
+func overlaps(a, b math.Rect) bool {
+	return a.Overlap(b).Size().Area() > 0
+}
@@ -0,0 +1,97 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+func TestAddDamage(t *testing.T) {
+	var damage []math.Rect
+	damage = addDamage(damage, math.CreateRect(0, 0, 10, 10))
+	damage = addDamage(damage, math.CreateRect(20, 0, 30, 10))
+	test_helper.AssertEquals(t, []math.Rect{math.CreateRect(0, 0, 10, 10), math.CreateRect(20, 0, 30, 10)}, damage)
+
+	// The union of overlapping rectangles can overlap the others.
+	damage = addDamage(damage, math.CreateRect(5, 5, 15, 15))
+	damage = addDamage(damage, math.CreateRect(12, 8, 22, 12))
+	test_helper.AssertEquals(t, []math.Rect{math.CreateRect(0, 0, 30, 15)}, damage)
+}
+
+func TestAddDamageLimit(t *testing.T) {
+	var damage []math.Rect
+	for i := 0; i < maxDamageRects; i++ {
+		damage = addDamage(damage, math.CreateRect(i*10, 0, i*10+5, 5))
+	}
+	test_helper.AssertEquals(t, maxDamageRects, len(damage))
+
+	// The rectangles closest to each other are merged.
+	damage = addDamage(damage, math.CreateRect(0, 6, 5, 10))
+	test_helper.AssertEquals(t, maxDamageRects, len(damage))
+	test_helper.AssertEquals(t, math.CreateRect(0, 0, 5, 10), damage[len(damage)-1])
+}
+
+// damagedParent records the rectangles redrawn by its children.
+type damagedParent struct {
+	zoomParent
+	damage  []math.Rect
+	redrawn bool
+}
+
+func (p *damagedParent) Redraw()                   { p.redrawn = true }
+func (p *damagedParent) RedrawRect(rect math.Rect) { p.damage = append(p.damage, rect) }
+
+// paintedControl is a control redrawn by a DrawPaintPart.
+type paintedControl struct {
+	Control
+	draw   DrawPaintPart
+	parent Parent
+	size   math.Size
+}
+
+func (c *paintedControl) Attached() bool      { return true }
+func (c *paintedControl) Parent() Parent      { return c.parent }
+func (c *paintedControl) Size() math.Size     { return c.size }
+func (c *paintedControl) Paint(canvas Canvas) {}
+
+func createPaintedControl(parent *damagedParent, offset math.Point) *paintedControl {
+	control := &paintedControl{parent: parent, size: math.Size{Width: 10, Height: 10}}
+	control.draw.Init(control, nil)
+	parent.children = append(parent.children, &Child{Control: control, Offset: offset})
+	return control
+}
+
+func TestRedrawRect(t *testing.T) {
+	parent := &damagedParent{zoomParent: zoomParent{zoom: 1}}
+	control := createPaintedControl(parent, math.Point{X: 20, Y: 30})
+
+	control.draw.RedrawRect(math.CreateRect(2, 3, 4, 5))
+	test_helper.AssertEquals(t, []math.Rect{math.CreateRect(22, 33, 24, 35)}, parent.damage)
+
+	// The whole control is damaged once, and covers the later rectangles.
+	control.draw.Redraw()
+	control.draw.Redraw()
+	control.draw.RedrawRect(math.CreateRect(2, 3, 4, 5))
+	test_helper.AssertEquals(t, []math.Rect{math.CreateRect(22, 33, 24, 35), math.CreateRect(20, 30, 30, 40)}, parent.damage)
+	test_helper.AssertEquals(t, false, parent.redrawn)
+}
+
+func TestRedrawRectTransformed(t *testing.T) {
+	parent := &damagedParent{zoomParent: zoomParent{zoom: 2}}
+	control := createPaintedControl(parent, math.Point{X: 20, Y: 30})
+
+	control.draw.RedrawRect(math.CreateRect(2, 3, 4, 5))
+	test_helper.AssertEquals(t, []math.Rect{math.CreateRect(24, 36, 28, 40)}, parent.damage)
+}
+
+func TestRedrawRectUnknownChild(t *testing.T) {
+	parent := &damagedParent{zoomParent: zoomParent{zoom: 1}}
+	control := &paintedControl{parent: parent, size: math.Size{Width: 10, Height: 10}}
+	control.draw.Init(control, nil)
+
+	// The whole parent is redrawn when it does not know where the control is.
+	control.draw.Redraw()
+	test_helper.AssertEquals(t, 0, len(parent.damage))
+	test_helper.AssertEquals(t, true, parent.redrawn)
+}
@@ -62,7 +62,8 @@ func BreadcrumbsAt(p Container, pnt math.Point) string {
 	return s
 }
 
-func Path(p interface{}) string {
+// ControlPath returns the types of p and of its ancestors, from the root down.
+func ControlPath(p interface{}) string {
 	if p == nil {
 		return "nil"
 	}
@@ -71,7 +72,7 @@ func Path(p interface{}) string {
 
 	if c, _ := p.(Control); c != nil {
 		if c.Parent() != nil {
-			return Path(c.Parent()) + " > " + s
+			return ControlPath(c.Parent()) + " > " + s
 		}
 	}
 
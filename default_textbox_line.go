@@ -5,18 +5,32 @@
 package gxui
 
 import (
+	"slices"
+
+	"github.com/badu/gxui/pkg/bidi"
 	"github.com/badu/gxui/pkg/interval"
 	"github.com/badu/gxui/pkg/math"
 )
 
+// lineCarets is the bidirectional layout of a visual line, and the X of each
+// of its visual caret positions, for the runes and font they were laid out
+// with.
+type lineCarets struct {
+	runes     []rune
+	font      Font
+	line      *bidi.Line
+	positions []int
+}
+
 // DefaultTextBoxLine
 type DefaultTextBoxLine struct {
 	ControlBase
 	parent     DefaultTextBoxLineParent
 	textbox    *TextBox
-	lineIndex  int
+	lineIndex  int // The visual line of the controller displayed.
 	caretWidth int
 	offset     int
+	lineCarets *lineCarets // The carets of the line, until its text changes.
 }
 
 func (t *DefaultTextBoxLine) Init(parent DefaultTextBoxLineParent, textbox *TextBox, lineIndex int) {
@@ -29,6 +43,8 @@ func (t *DefaultTextBoxLine) Init(parent DefaultTextBoxLineParent, textbox *Text
 		func() {
 			ev := t.textbox.OnRedrawLines(t.Redraw)
 			t.OnDetach(ev.Forget)
+			edited := t.textbox.OnTextChanged(func([]TextBoxEdit) { t.lineCarets = nil })
+			t.OnDetach(edited.Forget)
 		},
 	)
 }
@@ -45,6 +61,12 @@ func (t *DefaultTextBoxLine) DesiredSize(min, max math.Size) math.Size {
 	return max
 }
 
+// textWidth returns the width of the line the text is drawn in, leaving room
+// for the carets at its ends.
+func (t *DefaultTextBoxLine) textWidth() int {
+	return t.Size().Width - 2*t.caretWidth
+}
+
 func (t *DefaultTextBoxLine) Paint(canvas Canvas) {
 	if t.textbox.HasFocus() {
 		t.parent.PaintSelections(canvas)
@@ -64,8 +86,34 @@ func (t *DefaultTextBoxLine) MeasureRunes(start, end int) math.Size {
 	)
 }
 
+// carets returns the bidirectional layout of the line, and the X of each
+// visual caret position of the line from the start of its text. The line is
+// laid out again only once its runes or the font change, as the positions do
+// not depend on the width of the line.
+func (t *DefaultTextBoxLine) carets() (*bidi.Line, []int) {
+	runes := t.textbox.controller.VisualLineRunes(t.lineIndex)
+	textFont := t.textbox.font
+	if c := t.lineCarets; c != nil && c.font == textFont && slices.Equal(c.runes, runes) {
+		return c.line, c.positions
+	}
+
+	line := bidi.NewLine(runes, bidi.Auto)
+	offsets := textFont.Layout(&TextBlock{Runes: runes})
+	positions := make([]int, len(runes)+1)
+	for v := range runes {
+		positions[v] = offsets[line.Logical(v)].X
+		if v > 0 {
+			// Marks may be placed before their base glyph.
+			positions[v] = max(positions[v], positions[v-1])
+		}
+	}
+	positions[len(runes)] = textFont.Measure(&TextBlock{Runes: runes}).Width
+	t.lineCarets = &lineCarets{runes: slices.Clone(runes), font: textFont, line: line, positions: positions}
+	return line, positions
+}
+
 func (t *DefaultTextBoxLine) PaintText(canvas Canvas) {
-	runes := []rune(t.textbox.controller.Line(t.lineIndex))
+	runes := t.textbox.controller.VisualLineRunes(t.lineIndex)
 	textFont := t.textbox.font
 	offsets := textFont.Layout(
 		&TextBlock{
@@ -80,17 +128,18 @@ func (t *DefaultTextBoxLine) PaintText(canvas Canvas) {
 
 func (t *DefaultTextBoxLine) PaintCarets(canvas Canvas) {
 	controller := t.textbox.controller
+	line, positions := t.carets()
 	for caret, count := 0, controller.SelectionCount(); caret < count; caret++ {
 		caretEnd := controller.Caret(caret)
-		lineIndex := controller.LineIndex(caretEnd)
+		lineIndex := controller.VisualLineIndex(caretEnd)
 
 		if lineIndex != t.lineIndex {
 			continue
 		}
 
-		start := controller.LineStart(lineIndex)
-		measuredRunes := t.parent.MeasureRunes(start, caretEnd)
-		top := math.Point{X: t.caretWidth + measuredRunes.Width, Y: 0}
+		start := controller.VisualLineStart(lineIndex)
+		x := positions[line.VisualCaret(caretEnd-start)]
+		top := math.Point{X: t.caretWidth + x, Y: 0}
 		bottom := top.Add(math.Point{X: 0, Y: t.Size().Height})
 		t.parent.PaintCaret(canvas, top, bottom)
 	}
@@ -99,23 +148,38 @@ func (t *DefaultTextBoxLine) PaintCarets(canvas Canvas) {
 func (t *DefaultTextBoxLine) PaintSelections(canvas Canvas) {
 	controller := t.textbox.controller
 
-	lineStart, lineEnd := controller.LineStart(t.lineIndex), controller.LineEnd(t.lineIndex)
+	lineStart, lineEnd := controller.VisualLineStart(t.lineIndex), controller.VisualLineEnd(t.lineIndex)
 
 	selections := controller.Selections()
 	if t.textbox.selectionDragging {
 		interval.Replace(&selections, t.textbox.selectionDrag)
 	}
 
+	// The runes of a selection may be displayed apart in bidirectional text,
+	// so each span of runes displayed together is painted.
+	line, positions := t.carets()
+	height := t.textbox.font.GlyphMaxSize().Height
 	interval.Visit(
 		&selections,
 		CreateTextSelection(lineStart, lineEnd, false),
 		func(s, e uint64, _ int) {
-			if s < e {
-				x := t.parent.MeasureRunes(lineStart, int(s)).Width
-				m := t.parent.MeasureRunes(int(s), int(e))
-				top := math.Point{X: t.caretWidth + x, Y: 0}
-				bottom := top.Add(m.Point())
+			selected := func(v int) bool {
+				i := lineStart + line.Logical(v)
+				return i >= int(s) && i < int(e)
+			}
+			for v := 0; v < line.Len(); {
+				if !selected(v) {
+					v++
+					continue
+				}
+				end := v
+				for end < line.Len() && selected(end) {
+					end++
+				}
+				top := math.Point{X: t.caretWidth + positions[v], Y: 0}
+				bottom := math.Point{X: t.caretWidth + positions[end], Y: height}
 				t.parent.PaintSelection(canvas, top, bottom)
+				v = end
 			}
 		},
 	)
@@ -133,27 +197,24 @@ func (t *DefaultTextBoxLine) PaintSelection(canvas Canvas, top, bottom math.Poin
 
 // TextBoxLine compliance
 func (t *DefaultTextBoxLine) RuneIndexAt(point math.Point) int {
-	font := t.textbox.font
 	controller := t.textbox.controller
+	line, positions := t.carets()
 
-	x := point.X
-	line := controller.Line(t.lineIndex)
-	i := 0
-
-	// TODO : @Badu - why?
-	for ; i < len(line) && x > font.Measure(&TextBlock{Runes: []rune(line[:i+1])}).Width; i++ {
+	// The caret displayed closest to the point, as the positions only grow.
+	closest := 0
+	for closest < len(positions)-1 && 2*point.X > positions[closest]+positions[closest+1] {
+		closest++
 	}
-
-	return controller.LineStart(t.lineIndex) + i
+	index := controller.VisualLineStart(t.lineIndex) + line.LogicalCaret(closest)
+	return min(index, controller.visualLineLastCaret(t.lineIndex))
 }
 
 func (t *DefaultTextBoxLine) PositionAt(runeIndex int) math.Point {
-	font := t.textbox.font
 	controller := t.textbox.controller
+	line, positions := t.carets()
 
-	x := runeIndex - controller.LineStart(t.lineIndex)
-	line := controller.Line(t.lineIndex)
-	return font.Measure(&TextBlock{Runes: []rune(line[:x])}).Point()
+	x := positions[line.VisualCaret(runeIndex-controller.VisualLineStart(t.lineIndex))]
+	return math.Point{X: x, Y: t.textbox.font.GlyphMaxSize().Height}
 }
 
 func (t *DefaultTextBoxLine) SetOffset(offset int) {
@@ -0,0 +1,43 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+// monospaceFont lays out runes 10 DIPs apart, counting the layouts.
+type monospaceFont struct {
+	testFont
+	layouts int
+}
+
+func (f *monospaceFont) Measure(block *TextBlock) math.Size {
+	return math.Size{Width: 10 * len(block.Runes), Height: 10}
+}
+
+func (f *monospaceFont) Layout(block *TextBlock) []math.Point {
+	f.layouts++
+	offsets := make([]math.Point, len(block.Runes))
+	for i := range offsets {
+		offsets[i] = math.Point{X: 10 * i}
+	}
+	return offsets
+}
+
+func TestDefaultTextBoxLineCaretsCached(t *testing.T) {
+	font := &monospaceFont{testFont: testFont{size: 10}}
+	textbox := &TextBox{controller: CreateTextBoxController(), font: font}
+	textbox.controller.SetText("abc")
+	line := &DefaultTextBoxLine{textbox: textbox}
+
+	test_helper.AssertEquals(t, math.Point{X: 20, Y: 10}, line.PositionAt(2))
+	test_helper.AssertEquals(t, 1, line.RuneIndexAt(math.Point{X: 12}))
+	test_helper.AssertEquals(t, 1, font.layouts)
+
+	// The line is laid out again once its text changes.
+	textbox.controller.SetText("abcde")
+	test_helper.AssertEquals(t, math.Point{X: 50, Y: 10}, line.PositionAt(5))
+	test_helper.AssertEquals(t, 2, font.layouts)
+}
@@ -0,0 +1,324 @@
+package gxui
+
+import (
+	"fmt"
+
+	"github.com/badu/gxui/pkg/math"
+)
+
+// DisplayListOp identifies the Canvas method recorded by a DisplayListCommand.
+type DisplayListOp uint8
+
+const (
+	OpPush DisplayListOp = iota + 1
+	OpPop
+	OpAddClip
+	OpClear
+	OpDrawCanvas
+	OpDrawTexture
+	OpDrawRunes
+	OpDrawLines
+	OpDrawPolygon
+	OpDrawRect
+	OpDrawRoundedRect
+	OpFillPath
+	OpStrokePath
+	OpTransform
+	OpPushLayer
+	OpPopLayer
+	OpDrawShadow
+	OpBlurBackdrop
+)
+
+var displayListOpNames = map[DisplayListOp]string{
+	OpPush:            "Push",
+	OpPop:             "Pop",
+	OpAddClip:         "AddClip",
+	OpClear:           "Clear",
+	OpDrawCanvas:      "DrawCanvas",
+	OpDrawTexture:     "DrawTexture",
+	OpDrawRunes:       "DrawRunes",
+	OpDrawLines:       "DrawLines",
+	OpDrawPolygon:     "DrawPolygon",
+	OpDrawRect:        "DrawRect",
+	OpDrawRoundedRect: "DrawRoundedRect",
+	OpFillPath:        "FillPath",
+	OpStrokePath:      "StrokePath",
+	OpTransform:       "Transform",
+	OpPushLayer:       "PushLayer",
+	OpPopLayer:        "PopLayer",
+	OpDrawShadow:      "DrawShadow",
+	OpBlurBackdrop:    "BlurBackdrop",
+}
+
+func (o DisplayListOp) String() string {
+	if name, found := displayListOpNames[o]; found {
+		return name
+	}
+	return fmt.Sprintf("DisplayListOp(%d)", o)
+}
+
+// DisplayListCommand is a single recorded Canvas call.
+// Only the fields used by Op are set.
+type DisplayListCommand struct {
+	Op      DisplayListOp
+	Rect    math.Rect    // AddClip, DrawTexture, DrawRect, DrawRoundedRect, DrawShadow, BlurBackdrop
+	Point   math.Point   // DrawCanvas
+	Color   Color        // Clear, DrawRunes
+	Pen     Pen          // DrawLines, DrawPolygon, DrawRoundedRect, StrokePath
+	Brush   Brush        // DrawPolygon, DrawRect, DrawRoundedRect, FillPath
+	Polygon Polygon      // DrawLines, DrawPolygon
+	Radii   [4]float32   // DrawRoundedRect, DrawShadow: top-left, top-right, bottom-left, bottom-right
+	Font    Font         // DrawRunes
+	Runes   []rune       // DrawRunes
+	Points  []math.Point // DrawRunes
+	Canvas  *DisplayList // DrawCanvas
+	Texture Texture      // DrawTexture
+	Path    *Path        // FillPath, StrokePath
+	Rule    FillRule     // FillPath
+	Matrix  math.Mat3    // Transform
+	Opacity float32      // PushLayer
+	Blend   BlendMode    // PushLayer
+	Shadow  Shadow       // DrawShadow
+	Blur    float32      // BlurBackdrop
+}
+
+// DisplayList is a driver independent Canvas that records every call made to
+// it, so that it can be inspected, encoded, and replayed onto another Canvas.
+type DisplayList struct {
+	commands  []DisplayListCommand
+	size      math.Size
+	pushCount int
+	layers    layerStack
+	complete  bool
+}
+
+// layerStack holds the Push counts at which the layers of a canvas were pushed,
+// to check that each PushLayer is matched by a PopLayer rather than a Pop.
+type layerStack []int
+
+// push records a layer pushed by the pushCount-th Push.
+func (s *layerStack) push(pushCount int) {
+	*s = append(*s, pushCount)
+}
+
+// pop returns an error if the pushCount-th Push did not push the last layer.
+func (s *layerStack) pop(pushCount int) error {
+	if n := len(*s); n == 0 || (*s)[n-1] != pushCount {
+		return fmt.Errorf("PopLayer() without PushLayer()")
+	}
+	*s = (*s)[:len(*s)-1]
+	return nil
+}
+
+// check returns an error if the pushCount-th Push pushed a layer, which Pop
+// would leave uncomposited.
+func (s layerStack) check(pushCount int) error {
+	if n := len(s); n > 0 && s[n-1] == pushCount {
+		return fmt.Errorf("Pop() ends a layer, use PopLayer()")
+	}
+	return nil
+}
+
+func CreateDisplayList(size math.Size) *DisplayList {
+	if size.Width <= 0 || size.Height < 0 {
+		panic(fmt.Errorf("display list width and height must be positive. Size: %d", size))
+	}
+	return &DisplayList{size: size}
+}
+
+// Commands returns the commands recorded so far.
+// The returned slice must not be modified.
+func (l *DisplayList) Commands() []DisplayListCommand {
+	return l.commands
+}
+
+func (l *DisplayList) record(command DisplayListCommand) {
+	if l.complete {
+		panic(fmt.Errorf("%s() called after Complete()", command.Op))
+	}
+	l.commands = append(l.commands, command)
+}
+
+// Replay issues the recorded commands on canvas, in order. Nested display lists
+// are replayed onto canvases created with driver, each one created only once
+// no matter how many times it is drawn. Replay does not complete canvas.
+func (l *DisplayList) Replay(driver Driver, canvas Canvas) {
+	l.replay(driver, canvas, make(map[*DisplayList]Canvas))
+}
+
+func (l *DisplayList) replay(driver Driver, canvas Canvas, children map[*DisplayList]Canvas) {
+	for _, c := range l.commands {
+		switch c.Op {
+		case OpPush:
+			canvas.Push()
+		case OpPop:
+			canvas.Pop()
+		case OpAddClip:
+			canvas.AddClip(c.Rect)
+		case OpClear:
+			canvas.Clear(c.Color)
+		case OpDrawCanvas:
+			child, found := children[c.Canvas]
+			if !found {
+				child = driver.CreateCanvas(c.Canvas.Size())
+				c.Canvas.replay(driver, child, children)
+				child.Complete()
+				children[c.Canvas] = child
+			}
+			canvas.DrawCanvas(child, c.Point)
+		case OpDrawTexture:
+			canvas.DrawTexture(c.Texture, c.Rect)
+		case OpDrawRunes:
+			canvas.DrawRunes(c.Font, c.Runes, c.Points, c.Color)
+		case OpDrawLines:
+			canvas.DrawLines(c.Polygon, c.Pen)
+		case OpDrawPolygon:
+			canvas.DrawPolygon(c.Polygon, c.Pen, c.Brush)
+		case OpDrawRect:
+			canvas.DrawRect(c.Rect, c.Brush)
+		case OpDrawRoundedRect:
+			canvas.DrawRoundedRect(c.Rect, c.Radii[0], c.Radii[1], c.Radii[2], c.Radii[3], c.Pen, c.Brush)
+		case OpFillPath:
+			canvas.FillPath(c.Path, c.Rule, c.Brush)
+		case OpStrokePath:
+			canvas.StrokePath(c.Path, c.Pen)
+		case OpTransform:
+			canvas.Transform(c.Matrix)
+		case OpPushLayer:
+			canvas.PushLayer(c.Opacity, c.Blend)
+		case OpPopLayer:
+			canvas.PopLayer()
+		case OpDrawShadow:
+			canvas.DrawShadow(c.Rect, c.Radii[0], c.Radii[1], c.Radii[2], c.Radii[3], c.Shadow)
+		case OpBlurBackdrop:
+			canvas.BlurBackdrop(c.Rect, c.Blur)
+		default:
+			panic(fmt.Errorf("unknown display list op %v", c.Op))
+		}
+	}
+}
+
+// Canvas compliance
+func (l *DisplayList) Size() math.Size {
+	return l.size
+}
+
+func (l *DisplayList) IsComplete() bool {
+	return l.complete
+}
+
+func (l *DisplayList) Complete() {
+	if l.complete {
+		panic("Complete() called twice")
+	}
+
+	if l.pushCount != 0 {
+		panic(fmt.Errorf("Push() count was %d when calling Complete", l.pushCount))
+	}
+
+	l.complete = true
+}
+
+func (l *DisplayList) Push() {
+	l.pushCount++
+	l.record(DisplayListCommand{Op: OpPush})
+}
+
+func (l *DisplayList) Pop() {
+	if err := l.layers.check(l.pushCount); err != nil {
+		panic(err)
+	}
+	l.pushCount--
+	l.record(DisplayListCommand{Op: OpPop})
+}
+
+func (l *DisplayList) PushLayer(opacity float32, mode BlendMode) {
+	l.pushCount++
+	l.layers.push(l.pushCount)
+	l.record(DisplayListCommand{Op: OpPushLayer, Opacity: opacity, Blend: mode})
+}
+
+func (l *DisplayList) PopLayer() {
+	if err := l.layers.pop(l.pushCount); err != nil {
+		panic(err)
+	}
+	l.pushCount--
+	l.record(DisplayListCommand{Op: OpPopLayer})
+}
+
+func (l *DisplayList) Transform(m math.Mat3) {
+	l.record(DisplayListCommand{Op: OpTransform, Matrix: m})
+}
+
+func (l *DisplayList) AddClip(rect math.Rect) {
+	l.record(DisplayListCommand{Op: OpAddClip, Rect: rect})
+}
+
+func (l *DisplayList) Clear(color Color) {
+	l.record(DisplayListCommand{Op: OpClear, Color: color})
+}
+
+func (l *DisplayList) DrawCanvas(canvas Canvas, position math.Point) {
+	if canvas == nil {
+		panic("canvas cannot be nil")
+	}
+
+	child, ok := canvas.(*DisplayList)
+	if !ok {
+		panic(fmt.Errorf("a display list can only draw other display lists. Got %T", canvas))
+	}
+	l.record(DisplayListCommand{Op: OpDrawCanvas, Canvas: child, Point: position})
+}
+
+func (l *DisplayList) DrawTexture(texture Texture, bounds math.Rect) {
+	if texture == nil {
+		panic("texture cannot be nil")
+	}
+	l.record(DisplayListCommand{Op: OpDrawTexture, Texture: texture, Rect: bounds})
+}
+
+func (l *DisplayList) DrawRunes(font Font, runes []rune, points []math.Point, color Color) {
+	if font == nil {
+		panic("font cannot be nil")
+	}
+	l.record(DisplayListCommand{
+		Op:     OpDrawRunes,
+		Font:   font,
+		Runes:  append([]rune{}, runes...),
+		Points: append([]math.Point{}, points...),
+		Color:  color,
+	})
+}
+
+func (l *DisplayList) DrawLines(polygon Polygon, pen Pen) {
+	l.record(DisplayListCommand{Op: OpDrawLines, Polygon: append(Polygon{}, polygon...), Pen: pen})
+}
+
+func (l *DisplayList) DrawPolygon(polygon Polygon, pen Pen, brush Brush) {
+	l.record(DisplayListCommand{Op: OpDrawPolygon, Polygon: append(Polygon{}, polygon...), Pen: pen, Brush: brush})
+}
+
+func (l *DisplayList) DrawRect(rect math.Rect, brush Brush) {
+	l.record(DisplayListCommand{Op: OpDrawRect, Rect: rect, Brush: brush})
+}
+
+func (l *DisplayList) DrawRoundedRect(rect math.Rect, tl, tr, bl, br float32, pen Pen, brush Brush) {
+	l.record(DisplayListCommand{Op: OpDrawRoundedRect, Rect: rect, Radii: [4]float32{tl, tr, bl, br}, Pen: pen, Brush: brush})
+}
+
+func (l *DisplayList) DrawShadow(rect math.Rect, tl, tr, bl, br float32, shadow Shadow) {
+	l.record(DisplayListCommand{Op: OpDrawShadow, Rect: rect, Radii: [4]float32{tl, tr, bl, br}, Shadow: shadow})
+}
+
+func (l *DisplayList) BlurBackdrop(rect math.Rect, radius float32) {
+	l.record(DisplayListCommand{Op: OpBlurBackdrop, Rect: rect, Blur: radius})
+}
+
+func (l *DisplayList) FillPath(path *Path, rule FillRule, brush Brush) {
+	l.record(DisplayListCommand{Op: OpFillPath, Path: &Path{Segments: append([]PathSegment{}, path.Segments...)}, Rule: rule, Brush: brush})
+}
+
+func (l *DisplayList) StrokePath(path *Path, pen Pen) {
+	l.record(DisplayListCommand{Op: OpStrokePath, Path: &Path{Segments: append([]PathSegment{}, path.Segments...)}, Pen: pen})
+}
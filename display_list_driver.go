@@ -0,0 +1,64 @@
+package gxui
+
+import (
+	"github.com/badu/gxui/pkg/math"
+)
+
+// RecordingDriver wraps a Driver so that every canvas it creates is a
+// DisplayList. Each frame set on one of its viewports is passed to the frame
+// callback, then replayed onto a canvas of the wrapped driver for display.
+// Replaying recreates every nested canvas each frame, so RecordingDriver is
+// meant for tests and frame captures rather than regular use.
+type RecordingDriver struct {
+	Driver
+	onFrame func(viewport Viewport, frame *DisplayList)
+}
+
+// CreateRecordingDriver returns a RecordingDriver wrapping driver.
+// onFrame is called on the UI go-routine with every frame, and may be nil.
+func CreateRecordingDriver(driver Driver, onFrame func(viewport Viewport, frame *DisplayList)) *RecordingDriver {
+	return &RecordingDriver{Driver: driver, onFrame: onFrame}
+}
+
+func (d *RecordingDriver) CreateCanvas(size math.Size) Canvas {
+	return CreateDisplayList(size)
+}
+
+func (d *RecordingDriver) CreateWindowedViewport(width, height int, name string) Viewport {
+	return &recordingViewport{Viewport: d.Driver.CreateWindowedViewport(width, height, name), driver: d}
+}
+
+func (d *RecordingDriver) CreateFullscreenViewport(width, height int, name string) Viewport {
+	return &recordingViewport{Viewport: d.Driver.CreateFullscreenViewport(width, height, name), driver: d}
+}
+
+type recordingViewport struct {
+	Viewport
+	driver *RecordingDriver
+}
+
+func (v *recordingViewport) SetCanvas(canvas Canvas) {
+	if canvas == nil {
+		v.Viewport.SetCanvas(nil)
+		return
+	}
+	v.Viewport.SetCanvas(v.replay(canvas))
+}
+
+func (v *recordingViewport) SetCanvasDamage(canvas Canvas, damage []math.Rect) {
+	v.Viewport.SetCanvasDamage(v.replay(canvas), damage)
+}
+
+// replay passes the frame to the frame callback, and returns it replayed onto
+// a canvas of the wrapped driver.
+func (v *recordingViewport) replay(canvas Canvas) Canvas {
+	frame := canvas.(*DisplayList)
+	if v.driver.onFrame != nil {
+		v.driver.onFrame(v, frame)
+	}
+
+	inner := v.driver.Driver.CreateCanvas(frame.Size())
+	frame.Replay(v.driver.Driver, inner)
+	inner.Complete()
+	return inner
+}
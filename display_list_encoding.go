@@ -0,0 +1,1325 @@
+package gxui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	m "math"
+
+	"github.com/badu/gxui/pkg/math"
+)
+
+// DisplayListVersion is the version of the binary and JSON display list
+// encodings written by EncodeDisplayList and EncodeDisplayListJSON.
+const DisplayListVersion = 1
+
+// Maximum length of any array or string read by DecodeDisplayList.
+const maxDisplayListLength = 1 << 26
+
+var displayListMagic = []byte("GXDL")
+
+var gradientKindNames = map[GradientKind]string{
+	LinearGradient: "linear",
+	RadialGradient: "radial",
+}
+
+var wrapModeNames = map[WrapMode]string{
+	WrapRepeat: "repeat",
+	WrapClamp:  "clamp",
+	WrapMirror: "mirror",
+}
+
+var lineCapNames = map[LineCap]string{
+	ButtCap:   "butt",
+	RoundCap:  "round",
+	SquareCap: "square",
+}
+
+var lineJoinNames = map[LineJoin]string{
+	MiterJoin: "miter",
+	RoundJoin: "round",
+	BevelJoin: "bevel",
+}
+
+var fillRuleNames = map[FillRule]string{
+	NonZero: "nonzero",
+	EvenOdd: "evenodd",
+}
+
+var blendModeNames = map[BlendMode]string{
+	BlendNormal:   "normal",
+	BlendMultiply: "multiply",
+	BlendScreen:   "screen",
+	BlendAdd:      "add",
+}
+
+// The names of the path ops, and the number of points they have.
+var pathOpNames = map[PathOp]string{
+	PathMoveTo:  "move",
+	PathLineTo:  "line",
+	PathQuadTo:  "quad",
+	PathCubicTo: "cubic",
+	PathClose:   "close",
+}
+
+var pathOpPoints = map[PathOp]int{
+	PathMoveTo:  1,
+	PathLineTo:  1,
+	PathQuadTo:  2,
+	PathCubicTo: 3,
+	PathClose:   0,
+}
+
+// FontResolver returns the font with the given name and size. It is used to
+// find the fonts referenced by a display list when decoding it.
+type FontResolver func(name string, size int) (Font, error)
+
+// The fields of a displayListRecord used by each op.
+const (
+	fieldRect = 1 << iota
+	fieldPoint
+	fieldColor
+	fieldPen
+	fieldBrush
+	fieldPolygon
+	fieldRadii
+	fieldFont
+	fieldRunes
+	fieldPoints
+	fieldCanvas
+	fieldTexture
+	fieldPath
+	fieldRule
+	fieldMatrix
+	fieldLayer
+	fieldShadow
+	fieldBlur
+)
+
+var displayListOpFields = map[DisplayListOp]int{
+	OpPush:            0,
+	OpPop:             0,
+	OpAddClip:         fieldRect,
+	OpClear:           fieldColor,
+	OpDrawCanvas:      fieldCanvas | fieldPoint,
+	OpDrawTexture:     fieldTexture | fieldRect,
+	OpDrawRunes:       fieldFont | fieldRunes | fieldPoints | fieldColor,
+	OpDrawLines:       fieldPolygon | fieldPen,
+	OpDrawPolygon:     fieldPolygon | fieldPen | fieldBrush,
+	OpDrawRect:        fieldRect | fieldBrush,
+	OpDrawRoundedRect: fieldRect | fieldRadii | fieldPen | fieldBrush,
+	OpFillPath:        fieldPath | fieldRule | fieldBrush,
+	OpStrokePath:      fieldPath | fieldPen,
+	OpTransform:       fieldMatrix,
+	OpPushLayer:       fieldLayer,
+	OpPopLayer:        0,
+	OpDrawShadow:      fieldRect | fieldRadii | fieldShadow,
+	OpBlurBackdrop:    fieldRect | fieldBlur,
+}
+
+// displayListOpVersions are the first versions of the encodings with the ops
+// added after the first one. Lists of earlier versions cannot hold them.
+var displayListOpVersions = map[DisplayListOp]int{}
+
+// checkDisplayListOpVersion returns an error if the op is newer than the
+// version of the list holding it.
+func checkDisplayListOpVersion(op DisplayListOp, version int) error {
+	if first, found := displayListOpVersions[op]; found && first > version {
+		return fmt.Errorf("%v is not allowed in display list version %d", op, version)
+	}
+	return nil
+}
+
+func (o DisplayListOp) MarshalText() ([]byte, error) {
+	if _, found := displayListOpNames[o]; !found {
+		return nil, fmt.Errorf("unknown display list op %d", o)
+	}
+	return []byte(o.String()), nil
+}
+
+func (o *DisplayListOp) UnmarshalText(text []byte) error {
+	for op, name := range displayListOpNames {
+		if name == string(text) {
+			*o = op
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown display list op %q", text)
+}
+
+// displayListFile is the representation shared by the binary and JSON
+// encodings. Fonts, textures and nested canvases are stored once and referenced
+// by index. The first canvas is the root display list.
+type displayListFile struct {
+	Version   int                   `json:"version"`
+	Fonts     []displayListFont     `json:"fonts,omitempty"`
+	Textures  []displayListTexture  `json:"textures,omitempty"`
+	Gradients []displayListGradient `json:"gradients,omitempty"`
+	Patterns  []displayListPattern  `json:"patterns,omitempty"`
+	Canvases  []displayListCanvas   `json:"canvases"`
+}
+
+type displayListFont struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+type displayListTexture struct {
+	PNG          []byte  `json:"png"`
+	PixelsPerDip float32 `json:"pixelsPerDip"`
+	FlipY        bool    `json:"flipY,omitempty"`
+}
+
+type displayListGradientStop struct {
+	Offset float32    `json:"offset"`
+	Color  [4]float32 `json:"color"`
+}
+
+type displayListGradient struct {
+	Kind   string                    `json:"kind"`
+	Angle  float32                   `json:"angle,omitempty"`
+	Center [2]float32                `json:"center,omitempty"`
+	Radius float32                   `json:"radius,omitempty"`
+	Stops  []displayListGradientStop `json:"stops"`
+}
+
+type displayListPattern struct {
+	Texture   int        `json:"texture"`
+	Wrap      string     `json:"wrap"`
+	Transform [9]float32 `json:"transform"`
+}
+
+type displayListCanvas struct {
+	Width    int                 `json:"width"`
+	Height   int                 `json:"height"`
+	Commands []displayListRecord `json:"commands"`
+}
+
+type displayListPen struct {
+	Width      float32          `json:"width"`
+	Color      [4]float32       `json:"color"`
+	Cap        string           `json:"cap,omitempty"`
+	Join       string           `json:"join,omitempty"`
+	MiterLimit float32          `json:"miterLimit,omitempty"`
+	Dash       *displayListDash `json:"dash,omitempty"`
+}
+
+type displayListDash struct {
+	Lengths []float32 `json:"lengths"`
+	Offset  float32   `json:"offset,omitempty"`
+}
+
+type displayListBrush struct {
+	Color    [4]float32 `json:"color"`
+	Gradient *int       `json:"gradient,omitempty"`
+	Pattern  *int       `json:"pattern,omitempty"`
+}
+
+type displayListPathSegment struct {
+	Op     string       `json:"op"`
+	Points [][2]float32 `json:"points,omitempty"`
+}
+
+type displayListShadow struct {
+	Offset [2]int     `json:"offset"`
+	Blur   float32    `json:"blur,omitempty"`
+	Spread float32    `json:"spread,omitempty"`
+	Color  [4]float32 `json:"color"`
+}
+
+type displayListVertex struct {
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Radius float32 `json:"radius,omitempty"`
+}
+
+type displayListRecord struct {
+	Op      DisplayListOp            `json:"op"`
+	Rect    *[4]int                  `json:"rect,omitempty"`
+	Point   *[2]int                  `json:"point,omitempty"`
+	Color   *[4]float32              `json:"color,omitempty"`
+	Pen     *displayListPen          `json:"pen,omitempty"`
+	Brush   *displayListBrush        `json:"brush,omitempty"`
+	Polygon []displayListVertex      `json:"polygon,omitempty"`
+	Radii   *[4]float32              `json:"radii,omitempty"`
+	Font    *int                     `json:"font,omitempty"`
+	Runes   []rune                   `json:"runes,omitempty"`
+	Points  [][2]int                 `json:"points,omitempty"`
+	Canvas  *int                     `json:"canvas,omitempty"`
+	Texture *int                     `json:"texture,omitempty"`
+	Path    []displayListPathSegment `json:"path,omitempty"`
+	Rule    string                   `json:"rule,omitempty"`
+	Matrix  *math.Mat3               `json:"matrix,omitempty"`
+	Opacity *float32                 `json:"opacity,omitempty"`
+	Blend   string                   `json:"blend,omitempty"`
+	Shadow  *displayListShadow       `json:"shadow,omitempty"`
+	Blur    *float32                 `json:"blur,omitempty"`
+}
+
+func colorToArray(c Color) [4]float32 {
+	return [4]float32{c.R, c.G, c.B, c.A}
+}
+
+func arrayToColor(a [4]float32) Color {
+	return Color{R: a[0], G: a[1], B: a[2], A: a[3]}
+}
+
+// displayListEncoder flattens a display list and its dependencies into a
+// displayListFile.
+type displayListEncoder struct {
+	file      displayListFile
+	fonts     map[Font]int
+	textures  map[Texture]int
+	gradients map[*Gradient]int
+	patterns  map[*Pattern]int
+	canvases  map[*DisplayList]int
+	pending   []*DisplayList
+}
+
+func (e *displayListEncoder) font(font Font) int {
+	if index, found := e.fonts[font]; found {
+		return index
+	}
+	index := len(e.file.Fonts)
+	e.file.Fonts = append(e.file.Fonts, displayListFont{Name: font.Name(), Size: font.Size()})
+	e.fonts[font] = index
+	return index
+}
+
+func (e *displayListEncoder) texture(texture Texture) (int, error) {
+	if index, found := e.textures[texture]; found {
+		return index, nil
+	}
+	buffer := &bytes.Buffer{}
+	if err := png.Encode(buffer, texture.Image()); err != nil {
+		return 0, err
+	}
+	pixelsPerDip := float32(1)
+	if size := texture.Size(); size.Width > 0 {
+		pixelsPerDip = float32(texture.SizePixels().Width) / float32(size.Width)
+	}
+	index := len(e.file.Textures)
+	e.file.Textures = append(e.file.Textures, displayListTexture{
+		PNG:          buffer.Bytes(),
+		PixelsPerDip: pixelsPerDip,
+		FlipY:        texture.FlipY(),
+	})
+	e.textures[texture] = index
+	return index, nil
+}
+
+func (e *displayListEncoder) gradient(gradient *Gradient) int {
+	if index, found := e.gradients[gradient]; found {
+		return index
+	}
+	result := displayListGradient{
+		Kind:   gradientKindNames[gradient.Kind],
+		Angle:  gradient.Angle,
+		Center: [2]float32{gradient.Center.X, gradient.Center.Y},
+		Radius: gradient.Radius,
+	}
+	for _, stop := range gradient.Stops {
+		result.Stops = append(result.Stops, displayListGradientStop{Offset: stop.Offset, Color: colorToArray(stop.Color)})
+	}
+	index := len(e.file.Gradients)
+	e.file.Gradients = append(e.file.Gradients, result)
+	e.gradients[gradient] = index
+	return index
+}
+
+func (e *displayListEncoder) pattern(pattern *Pattern) (int, error) {
+	if index, found := e.patterns[pattern]; found {
+		return index, nil
+	}
+	texture, err := e.texture(pattern.Texture)
+	if err != nil {
+		return 0, err
+	}
+	index := len(e.file.Patterns)
+	e.file.Patterns = append(e.file.Patterns, displayListPattern{
+		Texture:   texture,
+		Wrap:      wrapModeNames[pattern.Wrap],
+		Transform: pattern.Transform,
+	})
+	e.patterns[pattern] = index
+	return index, nil
+}
+
+func (e *displayListEncoder) canvas(list *DisplayList) int {
+	if index, found := e.canvases[list]; found {
+		return index
+	}
+	index := len(e.canvases)
+	e.canvases[list] = index
+	e.pending = append(e.pending, list)
+	return index
+}
+
+func (e *displayListEncoder) record(c DisplayListCommand) (displayListRecord, error) {
+	fields := displayListOpFields[c.Op]
+	result := displayListRecord{Op: c.Op}
+	if fields&fieldRect != 0 {
+		result.Rect = &[4]int{c.Rect.Min.X, c.Rect.Min.Y, c.Rect.Max.X, c.Rect.Max.Y}
+	}
+	if fields&fieldPoint != 0 {
+		result.Point = &[2]int{c.Point.X, c.Point.Y}
+	}
+	if fields&fieldColor != 0 {
+		color := colorToArray(c.Color)
+		result.Color = &color
+	}
+	if fields&fieldPen != 0 {
+		result.Pen = &displayListPen{Width: c.Pen.Width, Color: colorToArray(c.Pen.Color)}
+		if c.Pen.IsStyled() {
+			result.Pen.Cap = lineCapNames[c.Pen.Cap]
+			result.Pen.Join = lineJoinNames[c.Pen.Join]
+			result.Pen.MiterLimit = c.Pen.MiterLimit
+		}
+		if c.Pen.Dash != nil {
+			result.Pen.Dash = &displayListDash{Lengths: c.Pen.Dash.Lengths, Offset: c.Pen.Dash.Offset}
+		}
+	}
+	if fields&fieldBrush != 0 {
+		result.Brush = &displayListBrush{Color: colorToArray(c.Brush.Color)}
+		if c.Brush.Gradient != nil {
+			gradient := e.gradient(c.Brush.Gradient)
+			result.Brush.Gradient = &gradient
+		}
+		if c.Brush.Pattern != nil {
+			pattern, err := e.pattern(c.Brush.Pattern)
+			if err != nil {
+				return result, err
+			}
+			result.Brush.Pattern = &pattern
+		}
+	}
+	if fields&fieldPolygon != 0 {
+		for _, v := range c.Polygon {
+			result.Polygon = append(result.Polygon, displayListVertex{X: v.Position.X, Y: v.Position.Y, Radius: v.RoundedRadius})
+		}
+	}
+	if fields&fieldMatrix != 0 {
+		matrix := c.Matrix
+		result.Matrix = &matrix
+	}
+	if fields&fieldRadii != 0 {
+		radii := c.Radii
+		result.Radii = &radii
+	}
+	if fields&fieldFont != 0 {
+		font := e.font(c.Font)
+		result.Font = &font
+	}
+	if fields&fieldRunes != 0 {
+		result.Runes = c.Runes
+	}
+	if fields&fieldPoints != 0 {
+		for _, p := range c.Points {
+			result.Points = append(result.Points, [2]int{p.X, p.Y})
+		}
+	}
+	if fields&fieldPath != 0 {
+		for _, segment := range c.Path.Segments {
+			record := displayListPathSegment{Op: pathOpNames[segment.Op]}
+			for _, p := range segment.Points[:pathOpPoints[segment.Op]] {
+				record.Points = append(record.Points, [2]float32{p.X, p.Y})
+			}
+			result.Path = append(result.Path, record)
+		}
+	}
+	if fields&fieldRule != 0 {
+		result.Rule = fillRuleNames[c.Rule]
+	}
+	if fields&fieldLayer != 0 {
+		opacity := c.Opacity
+		result.Opacity = &opacity
+		result.Blend = blendModeNames[c.Blend]
+	}
+	if fields&fieldShadow != 0 {
+		result.Shadow = &displayListShadow{
+			Offset: [2]int{c.Shadow.Offset.X, c.Shadow.Offset.Y},
+			Blur:   c.Shadow.Blur,
+			Spread: c.Shadow.Spread,
+			Color:  colorToArray(c.Shadow.Color),
+		}
+	}
+	if fields&fieldBlur != 0 {
+		blur := c.Blur
+		result.Blur = &blur
+	}
+	if fields&fieldCanvas != 0 {
+		canvas := e.canvas(c.Canvas)
+		result.Canvas = &canvas
+	}
+	if fields&fieldTexture != 0 {
+		texture, err := e.texture(c.Texture)
+		if err != nil {
+			return result, err
+		}
+		result.Texture = &texture
+	}
+	return result, nil
+}
+
+func encodeDisplayListFile(list *DisplayList) (*displayListFile, error) {
+	e := &displayListEncoder{
+		file:      displayListFile{Version: DisplayListVersion},
+		fonts:     make(map[Font]int),
+		textures:  make(map[Texture]int),
+		gradients: make(map[*Gradient]int),
+		patterns:  make(map[*Pattern]int),
+		canvases:  make(map[*DisplayList]int),
+	}
+	e.canvas(list)
+	for len(e.pending) > 0 {
+		current := e.pending[0]
+		e.pending = e.pending[1:]
+		canvas := displayListCanvas{Width: current.size.Width, Height: current.size.Height}
+		for _, command := range current.commands {
+			record, err := e.record(command)
+			if err != nil {
+				return nil, err
+			}
+			canvas.Commands = append(canvas.Commands, record)
+		}
+		e.file.Canvases = append(e.file.Canvases, canvas)
+	}
+	return &e.file, nil
+}
+
+// decodeDisplayListFile rebuilds the display lists stored in file, returning
+// the root list.
+func decodeDisplayListFile(file *displayListFile, driver Driver, resolve FontResolver) (*DisplayList, error) {
+	if file.Version < 1 || file.Version > DisplayListVersion {
+		return nil, fmt.Errorf("unsupported display list version %d", file.Version)
+	}
+	if len(file.Canvases) == 0 {
+		return nil, errors.New("display list has no canvases")
+	}
+
+	if resolve == nil && len(file.Fonts) > 0 {
+		return nil, fmt.Errorf("display list uses %d fonts and has no font resolver", len(file.Fonts))
+	}
+	fonts := make([]Font, len(file.Fonts))
+	for i, f := range file.Fonts {
+		font, err := resolve(f.Name, f.Size)
+		if err != nil {
+			return nil, fmt.Errorf("resolving font %q size %d: %w", f.Name, f.Size, err)
+		}
+		fonts[i] = font
+	}
+
+	textures := make([]Texture, len(file.Textures))
+	for i, t := range file.Textures {
+		img, err := png.Decode(bytes.NewReader(t.PNG))
+		if err != nil {
+			return nil, fmt.Errorf("decoding texture %d: %w", i, err)
+		}
+		textures[i] = driver.CreateTexture(img, t.PixelsPerDip)
+		textures[i].SetFlipY(t.FlipY)
+	}
+
+	gradients := make([]*Gradient, len(file.Gradients))
+	for i, g := range file.Gradients {
+		gradient := &Gradient{
+			Angle:  g.Angle,
+			Center: math.Vec2{X: g.Center[0], Y: g.Center[1]},
+			Radius: g.Radius,
+		}
+		found := false
+		for kind, name := range gradientKindNames {
+			if name == g.Kind {
+				gradient.Kind, found = kind, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("gradient %d has unknown kind %q", i, g.Kind)
+		}
+		for _, stop := range g.Stops {
+			gradient.Stops = append(gradient.Stops, GradientStop{Offset: stop.Offset, Color: arrayToColor(stop.Color)})
+		}
+		gradients[i] = gradient
+	}
+
+	patterns := make([]*Pattern, len(file.Patterns))
+	for i, p := range file.Patterns {
+		if p.Texture < 0 || p.Texture >= len(textures) {
+			return nil, fmt.Errorf("pattern %d references missing texture %d", i, p.Texture)
+		}
+		pattern := &Pattern{Texture: textures[p.Texture], Transform: p.Transform}
+		found := false
+		for wrap, name := range wrapModeNames {
+			if name == p.Wrap {
+				pattern.Wrap, found = wrap, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("pattern %d has unknown wrap mode %q", i, p.Wrap)
+		}
+		patterns[i] = pattern
+	}
+
+	lists := make([]*DisplayList, len(file.Canvases))
+	for i, c := range file.Canvases {
+		if c.Width <= 0 || c.Height < 0 {
+			return nil, fmt.Errorf("canvas %d has invalid size %dx%d", i, c.Width, c.Height)
+		}
+		lists[i] = &DisplayList{size: math.Size{Width: c.Width, Height: c.Height}}
+	}
+
+	for i, c := range file.Canvases {
+		list := lists[i]
+		for j, r := range c.Commands {
+			command, err := decodeDisplayListRecord(r, file.Version, fonts, textures, gradients, patterns, lists)
+			if err != nil {
+				return nil, fmt.Errorf("canvas %d, command %d: %w", i, j, err)
+			}
+			switch command.Op {
+			case OpPush:
+				list.pushCount++
+			case OpPop:
+				err = list.layers.check(list.pushCount)
+				list.pushCount--
+			case OpPushLayer:
+				list.pushCount++
+				list.layers.push(list.pushCount)
+			case OpPopLayer:
+				err = list.layers.pop(list.pushCount)
+				list.pushCount--
+			}
+			if err != nil {
+				return nil, fmt.Errorf("canvas %d, command %d: %w", i, j, err)
+			}
+			if list.pushCount < 0 {
+				return nil, fmt.Errorf("canvas %d, command %d: Pop() without Push()", i, j)
+			}
+			list.commands = append(list.commands, command)
+		}
+		if list.pushCount != 0 {
+			return nil, fmt.Errorf("canvas %d: Push() count was %d at the end of the list", i, list.pushCount)
+		}
+		list.complete = true
+	}
+
+	// Reject lists that draw themselves, which would never finish replaying.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[*DisplayList]int)
+	var visit func(list *DisplayList) error
+	visit = func(list *DisplayList) error {
+		switch states[list] {
+		case visiting:
+			return errors.New("display list contains a cycle")
+		case visited:
+			return nil
+		}
+		states[list] = visiting
+		for _, c := range list.commands {
+			if c.Op == OpDrawCanvas {
+				if err := visit(c.Canvas); err != nil {
+					return err
+				}
+			}
+		}
+		states[list] = visited
+		return nil
+	}
+	if err := visit(lists[0]); err != nil {
+		return nil, err
+	}
+
+	return lists[0], nil
+}
+
+func decodeDisplayListRecord(r displayListRecord, version int, fonts []Font, textures []Texture, gradients []*Gradient, patterns []*Pattern, lists []*DisplayList) (DisplayListCommand, error) {
+	fields, found := displayListOpFields[r.Op]
+	if !found {
+		return DisplayListCommand{}, fmt.Errorf("unknown op %d", r.Op)
+	}
+	if err := checkDisplayListOpVersion(r.Op, version); err != nil {
+		return DisplayListCommand{}, err
+	}
+
+	missing := func(name string) (DisplayListCommand, error) {
+		return DisplayListCommand{}, fmt.Errorf("%v is missing its %s", r.Op, name)
+	}
+
+	result := DisplayListCommand{Op: r.Op}
+	if fields&fieldRect != 0 {
+		if r.Rect == nil {
+			return missing("rect")
+		}
+		result.Rect = math.CreateRect(r.Rect[0], r.Rect[1], r.Rect[2], r.Rect[3])
+	}
+	if fields&fieldPoint != 0 {
+		if r.Point == nil {
+			return missing("point")
+		}
+		result.Point = math.Point{X: r.Point[0], Y: r.Point[1]}
+	}
+	if fields&fieldColor != 0 {
+		if r.Color == nil {
+			return missing("color")
+		}
+		result.Color = arrayToColor(*r.Color)
+	}
+	if fields&fieldPen != 0 {
+		if r.Pen == nil {
+			return missing("pen")
+		}
+		result.Pen = Pen{Width: r.Pen.Width, Color: arrayToColor(r.Pen.Color), MiterLimit: r.Pen.MiterLimit}
+		if r.Pen.Cap != "" {
+			found := false
+			for c, name := range lineCapNames {
+				if name == r.Pen.Cap {
+					result.Pen.Cap, found = c, true
+				}
+			}
+			if !found {
+				return DisplayListCommand{}, fmt.Errorf("%v has unknown line cap %q", r.Op, r.Pen.Cap)
+			}
+		}
+		if r.Pen.Join != "" {
+			found := false
+			for j, name := range lineJoinNames {
+				if name == r.Pen.Join {
+					result.Pen.Join, found = j, true
+				}
+			}
+			if !found {
+				return DisplayListCommand{}, fmt.Errorf("%v has unknown line join %q", r.Op, r.Pen.Join)
+			}
+		}
+		if r.Pen.Dash != nil {
+			result.Pen.Dash = &Dash{Lengths: append([]float32{}, r.Pen.Dash.Lengths...), Offset: r.Pen.Dash.Offset}
+		}
+	}
+	if fields&fieldBrush != 0 {
+		if r.Brush == nil {
+			return missing("brush")
+		}
+		result.Brush = Brush{Color: arrayToColor(r.Brush.Color)}
+		if r.Brush.Gradient != nil {
+			if *r.Brush.Gradient < 0 || *r.Brush.Gradient >= len(gradients) {
+				return missing("gradient")
+			}
+			result.Brush.Gradient = gradients[*r.Brush.Gradient]
+		}
+		if r.Brush.Pattern != nil {
+			if *r.Brush.Pattern < 0 || *r.Brush.Pattern >= len(patterns) {
+				return missing("pattern")
+			}
+			result.Brush.Pattern = patterns[*r.Brush.Pattern]
+		}
+	}
+	if fields&fieldPolygon != 0 {
+		result.Polygon = make(Polygon, len(r.Polygon))
+		for i, v := range r.Polygon {
+			result.Polygon[i] = PolygonVertex{
+				Position:      math.Point{X: v.X, Y: v.Y},
+				RoundedRadius: v.Radius,
+			}
+		}
+	}
+	if fields&fieldPath != 0 {
+		result.Path = &Path{}
+		for _, segment := range r.Path {
+			found := false
+			for op, name := range pathOpNames {
+				if name == segment.Op {
+					result.Path.Segments, found = append(result.Path.Segments, PathSegment{Op: op}), true
+				}
+			}
+			if !found {
+				return DisplayListCommand{}, fmt.Errorf("%v has unknown path op %q", r.Op, segment.Op)
+			}
+			last := &result.Path.Segments[len(result.Path.Segments)-1]
+			if len(segment.Points) != pathOpPoints[last.Op] {
+				return DisplayListCommand{}, fmt.Errorf("%v has a %s path op with %d points", r.Op, segment.Op, len(segment.Points))
+			}
+			for i, p := range segment.Points {
+				last.Points[i] = math.Vec2{X: p[0], Y: p[1]}
+			}
+		}
+	}
+	if fields&fieldRule != 0 {
+		found := false
+		for rule, name := range fillRuleNames {
+			if name == r.Rule {
+				result.Rule, found = rule, true
+			}
+		}
+		if !found {
+			return DisplayListCommand{}, fmt.Errorf("%v has unknown fill rule %q", r.Op, r.Rule)
+		}
+	}
+	if fields&fieldLayer != 0 {
+		if r.Opacity == nil {
+			return missing("opacity")
+		}
+		result.Opacity = *r.Opacity
+		found := false
+		for mode, name := range blendModeNames {
+			if name == r.Blend {
+				result.Blend, found = mode, true
+			}
+		}
+		if !found {
+			return DisplayListCommand{}, fmt.Errorf("%v has unknown blend mode %q", r.Op, r.Blend)
+		}
+	}
+	if fields&fieldShadow != 0 {
+		if r.Shadow == nil {
+			return missing("shadow")
+		}
+		result.Shadow = Shadow{
+			Offset: math.Point{X: r.Shadow.Offset[0], Y: r.Shadow.Offset[1]},
+			Blur:   r.Shadow.Blur,
+			Spread: r.Shadow.Spread,
+			Color:  arrayToColor(r.Shadow.Color),
+		}
+	}
+	if fields&fieldBlur != 0 {
+		if r.Blur == nil {
+			return missing("blur")
+		}
+		result.Blur = *r.Blur
+	}
+	if fields&fieldMatrix != 0 {
+		if r.Matrix == nil {
+			return missing("matrix")
+		}
+		result.Matrix = *r.Matrix
+	}
+	if fields&fieldRadii != 0 {
+		if r.Radii == nil {
+			return missing("radii")
+		}
+		result.Radii = *r.Radii
+	}
+	if fields&fieldFont != 0 {
+		if r.Font == nil || *r.Font < 0 || *r.Font >= len(fonts) {
+			return missing("font")
+		}
+		result.Font = fonts[*r.Font]
+	}
+	if fields&fieldRunes != 0 {
+		result.Runes = append([]rune{}, r.Runes...)
+	}
+	if fields&fieldPoints != 0 {
+		result.Points = make([]math.Point, len(r.Points))
+		for i, p := range r.Points {
+			result.Points[i] = math.Point{X: p[0], Y: p[1]}
+		}
+		if len(result.Points) != len(result.Runes) {
+			return DisplayListCommand{}, fmt.Errorf("%v has %d runes and %d points", r.Op, len(result.Runes), len(result.Points))
+		}
+	}
+	if fields&fieldCanvas != 0 {
+		if r.Canvas == nil || *r.Canvas < 0 || *r.Canvas >= len(lists) {
+			return missing("canvas")
+		}
+		result.Canvas = lists[*r.Canvas]
+	}
+	if fields&fieldTexture != 0 {
+		if r.Texture == nil || *r.Texture < 0 || *r.Texture >= len(textures) {
+			return missing("texture")
+		}
+		result.Texture = textures[*r.Texture]
+	}
+	return result, nil
+}
+
+// EncodeDisplayListJSON writes list, along with every display list, font
+// reference and texture it depends on, to w as JSON.
+func EncodeDisplayListJSON(w io.Writer, list *DisplayList) error {
+	file, err := encodeDisplayListFile(list)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
+
+// DecodeDisplayListJSON reads a display list written by EncodeDisplayListJSON.
+// Textures are created with driver, and fonts are looked up with resolve.
+func DecodeDisplayListJSON(r io.Reader, driver Driver, resolve FontResolver) (*DisplayList, error) {
+	file := &displayListFile{}
+	if err := json.NewDecoder(r).Decode(file); err != nil {
+		return nil, err
+	}
+	return decodeDisplayListFile(file, driver, resolve)
+}
+
+// EncodeDisplayList writes list, along with every display list, font reference
+// and texture it depends on, to w in the compact binary format.
+func EncodeDisplayList(w io.Writer, list *DisplayList) error {
+	file, err := encodeDisplayListFile(list)
+	if err != nil {
+		return err
+	}
+
+	e := &binaryWriter{w: bufio.NewWriter(w)}
+	e.bytes(displayListMagic)
+	e.int(file.Version)
+
+	e.int(len(file.Fonts))
+	for _, f := range file.Fonts {
+		e.string(f.Name)
+		e.int(f.Size)
+	}
+
+	e.int(len(file.Textures))
+	for _, t := range file.Textures {
+		e.int(len(t.PNG))
+		e.bytes(t.PNG)
+		e.float(t.PixelsPerDip)
+		e.bool(t.FlipY)
+	}
+
+	e.int(len(file.Gradients))
+	for _, g := range file.Gradients {
+		e.string(g.Kind)
+		e.float(g.Angle)
+		e.float(g.Center[0])
+		e.float(g.Center[1])
+		e.float(g.Radius)
+		e.int(len(g.Stops))
+		for _, stop := range g.Stops {
+			e.float(stop.Offset)
+			e.color(stop.Color)
+		}
+	}
+
+	e.int(len(file.Patterns))
+	for _, p := range file.Patterns {
+		e.int(p.Texture)
+		e.string(p.Wrap)
+		for _, v := range p.Transform {
+			e.float(v)
+		}
+	}
+
+	e.int(len(file.Canvases))
+	for _, c := range file.Canvases {
+		e.int(c.Width)
+		e.int(c.Height)
+		e.int(len(c.Commands))
+		for _, r := range c.Commands {
+			e.record(r)
+		}
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// DecodeDisplayList reads a display list written by EncodeDisplayList.
+// Textures are created with driver, and fonts are looked up with resolve.
+func DecodeDisplayList(r io.Reader, driver Driver, resolve FontResolver) (*DisplayList, error) {
+	d := &binaryReader{r: bufio.NewReader(r)}
+	if magic := d.bytes(len(displayListMagic)); d.err == nil && !bytes.Equal(magic, displayListMagic) {
+		return nil, errors.New("not a display list")
+	}
+
+	file := &displayListFile{Version: d.int()}
+	if d.err == nil && (file.Version < 1 || file.Version > DisplayListVersion) {
+		return nil, fmt.Errorf("unsupported display list version %d", file.Version)
+	}
+	d.version = file.Version
+
+	// Arrays are grown as they are read, so that a corrupt length fails on
+	// the missing data rather than on a huge allocation.
+	for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+		file.Fonts = append(file.Fonts, displayListFont{Name: d.string(), Size: d.int()})
+	}
+
+	for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+		file.Textures = append(file.Textures, displayListTexture{PNG: d.bytes(d.length()), PixelsPerDip: d.float(), FlipY: d.bool()})
+	}
+
+	for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+		g := displayListGradient{Kind: d.string(), Angle: d.float(), Center: [2]float32{d.float(), d.float()}, Radius: d.float()}
+		for j, count := 0, d.length(); j < count && d.err == nil; j++ {
+			g.Stops = append(g.Stops, displayListGradientStop{Offset: d.float(), Color: d.color()})
+		}
+		file.Gradients = append(file.Gradients, g)
+	}
+
+	for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+		p := displayListPattern{Texture: d.int(), Wrap: d.string()}
+		for j := range p.Transform {
+			p.Transform[j] = d.float()
+		}
+		file.Patterns = append(file.Patterns, p)
+	}
+
+	for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+		c := displayListCanvas{Width: d.int(), Height: d.int()}
+		for j, count := 0, d.length(); j < count && d.err == nil; j++ {
+			c.Commands = append(c.Commands, d.record())
+		}
+		file.Canvases = append(file.Canvases, c)
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	return decodeDisplayListFile(file, driver, resolve)
+}
+
+// binaryWriter writes the primitives of the binary display list format.
+// The first error encountered is kept, and makes all further writes no-ops.
+type binaryWriter struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (e *binaryWriter) bytes(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *binaryWriter) int(v int) {
+	e.bytes(e.buf[:binary.PutVarint(e.buf[:], int64(v))])
+}
+
+func (e *binaryWriter) float(v float32) {
+	e.bytes(binary.LittleEndian.AppendUint32(e.buf[:0], m.Float32bits(v)))
+}
+
+func (e *binaryWriter) bool(v bool) {
+	if v {
+		e.int(1)
+	} else {
+		e.int(0)
+	}
+}
+
+func (e *binaryWriter) string(s string) {
+	e.int(len(s))
+	e.bytes([]byte(s))
+}
+
+func (e *binaryWriter) color(c [4]float32) {
+	for _, v := range c {
+		e.float(v)
+	}
+}
+
+// optionalIndex writes index plus one, or zero if index is nil.
+func (e *binaryWriter) optionalIndex(index *int) {
+	if index != nil {
+		e.int(*index + 1)
+	} else {
+		e.int(0)
+	}
+}
+
+func (e *binaryWriter) record(r displayListRecord) {
+	fields := displayListOpFields[r.Op]
+	e.int(int(r.Op))
+	if fields&fieldRect != 0 {
+		for _, v := range r.Rect {
+			e.int(v)
+		}
+	}
+	if fields&fieldPoint != 0 {
+		e.int(r.Point[0])
+		e.int(r.Point[1])
+	}
+	if fields&fieldColor != 0 {
+		e.color(*r.Color)
+	}
+	if fields&fieldPen != 0 {
+		e.float(r.Pen.Width)
+		e.color(r.Pen.Color)
+		e.string(r.Pen.Cap)
+		e.string(r.Pen.Join)
+		e.float(r.Pen.MiterLimit)
+		e.bool(r.Pen.Dash != nil)
+		if r.Pen.Dash != nil {
+			e.int(len(r.Pen.Dash.Lengths))
+			for _, l := range r.Pen.Dash.Lengths {
+				e.float(l)
+			}
+			e.float(r.Pen.Dash.Offset)
+		}
+	}
+	if fields&fieldBrush != 0 {
+		e.color(r.Brush.Color)
+		// The gradient and pattern indices are stored plus one, leaving zero
+		// for none.
+		e.optionalIndex(r.Brush.Gradient)
+		e.optionalIndex(r.Brush.Pattern)
+	}
+	if fields&fieldPolygon != 0 {
+		e.int(len(r.Polygon))
+		for _, v := range r.Polygon {
+			e.int(v.X)
+			e.int(v.Y)
+			e.float(v.Radius)
+		}
+	}
+	if fields&fieldRadii != 0 {
+		for _, v := range r.Radii {
+			e.float(v)
+		}
+	}
+	if fields&fieldMatrix != 0 {
+		for _, v := range r.Matrix {
+			e.float(v)
+		}
+	}
+	if fields&fieldFont != 0 {
+		e.int(*r.Font)
+	}
+	if fields&fieldRunes != 0 {
+		e.int(len(r.Runes))
+		for _, v := range r.Runes {
+			e.int(int(v))
+		}
+	}
+	if fields&fieldPoints != 0 {
+		e.int(len(r.Points))
+		for _, p := range r.Points {
+			e.int(p[0])
+			e.int(p[1])
+		}
+	}
+	if fields&fieldCanvas != 0 {
+		e.int(*r.Canvas)
+	}
+	if fields&fieldTexture != 0 {
+		e.int(*r.Texture)
+	}
+	if fields&fieldPath != 0 {
+		e.int(len(r.Path))
+		for _, segment := range r.Path {
+			e.string(segment.Op)
+			e.int(len(segment.Points))
+			for _, p := range segment.Points {
+				e.float(p[0])
+				e.float(p[1])
+			}
+		}
+	}
+	if fields&fieldRule != 0 {
+		e.string(r.Rule)
+	}
+	if fields&fieldLayer != 0 {
+		e.float(*r.Opacity)
+		e.string(r.Blend)
+	}
+	if fields&fieldShadow != 0 {
+		e.int(r.Shadow.Offset[0])
+		e.int(r.Shadow.Offset[1])
+		e.float(r.Shadow.Blur)
+		e.float(r.Shadow.Spread)
+		e.color(r.Shadow.Color)
+	}
+	if fields&fieldBlur != 0 {
+		e.float(*r.Blur)
+	}
+}
+
+// binaryReader reads the primitives of the binary display list format.
+// The first error encountered is kept, and makes all further reads return
+// zero values.
+type binaryReader struct {
+	r       *bufio.Reader
+	version int
+	err     error
+}
+
+func (d *binaryReader) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	// The buffer grows as the bytes are read, like the arrays.
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, d.r, int64(n))
+	if err != nil {
+		if err == io.EOF && copied > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		d.err = err
+		return nil
+	}
+	return buf.Bytes()
+}
+
+func (d *binaryReader) int() int {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(d.r)
+	if err != nil {
+		d.err = err
+		return 0
+	}
+	return int(v)
+}
+
+// length reads the length of an array or string.
+func (d *binaryReader) length() int {
+	n := d.int()
+	if d.err == nil && (n < 0 || n > maxDisplayListLength) {
+		d.err = fmt.Errorf("invalid display list length %d", n)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return n
+}
+
+func (d *binaryReader) float() float32 {
+	b := d.bytes(4)
+	if d.err != nil {
+		return 0
+	}
+	return m.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+func (d *binaryReader) bool() bool {
+	return d.int() != 0
+}
+
+func (d *binaryReader) string() string {
+	return string(d.bytes(d.length()))
+}
+
+func (d *binaryReader) color() [4]float32 {
+	return [4]float32{d.float(), d.float(), d.float(), d.float()}
+}
+
+// optionalIndex reads an index written by binaryWriter.optionalIndex.
+func (d *binaryReader) optionalIndex() *int {
+	if index := d.int() - 1; index >= 0 {
+		return &index
+	}
+	return nil
+}
+
+func (d *binaryReader) record() displayListRecord {
+	result := displayListRecord{Op: DisplayListOp(d.int())}
+	fields, found := displayListOpFields[result.Op]
+	if !found {
+		if d.err == nil {
+			d.err = fmt.Errorf("unknown display list op %d", result.Op)
+		}
+		return result
+	}
+	// The fields of ops newer than the list are not read, as they may not be
+	// those written.
+	if err := checkDisplayListOpVersion(result.Op, d.version); err != nil {
+		if d.err == nil {
+			d.err = err
+		}
+		return result
+	}
+	if fields&fieldRect != 0 {
+		result.Rect = &[4]int{d.int(), d.int(), d.int(), d.int()}
+	}
+	if fields&fieldPoint != 0 {
+		result.Point = &[2]int{d.int(), d.int()}
+	}
+	if fields&fieldColor != 0 {
+		color := d.color()
+		result.Color = &color
+	}
+	if fields&fieldPen != 0 {
+		result.Pen = &displayListPen{Width: d.float(), Color: d.color(), Cap: d.string(), Join: d.string(), MiterLimit: d.float()}
+		if d.bool() {
+			dash := &displayListDash{}
+			for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+				dash.Lengths = append(dash.Lengths, d.float())
+			}
+			dash.Offset = d.float()
+			result.Pen.Dash = dash
+		}
+	}
+	if fields&fieldBrush != 0 {
+		result.Brush = &displayListBrush{Color: d.color(), Gradient: d.optionalIndex(), Pattern: d.optionalIndex()}
+	}
+	if fields&fieldPolygon != 0 {
+		for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+			result.Polygon = append(result.Polygon, displayListVertex{X: d.int(), Y: d.int(), Radius: d.float()})
+		}
+	}
+	if fields&fieldRadii != 0 {
+		result.Radii = &[4]float32{d.float(), d.float(), d.float(), d.float()}
+	}
+	if fields&fieldMatrix != 0 {
+		matrix := math.Mat3{}
+		for i := range matrix {
+			matrix[i] = d.float()
+		}
+		result.Matrix = &matrix
+	}
+	if fields&fieldFont != 0 {
+		font := d.int()
+		result.Font = &font
+	}
+	if fields&fieldRunes != 0 {
+		for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+			result.Runes = append(result.Runes, rune(d.int()))
+		}
+	}
+	if fields&fieldPoints != 0 {
+		for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+			result.Points = append(result.Points, [2]int{d.int(), d.int()})
+		}
+	}
+	if fields&fieldCanvas != 0 {
+		canvas := d.int()
+		result.Canvas = &canvas
+	}
+	if fields&fieldTexture != 0 {
+		texture := d.int()
+		result.Texture = &texture
+	}
+	if fields&fieldPath != 0 {
+		for i, n := 0, d.length(); i < n && d.err == nil; i++ {
+			segment := displayListPathSegment{Op: d.string()}
+			for j, m := 0, d.length(); j < m && d.err == nil; j++ {
+				segment.Points = append(segment.Points, [2]float32{d.float(), d.float()})
+			}
+			result.Path = append(result.Path, segment)
+		}
+	}
+	if fields&fieldRule != 0 {
+		result.Rule = d.string()
+	}
+	if fields&fieldLayer != 0 {
+		opacity := d.float()
+		result.Opacity = &opacity
+		result.Blend = d.string()
+	}
+	if fields&fieldShadow != 0 {
+		result.Shadow = &displayListShadow{
+			Offset: [2]int{d.int(), d.int()},
+			Blur:   d.float(),
+			Spread: d.float(),
+			Color:  d.color(),
+		}
+	}
+	if fields&fieldBlur != 0 {
+		blur := d.float()
+		result.Blur = &blur
+	}
+	return result
+}
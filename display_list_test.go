@@ -0,0 +1,342 @@
+package gxui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+type testFont struct {
+	name string
+	size int
+}
+
+func (f *testFont) Data() []byte                       { return nil }
+func (f *testFont) Name() string                       { return f.name }
+func (f *testFont) LoadGlyphs(first, last rune)        {}
+func (f *testFont) Size() int                          { return f.size }
+func (f *testFont) GlyphMaxSize() math.Size            { return math.Size{Width: f.size, Height: f.size} }
+func (f *testFont) Measure(*TextBlock) math.Size       { return math.ZeroSize }
+func (f *testFont) Layout(*TextBlock) (o []math.Point) { return nil }
+
+type testTexture struct {
+	img   image.Image
+	flipY bool
+}
+
+func (t *testTexture) Image() image.Image { return t.img }
+func (t *testTexture) Size() math.Size    { return t.SizePixels() }
+func (t *testTexture) SizePixels() math.Size {
+	return math.Size{Width: t.img.Bounds().Dx(), Height: t.img.Bounds().Dy()}
+}
+func (t *testTexture) FlipY() bool         { return t.flipY }
+func (t *testTexture) SetFlipY(flipY bool) { t.flipY = flipY }
+
+// testDriver creates display lists as canvases, counting them.
+type testDriver struct {
+	Driver
+	canvases int
+}
+
+func (d *testDriver) CreateCanvas(size math.Size) Canvas {
+	d.canvases++
+	return CreateDisplayList(size)
+}
+
+func (d *testDriver) CreateTexture(img image.Image, pixelsPerDip float32) Texture {
+	return &testTexture{img: img}
+}
+
+func testFonts(fonts ...*testFont) FontResolver {
+	return func(name string, size int) (Font, error) {
+		for _, f := range fonts {
+			if f.name == name && f.size == size {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("no font %s", name)
+	}
+}
+
+func createTestDisplayList(font Font, texture Texture) *DisplayList {
+	child := CreateDisplayList(math.Size{Width: 10, Height: 10})
+	child.DrawRect(math.CreateRect(0, 0, 10, 10), CreateBrush(Red))
+	child.Complete()
+
+	list := CreateDisplayList(math.Size{Width: 100, Height: 50})
+	list.Clear(Gray10)
+	list.Push()
+	list.AddClip(math.CreateRect(5, 5, 95, 45))
+	list.DrawRunes(font, []rune("hi"), []math.Point{{X: 1, Y: 2}, {X: 9, Y: 2}}, White)
+	list.DrawPolygon(
+		Polygon{
+			{Position: math.Point{X: 0, Y: 0}},
+			{Position: math.Point{X: 10, Y: 0}, RoundedRadius: 2},
+			{Position: math.Point{X: 10, Y: 10}},
+		},
+		CreatePen(1.5, Blue), CreateBrush(Green),
+	)
+	dashed := CreateDashedPen(2, Red, 1, 3, 1)
+	dashed.Cap, dashed.Join, dashed.MiterLimit = RoundCap, BevelJoin, 2
+	list.DrawLines(Polygon{{Position: math.Point{X: 1, Y: 1}}, {Position: math.Point{X: 2, Y: 3}}}, dashed)
+	list.DrawRoundedRect(math.CreateRect(20, 20, 40, 30), 1, 2, 3, 4, WhitePen, BlackBrush)
+	list.DrawTexture(texture, math.CreateRect(50, 0, 52, 2))
+	gradient := CreateRadialGradientBrush(math.Vec2{X: 0.5, Y: 0.5}, 0.5, GradientStop{Offset: 0, Color: White}, GradientStop{Offset: 1, Color: Transparent})
+	list.DrawRect(math.CreateRect(0, 40, 20, 50), gradient)
+	list.DrawRect(math.CreateRect(20, 40, 40, 50), gradient)
+	list.DrawRoundedRect(math.CreateRect(40, 40, 60, 50), 2, 2, 2, 2, TransparentPen, CreatePatternBrush(texture, WrapMirror, math.CreateMat3Scale(2, 2)))
+	path := &Path{}
+	path.MoveTo(math.Vec2{X: 1, Y: 2})
+	path.LineTo(math.Vec2{X: 10, Y: 2})
+	path.QuadTo(math.Vec2{X: 12, Y: 6}, math.Vec2{X: 10, Y: 10.5})
+	path.CubicTo(math.Vec2{X: 8, Y: 12}, math.Vec2{X: 4, Y: 12}, math.Vec2{X: 1, Y: 10})
+	path.Close()
+	list.FillPath(path, EvenOdd, gradient)
+	list.StrokePath(path, dashed)
+	list.Transform(math.CreateMat3Translate(50, 25).Mul(math.CreateMat3Rotate(0.5)))
+	list.DrawRect(math.CreateRect(-5, -5, 5, 5), CreateBrush(Yellow))
+	list.PushLayer(0.5, BlendMultiply)
+	list.DrawRect(math.CreateRect(0, 0, 5, 5), CreateBrush(Blue))
+	list.PopLayer()
+	list.Pop()
+	list.DrawShadow(math.CreateRect(10, 40, 30, 50), 2, 2, 4, 4, Shadow{Offset: math.Point{X: 1, Y: 2}, Blur: 6, Spread: 1, Color: Black})
+	list.BlurBackdrop(math.CreateRect(10, 40, 30, 50), 4)
+	list.DrawCanvas(child, math.Point{X: 60, Y: 10})
+	list.DrawCanvas(child, math.Point{X: 80, Y: 10})
+	list.Complete()
+	return list
+}
+
+func createTestTexture() *testTexture {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.SetNRGBA(1, 0, color.NRGBA{R: 0xff, A: 0xff})
+	return &testTexture{img: img, flipY: true}
+}
+
+func TestDisplayListRecord(t *testing.T) {
+	font := &testFont{name: "Test", size: 12}
+	list := createTestDisplayList(font, createTestTexture())
+
+	var ops []DisplayListOp
+	for _, c := range list.Commands() {
+		ops = append(ops, c.Op)
+	}
+	test_helper.AssertEquals(t, []DisplayListOp{
+		OpClear, OpPush, OpAddClip, OpDrawRunes, OpDrawPolygon, OpDrawLines,
+		OpDrawRoundedRect, OpDrawTexture, OpDrawRect, OpDrawRect, OpDrawRoundedRect, OpFillPath, OpStrokePath,
+		OpTransform, OpDrawRect, OpPushLayer, OpDrawRect, OpPopLayer, OpPop, OpDrawShadow, OpBlurBackdrop,
+		OpDrawCanvas, OpDrawCanvas,
+	}, ops)
+	test_helper.AssertEquals(t, [4]float32{1, 2, 3, 4}, list.Commands()[6].Radii)
+	test_helper.AssertEquals(t, "DrawRoundedRect", OpDrawRoundedRect.String())
+}
+
+func TestDisplayListReplay(t *testing.T) {
+	font := &testFont{name: "Test", size: 12}
+	list := createTestDisplayList(font, createTestTexture())
+
+	driver := &testDriver{}
+	replayed := CreateDisplayList(list.Size())
+	list.Replay(driver, replayed)
+	replayed.Complete()
+
+	test_helper.AssertEquals(t, list.Commands(), replayed.Commands())
+	// The child canvas is drawn twice, but must only be created once.
+	test_helper.AssertEquals(t, 1, driver.canvases)
+}
+
+func TestDisplayListEncodeBinary(t *testing.T) {
+	font := &testFont{name: "Test", size: 12}
+	list := createTestDisplayList(font, createTestTexture())
+
+	buffer := &bytes.Buffer{}
+	if err := EncodeDisplayList(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeDisplayList(buffer, &testDriver{}, testFonts(font))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test_helper.AssertEquals(t, list.Size(), decoded.Size())
+	test_helper.AssertEquals(t, list.Commands(), decoded.Commands())
+	test_helper.AssertEquals(t, true, decoded.IsComplete())
+	// Brushes sharing a gradient must still share it once decoded.
+	test_helper.AssertEquals(t, true, decoded.Commands()[8].Brush.Gradient == decoded.Commands()[9].Brush.Gradient)
+	// Patterns reference the textures also drawn by DrawTexture.
+	test_helper.AssertEquals(t, true, decoded.Commands()[10].Brush.Pattern.Texture == decoded.Commands()[7].Texture)
+}
+
+func TestDisplayListEncodeJSON(t *testing.T) {
+	font := &testFont{name: "Test", size: 12}
+	list := createTestDisplayList(font, createTestTexture())
+
+	buffer := &bytes.Buffer{}
+	if err := EncodeDisplayListJSON(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), `"op": "DrawRoundedRect"`) {
+		t.Errorf("JSON does not name the ops:\n%s", buffer.String())
+	}
+
+	decoded, err := DecodeDisplayListJSON(buffer, &testDriver{}, testFonts(font))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test_helper.AssertEquals(t, list.Commands(), decoded.Commands())
+}
+
+func TestDisplayListDecodeErrors(t *testing.T) {
+	font := &testFont{name: "Test", size: 12}
+	list := createTestDisplayList(font, createTestTexture())
+	buffer := &bytes.Buffer{}
+	if err := EncodeDisplayList(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buffer.Bytes()
+
+	for _, test := range []struct {
+		name  string
+		data  []byte
+		fonts FontResolver
+	}{
+		{"bad magic", append([]byte("XXXX"), encoded[4:]...), testFonts(font)},
+		{"truncated", encoded[:len(encoded)-3], testFonts(font)},
+		{"missing font", encoded, testFonts()},
+		{"no resolver", encoded, nil},
+	} {
+		if _, err := DecodeDisplayList(bytes.NewReader(test.data), &testDriver{}, test.fonts); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	for _, test := range []struct {
+		name string
+		json string
+	}{
+		{"version", `{"version": 99, "canvases": [{"width": 1, "height": 1}]}`},
+		{"unknown op", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "Fill"}]}]}`},
+		{"unbalanced", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "Push"}]}]}`},
+		{"cycle", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "DrawCanvas", "canvas": 0, "point": [0, 0]}]}]}`},
+		{"missing rect", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "AddClip"}]}]}`},
+		{"missing gradient", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "DrawRect", "rect": [0, 0, 1, 1], "brush": {"color": [0, 0, 0, 1], "gradient": 0}}]}]}`},
+		{"gradient kind", `{"version": 1, "gradients": [{"kind": "conic", "stops": []}], "canvases": [{"width": 1, "height": 1}]}`},
+		{"missing pattern", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "DrawRect", "rect": [0, 0, 1, 1], "brush": {"color": [0, 0, 0, 1], "pattern": 0}}]}]}`},
+		{"pattern texture", `{"version": 1, "patterns": [{"texture": 0, "wrap": "repeat"}], "canvases": [{"width": 1, "height": 1}]}`},
+		{"line cap", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "DrawLines", "pen": {"width": 1, "color": [0, 0, 0, 1], "cap": "arrow"}}]}]}`},
+		{"path op", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "StrokePath", "pen": {"width": 1, "color": [0, 0, 0, 1]}, "path": [{"op": "spline"}]}]}]}`},
+		{"path points", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "StrokePath", "pen": {"width": 1, "color": [0, 0, 0, 1]}, "path": [{"op": "quad", "points": [[0, 0]]}]}]}]}`},
+		{"fill rule", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "FillPath", "brush": {"color": [0, 0, 0, 1]}, "rule": "winding"}]}]}`},
+		{"missing matrix", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "Transform"}]}]}`},
+		{"missing opacity", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "PushLayer", "blend": "normal"}, {"op": "PopLayer"}]}]}`},
+		{"blend mode", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "PushLayer", "opacity": 1, "blend": "overlay"}, {"op": "PopLayer"}]}]}`},
+		{"layer popped", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "PushLayer", "opacity": 1, "blend": "normal"}, {"op": "Pop"}]}]}`},
+		{"layer not pushed", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "Push"}, {"op": "PopLayer"}]}]}`},
+		{"missing shadow", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "DrawShadow", "rect": [0, 0, 1, 1], "radii": [0, 0, 0, 0]}]}]}`},
+		{"missing blur", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "BlurBackdrop", "rect": [0, 0, 1, 1]}]}]}`},
+		{"line join", `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "DrawLines", "pen": {"width": 1, "color": [0, 0, 0, 1], "join": "arc"}}]}]}`},
+	} {
+		if _, err := DecodeDisplayListJSON(strings.NewReader(test.json), &testDriver{}, testFonts()); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	buffer.Reset()
+	if err := EncodeDisplayListJSON(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	json := strings.Replace(buffer.String(), `"wrap": "mirror"`, `"wrap": "spiral"`, 1)
+	if _, err := DecodeDisplayListJSON(strings.NewReader(json), &testDriver{}, testFonts(font)); err == nil || !strings.Contains(err.Error(), "spiral") {
+		t.Errorf("pattern wrap: expected an error about the wrap mode, got %v", err)
+	}
+}
+
+func TestDisplayListDecodeTruncatedBytes(t *testing.T) {
+	// A texture claiming the maximum length, followed by a few bytes only.
+	data := append([]byte{}, displayListMagic...)
+	for _, v := range []int64{DisplayListVersion, 0, 1, maxDisplayListLength} {
+		data = binary.AppendVarint(data, v)
+	}
+	data = append(data, "PNG"...)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := DecodeDisplayList(bytes.NewReader(data), &testDriver{}, testFonts())
+	runtime.ReadMemStats(&after)
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("%d bytes allocated decoding a truncated list", allocated)
+	}
+}
+
+func TestDisplayListDecodeNewerOp(t *testing.T) {
+	displayListOpVersions[OpBlurBackdrop] = DisplayListVersion + 1
+	defer delete(displayListOpVersions, OpBlurBackdrop)
+
+	list := CreateDisplayList(math.Size{Width: 1, Height: 1})
+	list.BlurBackdrop(math.CreateRect(0, 0, 1, 1), 2)
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := EncodeDisplayList(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeDisplayList(buffer, &testDriver{}, testFonts()); err == nil || !strings.Contains(err.Error(), "BlurBackdrop") {
+		t.Errorf("binary: expected an error about the op, got %v", err)
+	}
+
+	json := `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "BlurBackdrop", "rect": [0, 0, 1, 1], "blur": 2}]}]}`
+	if _, err := DecodeDisplayListJSON(strings.NewReader(json), &testDriver{}, testFonts()); err == nil || !strings.Contains(err.Error(), "BlurBackdrop") {
+		t.Errorf("JSON: expected an error about the op, got %v", err)
+	}
+}
+
+func TestDisplayListDecodeVersion1(t *testing.T) {
+	json := `{"version": 1, "canvases": [{"width": 1, "height": 1, "commands": [{"op": "DrawRect", "rect": [0, 0, 1, 1], "brush": {"color": [1, 0, 0, 1]}}]}]}`
+	decoded, err := DecodeDisplayListJSON(strings.NewReader(json), &testDriver{}, testFonts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, CreateBrush(Red), decoded.Commands()[0].Brush)
+}
+
+func TestRecordingDriver(t *testing.T) {
+	var frames []*DisplayList
+	inner := &testDriver{}
+	driver := CreateRecordingDriver(inner, func(viewport Viewport, frame *DisplayList) {
+		frames = append(frames, frame)
+	})
+
+	viewport := &recordingViewport{Viewport: &testViewport{}, driver: driver}
+	canvas := driver.CreateCanvas(math.Size{Width: 4, Height: 4})
+	canvas.DrawRect(math.CreateRect(0, 0, 2, 2), WhiteBrush)
+	canvas.Complete()
+	viewport.SetCanvas(canvas)
+
+	test_helper.AssertEquals(t, 1, len(frames))
+	test_helper.AssertEquals(t, canvas, Canvas(frames[0]))
+	test_helper.AssertEquals(t, frames[0].Commands(), viewport.Viewport.(*testViewport).canvas.(*DisplayList).Commands())
+}
+
+type testViewport struct {
+	Viewport
+	canvas Canvas
+	damage []math.Rect
+}
+
+func (v *testViewport) SetCanvas(canvas Canvas) { v.canvas = canvas }
+
+func (v *testViewport) SetCanvasDamage(canvas Canvas, damage []math.Rect) {
+	v.canvas, v.damage = canvas, damage
+}
@@ -30,6 +30,7 @@ type DrawPaintPart struct {
 	canvas          Canvas
 	dirty           bool
 	redrawRequested bool
+	redrawAll       bool // True once the whole control was reported as changed
 }
 
 func verifyDetach(parent DrawPaintParent) {
@@ -51,14 +52,50 @@ func (d *DrawPaintPart) Redraw() {
 	// TODO : @Badu - on desktop, why?
 	//d.driver.AssertUIGoroutine()
 
-	if !d.redrawRequested {
+	if !d.redrawAll {
 		if p := d.parent.Parent(); p != nil {
 			d.redrawRequested = true
-			p.Redraw()
+			d.redrawAll = true
+			d.redrawParent(p, d.parent.Size().Rect(), true)
 		}
 	}
 }
 
+// RedrawRect redraws the control, which only changed within rect.
+func (d *DrawPaintPart) RedrawRect(rect math.Rect) {
+	if !d.redrawAll {
+		if p := d.parent.Parent(); p != nil {
+			d.redrawRequested = true
+			d.redrawParent(p, rect.Overlap(d.parent.Size().Rect()), false)
+		}
+	}
+}
+
+// redrawParent redraws the parent of the control, which only changed within
+// rect. The shadow of the control, painted by the parent, changes along with
+// the whole control.
+func (d *DrawPaintPart) redrawParent(p Parent, rect math.Rect, whole bool) {
+	var child *Child
+	if control, ok := d.parent.(Control); ok {
+		child = p.Children().Find(control)
+	}
+	damageParent, ok := p.(DamageTrackingParent)
+	if !ok || child == nil {
+		p.Redraw()
+		return
+	}
+
+	if shadowed, ok := child.Control.(ShadowPainter); ok && whole {
+		rect = shadowed.ShadowBounds(rect)
+	}
+	if m, ok := childTransform(p, child); ok {
+		rect = m.TransformRect(rect)
+	} else {
+		rect = rect.Offset(child.Offset)
+	}
+	damageParent.RedrawRect(rect)
+}
+
 func (d *DrawPaintPart) Draw() Canvas {
 	if !d.parent.Attached() {
 		panic(fmt.Errorf("attempting to draw a non-attached control %T", d.parent))
@@ -72,6 +109,7 @@ func (d *DrawPaintPart) Draw() Canvas {
 	if d.canvas == nil || d.canvas.Size() != size || d.redrawRequested {
 		d.canvas = d.driver.CreateCanvas(size)
 		d.redrawRequested = false
+		d.redrawAll = false
 		d.parent.Paint(d.canvas)
 		d.canvas.Complete()
 	}
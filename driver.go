@@ -6,12 +6,19 @@ package gxui
 
 import (
 	"image"
+	"time"
 
 	"github.com/badu/gxui/pkg/math"
 )
 
-// A Font represents a TrueType font loaded by the GXUI driver.
+// A Font represents a TrueType or OpenType font loaded by the GXUI driver.
 type Font interface {
+	// Name returns the full name of the font face, as stored in the font file.
+	Name() string
+	// Data returns the bytes of the font as a single TrueType or OpenType font,
+	// decompressed and taken out of its collection when it was loaded from a
+	// WOFF or WOFF2 file or a font collection.
+	Data() []byte
 	LoadGlyphs(first, last rune)
 	Size() int
 	GlyphMaxSize() math.Size
@@ -19,14 +26,40 @@ type Font interface {
 	Layout(*TextBlock) (offsets []math.Point)
 }
 
-// TextBlock is a sequence of runes to be laid out.
+// A FontFamily is a Font drawing each rune with the first of its fonts holding
+// a glyph for it, so that runes missing from the first font, such as CJK or
+// symbols, are drawn by the fonts following it. The family is measured and
+// laid out with the metrics of its first font, whose name and data it reports.
+type FontFamily interface {
+	Font
+	// Fonts returns the fonts of the family, in the order they are tried.
+	Fonts() []Font
+}
+
+// TextBlock is a sequence of runes to be laid out. Each line is laid out in
+// the display order of the Unicode Bidirectional Algorithm, in the direction of
+// its first strong character, so right to left runs are laid out reversed.
 type TextBlock struct {
-	Runes     []rune
+	Runes []rune
+	// Runs splits the runes into runs laid out with their own fonts, when not
+	// empty. The runes following the runs are laid out with the font laying out
+	// the block, whose metrics place the first line.
+	Runs      []TextRun
 	AlignRect math.Rect
 	H         HAlign
 	V         VAlign
 }
 
+// TextRun is a sequence of runes of a TextBlock laid out with Font, or with the
+// font laying out the block when Font is nil, and raised BaselineOffset DIPs
+// above the baseline of its line. The lines are tall enough for the ascent and
+// descent of the fonts of their runs, once raised or lowered.
+type TextRun struct {
+	Runes          int
+	Font           Font
+	BaselineOffset int
+}
+
 type Canvas interface {
 	Size() math.Size
 	IsComplete() bool
@@ -34,6 +67,16 @@ type Canvas interface {
 	Push()
 	Pop()
 	AddClip(rect math.Rect)
+	// Transform applies m, in DIPs, to everything drawn and clipped afterwards,
+	// before the transforms already applied, until the Pop matching the last
+	// Push.
+	Transform(m math.Mat3)
+	// PushLayer pushes the draw state like Push, and draws everything until the
+	// matching PopLayer into a transparent layer. PopLayer then composites the
+	// layer with opacity and mode, so that what is drawn in the layer does not
+	// show through the layer itself.
+	PushLayer(opacity float32, mode BlendMode)
+	PopLayer()
 	Clear(color Color)
 	DrawCanvas(canvas Canvas, position math.Point)
 	DrawTexture(texture Texture, bounds math.Rect)
@@ -42,6 +85,15 @@ type Canvas interface {
 	DrawPolygon(polygon Polygon, pen Pen, brush Brush)
 	DrawRect(rect math.Rect, brush Brush)
 	DrawRoundedRect(rect math.Rect, tl, tr, bl, br float32, p Pen, b Brush)
+	// DrawShadow draws the shadow cast by the rounded rectangle rect, with the
+	// radii of its corners. The shadow is also drawn under the rectangle, which
+	// is expected to be filled afterwards.
+	DrawShadow(rect math.Rect, tl, tr, bl, br float32, shadow Shadow)
+	// BlurBackdrop blurs what was drawn under rect with a Gaussian blur of the
+	// radius, in DIPs, as the blur of a Shadow.
+	BlurBackdrop(rect math.Rect, radius float32)
+	FillPath(path *Path, rule FillRule, brush Brush)
+	StrokePath(path *Path, pen Pen)
 }
 
 type Viewport interface {
@@ -97,6 +149,21 @@ type Viewport interface {
 	// viewport will require a call to SetCanvas.
 	SetCanvas(canvas Canvas)
 
+	// SetCanvasDamage changes the displayed content of the viewport to the
+	// specified Canvas, like SetCanvas, where the canvas only differs from the
+	// previous one within the rectangles of damage, in device-independent
+	// pixels. The viewport only needs to redraw these rectangles, though it
+	// may still present the whole window, as the GL drivers do.
+	SetCanvasDamage(canvas Canvas, damage []math.Rect)
+
+	// RecordFrameTimes records the time the window took to lay out and paint
+	// the canvas set next, reported in the statistics of its frame.
+	RecordFrameTimes(layout, paint time.Duration)
+
+	// Stats returns the statistics of the recent frames of the viewport, and
+	// of the resources they use.
+	Stats() ViewportStats
+
 	// OnClose subscribes f to be called when the viewport closes.
 	OnClose(callback func()) EventSubscription
 
@@ -157,9 +224,34 @@ type Driver interface {
 	SetClipboard(content string)
 	GetClipboard() (content string, err error)
 
-	// CreateFont loads a font from the provided TrueType bytes.
+	// CreateFont loads a font from the provided bytes of a TrueType or OpenType
+	// font, with TrueType or CFF outlines, or of a WOFF or WOFF2 file. The first
+	// font of a font collection is loaded.
 	CreateFont(data []byte, size int) (Font, error)
 
+	// CreateCollectionFont loads the font at index of a TrueType or OpenType
+	// font collection (.ttc or .otc), or of a WOFF2 file holding one, as
+	// CreateFont does.
+	CreateCollectionFont(data []byte, index, size int) (Font, error)
+
+	// CreateFontFamily returns the family of the fonts, which must have been
+	// created by the driver. The fonts following the first are used at its size.
+	CreateFontFamily(fonts ...Font) (FontFamily, error)
+
+	// CreateDistanceFieldFont returns a copy of the font or font family, which
+	// must have been created by the driver, drawing its glyphs from signed
+	// distance fields. The fields of each font are rasterized once, into a
+	// single atlas, and drawn sharp at any scale, so scaling, zooming or
+	// animating the text does not rasterize it again. Small text is sharper
+	// drawn from the hinted glyphs of the font. Drivers rasterizing the glyphs
+	// at the size they are drawn at return the font.
+	CreateDistanceFieldFont(font Font) (Font, error)
+
+	// CreateResizedFont returns the font or font family, which must have been
+	// created by the driver, at size. The fonts created are kept, and returned
+	// again for the same font and size.
+	CreateResizedFont(font Font, size int) (Font, error)
+
 	// CreateWindowedViewport creates a new windowed Viewport with the specified width and height in device independent pixels.
 	CreateWindowedViewport(width, height int, name string) Viewport
 
@@ -171,6 +263,10 @@ type Driver interface {
 
 	CreateTexture(img image.Image, pixelsPerDip float32) Texture
 
+	// Stats returns the statistics of the resources of the driver, over all
+	// its viewports.
+	Stats() DriverStats
+
 	// Debug function used to verify that the caller is executing on the UI go-routine. If the caller is not on the UI go-routine then the function panics.
 	AssertUIGoroutine()
 }
@@ -0,0 +1,256 @@
+package cgo
+
+// functions binds the functions of the backend to the cgo OpenGL bindings.
+type functions struct {
+	fn *Functions
+}
+
+func (f functions) ActiveTexture(texture uint32) {
+	f.fn.ActiveTexture(Enum(texture))
+}
+
+func (f functions) AttachShader(program, shader uint32) {
+	f.fn.AttachShader(Program{V: uint(program)}, Shader{V: uint(shader)})
+}
+
+func (f functions) BindBuffer(target, buffer uint32) {
+	f.fn.BindBuffer(Enum(target), Buffer{V: uint(buffer)})
+}
+
+func (f functions) BindFramebuffer(target, framebuffer uint32) {
+	f.fn.BindFramebuffer(Enum(target), Framebuffer{V: uint(framebuffer)})
+}
+
+func (f functions) BindRenderbuffer(target, renderbuffer uint32) {
+	f.fn.BindRenderbuffer(Enum(target), Renderbuffer{V: uint(renderbuffer)})
+}
+
+func (f functions) BindTexture(target, texture uint32) {
+	f.fn.BindTexture(Enum(target), Texture{V: uint(texture)})
+}
+
+func (f functions) BlendFunc(sFactor, dFactor uint32) {
+	f.fn.BlendFunc(Enum(sFactor), Enum(dFactor))
+}
+
+func (f functions) BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	f.fn.BlendFuncSeparate(Enum(srcRGB), Enum(dstRGB), Enum(srcAlpha), Enum(dstAlpha))
+}
+
+func (f functions) BufferData(target uint32, data []byte, usage uint32) {
+	f.fn.BufferData(Enum(target), data, Enum(usage))
+}
+
+func (f functions) Clear(mask uint32) {
+	f.fn.Clear(Enum(mask))
+}
+
+func (f functions) ClearColor(red, green, blue, alpha float32) {
+	f.fn.ClearColor(red, green, blue, alpha)
+}
+
+func (f functions) ColorMask(red, green, blue, alpha bool) {
+	f.fn.ColorMask(red, green, blue, alpha)
+}
+
+func (f functions) CompileShader(shader uint32) {
+	f.fn.CompileShader(Shader{V: uint(shader)})
+}
+
+func (f functions) CopyTexSubImage2D(target uint32, level, xOffset, yOffset, x, y, width, height int32) {
+	f.fn.CopyTexSubImage2D(Enum(target), int(level), int(xOffset), int(yOffset), int(x), int(y), int(width), int(height))
+}
+
+func (f functions) CreateBuffer() uint32 {
+	return uint32(f.fn.CreateBuffer().V)
+}
+
+func (f functions) CreateFramebuffer() uint32 {
+	return uint32(f.fn.CreateFramebuffer().V)
+}
+
+func (f functions) CreateProgram() uint32 {
+	return uint32(f.fn.CreateProgram().V)
+}
+
+func (f functions) CreateRenderbuffer() uint32 {
+	return uint32(f.fn.CreateRenderbuffer().V)
+}
+
+func (f functions) CreateShader(shaderType uint32) uint32 {
+	return uint32(f.fn.CreateShader(Enum(shaderType)).V)
+}
+
+func (f functions) CreateTexture() uint32 {
+	return uint32(f.fn.CreateTexture().V)
+}
+
+func (f functions) DeleteBuffer(buffer uint32) {
+	f.fn.DeleteBuffer(Buffer{V: uint(buffer)})
+}
+
+func (f functions) DeleteFramebuffer(framebuffer uint32) {
+	f.fn.DeleteFramebuffer(Framebuffer{V: uint(framebuffer)})
+}
+
+func (f functions) DeleteProgram(program uint32) {
+	f.fn.DeleteProgram(Program{V: uint(program)})
+}
+
+func (f functions) DeleteRenderbuffer(renderbuffer uint32) {
+	f.fn.DeleteRenderbuffer(Renderbuffer{V: uint(renderbuffer)})
+}
+
+func (f functions) DeleteTexture(texture uint32) {
+	f.fn.DeleteTexture(Texture{V: uint(texture)})
+}
+
+func (f functions) Disable(capability uint32) {
+	f.fn.Disable(Enum(capability))
+}
+
+func (f functions) DisableVertexAttribArray(index uint32) {
+	f.fn.DisableVertexAttribArray(Attrib(index))
+}
+
+func (f functions) DrawArrays(mode uint32, first, count int) {
+	f.fn.DrawArrays(Enum(mode), first, count)
+}
+
+func (f functions) DrawElements(mode uint32, count int32, indexType uint32, offset int) {
+	f.fn.DrawElements(Enum(mode), int(count), Enum(indexType), offset)
+}
+
+func (f functions) Enable(capability uint32) {
+	f.fn.Enable(Enum(capability))
+}
+
+func (f functions) EnableVertexAttribArray(index uint32) {
+	f.fn.EnableVertexAttribArray(Attrib(index))
+}
+
+func (f functions) FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32) {
+	f.fn.FramebufferRenderbuffer(Enum(target), Enum(attachment), Enum(renderbufferTarget), Renderbuffer{V: uint(renderbuffer)})
+}
+
+func (f functions) FramebufferTexture2D(target, attachment, textureTarget, texture uint32, level int32) {
+	f.fn.FramebufferTexture2D(Enum(target), Enum(attachment), Enum(textureTarget), Texture{V: uint(texture)}, int(level))
+}
+
+func (f functions) GetActiveAttrib(program, index uint32) (string, int32, uint32) {
+	name, size, ty := f.fn.GetActiveAttrib(Program{V: uint(program)}, index)
+	return name, int32(size), uint32(ty)
+}
+
+func (f functions) GetActiveUniform(program, index uint32) (string, int32, uint32) {
+	name, size, ty := f.fn.GetActiveUniform(Program{V: uint(program)}, index)
+	return name, int32(size), uint32(ty)
+}
+
+func (f functions) GetAttribLocation(program uint32, name string) uint32 {
+	return uint32(f.fn.GetAttribLocation(Program{V: uint(program)}, name))
+}
+
+func (f functions) GetError() uint32 {
+	return uint32(f.fn.GetError())
+}
+
+func (f functions) GetProgramInfoLog(program uint32) string {
+	return f.fn.GetProgramInfoLog(Program{V: uint(program)})
+}
+
+func (f functions) GetProgrami(program, name uint32) int {
+	return f.fn.GetProgrami(Program{V: uint(program)}, Enum(name))
+}
+
+func (f functions) GetShaderInfoLog(shader uint32) string {
+	return f.fn.GetShaderInfoLog(Shader{V: uint(shader)})
+}
+
+func (f functions) GetShaderi(shader, name uint32) int {
+	return f.fn.GetShaderi(Shader{V: uint(shader)}, Enum(name))
+}
+
+func (f functions) GetUniformLocation(program uint32, name string) int32 {
+	return int32(f.fn.GetUniformLocation(Program{V: uint(program)}, name).V)
+}
+
+func (f functions) LinkProgram(program uint32) {
+	f.fn.LinkProgram(Program{V: uint(program)})
+}
+
+func (f functions) RenderbufferStorage(target, internalFormat uint32, width, height int32) {
+	f.fn.RenderbufferStorage(Enum(target), Enum(internalFormat), int(width), int(height))
+}
+
+func (f functions) Scissor(x, y, width, height int32) {
+	f.fn.Scissor(x, y, width, height)
+}
+
+func (f functions) ShaderSource(shader uint32, source string) {
+	f.fn.ShaderSource(Shader{V: uint(shader)}, source)
+}
+
+func (f functions) StencilFunc(function uint32, ref int32, mask uint32) {
+	f.fn.StencilFunc(Enum(function), ref, mask)
+}
+
+func (f functions) StencilOp(fail, zFail, zPass uint32) {
+	f.fn.StencilOp(Enum(fail), Enum(zFail), Enum(zPass))
+}
+
+func (f functions) TexImage2D(target uint32, level, width, height int32, format, pixelType uint32, pixels []byte) {
+	f.fn.TexImage2D(Enum(target), int(level), int(width), int(height), Enum(format), Enum(pixelType), pixels)
+}
+
+func (f functions) TexParameteri(target, name uint32, param int32) {
+	f.fn.TexParameteri(Enum(target), Enum(name), int(param))
+}
+
+func (f functions) Uniform1f(location int32, value float32) {
+	f.fn.Uniform1f(Uniform{V: int(location)}, value)
+}
+
+func (f functions) Uniform1fv(location int32, values []float32) {
+	f.fn.Uniform1fv(Uniform{V: int(location)}, values)
+}
+
+func (f functions) Uniform1i(location, value int32) {
+	f.fn.Uniform1i(Uniform{V: int(location)}, int(value))
+}
+
+func (f functions) Uniform2fv(location int32, values []float32) {
+	f.fn.Uniform2fv(Uniform{V: int(location)}, values)
+}
+
+func (f functions) Uniform3fv(location int32, values []float32) {
+	f.fn.Uniform3fv(Uniform{V: int(location)}, values)
+}
+
+func (f functions) Uniform4fv(location int32, values []float32) {
+	f.fn.Uniform4fv(Uniform{V: int(location)}, values)
+}
+
+func (f functions) UniformMatrix2fv(location int32, values []float32) {
+	f.fn.UniformMatrix2fv(Uniform{V: int(location)}, values)
+}
+
+func (f functions) UniformMatrix3fv(location int32, values []float32) {
+	f.fn.UniformMatrix3fv(Uniform{V: int(location)}, values)
+}
+
+func (f functions) UniformMatrix4fv(location int32, values []float32) {
+	f.fn.UniformMatrix4fv(Uniform{V: int(location)}, values)
+}
+
+func (f functions) UseProgram(program uint32) {
+	f.fn.UseProgram(Program{V: uint(program)})
+}
+
+func (f functions) VertexAttribPointer(index uint32, size int32, attribType uint32, normalized bool, stride int32, offset int) {
+	f.fn.VertexAttribPointer(Attrib(index), int(size), Enum(attribType), normalized, int(stride), offset)
+}
+
+func (f functions) Viewport(x, y, width, height int32) {
+	f.fn.Viewport(int(x), int(y), int(width), int(height))
+}
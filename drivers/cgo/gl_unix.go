@@ -132,6 +132,9 @@ typedef void  (*_glGetActiveUniform)(GLuint  program, GLuint  index, GLsizei  bu
 typedef void  (*_glGetActiveAttrib)(GLuint  program, GLuint  index, GLsizei  bufSize, GLsizei * length, GLint * size, GLenum * type, GLchar * name);
 typedef GLint  (*_glGetAttribLocation)(GLuint  program, const GLchar * name);
 typedef void  (*_glBlendFunc)(GLenum  sfactor, GLenum  dfactor);
+typedef void  (*_glColorMask)(GLboolean  red, GLboolean  green, GLboolean  blue, GLboolean  alpha);
+typedef void  (*_glStencilFunc)(GLenum  func, GLint  ref, GLuint  mask);
+typedef void  (*_glStencilOp)(GLenum  fail, GLenum  zfail, GLenum  zpass);
 typedef void  (*_glUniformMatrix2fv)(GLint  location, GLsizei  count, GLboolean  transpose, const GLfloat * value);
 typedef void  (*_glUniformMatrix3fv)(GLint  location, GLsizei  count, GLboolean  transpose, const GLfloat * value);
 typedef void  (*_glUniformMatrix4fv)(GLint  location, GLsizei  count, GLboolean  transpose, const GLfloat * value);
@@ -544,6 +547,18 @@ static void  glowBlendFunc(_glBlendFunc f, GLenum  sfactor, GLenum  dfactor) {
    f(sfactor, dfactor);
 }
 
+static void  glowColorMask(_glColorMask f, GLboolean  red, GLboolean  green, GLboolean  blue, GLboolean  alpha) {
+   f(red, green, blue, alpha);
+}
+
+static void  glowStencilFunc(_glStencilFunc f, GLenum  func, GLint  ref, GLuint  mask) {
+   f(func, ref, mask);
+}
+
+static void  glowStencilOp(_glStencilOp f, GLenum  fail, GLenum  zfail, GLenum  zpass) {
+   f(fail, zfail, zpass);
+}
+
 static void  glowUniformMatrix2fv(_glUniformMatrix2fv f, GLint  location, GLsizei  count, GLboolean  transpose, const GLfloat * value) {
    f(location, count, transpose, value);
 }
@@ -680,6 +695,9 @@ type Functions struct {
 	glowGetActiveAttrib   C._glGetActiveAttrib
 	glowGetAttribLocation C._glGetAttribLocation
 	glowBlendFunc         C._glBlendFunc
+	glowColorMask         C._glColorMask
+	glowStencilFunc       C._glStencilFunc
+	glowStencilOp         C._glStencilOp
 	glowUniformMatrix2fv  C._glUniformMatrix2fv
 	glowUniformMatrix3fv  C._glUniformMatrix3fv
 	glowUniformMatrix4fv  C._glUniformMatrix4fv
@@ -889,6 +907,9 @@ func (fn *Functions) load(forceES bool) error {
 	fn.glowGetActiveAttrib = must("glGetActiveAttrib")
 	fn.glowGetAttribLocation = must("glGetAttribLocation")
 	fn.glowBlendFunc = must("glBlendFunc")
+	fn.glowColorMask = must("glColorMask")
+	fn.glowStencilFunc = must("glStencilFunc")
+	fn.glowStencilOp = must("glStencilOp")
 	fn.glowUniformMatrix2fv = must("glUniformMatrix2fv")
 	fn.glowUniformMatrix3fv = must("glUniformMatrix3fv")
 	fn.glowUniformMatrix4fv = must("glUniformMatrix4fv")
@@ -1418,6 +1439,28 @@ func (fn *Functions) BlendFunc(sfactor, dfactor Enum) {
 	C.glowBlendFunc(fn.glowBlendFunc, C.GLenum(sfactor), C.GLenum(dfactor))
 }
 
+// ColorMask sets which color components are written to the framebuffer.
+func (fn *Functions) ColorMask(red, green, blue, alpha bool) {
+	glBool := func(b bool) C.GLboolean {
+		if b {
+			return C.GLboolean(TRUE)
+		}
+		return C.GLboolean(FALSE)
+	}
+	C.glowColorMask(fn.glowColorMask, glBool(red), glBool(green), glBool(blue), glBool(alpha))
+}
+
+// StencilFunc sets the test comparing ref to the stencil of the pixels drawn.
+func (fn *Functions) StencilFunc(fun Enum, ref int32, mask uint32) {
+	C.glowStencilFunc(fn.glowStencilFunc, C.GLenum(fun), C.GLint(ref), C.GLuint(mask))
+}
+
+// StencilOp sets the actions taken on the stencil when a pixel fails the
+// stencil test, fails the depth test, or passes both.
+func (fn *Functions) StencilOp(fail, zfail, zpass Enum) {
+	C.glowStencilOp(fn.glowStencilOp, C.GLenum(fail), C.GLenum(zfail), C.GLenum(zpass))
+}
+
 // GetActiveUniform returns details about an active uniform variable.
 // A value of 0 for index selects the first active uniform variable.
 // Permissible values for index range from 0 to the number of active
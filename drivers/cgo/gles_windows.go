@@ -104,6 +104,9 @@ var (
 	_glGetActiveAttrib   = LibGLESv2.NewProc("glGetActiveAttrib")
 	_glGetAttribLocation = LibGLESv2.NewProc("glGetAttribLocation")
 	_glBlendFunc         = LibGLESv2.NewProc("glBlendFunc")
+	_glColorMask         = LibGLESv2.NewProc("glColorMask")
+	_glStencilFunc       = LibGLESv2.NewProc("glStencilFunc")
+	_glStencilOp         = LibGLESv2.NewProc("glStencilOp")
 	_glUniformMatrix2fv  = LibGLESv2.NewProc("glUniformMatrix2fv")
 	_glUniformMatrix3fv  = LibGLESv2.NewProc("glUniformMatrix3fv")
 	_glUniformMatrix4fv  = LibGLESv2.NewProc("glUniformMatrix4fv")
@@ -524,6 +527,28 @@ func (fn *Functions) BlendFunc(sfactor, dfactor Enum) {
 	syscall.Syscall(_glBlendFunc.Addr(), 2, uintptr(sfactor), uintptr(dfactor), 0)
 }
 
+// ColorMask sets which color components are written to the framebuffer.
+func (fn *Functions) ColorMask(red, green, blue, alpha bool) {
+	glBool := func(b bool) uintptr {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	syscall.Syscall6(_glColorMask.Addr(), 4, glBool(red), glBool(green), glBool(blue), glBool(alpha), 0, 0)
+}
+
+// StencilFunc sets the test comparing ref to the stencil of the pixels drawn.
+func (fn *Functions) StencilFunc(fun Enum, ref int32, mask uint32) {
+	syscall.Syscall(_glStencilFunc.Addr(), 3, uintptr(fun), uintptr(ref), uintptr(mask))
+}
+
+// StencilOp sets the actions taken on the stencil when a pixel fails the
+// stencil test, fails the depth test, or passes both.
+func (fn *Functions) StencilOp(fail, zfail, zpass Enum) {
+	syscall.Syscall(_glStencilOp.Addr(), 3, uintptr(fail), uintptr(zfail), uintptr(zpass))
+}
+
 // GetActiveUniform returns details about an active uniform variable.
 // A value of 0 for index selects the first active uniform variable.
 // Permissible values for index range from 0 to the number of active
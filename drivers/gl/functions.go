@@ -0,0 +1,256 @@
+package gl
+
+import "github.com/goxjs/gl"
+
+// functions binds the functions of the backend to the goxjs OpenGL bindings.
+type functions struct{}
+
+func (functions) ActiveTexture(texture uint32) {
+	gl.ActiveTexture(gl.Enum(texture))
+}
+
+func (functions) AttachShader(program, shader uint32) {
+	gl.AttachShader(gl.Program{Value: program}, gl.Shader{Value: shader})
+}
+
+func (functions) BindBuffer(target, buffer uint32) {
+	gl.BindBuffer(gl.Enum(target), gl.Buffer{Value: buffer})
+}
+
+func (functions) BindFramebuffer(target, framebuffer uint32) {
+	gl.BindFramebuffer(gl.Enum(target), gl.Framebuffer{Value: framebuffer})
+}
+
+func (functions) BindRenderbuffer(target, renderbuffer uint32) {
+	gl.BindRenderbuffer(gl.Enum(target), gl.Renderbuffer{Value: renderbuffer})
+}
+
+func (functions) BindTexture(target, texture uint32) {
+	gl.BindTexture(gl.Enum(target), gl.Texture{Value: texture})
+}
+
+func (functions) BlendFunc(sFactor, dFactor uint32) {
+	gl.BlendFunc(gl.Enum(sFactor), gl.Enum(dFactor))
+}
+
+func (functions) BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	gl.BlendFuncSeparate(gl.Enum(srcRGB), gl.Enum(dstRGB), gl.Enum(srcAlpha), gl.Enum(dstAlpha))
+}
+
+func (functions) BufferData(target uint32, data []byte, usage uint32) {
+	gl.BufferData(gl.Enum(target), data, gl.Enum(usage))
+}
+
+func (functions) Clear(mask uint32) {
+	gl.Clear(gl.Enum(mask))
+}
+
+func (functions) ClearColor(red, green, blue, alpha float32) {
+	gl.ClearColor(red, green, blue, alpha)
+}
+
+func (functions) ColorMask(red, green, blue, alpha bool) {
+	gl.ColorMask(red, green, blue, alpha)
+}
+
+func (functions) CompileShader(shader uint32) {
+	gl.CompileShader(gl.Shader{Value: shader})
+}
+
+func (functions) CopyTexSubImage2D(target uint32, level, xOffset, yOffset, x, y, width, height int32) {
+	gl.CopyTexSubImage2D(gl.Enum(target), int(level), int(xOffset), int(yOffset), int(x), int(y), int(width), int(height))
+}
+
+func (functions) CreateBuffer() uint32 {
+	return gl.CreateBuffer().Value
+}
+
+func (functions) CreateFramebuffer() uint32 {
+	return gl.CreateFramebuffer().Value
+}
+
+func (functions) CreateProgram() uint32 {
+	return gl.CreateProgram().Value
+}
+
+func (functions) CreateRenderbuffer() uint32 {
+	return gl.CreateRenderbuffer().Value
+}
+
+func (functions) CreateShader(shaderType uint32) uint32 {
+	return gl.CreateShader(gl.Enum(shaderType)).Value
+}
+
+func (functions) CreateTexture() uint32 {
+	return gl.CreateTexture().Value
+}
+
+func (functions) DeleteBuffer(buffer uint32) {
+	gl.DeleteBuffer(gl.Buffer{Value: buffer})
+}
+
+func (functions) DeleteFramebuffer(framebuffer uint32) {
+	gl.DeleteFramebuffer(gl.Framebuffer{Value: framebuffer})
+}
+
+func (functions) DeleteProgram(program uint32) {
+	gl.DeleteProgram(gl.Program{Value: program})
+}
+
+func (functions) DeleteRenderbuffer(renderbuffer uint32) {
+	gl.DeleteRenderbuffer(gl.Renderbuffer{Value: renderbuffer})
+}
+
+func (functions) DeleteTexture(texture uint32) {
+	gl.DeleteTexture(gl.Texture{Value: texture})
+}
+
+func (functions) Disable(capability uint32) {
+	gl.Disable(gl.Enum(capability))
+}
+
+func (functions) DisableVertexAttribArray(index uint32) {
+	gl.DisableVertexAttribArray(gl.Attrib{Value: uint(index)})
+}
+
+func (functions) DrawArrays(mode uint32, first, count int) {
+	gl.DrawArrays(gl.Enum(mode), first, count)
+}
+
+func (functions) DrawElements(mode uint32, count int32, indexType uint32, offset int) {
+	gl.DrawElements(gl.Enum(mode), int(count), gl.Enum(indexType), offset)
+}
+
+func (functions) Enable(capability uint32) {
+	gl.Enable(gl.Enum(capability))
+}
+
+func (functions) EnableVertexAttribArray(index uint32) {
+	gl.EnableVertexAttribArray(gl.Attrib{Value: uint(index)})
+}
+
+func (functions) FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32) {
+	gl.FramebufferRenderbuffer(gl.Enum(target), gl.Enum(attachment), gl.Enum(renderbufferTarget), gl.Renderbuffer{Value: renderbuffer})
+}
+
+func (functions) FramebufferTexture2D(target, attachment, textureTarget, texture uint32, level int32) {
+	gl.FramebufferTexture2D(gl.Enum(target), gl.Enum(attachment), gl.Enum(textureTarget), gl.Texture{Value: texture}, int(level))
+}
+
+func (functions) GetActiveAttrib(program, index uint32) (string, int32, uint32) {
+	name, size, ty := gl.GetActiveAttrib(gl.Program{Value: program}, index)
+	return name, int32(size), uint32(ty)
+}
+
+func (functions) GetActiveUniform(program, index uint32) (string, int32, uint32) {
+	name, size, ty := gl.GetActiveUniform(gl.Program{Value: program}, index)
+	return name, int32(size), uint32(ty)
+}
+
+func (functions) GetAttribLocation(program uint32, name string) uint32 {
+	return uint32(gl.GetAttribLocation(gl.Program{Value: program}, name).Value)
+}
+
+func (functions) GetError() uint32 {
+	return uint32(gl.GetError())
+}
+
+func (functions) GetProgramInfoLog(program uint32) string {
+	return gl.GetProgramInfoLog(gl.Program{Value: program})
+}
+
+func (functions) GetProgrami(program, name uint32) int {
+	return gl.GetProgrami(gl.Program{Value: program}, gl.Enum(name))
+}
+
+func (functions) GetShaderInfoLog(shader uint32) string {
+	return gl.GetShaderInfoLog(gl.Shader{Value: shader})
+}
+
+func (functions) GetShaderi(shader, name uint32) int {
+	return gl.GetShaderi(gl.Shader{Value: shader}, gl.Enum(name))
+}
+
+func (functions) GetUniformLocation(program uint32, name string) int32 {
+	return gl.GetUniformLocation(gl.Program{Value: program}, name).Value
+}
+
+func (functions) LinkProgram(program uint32) {
+	gl.LinkProgram(gl.Program{Value: program})
+}
+
+func (functions) RenderbufferStorage(target, internalFormat uint32, width, height int32) {
+	gl.RenderbufferStorage(gl.Enum(target), gl.Enum(internalFormat), int(width), int(height))
+}
+
+func (functions) Scissor(x, y, width, height int32) {
+	gl.Scissor(x, y, width, height)
+}
+
+func (functions) ShaderSource(shader uint32, source string) {
+	gl.ShaderSource(gl.Shader{Value: shader}, source)
+}
+
+func (functions) StencilFunc(function uint32, ref int32, mask uint32) {
+	gl.StencilFunc(gl.Enum(function), int(ref), mask)
+}
+
+func (functions) StencilOp(fail, zFail, zPass uint32) {
+	gl.StencilOp(gl.Enum(fail), gl.Enum(zFail), gl.Enum(zPass))
+}
+
+func (functions) TexImage2D(target uint32, level, width, height int32, format, pixelType uint32, pixels []byte) {
+	gl.TexImage2D(gl.Enum(target), int(level), int(width), int(height), gl.Enum(format), gl.Enum(pixelType), pixels)
+}
+
+func (functions) TexParameteri(target, name uint32, param int32) {
+	gl.TexParameteri(gl.Enum(target), gl.Enum(name), int(param))
+}
+
+func (functions) Uniform1f(location int32, value float32) {
+	gl.Uniform1f(gl.Uniform{Value: location}, value)
+}
+
+func (functions) Uniform1fv(location int32, values []float32) {
+	gl.Uniform1fv(gl.Uniform{Value: location}, values)
+}
+
+func (functions) Uniform1i(location, value int32) {
+	gl.Uniform1i(gl.Uniform{Value: location}, int(value))
+}
+
+func (functions) Uniform2fv(location int32, values []float32) {
+	gl.Uniform2fv(gl.Uniform{Value: location}, values)
+}
+
+func (functions) Uniform3fv(location int32, values []float32) {
+	gl.Uniform3fv(gl.Uniform{Value: location}, values)
+}
+
+func (functions) Uniform4fv(location int32, values []float32) {
+	gl.Uniform4fv(gl.Uniform{Value: location}, values)
+}
+
+func (functions) UniformMatrix2fv(location int32, values []float32) {
+	gl.UniformMatrix2fv(gl.Uniform{Value: location}, values)
+}
+
+func (functions) UniformMatrix3fv(location int32, values []float32) {
+	gl.UniformMatrix3fv(gl.Uniform{Value: location}, values)
+}
+
+func (functions) UniformMatrix4fv(location int32, values []float32) {
+	gl.UniformMatrix4fv(gl.Uniform{Value: location}, values)
+}
+
+func (functions) UseProgram(program uint32) {
+	gl.UseProgram(gl.Program{Value: program})
+}
+
+func (functions) VertexAttribPointer(index uint32, size int32, attribType uint32, normalized bool, stride int32, offset int) {
+	gl.VertexAttribPointer(gl.Attrib{Value: uint(index)}, int(size), gl.Enum(attribType), normalized, int(stride), offset)
+}
+
+func (functions) Viewport(x, y, width, height int32) {
+	gl.Viewport(int(x), int(y), int(width), int(height))
+}
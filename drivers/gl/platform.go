@@ -0,0 +1,106 @@
+package gl
+
+import (
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/drivers/glbackend"
+	"github.com/goxjs/glfw"
+)
+
+// platform creates the GLFW windows of the driver.
+type platform struct{}
+
+func (platform) CreateWindow(width, height int, title string, fullscreen bool) (glbackend.Window, error) {
+	glfw.DefaultWindowHints()
+	glfw.WindowHint(glfw.Samples, 4)
+
+	var monitor *glfw.Monitor
+	if fullscreen {
+		monitor = glfw.GetPrimaryMonitor()
+		if width == 0 || height == 0 {
+			vm := monitor.GetVideoMode()
+			width, height = vm.Width, vm.Height
+		}
+	}
+
+	wnd, err := glfw.CreateWindow(width, height, title, monitor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return window{wnd}, nil
+}
+
+func (platform) WaitEvents() {
+	glfw.WaitEvents()
+}
+
+func (platform) PostEmptyEvent() {
+	glfw.PostEmptyEvent()
+}
+
+// window adapts a GLFW window to the backend.
+type window struct {
+	*glfw.Window
+}
+
+func (w window) SetCallbacks(callbacks glbackend.WindowCallbacks) {
+	w.SetCloseCallback(func(*glfw.Window) { callbacks.Close() })
+	w.SetPosCallback(func(_ *glfw.Window, x, y int) { callbacks.Pos(x, y) })
+	w.SetSizeCallback(func(_ *glfw.Window, width, height int) { callbacks.Size(width, height) })
+	w.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) { callbacks.FramebufferSize(width, height) })
+	w.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) { callbacks.CursorPos(x, y) })
+	w.SetCursorEnterCallback(func(_ *glfw.Window, entered bool) { callbacks.CursorEnter(entered) })
+	w.SetScrollCallback(func(_ *glfw.Window, xOffset, yOffset float64) { callbacks.Scroll(xOffset, yOffset) })
+	w.SetMouseButtonCallback(
+		func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+			callbacks.MouseButton(translateMouseButton(button), translateAction(action), translateKeyboardModifier(mods))
+		},
+	)
+	w.SetKeyCallback(
+		func(_ *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			callbacks.Key(translateKeyboardKey(key), translateAction(action), translateKeyboardModifier(mods))
+		},
+	)
+	w.SetCharModsCallback(func(_ *glfw.Window, char rune, mods glfw.ModifierKey) {
+		callbacks.Char(char, translateKeyboardModifier(mods))
+	})
+	w.SetRefreshCallback(func(*glfw.Window) { callbacks.Refresh() })
+}
+
+func (w window) Size() (int, int) {
+	return w.GetSize()
+}
+
+func (w window) FramebufferSize() (int, int) {
+	return w.GetFramebufferSize()
+}
+
+func (w window) Pos() (int, int) {
+	return w.GetPos()
+}
+
+func (w window) CursorPos() (float64, float64) {
+	return w.GetCursorPos()
+}
+
+func (w window) MouseState() gxui.MouseState {
+	return getMouseState(w.Window)
+}
+
+func (w window) Clipboard() string {
+	return w.GetClipboardString()
+}
+
+func (w window) SetClipboard(str string) {
+	w.SetClipboardString(str)
+}
+
+func translateAction(action glfw.Action) glbackend.Action {
+	switch action {
+	case glfw.Press:
+		return glbackend.Press
+	case glfw.Repeat:
+		return glbackend.Repeat
+	default:
+		return glbackend.Release
+	}
+}
@@ -0,0 +1,130 @@
+package glbackend
+
+import (
+	"github.com/badu/gxui/pkg/geometry"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// Width of the fringe around the edges of shapes, in pixels. The coverage of
+// the fringe fades from opaque on the edge to transparent, which smooths the
+// edges without multisampling.
+const fringeWidth = 1
+
+// Distance, in DIPs, from the middle of an edge to the point tested to find
+// whether another triangle is on the other side.
+const fringeProbe = 0.01
+
+// fringeEdge is an edge of the area covered by triangles, with its normal
+// pointing out of the area.
+type fringeEdge struct {
+	a, b   math.Vec2
+	normal math.Vec2
+}
+
+type edgeKey [2]math.Vec2
+
+func newEdgeKey(a, b math.Vec2) edgeKey {
+	if a.X < b.X || a.X == b.X && a.Y < b.Y {
+		return edgeKey{a, b}
+	}
+	return edgeKey{b, a}
+}
+
+// fringeEdges returns the edges around the area covered by the triangles. The
+// edges shared by two triangles are inside the area, as are the edges with
+// another triangle just on the other side if the triangles are overlapping.
+// Degenerate triangles are ignored.
+func fringeEdges(triangles []math.Vec2, overlapping bool) []fringeEdge {
+	var solid []int
+	counts := map[edgeKey]int{}
+	for i := 0; i+3 <= len(triangles); i += 3 {
+		a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+		if math32.Abs(b.Sub(a).Cross(c.Sub(a))) <= geometry.Epsilon {
+			continue
+		}
+		solid = append(solid, i)
+		counts[newEdgeKey(a, b)]++
+		counts[newEdgeKey(b, c)]++
+		counts[newEdgeKey(c, a)]++
+	}
+
+	covered := func(p math.Vec2, skip int) bool {
+		if !overlapping {
+			return false
+		}
+		for _, i := range solid {
+			a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+			if i != skip && geometry.InTriangle(p, a, b, c) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var result []fringeEdge
+	for _, i := range solid {
+		for j := 0; j < 3; j++ {
+			a, b, c := triangles[i+j], triangles[i+(j+1)%3], triangles[i+(j+2)%3]
+			if counts[newEdgeKey(a, b)] > 1 {
+				continue
+			}
+			normal := b.Sub(a).Normalize().Tangent()
+			if normal.Dot(c.Sub(a)) > 0 {
+				normal = normal.MulS(-1)
+			}
+			if covered(a.Add(b).MulS(0.5).Add(normal.MulS(fringeProbe)), i) {
+				continue
+			}
+			result = append(result, fringeEdge{a: a, b: b, normal: normal})
+		}
+	}
+	return result
+}
+
+// antialias returns the vertices of the triangles followed by the fringe
+// around their edges, with the direction each vertex is moved by the fringe
+// width and the coverage of the vertex. The fringe of the edges meeting at a
+// point is mitered, so that there are no gaps or overlaps at the corners.
+func antialias(triangles []math.Vec2, overlapping bool) (positions, fringes, coverages []float32) {
+	edges := fringeEdges(triangles, overlapping)
+
+	normals := map[math.Vec2]math.Vec2{}
+	for _, e := range edges {
+		normals[e.a] = normals[e.a].Add(e.normal)
+		normals[e.b] = normals[e.b].Add(e.normal)
+	}
+	extrude := func(p math.Vec2, e fringeEdge) math.Vec2 {
+		n := normals[p]
+		if n.Len() < 0.001 {
+			return e.normal
+		}
+		n = n.Normalize()
+		// Keep the miter of sharp corners within a few pixels.
+		return n.DivS(max(n.Dot(e.normal), 0.25))
+	}
+
+	count := len(triangles) + len(edges)*6
+	positions = make([]float32, 0, count*2)
+	fringes = make([]float32, 0, count*2)
+	coverages = make([]float32, 0, count)
+	add := func(p, fringe math.Vec2, coverage float32) {
+		positions = append(positions, p.X, p.Y)
+		fringes = append(fringes, fringe.X, fringe.Y)
+		coverages = append(coverages, coverage)
+	}
+
+	for _, p := range triangles {
+		add(p, math.Vec2{}, 1)
+	}
+	for _, e := range edges {
+		ea, eb := extrude(e.a, e), extrude(e.b, e)
+		add(e.a, math.Vec2{}, 1)
+		add(e.b, math.Vec2{}, 1)
+		add(e.b, eb, 0)
+		add(e.a, math.Vec2{}, 1)
+		add(e.b, eb, 0)
+		add(e.a, ea, 0)
+	}
+	return positions, fringes, coverages
+}
@@ -0,0 +1,58 @@
+package glbackend
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+func v(a, b float32) math.Vec2 {
+	return math.Vec2{X: a, Y: b}
+}
+
+func TestFringeEdges(t *testing.T) {
+	square := []math.Vec2{
+		v(0, 0), v(10, 0), v(10, 10),
+		v(0, 0), v(10, 10), v(0, 10),
+	}
+	edges := fringeEdges(square, false)
+	test_helper.AssertEquals(t, 4, len(edges))
+	for _, e := range edges {
+		if out := e.a.Add(e.b).MulS(0.5).Add(e.normal).Sub(v(5, 5)); out.Len() < 5.5 {
+			t.Errorf("Expected the normal of %v-%v to point out of the square, got %v", e.a, e.b, e.normal)
+		}
+	}
+
+	// The edges of a triangle inside the square are covered by it.
+	inner := append([]math.Vec2{v(2, 2), v(8, 2), v(5, 8)}, square...)
+	test_helper.AssertEquals(t, 4, len(fringeEdges(inner, true)))
+	test_helper.AssertEquals(t, 7, len(fringeEdges(inner, false)))
+
+	// Degenerate triangles have no edges.
+	test_helper.AssertEquals(t, 0, len(fringeEdges([]math.Vec2{v(0, 0), v(5, 0), v(10, 0)}, false)))
+}
+
+func TestAntialias(t *testing.T) {
+	square := []math.Vec2{
+		v(0, 0), v(10, 0), v(10, 10),
+		v(0, 0), v(10, 10), v(0, 10),
+	}
+	positions, fringes, coverages := antialias(square, false)
+	// The square, then two triangles for each edge.
+	test_helper.AssertEquals(t, (6+4*6)*2, len(positions))
+	test_helper.AssertEquals(t, len(positions), len(fringes))
+	test_helper.AssertEquals(t, len(positions)/2, len(coverages))
+
+	for i, c := range coverages {
+		p := v(positions[i*2], positions[i*2+1])
+		f := v(fringes[i*2], fringes[i*2+1])
+		switch {
+		case c == 1 && f != (math.Vec2{}):
+			t.Errorf("Expected opaque vertex %d to stay in place, got moved by %v", i, f)
+		case c == 0 && p == v(0, 0) && f.Sub(v(-1, -1)).Len() > 0.001:
+			// The corners are mitered.
+			t.Errorf("Expected the corner to be moved by (-1, -1), got %v", f)
+		}
+	}
+}
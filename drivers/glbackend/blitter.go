@@ -0,0 +1,953 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
+
+import (
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+const (
+	debugWireframePolygons = false
+
+	vsCopySrc = `
+  attribute vec2 aPosition;
+  varying vec2 vTexcoords;
+  uniform mat3 mPos;
+  uniform mat3 mUV;
+  void main() {
+    vec3 pos3 = vec3(aPosition, 1.0);
+    gl_Position = vec4(mPos * pos3, 1.0);
+    vTexcoords = (mUV * pos3).xy;
+  }`
+
+	fsCopySrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform sampler2D source;
+  varying vec2 vTexcoords;
+  void main() {
+    gl_FragColor = texture2D(source, vTexcoords);
+  }`
+
+	// The vertices of the fringe of shapes are moved out by aFringe times the
+	// width of the fringe, in DIPs, and fade out with aCoverage.
+	fsLayerSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform sampler2D source;
+  uniform float opacity;
+  varying vec2 vTexcoords;
+  void main() {
+    gl_FragColor = texture2D(source, vTexcoords) * opacity;
+  }`
+
+	vsShadowSrc = `
+  attribute vec2 aPosition;
+  varying vec2 vPosition;
+  uniform mat3 mPos;
+  uniform mat3 mLocal;
+  void main() {
+    vec3 pos3 = vec3(aPosition, 1.0);
+    gl_Position = vec4((mPos * pos3).xy, 0.0, 1.0);
+    vPosition = (mLocal * pos3).xy;
+  }`
+
+	// The shadow of the rounded rectangle box is its coverage convolved with a
+	// Gaussian, which is integrated exactly along the rows with erf, and
+	// sampled along the columns. radii holds the top-left, top-right,
+	// bottom-left and bottom-right radii of the corners. Everything is in DIPs.
+	fsShadowSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform vec4 box;
+  uniform vec4 radii;
+  uniform float sigma;
+  uniform vec4 Color;
+  varying vec2 vPosition;
+
+  vec2 erf(vec2 x) {
+    vec2 s = sign(x), a = abs(x);
+    x = 1.0 + (0.278393 + (0.230389 + 0.078108 * (a * a)) * a) * a;
+    x *= x;
+    return s - s / (x * x);
+  }
+
+  float gaussian(float x) {
+    return exp(-(x * x) / (2.0 * sigma * sigma)) / (2.5066283 * sigma);
+  }
+
+  // The coverage of the row y, blurred along the row at x.
+  float row(float x, float y, float corner, vec2 halfSize) {
+    float delta = min(halfSize.y - corner - abs(y), 0.0);
+    float curved = halfSize.x - corner + sqrt(max(0.0, corner * corner - delta * delta));
+    vec2 integral = 0.5 + 0.5 * erf((x + vec2(-curved, curved)) * (0.7071068 / sigma));
+    return integral.y - integral.x;
+  }
+
+  void main() {
+    vec2 halfSize = (box.zw - box.xy) * 0.5;
+    vec2 p = vPosition - (box.xy + box.zw) * 0.5;
+    float corner = p.y < 0.0 ? (p.x < 0.0 ? radii.x : radii.y) : (p.x < 0.0 ? radii.z : radii.w);
+
+    float start = clamp(-3.0 * sigma, p.y - halfSize.y, p.y + halfSize.y);
+    float end = clamp(3.0 * sigma, p.y - halfSize.y, p.y + halfSize.y);
+    float dy = (end - start) / 4.0;
+    float y = start + dy * 0.5;
+    float coverage = 0.0;
+    for (int i = 0; i < 4; i++) {
+      coverage += row(p.x, p.y - y, corner, halfSize) * gaussian(y) * dy;
+      y += dy;
+    }
+    gl_FragColor = vec4(Color.rgb * Color.a, Color.a) * coverage;
+  }`
+
+	vsBlurSrc = `
+  attribute vec2 aPosition;
+  uniform mat3 mPos;
+  void main() {
+    gl_Position = vec4((mPos * vec3(aPosition, 1.0)).xy, 0.0, 1.0);
+  }`
+
+	// The blur samples source, the size of the window, along direction in
+	// blurTaps steps of at least a pixel each way, with a standard deviation
+	// of sigma pixels.
+	fsBlurSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform sampler2D source;
+  uniform vec2 size;
+  uniform vec2 direction;
+  uniform float sigma;
+  void main() {
+    vec2 uv = gl_FragCoord.xy / size;
+    float spacing = max(1.0, 3.0 * sigma / 32.0);
+    vec4 sum = vec4(0.0);
+    float total = 0.0;
+    for (int i = -32; i <= 32; i++) {
+      float x = float(i) * spacing;
+      float weight = exp(-(x * x) / (2.0 * sigma * sigma));
+      sum += texture2D(source, uv + direction * x) * weight;
+      total += weight;
+    }
+    gl_FragColor = sum / total;
+  }`
+
+	vsColorSrc = `
+  attribute vec2 aPosition;
+  attribute vec2 aFringe;
+  attribute float aCoverage;
+  varying float vCoverage;
+  uniform mat3 mPos;
+  uniform float fringe;
+  void main() {
+    vec3 pos3 = vec3(aPosition + aFringe * fringe, 1.0);
+    gl_Position = vec4((mPos * pos3).xy, 0.0, 1.0);
+    vCoverage = aCoverage;
+  }`
+
+	fsColorSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform vec4 Color;
+  varying float vCoverage;
+  void main() {
+    gl_FragColor = Color;
+    gl_FragColor *= gl_FragColor.a * vCoverage; // PMA
+  }`
+
+	vsGradientSrc = `
+  attribute vec2 aPosition;
+  attribute vec2 aFringe;
+  attribute float aCoverage;
+  varying vec2 vGradient;
+  varying float vCoverage;
+  uniform mat3 mPos;
+  uniform mat3 mGradient;
+  uniform float fringe;
+  void main() {
+    vec3 pos3 = vec3(aPosition + aFringe * fringe, 1.0);
+    gl_Position = vec4((mPos * pos3).xy, 0.0, 1.0);
+    vGradient = (mGradient * pos3).xy;
+    vCoverage = aCoverage;
+  }`
+
+	// The ramp holds gradientRampSize premultiplied colors, sampled at the
+	// texel centers so that offsets 0 and 1 are the first and last stops.
+	fsGradientSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform sampler2D ramp;
+  uniform float radial;
+  varying vec2 vGradient;
+  varying float vCoverage;
+  void main() {
+    float offset = clamp(mix(vGradient.x, length(vGradient), radial), 0.0, 1.0);
+    gl_FragColor = texture2D(ramp, vec2(offset * (255.0 / 256.0) + (0.5 / 256.0), 0.5));
+    gl_FragColor *= vCoverage;
+  }`
+
+	vsPatternSrc = `
+  attribute vec2 aPosition;
+  attribute vec2 aFringe;
+  attribute float aCoverage;
+  varying vec2 vTexcoords;
+  varying float vCoverage;
+  uniform mat3 mPos;
+  uniform mat3 mUV;
+  uniform float fringe;
+  void main() {
+    vec3 pos3 = vec3(aPosition + aFringe * fringe, 1.0);
+    gl_Position = vec4((mPos * pos3).xy, 0.0, 1.0);
+    vTexcoords = (mUV * pos3).xy;
+    vCoverage = aCoverage;
+  }`
+
+	// The texture coordinates are wrapped here rather than by the texture
+	// parameters, as GL ES cannot repeat textures whose size is not a power
+	// of two. wrap is 0 to repeat, 1 to clamp and 2 to mirror. The coverage
+	// only scales the alpha of textures without premultiplied alpha, as they
+	// are blended by their alpha.
+	fsPatternSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform sampler2D source;
+  uniform float wrap;
+  uniform float flipY;
+  uniform float pma;
+  varying vec2 vTexcoords;
+  varying float vCoverage;
+  void main() {
+    vec2 uv = fract(vTexcoords);
+    if (wrap > 1.5) {
+      uv = 1.0 - abs(mod(vTexcoords, 2.0) - 1.0);
+    } else if (wrap > 0.5) {
+      uv = clamp(vTexcoords, 0.0, 1.0);
+    }
+    uv.y = mix(uv.y, 1.0 - uv.y, flipY);
+    gl_FragColor = texture2D(source, uv);
+    gl_FragColor *= vec4(vec3(mix(1.0, vCoverage, pma)), vCoverage);
+  }`
+
+	vsFontSrc = `
+  attribute vec2 aSrc;
+  attribute vec2 aDst;
+  attribute vec4 aClp;
+  attribute vec4 aCol;
+  varying vec2 vSrc;
+  varying vec4 vCol;
+  varying vec2 vClp;
+  uniform mat3 mSrc;
+  uniform mat3 mDst;
+  void main() {
+    vec2 vClipMin = (mDst * vec3(aClp.xy, 1.0)).xy;
+    vec2 vClipMax = (mDst * vec3(aClp.zw, 1.0)).xy;
+    gl_Position = vec4(mDst * vec3(aDst, 1.0), 1.0);
+    vSrc = (mSrc * vec3(aSrc, 1.0)).xy;
+    vClp = (gl_Position.xy - vClipMin) / (vClipMax - vClipMin);
+    vCol = aCol;
+  }`
+
+	fsFontSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform sampler2D source;
+  varying vec2 vSrc;
+  varying vec4 vCol;
+  varying vec2 vClp;
+  void main() {
+    vec2 clipping = step(vec2(0.0, 0.0), vClp) * step(vClp, vec2(1.0, 1.0));
+    gl_FragColor  = vCol * texture2D(source, vSrc).aaaa;
+    gl_FragColor *= clipping.x * clipping.y;
+  }`
+
+	// Glyphs drawn from distance fields also take aSmo, the distance in alpha
+	// of the field from its outline at which their edges fade out.
+	vsDistanceFieldSrc = `
+  attribute vec2 aSrc;
+  attribute vec2 aDst;
+  attribute vec4 aClp;
+  attribute vec4 aCol;
+  attribute float aSmo;
+  varying vec2 vSrc;
+  varying vec4 vCol;
+  varying vec2 vClp;
+  varying float vSmo;
+  uniform mat3 mSrc;
+  uniform mat3 mDst;
+  void main() {
+    vec2 vClipMin = (mDst * vec3(aClp.xy, 1.0)).xy;
+    vec2 vClipMax = (mDst * vec3(aClp.zw, 1.0)).xy;
+    gl_Position = vec4(mDst * vec3(aDst, 1.0), 1.0);
+    vSrc = (mSrc * vec3(aSrc, 1.0)).xy;
+    vClp = (gl_Position.xy - vClipMin) / (vClipMax - vClipMin);
+    vCol = aCol;
+    vSmo = aSmo;
+  }`
+
+	// The outline of glyphs drawn from distance fields is at half alpha.
+	fsDistanceFieldSrc = `
+  #ifdef GL_ES
+    precision mediump float;
+  #endif
+
+  uniform sampler2D source;
+  varying vec2 vSrc;
+  varying vec4 vCol;
+  varying vec2 vClp;
+  varying float vSmo;
+  void main() {
+    vec2 clipping = step(vec2(0.0, 0.0), vClp) * step(vClp, vec2(1.0, 1.0));
+    float distance = texture2D(source, vSrc).a;
+    gl_FragColor  = vCol * smoothstep(0.5 - vSmo, 0.5 + vSmo, distance);
+    gl_FragColor *= clipping.x * clipping.y;
+  }`
+)
+
+// Number of colors in the lookup textures of the gradients.
+const gradientRampSize = 256
+
+type glyphBatch struct {
+	GlyphPage *textureContext
+	// DistanceField is true when the glyph page holds distance fields, whose
+	// glyphs fade out over Smoothing.
+	DistanceField bool
+	DstRects      []float32
+	SrcRects      []float32
+	Colors        []float32
+	ClipRects     []float32
+	Smoothing     []float32
+	Indices       []uint16
+}
+
+type blitter struct {
+	stats               *contextStats
+	quad                *shape
+	copyShader          *shaderProgram
+	layerShader         *shaderProgram
+	shadowShader        *shaderProgram
+	blurShader          *shaderProgram
+	colorShader         *shaderProgram
+	gradientShader      *shaderProgram
+	patternShader       *shaderProgram
+	fontShader          *shaderProgram
+	distanceFieldShader *shaderProgram
+	glyphBatch          glyphBatch
+	ramps               map[*gxui.Gradient]*TextureImpl
+}
+
+func newBlitter(ctx *context, stats *contextStats) *blitter {
+	return &blitter{
+		stats:               stats,
+		quad:                newQuadShape(ctx.fn),
+		copyShader:          newShaderProgram(ctx, vsCopySrc, fsCopySrc),
+		layerShader:         newShaderProgram(ctx, vsCopySrc, fsLayerSrc),
+		shadowShader:        newShaderProgram(ctx, vsShadowSrc, fsShadowSrc),
+		blurShader:          newShaderProgram(ctx, vsBlurSrc, fsBlurSrc),
+		colorShader:         newShaderProgram(ctx, vsColorSrc, fsColorSrc),
+		gradientShader:      newShaderProgram(ctx, vsGradientSrc, fsGradientSrc),
+		patternShader:       newShaderProgram(ctx, vsPatternSrc, fsPatternSrc),
+		fontShader:          newShaderProgram(ctx, vsFontSrc, fsFontSrc),
+		distanceFieldShader: newShaderProgram(ctx, vsDistanceFieldSrc, fsDistanceFieldSrc),
+		ramps:               make(map[*gxui.Gradient]*TextureImpl),
+	}
+}
+
+func (b *blitter) destroy(ctx *context) {
+	b.copyShader.destroy(ctx)
+	b.layerShader.destroy(ctx)
+	b.shadowShader.destroy(ctx)
+	b.blurShader.destroy(ctx)
+	b.colorShader.destroy(ctx)
+	b.gradientShader.destroy(ctx)
+	b.patternShader.destroy(ctx)
+	b.fontShader.destroy(ctx)
+	b.distanceFieldShader.destroy(ctx)
+}
+
+// mark marks the quad the rectangles are drawn with as used by the frame.
+func (b *blitter) mark(ctx *context) {
+	ctx.markShape(b.quad)
+}
+
+// windowToClip returns the matrix transforming window pixels into clip space.
+func windowToClip(ctx *context) math.Mat3 {
+	dw, dh := ctx.sizePixels.WH()
+	return math.CreateMat3(
+		+2.0/float32(dw), 0, 0,
+		0, -2.0/float32(dh), 0,
+		-1.0, +1.0, 1,
+	)
+}
+
+// rectToClip returns the matrix transforming the unit square into dstRect, in
+// pixels relative to the origin of the state, in clip space.
+func rectToClip(ctx *context, dstRect math.Rect, state *drawState) math.Mat3 {
+	return windowToClip(ctx).Mul(state.pixelsToWindow(ctx)).Mul(rectMatrix(dstRect))
+}
+
+// shapeFringe returns the width of the fringe of shapes in DIPs, so that it is
+// fringeWidth pixels wide once transformed.
+func shapeFringe(ctx *context, state *drawState) float32 {
+	scale := ctx.resolution.dipsToPixels() * state.Transform.Scale()
+	if scale == 0 {
+		return 0
+	}
+	return fringeWidth / scale
+}
+
+func (b *blitter) blit(ctx *context, textureCtx *textureContext, srcRect, dstRect math.Rect, state *drawState) {
+	b.commitGlyphs(ctx)
+
+	sw, sh := textureCtx.sizePixels.WH()
+
+	var mUV math.Mat3
+	if textureCtx.flipY {
+		mUV = math.CreateMat3(
+			float32(srcRect.Width())/float32(sw), 0, 0,
+			0, -float32(srcRect.Height())/float32(sh), 0,
+			float32(srcRect.Min.X)/float32(sw),
+			1.0-float32(srcRect.Min.Y)/float32(sh), 1,
+		)
+	} else {
+		mUV = math.CreateMat3(
+			float32(srcRect.Width())/float32(sw), 0, 0,
+			0, float32(srcRect.Height())/float32(sh), 0,
+			float32(srcRect.Min.X)/float32(sw),
+			float32(srcRect.Min.Y)/float32(sh), 1,
+		)
+	}
+
+	mPos := rectToClip(ctx, dstRect, state)
+
+	if !textureCtx.pma {
+		ctx.fn.BlendFunc(SRC_ALPHA, ONE_MINUS_SRC_ALPHA)
+	}
+
+	b.quad.draw(
+		ctx,
+		b.copyShader,
+		uniformBindings{
+			"source": textureCtx,
+			"mUV":    mUV,
+			"mPos":   mPos,
+		},
+	)
+
+	if !textureCtx.pma {
+		ctx.fn.BlendFunc(ONE, ONE_MINUS_SRC_ALPHA)
+	}
+	b.stats.drawCallCount++
+}
+
+// blitLayer composites the whole layer onto the bound render target.
+func (b *blitter) blitLayer(ctx *context, l *layer) {
+	b.commitGlyphs(ctx)
+
+	w, h := ctx.sizePixels.WH()
+	mPos := windowToClip(ctx).Mul(math.CreateMat3Scale(float32(w), float32(h)))
+	// Flip the rows of the framebuffer.
+	mUV := math.CreateMat3(
+		1, 0, 0,
+		0, -1, 0,
+		0, 1, 1,
+	)
+
+	src, dst := blendFuncs(l.mode)
+	ctx.fn.BlendFuncSeparate(src, dst, ONE, ONE_MINUS_SRC_ALPHA)
+	b.quad.draw(ctx, b.layerShader, uniformBindings{
+		"source":  l.target.texture,
+		"mUV":     mUV,
+		"mPos":    mPos,
+		"opacity": l.opacity,
+	})
+	ctx.fn.BlendFunc(ONE, ONE_MINUS_SRC_ALPHA)
+
+	b.stats.drawCallCount++
+}
+
+// blitShadow draws the shadow of the rounded rectangle from min to max, in
+// DIPs, with the radii of its top-left, top-right, bottom-left and bottom-right
+// corners, blurred with the standard deviation sigma, in DIPs.
+func (b *blitter) blitShadow(ctx *context, min, max math.Vec2, radii [4]float32, sigma float32, color gxui.Color, state *drawState) {
+	fringe := shapeFringe(ctx, state)
+	if fringe == 0 {
+		return
+	}
+	b.commitGlyphs(ctx)
+
+	// Shadows are blurred across at least half the fringe, which anti-aliases
+	// their edges.
+	sigma = math32.Max(sigma, fringe/2)
+	extent := math.Vec2{X: 3 * sigma, Y: 3 * sigma}
+	from, to := min.Sub(extent), max.Add(extent)
+	mLocal := math.CreateMat3(
+		to.X-from.X, 0, 0,
+		0, to.Y-from.Y, 0,
+		from.X, from.Y, 1,
+	)
+
+	b.quad.draw(ctx, b.shadowShader, uniformBindings{
+		"mPos":   windowToClip(ctx).Mul(state.toWindow(ctx)).Mul(mLocal),
+		"mLocal": mLocal,
+		"box":    math.Vec4{X: min.X, Y: min.Y, Z: max.X, W: max.Y},
+		"radii":  math.Vec4{X: radii[0], Y: radii[1], Z: radii[2], W: radii[3]},
+		"sigma":  sigma,
+		"Color":  color,
+	})
+
+	b.stats.drawCallCount++
+}
+
+// Number of samples of the blur on each side of a pixel, as in fsBlurSrc.
+const blurTaps = 32
+
+// blurBackdrop replaces what is under rect, in DIPs, with it blurred with the
+// standard deviation sigma, in DIPs. The rows are blurred into a render target,
+// then the columns back into the render target of the state.
+func (b *blitter) blurBackdrop(ctx *context, rect math.Rect, sigma float32, state *drawState) {
+	sigmaPixels := sigma * ctx.resolution.dipsToPixels() * state.Transform.Scale()
+	window := ctx.sizePixels.Rect()
+	toWindow := state.toWindow(ctx)
+	dst := toWindow.TransformRect(rect).Overlap(state.ClipPixels).Overlap(window)
+	if sigmaPixels <= 0 || dst.Width() <= 0 || dst.Height() <= 0 {
+		return
+	}
+	b.commitGlyphs(ctx)
+
+	// The region holds every pixel sampled by the blur of dst.
+	extent := int(math32.Max(blurTaps, math32.Ceil(3*sigmaPixels))) + 1
+	region := dst.ExpandI(extent).Overlap(window)
+	w, h := ctx.sizePixels.WH()
+	size := math.Vec2{X: float32(w), Y: float32(h)}
+
+	// The rows of the render targets and of the window both go up.
+	source, rows := ctx.acquireRenderTarget(), ctx.acquireRenderTarget()
+	// Creating render targets binds the framebuffer of the window.
+	ctx.bindLayer(state.Layer)
+	ctx.fn.BindTexture(TEXTURE_2D, source.texture.texture)
+	x, y := int32(region.Min.X), int32(h-region.Max.Y)
+	ctx.fn.CopyTexSubImage2D(TEXTURE_2D, 0, x, y, x, y, int32(region.Width()), int32(region.Height()))
+	ctx.fn.BindTexture(TEXTURE_2D, 0)
+
+	ctx.fn.Disable(BLEND)
+	ctx.bindLayer(&layer{target: rows})
+	ctx.fn.Disable(SCISSOR_TEST)
+	b.quad.draw(ctx, b.blurShader, uniformBindings{
+		"mPos":      windowToClip(ctx).Mul(rectMatrix(region)),
+		"source":    source.texture,
+		"size":      size,
+		"direction": math.Vec2{X: 1 / size.X},
+		"sigma":     sigmaPixels,
+	})
+	ctx.fn.Enable(SCISSOR_TEST)
+
+	ctx.bindLayer(state.Layer)
+	ctx.apply(state)
+	b.quad.draw(ctx, b.blurShader, uniformBindings{
+		"mPos":      windowToClip(ctx).Mul(toWindow).Mul(rectMatrix(rect)),
+		"source":    rows.texture,
+		"size":      size,
+		"direction": math.Vec2{Y: 1 / size.Y},
+		"sigma":     sigmaPixels,
+	})
+	ctx.fn.Enable(BLEND)
+
+	ctx.releaseRenderTarget(source)
+	ctx.releaseRenderTarget(rows)
+	b.stats.drawCallCount += 2
+}
+
+// rectMatrix returns the matrix transforming the unit square into r.
+func rectMatrix(r math.Rect) math.Mat3 {
+	size := r.Size()
+	return math.CreateMat3(
+		float32(size.Width), 0, 0,
+		0, float32(size.Height), 0,
+		float32(r.Min.X), float32(r.Min.Y), 1,
+	)
+}
+
+func (b *blitter) blitGlyph(ctx *context, textureCtx *textureContext, color gxui.Color, srcRect, dstRect math.Rect, state *drawState) {
+	corners := [4]math.Vec2{
+		dstRect.TopLeft().Vec2(), dstRect.TopRight().Vec2(),
+		dstRect.BottomLeft().Vec2(), dstRect.BottomRight().Vec2(),
+	}
+	b.batchGlyph(ctx, textureCtx, false, color, srcRect, corners, 0, state)
+}
+
+// blitDistanceFieldGlyph draws the glyph of a distance field glyph page whose
+// field is srcRect, scaled by scale and with its top-left corner at origin, in
+// pixels. spread is the distance in texels of the field from the outline at
+// which it reaches full or zero alpha.
+func (b *blitter) blitDistanceFieldGlyph(ctx *context, textureCtx *textureContext, color gxui.Color, srcRect math.Rect, origin math.Vec2, scale float32, spread int, state *drawState) {
+	w, h := srcRect.Size().WH()
+	size := math.Vec2{X: float32(w) * scale, Y: float32(h) * scale}
+	corners := [4]math.Vec2{
+		origin, origin.Add(math.Vec2{X: size.X}),
+		origin.Add(math.Vec2{Y: size.Y}), origin.Add(size),
+	}
+
+	// The edges fade out over a pixel once scaled and transformed, each texel
+	// of the field changing its alpha by 1 / (2 * spread).
+	smoothing := float32(0.5)
+	if pixels := scale * state.Transform.Scale(); pixels > 0 {
+		smoothing = math32.Min(0.5, 1/(4*float32(spread)*pixels))
+	}
+	b.batchGlyph(ctx, textureCtx, true, color, srcRect, corners, smoothing, state)
+}
+
+// batchGlyph adds the glyph at the corners, in pixels relative to the origin of
+// the state, to the batch of glyphs drawn from the glyph page. The batch is
+// drawn first when it draws another glyph page.
+func (b *blitter) batchGlyph(ctx *context, textureCtx *textureContext, distanceField bool, color gxui.Color, srcRect math.Rect, corners [4]math.Vec2, smoothing float32, state *drawState) {
+	if state.Transform == math.Mat3Ident {
+		origin := state.OriginPixels.Vec2()
+		for i, c := range corners {
+			corners[i] = c.Add(origin)
+		}
+	} else {
+		toWindow := state.pixelsToWindow(ctx)
+		for i, c := range corners {
+			corners[i] = toWindow.TransformVec2(c)
+		}
+	}
+
+	if b.glyphBatch.GlyphPage != textureCtx {
+		b.commitGlyphs(ctx)
+		b.glyphBatch.GlyphPage = textureCtx
+		b.glyphBatch.DistanceField = distanceField
+	}
+
+	i := uint16(len(b.glyphBatch.DstRects)) / 2
+
+	clip := []float32{
+		float32(state.ClipPixels.Min.X),
+		float32(state.ClipPixels.Min.Y),
+		float32(state.ClipPixels.Max.X),
+		float32(state.ClipPixels.Max.Y),
+	}
+
+	b.glyphBatch.DstRects = append(b.glyphBatch.DstRects,
+		corners[0].X, corners[0].Y,
+		corners[1].X, corners[1].Y,
+		corners[2].X, corners[2].Y,
+		corners[3].X, corners[3].Y,
+	)
+
+	b.glyphBatch.SrcRects = append(b.glyphBatch.SrcRects,
+		float32(srcRect.Min.X), float32(srcRect.Min.Y),
+		float32(srcRect.Max.X), float32(srcRect.Min.Y),
+		float32(srcRect.Min.X), float32(srcRect.Max.Y),
+		float32(srcRect.Max.X), float32(srcRect.Max.Y),
+	)
+
+	b.glyphBatch.ClipRects = append(b.glyphBatch.ClipRects,
+		clip[0], clip[1], clip[2], clip[3],
+		clip[0], clip[1], clip[2], clip[3],
+		clip[0], clip[1], clip[2], clip[3],
+		clip[0], clip[1], clip[2], clip[3],
+	)
+
+	if distanceField {
+		b.glyphBatch.Smoothing = append(b.glyphBatch.Smoothing, smoothing, smoothing, smoothing, smoothing)
+	}
+
+	color = color.MulRGB(color.A) // PMA
+
+	b.glyphBatch.Colors = append(b.glyphBatch.Colors,
+		color.R, color.G, color.B, color.A,
+		color.R, color.G, color.B, color.A,
+		color.R, color.G, color.B, color.A,
+		color.R, color.G, color.B, color.A,
+	)
+
+	b.glyphBatch.Indices = append(b.glyphBatch.Indices,
+		i, i+1, i+2,
+		i+2, i+1, i+3,
+	)
+}
+
+func (b *blitter) blitShape(ctx *context, shape shape, color gxui.Color, state *drawState) {
+	b.commitGlyphs(ctx)
+
+	mPos := windowToClip(ctx).Mul(state.toWindow(ctx))
+
+	fringe := shapeFringe(ctx, state)
+	shape.draw(
+		ctx,
+		b.colorShader,
+		uniformBindings{
+			"mPos":   mPos,
+			"Color":  color,
+			"fringe": fringe,
+		},
+	)
+
+	if debugWireframePolygons {
+		// glPolygonMode is not available in OpenGL ES/WebGL (since its implementation is very inefficient; a shame because it's useful for debugging).
+		//PolygonMode(FRONT_AND_BACK, LINE)
+		shape.draw(ctx, b.colorShader, uniformBindings{
+			"mPos":   mPos,
+			"Color":  gxui.Blue,
+			"fringe": fringe,
+		})
+		//PolygonMode(FRONT_AND_BACK, FILL)
+	}
+
+	b.stats.drawCallCount++
+}
+
+func (b *blitter) blitRect(ctx *context, dstRect math.Rect, color gxui.Color, state *drawState) {
+	b.commitGlyphs(ctx)
+	mPos := rectToClip(ctx, dstRect, state)
+
+	b.quad.draw(ctx, b.colorShader, uniformBindings{
+		"mPos":   mPos,
+		"Color":  color,
+		"fringe": float32(0),
+	})
+
+	b.stats.drawCallCount++
+}
+
+// ramp returns the lookup texture of the gradient. The textures are kept for as
+// long as their context is, see context.endDraw.
+func (b *blitter) ramp(ctx *context, gradient *gxui.Gradient) *textureContext {
+	texture, found := b.ramps[gradient]
+	if !found {
+		texture = NewTexture(gradient.Ramp(gradientRampSize), 1)
+		b.ramps[gradient] = texture
+	}
+	return ctx.getOrCreateTextureContext(texture)
+}
+
+// gradientMatrix returns the matrix transforming vertex positions into the
+// gradient space, for vertices at position*scale+offset in the space of bounds.
+func gradientMatrix(gradient *gxui.Gradient, bounds math.Rect, scale, offset math.Vec2) math.Mat3 {
+	origin, u, v := gradient.Axes(bounds)
+	o := offset.Sub(origin)
+	return math.CreateMat3(
+		u.X*scale.X, v.X*scale.X, 0,
+		u.Y*scale.Y, v.Y*scale.Y, 0,
+		o.Dot(u), o.Dot(v), 1,
+	)
+}
+
+func (b *blitter) gradientUniforms(ctx *context, gradient *gxui.Gradient, mPos, mGradient math.Mat3, fringe float32) uniformBindings {
+	radial := float32(0)
+	if gradient.Kind == gxui.RadialGradient {
+		radial = 1
+	}
+	return uniformBindings{
+		"mPos":      mPos,
+		"mGradient": mGradient,
+		"ramp":      b.ramp(ctx, gradient),
+		"radial":    radial,
+		"fringe":    fringe,
+	}
+}
+
+// blitGradientShape fills the shape with the gradient, where bounds is the
+// bounding box of the shape in DIPs.
+func (b *blitter) blitGradientShape(ctx *context, shape shape, gradient *gxui.Gradient, bounds math.Rect, state *drawState) {
+	b.commitGlyphs(ctx)
+
+	mPos := windowToClip(ctx).Mul(state.toWindow(ctx))
+	mGradient := gradientMatrix(gradient, bounds, math.Vec2{X: 1, Y: 1}, math.Vec2{})
+
+	shape.draw(ctx, b.gradientShader, b.gradientUniforms(ctx, gradient, mPos, mGradient, shapeFringe(ctx, state)))
+
+	b.stats.drawCallCount++
+}
+
+// blitGradientRect fills dstRect, in pixels, with the gradient.
+func (b *blitter) blitGradientRect(ctx *context, dstRect math.Rect, gradient *gxui.Gradient, state *drawState) {
+	b.commitGlyphs(ctx)
+	mPos := rectToClip(ctx, dstRect, state)
+	size := dstRect.Size()
+	mGradient := gradientMatrix(gradient, dstRect, math.Vec2{X: float32(size.Width), Y: float32(size.Height)}, dstRect.Min.Vec2())
+
+	b.quad.draw(ctx, b.gradientShader, b.gradientUniforms(ctx, gradient, mPos, mGradient, 0))
+
+	b.stats.drawCallCount++
+}
+
+// patternMatrix returns the matrix transforming vertex positions into texture
+// coordinates, for vertices at position*scale+offset in the space of bounds.
+func patternMatrix(pattern *gxui.Pattern, bounds math.Rect, scale, offset math.Vec2) (math.Mat3, bool) {
+	texCoords, ok := pattern.TexCoords(bounds)
+	if !ok {
+		return math.Mat3{}, false
+	}
+	return texCoords.Mul(math.CreateMat3Translate(offset.X, offset.Y)).Mul(math.CreateMat3Scale(scale.X, scale.Y)), true
+}
+
+func (b *blitter) drawPattern(ctx *context, shape shape, pattern *gxui.Pattern, mPos, mUV math.Mat3, fringe float32) {
+	textureCtx := ctx.getOrCreateTextureContext(pattern.Texture.(*TextureImpl))
+	flipY, pma := float32(0), float32(0)
+	if textureCtx.flipY {
+		flipY = 1
+	}
+	if textureCtx.pma {
+		pma = 1
+	}
+
+	if !textureCtx.pma {
+		ctx.fn.BlendFunc(SRC_ALPHA, ONE_MINUS_SRC_ALPHA)
+	}
+
+	shape.draw(ctx, b.patternShader, uniformBindings{
+		"source": textureCtx,
+		"mPos":   mPos,
+		"mUV":    mUV,
+		"wrap":   float32(pattern.Wrap),
+		"flipY":  flipY,
+		"pma":    pma,
+		"fringe": fringe,
+	})
+
+	if !textureCtx.pma {
+		ctx.fn.BlendFunc(ONE, ONE_MINUS_SRC_ALPHA)
+	}
+	b.stats.drawCallCount++
+}
+
+// blitPatternShape fills the shape with the pattern, where bounds is the
+// bounding box of the shape in DIPs.
+func (b *blitter) blitPatternShape(ctx *context, shape shape, pattern *gxui.Pattern, bounds math.Rect, state *drawState) {
+	b.commitGlyphs(ctx)
+
+	mUV, ok := patternMatrix(pattern, bounds, math.Vec2{X: 1, Y: 1}, math.Vec2{})
+	if !ok {
+		return
+	}
+	mPos := windowToClip(ctx).Mul(state.toWindow(ctx))
+
+	b.drawPattern(ctx, shape, pattern, mPos, mUV, shapeFringe(ctx, state))
+}
+
+// blitPatternRect fills dstRect, in pixels, with the pattern. As patterns are
+// placed in DIPs, bounds is the same rectangle in DIPs.
+func (b *blitter) blitPatternRect(ctx *context, dstRect math.Rect, pattern *gxui.Pattern, bounds math.Rect, state *drawState) {
+	b.commitGlyphs(ctx)
+
+	size := bounds.Size()
+	mUV, ok := patternMatrix(pattern, bounds, math.Vec2{X: float32(size.Width), Y: float32(size.Height)}, bounds.Min.Vec2())
+	if !ok {
+		return
+	}
+	mPos := rectToClip(ctx, dstRect, state)
+
+	b.drawPattern(ctx, *b.quad, pattern, mPos, mUV, 0)
+}
+
+// stencil limits drawing to the intersection of the clips, each given as the
+// matrix transforming the unit square into the clip, in window pixels. The
+// stencil of each pixel counts the clips containing it, up to the first clip
+// not containing it.
+func (b *blitter) stencil(ctx *context, clips []math.Mat3) {
+	if len(clips) == 0 {
+		ctx.fn.Disable(STENCIL_TEST)
+		return
+	}
+
+	ctx.fn.Disable(SCISSOR_TEST)
+	ctx.fn.Enable(STENCIL_TEST)
+	ctx.fn.Clear(STENCIL_BUFFER_BIT)
+	ctx.fn.ColorMask(false, false, false, false)
+	ctx.fn.StencilOp(KEEP, KEEP, INCR)
+	windowToClip := windowToClip(ctx)
+	for i, clip := range clips {
+		ctx.fn.StencilFunc(EQUAL, int32(i), 0xff)
+		b.quad.draw(ctx, b.colorShader, uniformBindings{
+			"mPos":   windowToClip.Mul(clip),
+			"Color":  gxui.White,
+			"fringe": float32(0),
+		})
+		b.stats.drawCallCount++
+	}
+	ctx.fn.ColorMask(true, true, true, true)
+	ctx.fn.StencilFunc(EQUAL, int32(len(clips)), 0xff)
+	ctx.fn.StencilOp(KEEP, KEEP, KEEP)
+	ctx.fn.Enable(SCISSOR_TEST)
+}
+
+func (b *blitter) commit(ctx *context) {
+	b.commitGlyphs(ctx)
+}
+
+func (b *blitter) commitGlyphs(ctx *context) {
+	tc := b.glyphBatch.GlyphPage
+	if tc == nil {
+		return
+	}
+
+	sw, sh := tc.sizePixels.WH()
+
+	mSrc := math.CreateMat3(
+		1.0/float32(sw), 0, 0,
+		0, 1.0/float32(sh), 0,
+		0.0, 0.0, 1,
+	)
+	mDst := windowToClip(ctx)
+
+	streams := []*vertexStream{
+		newVertexStream("aDst", stFloatVec2, b.glyphBatch.DstRects),
+		newVertexStream("aSrc", stFloatVec2, b.glyphBatch.SrcRects),
+		newVertexStream("aClp", stFloatVec4, b.glyphBatch.ClipRects),
+		newVertexStream("aCol", stFloatVec4, b.glyphBatch.Colors),
+	}
+	shader := b.fontShader
+	if b.glyphBatch.DistanceField {
+		streams = append(streams, newVertexStream("aSmo", stFloatVec1, b.glyphBatch.Smoothing))
+		shader = b.distanceFieldShader
+	}
+	buffer := newVertexBuffer(streams...)
+
+	indexesBuffer := newIndexBuffer(ctx.fn, ptUshort, b.glyphBatch.Indices)
+
+	targetShape := newShape(buffer, indexesBuffer, dmTriangles)
+
+	ctx.fn.Disable(SCISSOR_TEST)
+	targetShape.draw(
+		ctx,
+		shader,
+		uniformBindings{
+			"source": tc,
+			"mDst":   mDst,
+			"mSrc":   mSrc,
+		},
+	)
+	ctx.fn.Enable(SCISSOR_TEST)
+
+	b.glyphBatch.GlyphPage = nil
+	b.glyphBatch.DstRects = b.glyphBatch.DstRects[:0]
+	b.glyphBatch.SrcRects = b.glyphBatch.SrcRects[:0]
+	b.glyphBatch.ClipRects = b.glyphBatch.ClipRects[:0]
+	b.glyphBatch.Colors = b.glyphBatch.Colors[:0]
+	b.glyphBatch.Smoothing = b.glyphBatch.Smoothing[:0]
+	b.glyphBatch.Indices = b.glyphBatch.Indices[:0]
+
+	b.stats.drawCallCount++
+	b.stats.glyphBatchCount++
+}
@@ -0,0 +1,453 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/geometry"
+	"github.com/badu/gxui/pkg/math"
+)
+
+type drawStateStack []drawState
+
+func (s *drawStateStack) head() *drawState {
+	return &(*s)[len(*s)-1]
+}
+func (s *drawStateStack) push(ds drawState) {
+	*s = append(*s, ds)
+}
+func (s *drawStateStack) pop() {
+	*s = (*s)[:len(*s)-1]
+}
+
+type canvasOp func(ctx *context, stack *drawStateStack)
+
+// canvasUse marks the resources drawn by an op as used by the frame.
+type canvasUse func(ctx *context)
+
+type drawState struct {
+	// The below are all in window coordinates
+	ClipPixels   math.Rect
+	OriginPixels math.Point
+	// Transform is applied to everything drawn, in DIPs, before it is offset
+	// by OriginPixels.
+	Transform math.Mat3
+	// Clips are the clips that are not aligned to the axes, each as the matrix
+	// transforming the unit square into the clip, in window pixels.
+	Clips []math.Mat3
+	// Layer is the layer drawn into, or nil to draw into the window.
+	Layer *layer
+}
+
+// toWindow returns the matrix transforming DIPs into window pixels.
+func (s *drawState) toWindow(ctx *context) math.Mat3 {
+	dipsToPixels := ctx.resolution.dipsToPixels()
+	return math.CreateMat3Translate(float32(s.OriginPixels.X), float32(s.OriginPixels.Y)).
+		Mul(math.CreateMat3Scale(dipsToPixels, dipsToPixels)).
+		Mul(s.Transform)
+}
+
+// pixelsToWindow returns the matrix transforming pixels, relative to the
+// origin, into window pixels.
+func (s *drawState) pixelsToWindow(ctx *context) math.Mat3 {
+	pixelsToDips := 1 / ctx.resolution.dipsToPixels()
+	return s.toWindow(ctx).Mul(math.CreateMat3Scale(pixelsToDips, pixelsToDips))
+}
+
+type CanvasImpl struct {
+	fn                Functions
+	ops               []canvasOp
+	uses              []canvasUse
+	sizeDips          math.Size
+	buildingPushCount int
+	buildingLayers    []int // The push counts of the layers being built.
+	built             bool
+	readsBackdrop     bool // True if the canvas draws with what is under it.
+}
+
+func NewCanvas(fn Functions, sizeDips math.Size) *CanvasImpl {
+	if sizeDips.Width <= 0 || sizeDips.Height < 0 {
+		panic(fmt.Errorf("canvas width and height must be positive. Size: %d", sizeDips))
+	}
+
+	result := &CanvasImpl{fn: fn, sizeDips: sizeDips}
+	return result
+}
+
+func (c *CanvasImpl) draw(ctx *context, stack *drawStateStack) {
+	head := stack.head()
+	ctx.apply(head)
+
+	for _, op := range c.ops {
+		op(ctx, stack)
+	}
+}
+
+func (c *CanvasImpl) appendOp(name string, op canvasOp) {
+	if c.built {
+		panic(fmt.Errorf("%s() called after Complete()", name))
+	}
+	c.ops = append(c.ops, op)
+}
+
+func (c *CanvasImpl) appendUse(use canvasUse) {
+	c.uses = append(c.uses, use)
+}
+
+// mark marks the resources of the canvas, and of the canvases it draws, as
+// used by the frame. The frames drawing only part of the window keep the
+// resources of the canvas they did not draw.
+func (c *CanvasImpl) mark(ctx *context) {
+	for _, use := range c.uses {
+		use(ctx)
+	}
+}
+
+// Size is gxui.Canvas compliance
+func (c *CanvasImpl) Size() math.Size {
+	return c.sizeDips
+}
+
+func (c *CanvasImpl) IsComplete() bool {
+	return c.built
+}
+
+func (c *CanvasImpl) Complete() {
+	if c.built {
+		panic("complete() called twice")
+	}
+
+	if c.buildingPushCount != 0 {
+		panic(fmt.Errorf("push() count was %d when calling Complete", c.buildingPushCount))
+	}
+
+	c.built = true
+}
+
+func (c *CanvasImpl) Push() {
+	c.buildingPushCount++
+	c.appendOp(
+		"Push",
+		func(ctx *context, stack *drawStateStack) {
+			stack.push(*stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) Pop() {
+	if n := len(c.buildingLayers); n > 0 && c.buildingLayers[n-1] == c.buildingPushCount {
+		panic("Pop() called to end a layer, use PopLayer()")
+	}
+	c.buildingPushCount--
+	c.appendOp(
+		"Pop",
+		func(ctx *context, stack *drawStateStack) {
+			stack.pop()
+			ctx.apply(stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) PushLayer(opacity float32, mode gxui.BlendMode) {
+	c.buildingPushCount++
+	c.buildingLayers = append(c.buildingLayers, c.buildingPushCount)
+	c.appendOp(
+		"PushLayer",
+		func(ctx *context, stack *drawStateStack) {
+			stack.push(*stack.head())
+			head := stack.head()
+			head.Layer = ctx.pushLayer(opacity, mode)
+			ctx.apply(head)
+		},
+	)
+}
+
+func (c *CanvasImpl) PopLayer() {
+	if n := len(c.buildingLayers); n == 0 || c.buildingLayers[n-1] != c.buildingPushCount {
+		panic("PopLayer() called without PushLayer()")
+	}
+	c.buildingLayers = c.buildingLayers[:len(c.buildingLayers)-1]
+	c.buildingPushCount--
+	c.appendOp(
+		"PopLayer",
+		func(ctx *context, stack *drawStateStack) {
+			l := stack.head().Layer
+			stack.pop()
+			ctx.popLayer(l, stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) AddClip(rect math.Rect) {
+	c.appendOp(
+		"AddClip",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			if head.Transform == math.Mat3Ident {
+				rectLocalPixels := ctx.resolution.rectDipsToPixels(rect)
+				rectWindowPixels := rectLocalPixels.Offset(head.OriginPixels)
+				head.ClipPixels = head.ClipPixels.Overlap(rectWindowPixels)
+			} else {
+				toWindow := head.toWindow(ctx)
+				head.ClipPixels = head.ClipPixels.Overlap(toWindow.TransformRect(rect))
+				if !head.Transform.IsAxisAligned() {
+					// The scissor only clips to the bounds of the clip, the
+					// stencil clips the rest.
+					size := rect.Size()
+					clip := toWindow.
+						Mul(math.CreateMat3Translate(float32(rect.Min.X), float32(rect.Min.Y))).
+						Mul(math.CreateMat3Scale(float32(size.Width), float32(size.Height)))
+					head.Clips = append(slices.Clip(head.Clips), clip)
+				}
+			}
+			ctx.apply(head)
+		},
+	)
+}
+
+func (c *CanvasImpl) Transform(m math.Mat3) {
+	c.appendOp(
+		"Transform",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			head.Transform = head.Transform.Mul(m)
+		},
+	)
+}
+
+func (c *CanvasImpl) Clear(color gxui.Color) {
+	c.appendOp(
+		"Clear",
+		func(ctx *context, stack *drawStateStack) {
+			c.fn.ClearColor(color.R, color.G, color.B, color.A)
+			c.fn.Clear(COLOR_BUFFER_BIT)
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawCanvas(targetCanvas gxui.Canvas, offsetDips math.Point) {
+	if targetCanvas == nil {
+		panic("target canvas cannot be nil")
+	}
+
+	childCanvas := targetCanvas.(*CanvasImpl)
+	c.readsBackdrop = c.readsBackdrop || childCanvas.readsBackdrop
+	c.appendUse(childCanvas.mark)
+	c.appendOp(
+		"DrawCanvas",
+		func(ctx *context, stack *drawStateStack) {
+			stack.push(*stack.head())
+			head := stack.head()
+			if head.Transform == math.Mat3Ident {
+				offsetPixels := ctx.resolution.pointDipsToPixels(offsetDips)
+				head.OriginPixels = head.OriginPixels.Add(offsetPixels)
+			} else {
+				head.Transform = head.Transform.Mul(math.CreateMat3Translate(float32(offsetDips.X), float32(offsetDips.Y)))
+			}
+			// Nothing of the child canvas is drawn outside of the clip.
+			if head.ClipPixels.Size().Area() > 0 {
+				childCanvas.draw(ctx, stack)
+			}
+			stack.pop()
+			ctx.apply(stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawRunes(useFont gxui.Font, runes []rune, points []math.Point, color gxui.Color) {
+	if useFont == nil {
+		panic("font cannot be nil")
+	}
+
+	runesCopy := append([]rune{}, runes...)
+	pointsCopy := append([]math.Point{}, points...)
+	c.appendUse(driverFont(useFont).mark)
+	c.appendOp(
+		"DrawRunes",
+		func(ctx *context, stack *drawStateStack) {
+			driverFont(useFont).DrawRunes(c.fn, ctx, runesCopy, pointsCopy, color, stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawLines(lines gxui.Polygon, pen gxui.Pen) {
+	edge := openPolyToShape(c.fn, lines, pen)
+	c.appendUse(func(ctx *context) {
+		ctx.markShape(edge)
+	})
+	c.appendOp(
+		"DrawLines",
+		func(ctx *context, dss *drawStateStack) {
+			head := dss.head()
+			if edge != nil && pen.Color.A > 0 {
+				ctx.blitter.blitShape(ctx, *edge, pen.Color, head)
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawPolygon(poly gxui.Polygon, pen gxui.Pen, brush gxui.Brush) {
+	fill, edge := closedPolyToShape(poly, pen)
+	bounds := poly.Bounds()
+	c.appendUse(func(ctx *context) {
+		ctx.markShape(fill)
+		ctx.markShape(edge)
+		ctx.markBrush(brush)
+	})
+	c.appendOp(
+		"DrawPolygon",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			if fill != nil && !brush.IsTransparent() {
+				if brush.Pattern != nil {
+					ctx.blitter.blitPatternShape(ctx, *fill, brush.Pattern, bounds, head)
+				} else if brush.Gradient != nil {
+					ctx.blitter.blitGradientShape(ctx, *fill, brush.Gradient, bounds, head)
+				} else {
+					ctx.blitter.blitShape(ctx, *fill, brush.Color, head)
+				}
+			}
+			if edge != nil && pen.Color.A > 0 {
+				ctx.blitter.blitShape(ctx, *edge, pen.Color, head)
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawRect(rect math.Rect, brush gxui.Brush) {
+	// The shape of the rectangle is only built if it is drawn rotated or
+	// skewed, as its edges then need anti-aliasing.
+	var fill *shape
+	c.appendUse(func(ctx *context) {
+		ctx.markShape(fill)
+		ctx.markBrush(brush)
+	})
+	c.appendOp(
+		"DrawRect",
+		func(ctx *context, dss *drawStateStack) {
+			head := dss.head()
+			if !head.Transform.IsAxisAligned() {
+				if fill == nil {
+					fill = trianglesToShape(rectTriangles(rect), false)
+				}
+				if brush.Pattern != nil {
+					ctx.blitter.blitPatternShape(ctx, *fill, brush.Pattern, rect, head)
+				} else if brush.Gradient != nil {
+					ctx.blitter.blitGradientShape(ctx, *fill, brush.Gradient, rect, head)
+				} else {
+					ctx.blitter.blitShape(ctx, *fill, brush.Color, head)
+				}
+			} else if brush.Pattern != nil {
+				ctx.blitter.blitPatternRect(ctx, ctx.resolution.rectDipsToPixels(rect), brush.Pattern, rect, dss.head())
+			} else if brush.Gradient != nil {
+				ctx.blitter.blitGradientRect(ctx, ctx.resolution.rectDipsToPixels(rect), brush.Gradient, dss.head())
+			} else {
+				ctx.blitter.blitRect(ctx, ctx.resolution.rectDipsToPixels(rect), brush.Color, dss.head())
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawRoundedRect(rect math.Rect, tl, tr, bl, br float32, pen gxui.Pen, brush gxui.Brush) {
+	if tl == 0 && tr == 0 && bl == 0 && br == 0 && pen.Color.A == 0 {
+		c.DrawRect(rect, brush)
+		return
+	}
+
+	polygon := gxui.Polygon{
+		gxui.PolygonVertex{Position: rect.TopLeft(), RoundedRadius: tl},
+		gxui.PolygonVertex{Position: rect.TopRight(), RoundedRadius: tr},
+		gxui.PolygonVertex{Position: rect.BottomRight(), RoundedRadius: br},
+		gxui.PolygonVertex{Position: rect.BottomLeft(), RoundedRadius: bl},
+	}
+
+	c.DrawPolygon(polygon, pen, brush)
+}
+
+func (c *CanvasImpl) DrawShadow(rect math.Rect, tl, tr, bl, br float32, shadow gxui.Shadow) {
+	min, max, radii, ok := geometry.ShadowBox(rect, [4]float32{tl, tr, bl, br}, shadow)
+	c.appendOp(
+		"DrawShadow",
+		func(ctx *context, stack *drawStateStack) {
+			if ok && shadow.IsVisible() {
+				ctx.blitter.blitShadow(ctx, min, max, radii, shadow.Blur/2, shadow.Color, stack.head())
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) BlurBackdrop(rect math.Rect, radius float32) {
+	c.readsBackdrop = c.readsBackdrop || radius > 0
+	c.appendOp(
+		"BlurBackdrop",
+		func(ctx *context, stack *drawStateStack) {
+			if radius > 0 {
+				ctx.blitter.blurBackdrop(ctx, rect, radius/2, stack.head())
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) FillPath(path *gxui.Path, rule gxui.FillRule, brush gxui.Brush) {
+	fill := trianglesToShape(geometry.FillPathTriangles(path, rule), false)
+	bounds := path.Bounds()
+	c.appendUse(func(ctx *context) {
+		ctx.markShape(fill)
+		ctx.markBrush(brush)
+	})
+	c.appendOp(
+		"FillPath",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			if fill != nil && !brush.IsTransparent() {
+				if brush.Pattern != nil {
+					ctx.blitter.blitPatternShape(ctx, *fill, brush.Pattern, bounds, head)
+				} else if brush.Gradient != nil {
+					ctx.blitter.blitGradientShape(ctx, *fill, brush.Gradient, bounds, head)
+				} else {
+					ctx.blitter.blitShape(ctx, *fill, brush.Color, head)
+				}
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) StrokePath(path *gxui.Path, pen gxui.Pen) {
+	var edge *shape
+	if pen.Width > 0 {
+		edge = trianglesToShape(geometry.StrokePath(path, pen), true)
+	}
+	c.appendUse(func(ctx *context) {
+		ctx.markShape(edge)
+	})
+	c.appendOp(
+		"StrokePath",
+		func(ctx *context, stack *drawStateStack) {
+			if edge != nil && pen.Color.A > 0 {
+				ctx.blitter.blitShape(ctx, *edge, pen.Color, stack.head())
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawTexture(targetTexture gxui.Texture, r math.Rect) {
+	if targetTexture == nil {
+		panic("target texture cannot be nil")
+	}
+
+	c.appendUse(func(ctx *context) {
+		ctx.markTexture(targetTexture.(*TextureImpl))
+	})
+	c.appendOp(
+		"DrawTexture",
+		func(ctx *context, stack *drawStateStack) {
+			textureCtx := ctx.getOrCreateTextureContext(targetTexture.(*TextureImpl))
+			ctx.blitter.blit(ctx, textureCtx, textureCtx.sizePixels.Rect(), ctx.resolution.rectDipsToPixels(r), stack.head())
+		},
+	)
+}
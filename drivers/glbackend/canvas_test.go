@@ -0,0 +1,99 @@
+package glbackend
+
+import (
+	"testing"
+
+	"github.com/badu/gxui"
+	gxfont "github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+	"github.com/chewxy/math32"
+)
+
+func near(a, b math.Vec2) bool {
+	return a.Sub(b).Len() < 0.001
+}
+
+func TestDrawStateToWindow(t *testing.T) {
+	ctx := &context{resolution: resolution(2 << 16), sizePixels: math.Size{Width: 200, Height: 100}}
+	state := &drawState{
+		OriginPixels: math.Point{X: 10, Y: 20},
+		Transform:    math.CreateMat3Rotate(math32.Pi / 2),
+	}
+	if p := state.toWindow(ctx).TransformVec2(v(5, 0)); !near(p, v(10, 30)) {
+		t.Errorf("Expected (5, 0) to be drawn at (10, 30), got %v", p)
+	}
+	if p := state.pixelsToWindow(ctx).TransformVec2(v(10, 0)); !near(p, v(10, 30)) {
+		t.Errorf("Expected the pixel (10, 0) to be drawn at (10, 30), got %v", p)
+	}
+	test_helper.AssertEquals(t, float32(0.25), shapeFringe(ctx, &drawState{Transform: math.CreateMat3Scale(2, 2)}))
+
+	// Without a transform, the unit square is placed at the offset rectangle.
+	state.Transform = math.Mat3Ident
+	m := rectToClip(ctx, math.CreateRect(10, 0, 110, 50), state)
+	if p := m.TransformVec2(v(0, 0)); !near(p, v(-0.8, 0.6)) {
+		t.Errorf("Expected the top left corner at (-0.8, 0.6), got %v", p)
+	}
+	if p := m.TransformVec2(v(1, 1)); !near(p, v(0.2, -0.4)) {
+		t.Errorf("Expected the bottom right corner at (0.2, -0.4), got %v", p)
+	}
+}
+
+func TestBlitDistanceFieldGlyph(t *testing.T) {
+	ctx := &context{resolution: resolution(2 << 16), sizePixels: math.Size{Width: 200, Height: 100}}
+	b := &blitter{}
+	page := &textureContext{}
+	state := &drawState{OriginPixels: math.Point{X: 10, Y: 20}, Transform: math.Mat3Ident}
+
+	// A field of 20x10 texels scaled by half, with edges fading over a pixel.
+	b.blitDistanceFieldGlyph(ctx, page, gxui.White, math.CreateRect(0, 0, 20, 10), v(5, 5), 0.5, 4, state)
+	test_helper.AssertEquals(t, true, b.glyphBatch.DistanceField)
+	test_helper.AssertEquals(t, []float32{15, 25, 25, 25, 15, 30, 25, 30}, b.glyphBatch.DstRects)
+	test_helper.AssertEquals(t, []float32{0.125, 0.125, 0.125, 0.125}, b.glyphBatch.Smoothing)
+
+	// Transforms scaling the glyph up sharpen its edges.
+	state.Transform = math.CreateMat3Scale(4, 4)
+	b.blitDistanceFieldGlyph(ctx, page, gxui.White, math.CreateRect(0, 0, 20, 10), v(5, 5), 0.5, 4, state)
+	test_helper.AssertEquals(t, float32(0.03125), b.glyphBatch.Smoothing[4])
+	test_helper.AssertEquals(t, 12, len(b.glyphBatch.Indices))
+}
+
+func TestDistanceFieldFont(t *testing.T) {
+	regular, err := newFont(gxfont.Default, 0, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monospace, _ := newFont(gxfont.Monospace, 0, 16)
+	family, err := newFontFamily([]gxui.Font{regular, monospace})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := newDistanceFieldFont(regular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, true, driverFont(f).distanceField)
+	test_helper.AssertEquals(t, false, regular.distanceField)
+	text := &gxui.TextBlock{Runes: []rune("Distance")}
+	test_helper.AssertEquals(t, regular.Measure(text), f.Measure(text))
+
+	f, err = newDistanceFieldFont(family)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, true, driverFont(f).distanceField)
+	test_helper.AssertEquals(t, false, family.distanceField)
+	test_helper.AssertEquals(t, 2, len(f.(gxui.FontFamily).Fonts()))
+
+	// The fields of the glyphs are padded by the spread.
+	index := regular.Shaper().Index('D')
+	entry := regular.distanceFieldTable(regular.Font).get(index).get(index)
+	if entry.bounds.Width() < 2*distanceFieldSpread || entry.bounds.Height() < distanceFieldSize/2 {
+		t.Errorf("Unexpected bounds %v of the field of D", entry.bounds)
+	}
+
+	if _, err := newDistanceFieldFont(nil); err == nil {
+		t.Errorf("Expected an error for a font not created by the driver")
+	}
+}
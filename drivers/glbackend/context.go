@@ -0,0 +1,379 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
+
+import (
+	"slices"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// contextResource is used as an anonymous field by types that are constructed per context.
+type contextResource struct {
+	lastContextUse int // used for mark-and-sweeping the resource.
+}
+
+type context struct {
+	fn                   Functions
+	blitter              *blitter
+	textureContexts      map[*TextureImpl]*textureContext
+	vertexStreamContexts map[*vertexStream]*vertexStreamContext
+	indexBufferContexts  map[*indexBuffer]*indexBufferContext
+	stats                contextStats
+	clip                 math.Rect
+	clips                []math.Mat3
+	renderTargets        []*renderTarget
+	retained             *renderTarget // The render target the window is drawn into
+	sizeDips             math.Size
+	sizePixels           math.Size
+	frame                int
+	resolution           resolution
+}
+
+func newContext(fn Functions) *context {
+	result := &context{
+		fn:                   fn,
+		textureContexts:      make(map[*TextureImpl]*textureContext),
+		vertexStreamContexts: make(map[*vertexStream]*vertexStreamContext),
+		indexBufferContexts:  make(map[*indexBuffer]*indexBufferContext),
+	}
+	result.blitter = newBlitter(result, &result.stats)
+	return result
+}
+
+func (c *context) destroy() {
+	if c.fn == nil {
+		panic("fn cannot be nil while destroying context")
+	}
+
+	for textureCtx, tc := range c.textureContexts {
+		delete(c.textureContexts, textureCtx)
+		tc.destroy(c.fn)
+		c.stats.textureCount--
+	}
+
+	for stream, sc := range c.vertexStreamContexts {
+		delete(c.vertexStreamContexts, stream)
+		sc.destroy(c.fn)
+		c.stats.vertexStreamCount--
+	}
+
+	for buffer, ic := range c.indexBufferContexts {
+		delete(c.indexBufferContexts, buffer)
+		ic.destroy(c.fn)
+		c.stats.indexBufferCount--
+	}
+
+	for _, t := range c.renderTargets {
+		t.destroy(c.fn)
+	}
+	c.renderTargets = nil
+
+	if c.retained != nil {
+		c.retained.destroy(c.fn)
+		c.retained = nil
+	}
+
+	c.blitter.destroy(c)
+	c.blitter = nil
+}
+
+// beginDraw starts a frame, and binds the retained render target the window is
+// drawn into. The retained render target keeps the last frame, so that a frame
+// can draw only part of the window. beginDraw returns false if it has no frame
+// yet, and the whole window must be drawn.
+func (c *context) beginDraw(sizeDips, sizePixels math.Size) bool {
+	dipsToPixels := float32(sizePixels.Width) / float32(sizeDips.Width)
+
+	if c.sizePixels != sizePixels {
+		// The render targets of the layers are the size of the window.
+		for _, t := range c.renderTargets {
+			t.destroy(c.fn)
+		}
+		c.renderTargets = nil
+		if c.retained != nil {
+			c.retained.destroy(c.fn)
+			c.retained = nil
+		}
+	}
+
+	c.sizeDips = sizeDips
+	c.sizePixels = sizePixels
+	c.resolution = resolution(dipsToPixels*65536 + 0.5)
+
+	c.stats.drawCallCount = 0
+	c.stats.glyphBatchCount = 0
+	c.stats.timer("Frame").start()
+
+	kept := c.retained != nil
+	if !kept {
+		c.retained = newRenderTarget(c.fn, c.sizePixels)
+	}
+	c.bindLayer(nil)
+	return kept
+}
+
+// present draws the whole retained render target to the window, which is then
+// swapped whole. The windows of the platforms expose neither the age of their
+// back buffers nor swaps of part of them, so the damage of a frame only saves
+// drawing into the retained render target, not presenting it.
+func (c *context) present() {
+	c.blitter.commitGlyphs(c)
+	c.fn.BindFramebuffer(FRAMEBUFFER, uint32(0))
+	c.clips = nil
+	c.fn.Disable(STENCIL_TEST)
+	c.apply(&drawState{ClipPixels: c.sizePixels.Rect(), Transform: math.Mat3Ident})
+	// The retained render target is copied as is.
+	c.fn.Disable(BLEND)
+	c.blitter.blitLayer(c, &layer{target: c.retained, opacity: 1})
+	c.fn.Enable(BLEND)
+}
+
+// drawFrame draws the canvas within the rectangles of damage, in DIPs, into
+// the retained render target, and presents it to the window. The whole canvas
+// is drawn if damage is empty, or the last frame was not kept. drawFrame
+// returns true if the whole canvas was drawn.
+func (c *context) drawFrame(canvas *CanvasImpl, sizeDips, sizePixels math.Size, damage []math.Rect) bool {
+	kept := c.beginDraw(sizeDips, sizePixels)
+
+	// The blurs of the backdrop sample around the damage, where the last
+	// frame may already be blurred.
+	window := sizePixels.Rect()
+	clips := []math.Rect{window}
+	if kept && len(damage) > 0 && !canvas.readsBackdrop {
+		clips = clips[:0]
+		for _, rect := range damage {
+			// The pixels of the rectangle may round differently than the clips
+			// of the controls within it.
+			clips = append(clips, c.resolution.rectDipsToPixels(rect).ExpandI(1).Overlap(window))
+		}
+	}
+
+	for _, clip := range clips {
+		stack := drawStateStack{
+			drawState{
+				ClipPixels: clip,
+				Transform:  math.Mat3Ident,
+			},
+		}
+
+		c.apply(stack.head())
+		c.fn.ClearColor(clearColorR, clearColorG, clearColorB, 1.0)
+		c.fn.Clear(COLOR_BUFFER_BIT)
+
+		canvas.draw(c, &stack)
+		if len(stack) != 1 {
+			panic("DrawStateStack count was not 1 after calling Canvas.Draw")
+		}
+
+		c.apply(stack.head())
+		c.blitter.commit(c)
+	}
+
+	c.present()
+	return len(clips) == 1 && clips[0] == window
+}
+
+// endDraw ends the frame, reaping the resources neither used by the frame nor
+// by canvas, which the next frames draw from.
+func (c *context) endDraw(canvas *CanvasImpl) {
+	canvas.mark(c)
+	c.blitter.mark(c)
+	c.reap()
+
+	c.stats.timer("Frame").stop()
+	c.stats.frameCount++
+	c.frame++
+}
+
+func (c *context) reap() {
+	// Reap any unused resources
+	for textureCtx, tc := range c.textureContexts {
+		if tc.lastContextUse != c.frame {
+			delete(c.textureContexts, textureCtx)
+			tc.destroy(c.fn)
+			c.stats.textureCount--
+		}
+	}
+
+	for stream, sc := range c.vertexStreamContexts {
+		if sc.lastContextUse != c.frame {
+			delete(c.vertexStreamContexts, stream)
+			sc.destroy(c.fn)
+			c.stats.vertexStreamCount--
+		}
+	}
+
+	for buffer, ic := range c.indexBufferContexts {
+		if ic.lastContextUse != c.frame {
+			delete(c.indexBufferContexts, buffer)
+			ic.destroy(c.fn)
+			c.stats.indexBufferCount--
+		}
+	}
+
+	// Forget the ramps of the gradients whose textures were reaped.
+	for gradient, ramp := range c.blitter.ramps {
+		if _, found := c.textureContexts[ramp]; !found {
+			delete(c.blitter.ramps, gradient)
+		}
+	}
+}
+
+// resourceStats sets the statistics of the resources of the context.
+func (c *context) resourceStats(stats *gxui.ViewportStats) {
+	for _, tc := range c.textureContexts {
+		stats.Textures++
+		stats.TextureBytes += tc.bytes
+	}
+	targets := c.renderTargets
+	if c.retained != nil {
+		targets = append(targets[:len(targets):len(targets)], c.retained)
+	}
+	for _, t := range targets {
+		stats.Textures++
+		stats.TextureBytes += t.texture.bytes
+	}
+	stats.VertexStreams = len(c.vertexStreamContexts)
+	stats.IndexBuffers = len(c.indexBufferContexts)
+}
+
+// markTexture marks the context of the texture, if it has one, as used by the
+// frame.
+func (c *context) markTexture(texture *TextureImpl) {
+	if tc, found := c.textureContexts[texture]; found {
+		tc.lastContextUse = c.frame
+	}
+}
+
+// markShape marks the contexts of the streams and indices of the shape, if it
+// has them, as used by the frame.
+func (c *context) markShape(s *shape) {
+	if s == nil {
+		return
+	}
+	for _, stream := range s.vertexBuffer.streams {
+		if sc, found := c.vertexStreamContexts[stream]; found {
+			sc.lastContextUse = c.frame
+		}
+	}
+	if ic, found := c.indexBufferContexts[s.indexBuffer]; found {
+		ic.lastContextUse = c.frame
+	}
+}
+
+// markBrush marks the textures of the pattern or gradient of the brush as used
+// by the frame.
+func (c *context) markBrush(brush gxui.Brush) {
+	if brush.Pattern != nil {
+		c.markTexture(brush.Pattern.Texture.(*TextureImpl))
+	}
+	if brush.Gradient != nil {
+		if ramp, found := c.blitter.ramps[brush.Gradient]; found {
+			c.markTexture(ramp)
+		}
+	}
+}
+
+func (c *context) getOrCreateTextureContext(targetTexture *TextureImpl) *textureContext {
+	textureCtx, found := c.textureContexts[targetTexture]
+	if !found {
+		textureCtx = targetTexture.newContext(c.fn)
+		c.textureContexts[targetTexture] = textureCtx
+		c.stats.textureCount++
+	}
+	textureCtx.lastContextUse = c.frame
+	return textureCtx
+}
+
+func (c *context) getOrCreateVertexStreamContext(targetStream *vertexStream) *vertexStreamContext {
+	stream, found := c.vertexStreamContexts[targetStream]
+	if !found {
+		stream = targetStream.newContext(c.fn)
+		c.vertexStreamContexts[targetStream] = stream
+		c.stats.vertexStreamCount++
+	}
+	stream.lastContextUse = c.frame
+	return stream
+}
+
+func (c *context) getOrCreateIndexBufferContext(targetBuffer *indexBuffer) *indexBufferContext {
+	buffer, found := c.indexBufferContexts[targetBuffer]
+	if !found {
+		buffer = targetBuffer.newContext()
+		c.indexBufferContexts[targetBuffer] = buffer
+		c.stats.indexBufferCount++
+	}
+	buffer.lastContextUse = c.frame
+	return buffer
+}
+
+// acquireRenderTarget returns a render target the size of the window, with
+// undefined content. Render targets are reused once released.
+func (c *context) acquireRenderTarget() *renderTarget {
+	if n := len(c.renderTargets); n > 0 {
+		target := c.renderTargets[n-1]
+		c.renderTargets = c.renderTargets[:n-1]
+		return target
+	}
+	return newRenderTarget(c.fn, c.sizePixels)
+}
+
+func (c *context) releaseRenderTarget(target *renderTarget) {
+	c.renderTargets = append(c.renderTargets, target)
+}
+
+// pushLayer returns a transparent layer, and binds its render target.
+func (c *context) pushLayer(opacity float32, mode gxui.BlendMode) *layer {
+	l := &layer{target: c.acquireRenderTarget(), opacity: opacity, mode: mode}
+	c.bindLayer(l)
+	c.fn.Disable(SCISSOR_TEST)
+	c.fn.ClearColor(0, 0, 0, 0)
+	c.fn.Clear(COLOR_BUFFER_BIT | STENCIL_BUFFER_BIT)
+	c.fn.Enable(SCISSOR_TEST)
+	return l
+}
+
+// popLayer binds the render target of the state, and composites the layer
+// onto it.
+func (c *context) popLayer(l *layer, state *drawState) {
+	c.bindLayer(state.Layer)
+	c.apply(state)
+	c.blitter.blitLayer(c, l)
+	c.releaseRenderTarget(l.target)
+}
+
+// bindLayer binds the render target of the layer, or the retained render
+// target of the window if l is nil.
+func (c *context) bindLayer(l *layer) {
+	// The batched glyphs are drawn to the render target they were batched for.
+	c.blitter.commitGlyphs(c)
+	if l != nil {
+		c.fn.BindFramebuffer(FRAMEBUFFER, l.target.framebuffer)
+	} else {
+		c.fn.BindFramebuffer(FRAMEBUFFER, c.retained.framebuffer)
+	}
+	// Each render target has its own stencil.
+	c.clips = nil
+	c.fn.Disable(STENCIL_TEST)
+}
+
+func (c *context) apply(state *drawState) {
+	rect := state.ClipPixels
+	cClip := c.clip
+	if cClip != rect {
+		c.clip = rect
+		sizePixels := c.sizePixels
+		rectSize := rect.Size()
+		c.fn.Scissor(int32(rect.Min.X), int32(sizePixels.Height)-int32(rect.Max.Y), int32(rectSize.Width), int32(rectSize.Height))
+	}
+	if !slices.Equal(c.clips, state.Clips) {
+		// The batched glyphs are drawn before the stencil changes.
+		c.blitter.commitGlyphs(c)
+		c.clips = state.Clips
+		c.blitter.stencil(c, c.clips)
+	}
+}
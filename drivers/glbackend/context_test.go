@@ -0,0 +1,194 @@
+package glbackend
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/badu/gxui"
+	gxfont "github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+// declarations matches the attributes and uniforms declared by the shaders.
+var declarations = regexp.MustCompile(`(attribute|uniform)\s+(\w+)\s+(\w+);`)
+
+type declaration struct {
+	name       string
+	shaderType uint32
+}
+
+// fakeFunctions draws nothing, and reports the attributes and uniforms declared
+// by the sources of the shaders as active.
+type fakeFunctions struct {
+	names    uint32
+	sources  map[uint32]string
+	programs map[uint32][]uint32
+	buffers  int // The number of buffers not deleted.
+	textures int // The number of textures not deleted.
+}
+
+func newFakeFunctions() *fakeFunctions {
+	return &fakeFunctions{sources: make(map[uint32]string), programs: make(map[uint32][]uint32)}
+}
+
+func (f *fakeFunctions) name() uint32 {
+	f.names++
+	return f.names
+}
+
+func (f *fakeFunctions) declarations(program uint32, kind string) []declaration {
+	types := map[string]uint32{
+		"float":     FLOAT,
+		"vec2":      FLOAT_VEC2,
+		"vec4":      FLOAT_VEC4,
+		"mat3":      FLOAT_MAT3,
+		"sampler2D": SAMPLER_2D,
+	}
+	var result []declaration
+	seen := make(map[string]bool)
+	for _, shader := range f.programs[program] {
+		for _, match := range declarations.FindAllStringSubmatch(f.sources[shader], -1) {
+			if match[1] == kind && !seen[match[3]] {
+				seen[match[3]] = true
+				result = append(result, declaration{match[3], types[match[2]]})
+			}
+		}
+	}
+	return result
+}
+
+func (f *fakeFunctions) ActiveTexture(uint32) {}
+func (f *fakeFunctions) AttachShader(program, shader uint32) {
+	f.programs[program] = append(f.programs[program], shader)
+}
+func (f *fakeFunctions) BindBuffer(uint32, uint32)           {}
+func (f *fakeFunctions) BindFramebuffer(uint32, uint32)      {}
+func (f *fakeFunctions) BindRenderbuffer(uint32, uint32)     {}
+func (f *fakeFunctions) BindTexture(uint32, uint32)          {}
+func (f *fakeFunctions) BlendFunc(uint32, uint32)            {}
+func (f *fakeFunctions) BlendFuncSeparate(_, _, _, _ uint32) {}
+func (f *fakeFunctions) BufferData(uint32, []byte, uint32)   {}
+func (f *fakeFunctions) Clear(uint32)                        {}
+func (f *fakeFunctions) ClearColor(_, _, _, _ float32)       {}
+func (f *fakeFunctions) ColorMask(_, _, _, _ bool)           {}
+func (f *fakeFunctions) CompileShader(uint32)                {}
+func (f *fakeFunctions) CopyTexSubImage2D(uint32, int32, int32, int32, int32, int32, int32, int32) {
+}
+func (f *fakeFunctions) CreateBuffer() uint32                            { f.buffers++; return f.name() }
+func (f *fakeFunctions) CreateFramebuffer() uint32                       { return f.name() }
+func (f *fakeFunctions) CreateProgram() uint32                           { return f.name() }
+func (f *fakeFunctions) CreateRenderbuffer() uint32                      { return f.name() }
+func (f *fakeFunctions) CreateShader(uint32) uint32                      { return f.name() }
+func (f *fakeFunctions) CreateTexture() uint32                           { f.textures++; return f.name() }
+func (f *fakeFunctions) DeleteBuffer(uint32)                             { f.buffers-- }
+func (f *fakeFunctions) DeleteFramebuffer(uint32)                        {}
+func (f *fakeFunctions) DeleteProgram(uint32)                            {}
+func (f *fakeFunctions) DeleteRenderbuffer(uint32)                       {}
+func (f *fakeFunctions) DeleteTexture(uint32)                            { f.textures-- }
+func (f *fakeFunctions) Disable(uint32)                                  {}
+func (f *fakeFunctions) DisableVertexAttribArray(uint32)                 {}
+func (f *fakeFunctions) DrawArrays(uint32, int, int)                     {}
+func (f *fakeFunctions) DrawElements(uint32, int32, uint32, int)         {}
+func (f *fakeFunctions) Enable(uint32)                                   {}
+func (f *fakeFunctions) EnableVertexAttribArray(uint32)                  {}
+func (f *fakeFunctions) FramebufferRenderbuffer(_, _, _, _ uint32)       {}
+func (f *fakeFunctions) FramebufferTexture2D(_, _, _, _ uint32, _ int32) {}
+func (f *fakeFunctions) GetActiveAttrib(program, index uint32) (string, int32, uint32) {
+	d := f.declarations(program, "attribute")[index]
+	return d.name, 1, d.shaderType
+}
+func (f *fakeFunctions) GetActiveUniform(program, index uint32) (string, int32, uint32) {
+	d := f.declarations(program, "uniform")[index]
+	return d.name, 1, d.shaderType
+}
+func (f *fakeFunctions) GetAttribLocation(uint32, string) uint32 { return 0 }
+func (f *fakeFunctions) GetError() uint32                        { return 0 }
+func (f *fakeFunctions) GetProgramInfoLog(uint32) string         { return "" }
+func (f *fakeFunctions) GetProgrami(program, name uint32) int {
+	switch name {
+	case ACTIVE_ATTRIBUTES:
+		return len(f.declarations(program, "attribute"))
+	case ACTIVE_UNIFORMS:
+		return len(f.declarations(program, "uniform"))
+	}
+	return TRUE
+}
+func (f *fakeFunctions) GetShaderInfoLog(uint32) string                                 { return "" }
+func (f *fakeFunctions) GetShaderi(uint32, uint32) int                                  { return TRUE }
+func (f *fakeFunctions) GetUniformLocation(uint32, string) int32                        { return 0 }
+func (f *fakeFunctions) LinkProgram(uint32)                                             {}
+func (f *fakeFunctions) RenderbufferStorage(uint32, uint32, int32, int32)               {}
+func (f *fakeFunctions) Scissor(_, _, _, _ int32)                                       {}
+func (f *fakeFunctions) ShaderSource(shader uint32, source string)                      { f.sources[shader] = source }
+func (f *fakeFunctions) StencilFunc(uint32, int32, uint32)                              {}
+func (f *fakeFunctions) StencilOp(_, _, _ uint32)                                       {}
+func (f *fakeFunctions) TexImage2D(uint32, int32, int32, int32, uint32, uint32, []byte) {}
+func (f *fakeFunctions) TexParameteri(uint32, uint32, int32)                            {}
+func (f *fakeFunctions) Uniform1f(int32, float32)                                       {}
+func (f *fakeFunctions) Uniform1fv(int32, []float32)                                    {}
+func (f *fakeFunctions) Uniform1i(int32, int32)                                         {}
+func (f *fakeFunctions) Uniform2fv(int32, []float32)                                    {}
+func (f *fakeFunctions) Uniform3fv(int32, []float32)                                    {}
+func (f *fakeFunctions) Uniform4fv(int32, []float32)                                    {}
+func (f *fakeFunctions) UniformMatrix2fv(int32, []float32)                              {}
+func (f *fakeFunctions) UniformMatrix3fv(int32, []float32)                              {}
+func (f *fakeFunctions) UniformMatrix4fv(int32, []float32)                              {}
+func (f *fakeFunctions) UseProgram(uint32)                                              {}
+func (f *fakeFunctions) VertexAttribPointer(uint32, int32, uint32, bool, int32, int)    {}
+func (f *fakeFunctions) Viewport(_, _, _, _ int32)                                      {}
+
+func TestPartialFramesReapResources(t *testing.T) {
+	fn := newFakeFunctions()
+	ctx := newContext(fn)
+	defer ctx.destroy()
+
+	regular, err := newFont(gxfont.Default, 0, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := math.Size{Width: 100, Height: 50}
+	stops := []gxui.GradientStop{{Offset: 0, Color: gxui.Black}, {Offset: 1, Color: gxui.White}}
+	triangle := gxui.Polygon{
+		{Position: math.Point{X: 60, Y: 10}},
+		{Position: math.Point{X: 90, Y: 10}},
+		{Position: math.Point{X: 90, Y: 40}},
+	}
+
+	// The child is clipped out of the damage of the partial frames, which do
+	// not draw it.
+	child := NewCanvas(fn, size)
+	child.DrawPolygon(triangle, gxui.CreatePen(1, gxui.White), gxui.CreateLinearGradientBrush(0, stops...))
+	child.Complete()
+
+	frame := func(damage []math.Rect) gxui.ViewportStats {
+		// Each frame draws new shapes, and glyphs batched anew.
+		canvas := NewCanvas(fn, size)
+		canvas.Push()
+		canvas.AddClip(math.CreateRect(50, 0, 100, 50))
+		canvas.DrawCanvas(child, math.ZeroPoint)
+		canvas.Pop()
+		canvas.DrawPolygon(triangle, gxui.TransparentPen, gxui.CreateBrush(gxui.Red))
+		canvas.DrawRunes(regular, []rune("gxui"), []math.Point{{X: 0}, {X: 8}, {X: 16}, {X: 24}}, gxui.White)
+		canvas.Complete()
+
+		ctx.drawFrame(canvas, size, size, damage)
+		ctx.endDraw(canvas)
+		var stats gxui.ViewportStats
+		ctx.resourceStats(&stats)
+		return stats
+	}
+
+	frame(nil)
+	damage := []math.Rect{math.CreateRect(0, 0, 40, 20)}
+	first := frame(damage)
+	for range 100 {
+		frame(damage)
+	}
+	last := frame(damage)
+	test_helper.AssertEquals(t, first, last)
+	test_helper.AssertEquals(t, first.VertexStreams+first.IndexBuffers, fn.buffers)
+
+	// The ramp of the gradient of the child is kept, although not drawn.
+	test_helper.AssertEquals(t, 1, len(ctx.blitter.ramps))
+}
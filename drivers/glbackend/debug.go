@@ -1,7 +1,4 @@
-// Copyright 2015 The Go Authors. All rights reserved.
-// Use of this source code is governed by a BSD-style
-// license that can be found in the LICENSE file.
-package gl
+package glbackend
 
 import (
 	"runtime"
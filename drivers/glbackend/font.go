@@ -0,0 +1,266 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/pkg/shaping"
+	"github.com/badu/gxui/pkg/textlayout"
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+)
+
+// The distance fields of the glyphs are rasterized at distanceFieldSize pixels
+// per em, and reach full or zero alpha distanceFieldSpread pixels away from the
+// outlines.
+const (
+	distanceFieldSize   = 48
+	distanceFieldSpread = 6
+)
+
+// faceResolution is a font drawing glyphs, at a resolution.
+type faceResolution struct {
+	face       *textlayout.Font
+	resolution resolution
+}
+
+// font draws the glyphs laid out by its textlayout.Font.
+type font struct {
+	*textlayout.Font
+	// tables holds the glyph tables of the font and of its fallbacks, by
+	// resolution.
+	tables map[faceResolution]*glyphTable
+	// sizes holds the copies of the font resized to other sizes.
+	sizes map[int]*font
+	// distanceField is true when the glyphs are drawn from their distance
+	// fields, held by distanceFields, rather than rasterized per resolution.
+	distanceField  bool
+	distanceFields map[*textlayout.Font]*glyphTable
+}
+
+// newFont loads the font at index of the font data, which is only a font
+// collection's when index is not 0.
+func newFont(data []byte, index, size int) (*font, error) {
+	layout, err := textlayout.New(data, index, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLayoutFont(layout), nil
+}
+
+// newLayoutFont returns the font drawing the glyphs laid out by layout.
+func newLayoutFont(layout *textlayout.Font) *font {
+	return &font{
+		Font:           layout,
+		tables:         make(map[faceResolution]*glyphTable),
+		distanceFields: make(map[*textlayout.Font]*glyphTable),
+	}
+}
+
+// resized returns the font at size, with its fallbacks at size too. The fonts
+// resized are kept by size.
+func (f *font) resized(size int) *font {
+	if size == f.Size() {
+		return f
+	}
+	if result, found := f.sizes[size]; found {
+		return result
+	}
+
+	result := newLayoutFont(f.Font.Resized(size))
+	result.distanceField = f.distanceField
+	if f.sizes == nil {
+		f.sizes = make(map[int]*font)
+	}
+	f.sizes[size] = result
+	return result
+}
+
+// glyphTable returns the glyph table of face, the font or one of its
+// fallbacks, at the resolution.
+func (f *font) glyphTable(face *textlayout.Font, resolution resolution) *glyphTable {
+	key := faceResolution{face, resolution}
+	result, found := f.tables[key]
+	if !found {
+		opt := truetype.Options{
+			Size:              float64(face.Size()),
+			DPI:               float64(resolution.intDipsToPixels(72)),
+			Hinting:           imageFont.HintingFull,
+			GlyphCacheEntries: 1,
+			SubPixelsX:        1,
+			SubPixelsY:        1,
+		}
+		result = newGlyphTable(shaping.NewFace(face.Shaper(), &opt))
+		f.tables[key] = result
+	}
+	return result
+}
+
+// distanceFieldTable returns the glyph table of the distance fields of the
+// glyphs of face, the font or one of its fallbacks, drawn at any size.
+func (f *font) distanceFieldTable(face *textlayout.Font) *glyphTable {
+	result, found := f.distanceFields[face]
+	if !found {
+		result = newGlyphTable(shaping.NewDistanceFieldFace(face.Shaper(), distanceFieldSize, distanceFieldSpread))
+		f.distanceFields[face] = result
+	}
+	return result
+}
+
+func (f *font) DrawRunes(fn Functions, ctx *context, runes []rune, offsets []math.Point, color gxui.Color, state *drawState) {
+	if len(runes) != len(offsets) {
+		panic(fmt.Errorf("there must be the same number of runes to offsets. Got %d runes and %d offsets", len(runes), len(offsets)))
+	}
+
+	atResolution := ctx.resolution
+
+	for _, glyph := range f.Glyphs(runes) {
+		if unicode.IsSpace(runes[glyph.Cluster]) {
+			continue
+		}
+		if f.distanceField {
+			f.drawDistanceField(ctx, glyph, offsets, color, state)
+			continue
+		}
+
+		page := f.glyphTable(glyph.Font, atResolution).get(glyph.Index)
+		glyphTexture := page.texture()
+		entry := page.get(glyph.Index)
+		srcRect := entry.bounds.Offset(entry.offset)
+		dstRect := entry.bounds.Offset(atResolution.pointDipsToPixels(glyph.Origin(offsets)))
+		textureCtx := ctx.getOrCreateTextureContext(glyphTexture)
+		ctx.blitter.blitGlyph(ctx, textureCtx, color, srcRect, dstRect, state)
+	}
+}
+
+// drawDistanceField draws the glyph from its distance field, scaled to the size
+// of its font at the resolution of the context.
+func (f *font) drawDistanceField(ctx *context, glyph textlayout.Glyph, offsets []math.Point, color gxui.Color, state *drawState) {
+	page := f.distanceFieldTable(glyph.Font).get(glyph.Index)
+	entry := page.get(glyph.Index)
+	pixels := ctx.resolution.dipsToPixels()
+	scale := float32(glyph.Font.Size()) * pixels / distanceFieldSize
+	origin := glyph.Origin(offsets).Vec2().MulS(pixels).Add(entry.bounds.Min.Vec2().MulS(scale))
+	textureCtx := ctx.getOrCreateTextureContext(page.texture())
+	ctx.blitter.blitDistanceFieldGlyph(ctx, textureCtx, color, entry.bounds.Offset(entry.offset), origin, scale, distanceFieldSpread, state)
+}
+
+// mark marks the glyph pages of the font and of its fallbacks, at the
+// resolution of the context, as used by the frame.
+func (f *font) mark(ctx *context) {
+	for _, face := range f.Faces() {
+		if table, found := f.tables[faceResolution{face, ctx.resolution}]; found {
+			table.mark(ctx)
+		}
+		if table, found := f.distanceFields[face]; found {
+			table.mark(ctx)
+		}
+	}
+}
+
+// fontFamily is a font drawing the runes missing from its first font with the
+// first of the others holding them. The family has the metrics of its first
+// font, so that lines keep their height and baseline whichever fonts draw
+// them.
+type fontFamily struct {
+	*font
+	fonts []gxui.Font
+	// sizes holds the copies of the family resized to other sizes.
+	sizes map[int]*fontFamily
+}
+
+// newFontFamily returns the family of the fonts, which must have been created
+// by the driver. The fonts following the first are used at its size.
+func newFontFamily(fonts []gxui.Font) (*fontFamily, error) {
+	if len(fonts) == 0 {
+		return nil, fmt.Errorf("a font family needs at least one font")
+	}
+	faces := make([]*font, len(fonts))
+	for i, f := range fonts {
+		face, ok := f.(*font)
+		if !ok {
+			return nil, fmt.Errorf("font %d of the family was not created by this driver", i)
+		}
+		faces[i] = face
+	}
+
+	fallbacks := make([]*textlayout.Font, 0, len(faces)-1)
+	for _, face := range faces[1:] {
+		fallbacks = append(fallbacks, face.Font)
+	}
+	primary := newLayoutFont(faces[0].Font.Family(fallbacks))
+	primary.distanceField = faces[0].distanceField
+	return &fontFamily{font: primary, fonts: append([]gxui.Font{}, fonts...)}, nil
+}
+
+func (f *fontFamily) Fonts() []gxui.Font {
+	return append([]gxui.Font{}, f.fonts...)
+}
+
+// resized returns the family at size, whose fonts are all at size. The
+// families resized are kept by size.
+func (f *fontFamily) resized(size int) *fontFamily {
+	if size == f.Size() {
+		return f
+	}
+	if result, found := f.sizes[size]; found {
+		return result
+	}
+
+	result := &fontFamily{font: f.font.resized(size), fonts: make([]gxui.Font, len(f.fonts))}
+	for i, face := range f.fonts {
+		result.fonts[i] = face.(*font).resized(size)
+	}
+	if f.sizes == nil {
+		f.sizes = make(map[int]*fontFamily)
+	}
+	f.sizes[size] = result
+	return result
+}
+
+// newResizedFont returns f, a font or font family created by the driver, at
+// size.
+func newResizedFont(f gxui.Font, size int) (gxui.Font, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid font size %d", size)
+	}
+	switch f := f.(type) {
+	case *font:
+		return f.resized(size), nil
+	case *fontFamily:
+		return f.resized(size), nil
+	}
+	return nil, fmt.Errorf("the font was not created by this driver")
+}
+
+// newDistanceFieldFont returns a copy of f, a font or font family created by
+// the driver, drawing its glyphs from their distance fields.
+func newDistanceFieldFont(f gxui.Font) (gxui.Font, error) {
+	switch f := f.(type) {
+	case *font:
+		result := newLayoutFont(f.Font)
+		result.distanceField = true
+		return result, nil
+	case *fontFamily:
+		primary := newLayoutFont(f.font.Font)
+		primary.distanceField = true
+		return &fontFamily{font: primary, fonts: f.fonts}, nil
+	}
+	return nil, fmt.Errorf("the font was not created by this driver")
+}
+
+// driverFont returns the font drawing the runes of f, a font or font family
+// created by the driver.
+func driverFont(f gxui.Font) *font {
+	if family, ok := f.(*fontFamily); ok {
+		return family.font
+	}
+	return f.(*font)
+}
@@ -0,0 +1,135 @@
+package glbackend
+
+// Functions is the table of the OpenGL functions the backend draws with. The
+// objects are named by their GL names, and the uniforms by their locations.
+// The functions are called on the driver routine, with the GL context of the
+// viewport drawn current.
+type Functions interface {
+	ActiveTexture(texture uint32)
+	AttachShader(program, shader uint32)
+	BindBuffer(target, buffer uint32)
+	BindFramebuffer(target, framebuffer uint32)
+	BindRenderbuffer(target, renderbuffer uint32)
+	BindTexture(target, texture uint32)
+	BlendFunc(sFactor, dFactor uint32)
+	BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32)
+	BufferData(target uint32, data []byte, usage uint32)
+	Clear(mask uint32)
+	ClearColor(red, green, blue, alpha float32)
+	ColorMask(red, green, blue, alpha bool)
+	CompileShader(shader uint32)
+	CopyTexSubImage2D(target uint32, level, xOffset, yOffset, x, y, width, height int32)
+	CreateBuffer() uint32
+	CreateFramebuffer() uint32
+	CreateProgram() uint32
+	CreateRenderbuffer() uint32
+	CreateShader(shaderType uint32) uint32
+	CreateTexture() uint32
+	DeleteBuffer(buffer uint32)
+	DeleteFramebuffer(framebuffer uint32)
+	DeleteProgram(program uint32)
+	DeleteRenderbuffer(renderbuffer uint32)
+	DeleteTexture(texture uint32)
+	Disable(capability uint32)
+	DisableVertexAttribArray(index uint32)
+	DrawArrays(mode uint32, first, count int)
+	DrawElements(mode uint32, count int32, indexType uint32, offset int)
+	Enable(capability uint32)
+	EnableVertexAttribArray(index uint32)
+	FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32)
+	FramebufferTexture2D(target, attachment, textureTarget, texture uint32, level int32)
+	GetActiveAttrib(program, index uint32) (name string, size int32, attribType uint32)
+	GetActiveUniform(program, index uint32) (name string, size int32, uniformType uint32)
+	GetAttribLocation(program uint32, name string) uint32
+	GetError() uint32
+	GetProgramInfoLog(program uint32) string
+	GetProgrami(program, name uint32) int
+	GetShaderInfoLog(shader uint32) string
+	GetShaderi(shader, name uint32) int
+	GetUniformLocation(program uint32, name string) int32
+	LinkProgram(program uint32)
+	RenderbufferStorage(target, internalFormat uint32, width, height int32)
+	Scissor(x, y, width, height int32)
+	ShaderSource(shader uint32, source string)
+	StencilFunc(function uint32, ref int32, mask uint32)
+	StencilOp(fail, zFail, zPass uint32)
+	TexImage2D(target uint32, level, width, height int32, format, pixelType uint32, pixels []byte)
+	TexParameteri(target, name uint32, param int32)
+	Uniform1f(location int32, value float32)
+	Uniform1fv(location int32, values []float32)
+	Uniform1i(location int32, value int32)
+	Uniform2fv(location int32, values []float32)
+	Uniform3fv(location int32, values []float32)
+	Uniform4fv(location int32, values []float32)
+	UniformMatrix2fv(location int32, values []float32)
+	UniformMatrix3fv(location int32, values []float32)
+	UniformMatrix4fv(location int32, values []float32)
+	UseProgram(program uint32)
+	VertexAttribPointer(index uint32, size int32, attribType uint32, normalized bool, stride int32, offset int)
+	Viewport(x, y, width, height int32)
+}
+
+// The OpenGL constants passed to the functions.
+const (
+	ACTIVE_ATTRIBUTES             = 0x8B89
+	ACTIVE_UNIFORMS               = 0x8B86
+	ALPHA                         = 0x1906
+	ARRAY_BUFFER                  = 0x8892
+	BLEND                         = 0x0BE2
+	CLAMP_TO_EDGE                 = 0x812F
+	COLOR_ATTACHMENT0             = 0x8CE0
+	COLOR_BUFFER_BIT              = 0x4000
+	COMPILE_STATUS                = 0x8B81
+	DST_COLOR                     = 0x0306
+	ELEMENT_ARRAY_BUFFER          = 0x8893
+	EQUAL                         = 0x0202
+	FLOAT                         = 0x1406
+	FLOAT_MAT2                    = 0x8B5A
+	FLOAT_MAT3                    = 0x8B5B
+	FLOAT_MAT4                    = 0x8B5C
+	FLOAT_VEC2                    = 0x8B50
+	FLOAT_VEC3                    = 0x8B51
+	FLOAT_VEC4                    = 0x8B52
+	FRAGMENT_SHADER               = 0x8B30
+	FRAMEBUFFER                   = 0x8D40
+	INCR                          = 0x1E02
+	INT                           = 0x1404
+	INVALID_ENUM                  = 0x0500
+	INVALID_FRAMEBUFFER_OPERATION = 0x0506
+	INVALID_OPERATION             = 0x0502
+	INVALID_VALUE                 = 0x0501
+	KEEP                          = 0x1E00
+	LINEAR                        = 0x2601
+	LINES                         = 0x0001
+	LINE_LOOP                     = 0x0002
+	LINE_STRIP                    = 0x0003
+	LINK_STATUS                   = 0x8B82
+	ONE                           = 0x0001
+	ONE_MINUS_SRC_ALPHA           = 0x0303
+	ONE_MINUS_SRC_COLOR           = 0x0301
+	POINTS                        = 0x0000
+	RENDERBUFFER                  = 0x8D41
+	RGBA                          = 0x1908
+	SAMPLER_2D                    = 0x8B5E
+	SCISSOR_TEST                  = 0x0C11
+	SRC_ALPHA                     = 0x0302
+	STATIC_DRAW                   = 0x88E4
+	STENCIL_ATTACHMENT            = 0x8D20
+	STENCIL_BUFFER_BIT            = 0x00000400
+	STENCIL_INDEX8                = 0x8D48
+	STENCIL_TEST                  = 0x0B90
+	TEXTURE0                      = 0x84C0
+	TEXTURE_2D                    = 0x0DE1
+	TEXTURE_MAG_FILTER            = 0x2800
+	TEXTURE_MIN_FILTER            = 0x2801
+	TEXTURE_WRAP_S                = 0x2802
+	TEXTURE_WRAP_T                = 0x2803
+	TRIANGLES                     = 0x0004
+	TRIANGLE_FAN                  = 0x0006
+	TRIANGLE_STRIP                = 0x0005
+	TRUE                          = 1
+	UNSIGNED_BYTE                 = 0x1401
+	UNSIGNED_INT                  = 0x1405
+	UNSIGNED_SHORT                = 0x1403
+	VERTEX_SHADER                 = 0x8B31
+)
@@ -1,4 +1,8 @@
-package purego
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"fmt"
@@ -8,7 +12,7 @@ import (
 	"os"
 
 	"github.com/badu/gxui/pkg/math"
-	imageFont "golang.org/x/image/font"
+	"github.com/badu/gxui/pkg/shaping"
 	"golang.org/x/image/math/fixed"
 )
 
@@ -27,7 +31,7 @@ type glyphEntry struct {
 
 type glyphPage struct {
 	image     *image.Alpha
-	entries   map[rune]glyphEntry
+	entries   map[shaping.GlyphIndex]glyphEntry
 	tex       *TextureImpl
 	size      math.Size // in pixels
 	nextPoint math.Point
@@ -46,9 +50,9 @@ func align(width, size int) int {
 	return (width + size - 1) & ^(size - 1)
 }
 
-func newGlyphPage(face imageFont.Face, whichRune rune) *glyphPage {
-	// Start the page big enough to hold the initial rune.
-	glyphBounds, _, _ := face.GlyphBounds(whichRune)
+func newGlyphPage(face glyphFace, glyph shaping.GlyphIndex) *glyphPage {
+	// Start the page big enough to hold the initial glyph.
+	glyphBounds, _, _ := face.GlyphBounds(glyph)
 	bounds := rectangle26_6toRect(glyphBounds)
 	size := math.Size{Width: glyphPageWidth, Height: glyphPageHeight}.Max(bounds.Size())
 	size.Width = align(size.Width, glyphSizeAlignment)
@@ -57,10 +61,10 @@ func newGlyphPage(face imageFont.Face, whichRune rune) *glyphPage {
 	page := &glyphPage{
 		image:     image.NewAlpha(image.Rect(0, 0, size.Width, size.Height)),
 		size:      size,
-		entries:   make(map[rune]glyphEntry),
+		entries:   make(map[shaping.GlyphIndex]glyphEntry),
 		rowHeight: 0,
 	}
-	page.add(face, whichRune)
+	page.add(face, glyph)
 	return page
 }
 
@@ -84,12 +88,12 @@ func (p *glyphPage) commit() {
 	}
 }
 
-func (p *glyphPage) add(face imageFont.Face, whichRune rune) bool {
-	if _, found := p.entries[whichRune]; found {
+func (p *glyphPage) add(face glyphFace, glyph shaping.GlyphIndex) bool {
+	if _, found := p.entries[glyph]; found {
 		panic("Glyph already added to glyph page")
 	}
 
-	glyphBounds, mask, maskp, _, _ := face.Glyph(fixed.Point26_6{}, whichRune)
+	glyphBounds, mask, maskp, _, _ := face.Glyph(fixed.Point26_6{}, glyph)
 	bounds := math.CreateRect(glyphBounds.Min.X, glyphBounds.Min.Y, glyphBounds.Max.X, glyphBounds.Max.Y)
 
 	w, h := bounds.Size().WH()
@@ -108,7 +112,7 @@ func (p *glyphPage) add(face imageFont.Face, whichRune rune) bool {
 
 	draw.Draw(p.image, image.Rect(x, y, x+w, y+h), mask, maskp, draw.Src)
 
-	p.entries[whichRune] = glyphEntry{
+	p.entries[glyph] = glyphEntry{
 		offset: math.Point{X: x, Y: y}.Sub(bounds.Min),
 		bounds: bounds,
 	}
@@ -128,6 +132,6 @@ func (p *glyphPage) texture() *TextureImpl {
 	return p.tex
 }
 
-func (p *glyphPage) get(rune rune) glyphEntry {
-	return p.entries[rune]
+func (p *glyphPage) get(glyph shaping.GlyphIndex) glyphEntry {
+	return p.entries[glyph]
 }
@@ -0,0 +1,59 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
+
+import (
+	"image"
+
+	"github.com/badu/gxui/pkg/shaping"
+	"golang.org/x/image/math/fixed"
+)
+
+// glyphFace rasterizes the glyphs of a glyph table, as a shaping.Face or a
+// shaping.DistanceFieldFace.
+type glyphFace interface {
+	Glyph(dot fixed.Point26_6, index shaping.GlyphIndex) (dr image.Rectangle, mask *image.Alpha, maskp image.Point, advance fixed.Int26_6, ok bool)
+	GlyphBounds(index shaping.GlyphIndex) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool)
+}
+
+type glyphTable struct {
+	face  glyphFace
+	index map[shaping.GlyphIndex]int
+	pages []*glyphPage
+}
+
+func newGlyphTable(face glyphFace) *glyphTable {
+	return &glyphTable{face: face, index: make(map[shaping.GlyphIndex]int)}
+}
+
+func (t *glyphTable) get(glyph shaping.GlyphIndex) *glyphPage {
+	index, found := t.index[glyph]
+	if found {
+		return t.pages[index]
+	}
+
+	if len(t.pages) == 0 {
+		t.pages = append(t.pages, newGlyphPage(t.face, glyph))
+	} else {
+		page := t.pages[len(t.pages)-1]
+		if !page.add(t.face, glyph) {
+			page = newGlyphPage(t.face, glyph)
+			t.pages = append(t.pages, page)
+		}
+	}
+
+	index = len(t.pages) - 1
+	t.index[glyph] = index
+	return t.pages[index]
+}
+
+// mark marks the textures of the pages of the table as used by the frame.
+func (t *glyphTable) mark(ctx *context) {
+	for _, page := range t.pages {
+		if page.tex != nil {
+			ctx.markTexture(page.tex)
+		}
+	}
+}
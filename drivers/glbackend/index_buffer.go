@@ -1,4 +1,8 @@
-package purego
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"fmt"
@@ -6,12 +10,12 @@ import (
 )
 
 type indexBuffer struct {
-	fn       *Functions
+	fn       Functions
 	data     []byte
 	primType primitiveType
 }
 
-func newIndexBuffer(fn *Functions, primType primitiveType, dataU16 []uint16) *indexBuffer {
+func newIndexBuffer(fn Functions, primType primitiveType, dataU16 []uint16) *indexBuffer {
 	switch primType {
 	case ptUbyte, ptUshort, ptUint:
 		if !primType.isArrayOfType(dataU16) {
@@ -58,16 +62,16 @@ type indexBufferContext struct {
 	length   int32
 }
 
-func (c *indexBufferContext) destroy(fn *Functions) {
+func (c *indexBufferContext) destroy(fn Functions) {
 	globalStats.indexBufferContextCount.dec()
 
 	fn.DeleteBuffer(c.glBuffer)
 	c.glBuffer = 0
 }
 
-func (c *indexBufferContext) render(mode drawMode, fn *Functions) {
+func (c *indexBufferContext) render(mode drawMode, fn Functions) {
 	fn.BindBuffer(ELEMENT_ARRAY_BUFFER, c.glBuffer)
-	fn.DrawElements(Enum(mode), c.length, Enum(c.primType), 0)
+	fn.DrawElements(uint32(mode), c.length, uint32(c.primType), 0)
 	fn.BindBuffer(ELEMENT_ARRAY_BUFFER, uint32(0))
 	checkError(fn)
 }
@@ -0,0 +1,81 @@
+package glbackend
+
+import (
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// renderTarget is an offscreen framebuffer the size of the window, with its own
+// stencil for the clips that are not aligned to the axes.
+type renderTarget struct {
+	framebuffer uint32
+	stencil     uint32
+	texture     *textureContext
+}
+
+func newRenderTarget(fn Functions, sizePixels math.Size) *renderTarget {
+	w, h := sizePixels.WH()
+	texture := fn.CreateTexture()
+	fn.BindTexture(TEXTURE_2D, texture)
+	fn.TexImage2D(TEXTURE_2D, 0, int32(w), int32(h), RGBA, UNSIGNED_BYTE, nil)
+	fn.TexParameteri(TEXTURE_2D, TEXTURE_MAG_FILTER, LINEAR)
+	fn.TexParameteri(TEXTURE_2D, TEXTURE_MIN_FILTER, LINEAR)
+	fn.TexParameteri(TEXTURE_2D, TEXTURE_WRAP_S, CLAMP_TO_EDGE)
+	fn.TexParameteri(TEXTURE_2D, TEXTURE_WRAP_T, CLAMP_TO_EDGE)
+	fn.BindTexture(TEXTURE_2D, uint32(0))
+
+	stencil := fn.CreateRenderbuffer()
+	fn.BindRenderbuffer(RENDERBUFFER, stencil)
+	fn.RenderbufferStorage(RENDERBUFFER, STENCIL_INDEX8, int32(w), int32(h))
+	fn.BindRenderbuffer(RENDERBUFFER, uint32(0))
+
+	framebuffer := fn.CreateFramebuffer()
+	fn.BindFramebuffer(FRAMEBUFFER, framebuffer)
+	fn.FramebufferTexture2D(FRAMEBUFFER, COLOR_ATTACHMENT0, TEXTURE_2D, texture, 0)
+	fn.FramebufferRenderbuffer(FRAMEBUFFER, STENCIL_ATTACHMENT, RENDERBUFFER, stencil)
+	fn.BindFramebuffer(FRAMEBUFFER, uint32(0))
+	checkError(fn)
+
+	globalStats.textureContextCount.inc()
+	return &renderTarget{
+		framebuffer: framebuffer,
+		stencil:     stencil,
+		// The rows of the framebuffer go up, and what is drawn into it is
+		// premultiplied.
+		texture: &textureContext{
+			texture:    texture,
+			sizePixels: sizePixels,
+			flipY:      true,
+			pma:        true,
+			bytes:      4 * w * h,
+		},
+	}
+}
+
+func (t *renderTarget) destroy(fn Functions) {
+	fn.DeleteFramebuffer(t.framebuffer)
+	fn.DeleteRenderbuffer(t.stencil)
+	t.texture.destroy(fn)
+}
+
+// layer is drawn into by the canvas ops between PushLayer and PopLayer.
+type layer struct {
+	target  *renderTarget
+	opacity float32
+	mode    gxui.BlendMode
+}
+
+// blendFuncs returns the blending factors compositing the colors of a layer
+// with the mode. The alpha of layers is always blended as with BlendNormal.
+func blendFuncs(mode gxui.BlendMode) (src, dst uint32) {
+	switch mode {
+	case gxui.BlendMultiply:
+		return DST_COLOR, ONE_MINUS_SRC_ALPHA
+	case gxui.BlendScreen:
+		return ONE, ONE_MINUS_SRC_COLOR
+	case gxui.BlendAdd:
+		return ONE, ONE
+	default:
+		return ONE, ONE_MINUS_SRC_ALPHA
+	}
+}
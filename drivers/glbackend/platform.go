@@ -0,0 +1,69 @@
+package glbackend
+
+import "github.com/badu/gxui"
+
+// Platform creates the windows the backend draws into, and delivers their
+// events. The functions are called on the driver routine.
+type Platform interface {
+	// CreateWindow creates a window with an OpenGL context, of the size in
+	// screen coordinates. A fullscreen window covers the primary monitor, and
+	// takes the size of its video mode when the size is zero.
+	CreateWindow(width, height int, title string, fullscreen bool) (Window, error)
+	// WaitEvents blocks until the windows receive events, and calls their
+	// callbacks.
+	WaitEvents()
+	// PostEmptyEvent wakes a call to WaitEvents.
+	PostEmptyEvent()
+}
+
+// Window is a window of a Platform.
+type Window interface {
+	// SetCallbacks sets the functions called by WaitEvents on the events of
+	// the window.
+	SetCallbacks(callbacks WindowCallbacks)
+	MakeContextCurrent()
+	SwapBuffers()
+	// Size returns the size of the window in screen coordinates.
+	Size() (width, height int)
+	SetSize(width, height int)
+	// FramebufferSize returns the size of the window in pixels.
+	FramebufferSize() (width, height int)
+	Pos() (x, y int)
+	SetPos(x, y int)
+	// CursorPos returns the position of the cursor relative to the window, in
+	// screen coordinates.
+	CursorPos() (x, y float64)
+	// MouseState returns the mouse buttons held down over the window.
+	MouseState() gxui.MouseState
+	SetTitle(title string)
+	Clipboard() string
+	SetClipboard(str string)
+	Show()
+	Hide()
+	Destroy()
+}
+
+// Action is what happened to a key or a mouse button.
+type Action int
+
+const (
+	Release Action = iota
+	Press
+	Repeat
+)
+
+// WindowCallbacks are the functions called on the events of a Window. The
+// keys, buttons and modifiers are translated to gxui by the Platform.
+type WindowCallbacks struct {
+	Close           func()
+	Pos             func(x, y int)
+	Size            func(width, height int)
+	FramebufferSize func(width, height int)
+	CursorPos       func(x, y float64)
+	CursorEnter     func(entered bool)
+	Scroll          func(xOffset, yOffset float64)
+	MouseButton     func(button gxui.MouseButton, action Action, modifier gxui.KeyboardModifier)
+	Key             func(key gxui.KeyboardKey, action Action, modifier gxui.KeyboardModifier)
+	Char            func(char rune, modifier gxui.KeyboardModifier)
+	Refresh         func()
+}
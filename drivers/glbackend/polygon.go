@@ -0,0 +1,42 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
+
+import (
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/geometry"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// trianglesToShape returns the shape of the triangles, with a fringe smoothing
+// the edges of their union. overlapping is false for triangles known not to
+// overlap each other, such as those returned by geometry.Triangulate.
+func trianglesToShape(triangles []math.Vec2, overlapping bool) *shape {
+	if len(triangles) == 0 {
+		return nil
+	}
+	positions, fringes, coverages := antialias(triangles, overlapping)
+	return newShape(newVertexBuffer(
+		newVertexStream("aPosition", stFloatVec2, positions),
+		newVertexStream("aFringe", stFloatVec2, fringes),
+		newVertexStream("aCoverage", stFloatVec1, coverages),
+	), nil, dmTriangles)
+}
+
+// rectTriangles returns the two triangles covering the rectangle.
+func rectTriangles(r math.Rect) []math.Vec2 {
+	tl, tr := r.TopLeft().Vec2(), r.TopRight().Vec2()
+	bl, br := r.BottomLeft().Vec2(), r.BottomRight().Vec2()
+	return []math.Vec2{tl, tr, br, tl, br, bl}
+}
+
+func closedPolyToShape(p gxui.Polygon, pen gxui.Pen) (fillShape, edgeShape *shape) {
+	fill, edge := geometry.ClosedPolygon(p, pen)
+	return trianglesToShape(geometry.Triangulate(fill), false), trianglesToShape(edge, true)
+}
+
+func openPolyToShape(fn Functions, p gxui.Polygon, pen gxui.Pen) *shape {
+	return trianglesToShape(geometry.OpenPolygon(p, pen), true)
+}
@@ -1,4 +1,8 @@
-package purego
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"fmt"
@@ -12,8 +16,8 @@ type shaderProgram struct {
 	program    uint32
 }
 
-func compile(fn *Functions, source string, shaderType int) uint32 {
-	shader := fn.CreateShader(Enum(shaderType))
+func compile(fn Functions, source string, shaderType int) uint32 {
+	shader := fn.CreateShader(uint32(shaderType))
 	fn.ShaderSource(shader, source)
 
 	fn.CompileShader(shader)
@@ -130,7 +134,7 @@ func (s *shaderProgram) bind(ctx *context, buffer *vertexBuffer, uniforms unifor
 	checkError(ctx.fn)
 }
 
-func (s *shaderProgram) unbind(fn *Functions) {
+func (s *shaderProgram) unbind(fn Functions) {
 	for _, a := range s.attributes {
 		if a.name == "" {
 			panic("empty attribute name on unbind")
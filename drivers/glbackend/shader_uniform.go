@@ -1,4 +1,8 @@
-package purego
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"fmt"
@@ -15,7 +19,7 @@ type shaderUniform struct {
 	textureUnit int32
 }
 
-func (u *shaderUniform) bind(fn *Functions, target interface{}) {
+func (u *shaderUniform) bind(fn Functions, target interface{}) {
 	switch u.shaderType {
 	case stFloatMat2:
 		fn.UniformMatrix2fv(u.location, target.([]float32))
@@ -83,7 +87,7 @@ func (u *shaderUniform) bind(fn *Functions, target interface{}) {
 
 	case stSampler2d:
 		textureCtx := target.(*textureContext)
-		fn.ActiveTexture(Enum(TEXTURE0 + u.textureUnit))
+		fn.ActiveTexture(uint32(TEXTURE0 + u.textureUnit))
 		fn.BindTexture(TEXTURE_2D, textureCtx.texture)
 		fn.Uniform1i(u.location, u.textureUnit)
 
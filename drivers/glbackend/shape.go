@@ -2,9 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package gl
-
-import "github.com/goxjs/gl"
+package glbackend
 
 type shape struct {
 	vertexBuffer *vertexBuffer
@@ -25,7 +23,7 @@ func newShape(vertexBuffer *vertexBuffer, indexBuffer *indexBuffer, mode drawMod
 	return result
 }
 
-func newQuadShape() *shape {
+func newQuadShape(fn Functions) *shape {
 	pos := newVertexStream(
 		"aPosition",
 		stFloatVec2,
@@ -36,8 +34,12 @@ func newQuadShape() *shape {
 			1.0, 1.0,
 		},
 	)
-	vBuffer := newVertexBuffer(pos)
+	// Rectangles are aligned to pixels, and have no fringe.
+	fringe := newVertexStream("aFringe", stFloatVec2, make([]float32, 4*2))
+	coverage := newVertexStream("aCoverage", stFloatVec1, []float32{1, 1, 1, 1})
+	vBuffer := newVertexBuffer(pos, fringe, coverage)
 	iBuffer := newIndexBuffer(
+		fn,
 		ptUshort,
 		[]uint16{
 			0, 1, 2,
@@ -52,11 +54,11 @@ func (s shape) draw(ctx *context, shader *shaderProgram, bindings uniformBinding
 	shader.bind(ctx, s.vertexBuffer, bindings)
 
 	if s.indexBuffer != nil {
-		ctx.getOrCreateIndexBufferContext(s.indexBuffer).render(s.drawMode)
+		ctx.getOrCreateIndexBufferContext(s.indexBuffer).render(s.drawMode, ctx.fn)
 	} else {
-		gl.DrawArrays(gl.Enum(s.drawMode), 0, s.vertexBuffer.count)
+		ctx.fn.DrawArrays(uint32(s.drawMode), 0, s.vertexBuffer.count)
 	}
 
-	shader.unbind(ctx)
-	checkError()
+	shader.unbind(ctx.fn)
+	checkError(ctx.fn)
 }
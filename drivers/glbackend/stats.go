@@ -1,10 +1,16 @@
-package purego
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"bytes"
 	"fmt"
 	"sync/atomic"
 	"time"
+
+	"github.com/badu/gxui"
 )
 
 const (
@@ -117,6 +123,7 @@ type contextStats struct {
 	shaderProgramCount int
 	frameCount         int
 	drawCallCount      int
+	glyphBatchCount    int
 }
 
 func (s *contextStats) timer(name string) *timer {
@@ -136,6 +143,7 @@ func (s contextStats) String() string {
 		_, _ = fmt.Fprintf(buffer, "%v\n", t)
 	}
 	_, _ = fmt.Fprintf(buffer, "Draw calls per frame: %d\n", s.drawCallCount)
+	_, _ = fmt.Fprintf(buffer, "Glyph batches per frame: %d\n", s.glyphBatchCount)
 	_, _ = fmt.Fprintf(buffer, "Frame count: %d\n", s.frameCount)
 	_, _ = fmt.Fprintf(buffer, "Textures: %d\n", s.textureCount)
 	_, _ = fmt.Fprintf(buffer, "Vertex stream count: %d\n", s.vertexStreamCount)
@@ -143,3 +151,24 @@ func (s contextStats) String() string {
 	_, _ = fmt.Fprintf(buffer, "Shader program count: %d\n", s.shaderProgramCount)
 	return buffer.String()
 }
+
+// frameHistory holds the statistics of the most recent frames of a viewport.
+type frameHistory struct {
+	frames [historySize]gxui.FrameStats
+	count  int // The number of frames added
+}
+
+func (h *frameHistory) add(frame gxui.FrameStats) {
+	h.frames[h.count%historySize] = frame
+	h.count++
+}
+
+// recent returns the statistics of the most recent frames, oldest first.
+func (h *frameHistory) recent() []gxui.FrameStats {
+	n := min(h.count, historySize)
+	result := make([]gxui.FrameStats, n)
+	for i := range result {
+		result[i] = h.frames[(h.count-n+i)%historySize]
+	}
+	return result
+}
@@ -1,4 +1,8 @@
-package purego
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"image"
@@ -12,6 +16,7 @@ type textureContext struct {
 	texture    uint32
 	flipY      bool
 	pma        bool
+	bytes      int // The memory used by the texture
 }
 
 type TextureImpl struct {
@@ -50,8 +55,8 @@ func (t *TextureImpl) SetFlipY(flipY bool) {
 	t.flipY = flipY
 }
 
-func (t *TextureImpl) newContext(fn *Functions) *textureContext {
-	var format Enum
+func (t *TextureImpl) newContext(fn Functions) *textureContext {
+	var format uint32
 	var data []byte
 	var pma bool
 
@@ -85,10 +90,11 @@ func (t *TextureImpl) newContext(fn *Functions) *textureContext {
 		sizePixels: t.Size(),
 		flipY:      t.flipY,
 		pma:        pma,
+		bytes:      len(data),
 	}
 }
 
-func (c *textureContext) destroy(fn *Functions) {
+func (c *textureContext) destroy(fn Functions) {
 	globalStats.textureContextCount.dec()
 	fn.DeleteTexture(c.texture)
 	c.texture = 0
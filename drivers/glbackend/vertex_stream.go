@@ -1,4 +1,8 @@
-package purego
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"fmt"
@@ -36,7 +40,7 @@ func newVertexStream(name string, shaderType shaderDataType, data32 []float32) *
 	return stream
 }
 
-func (s *vertexStream) newContext(fn *Functions) *vertexStreamContext {
+func (s *vertexStream) newContext(fn Functions) *vertexStreamContext {
 	buffer := fn.CreateBuffer()
 
 	fn.BindBuffer(ARRAY_BUFFER, buffer)
@@ -53,11 +57,11 @@ type vertexStreamContext struct {
 	glBuffer uint32
 }
 
-func (c *vertexStreamContext) bind(fn *Functions) {
+func (c *vertexStreamContext) bind(fn Functions) {
 	fn.BindBuffer(ARRAY_BUFFER, c.glBuffer)
 }
 
-func (c *vertexStreamContext) destroy(fn *Functions) {
+func (c *vertexStreamContext) destroy(fn Functions) {
 	globalStats.vertexStreamContextCount.dec()
 	fn.DeleteBuffer(c.glBuffer)
 }
@@ -1,14 +1,18 @@
-package cgo
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glbackend
 
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 	"unicode"
 
 	"github.com/badu/gxui"
-	"github.com/badu/gxui/drivers/gl/platform"
+	"github.com/badu/gxui/drivers/glbackend/platform"
 	"github.com/badu/gxui/pkg/math"
-	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 const viewportDebugEnabled = false
@@ -37,8 +41,9 @@ type ViewportImpl struct {
 	onDestroy               gxui.Event
 	driver                  *DriverImpl
 	context                 *context
-	window                  *Window
+	window                  Window
 	canvas                  *CanvasImpl
+	damage                  []math.Rect // The rectangles to redraw, in DIPs
 	pendingMouseMoveEvent   *gxui.MouseEvent
 	pendingMouseScrollEvent *gxui.MouseEvent
 	title                   string
@@ -51,35 +56,24 @@ type ViewportImpl struct {
 
 	scaling     float32
 	redrawCount uint32
+	frames      frameHistory
+	layoutTime  time.Duration // The time spent laying out the next canvas
+	paintTime   time.Duration // The time spent painting the next canvas
 
 	fullscreen bool
+	redrawAll  bool
 	destroyed  bool
 }
 
 func NewViewport(driver *DriverImpl, width, height int, title string, fullscreen bool) *ViewportImpl {
-
 	result := &ViewportImpl{fullscreen: fullscreen, scaling: 1, title: title}
 
-	DefaultWindowHints()
-	WindowHint(Samples, 4)
-
-	var monitor *Monitor
-	if fullscreen {
-		monitor = GetPrimaryMonitor()
-		if width == 0 || height == 0 {
-			vm := monitor.GetVideoMode()
-			if vm == nil {
-				panic("No video mode available on primary monitor")
-			}
-			width, height = vm.Width, vm.Height
-		}
-	}
-
-	wnd, err := CreateWindow(width, height, result.title, monitor, nil)
+	wnd, err := driver.platform.CreateWindow(width, height, result.title, fullscreen)
 	if err != nil {
 		panic(err)
 	}
-	width, height = wnd.GetSize() // At this time, width and height serve as a "hint" for CreateWindow, so get actual values from window.
+
+	width, height = wnd.Size() // At this time, width and height serve as a "hint" for CreateWindow, so get actual values from window.
 
 	wnd.MakeContextCurrent()
 
@@ -92,44 +86,36 @@ func NewViewport(driver *DriverImpl, width, height int, title string, fullscreen
 		y -= 3.0
 		return math.Point{X: int(x), Y: int(y)}.ScaleS(1 / result.scaling)
 	}
-	wnd.SetCloseCallback(
-		func(*Window) {
+	wnd.SetCallbacks(WindowCallbacks{
+		Close: func() {
 			result.Close()
 		},
-	)
 
-	wnd.SetPosCallback(
-		func(w *Window, x, y int) {
+		Pos: func(x, y int) {
 			result.Lock()
 			result.position = math.NewPoint(x, y)
 			result.Unlock()
 		},
-	)
 
-	wnd.SetSizeCallback(
-		func(_ *Window, w, h int) {
+		Size: func(w, h int) {
 			result.Lock()
 			result.sizeDipsUnscaled = math.Size{Width: w, Height: h}
 			result.sizeDips = result.sizeDipsUnscaled.ScaleS(1 / result.scaling)
 			result.Unlock()
 			result.onResize.Emit()
 		},
-	)
 
-	wnd.SetFramebufferSizeCallback(
-		func(_ *Window, w, h int) {
+		FramebufferSize: func(w, h int) {
 			result.Lock()
 			result.sizePixels = math.Size{Width: w, Height: h}
 			result.Unlock()
-			driver.fn.Viewport(0, 0, w, h)
+			driver.fn.Viewport(0, 0, int32(w), int32(h))
 			driver.fn.ClearColor(clearColorR, clearColorG, clearColorB, 1.0)
 			driver.fn.Clear(COLOR_BUFFER_BIT)
 		},
-	)
 
-	wnd.SetCursorPosCallback(
-		func(w *Window, x, y float64) {
-			p := cursorPoint(w.GetCursorPos())
+		CursorPos: func(x, y float64) {
+			p := cursorPoint(wnd.CursorPos())
 			result.Lock()
 			if result.pendingMouseMoveEvent == nil {
 				result.pendingMouseMoveEvent = &gxui.MouseEvent{}
@@ -142,29 +128,25 @@ func NewViewport(driver *DriverImpl, width, height int, title string, fullscreen
 				})
 			}
 			result.pendingMouseMoveEvent.Point = p
-			result.pendingMouseMoveEvent.State = getMouseState(w)
+			result.pendingMouseMoveEvent.State = wnd.MouseState()
 			result.Unlock()
 		},
-	)
 
-	wnd.SetCursorEnterCallback(
-		func(w *Window, entered bool) {
-			p := cursorPoint(w.GetCursorPos())
+		CursorEnter: func(entered bool) {
+			p := cursorPoint(wnd.CursorPos())
 			ev := gxui.MouseEvent{
 				Point: p,
 			}
-			ev.State = getMouseState(w)
+			ev.State = wnd.MouseState()
 			if entered {
 				result.onMouseEnter.Emit(ev)
 			} else {
 				result.onMouseExit.Emit(ev)
 			}
 		},
-	)
 
-	wnd.SetScrollCallback(
-		func(w *Window, xoff, yoff float64) {
-			p := cursorPoint(w.GetCursorPos())
+		Scroll: func(xoff, yoff float64) {
+			p := cursorPoint(wnd.CursorPos())
 			result.Lock()
 			if result.pendingMouseScrollEvent == nil {
 				result.pendingMouseScrollEvent = &gxui.MouseEvent{}
@@ -186,47 +168,41 @@ func NewViewport(driver *DriverImpl, width, height int, title string, fullscreen
 			result.pendingMouseScrollEvent.Point = p
 			result.scrollAccumX += xoff * platform.ScrollSpeed
 			result.scrollAccumY += yoff * platform.ScrollSpeed
-			result.pendingMouseScrollEvent.State = getMouseState(w)
+			result.pendingMouseScrollEvent.State = wnd.MouseState()
 			result.Unlock()
 		},
-	)
 
-	wnd.SetMouseButtonCallback(
-		func(w *Window, button MouseButton, action Action, mod ModifierKey) {
-			p := cursorPoint(w.GetCursorPos())
+		MouseButton: func(button gxui.MouseButton, action Action, modifier gxui.KeyboardModifier) {
+			p := cursorPoint(wnd.CursorPos())
 			ev := gxui.MouseEvent{
 				Point:    p,
-				Modifier: translateKeyboardModifier(mod),
+				Modifier: modifier,
 			}
-			ev.Button = translateMouseButton(button)
-			ev.State = getMouseState(w)
-			if action == glfw.Press {
+			ev.Button = button
+			ev.State = wnd.MouseState()
+			if action == Press {
 				result.onMouseDown.Emit(ev)
 			} else {
 				result.onMouseUp.Emit(ev)
 			}
 		},
-	)
 
-	wnd.SetKeyCallback(
-		func(w *Window, key Key, scancode int, action Action, mods ModifierKey) {
+		Key: func(key gxui.KeyboardKey, action Action, modifier gxui.KeyboardModifier) {
 			ev := gxui.KeyboardEvent{
-				Key:      translateKeyboardKey(key),
-				Modifier: translateKeyboardModifier(mods),
+				Key:      key,
+				Modifier: modifier,
 			}
 			switch action {
-			case glfw.Press:
+			case Press:
 				result.onKeyDown.Emit(ev)
-			case glfw.Release:
+			case Release:
 				result.onKeyUp.Emit(ev)
-			case glfw.Repeat:
+			case Repeat:
 				result.onKeyRepeat.Emit(ev)
 			}
 		},
-	)
 
-	wnd.SetCharModsCallback(
-		func(w *Window, char rune, mods ModifierKey) {
+		Char: func(char rune, modifier gxui.KeyboardModifier) {
 			if !unicode.IsControl(char) &&
 				!unicode.IsGraphic(char) &&
 				!unicode.IsLetter(char) &&
@@ -240,28 +216,26 @@ func NewViewport(driver *DriverImpl, width, height int, title string, fullscreen
 
 			ev := gxui.KeyStrokeEvent{
 				Character: char,
-				Modifier:  translateKeyboardModifier(mods),
+				Modifier:  modifier,
 			}
 			result.onKeyStroke.Emit(ev)
 		},
-	)
 
-	wnd.SetRefreshCallback(
-		func(w *Window) {
+		Refresh: func() {
 			if result.canvas != nil {
-				result.render()
+				result.render(nil)
 			}
 		},
-	)
+	})
 
-	fw, fh := wnd.GetFramebufferSize()
-	posX, posY := wnd.GetPos()
+	fw, fh := wnd.FramebufferSize()
+	posX, posY := wnd.Pos()
 
 	// Pre-multiplied alpha blending
 	driver.fn.BlendFunc(ONE, ONE_MINUS_SRC_ALPHA)
 	driver.fn.Enable(BLEND)
 	driver.fn.Enable(SCISSOR_TEST)
-	driver.fn.Viewport(0, 0, fw, fh)
+	driver.fn.Viewport(0, 0, int32(fw), int32(fh))
 	driver.fn.Scissor(0, 0, int32(fw), int32(fh))
 	driver.fn.ClearColor(clearColorR, clearColorG, clearColorB, 1.0)
 	driver.fn.Clear(COLOR_BUFFER_BIT)
@@ -295,35 +269,31 @@ func NewViewport(driver *DriverImpl, width, height int, title string, fullscreen
 
 // Driver methods
 // These methods are all called on the driver routine
-func (v *ViewportImpl) render() {
+
+// render draws the canvas within the rectangles of damage, in DIPs, keeping
+// the rest of the last frame. The whole canvas is drawn if damage is empty.
+func (v *ViewportImpl) render(damage []math.Rect) {
 	if v.destroyed {
 		return
 	}
 
 	v.window.MakeContextCurrent()
 
+	start := time.Now()
 	ctx := v.context
-	ctx.beginDraw(v.SizeDips(), v.SizePixels())
-
-	stack := drawStateStack{
-		drawState{
-			ClipPixels: v.sizePixels.Rect(),
-		},
-	}
-
-	v.canvas.draw(ctx, &stack)
-	if len(stack) != 1 {
-		panic("DrawStateStack count was not 1 after calling Canvas.Draw")
-	}
-
-	ctx.apply(stack.head())
-	ctx.blitter.commit(ctx)
+	full := ctx.drawFrame(v.canvas, v.SizeDips(), v.SizePixels(), damage)
 
 	if viewportDebugEnabled {
 		v.drawFrameUpdate(ctx)
 	}
 
-	ctx.endDraw()
+	ctx.endDraw(v.canvas)
+	v.addFrame(gxui.FrameStats{
+		Submit:       time.Since(start),
+		DrawCalls:    ctx.stats.drawCallCount,
+		GlyphBatches: ctx.stats.glyphBatchCount,
+		Partial:      !full,
+	})
 
 	v.window.SwapBuffers()
 }
@@ -331,27 +301,96 @@ func (v *ViewportImpl) render() {
 func (v *ViewportImpl) drawFrameUpdate(ctx *context) {
 	dx := (ctx.stats.frameCount * 10) & 0xFF
 	rect := math.CreateRect(dx-5, 0, dx+5, 3)
-	state := &drawState{}
+	state := &drawState{Transform: math.Mat3Ident}
 	ctx.blitter.blitRect(ctx, rect, gxui.White, state)
 }
 
 // SetCanvas is gxui.Viewport compliance
 // These methods are all called on the application routine
 func (v *ViewportImpl) SetCanvas(newCanvas gxui.Canvas) {
+	v.setCanvas(newCanvas, nil)
+}
+
+func (v *ViewportImpl) SetCanvasDamage(newCanvas gxui.Canvas, damage []math.Rect) {
+	v.setCanvas(newCanvas, damage)
+}
+
+// setCanvas renders the canvas within the rectangles of damage, or whole if
+// damage is empty. The damage of the canvases skipped for a more recent one is
+// rendered along with it.
+func (v *ViewportImpl) setCanvas(newCanvas gxui.Canvas, damage []math.Rect) {
 	cnt := atomic.AddUint32(&v.redrawCount, 1)
 	childCanvas := newCanvas.(*CanvasImpl)
+	v.addDamage(damage)
 	v.driver.asyncDriver(func() {
 		// Only use the canvas of the most recent SetCanvas call.
 		v.window.MakeContextCurrent()
 		if atomic.LoadUint32(&v.redrawCount) == cnt {
 			v.canvas = childCanvas
 			if v.canvas != nil {
-				v.render()
+				v.render(v.takeDamage())
+			} else {
+				v.addDamage(nil)
 			}
 		}
 	})
 }
 
+// addDamage adds the rectangles of damage to the ones to redraw, or redraws
+// everything if damage is empty.
+func (v *ViewportImpl) addDamage(damage []math.Rect) {
+	v.Lock()
+	defer v.Unlock()
+	if len(damage) == 0 {
+		v.redrawAll, v.damage = true, nil
+	} else if !v.redrawAll {
+		v.damage = append(v.damage, damage...)
+	}
+}
+
+// takeDamage returns the rectangles to redraw, or nil to redraw everything,
+// and forgets them.
+func (v *ViewportImpl) takeDamage() []math.Rect {
+	v.Lock()
+	defer v.Unlock()
+	damage := v.damage
+	v.redrawAll, v.damage = false, nil
+	return damage
+}
+
+func (v *ViewportImpl) RecordFrameTimes(layout, paint time.Duration) {
+	v.Lock()
+	defer v.Unlock()
+	v.layoutTime += layout
+	v.paintTime += paint
+}
+
+func (v *ViewportImpl) Stats() gxui.ViewportStats {
+	var result gxui.ViewportStats
+	v.driver.syncDriver(func() {
+		result = v.stats()
+	})
+	return result
+}
+
+// stats returns the statistics of the viewport. stats is called on the driver
+// routine.
+func (v *ViewportImpl) stats() gxui.ViewportStats {
+	result := gxui.ViewportStats{Frames: v.frames.recent(), FrameCount: v.frames.count}
+	v.context.resourceStats(&result)
+	return result
+}
+
+// addFrame adds the statistics of a frame drawn, along with the times taken to
+// lay out and paint its canvas.
+func (v *ViewportImpl) addFrame(frame gxui.FrameStats) {
+	v.Lock()
+	frame.Layout, frame.Paint = v.layoutTime, v.paintTime
+	v.layoutTime, v.paintTime = 0, 0
+	v.Unlock()
+	v.frames.add(frame)
+}
+
 func (v *ViewportImpl) Scale() float32 {
 	v.Lock()
 	defer v.Unlock()
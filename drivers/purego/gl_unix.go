@@ -26,6 +26,7 @@ type Functions struct {
 	gpClear                    uintptr
 	gpColorMask                uintptr
 	gpCompileShader            uintptr
+	gpCopyTexSubImage2D        uintptr
 	gpCreateProgram            uintptr
 	gpCreateShader             uintptr
 	gpDeleteBuffers            uintptr
@@ -90,6 +91,7 @@ type Functions struct {
 	glDrawArrays        uintptr
 	glUniform1f         uintptr
 	glBlendFunc         uintptr
+	glStencilOp         uintptr
 	glGetActiveUniform  uintptr
 	glGetActiveAttrib   uintptr
 	glGetAttribLocation uintptr
@@ -125,6 +127,13 @@ func (f *Functions) BlendFunc(sFactor, dFactor uint32) {
 	purego.SyscallN(f.glBlendFunc, uintptr(sFactor), uintptr(dFactor))
 }
 
+// StencilOp sets the actions taken on the stencil when a pixel fails the
+// stencil test, fails the depth test, or passes both.
+// StencilOp(fail, zfail, zpass Enum)
+func (f *Functions) StencilOp(fail, zFail, zPass uint32) {
+	purego.SyscallN(f.glStencilOp, uintptr(fail), uintptr(zFail), uintptr(zPass))
+}
+
 // GetActiveUniform returns details about an active uniform variable.
 // A value of 0 for index selects the first active uniform variable.
 // Permissible values for index range from 0 to the number of active
@@ -298,6 +307,11 @@ func (f *Functions) CompileShader(shader uint32) {
 	purego.SyscallN(f.gpCompileShader, uintptr(shader))
 }
 
+// CopyTexSubImage2D(target Enum, level, xoffset, yoffset, x, y, width, height int)
+func (f *Functions) CopyTexSubImage2D(target uint32, level, xoffset, yoffset, x, y, width, height int32) {
+	purego.SyscallN(f.gpCopyTexSubImage2D, uintptr(target), uintptr(level), uintptr(xoffset), uintptr(yoffset), uintptr(x), uintptr(y), uintptr(width), uintptr(height))
+}
+
 // CreateBuffer() Buffer
 func (f *Functions) CreateBuffer() uint32 {
 	var buffer uint32
@@ -697,6 +711,10 @@ func (f *Functions) LoadFunctions() error {
 	if err != nil {
 		return err
 	}
+	f.gpCopyTexSubImage2D, err = f.get("glCopyTexSubImage2D")
+	if err != nil {
+		return err
+	}
 	f.gpCreateProgram, err = f.get("glCreateProgram")
 	if err != nil {
 		return err
@@ -943,6 +961,10 @@ func (f *Functions) LoadFunctions() error {
 	if err != nil {
 		return err
 	}
+	f.glStencilOp, err = f.get("glStencilOp")
+	if err != nil {
+		return err
+	}
 	f.glGetActiveUniform, err = f.get("glGetActiveUniform")
 	if err != nil {
 		return err
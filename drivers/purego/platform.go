@@ -0,0 +1,106 @@
+package purego
+
+import (
+	"errors"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/drivers/glbackend"
+)
+
+// platform creates the GLFW windows of the driver.
+type platform struct{}
+
+func (platform) CreateWindow(width, height int, title string, fullscreen bool) (glbackend.Window, error) {
+	DefaultWindowHints()
+	WindowHint(Samples, 4)
+
+	var monitor *Monitor
+	if fullscreen {
+		monitor = GetPrimaryMonitor()
+		if width == 0 || height == 0 {
+			vm := monitor.GetVideoMode()
+			if vm == nil {
+				return nil, errors.New("no video mode available on primary monitor")
+			}
+			width, height = vm.Width, vm.Height
+		}
+	}
+
+	return window{CreateWindow(width, height, title, monitor, nil)}, nil
+}
+
+func (platform) WaitEvents() {
+	WaitEvents()
+}
+
+func (platform) PostEmptyEvent() {
+	PostEmptyEvent()
+}
+
+// window adapts a GLFW window to the backend.
+type window struct {
+	*Window
+}
+
+func (w window) SetCallbacks(callbacks glbackend.WindowCallbacks) {
+	w.SetCloseCallback(func(*Window) { callbacks.Close() })
+	w.SetPosCallback(func(_ *Window, x, y int32) { callbacks.Pos(int(x), int(y)) })
+	w.SetSizeCallback(func(_ *Window, width, height int32) { callbacks.Size(int(width), int(height)) })
+	w.SetFramebufferSizeCallback(func(_ *Window, width, height int32) { callbacks.FramebufferSize(int(width), int(height)) })
+	w.SetCursorPosCallback(func(_ *Window, x, y float64) { callbacks.CursorPos(x, y) })
+	w.SetCursorEnterCallback(func(_ *Window, entered bool) { callbacks.CursorEnter(entered) })
+	w.SetScrollCallback(func(_ *Window, xOffset, yOffset float64) { callbacks.Scroll(xOffset, yOffset) })
+	w.SetMouseButtonCallback(
+		func(_ *Window, button MouseButton, action Action, mods ModifierKey) {
+			callbacks.MouseButton(translateMouseButton(button), translateAction(action), translateKeyboardModifier(mods))
+		},
+	)
+	w.SetKeyCallback(
+		func(_ *Window, key Key, scancode int32, action Action, mods ModifierKey) {
+			callbacks.Key(translateKeyboardKey(key), translateAction(action), translateKeyboardModifier(mods))
+		},
+	)
+	w.SetCharModsCallback(func(_ *Window, char rune, mods ModifierKey) { callbacks.Char(char, translateKeyboardModifier(mods)) })
+	w.SetRefreshCallback(func(*Window) { callbacks.Refresh() })
+}
+
+func (w window) Size() (int, int) {
+	return w.GetSize()
+}
+
+func (w window) FramebufferSize() (int, int) {
+	width, height := w.GetFramebufferSize()
+	return int(width), int(height)
+}
+
+func (w window) Pos() (int, int) {
+	x, y := w.GetPos()
+	return int(x), int(y)
+}
+
+func (w window) CursorPos() (float64, float64) {
+	return w.GetCursorPos()
+}
+
+func (w window) MouseState() gxui.MouseState {
+	return getMouseState(w.Window)
+}
+
+func (w window) Clipboard() string {
+	return w.GetClipboardString()
+}
+
+func (w window) SetClipboard(str string) {
+	w.SetClipboardString(str)
+}
+
+func translateAction(action Action) glbackend.Action {
+	switch action {
+	case Press:
+		return glbackend.Press
+	case Repeat:
+		return glbackend.Repeat
+	default:
+		return glbackend.Release
+	}
+}
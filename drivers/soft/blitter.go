@@ -0,0 +1,518 @@
+package soft
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/vector"
+)
+
+// blitter rasterizes the primitives of a canvas into the target of a context.
+// All the rectangles passed to the blitter are in pixels, relative to the
+// state's origin, while shapes are in DIPs. Only shapes, textures and glyphs
+// are drawn with the transform of the state.
+type blitter struct {
+	rasterizer vector.Rasterizer
+	ramps      map[*gxui.Gradient]*gradientRamp
+}
+
+// gradientRamp holds the colors sampled from a gradient, and whether it was
+// drawn during the current frame.
+type gradientRamp struct {
+	image *image.RGBA
+	used  bool
+}
+
+// Number of colors sampled from the gradients.
+const gradientRampSize = 256
+
+func toImageRect(rect math.Rect) image.Rectangle {
+	return image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+}
+
+func toColor(c gxui.Color) color.NRGBA64 {
+	c = c.Saturate()
+	return color.NRGBA64{
+		R: uint16(c.R*0xffff + 0.5),
+		G: uint16(c.G*0xffff + 0.5),
+		B: uint16(c.B*0xffff + 0.5),
+		A: uint16(c.A*0xffff + 0.5),
+	}
+}
+
+// clip returns the part of the target that can be drawn to with the given state.
+func (b *blitter) clip(ctx *context, state *drawState) image.Rectangle {
+	return toImageRect(state.ClipPixels).Intersect(ctx.target.Bounds())
+}
+
+// toAff3 returns the matrix m as used by the x/image/draw transforms.
+func toAff3(m math.Mat3) f64.Aff3 {
+	return f64.Aff3{
+		float64(m[0]), float64(m[3]), float64(m[6]),
+		float64(m[1]), float64(m[4]), float64(m[7]),
+	}
+}
+
+// options returns the options drawing through the mask of the state.
+func options(state *drawState) *xdraw.Options {
+	if state.Mask == nil {
+		return nil
+	}
+	return &xdraw.Options{DstMask: state.Mask}
+}
+
+// draw draws src through mask over dst, which is already clipped, and through
+// the mask of the state if there is one.
+func (b *blitter) draw(ctx *context, dst image.Rectangle, src image.Image, sp image.Point, mask image.Image, mp image.Point, op draw.Op, state *drawState) {
+	switch {
+	case state.Mask == nil:
+	case mask == nil:
+		mask, mp = state.Mask, dst.Min
+	default:
+		combined := image.NewAlpha(dst)
+		for y := dst.Min.Y; y < dst.Max.Y; y++ {
+			for x := dst.Min.X; x < dst.Max.X; x++ {
+				_, _, _, a := mask.At(x-dst.Min.X+mp.X, y-dst.Min.Y+mp.Y).RGBA()
+				combined.SetAlpha(x, y, color.Alpha{A: uint8(a * uint32(state.Mask.AlphaAt(x, y).A) / 0xffff)})
+			}
+		}
+		mask, mp = combined, dst.Min
+	}
+	draw.DrawMask(ctx.target, dst, src, sp, mask, mp, op)
+}
+
+// clipMask returns the mask of the state intersected with rect, in DIPs.
+func (b *blitter) clipMask(ctx *context, rect math.Rect, state *drawState) *image.Alpha {
+	bounds := b.clip(ctx, state)
+	mask := image.NewAlpha(bounds)
+	if bounds.Empty() {
+		return mask
+	}
+
+	toTarget := state.toTarget(ctx)
+	offset := math.Vec2{X: float32(bounds.Min.X), Y: float32(bounds.Min.Y)}
+	b.rasterizer.Reset(bounds.Dx(), bounds.Dy())
+	for i, corner := range rectShape(rect)[0] {
+		p := toTarget.TransformVec2(corner).Sub(offset)
+		if i == 0 {
+			b.rasterizer.MoveTo(p.X, p.Y)
+		} else {
+			b.rasterizer.LineTo(p.X, p.Y)
+		}
+	}
+	b.rasterizer.ClosePath()
+	b.rasterizer.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+	if state.Mask != nil {
+		for i, a := range mask.Pix {
+			x, y := bounds.Min.X+i%bounds.Dx(), bounds.Min.Y+i/bounds.Dx()
+			mask.Pix[i] = uint8(uint32(a) * uint32(state.Mask.AlphaAt(x, y).A) / 0xff)
+		}
+	}
+	return mask
+}
+
+func (b *blitter) clear(ctx *context, color gxui.Color, state *drawState) {
+	b.draw(ctx, b.clip(ctx, state), image.NewUniform(toColor(color)), image.Point{}, nil, image.Point{}, draw.Src, state)
+}
+
+func (b *blitter) blit(ctx *context, texture *TextureImpl, srcRect, dstRect math.Rect, state *drawState) {
+	clip := b.clip(ctx, state)
+	src := texture.source()
+	sr := toImageRect(srcRect).Add(src.Bounds().Min)
+	target := ctx.target.SubImage(clip).(*image.RGBA)
+
+	if state.Transform != math.Mat3Ident {
+		// Map the source rectangle onto the transformed destination.
+		srcSize, dstSize := srcRect.Size(), dstRect.Size()
+		s2d := state.pixelsToTarget(ctx).
+			Mul(math.CreateMat3Translate(float32(dstRect.Min.X), float32(dstRect.Min.Y))).
+			Mul(math.CreateMat3Scale(float32(dstSize.Width)/float32(srcSize.Width), float32(dstSize.Height)/float32(srcSize.Height))).
+			Mul(math.CreateMat3Translate(-float32(sr.Min.X), -float32(sr.Min.Y)))
+		xdraw.ApproxBiLinear.Transform(target, toAff3(s2d), src, sr, xdraw.Over, options(state))
+		return
+	}
+
+	dst := toImageRect(dstRect.Offset(state.OriginPixels))
+	if dst.Intersect(clip).Empty() {
+		return
+	}
+	xdraw.ApproxBiLinear.Scale(target, dst, src, sr, xdraw.Over, options(state))
+}
+
+// blitGlyph draws the glyph mask, where dstRect is the glyph in pixels
+// relative to the state's origin.
+// blitLayer composites the image src drawn into the layer onto the target.
+func (b *blitter) blitLayer(ctx *context, l *layer, src *image.RGBA, state *drawState) {
+	dst := src.Bounds().Intersect(b.clip(ctx, state))
+	if l.mode == gxui.BlendNormal {
+		opacity := image.NewUniform(color.Alpha16{A: uint16(math.Saturate(l.opacity)*0xffff + 0.5)})
+		b.draw(ctx, dst, src, dst.Min, opacity, image.Point{}, draw.Over, state)
+		return
+	}
+
+	// The colors are premultiplied, as blended by the GL drivers.
+	for y := dst.Min.Y; y < dst.Max.Y; y++ {
+		for x := dst.Min.X; x < dst.Max.X; x++ {
+			alpha := math.Saturate(l.opacity)
+			if state.Mask != nil {
+				alpha *= float32(state.Mask.AlphaAt(x, y).A) / 0xff
+			}
+			s, d := src.RGBAAt(x, y), ctx.target.RGBAAt(x, y)
+			sa := float32(s.A) / 0xff * alpha
+			blend := func(sc, dc uint8) uint8 {
+				sv, dv := float32(sc)/0xff*alpha, float32(dc)/0xff
+				var c float32
+				switch l.mode {
+				case gxui.BlendMultiply:
+					c = sv*dv + dv*(1-sa)
+				case gxui.BlendScreen:
+					c = sv + dv*(1-sv)
+				case gxui.BlendAdd:
+					c = sv + dv
+				}
+				return uint8(math.Saturate(c)*0xff + 0.5)
+			}
+			ctx.target.SetRGBA(x, y, color.RGBA{
+				R: blend(s.R, d.R),
+				G: blend(s.G, d.G),
+				B: blend(s.B, d.B),
+				A: uint8(math.Saturate(sa+float32(d.A)/0xff*(1-sa))*0xff + 0.5),
+			})
+		}
+	}
+}
+
+func (b *blitter) blitGlyph(ctx *context, mask image.Image, maskPoint image.Point, dstRect image.Rectangle, color gxui.Color, state *drawState) {
+	clip := b.clip(ctx, state)
+	src := image.NewUniform(toColor(color))
+
+	if state.Transform != math.Mat3Ident {
+		sr := image.Rectangle{Min: maskPoint, Max: maskPoint.Add(dstRect.Size())}
+		s2d := state.pixelsToTarget(ctx).
+			Mul(math.CreateMat3Translate(float32(dstRect.Min.X-maskPoint.X), float32(dstRect.Min.Y-maskPoint.Y)))
+		opts := &xdraw.Options{SrcMask: mask}
+		if state.Mask != nil {
+			opts.DstMask = state.Mask
+		}
+		xdraw.ApproxBiLinear.Transform(ctx.target.SubImage(clip).(*image.RGBA), toAff3(s2d), src, sr, xdraw.Over, opts)
+		return
+	}
+
+	dstRect = dstRect.Add(image.Point{X: state.OriginPixels.X, Y: state.OriginPixels.Y})
+	dst := dstRect.Intersect(clip)
+	if dst.Empty() {
+		return
+	}
+
+	maskPoint = maskPoint.Add(dst.Min.Sub(dstRect.Min))
+	b.draw(ctx, dst, src, image.Point{}, mask, maskPoint, draw.Over, state)
+}
+
+func (b *blitter) blitShape(ctx *context, shape shape, color gxui.Color, state *drawState) {
+	b.fillShape(ctx, shape, image.NewUniform(toColor(color)), state)
+}
+
+// blitGradientShape fills the shape with the gradient, where bounds is the
+// bounding box of the shape in DIPs.
+func (b *blitter) blitGradientShape(ctx *context, shape shape, gradient *gxui.Gradient, bounds math.Rect, state *drawState) {
+	if state.Transform == math.Mat3Ident {
+		boundsPixels := ctx.resolution.rectDipsToPixels(bounds).Offset(state.OriginPixels)
+		b.fillShape(ctx, shape, b.gradient(gradient, boundsPixels, math.Mat3Ident), state)
+	} else if fromTarget, ok := state.toTarget(ctx).Invert(); ok {
+		b.fillShape(ctx, shape, b.gradient(gradient, bounds, fromTarget), state)
+	}
+}
+
+// blitPatternShape fills the shape with the pattern, where bounds is the
+// bounding box of the shape in DIPs.
+func (b *blitter) blitPatternShape(ctx *context, shape shape, pattern *gxui.Pattern, bounds math.Rect, state *drawState) {
+	if src := b.pattern(ctx, pattern, bounds, state); src != nil {
+		b.fillShape(ctx, shape, src, state)
+	}
+}
+
+// fillShape fills the shape with src, which is aligned with the target.
+func (b *blitter) fillShape(ctx *context, shape shape, src image.Image, state *drawState) {
+	toTarget := state.toTarget(ctx)
+
+	// Transform the contours into pixels, keeping track of the bounds.
+	bounds := image.Rectangle{}
+	contours := make([][]math.Vec2, len(shape))
+	for i, contour := range shape {
+		contours[i] = make([]math.Vec2, len(contour))
+		for j, point := range contour {
+			p := toTarget.TransformVec2(point)
+			contours[i][j] = p
+			pointBounds := image.Rect(int(p.X)-1, int(p.Y)-1, int(p.X)+2, int(p.Y)+2)
+			if i == 0 && j == 0 {
+				bounds = pointBounds
+			} else {
+				bounds = bounds.Union(pointBounds)
+			}
+		}
+	}
+
+	dst := bounds.Intersect(b.clip(ctx, state))
+	if dst.Empty() {
+		return
+	}
+
+	offset := math.Vec2{X: float32(dst.Min.X), Y: float32(dst.Min.Y)}
+	b.rasterizer.Reset(dst.Dx(), dst.Dy())
+	for _, contour := range contours {
+		if len(contour) < 3 {
+			continue
+		}
+		first := contour[0].Sub(offset)
+		b.rasterizer.MoveTo(first.X, first.Y)
+		for _, point := range contour[1:] {
+			p := point.Sub(offset)
+			b.rasterizer.LineTo(p.X, p.Y)
+		}
+		b.rasterizer.ClosePath()
+	}
+	if state.Mask == nil {
+		b.rasterizer.Draw(ctx.target, dst, src, dst.Min)
+		return
+	}
+	coverage := image.NewAlpha(image.Rect(0, 0, dst.Dx(), dst.Dy()))
+	b.rasterizer.Draw(coverage, coverage.Bounds(), image.Opaque, image.Point{})
+	b.draw(ctx, dst, src, dst.Min, coverage, image.Point{}, draw.Over, state)
+}
+
+// blitShadow fills the shape, in DIPs, with the color, blurred with the
+// standard deviation sigma, in DIPs.
+func (b *blitter) blitShadow(ctx *context, shape shape, sigma float32, color gxui.Color, state *drawState) {
+	toTarget := state.toTarget(ctx)
+	sigmaPixels := sigma * ctx.resolution.dipsToPixels() * state.Transform.Scale()
+	extent := int(math32.Ceil(3*sigmaPixels)) + 1
+
+	bounds := image.Rectangle{}
+	contours := make([][]math.Vec2, len(shape))
+	for i, contour := range shape {
+		contours[i] = make([]math.Vec2, len(contour))
+		for j, point := range contour {
+			p := toTarget.TransformVec2(point)
+			contours[i][j] = p
+			pointBounds := image.Rect(int(p.X)-1, int(p.Y)-1, int(p.X)+2, int(p.Y)+2)
+			if i == 0 && j == 0 {
+				bounds = pointBounds
+			} else {
+				bounds = bounds.Union(pointBounds)
+			}
+		}
+	}
+
+	dst := bounds.Inset(-extent).Intersect(b.clip(ctx, state))
+	if dst.Empty() {
+		return
+	}
+
+	// The mask also covers the parts of the shape blurred into dst.
+	maskBounds := dst.Inset(-extent).Intersect(bounds)
+	if maskBounds.Empty() {
+		return
+	}
+	mask := image.NewAlpha(dst.Union(maskBounds))
+	origin := mask.Rect.Min
+	offset := math.Vec2{X: float32(origin.X), Y: float32(origin.Y)}
+	b.rasterizer.Reset(mask.Rect.Dx(), mask.Rect.Dy())
+	for _, contour := range contours {
+		if len(contour) < 3 {
+			continue
+		}
+		first := contour[0].Sub(offset)
+		b.rasterizer.MoveTo(first.X, first.Y)
+		for _, point := range contour[1:] {
+			p := point.Sub(offset)
+			b.rasterizer.LineTo(p.X, p.Y)
+		}
+		b.rasterizer.ClosePath()
+	}
+	b.rasterizer.Draw(mask, mask.Rect, image.Opaque, image.Point{})
+	blurAlpha(mask, sigmaPixels)
+
+	b.draw(ctx, dst, image.NewUniform(toColor(color)), image.Point{}, mask, dst.Min, draw.Over, state)
+}
+
+// blurBackdrop replaces the target under rect, in DIPs, with the target blurred
+// with the standard deviation sigma, in DIPs.
+func (b *blitter) blurBackdrop(ctx *context, rect math.Rect, sigma float32, state *drawState) {
+	clip := b.clip(ctx, state)
+	mask := state.Mask
+	var dst image.Rectangle
+	if state.Transform.IsAxisAligned() {
+		dst = toImageRect(state.toTarget(ctx).TransformRect(rect)).Intersect(clip)
+	} else {
+		mask = b.clipMask(ctx, rect, state)
+		dst = mask.Rect
+	}
+	if dst.Empty() {
+		return
+	}
+
+	sigmaPixels := sigma * ctx.resolution.dipsToPixels() * state.Transform.Scale()
+	extent := int(math32.Ceil(3*sigmaPixels)) + 1
+	blurred := image.NewRGBA(dst.Inset(-extent).Intersect(ctx.target.Bounds()))
+	draw.Draw(blurred, blurred.Rect, ctx.target, blurred.Rect.Min, draw.Src)
+	blurRGBA(blurred, sigmaPixels)
+
+	for y := dst.Min.Y; y < dst.Max.Y; y++ {
+		for x := dst.Min.X; x < dst.Max.X; x++ {
+			src := blurred.RGBAAt(x, y)
+			if mask != nil {
+				a := uint32(mask.AlphaAt(x, y).A)
+				d := ctx.target.RGBAAt(x, y)
+				lerp := func(s, d uint8) uint8 {
+					return uint8((uint32(s)*a + uint32(d)*(0xff-a) + 0x7f) / 0xff)
+				}
+				src = color.RGBA{R: lerp(src.R, d.R), G: lerp(src.G, d.G), B: lerp(src.B, d.B), A: lerp(src.A, d.A)}
+			}
+			ctx.target.SetRGBA(x, y, src)
+		}
+	}
+}
+
+func (b *blitter) blitRect(ctx *context, dstRect math.Rect, color gxui.Color, state *drawState) {
+	b.fillRect(ctx, dstRect, image.NewUniform(toColor(color)), state)
+}
+
+// blitGradientRect fills dstRect, in pixels, with the gradient.
+func (b *blitter) blitGradientRect(ctx *context, dstRect math.Rect, gradient *gxui.Gradient, state *drawState) {
+	b.fillRect(ctx, dstRect, b.gradient(gradient, dstRect.Offset(state.OriginPixels), math.Mat3Ident), state)
+}
+
+// blitPatternRect fills dstRect, in pixels, with the pattern. As patterns are
+// placed in DIPs, bounds is the same rectangle in DIPs.
+func (b *blitter) blitPatternRect(ctx *context, dstRect math.Rect, pattern *gxui.Pattern, bounds math.Rect, state *drawState) {
+	if src := b.pattern(ctx, pattern, bounds, state); src != nil {
+		b.fillRect(ctx, dstRect, src, state)
+	}
+}
+
+// fillRect fills dstRect, in pixels, with src, which is aligned with the target.
+func (b *blitter) fillRect(ctx *context, dstRect math.Rect, src image.Image, state *drawState) {
+	dst := toImageRect(dstRect.Offset(state.OriginPixels)).Intersect(b.clip(ctx, state))
+	if dst.Empty() {
+		return
+	}
+
+	b.draw(ctx, dst, src, dst.Min, nil, image.Point{}, draw.Over, state)
+}
+
+// gradient returns the image of the gradient filling bounds, where fromTarget
+// transforms target pixels into the space of bounds.
+func (b *blitter) gradient(gradient *gxui.Gradient, bounds math.Rect, fromTarget math.Mat3) image.Image {
+	ramp, found := b.ramps[gradient]
+	if !found {
+		ramp = &gradientRamp{image: gradient.Ramp(gradientRampSize)}
+		b.ramps[gradient] = ramp
+	}
+	ramp.used = true
+	origin, u, v := gradient.Axes(bounds)
+	return &gradientImage{
+		ramp:       ramp.image,
+		fromTarget: fromTarget,
+		origin:     origin,
+		u:          u,
+		v:          v,
+		radial:     gradient.Kind == gxui.RadialGradient,
+	}
+}
+
+// markGradient marks the ramp of the gradient, if it has one, as used by the
+// frame.
+func (b *blitter) markGradient(gradient *gxui.Gradient) {
+	if ramp, found := b.ramps[gradient]; found {
+		ramp.used = true
+	}
+}
+
+// endDraw forgets the ramps of the gradients that were not used this frame.
+func (b *blitter) endDraw() {
+	for gradient, ramp := range b.ramps {
+		if !ramp.used {
+			delete(b.ramps, gradient)
+		}
+		ramp.used = false
+	}
+}
+
+// gradientImage is an unbounded image of a gradient, looking up the colors of
+// each pixel in the ramp of the gradient.
+type gradientImage struct {
+	ramp       *image.RGBA
+	fromTarget math.Mat3
+	origin     math.Vec2
+	u, v       math.Vec2
+	radial     bool
+}
+
+func (g *gradientImage) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (g *gradientImage) Bounds() image.Rectangle {
+	return image.Rect(-1e9, -1e9, 1e9, 1e9)
+}
+
+func (g *gradientImage) At(x, y int) color.Color {
+	// Sample at the center of the pixel.
+	d := g.fromTarget.TransformVec2(math.Vec2{X: float32(x) + 0.5, Y: float32(y) + 0.5}).Sub(g.origin)
+	offset := d.Dot(g.u)
+	if g.radial {
+		offset = math.Vec2{X: offset, Y: d.Dot(g.v)}.Len()
+	}
+	i := int(math.Saturate(offset)*float32(gradientRampSize-1) + 0.5)
+	return g.ramp.RGBAAt(i, 0)
+}
+
+// pattern returns the image of the pattern filling bounds, in DIPs, or nil if
+// the pattern transform or the transform of the state is degenerate.
+func (b *blitter) pattern(ctx *context, pattern *gxui.Pattern, bounds math.Rect, state *drawState) image.Image {
+	texCoords, ok := pattern.TexCoords(bounds)
+	if !ok {
+		return nil
+	}
+	fromTarget, ok := state.toTarget(ctx).Invert()
+	if !ok {
+		return nil
+	}
+	return &patternImage{
+		source: pattern.Texture.(*TextureImpl).source(),
+		toUV:   texCoords.Mul(fromTarget),
+		wrap:   pattern.Wrap,
+	}
+}
+
+// patternImage is an unbounded image of a wrapped texture, sampling the nearest
+// texture pixel.
+type patternImage struct {
+	source image.Image
+	toUV   math.Mat3
+	wrap   gxui.WrapMode
+}
+
+func (p *patternImage) ColorModel() color.Model {
+	return p.source.ColorModel()
+}
+
+func (p *patternImage) Bounds() image.Rectangle {
+	return image.Rect(-1e9, -1e9, 1e9, 1e9)
+}
+
+func (p *patternImage) At(x, y int) color.Color {
+	uv := p.toUV.TransformVec2(math.Vec2{X: float32(x) + 0.5, Y: float32(y) + 0.5})
+	bounds := p.source.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	sx := math.Clamp(int(p.wrap.Apply(uv.X)*float32(w)), 0, w-1)
+	sy := math.Clamp(int(p.wrap.Apply(uv.Y)*float32(h)), 0, h-1)
+	return p.source.At(bounds.Min.X+sx, bounds.Min.Y+sy)
+}
@@ -0,0 +1,77 @@
+package soft
+
+import (
+	"image"
+
+	"github.com/chewxy/math32"
+)
+
+// gaussianKernel returns the normalized weights of a Gaussian blur with the
+// standard deviation sigma, from the center to one of its ends.
+func gaussianKernel(sigma float32) []float32 {
+	radius := int(math32.Ceil(3 * sigma))
+	kernel := make([]float32, radius+1)
+	sum := float32(0)
+	for i := range kernel {
+		kernel[i] = math32.Exp(-float32(i*i) / (2 * sigma * sigma))
+		if i == 0 {
+			sum += kernel[i]
+		} else {
+			sum += 2 * kernel[i]
+		}
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// blur applies the Gaussian blur with the standard deviation sigma to the
+// rows then the columns of pix, which has the stride and channels per pixel
+// given, within bounds relative to pix. Pixels outside bounds are clamped to
+// its edges.
+func blur(pix []uint8, stride, channels int, bounds image.Rectangle, sigma float32) {
+	if sigma <= 0 || bounds.Empty() {
+		return
+	}
+	kernel := gaussianKernel(sigma)
+	w, h := bounds.Dx(), bounds.Dy()
+	line := make([]float32, max(w, h)*channels)
+
+	pass := func(count, length int, offset func(i, j int) int) {
+		for i := 0; i < count; i++ {
+			for j := 0; j < length; j++ {
+				for c := 0; c < channels; c++ {
+					sum := float32(0)
+					for k, weight := range kernel {
+						sum += weight * float32(pix[offset(i, min(j+k, length-1))+c])
+						if k > 0 {
+							sum += weight * float32(pix[offset(i, max(j-k, 0))+c])
+						}
+					}
+					line[j*channels+c] = sum
+				}
+			}
+			for j := 0; j < length; j++ {
+				for c := 0; c < channels; c++ {
+					pix[offset(i, j)+c] = uint8(math32.Min(line[j*channels+c]+0.5, 0xff))
+				}
+			}
+		}
+	}
+
+	x0, y0 := bounds.Min.X, bounds.Min.Y
+	pass(h, w, func(y, x int) int { return (y0+y)*stride + (x0+x)*channels })
+	pass(w, h, func(x, y int) int { return (y0+y)*stride + (x0+x)*channels })
+}
+
+// blurAlpha blurs the whole of img.
+func blurAlpha(img *image.Alpha, sigma float32) {
+	blur(img.Pix, img.Stride, 1, img.Rect.Sub(img.Rect.Min), sigma)
+}
+
+// blurRGBA blurs the whole of img. The colors of img are premultiplied, so they
+// are blurred channel by channel.
+func blurRGBA(img *image.RGBA, sigma float32) {
+	blur(img.Pix, img.Stride, 4, img.Rect.Sub(img.Rect.Min), sigma)
+}
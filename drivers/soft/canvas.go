@@ -0,0 +1,430 @@
+package soft
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/geometry"
+	"github.com/badu/gxui/pkg/math"
+)
+
+type drawStateStack []drawState
+
+func (s *drawStateStack) head() *drawState {
+	return &(*s)[len(*s)-1]
+}
+func (s *drawStateStack) push(ds drawState) {
+	*s = append(*s, ds)
+}
+func (s *drawStateStack) pop() {
+	*s = (*s)[:len(*s)-1]
+}
+
+type canvasOp func(ctx *context, stack *drawStateStack)
+
+// canvasUse marks the resources drawn by an op as used by the frame.
+type canvasUse func(ctx *context)
+
+type drawState struct {
+	// The below are all in target image coordinates
+	ClipPixels   math.Rect
+	OriginPixels math.Point
+	// Transform is applied to everything drawn, in DIPs, before it is offset
+	// by OriginPixels.
+	Transform math.Mat3
+	// Mask is the coverage of the clips that are not aligned to the axes, or
+	// nil if there are none. It covers at least ClipPixels.
+	Mask *image.Alpha
+	// Layer is the layer drawn into, or nil to draw into the viewport's image.
+	Layer *layer
+}
+
+// layer is drawn into by the canvas ops between PushLayer and PopLayer, in
+// place of the target it is composited onto.
+type layer struct {
+	parent  *image.RGBA
+	opacity float32
+	mode    gxui.BlendMode
+}
+
+// toTarget returns the matrix transforming DIPs into target pixels.
+func (s *drawState) toTarget(ctx *context) math.Mat3 {
+	dipsToPixels := ctx.resolution.dipsToPixels()
+	return math.CreateMat3Translate(float32(s.OriginPixels.X), float32(s.OriginPixels.Y)).
+		Mul(math.CreateMat3Scale(dipsToPixels, dipsToPixels)).
+		Mul(s.Transform)
+}
+
+// pixelsToTarget returns the matrix transforming pixels, relative to the
+// origin, into target pixels.
+func (s *drawState) pixelsToTarget(ctx *context) math.Mat3 {
+	pixelsToDips := 1 / ctx.resolution.dipsToPixels()
+	return s.toTarget(ctx).Mul(math.CreateMat3Scale(pixelsToDips, pixelsToDips))
+}
+
+type CanvasImpl struct {
+	ops               []canvasOp
+	uses              []canvasUse
+	sizeDips          math.Size
+	buildingPushCount int
+	buildingLayers    []int // The push counts of the layers being built.
+	built             bool
+	readsBackdrop     bool // True if the canvas draws with what is under it.
+}
+
+func NewCanvas(sizeDips math.Size) *CanvasImpl {
+	if sizeDips.Width <= 0 || sizeDips.Height < 0 {
+		panic(fmt.Errorf("canvas width and height must be positive. Size: %d", sizeDips))
+	}
+
+	result := &CanvasImpl{sizeDips: sizeDips}
+	return result
+}
+
+func (c *CanvasImpl) draw(ctx *context, stack *drawStateStack) {
+	for _, op := range c.ops {
+		op(ctx, stack)
+	}
+}
+
+func (c *CanvasImpl) appendOp(name string, op canvasOp) {
+	if c.built {
+		panic(fmt.Errorf("%s() called after Complete()", name))
+	}
+	c.ops = append(c.ops, op)
+}
+
+func (c *CanvasImpl) appendUse(use canvasUse) {
+	c.uses = append(c.uses, use)
+}
+
+// mark marks the resources of the canvas, and of the canvases it draws, as
+// used by the frame. The frames drawing only part of the window keep the
+// resources of the canvas they did not draw.
+func (c *CanvasImpl) mark(ctx *context) {
+	for _, use := range c.uses {
+		use(ctx)
+	}
+}
+
+// gxui.Canvas compliance
+func (c *CanvasImpl) Size() math.Size {
+	return c.sizeDips
+}
+
+func (c *CanvasImpl) IsComplete() bool {
+	return c.built
+}
+
+func (c *CanvasImpl) Complete() {
+	if c.built {
+		panic("complete() called twice")
+	}
+
+	if c.buildingPushCount != 0 {
+		panic(fmt.Errorf("push() count was %d when calling Complete", c.buildingPushCount))
+	}
+
+	c.built = true
+}
+
+func (c *CanvasImpl) Push() {
+	c.buildingPushCount++
+	c.appendOp(
+		"Push",
+		func(ctx *context, stack *drawStateStack) {
+			stack.push(*stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) Pop() {
+	if n := len(c.buildingLayers); n > 0 && c.buildingLayers[n-1] == c.buildingPushCount {
+		panic("Pop() called to end a layer, use PopLayer()")
+	}
+	c.buildingPushCount--
+	c.appendOp(
+		"Pop",
+		func(ctx *context, stack *drawStateStack) {
+			stack.pop()
+		},
+	)
+}
+
+func (c *CanvasImpl) PushLayer(opacity float32, mode gxui.BlendMode) {
+	c.buildingPushCount++
+	c.buildingLayers = append(c.buildingLayers, c.buildingPushCount)
+	c.appendOp(
+		"PushLayer",
+		func(ctx *context, stack *drawStateStack) {
+			stack.push(*stack.head())
+			head := stack.head()
+			head.Layer = &layer{parent: ctx.target, opacity: opacity, mode: mode}
+			// Nothing is drawn outside the clip, so the layer only covers it.
+			ctx.target = image.NewRGBA(ctx.blitter.clip(ctx, head))
+		},
+	)
+}
+
+func (c *CanvasImpl) PopLayer() {
+	if n := len(c.buildingLayers); n == 0 || c.buildingLayers[n-1] != c.buildingPushCount {
+		panic("PopLayer() called without PushLayer()")
+	}
+	c.buildingLayers = c.buildingLayers[:len(c.buildingLayers)-1]
+	c.buildingPushCount--
+	c.appendOp(
+		"PopLayer",
+		func(ctx *context, stack *drawStateStack) {
+			l, src := stack.head().Layer, ctx.target
+			stack.pop()
+			ctx.target = l.parent
+			ctx.blitter.blitLayer(ctx, l, src, stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) AddClip(rect math.Rect) {
+	c.appendOp(
+		"AddClip",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			if head.Transform == math.Mat3Ident {
+				rectLocalPixels := ctx.resolution.rectDipsToPixels(rect)
+				rectWindowPixels := rectLocalPixels.Offset(head.OriginPixels)
+				head.ClipPixels = head.ClipPixels.Overlap(rectWindowPixels)
+			} else {
+				head.ClipPixels = head.ClipPixels.Overlap(head.toTarget(ctx).TransformRect(rect))
+				if !head.Transform.IsAxisAligned() {
+					head.Mask = ctx.blitter.clipMask(ctx, rect, head)
+				}
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) Transform(m math.Mat3) {
+	c.appendOp(
+		"Transform",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			head.Transform = head.Transform.Mul(m)
+		},
+	)
+}
+
+func (c *CanvasImpl) Clear(color gxui.Color) {
+	c.appendOp(
+		"Clear",
+		func(ctx *context, stack *drawStateStack) {
+			ctx.blitter.clear(ctx, color, stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawCanvas(targetCanvas gxui.Canvas, offsetDips math.Point) {
+	if targetCanvas == nil {
+		panic("target canvas cannot be nil")
+	}
+
+	childCanvas := targetCanvas.(*CanvasImpl)
+	c.readsBackdrop = c.readsBackdrop || childCanvas.readsBackdrop
+	c.appendUse(childCanvas.mark)
+	c.appendOp(
+		"DrawCanvas",
+		func(ctx *context, stack *drawStateStack) {
+			stack.push(*stack.head())
+			head := stack.head()
+			if head.Transform == math.Mat3Ident {
+				offsetPixels := ctx.resolution.pointDipsToPixels(offsetDips)
+				head.OriginPixels = head.OriginPixels.Add(offsetPixels)
+			} else {
+				head.Transform = head.Transform.Mul(math.CreateMat3Translate(float32(offsetDips.X), float32(offsetDips.Y)))
+			}
+			// Nothing of the child canvas is drawn outside of the clip.
+			if head.ClipPixels.Size().Area() > 0 {
+				childCanvas.draw(ctx, stack)
+			}
+			stack.pop()
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawRunes(useFont gxui.Font, runes []rune, points []math.Point, color gxui.Color) {
+	if useFont == nil {
+		panic("font cannot be nil")
+	}
+
+	runesCopy := append([]rune{}, runes...)
+	pointsCopy := append([]math.Point{}, points...)
+	c.appendOp(
+		"DrawRunes",
+		func(ctx *context, stack *drawStateStack) {
+			driverFont(useFont).DrawRunes(ctx, runesCopy, pointsCopy, color, stack.head())
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawLines(lines gxui.Polygon, pen gxui.Pen) {
+	edge := openPolyToShape(lines, pen)
+	c.appendOp(
+		"DrawLines",
+		func(ctx *context, dss *drawStateStack) {
+			head := dss.head()
+			if edge != nil && pen.Color.A > 0 {
+				ctx.blitter.blitShape(ctx, edge, pen.Color, head)
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawPolygon(poly gxui.Polygon, pen gxui.Pen, brush gxui.Brush) {
+	fill, edge := closedPolyToShape(poly, pen)
+	bounds := poly.Bounds()
+	c.markBrush(brush)
+	c.appendOp(
+		"DrawPolygon",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			if fill != nil && !brush.IsTransparent() {
+				if brush.Pattern != nil {
+					ctx.blitter.blitPatternShape(ctx, fill, brush.Pattern, bounds, head)
+				} else if brush.Gradient != nil {
+					ctx.blitter.blitGradientShape(ctx, fill, brush.Gradient, bounds, head)
+				} else {
+					ctx.blitter.blitShape(ctx, fill, brush.Color, head)
+				}
+			}
+			if edge != nil && pen.Color.A > 0 {
+				ctx.blitter.blitShape(ctx, edge, pen.Color, head)
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawRect(rect math.Rect, brush gxui.Brush) {
+	c.markBrush(brush)
+	c.appendOp(
+		"DrawRect",
+		func(ctx *context, dss *drawStateStack) {
+			head := dss.head()
+			if head.Transform != math.Mat3Ident {
+				// Transformed rectangles are no longer aligned to pixels.
+				fill := rectShape(rect)
+				if brush.Pattern != nil {
+					ctx.blitter.blitPatternShape(ctx, fill, brush.Pattern, rect, head)
+				} else if brush.Gradient != nil {
+					ctx.blitter.blitGradientShape(ctx, fill, brush.Gradient, rect, head)
+				} else {
+					ctx.blitter.blitShape(ctx, fill, brush.Color, head)
+				}
+			} else if brush.Pattern != nil {
+				ctx.blitter.blitPatternRect(ctx, ctx.resolution.rectDipsToPixels(rect), brush.Pattern, rect, dss.head())
+			} else if brush.Gradient != nil {
+				ctx.blitter.blitGradientRect(ctx, ctx.resolution.rectDipsToPixels(rect), brush.Gradient, dss.head())
+			} else {
+				ctx.blitter.blitRect(ctx, ctx.resolution.rectDipsToPixels(rect), brush.Color, dss.head())
+			}
+		},
+	)
+}
+
+// markBrush marks the ramp of the gradient of the brush as used by the frames
+// drawing the canvas.
+func (c *CanvasImpl) markBrush(brush gxui.Brush) {
+	if gradient := brush.Gradient; gradient != nil {
+		c.appendUse(func(ctx *context) {
+			ctx.blitter.markGradient(gradient)
+		})
+	}
+}
+
+func (c *CanvasImpl) DrawRoundedRect(rect math.Rect, tl, tr, bl, br float32, pen gxui.Pen, brush gxui.Brush) {
+	if tl == 0 && tr == 0 && bl == 0 && br == 0 && pen.Color.A == 0 {
+		c.DrawRect(rect, brush)
+		return
+	}
+
+	polygon := gxui.Polygon{
+		gxui.PolygonVertex{Position: rect.TopLeft(), RoundedRadius: tl},
+		gxui.PolygonVertex{Position: rect.TopRight(), RoundedRadius: tr},
+		gxui.PolygonVertex{Position: rect.BottomRight(), RoundedRadius: br},
+		gxui.PolygonVertex{Position: rect.BottomLeft(), RoundedRadius: bl},
+	}
+
+	c.DrawPolygon(polygon, pen, brush)
+}
+
+func (c *CanvasImpl) DrawShadow(rect math.Rect, tl, tr, bl, br float32, shadow gxui.Shadow) {
+	caster := shadowShape(rect, [4]float32{tl, tr, bl, br}, shadow)
+	c.appendOp(
+		"DrawShadow",
+		func(ctx *context, stack *drawStateStack) {
+			if caster != nil && shadow.IsVisible() {
+				ctx.blitter.blitShadow(ctx, caster, shadow.Blur/2, shadow.Color, stack.head())
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) BlurBackdrop(rect math.Rect, radius float32) {
+	c.readsBackdrop = c.readsBackdrop || radius > 0
+	c.appendOp(
+		"BlurBackdrop",
+		func(ctx *context, stack *drawStateStack) {
+			if radius > 0 {
+				ctx.blitter.blurBackdrop(ctx, rect, radius/2, stack.head())
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) FillPath(path *gxui.Path, rule gxui.FillRule, brush gxui.Brush) {
+	fill := fillPathShape(path, rule)
+	bounds := path.Bounds()
+	c.markBrush(brush)
+	c.appendOp(
+		"FillPath",
+		func(ctx *context, stack *drawStateStack) {
+			head := stack.head()
+			if fill != nil && !brush.IsTransparent() {
+				if brush.Pattern != nil {
+					ctx.blitter.blitPatternShape(ctx, fill, brush.Pattern, bounds, head)
+				} else if brush.Gradient != nil {
+					ctx.blitter.blitGradientShape(ctx, fill, brush.Gradient, bounds, head)
+				} else {
+					ctx.blitter.blitShape(ctx, fill, brush.Color, head)
+				}
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) StrokePath(path *gxui.Path, pen gxui.Pen) {
+	var edge shape
+	if pen.Width > 0 {
+		edge = newTrianglesShape(geometry.StrokePath(path, pen))
+	}
+	c.appendOp(
+		"StrokePath",
+		func(ctx *context, stack *drawStateStack) {
+			if edge != nil && pen.Color.A > 0 {
+				ctx.blitter.blitShape(ctx, edge, pen.Color, stack.head())
+			}
+		},
+	)
+}
+
+func (c *CanvasImpl) DrawTexture(targetTexture gxui.Texture, r math.Rect) {
+	if targetTexture == nil {
+		panic("target texture cannot be nil")
+	}
+
+	texture := targetTexture.(*TextureImpl)
+	c.appendOp(
+		"DrawTexture",
+		func(ctx *context, stack *drawStateStack) {
+			ctx.blitter.blit(ctx, texture, texture.SizePixels().Rect(), ctx.resolution.rectDipsToPixels(r), stack.head())
+		},
+	)
+}
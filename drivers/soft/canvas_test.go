@@ -0,0 +1,721 @@
+package soft
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/badu/gxui"
+	gxfont "github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+	"github.com/chewxy/math32"
+)
+
+func render(driver *DriverImpl, width, height int, scale float32, paint func(canvas gxui.Canvas)) *image.RGBA {
+	viewport := driver.CreateWindowedViewport(width, height, "test").(*ViewportImpl)
+	viewport.SetScale(scale)
+	driver.CallSync(func() {
+		canvas := driver.CreateCanvas(viewport.SizeDips())
+		paint(canvas)
+		canvas.Complete()
+		viewport.SetCanvas(canvas)
+	})
+	return viewport.Image()
+}
+
+func rgba(img *image.RGBA, x, y int) color.RGBA {
+	return img.RGBAAt(x, y)
+}
+
+func TestDrawRect(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 10, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawRect(math.CreateRect(5, 2, 10, 8), gxui.CreateBrush(gxui.Red))
+	})
+
+	test_helper.AssertEquals(t, image.Rect(0, 0, 20, 10), img.Bounds())
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 5, 2))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 9, 7))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 4, 2))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 10, 7))
+}
+
+func TestDrawRectScaled(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 20, 2, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawRect(math.CreateRect(1, 1, 2, 2), gxui.CreateBrush(gxui.White))
+	})
+
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 1, 1))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, rgba(img, 2, 2))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, rgba(img, 3, 3))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 4, 4))
+}
+
+func TestClipAndDrawCanvas(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 20, 1, func(canvas gxui.Canvas) {
+		child := driver.CreateCanvas(math.Size{Width: 10, Height: 10})
+		child.DrawRect(math.CreateRect(0, 0, 10, 10), gxui.CreateBrush(gxui.Green))
+		child.Complete()
+
+		canvas.Clear(gxui.Black)
+		canvas.Push()
+		canvas.AddClip(math.CreateRect(0, 0, 8, 8))
+		canvas.DrawCanvas(child, math.Point{X: 4, Y: 4})
+		canvas.Pop()
+	})
+
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 3, 3))
+	test_helper.AssertEquals(t, color.RGBA{G: 0xff, A: 0xff}, rgba(img, 4, 4))
+	test_helper.AssertEquals(t, color.RGBA{G: 0xff, A: 0xff}, rgba(img, 7, 7))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 8, 8))
+}
+
+func TestSetCanvasDamage(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	viewport := driver.CreateWindowedViewport(20, 10, "test").(*ViewportImpl)
+	cleared := func(color gxui.Color) gxui.Canvas {
+		canvas := driver.CreateCanvas(viewport.SizeDips())
+		canvas.Clear(color)
+		canvas.Complete()
+		return canvas
+	}
+
+	driver.CallSync(func() {
+		viewport.SetCanvas(cleared(gxui.Black))
+	})
+	viewport.Image()
+	driver.CallSync(func() {
+		viewport.SetCanvasDamage(cleared(gxui.Red), []math.Rect{math.CreateRect(5, 2, 10, 8)})
+	})
+	img := viewport.Image()
+
+	// Only the damage, grown by a pixel, is redrawn.
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 5, 2))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 10, 8))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 3, 2))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 12, 5))
+
+	// The damage of the canvases replaced before being drawn is redrawn with
+	// the last one.
+	driver.CallSync(func() {
+		viewport.SetCanvasDamage(cleared(gxui.Red), []math.Rect{math.CreateRect(0, 0, 2, 2)})
+		viewport.SetCanvasDamage(cleared(gxui.Green), []math.Rect{math.CreateRect(16, 6, 20, 10)})
+	})
+	img = viewport.Image()
+	test_helper.AssertEquals(t, color.RGBA{G: 0xff, A: 0xff}, rgba(img, 0, 0))
+	test_helper.AssertEquals(t, color.RGBA{G: 0xff, A: 0xff}, rgba(img, 19, 9))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 7, 5))
+}
+
+func TestPartialFramesKeepRamps(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	viewport := driver.CreateWindowedViewport(20, 10, "test").(*ViewportImpl)
+	stops := []gxui.GradientStop{{Offset: 0, Color: gxui.Black}, {Offset: 1, Color: gxui.White}}
+
+	// The child is clipped out of the damage of the partial frames, which do
+	// not draw it.
+	brush := gxui.CreateLinearGradientBrush(0, stops...)
+	child := driver.CreateCanvas(viewport.SizeDips())
+	child.DrawRect(math.CreateRect(10, 0, 20, 10), brush)
+	child.Complete()
+	frame := func(damage []math.Rect) {
+		driver.CallSync(func() {
+			canvas := driver.CreateCanvas(viewport.SizeDips())
+			canvas.Push()
+			canvas.AddClip(math.CreateRect(10, 0, 20, 10))
+			canvas.DrawCanvas(child, math.ZeroPoint)
+			canvas.Pop()
+			// Each frame draws a new gradient.
+			canvas.DrawRect(math.CreateRect(0, 0, 5, 5), gxui.CreateLinearGradientBrush(0, stops...))
+			canvas.Complete()
+			viewport.SetCanvasDamage(canvas, damage)
+		})
+		viewport.Image()
+	}
+
+	frame(nil)
+	for range 100 {
+		frame([]math.Rect{math.CreateRect(0, 0, 5, 5)})
+	}
+	driver.CallSync(func() {
+		// The ramps of the gradients of the last frame are kept.
+		_, found := viewport.context.blitter.ramps[brush.Gradient]
+		test_helper.AssertEquals(t, true, found)
+		test_helper.AssertEquals(t, 2, len(viewport.context.blitter.ramps))
+	})
+}
+
+func TestViewportStats(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	viewport := driver.CreateWindowedViewport(20, 10, "test").(*ViewportImpl)
+	canvas := driver.CreateCanvas(viewport.SizeDips())
+	canvas.Clear(gxui.Black)
+	canvas.Complete()
+
+	driver.CallSync(func() {
+		viewport.RecordFrameTimes(time.Millisecond, 2*time.Millisecond)
+		viewport.SetCanvas(canvas)
+	})
+	viewport.Image()
+	driver.CallSync(func() {
+		viewport.SetCanvasDamage(canvas, []math.Rect{math.CreateRect(5, 2, 10, 8)})
+	})
+	viewport.Image()
+
+	stats := viewport.Stats()
+	test_helper.AssertEquals(t, 2, stats.FrameCount)
+	test_helper.AssertEquals(t, 2, len(stats.Frames))
+	test_helper.AssertEquals(t, time.Millisecond, stats.Frames[0].Layout)
+	test_helper.AssertEquals(t, 2*time.Millisecond, stats.Frames[0].Paint)
+	test_helper.AssertEquals(t, false, stats.Frames[0].Partial)
+	test_helper.AssertEquals(t, time.Duration(0), stats.Frames[1].Layout)
+	test_helper.AssertEquals(t, true, stats.Frames[1].Partial)
+	test_helper.AssertEquals(t, 1, driver.Stats().Viewports)
+}
+
+func TestDrawPolygon(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 20, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawPolygon(
+			gxui.Polygon{
+				{Position: math.Point{X: 2, Y: 2}},
+				{Position: math.Point{X: 18, Y: 2}},
+				{Position: math.Point{X: 18, Y: 18}},
+				{Position: math.Point{X: 2, Y: 18}},
+			},
+			gxui.CreatePen(2, gxui.Red),
+			gxui.CreateBrush(gxui.Blue),
+		)
+	})
+
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 1, 1))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 2, 10))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 10, 3))
+	test_helper.AssertEquals(t, color.RGBA{B: 0xff, A: 0xff}, rgba(img, 10, 10))
+}
+
+func TestDrawDashedPolygon(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 24, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawPolygon(
+			gxui.Polygon{
+				{Position: math.Point{X: 2, Y: 2}},
+				{Position: math.Point{X: 18, Y: 2}},
+				{Position: math.Point{X: 18, Y: 18}},
+				{Position: math.Point{X: 2, Y: 18}},
+			},
+			gxui.CreateDashedPen(2, gxui.Red, 0, 4, 4),
+			gxui.CreateBrush(gxui.Blue),
+		)
+		canvas.DrawLines(
+			gxui.Polygon{
+				{Position: math.Point{X: 2, Y: 20}},
+				{Position: math.Point{X: 18, Y: 20}},
+			},
+			gxui.CreateDashedPen(2, gxui.Green, 0, 4, 4),
+		)
+	})
+
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 1, 1))
+	// The dashes are inside the outline, and the gaps show the fill.
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 4, 2))
+	test_helper.AssertEquals(t, color.RGBA{B: 0xff, A: 0xff}, rgba(img, 8, 2))
+	test_helper.AssertEquals(t, color.RGBA{B: 0xff, A: 0xff}, rgba(img, 10, 10))
+	// Lines are drawn below their points, as with plain pens.
+	test_helper.AssertEquals(t, color.RGBA{G: 0xff, A: 0xff}, rgba(img, 3, 21))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 7, 21))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 3, 19))
+}
+
+func TestDrawPaths(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 20, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		path := &gxui.Path{}
+		for _, r := range []float32{8, 4} {
+			path.MoveTo(math.Vec2{X: 10 - r, Y: 10})
+			path.ArcTo(math.Vec2{X: r, Y: r}, 0, false, true, math.Vec2{X: 10 + r, Y: 10})
+			path.ArcTo(math.Vec2{X: r, Y: r}, 0, false, true, math.Vec2{X: 10 - r, Y: 10})
+			path.Close()
+		}
+		canvas.FillPath(path, gxui.EvenOdd, gxui.CreateBrush(gxui.Blue))
+
+		line := &gxui.Path{}
+		line.MoveTo(math.Vec2{X: 0, Y: 19})
+		line.QuadTo(math.Vec2{X: 10, Y: 19}, math.Vec2{X: 20, Y: 19})
+		canvas.StrokePath(line, gxui.CreatePen(2, gxui.Red))
+	})
+
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 0, 0))
+	test_helper.AssertEquals(t, color.RGBA{B: 0xff, A: 0xff}, rgba(img, 3, 10))
+	// The inner circle is a hole with the even-odd rule.
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 10, 10))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 10, 18))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, rgba(img, 10, 19))
+}
+
+func TestDrawGradients(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	stops := []gxui.GradientStop{{Offset: 0, Color: gxui.Black}, {Offset: 1, Color: gxui.White}}
+	img := render(driver, 40, 20, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Red)
+		canvas.DrawRect(math.CreateRect(0, 0, 20, 20), gxui.CreateLinearGradientBrush(0, stops...))
+		canvas.DrawPolygon(
+			gxui.Polygon{
+				{Position: math.Point{X: 20, Y: 0}},
+				{Position: math.Point{X: 40, Y: 0}},
+				{Position: math.Point{X: 40, Y: 20}},
+				{Position: math.Point{X: 20, Y: 20}},
+			},
+			gxui.TransparentPen,
+			gxui.CreateRadialGradientBrush(math.Vec2{X: 0.5, Y: 0.5}, 0.5, stops...),
+		)
+	})
+
+	// The linear gradient goes from black on the left to white on the right.
+	if left, right := rgba(img, 0, 10), rgba(img, 19, 10); left.R > 0x10 || right.R < 0xf0 || left.G != left.R {
+		t.Errorf("Expected the linear gradient to go from black to white, got %v and %v", left, right)
+	}
+	if middle := rgba(img, 10, 5); middle.R < 0x70 || middle.R > 0x90 {
+		t.Errorf("Expected the middle of the linear gradient to be gray, got %v", middle)
+	}
+	// The radial gradient is black at its center and white on its edge.
+	if center, edge := rgba(img, 30, 10), rgba(img, 30, 0); center.R > 0x20 || edge.R < 0xe0 {
+		t.Errorf("Expected the radial gradient to go from black to white, got %v and %v", center, edge)
+	}
+}
+
+func TestDrawPatterns(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	// A 2x2 texture with a red, green, blue and white pixel.
+	checker := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	red, green, blue, white := color.RGBA{R: 0xff, A: 0xff}, color.RGBA{G: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	checker.SetRGBA(0, 0, red)
+	checker.SetRGBA(1, 0, green)
+	checker.SetRGBA(0, 1, blue)
+	checker.SetRGBA(1, 1, white)
+	texture := driver.CreateTexture(checker, 1)
+
+	scale := math.CreateMat3Scale(2, 2)
+	img := render(driver, 8, 24, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawRect(math.CreateRect(0, 0, 8, 4), gxui.CreatePatternBrush(texture, gxui.WrapRepeat, scale))
+		canvas.DrawRect(math.CreateRect(0, 8, 8, 12), gxui.CreatePatternBrush(texture, gxui.WrapMirror, scale))
+		canvas.DrawRect(math.CreateRect(0, 16, 8, 20), gxui.CreatePatternBrush(texture, gxui.WrapClamp, scale))
+		canvas.DrawRoundedRect(math.CreateRect(0, 20, 8, 24), 1, 1, 1, 1, gxui.TransparentPen, gxui.CreateImageBrush(texture, math.Size{Width: 8, Height: 4}))
+	})
+
+	for _, test := range []struct {
+		name     string
+		y        int
+		expected [4]color.RGBA
+	}{
+		{"repeat", 0, [4]color.RGBA{red, green, red, green}},
+		{"mirror", 8, [4]color.RGBA{red, green, green, red}},
+		{"clamp", 16, [4]color.RGBA{red, green, green, green}},
+		{"image", 21, [4]color.RGBA{red, red, green, green}},
+	} {
+		for i, expected := range test.expected {
+			if got := rgba(img, i*2+1, test.y); got != expected {
+				t.Errorf("%s: expected %v at x=%d, got %v", test.name, expected, i*2+1, got)
+			}
+		}
+	}
+	// The pattern is placed relative to the top-left corner of the rect.
+	test_helper.AssertEquals(t, blue, rgba(img, 1, 3))
+	test_helper.AssertEquals(t, white, rgba(img, 5, 23))
+}
+
+func TestDrawRunes(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	f, err := driver.CreateFont(gxfont.Default, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := render(driver, 40, 20, 1, func(canvas gxui.Canvas) {
+		runes := []rune("gx")
+		offsets := f.Layout(&gxui.TextBlock{Runes: runes, AlignRect: canvas.Size().Rect()})
+		canvas.Clear(gxui.Black)
+		canvas.DrawRunes(f, runes, offsets, gxui.White)
+	})
+
+	lit := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] > 0x80 {
+			lit++
+		}
+	}
+	if lit == 0 {
+		t.Errorf("expected some glyph pixels to be drawn")
+	}
+}
+
+func TestTransformAndDrawCanvas(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	green := color.RGBA{G: 0xff, A: 0xff}
+	black := color.RGBA{A: 0xff}
+	img := render(driver, 20, 20, 1, func(canvas gxui.Canvas) {
+		child := driver.CreateCanvas(math.Size{Width: 5, Height: 5})
+		child.DrawRect(math.CreateRect(0, 0, 5, 5), gxui.CreateBrush(gxui.Green))
+		child.Complete()
+
+		canvas.Clear(gxui.Black)
+		canvas.Push()
+		canvas.Transform(math.CreateMat3Scale(2, 2))
+		canvas.DrawCanvas(child, math.Point{X: 2, Y: 2})
+		canvas.Pop()
+	})
+
+	// The offset of the child is scaled too.
+	test_helper.AssertEquals(t, black, rgba(img, 3, 3))
+	test_helper.AssertEquals(t, green, rgba(img, 4, 4))
+	test_helper.AssertEquals(t, green, rgba(img, 13, 13))
+	test_helper.AssertEquals(t, black, rgba(img, 14, 14))
+}
+
+func TestRotatedClip(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	f, err := driver.CreateFont(gxfont.Default, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	red := color.RGBA{R: 0xff, A: 0xff}
+	black := color.RGBA{A: 0xff}
+	img := render(driver, 40, 40, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.Push()
+		canvas.Transform(math.CreateMat3Translate(10, 10).Mul(math.CreateMat3Rotate(math32.Pi / 4)))
+		canvas.AddClip(math.CreateRect(-5, -5, 5, 5))
+		canvas.DrawRect(math.CreateRect(-10, -10, 10, 10), gxui.CreateBrush(gxui.Red))
+		canvas.Pop()
+
+		// Text rotated by a quarter turn runs down the right half.
+		runes := []rune("gx")
+		canvas.Push()
+		canvas.Transform(math.CreateMat3Translate(40, 20).Mul(math.CreateMat3Rotate(math32.Pi / 2)))
+		canvas.DrawRunes(f, runes, f.Layout(&gxui.TextBlock{Runes: runes}), gxui.White)
+		canvas.Pop()
+	})
+
+	// The clip is a diamond, and the rectangle does not overflow it.
+	test_helper.AssertEquals(t, red, rgba(img, 10, 10))
+	test_helper.AssertEquals(t, red, rgba(img, 15, 10))
+	test_helper.AssertEquals(t, black, rgba(img, 14, 14))
+	test_helper.AssertEquals(t, black, rgba(img, 5, 5))
+
+	lit := image.Rectangle{}
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			if rgba(img, x, y).B > 0x80 {
+				lit = lit.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if lit.Empty() || lit.Min.X < 20 || lit.Min.Y < 20 || lit.Dy() <= lit.Dx() {
+		t.Errorf("Expected the glyphs to be drawn down the right half, got %v", lit)
+	}
+}
+
+func TestLayer(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 10, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.PushLayer(0.5, gxui.BlendNormal)
+		canvas.DrawRect(math.CreateRect(0, 0, 10, 10), gxui.CreateBrush(gxui.White))
+		canvas.DrawRect(math.CreateRect(5, 0, 15, 10), gxui.CreateBrush(gxui.White))
+		canvas.PopLayer()
+	})
+
+	// The overlapping rectangles do not show through the layer.
+	test_helper.AssertEquals(t, color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}, rgba(img, 2, 5))
+	test_helper.AssertEquals(t, color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}, rgba(img, 7, 5))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 17, 5))
+}
+
+func TestLayerBlendModes(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	for _, test := range []struct {
+		mode     gxui.BlendMode
+		expected color.RGBA
+	}{
+		{gxui.BlendMultiply, color.RGBA{R: 0x80, A: 0xff}},
+		{gxui.BlendScreen, color.RGBA{R: 0xff, G: 0x80, B: 0x80, A: 0xff}},
+		{gxui.BlendAdd, color.RGBA{R: 0xff, G: 0x80, B: 0x80, A: 0xff}},
+	} {
+		img := render(driver, 10, 10, 1, func(canvas gxui.Canvas) {
+			canvas.Clear(gxui.Red)
+			canvas.PushLayer(1, test.mode)
+			canvas.DrawRect(math.CreateRect(0, 0, 10, 10), gxui.CreateBrush(gxui.Gray50))
+			canvas.PopLayer()
+		})
+		test_helper.AssertEquals(t, test.expected, rgba(img, 5, 5))
+	}
+}
+
+func TestDrawShadow(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 40, 20, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.White)
+		canvas.DrawShadow(math.CreateRect(2, 2, 8, 8), 0, 0, 0, 0, gxui.Shadow{Offset: math.Point{X: 2, Y: 2}, Spread: 1, Color: gxui.Black})
+		canvas.DrawShadow(math.CreateRect(20, 5, 30, 15), 0, 0, 0, 0, gxui.Shadow{Blur: 4, Color: gxui.Black})
+	})
+
+	// The shadow is offset and spread, without blur.
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 3, 3))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 10, 10))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, rgba(img, 2, 2))
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, rgba(img, 11, 11))
+
+	// The blurred shadow fades out across the edges.
+	inside, edge, outside := rgba(img, 22, 10).R, rgba(img, 20, 10).R, rgba(img, 18, 10).R
+	if !(inside < edge && edge < outside && outside < 0xff) {
+		t.Errorf("Expected the shadow to fade out, got %d, %d, %d", inside, edge, outside)
+	}
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, rgba(img, 13, 10))
+}
+
+func TestBlurBackdrop(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	img := render(driver, 20, 10, 1, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.White)
+		canvas.DrawRect(math.CreateRect(10, 0, 20, 10), gxui.CreateBrush(gxui.Black))
+		canvas.BlurBackdrop(math.CreateRect(5, 0, 15, 5), 4)
+	})
+
+	// Only the backdrop under the rectangle is blurred.
+	if c := rgba(img, 9, 2).R; c == 0xff || c < 0x80 {
+		t.Errorf("Expected a light gray, got %d", c)
+	}
+	if c := rgba(img, 10, 2).R; c == 0 || c > 0x80 {
+		t.Errorf("Expected a dark gray, got %d", c)
+	}
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, rgba(img, 9, 7))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, rgba(img, 10, 7))
+}
+
+func TestLayoutKerning(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	f, err := driver.CreateFont(gxfont.Default, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := func(text string) []math.Point {
+		return f.Layout(&gxui.TextBlock{Runes: []rune(text)})
+	}
+
+	// V is pulled under the A, and the runes of the fi ligature share its advance.
+	kerned, plain := layout("AVA"), layout("AAA")
+	if kerned[1].X >= plain[1].X {
+		t.Errorf("Expected V to be kerned closer to A, got %v and %v", kerned, plain)
+	}
+	ligature := layout("fix")
+	if ligature[1].X <= ligature[0].X || ligature[2].X <= ligature[1].X {
+		t.Errorf("Expected the runes of the ligature to advance, got %v", ligature)
+	}
+	test_helper.AssertEquals(t, f.Measure(&gxui.TextBlock{Runes: []rune("AVA")}).Width < f.Measure(&gxui.TextBlock{Runes: []rune("AAA")}).Width, true)
+}
+
+func TestLayoutRuns(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	f, err := driver.CreateFont(gxfont.Default, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, err := driver.CreateResizedFont(f, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := driver.CreateResizedFont(f, 32); again != large {
+		t.Errorf("Expected the resized font to be kept")
+	}
+	test_helper.AssertEquals(t, 32, large.Size())
+
+	text := []rune("ab\ncd")
+	plain := f.Layout(&gxui.TextBlock{Runes: text})
+	test_helper.AssertEquals(t, plain, f.Layout(&gxui.TextBlock{Runes: text, Runs: []gxui.TextRun{{Runes: 5}}}))
+
+	// The large b is raised above the baseline of the a, which is lowered to
+	// fit it, and pushes the second line down by its descent.
+	runs := []gxui.TextRun{{Runes: 1}, {Runes: 1, Font: large, BaselineOffset: 3}}
+	offsets := f.Layout(&gxui.TextBlock{Runes: text, Runs: runs})
+	smallAscent := f.Layout(&gxui.TextBlock{Runes: []rune{' '}})[0].Y
+	largeAscent := large.Layout(&gxui.TextBlock{Runes: []rune{' '}})[0].Y
+	largeDescent := large.GlyphMaxSize().Height - largeAscent
+	test_helper.AssertEquals(t, largeAscent+3, offsets[0].Y)
+	test_helper.AssertEquals(t, offsets[0].Y-3, offsets[1].Y)
+	if offsets[1].X != plain[1].X || offsets[4].X-offsets[3].X != plain[4].X-plain[3].X {
+		t.Errorf("Expected the advances of the small runes, got %v", offsets)
+	}
+	test_helper.AssertEquals(t, offsets[0].Y+largeDescent-3+smallAscent, offsets[3].Y)
+
+	measured := f.Measure(&gxui.TextBlock{Runes: text, Runs: runs})
+	test_helper.AssertEquals(t, offsets[3].Y+f.GlyphMaxSize().Height-smallAscent, measured.Height)
+
+	if _, err := driver.CreateResizedFont(f, 0); err == nil {
+		t.Errorf("Expected an error resizing a font to 0")
+	}
+}
+
+func TestLayoutBidi(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	f, err := driver.CreateFont(gxfont.Default, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The Hebrew run is laid out right to left, after the Latin run.
+	offsets := f.Layout(&gxui.TextBlock{Runes: []rune("ab אבג")})
+	if offsets[3].X <= offsets[4].X || offsets[4].X <= offsets[5].X || offsets[5].X <= offsets[2].X {
+		t.Errorf("Expected the Hebrew runes to be laid out right to left, got %v", offsets)
+	}
+
+	// Right to left lines start at the left, with their first rune rightmost.
+	offsets = f.Layout(&gxui.TextBlock{Runes: []rune("אב cd")})
+	if offsets[0].X <= offsets[1].X || offsets[1].X <= offsets[3].X || offsets[3].X >= offsets[4].X {
+		t.Errorf("Expected a right to left line, got %v", offsets)
+	}
+	test_helper.AssertEquals(t, 0, offsets[3].X)
+}
+
+func TestFontFamily(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	monospace, err := driver.CreateFont(gxfont.Monospace, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roboto, err := driver.CreateFont(gxfont.Default, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fallback is used at the size of the first font.
+	small, err := driver.CreateFont(gxfont.Default, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	family, err := driver.CreateFontFamily(monospace, small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fonts := family.Fonts(); len(fonts) != 2 || fonts[0] != monospace || fonts[1] != small {
+		t.Errorf("Expected the fonts of the family in order, got %v", fonts)
+	}
+	test_helper.AssertEquals(t, monospace.Name(), family.Name())
+	test_helper.AssertEquals(t, monospace.GlyphMaxSize(), family.GlyphMaxSize())
+
+	// The rupee sign is missing from the monospace font, and advances by its
+	// width in the fallback.
+	offsets := family.Layout(&gxui.TextBlock{Runes: []rune("a₹b")})
+	rupee := roboto.Measure(&gxui.TextBlock{Runes: []rune("₹")}).Width
+	test_helper.AssertEquals(t, rupee, offsets[2].X-offsets[1].X)
+	test_helper.AssertEquals(t, offsets[1].Y, offsets[2].Y)
+
+	draw := func(f gxui.Font) *image.RGBA {
+		return render(driver, 40, 40, 1, func(canvas gxui.Canvas) {
+			canvas.Clear(gxui.Black)
+			canvas.DrawRunes(f, []rune("₹"), []math.Point{{X: 4, Y: 32}}, gxui.White)
+		})
+	}
+	if string(draw(family).Pix) != string(draw(roboto).Pix) {
+		t.Errorf("Expected the family to draw the rune with the fallback font")
+	}
+	if string(draw(monospace).Pix) == string(draw(roboto).Pix) {
+		t.Errorf("Expected the monospace font to miss the rune")
+	}
+
+	if _, err := driver.CreateFontFamily(); err == nil {
+		t.Errorf("Expected an error creating an empty family")
+	}
+}
+
+func TestCFFFont(t *testing.T) {
+	driver := NewDriver()
+	defer driver.Terminate()
+
+	data, err := os.ReadFile("../../pkg/shaping/testdata/CFFTest.otf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := driver.CreateFont(data, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, "CFFTest", f.Name())
+	if size := f.Measure(&gxui.TextBlock{Runes: []rune("01中")}); size.Width <= 0 || size.Height <= 0 {
+		t.Errorf("Expected the runes to be measured, got %v", size)
+	}
+
+	img := render(driver, 80, 40, 1, func(canvas gxui.Canvas) {
+		runes := []rune("01中")
+		offsets := f.Layout(&gxui.TextBlock{Runes: runes, AlignRect: canvas.Size().Rect()})
+		canvas.Clear(gxui.Black)
+		canvas.DrawRunes(f, runes, offsets, gxui.White)
+	})
+	lit := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] > 0x80 {
+			lit++
+		}
+	}
+	if lit == 0 {
+		t.Errorf("expected some glyph pixels to be drawn")
+	}
+
+	if _, err := driver.CreateCollectionFont(data, 1, 32); err == nil {
+		t.Errorf("Expected an error loading a second font from a single font")
+	}
+}
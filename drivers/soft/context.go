@@ -0,0 +1,40 @@
+package soft
+
+import (
+	"image"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// context holds the state of a single frame being rasterized into target.
+type context struct {
+	blitter    *blitter
+	target     *image.RGBA
+	sizeDips   math.Size
+	sizePixels math.Size
+	resolution resolution
+}
+
+func newContext() *context {
+	return &context{blitter: &blitter{ramps: make(map[*gxui.Gradient]*gradientRamp)}}
+}
+
+func (c *context) beginDraw(target *image.RGBA, sizeDips math.Size) {
+	bounds := target.Bounds()
+	sizePixels := math.Size{Width: bounds.Dx(), Height: bounds.Dy()}
+	dipsToPixels := float32(sizePixels.Width) / float32(sizeDips.Width)
+
+	c.target = target
+	c.sizeDips = sizeDips
+	c.sizePixels = sizePixels
+	c.resolution = resolution(dipsToPixels*65536 + 0.5)
+}
+
+// endDraw ends the frame, forgetting the resources neither used by the frame
+// nor by canvas, which the next frames draw from.
+func (c *context) endDraw(canvas *CanvasImpl) {
+	canvas.mark(c)
+	c.blitter.endDraw()
+	c.target = nil
+}
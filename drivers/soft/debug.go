@@ -1,4 +1,4 @@
-package purego
+package soft
 
 import (
 	"runtime"
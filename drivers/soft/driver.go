@@ -0,0 +1,273 @@
+// Package soft contains a software rasterizer implementation of the gxui.Driver interface.
+//
+// The driver renders into *image.RGBA targets using image/draw and golang/freetype and
+// does not need GLFW, OpenGL or a display, which makes it suitable for running gxui
+// applications headless, for instance inside CI containers.
+package soft
+
+import (
+	"fmt"
+	"image"
+	"sync/atomic"
+	"time"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/list"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// Maximum time allowed for application to process events on termination.
+const maxFlushTime = time.Second * 3
+
+// Size of the virtual screen, used by fullscreen viewports created with a zero width or height.
+const (
+	screenWidth  = 1920
+	screenHeight = 1080
+)
+
+type DriverImpl struct {
+	pendingDriver chan func()
+	pendingApp    chan func()
+	viewports     *list.List[*ViewportImpl]
+	clipboard     string
+	done          chan struct{}
+
+	pcs        []uintptr // reusable scratch-buffer for use by runtime.Callers.
+	uiPC       uintptr   // the program-counter of the applicationLoop function.
+	terminated int32     // non-zero represents driver terminations
+}
+
+// StartDriver starts the software driver with the given appRoutine and blocks until the driver is terminated.
+func StartDriver(appRoutine func(driver gxui.Driver)) {
+	result := NewDriver()
+	result.Call(func() { appRoutine(result) })
+	<-result.done
+}
+
+// NewDriver creates a software driver and starts its application and driver go-routines.
+// Unlike StartDriver it returns immediately, so the driver can be used from tests.
+func NewDriver() *DriverImpl {
+	result := &DriverImpl{
+		pendingDriver: make(chan func(), 256),
+		pendingApp:    make(chan func(), 256),
+		viewports:     list.New[*ViewportImpl](),
+		done:          make(chan struct{}),
+		pcs:           make([]uintptr, 256),
+	}
+
+	result.pendingApp <- result.discoverUIGoRoutine
+
+	go result.applicationLoop()
+	go result.driverLoop()
+
+	return result
+}
+
+func (d *DriverImpl) asyncDriver(callback func()) {
+	d.pendingDriver <- callback
+}
+
+func (d *DriverImpl) syncDriver(callback func()) {
+	done := make(chan bool, 1)
+	d.asyncDriver(
+		func() { callback(); done <- true },
+	)
+	<-done
+}
+
+func (d *DriverImpl) createDriverEvent(signature interface{}) gxui.Event {
+	return gxui.CreateChanneledEvent(signature, d.pendingDriver)
+}
+
+func (d *DriverImpl) createAppEvent(signature interface{}) gxui.Event {
+	return gxui.CreateChanneledEvent(signature, d.pendingApp)
+}
+
+// driverLoop pulls and executes funcs from the pendingDriver chan until the
+// chan is closed. Rendering of all the viewports happens on this routine.
+func (d *DriverImpl) driverLoop() {
+	for ev := range d.pendingDriver {
+		ev()
+	}
+	close(d.done)
+}
+
+// applicationLoop pulls and executes funcs from the pendingApp chan until
+// the chan is closed.
+func (d *DriverImpl) applicationLoop() {
+	for ev := range d.pendingApp {
+		ev()
+	}
+}
+
+// gxui.Driver compliance
+func (d *DriverImpl) Call(callback func()) bool {
+	if callback == nil {
+		panic("Function must not be nil")
+	}
+
+	if atomic.LoadInt32(&d.terminated) != 0 {
+		return false // Driver.Terminate has been called
+	}
+	d.pendingApp <- callback
+	return true
+}
+
+func (d *DriverImpl) CallSync(callback func()) bool {
+	done := make(chan struct{})
+	if d.Call(
+		func() {
+			callback()
+			close(done)
+		},
+	) {
+		<-done
+		return true
+	}
+	return false
+}
+
+func (d *DriverImpl) Terminate() {
+	d.asyncDriver(
+		func() {
+			// Close all viewports. This will notify the application.
+			for frontViewport := d.viewports.Front(); frontViewport != nil; frontViewport = frontViewport.Next() {
+				frontViewport.Value.Destroy()
+			}
+
+			// Flush all remaining events from the application and driver.
+			// This gives the application an opportunity to handle shutdown.
+			flushStart := time.Now()
+			for time.Since(flushStart) < maxFlushTime {
+				done := true
+
+				// Process any application events
+				sync := make(chan struct{})
+				d.Call(func() {
+					select {
+					case ev := <-d.pendingApp:
+						ev()
+						done = false
+					default:
+					}
+					close(sync)
+				})
+
+				<-sync
+
+				// Process any driver events
+				select {
+				case ev := <-d.pendingDriver:
+					ev()
+					done = false
+				default:
+				}
+
+				if done {
+					break
+				}
+			}
+
+			// All done.
+			atomic.StoreInt32(&d.terminated, 1)
+
+			close(d.pendingApp)
+			close(d.pendingDriver)
+
+			d.viewports = nil
+		})
+}
+
+func (d *DriverImpl) SetClipboard(str string) {
+	d.asyncDriver(
+		func() {
+			d.clipboard = str
+		},
+	)
+}
+
+func (d *DriverImpl) GetClipboard() (str string, err error) {
+	d.syncDriver(
+		func() {
+			str = d.clipboard
+		},
+	)
+	return
+}
+
+func (d *DriverImpl) CreateFont(data []byte, size int) (gxui.Font, error) {
+	return newFont(data, 0, size)
+}
+
+func (d *DriverImpl) CreateCollectionFont(data []byte, index, size int) (gxui.Font, error) {
+	return newFont(data, index, size)
+}
+
+func (d *DriverImpl) CreateFontFamily(fonts ...gxui.Font) (gxui.FontFamily, error) {
+	return newFontFamily(fonts)
+}
+
+func (d *DriverImpl) CreateResizedFont(font gxui.Font, size int) (gxui.Font, error) {
+	return newResizedFont(font, size)
+}
+
+// CreateDistanceFieldFont returns the font, whose glyphs are rasterized at the
+// size they are drawn at.
+func (d *DriverImpl) CreateDistanceFieldFont(f gxui.Font) (gxui.Font, error) {
+	switch f.(type) {
+	case *font, *fontFamily:
+		return f, nil
+	}
+	return nil, fmt.Errorf("the font was not created by this driver")
+}
+
+func (d *DriverImpl) CreateWindowedViewport(width, height int, name string) gxui.Viewport {
+	var v *ViewportImpl
+	d.syncDriver(
+		func() {
+			v = NewViewport(d, width, height, name, false)
+			e := d.viewports.PushBack(v)
+			v.onDestroy.Listen(func() {
+				d.viewports.Remove(e)
+			})
+		},
+	)
+	return v
+}
+
+func (d *DriverImpl) CreateFullscreenViewport(width, height int, name string) gxui.Viewport {
+	var v *ViewportImpl
+	d.syncDriver(
+		func() {
+			if width == 0 || height == 0 {
+				width, height = screenWidth, screenHeight
+			}
+			v = NewViewport(d, width, height, name, true)
+			e := d.viewports.PushBack(v)
+			v.onDestroy.Listen(func() {
+				d.viewports.Remove(e)
+			})
+		},
+	)
+	return v
+}
+
+func (d *DriverImpl) CreateCanvas(s math.Size) gxui.Canvas {
+	return NewCanvas(s)
+}
+
+func (d *DriverImpl) CreateTexture(img image.Image, pixelsPerDip float32) gxui.Texture {
+	return NewTexture(img, pixelsPerDip)
+}
+
+// Stats returns the number of viewports of the driver. The software driver has
+// no other resources.
+func (d *DriverImpl) Stats() gxui.DriverStats {
+	var result gxui.DriverStats
+	d.syncDriver(
+		func() {
+			result.Viewports = d.viewports.Len()
+		},
+	)
+	return result
+}
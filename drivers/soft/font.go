@@ -0,0 +1,189 @@
+package soft
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/pkg/shaping"
+	"github.com/badu/gxui/pkg/textlayout"
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Number of rasterized glyphs each face keeps cached.
+const glyphCacheEntries = 512
+
+// faceResolution is a font drawing glyphs, at a resolution.
+type faceResolution struct {
+	font       *textlayout.Font
+	resolution resolution
+}
+
+// font draws the glyphs laid out by its textlayout.Font.
+type font struct {
+	*textlayout.Font
+	// faces holds the faces rasterizing the glyphs of the font and of its
+	// fallbacks, by resolution.
+	faces map[faceResolution]*shaping.Face
+	// sizes holds the copies of the font resized to other sizes.
+	sizes map[int]*font
+}
+
+// newFont loads the font at index of the font data, which is only a font
+// collection's when index is not 0.
+func newFont(data []byte, index, size int) (*font, error) {
+	layout, err := textlayout.New(data, index, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLayoutFont(layout), nil
+}
+
+// newLayoutFont returns the font drawing the glyphs laid out by layout.
+func newLayoutFont(layout *textlayout.Font) *font {
+	return &font{Font: layout, faces: make(map[faceResolution]*shaping.Face)}
+}
+
+// resized returns the font at size, with its fallbacks at size too. The fonts
+// resized are kept by size.
+func (f *font) resized(size int) *font {
+	if size == f.Size() {
+		return f
+	}
+	if result, found := f.sizes[size]; found {
+		return result
+	}
+
+	result := newLayoutFont(f.Font.Resized(size))
+	if f.sizes == nil {
+		f.sizes = make(map[int]*font)
+	}
+	f.sizes[size] = result
+	return result
+}
+
+// face returns the face rasterizing the glyphs of layout, the font or one of
+// its fallbacks, at the resolution.
+func (f *font) face(layout *textlayout.Font, resolution resolution) *shaping.Face {
+	key := faceResolution{layout, resolution}
+	result, found := f.faces[key]
+	if !found {
+		opt := truetype.Options{
+			Size:              float64(layout.Size()),
+			DPI:               float64(resolution.intDipsToPixels(72)),
+			Hinting:           imageFont.HintingFull,
+			GlyphCacheEntries: glyphCacheEntries,
+			SubPixelsX:        1,
+			SubPixelsY:        1,
+		}
+		result = shaping.NewFace(layout.Shaper(), &opt)
+		f.faces[key] = result
+	}
+	return result
+}
+
+func (f *font) DrawRunes(ctx *context, runes []rune, offsets []math.Point, color gxui.Color, state *drawState) {
+	if len(runes) != len(offsets) {
+		panic(fmt.Errorf("there must be the same number of runes to offsets. Got %d runes and %d offsets", len(runes), len(offsets)))
+	}
+
+	for _, glyph := range f.Glyphs(runes) {
+		if unicode.IsSpace(runes[glyph.Cluster]) {
+			continue
+		}
+
+		dot := ctx.resolution.pointDipsToPixels(glyph.Origin(offsets))
+		dstRect, mask, maskPoint, _, ok := f.face(glyph.Font, ctx.resolution).Glyph(fixed.P(dot.X, dot.Y), glyph.Index)
+		if !ok || mask == nil {
+			continue
+		}
+		ctx.blitter.blitGlyph(ctx, mask, maskPoint, dstRect, color, state)
+	}
+}
+
+// fontFamily is a font drawing the runes missing from its first font with the
+// first of the others holding them. The family has the metrics of its first
+// font, so that lines keep their height and baseline whichever fonts draw
+// them.
+type fontFamily struct {
+	*font
+	fonts []gxui.Font
+	// sizes holds the copies of the family resized to other sizes.
+	sizes map[int]*fontFamily
+}
+
+// newFontFamily returns the family of the fonts, which must have been created
+// by the driver. The fonts following the first are used at its size.
+func newFontFamily(fonts []gxui.Font) (*fontFamily, error) {
+	if len(fonts) == 0 {
+		return nil, fmt.Errorf("a font family needs at least one font")
+	}
+	faces := make([]*font, len(fonts))
+	for i, f := range fonts {
+		face, ok := f.(*font)
+		if !ok {
+			return nil, fmt.Errorf("font %d of the family was not created by this driver", i)
+		}
+		faces[i] = face
+	}
+
+	fallbacks := make([]*textlayout.Font, 0, len(faces)-1)
+	for _, face := range faces[1:] {
+		fallbacks = append(fallbacks, face.Font)
+	}
+	primary := newLayoutFont(faces[0].Font.Family(fallbacks))
+	return &fontFamily{font: primary, fonts: append([]gxui.Font{}, fonts...)}, nil
+}
+
+func (f *fontFamily) Fonts() []gxui.Font {
+	return append([]gxui.Font{}, f.fonts...)
+}
+
+// resized returns the family at size, whose fonts are all at size. The
+// families resized are kept by size.
+func (f *fontFamily) resized(size int) *fontFamily {
+	if size == f.Size() {
+		return f
+	}
+	if result, found := f.sizes[size]; found {
+		return result
+	}
+
+	result := &fontFamily{font: f.font.resized(size), fonts: make([]gxui.Font, len(f.fonts))}
+	for i, face := range f.fonts {
+		result.fonts[i] = face.(*font).resized(size)
+	}
+	if f.sizes == nil {
+		f.sizes = make(map[int]*fontFamily)
+	}
+	f.sizes[size] = result
+	return result
+}
+
+// newResizedFont returns f, a font or font family created by the driver, at
+// size.
+func newResizedFont(f gxui.Font, size int) (gxui.Font, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid font size %d", size)
+	}
+	switch f := f.(type) {
+	case *font:
+		return f.resized(size), nil
+	case *fontFamily:
+		return f.resized(size), nil
+	}
+	return nil, fmt.Errorf("the font was not created by this driver")
+}
+
+// driverFont returns the font drawing the runes of f, a font or font family
+// created by the driver.
+func driverFont(f gxui.Font) *font {
+	if family, ok := f.(*fontFamily); ok {
+		return family.font
+	}
+	return f.(*font)
+}
@@ -0,0 +1,89 @@
+package soft
+
+import (
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/geometry"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// shape is a list of closed contours in DIPs. The contours are rasterized
+// together, so overlapping contours of the same winding are unioned.
+type shape [][]math.Vec2
+
+// rectShape returns the shape of the rectangle.
+func rectShape(r math.Rect) shape {
+	return shape{{r.TopLeft().Vec2(), r.TopRight().Vec2(), r.BottomRight().Vec2(), r.BottomLeft().Vec2()}}
+}
+
+// newTrianglesShape converts the triangle list (as built by the geometry
+// package) into a shape of individual triangles, all with the same winding.
+func newTrianglesShape(triangles []math.Vec2) shape {
+	var result shape
+	for i := 0; i+3 <= len(triangles); i += 3 {
+		a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+		if b.Sub(a).Cross(c.Sub(a)) < 0 {
+			b, c = c, b
+		}
+		result = append(result, []math.Vec2{a, b, c})
+	}
+	return result
+}
+
+func closedPolyToShape(p gxui.Polygon, pen gxui.Pen) (fillShape, edgeShape shape) {
+	fill, edge := geometry.ClosedPolygon(p, pen)
+	if fill != nil {
+		fillShape = shape{fill}
+	}
+	return fillShape, newTrianglesShape(edge)
+}
+
+func openPolyToShape(p gxui.Polygon, pen gxui.Pen) shape {
+	return newTrianglesShape(geometry.OpenPolygon(p, pen))
+}
+
+// fillPathShape returns the shape of the area of the path filled with the
+// rule. Open sub-paths are closed.
+func fillPathShape(path *gxui.Path, rule gxui.FillRule) shape {
+	return shape(geometry.FillPathPolygons(path, rule))
+}
+
+// Number of segments approximating each rounded corner of a shadow.
+const shadowCornerSegments = 8
+
+// shadowShape returns the rounded rectangle casting the shadow, moved by the
+// offset of the shadow and grown by its spread. The radii are those of the
+// top-left, top-right, bottom-left and bottom-right corners of rect.
+func shadowShape(rect math.Rect, radii [4]float32, shadow gxui.Shadow) shape {
+	minP, maxP, corner, ok := geometry.ShadowBox(rect, radii, shadow)
+	if !ok {
+		return nil
+	}
+
+	// The corners clockwise from the top-left, with the angle their arc starts
+	// at.
+	corners := []struct {
+		radius float32
+		center func(r float32) math.Vec2
+		angle  float32
+	}{
+		{corner[0], func(r float32) math.Vec2 { return math.Vec2{X: minP.X + r, Y: minP.Y + r} }, math32.Pi},
+		{corner[1], func(r float32) math.Vec2 { return math.Vec2{X: maxP.X - r, Y: minP.Y + r} }, math32.Pi * 1.5},
+		{corner[3], func(r float32) math.Vec2 { return math.Vec2{X: maxP.X - r, Y: maxP.Y - r} }, 0},
+		{corner[2], func(r float32) math.Vec2 { return math.Vec2{X: minP.X + r, Y: maxP.Y - r} }, math32.Pi * 0.5},
+	}
+
+	contour := []math.Vec2{}
+	for _, c := range corners {
+		center := c.center(c.radius)
+		if c.radius == 0 {
+			contour = append(contour, center)
+			continue
+		}
+		for i := 0; i <= shadowCornerSegments; i++ {
+			a := c.angle + math32.Pi/2*float32(i)/shadowCornerSegments
+			contour = append(contour, math.Vec2{X: center.X + c.radius*math32.Cos(a), Y: center.Y + c.radius*math32.Sin(a)})
+		}
+	}
+	return shape{contour}
+}
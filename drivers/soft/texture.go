@@ -0,0 +1,64 @@
+package soft
+
+import (
+	"image"
+
+	"github.com/badu/gxui/pkg/math"
+)
+
+type TextureImpl struct {
+	image        image.Image
+	flipped      image.Image // lazily built, vertically flipped copy of image.
+	pixelsPerDip float32
+	flipY        bool
+}
+
+func NewTexture(fromImage image.Image, pixelsPerDip float32) *TextureImpl {
+	result := &TextureImpl{
+		image:        fromImage,
+		pixelsPerDip: pixelsPerDip,
+	}
+	return result
+}
+
+// gxui.Texture compliance
+func (t *TextureImpl) Image() image.Image {
+	return t.image
+}
+
+func (t *TextureImpl) Size() math.Size {
+	return t.SizePixels().ScaleS(1.0 / t.pixelsPerDip)
+}
+
+func (t *TextureImpl) SizePixels() math.Size {
+	s := t.image.Bounds().Size()
+	return math.Size{Width: s.X, Height: s.Y}
+}
+
+func (t *TextureImpl) FlipY() bool {
+	return t.flipY
+}
+
+func (t *TextureImpl) SetFlipY(flipY bool) {
+	t.flipY = flipY
+}
+
+// source returns the image to sample from, taking FlipY into account.
+func (t *TextureImpl) source() image.Image {
+	if !t.flipY {
+		return t.image
+	}
+
+	if t.flipped == nil {
+		bounds := t.image.Bounds()
+		flipped := image.NewNRGBA(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			srcY := bounds.Max.Y - 1 - (y - bounds.Min.Y)
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				flipped.Set(x, y, t.image.At(x, srcY))
+			}
+		}
+		t.flipped = flipped
+	}
+	return t.flipped
+}
@@ -0,0 +1,398 @@
+package soft
+
+import (
+	"image"
+	"image/draw"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+)
+
+const clearColorR = 0.5
+const clearColorG = 0.5
+const clearColorB = 0.5
+
+// ViewportImpl is an offscreen viewport. Every canvas set on the viewport is
+// rasterized into an image, which can be retrieved with Image.
+type ViewportImpl struct {
+	sync.Mutex
+	// Broadcasts to application thread
+	onClose       gxui.Event // ()
+	onResize      gxui.Event // ()
+	onMouseMove   gxui.Event // (gxui.MouseEvent)
+	onMouseEnter  gxui.Event // (gxui.MouseEvent)
+	onMouseExit   gxui.Event // (gxui.MouseEvent)
+	onMouseDown   gxui.Event // (gxui.MouseEvent)
+	onMouseUp     gxui.Event // (gxui.MouseEvent)
+	onMouseScroll gxui.Event // (gxui.MouseEvent)
+	onKeyDown     gxui.Event // (gxui.KeyboardEvent)
+	onKeyUp       gxui.Event // (gxui.KeyboardEvent)
+	onKeyRepeat   gxui.Event // (gxui.KeyboardEvent)
+	onKeyStroke   gxui.Event // (gxui.KeyStrokeEvent)
+
+	// Broadcasts to driver thread
+	onDestroy        gxui.Event
+	driver           *DriverImpl
+	context          *context
+	canvas           *CanvasImpl
+	target           *image.RGBA
+	damage           []math.Rect // The rectangles to redraw, in DIPs
+	title            string
+	sizeDipsUnscaled math.Size
+	sizeDips         math.Size
+	sizePixels       math.Size
+	position         math.Point
+
+	scaling     float32
+	redrawCount uint32
+	frames      frameHistory
+	layoutTime  time.Duration // The time spent laying out the next canvas
+	paintTime   time.Duration // The time spent painting the next canvas
+
+	fullscreen bool
+	visible    bool
+	redrawAll  bool
+	destroyed  bool
+}
+
+func NewViewport(driver *DriverImpl, width, height int, title string, fullscreen bool) *ViewportImpl {
+	result := &ViewportImpl{
+		fullscreen: fullscreen,
+		scaling:    1,
+		title:      title,
+		visible:    true,
+		driver:     driver,
+		context:    newContext(),
+	}
+
+	result.onClose = driver.createAppEvent(func() {})
+	result.onResize = driver.createAppEvent(func() {})
+
+	result.onMouseMove = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseEnter = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseExit = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseDown = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseUp = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseScroll = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onKeyDown = driver.createAppEvent(func(gxui.KeyboardEvent) {})
+	result.onKeyUp = driver.createAppEvent(func(gxui.KeyboardEvent) {})
+	result.onKeyRepeat = driver.createAppEvent(func(gxui.KeyboardEvent) {})
+	result.onKeyStroke = driver.createAppEvent(func(gxui.KeyStrokeEvent) {})
+	result.onDestroy = driver.createDriverEvent(func() {})
+
+	result.sizeDipsUnscaled = math.Size{Width: width, Height: height}
+	result.sizeDips = result.sizeDipsUnscaled.ScaleS(1 / result.scaling)
+	result.sizePixels = result.sizeDipsUnscaled
+	result.target = image.NewRGBA(image.Rect(0, 0, width, height))
+	result.clearTarget(result.sizePixels.Rect())
+
+	return result
+}
+
+// Driver methods
+// These methods are all called on the driver routine
+func (v *ViewportImpl) clearTarget(rect math.Rect) {
+	clearColor := toColor(gxui.Color{R: clearColorR, G: clearColorG, B: clearColorB, A: 1.0})
+	draw.Draw(v.target, toImageRect(rect), image.NewUniform(clearColor), image.Point{}, draw.Src)
+}
+
+// render draws the canvas within the rectangles of damage, in DIPs, keeping
+// the rest of the last frame. The whole canvas is drawn if damage is empty.
+func (v *ViewportImpl) render(damage []math.Rect) {
+	if v.destroyed {
+		return
+	}
+
+	sizeDips, sizePixels := v.SizeDips(), v.SizePixels()
+	if sizeDips.Area() == 0 || sizePixels.Area() == 0 {
+		return
+	}
+
+	if bounds := v.target.Bounds(); bounds.Dx() != sizePixels.Width || bounds.Dy() != sizePixels.Height {
+		v.target = image.NewRGBA(image.Rect(0, 0, sizePixels.Width, sizePixels.Height))
+		damage = nil
+	}
+
+	start := time.Now()
+	ctx := v.context
+	ctx.beginDraw(v.target, sizeDips)
+
+	// The blurs of the backdrop sample around the damage, where the last
+	// frame may already be blurred.
+	window := sizePixels.Rect()
+	clips := []math.Rect{window}
+	if len(damage) > 0 && !v.canvas.readsBackdrop {
+		clips = clips[:0]
+		for _, rect := range damage {
+			// The pixels of the rectangle may round differently than the clips
+			// of the controls within it.
+			clips = append(clips, ctx.resolution.rectDipsToPixels(rect).ExpandI(1).Overlap(window))
+		}
+	}
+
+	for _, clip := range clips {
+		v.clearTarget(clip)
+		stack := drawStateStack{
+			drawState{
+				ClipPixels: clip,
+				Transform:  math.Mat3Ident,
+			},
+		}
+
+		v.canvas.draw(ctx, &stack)
+		if len(stack) != 1 {
+			panic("DrawStateStack count was not 1 after calling Canvas.Draw")
+		}
+	}
+
+	full := len(clips) == 1 && clips[0] == window
+	ctx.endDraw(v.canvas)
+	v.addFrame(gxui.FrameStats{Submit: time.Since(start), Partial: !full})
+}
+
+// Image returns a copy of the last frame rendered by the viewport.
+// Image waits for any canvas previously passed to SetCanvas to be rendered.
+func (v *ViewportImpl) Image() *image.RGBA {
+	var result *image.RGBA
+	v.driver.syncDriver(func() {
+		result = image.NewRGBA(v.target.Bounds())
+		copy(result.Pix, v.target.Pix)
+	})
+	return result
+}
+
+// IsVisible returns true if the viewport is shown.
+func (v *ViewportImpl) IsVisible() bool {
+	v.Lock()
+	defer v.Unlock()
+	return v.visible
+}
+
+// gxui.Viewport compliance
+// These methods are all called on the application routine
+func (v *ViewportImpl) SetCanvas(newCanvas gxui.Canvas) {
+	v.setCanvas(newCanvas, nil)
+}
+
+func (v *ViewportImpl) SetCanvasDamage(newCanvas gxui.Canvas, damage []math.Rect) {
+	v.setCanvas(newCanvas, damage)
+}
+
+// setCanvas renders the canvas within the rectangles of damage, or whole if
+// damage is empty. The damage of the canvases skipped for a more recent one is
+// rendered along with it.
+func (v *ViewportImpl) setCanvas(newCanvas gxui.Canvas, damage []math.Rect) {
+	cnt := atomic.AddUint32(&v.redrawCount, 1)
+	var childCanvas *CanvasImpl
+	if newCanvas != nil {
+		childCanvas = newCanvas.(*CanvasImpl)
+	}
+	v.addDamage(damage)
+	v.driver.asyncDriver(func() {
+		// Only use the canvas of the most recent SetCanvas call.
+		if atomic.LoadUint32(&v.redrawCount) == cnt {
+			v.canvas = childCanvas
+			if v.canvas != nil {
+				v.render(v.takeDamage())
+			} else {
+				v.addDamage(nil)
+			}
+		}
+	})
+}
+
+// addDamage adds the rectangles of damage to the ones to redraw, or redraws
+// everything if damage is empty.
+func (v *ViewportImpl) addDamage(damage []math.Rect) {
+	v.Lock()
+	defer v.Unlock()
+	if len(damage) == 0 {
+		v.redrawAll, v.damage = true, nil
+	} else if !v.redrawAll {
+		v.damage = append(v.damage, damage...)
+	}
+}
+
+// takeDamage returns the rectangles to redraw, or nil to redraw everything,
+// and forgets them.
+func (v *ViewportImpl) takeDamage() []math.Rect {
+	v.Lock()
+	defer v.Unlock()
+	damage := v.damage
+	v.redrawAll, v.damage = false, nil
+	return damage
+}
+
+func (v *ViewportImpl) RecordFrameTimes(layout, paint time.Duration) {
+	v.Lock()
+	defer v.Unlock()
+	v.layoutTime += layout
+	v.paintTime += paint
+}
+
+func (v *ViewportImpl) Stats() gxui.ViewportStats {
+	var result gxui.ViewportStats
+	v.driver.syncDriver(func() {
+		result = v.stats()
+	})
+	return result
+}
+
+// stats returns the statistics of the viewport. stats is called on the driver
+// routine.
+func (v *ViewportImpl) stats() gxui.ViewportStats {
+	return gxui.ViewportStats{Frames: v.frames.recent(), FrameCount: v.frames.count}
+}
+
+// addFrame adds the statistics of a frame drawn, along with the times taken to
+// lay out and paint its canvas.
+func (v *ViewportImpl) addFrame(frame gxui.FrameStats) {
+	v.Lock()
+	frame.Layout, frame.Paint = v.layoutTime, v.paintTime
+	v.layoutTime, v.paintTime = 0, 0
+	v.Unlock()
+	v.frames.add(frame)
+}
+
+func (v *ViewportImpl) Scale() float32 {
+	v.Lock()
+	defer v.Unlock()
+	return v.scaling
+}
+
+func (v *ViewportImpl) SetScale(newScale float32) {
+	v.Lock()
+	defer v.Unlock()
+	if newScale != v.scaling {
+		v.scaling = newScale
+		v.sizeDips = v.sizeDipsUnscaled.ScaleS(1 / newScale)
+		v.onResize.Emit()
+	}
+}
+
+func (v *ViewportImpl) SizeDips() math.Size {
+	v.Lock()
+	defer v.Unlock()
+	return v.sizeDips
+}
+
+func (v *ViewportImpl) SetSizeDips(size math.Size) {
+	v.Lock()
+	v.sizeDips = size
+	v.sizeDipsUnscaled = size.ScaleS(v.scaling)
+	v.sizePixels = v.sizeDipsUnscaled
+	v.Unlock()
+	v.onResize.Emit()
+}
+
+func (v *ViewportImpl) SizePixels() math.Size {
+	v.Lock()
+	defer v.Unlock()
+	return v.sizePixels
+}
+
+func (v *ViewportImpl) Title() string {
+	v.Lock()
+	defer v.Unlock()
+	return v.title
+}
+
+func (v *ViewportImpl) SetTitle(title string) {
+	v.Lock()
+	v.title = title
+	v.Unlock()
+}
+
+func (v *ViewportImpl) Position() math.Point {
+	v.Lock()
+	defer v.Unlock()
+	return v.position
+}
+
+func (v *ViewportImpl) SetPosition(newPosition math.Point) {
+	v.Lock()
+	v.position = newPosition
+	v.Unlock()
+}
+
+func (v *ViewportImpl) Fullscreen() bool {
+	return v.fullscreen
+}
+
+func (v *ViewportImpl) Show() {
+	v.Lock()
+	v.visible = true
+	v.Unlock()
+}
+
+func (v *ViewportImpl) Hide() {
+	v.Lock()
+	v.visible = false
+	v.Unlock()
+}
+
+func (v *ViewportImpl) Close() {
+	v.onClose.Emit()
+	v.Destroy()
+}
+
+func (v *ViewportImpl) OnResize(f func()) gxui.EventSubscription {
+	return v.onResize.Listen(f)
+}
+
+func (v *ViewportImpl) OnClose(f func()) gxui.EventSubscription {
+	return v.onClose.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseMove(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseMove.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseEnter(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseEnter.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseExit(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseExit.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseDown(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseDown.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseUp(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseUp.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseScroll(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseScroll.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyDown(f func(gxui.KeyboardEvent)) gxui.EventSubscription {
+	return v.onKeyDown.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyUp(f func(gxui.KeyboardEvent)) gxui.EventSubscription {
+	return v.onKeyUp.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyRepeat(f func(gxui.KeyboardEvent)) gxui.EventSubscription {
+	return v.onKeyRepeat.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyStroke(f func(gxui.KeyStrokeEvent)) gxui.EventSubscription {
+	return v.onKeyStroke.Listen(f)
+}
+
+func (v *ViewportImpl) Destroy() {
+	v.driver.asyncDriver(func() {
+		if !v.destroyed {
+			v.canvas = nil
+			v.onDestroy.Emit()
+			v.destroyed = true
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+// draw draws the canvas painted by paint on the terminal of the driver,
+// and returns the cells drawn.
+func draw(driver *DriverImpl, paint func(canvas gxui.Canvas)) *screen {
+	viewport := driver.CreateWindowedViewport(0, 0, "test").(*ViewportImpl)
+	driver.CallSync(func() {
+		canvas := driver.CreateCanvas(viewport.SizeDips())
+		paint(canvas)
+		canvas.Complete()
+		viewport.SetCanvas(canvas)
+	})
+	var result *screen
+	driver.syncDriver(func() {
+		result = driver.screen
+	})
+	return result
+}
+
+// texts returns the text of the cells of the row, with a dot for the cells
+// without text.
+func texts(s *screen, row int) string {
+	var b strings.Builder
+	for col := 0; col < s.cols; col++ {
+		switch c := s.cell(col, row); {
+		case c.Covered:
+		case c.Text == "":
+			b.WriteByte('.')
+		default:
+			b.WriteString(c.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestDrawRect(t *testing.T) {
+	driver := NewDriver(nil, io.Discard, 4, 2)
+	defer driver.Terminate()
+
+	s := draw(driver, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawRect(math.CreateRect(8, 8, 24, 32), gxui.CreateBrush(gxui.Red))
+	})
+	test_helper.AssertEquals(t, gxui.Black, s.cell(0, 0).Bottom)
+	test_helper.AssertEquals(t, gxui.Black, s.cell(1, 0).Top)
+	test_helper.AssertEquals(t, gxui.Red, s.cell(1, 0).Bottom)
+	test_helper.AssertEquals(t, gxui.Red, s.cell(2, 1).Top)
+	test_helper.AssertEquals(t, gxui.Red, s.cell(2, 1).Bottom)
+	test_helper.AssertEquals(t, gxui.Black, s.cell(3, 1).Bottom)
+}
+
+func TestDrawThinRect(t *testing.T) {
+	driver := NewDriver(nil, io.Discard, 4, 1)
+	defer driver.Terminate()
+
+	// A rectangle thinner than a pixel, like a caret, covers the pixel holding
+	// its middle, and keeps the text of the cell.
+	s := draw(driver, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		font, _ := driver.CreateFont(nil, 12)
+		canvas.DrawRunes(font, []rune("ab"), []math.Point{{X: 0, Y: 12}, {X: 8, Y: 12}}, gxui.White)
+		canvas.DrawRect(math.CreateRect(9, 0, 11, 16), gxui.WhiteBrush)
+	})
+	test_helper.AssertEquals(t, gxui.Black, s.cell(0, 0).Top)
+	test_helper.AssertEquals(t, gxui.White, s.cell(1, 0).Top)
+	test_helper.AssertEquals(t, gxui.White, s.cell(1, 0).Bottom)
+	test_helper.AssertEquals(t, "ab..", texts(s, 0))
+
+	// The text under an opaque rectangle is removed.
+	s = draw(driver, func(canvas gxui.Canvas) {
+		font, _ := driver.CreateFont(nil, 12)
+		canvas.DrawRunes(font, []rune("ab"), []math.Point{{X: 0, Y: 12}, {X: 8, Y: 12}}, gxui.White)
+		canvas.DrawRect(math.CreateRect(0, 0, 16, 16), gxui.BlackBrush)
+	})
+	test_helper.AssertEquals(t, "....", texts(s, 0))
+}
+
+func TestDrawRunes(t *testing.T) {
+	driver := NewDriver(nil, io.Discard, 12, 2)
+	defer driver.Terminate()
+
+	font, err := driver.CreateFont(nil, 12)
+	test_helper.AssertEquals(t, nil, err)
+
+	// The mark follows the rune it is drawn with.
+	runes := []rune("e\u0301 世界\nok")
+	offsets := font.Layout(&gxui.TextBlock{
+		Runes:     runes,
+		AlignRect: math.CreateRect(8, 0, 96, 32),
+		H:         gxui.AlignLeft,
+		V:         gxui.AlignTop,
+	})
+	test_helper.AssertEquals(t, math.Point{X: 8, Y: ascentDips}, offsets[0])
+	test_helper.AssertEquals(t, math.Point{X: 24, Y: ascentDips}, offsets[3])
+	test_helper.AssertEquals(t, math.Point{X: 8, Y: CellHeight + ascentDips}, offsets[6])
+	test_helper.AssertEquals(t, math.Size{Width: 48, Height: 2 * CellHeight}, font.Measure(&gxui.TextBlock{Runes: runes}))
+
+	s := draw(driver, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawRunes(font, runes, offsets, gxui.White)
+	})
+	test_helper.AssertEquals(t, ".e\u0301.世界.....", texts(s, 0))
+	test_helper.AssertEquals(t, true, s.cell(3, 0).Wide)
+	test_helper.AssertEquals(t, true, s.cell(4, 0).Covered)
+	test_helper.AssertEquals(t, ".ok.........", texts(s, 1))
+}
+
+func TestDrawBox(t *testing.T) {
+	driver := NewDriver(nil, io.Discard, 6, 4)
+	defer driver.Terminate()
+
+	pen := gxui.CreatePen(1, gxui.White)
+	s := draw(driver, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawRoundedRect(math.CreateRect(0, 0, 40, 48), 0, 0, 0, 0, pen, gxui.TransparentBrush)
+	})
+	test_helper.AssertEquals(t, "┌───┐.", texts(s, 0))
+	test_helper.AssertEquals(t, "│...│.", texts(s, 1))
+	test_helper.AssertEquals(t, "└───┘.", texts(s, 2))
+
+	// The text within the border is kept.
+	s = draw(driver, func(canvas gxui.Canvas) {
+		font, _ := driver.CreateFont(nil, 12)
+		canvas.Clear(gxui.Black)
+		canvas.DrawRunes(font, []rune("x"), []math.Point{{X: 0, Y: CellHeight + ascentDips}}, gxui.White)
+		canvas.DrawRoundedRect(math.CreateRect(0, 0, 40, 48), 4, 4, 4, 4, pen, gxui.TransparentBrush)
+	})
+	test_helper.AssertEquals(t, "╭───╮.", texts(s, 0))
+	test_helper.AssertEquals(t, "x...│.", texts(s, 1))
+	test_helper.AssertEquals(t, "╰───╯.", texts(s, 2))
+}
+
+func TestClipAndDrawCanvas(t *testing.T) {
+	driver := NewDriver(nil, io.Discard, 4, 1)
+	defer driver.Terminate()
+
+	s := draw(driver, func(canvas gxui.Canvas) {
+		child := driver.CreateCanvas(math.Size{Width: 32, Height: 16})
+		child.DrawRect(math.CreateRect(0, 0, 32, 16), gxui.CreateBrush(gxui.Green))
+		child.Complete()
+
+		canvas.Clear(gxui.Black)
+		canvas.Push()
+		canvas.AddClip(math.CreateRect(0, 0, 24, 16))
+		canvas.DrawCanvas(child, math.Point{X: 8})
+		canvas.Pop()
+	})
+	test_helper.AssertEquals(t, gxui.Black, s.cell(0, 0).Top)
+	test_helper.AssertEquals(t, gxui.Green, s.cell(1, 0).Top)
+	test_helper.AssertEquals(t, gxui.Green, s.cell(2, 0).Bottom)
+	test_helper.AssertEquals(t, gxui.Black, s.cell(3, 0).Top)
+}
+
+func TestDrawTexture(t *testing.T) {
+	driver := NewDriver(nil, io.Discard, 2, 1)
+	defer driver.Terminate()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if y < 2 {
+				img.Set(x, y, color.RGBA{R: 0xff, A: 0xff})
+			} else {
+				img.Set(x, y, color.RGBA{B: 0xff, A: 0xff})
+			}
+		}
+	}
+	texture := driver.CreateTexture(img, 1)
+	s := draw(driver, func(canvas gxui.Canvas) {
+		canvas.Clear(gxui.Black)
+		canvas.DrawTexture(texture, math.CreateRect(0, 0, 16, 16))
+	})
+	test_helper.AssertEquals(t, rgb{R: 0xff}, toRGB(s.cell(0, 0).Top))
+	test_helper.AssertEquals(t, rgb{B: 0xff}, toRGB(s.cell(1, 0).Bottom))
+
+	texture.SetFlipY(true)
+	s = draw(driver, func(canvas gxui.Canvas) {
+		canvas.DrawTexture(texture, math.CreateRect(0, 0, 16, 16))
+	})
+	test_helper.AssertEquals(t, rgb{B: 0xff}, toRGB(s.cell(0, 0).Top))
+	test_helper.AssertEquals(t, rgb{R: 0xff}, toRGB(s.cell(0, 0).Bottom))
+}
+
+func TestEncode(t *testing.T) {
+	s := newScreen(3, 1)
+	for i := range s.cells {
+		s.cells[i].Top, s.cells[i].Bottom = gxui.Black, gxui.Black
+	}
+	s.cell(1, 0).Bottom = gxui.Red
+	var buf bytes.Buffer
+	s.encode(&buf, nil)
+	test_helper.AssertEquals(t,
+		"\x1b[1;1H\x1b[48;2;0;0;0m \x1b[48;2;255;0;0m\x1b[38;2;0;0;0m▀\x1b[48;2;0;0;0m \x1b[0m",
+		buf.String())
+
+	// Only the cells which changed are written.
+	next := newScreen(3, 1)
+	copy(next.cells, s.cells)
+	next.setText(2, 0, "a", 1, gxui.White)
+	buf.Reset()
+	next.encode(&buf, s)
+	test_helper.AssertEquals(t, "\x1b[1;3H\x1b[48;2;0;0;0m\x1b[38;2;255;255;255ma\x1b[0m", buf.String())
+}
+
+func TestTitleSequence(t *testing.T) {
+	test_helper.AssertEquals(t, "\x1b]2;title\x07", titleSequence("title"))
+	test_helper.AssertEquals(t, "\x1b]2;a[2Jb]0;cd\x07", titleSequence("a\x07\x1b[2Jb\x1b]0;c\u009cd"))
+}
+
+func TestInputEvents(t *testing.T) {
+	in, input := io.Pipe()
+	driver := NewDriver(in, io.Discard, 10, 5)
+	defer driver.Terminate()
+
+	viewport := driver.CreateWindowedViewport(0, 0, "test")
+	events := make(chan string, 16)
+	driver.CallSync(func() {
+		viewport.OnMouseDown(func(ev gxui.MouseEvent) {
+			events <- fmt.Sprint("down ", ev.Point)
+		})
+		viewport.OnMouseScroll(func(ev gxui.MouseEvent) {
+			events <- fmt.Sprint("scroll ", ev.ScrollX, ev.ScrollY)
+		})
+		viewport.OnKeyDown(func(ev gxui.KeyboardEvent) {
+			events <- "key down"
+		})
+		viewport.OnKeyStroke(func(ev gxui.KeyStrokeEvent) {
+			events <- "stroke " + string(ev.Character)
+		})
+		viewport.OnResize(func() {
+			events <- fmt.Sprint("resize ", viewport.SizeDips())
+		})
+	})
+
+	next := func() string {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(time.Second):
+			return "timeout"
+		}
+	}
+
+	input.Write([]byte("\x1b[<0;3;2Mq"))
+	test_helper.AssertEquals(t, fmt.Sprint("down ", math.Point{X: 20, Y: 24}), next())
+	test_helper.AssertEquals(t, "key down", next())
+	test_helper.AssertEquals(t, "stroke q", next())
+
+	input.Write([]byte("\x1b[<65;1;1M"))
+	test_helper.AssertEquals(t, fmt.Sprint("scroll ", 0, -CellHeight), next())
+
+	driver.Resize(20, 6)
+	test_helper.AssertEquals(t, fmt.Sprint("resize ", math.Size{Width: 160, Height: 96}), next())
+}
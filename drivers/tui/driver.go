@@ -0,0 +1,568 @@
+// Package tui contains a terminal implementation of the gxui.Driver interface.
+//
+// The driver draws the canvases into the character cells of an ANSI terminal
+// with truecolor escape sequences: the runes in the cells, the borders of boxes
+// with box-drawing runes, and the shapes and images with two pixels per cell,
+// drawn with half blocks. The mouse and keyboard escape sequences read from the
+// terminal are translated into gxui events, so that the same gxui tools can run
+// in a terminal, for instance over SSH on a headless server.
+//
+// A cell is CellWidth by CellHeight DIPs. The terminal draws the runes with its
+// own font, whatever the fonts created, and a viewport always covers the whole
+// terminal, whatever the size it was created with. The last viewport shown is
+// the one drawn, and receives the events.
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/list"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// Maximum time allowed for application to process events on termination.
+const maxFlushTime = time.Second * 3
+
+// The time waited for the rest of an escape sequence, after which an escape
+// is the escape key.
+const escapeDelay = 25 * time.Millisecond
+
+// The escape sequences switching to the alternate screen, hiding the cursor,
+// and reporting the mouse in SGR mode, the focus and the pasted text, then
+// restoring the terminal.
+const (
+	setupSequence   = "\x1b[?1049h\x1b[?25l\x1b[?1003h\x1b[?1006h\x1b[?1004h\x1b[?2004h\x1b[2J"
+	restoreSequence = "\x1b[?2004l\x1b[?1004l\x1b[?1006l\x1b[?1003l\x1b[0m\x1b[?25h\x1b[?1049l"
+)
+
+// The color of the cells not drawn by the canvas of a viewport.
+var clearColor = gxui.Color{R: 0, G: 0, B: 0, A: 1}
+
+type DriverImpl struct {
+	pendingDriver chan func()
+	pendingApp    chan func()
+	pendingInput  chan input
+	viewports     *list.List[*ViewportImpl]
+	clipboard     string
+	done          chan struct{}
+
+	out        io.Writer
+	screen     *screen // The cells drawn on the terminal, or nil to draw them all.
+	cols, rows int
+	mouseState gxui.MouseState
+	mouseOver  bool
+	mouseCell  math.Point
+
+	pcs        []uintptr // reusable scratch-buffer for use by runtime.Callers.
+	uiPC       uintptr   // the program-counter of the applicationLoop function.
+	terminated int32     // non-zero represents driver terminations
+}
+
+// StartDriver starts the terminal driver on the standard input and output with
+// the given appRoutine, and blocks until the driver is terminated. The terminal
+// is put in raw mode until then. An interrupt, such as Ctrl+C, or the terminal
+// hanging up terminates the driver.
+func StartDriver(appRoutine func(driver gxui.Driver)) {
+	term, err := openTerminal(os.Stdin)
+	if err != nil {
+		panic(fmt.Errorf("failed to open the terminal: %w", err))
+	}
+	defer term.restore()
+
+	cols, rows := term.size()
+	result := NewDriver(os.Stdin, os.Stdout, cols, rows)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer signal.Stop(signals)
+	defer signal.Stop(resized)
+	go func() {
+		for {
+			select {
+			case <-resized:
+				result.Resize(term.size())
+			case <-signals:
+				result.Terminate()
+				return
+			case <-result.done:
+				return
+			}
+		}
+	}()
+
+	result.Call(func() { appRoutine(result) })
+	<-result.done
+}
+
+// NewDriver creates a terminal driver drawing into out, a terminal of cols by
+// rows cells, and reading the input of the terminal from in, which may be nil.
+// It starts the application and driver go-routines and returns immediately, so
+// the driver can be used from tests.
+func NewDriver(in io.Reader, out io.Writer, cols, rows int) *DriverImpl {
+	result := &DriverImpl{
+		pendingDriver: make(chan func(), 256),
+		pendingApp:    make(chan func(), 256),
+		pendingInput:  make(chan input, 256),
+		viewports:     list.New[*ViewportImpl](),
+		done:          make(chan struct{}),
+		out:           out,
+		cols:          cols,
+		rows:          rows,
+		pcs:           make([]uintptr, 256),
+	}
+
+	result.pendingApp <- result.discoverUIGoRoutine
+	io.WriteString(out, setupSequence)
+
+	go result.applicationLoop()
+	go result.driverLoop()
+	if in != nil {
+		go result.inputLoop(in)
+	}
+
+	return result
+}
+
+func (d *DriverImpl) asyncDriver(callback func()) {
+	d.pendingDriver <- callback
+}
+
+func (d *DriverImpl) syncDriver(callback func()) {
+	done := make(chan bool, 1)
+	d.asyncDriver(
+		func() { callback(); done <- true },
+	)
+	<-done
+}
+
+func (d *DriverImpl) createDriverEvent(signature interface{}) gxui.Event {
+	return gxui.CreateChanneledEvent(signature, d.pendingDriver)
+}
+
+func (d *DriverImpl) createAppEvent(signature interface{}) gxui.Event {
+	return gxui.CreateChanneledEvent(signature, d.pendingApp)
+}
+
+// driverLoop pulls and executes funcs from the pendingDriver chan until the
+// chan is closed, and handles the input of the terminal. Drawing happens on
+// this routine.
+func (d *DriverImpl) driverLoop() {
+	for {
+		select {
+		case ev, ok := <-d.pendingDriver:
+			if !ok {
+				io.WriteString(d.out, restoreSequence)
+				close(d.done)
+				return
+			}
+			ev()
+		case in := <-d.pendingInput:
+			d.handleInput(in)
+		}
+	}
+}
+
+// applicationLoop pulls and executes funcs from the pendingApp chan until
+// the chan is closed.
+func (d *DriverImpl) applicationLoop() {
+	for ev := range d.pendingApp {
+		ev()
+	}
+}
+
+// inputLoop reads the input of the terminal until the driver is terminated,
+// and passes its events to the driver routine.
+func (d *DriverImpl) inputLoop(in io.Reader) {
+	reads := make(chan []byte)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				data := append([]byte{}, buf[:n]...)
+				select {
+				case reads <- data:
+				case <-d.done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var pending []byte
+	for {
+		var timeout <-chan time.Time
+		if len(pending) > 0 {
+			timeout = time.After(escapeDelay)
+		}
+		final := false
+		select {
+		case data := <-reads:
+			pending = append(pending, data...)
+		case <-timeout:
+			final = true
+		case <-d.done:
+			return
+		}
+
+		var events []input
+		events, pending = parseInput(pending, final)
+		for _, ev := range events {
+			select {
+			case d.pendingInput <- ev:
+			case <-d.done:
+				return
+			}
+		}
+	}
+}
+
+// Resize sets the size of the terminal, in cells. The viewports are resized to
+// cover it.
+func (d *DriverImpl) Resize(cols, rows int) {
+	select {
+	case d.pendingInput <- input{Kind: inputResize, Col: cols, Row: rows}:
+	case <-d.done:
+	}
+}
+
+// top returns the viewport drawn on the terminal, the last one shown, or nil
+// if there is none. top is called on the driver routine.
+func (d *DriverImpl) top() *ViewportImpl {
+	for e := d.viewports.Back(); e != nil; e = e.Prev() {
+		if e.Value.IsVisible() {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+// raise moves the viewport in front of the others, and draws it.
+func (d *DriverImpl) raise(v *ViewportImpl) {
+	for e := d.viewports.Front(); e != nil; e = e.Next() {
+		if e.Value == v {
+			d.viewports.MoveToBack(e)
+			break
+		}
+	}
+	d.redraw()
+}
+
+// redraw draws the viewport on top on the whole terminal, or clears the
+// terminal if there is none.
+func (d *DriverImpl) redraw() {
+	d.screen = nil
+	if v := d.top(); v != nil {
+		d.display(v)
+		return
+	}
+	d.write("\x1b[0m\x1b[2J")
+}
+
+// display draws the canvas of the viewport on the terminal, if the viewport is
+// on top. Only the cells which changed since the last frame are written.
+func (d *DriverImpl) display(v *ViewportImpl) {
+	if v != d.top() || v.canvas == nil {
+		return
+	}
+
+	start := time.Now()
+	s := newScreen(d.cols, d.rows)
+	for i := range s.cells {
+		s.cells[i].Top, s.cells[i].Bottom = clearColor, clearColor
+	}
+	drawCalls := render(s, v.canvas, v.Scale())
+
+	var buf bytes.Buffer
+	if d.screen == nil {
+		buf.WriteString(titleSequence(v.Title()))
+	}
+	s.encode(&buf, d.screen)
+	d.screen = s
+	d.write(buf.String())
+	v.addFrame(gxui.FrameStats{Submit: time.Since(start), DrawCalls: drawCalls})
+}
+
+func (d *DriverImpl) write(str string) {
+	io.WriteString(d.out, str)
+}
+
+// titleSequence returns the escape sequence setting the title of the terminal.
+// The C0 and C1 control characters are removed from the title, as they could
+// end the sequence and have the rest of the title read as escape sequences.
+func titleSequence(title string) string {
+	title = strings.Map(func(r rune) rune {
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, title)
+	return "\x1b]2;" + title + "\x07"
+}
+
+// handleInput handles an event read from the terminal, on the driver routine.
+func (d *DriverImpl) handleInput(in input) {
+	if in.Kind == inputResize {
+		d.cols, d.rows = in.Col, in.Row
+		for e := d.viewports.Front(); e != nil; e = e.Next() {
+			e.Value.resize(d.cols, d.rows)
+		}
+		d.redraw()
+		return
+	}
+
+	v := d.top()
+	if v == nil {
+		return
+	}
+
+	switch in.Kind {
+	case inputKey:
+		ev := gxui.KeyboardEvent{Key: in.Key, Modifier: in.Modifier}
+		// The terminal only reports the keys typed.
+		v.onKeyDown.Emit(ev)
+		if in.isTyped() {
+			v.onKeyStroke.Emit(gxui.KeyStrokeEvent{Character: in.Char, Modifier: in.Modifier})
+		}
+		v.onKeyUp.Emit(ev)
+	case inputPaste:
+		for _, r := range in.Text {
+			switch r {
+			case '\r', '\n':
+				ev := gxui.KeyboardEvent{Key: gxui.KeyEnter}
+				v.onKeyDown.Emit(ev)
+				v.onKeyUp.Emit(ev)
+			default:
+				v.onKeyStroke.Emit(gxui.KeyStrokeEvent{Character: r})
+			}
+		}
+	case inputBlur:
+		if d.mouseOver {
+			d.mouseOver = false
+			v.onMouseExit.Emit(d.mouseEvent(v, in.Modifier))
+		}
+	case inputMouseDown, inputMouseUp, inputMouseMove, inputMouseScroll:
+		d.mouseCell = math.Point{X: in.Col, Y: in.Row}
+		if !d.mouseOver {
+			d.mouseOver = true
+			v.onMouseEnter.Emit(d.mouseEvent(v, in.Modifier))
+		}
+		switch in.Kind {
+		case inputMouseDown:
+			d.mouseState |= 1 << uint(in.Button)
+			ev := d.mouseEvent(v, in.Modifier)
+			ev.Button = in.Button
+			v.onMouseDown.Emit(ev)
+		case inputMouseUp:
+			d.mouseState &^= 1 << uint(in.Button)
+			ev := d.mouseEvent(v, in.Modifier)
+			ev.Button = in.Button
+			v.onMouseUp.Emit(ev)
+		case inputMouseMove:
+			v.onMouseMove.Emit(d.mouseEvent(v, in.Modifier))
+		case inputMouseScroll:
+			ev := d.mouseEvent(v, in.Modifier)
+			ev.ScrollX, ev.ScrollY = in.ScrollX*CellHeight, in.ScrollY*CellHeight
+			v.onMouseScroll.Emit(ev)
+		}
+	}
+}
+
+// mouseEvent returns the event of the mouse at the middle of its cell.
+func (d *DriverImpl) mouseEvent(v *ViewportImpl, modifier gxui.KeyboardModifier) gxui.MouseEvent {
+	middle := math.Point{
+		X: d.mouseCell.X*CellWidth + CellWidth/2,
+		Y: d.mouseCell.Y*CellHeight + CellHeight/2,
+	}
+	return gxui.MouseEvent{
+		Point:    middle.ScaleS(1 / v.Scale()),
+		State:    d.mouseState,
+		Modifier: modifier,
+	}
+}
+
+// gxui.Driver compliance
+func (d *DriverImpl) Call(callback func()) bool {
+	if callback == nil {
+		panic("Function must not be nil")
+	}
+
+	if atomic.LoadInt32(&d.terminated) != 0 {
+		return false // Driver.Terminate has been called
+	}
+	d.pendingApp <- callback
+	return true
+}
+
+func (d *DriverImpl) CallSync(callback func()) bool {
+	done := make(chan struct{})
+	if d.Call(
+		func() {
+			callback()
+			close(done)
+		},
+	) {
+		<-done
+		return true
+	}
+	return false
+}
+
+func (d *DriverImpl) Terminate() {
+	d.asyncDriver(
+		func() {
+			// Close all viewports. This will notify the application.
+			for frontViewport := d.viewports.Front(); frontViewport != nil; frontViewport = frontViewport.Next() {
+				frontViewport.Value.Destroy()
+			}
+
+			// Flush all remaining events from the application and driver.
+			// This gives the application an opportunity to handle shutdown.
+			flushStart := time.Now()
+			for time.Since(flushStart) < maxFlushTime {
+				done := true
+
+				// Process any application events
+				sync := make(chan struct{})
+				d.Call(func() {
+					select {
+					case ev := <-d.pendingApp:
+						ev()
+						done = false
+					default:
+					}
+					close(sync)
+				})
+
+				<-sync
+
+				// Process any driver events
+				select {
+				case ev := <-d.pendingDriver:
+					ev()
+					done = false
+				default:
+				}
+
+				if done {
+					break
+				}
+			}
+
+			// All done.
+			atomic.StoreInt32(&d.terminated, 1)
+
+			close(d.pendingApp)
+			close(d.pendingDriver)
+
+			d.viewports = nil
+		})
+}
+
+// SetClipboard sets the clipboard of the driver, and of the terminal with the
+// OSC 52 escape sequence, which reaches the clipboard of the computer running
+// the terminal over SSH. The clipboard of the terminal cannot be read.
+func (d *DriverImpl) SetClipboard(str string) {
+	d.asyncDriver(
+		func() {
+			d.clipboard = str
+			d.write("\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(str)) + "\x07")
+		},
+	)
+}
+
+func (d *DriverImpl) GetClipboard() (str string, err error) {
+	d.syncDriver(
+		func() {
+			str = d.clipboard
+		},
+	)
+	return
+}
+
+func (d *DriverImpl) CreateFont(data []byte, size int) (gxui.Font, error) {
+	return newFont(data, size), nil
+}
+
+func (d *DriverImpl) CreateCollectionFont(data []byte, index, size int) (gxui.Font, error) {
+	return newFont(data, size), nil
+}
+
+func (d *DriverImpl) CreateFontFamily(fonts ...gxui.Font) (gxui.FontFamily, error) {
+	return newFontFamily(fonts)
+}
+
+func (d *DriverImpl) CreateResizedFont(font gxui.Font, size int) (gxui.Font, error) {
+	return newResizedFont(font, size)
+}
+
+// CreateDistanceFieldFont returns the font, whose runes are drawn by the
+// terminal.
+func (d *DriverImpl) CreateDistanceFieldFont(f gxui.Font) (gxui.Font, error) {
+	switch f.(type) {
+	case *font, *fontFamily:
+		return f, nil
+	}
+	return nil, fmt.Errorf("the font was not created by this driver")
+}
+
+func (d *DriverImpl) CreateWindowedViewport(width, height int, name string) gxui.Viewport {
+	return d.createViewport(name, false)
+}
+
+func (d *DriverImpl) CreateFullscreenViewport(width, height int, name string) gxui.Viewport {
+	return d.createViewport(name, true)
+}
+
+// createViewport creates a viewport covering the terminal, on top of the
+// others.
+func (d *DriverImpl) createViewport(name string, fullscreen bool) *ViewportImpl {
+	var v *ViewportImpl
+	d.syncDriver(
+		func() {
+			v = NewViewport(d, d.cols, d.rows, name, fullscreen)
+			e := d.viewports.PushBack(v)
+			v.onDestroy.Listen(func() {
+				d.viewports.Remove(e)
+				d.redraw()
+			})
+		},
+	)
+	return v
+}
+
+func (d *DriverImpl) CreateCanvas(s math.Size) gxui.Canvas {
+	return gxui.CreateDisplayList(s)
+}
+
+func (d *DriverImpl) CreateTexture(img image.Image, pixelsPerDip float32) gxui.Texture {
+	return NewTexture(img, pixelsPerDip)
+}
+
+// Stats returns the number of viewports of the driver. The terminal driver has
+// no other resources.
+func (d *DriverImpl) Stats() gxui.DriverStats {
+	var result gxui.DriverStats
+	d.syncDriver(
+		func() {
+			result.Viewports = d.viewports.Len()
+		},
+	)
+	return result
+}
@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"golang.org/x/text/width"
+)
+
+// The baseline of the runes of a cell, from the top of the cell.
+const ascentDips = CellHeight * 3 / 4
+
+// font lays out runes in the cells of the terminal. Whatever font is loaded,
+// the terminal draws the runes with its own, one or two cells wide.
+type font struct {
+	data []byte
+	size int
+}
+
+func newFont(data []byte, size int) *font {
+	return &font{data: data, size: size}
+}
+
+// cells returns the number of cells r is drawn across: zero for marks and
+// control characters, drawn with the rune they follow, and two for wide runes.
+func cells(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.IsControl(r):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// layout returns the offsets of the runes from the origin of the first line,
+// and the size of the lines.
+func (f *font) layout(runes []rune) ([]math.Point, math.Size) {
+	offsets := make([]math.Point, len(runes))
+	size := math.Size{Height: CellHeight}
+	offset := math.Point{}
+	for i, r := range runes {
+		if r == '\n' {
+			offsets[i] = offset
+			offset = math.Point{Y: offset.Y + CellHeight}
+			size.Height = offset.Y + CellHeight
+			continue
+		}
+		offsets[i] = offset
+		offset.X += cells(r) * CellWidth
+		size.Width = max(size.Width, offset.X)
+	}
+	return offsets, size
+}
+
+func (f *font) align(rect math.Rect, size math.Size, horizontalAlignment gxui.HAlign, verticalAlignment gxui.VAlign) math.Point {
+	var origin math.Point
+
+	switch horizontalAlignment {
+	case gxui.AlignLeft:
+		origin.X = rect.Min.X
+	case gxui.AlignCenter:
+		origin.X = rect.Middle().X - (size.Width / 2)
+	case gxui.AlignRight:
+		origin.X = rect.Max.X - size.Width
+	}
+
+	switch verticalAlignment {
+	case gxui.AlignTop:
+		origin.Y = rect.Min.Y + ascentDips
+	case gxui.AlignMiddle:
+		origin.Y = rect.Middle().Y - (size.Height / 2) + ascentDips
+	case gxui.AlignBottom:
+		origin.Y = rect.Max.Y - size.Height + ascentDips
+	}
+
+	return origin
+}
+
+// gxui.Font compliance
+func (f *font) Name() string {
+	return "Terminal"
+}
+
+func (f *font) Data() []byte {
+	return f.data
+}
+
+func (f *font) Size() int {
+	return f.size
+}
+
+func (f *font) LoadGlyphs(first, last rune) {}
+
+func (f *font) GlyphMaxSize() math.Size {
+	return math.Size{Width: 2 * CellWidth, Height: CellHeight}
+}
+
+// Measure returns the size of the text. The runs are measured with the font,
+// as the terminal draws them.
+func (f *font) Measure(textBlock *gxui.TextBlock) math.Size {
+	_, size := f.layout(textBlock.Runes)
+	return size
+}
+
+// Layout returns the baseline origins of the runes, from left to right. The
+// terminal lays out right to left runes itself, and cannot raise the runs.
+func (f *font) Layout(textBlock *gxui.TextBlock) []math.Point {
+	offsets, size := f.layout(textBlock.Runes)
+	origin := f.align(textBlock.AlignRect, size, textBlock.H, textBlock.V)
+	for i, p := range offsets {
+		offsets[i] = p.Add(origin)
+	}
+	return offsets
+}
+
+// fontFamily is a font of the terminal, which draws every rune it can with
+// its own font.
+type fontFamily struct {
+	*font
+	fonts []gxui.Font
+}
+
+func newFontFamily(fonts []gxui.Font) (*fontFamily, error) {
+	if len(fonts) == 0 {
+		return nil, fmt.Errorf("a font family needs at least one font")
+	}
+	first, ok := fonts[0].(*font)
+	if !ok {
+		return nil, fmt.Errorf("the font was not created by this driver")
+	}
+	return &fontFamily{font: first, fonts: append([]gxui.Font{}, fonts...)}, nil
+}
+
+func (f *fontFamily) Fonts() []gxui.Font {
+	return f.fonts
+}
+
+func newResizedFont(f gxui.Font, size int) (gxui.Font, error) {
+	switch f := f.(type) {
+	case *font:
+		return newFont(f.data, size), nil
+	case *fontFamily:
+		return &fontFamily{font: newFont(f.data, size), fonts: f.fonts}, nil
+	}
+	return nil, fmt.Errorf("the font was not created by this driver")
+}
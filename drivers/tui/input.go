@@ -0,0 +1,354 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/badu/gxui"
+)
+
+// inputKind is the kind of an input event read from the terminal.
+type inputKind int
+
+const (
+	// inputKey is a key pressed, with the character it types if any.
+	inputKey inputKind = iota
+	inputMouseDown
+	inputMouseUp
+	inputMouseMove
+	inputMouseScroll
+	// inputFocus and inputBlur are the terminal gaining and losing the focus.
+	inputFocus
+	inputBlur
+	// inputPaste is text pasted in the terminal.
+	inputPaste
+	// inputResize is the terminal resized to Col by Row cells.
+	inputResize
+)
+
+// input is an event read from the terminal.
+type input struct {
+	Kind     inputKind
+	Key      gxui.KeyboardKey
+	Char     rune // The character typed by the key, or zero.
+	Modifier gxui.KeyboardModifier
+	Button   gxui.MouseButton
+	// Col and Row are the cell of the mouse events, from zero.
+	Col, Row int
+	// Scroll is the number of notches the wheel turned by, up or right being
+	// positive.
+	ScrollX, ScrollY int
+	Text             string // The text pasted.
+}
+
+// The sequences around pasted text, in bracketed paste mode.
+const (
+	pasteStart = "\x1b[200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+// The keys of the final bytes of the CSI and SS3 sequences.
+var finalKeys = map[byte]gxui.KeyboardKey{
+	'A': gxui.KeyUp,
+	'B': gxui.KeyDown,
+	'C': gxui.KeyRight,
+	'D': gxui.KeyLeft,
+	'E': gxui.KeyKp5,
+	'F': gxui.KeyEnd,
+	'H': gxui.KeyHome,
+	'P': gxui.KeyF1,
+	'Q': gxui.KeyF2,
+	'R': gxui.KeyF3,
+	'S': gxui.KeyF4,
+	'M': gxui.KeyKpEnter,
+}
+
+// The keys of the numbers of the CSI sequences ending with a tilde.
+var tildeKeys = map[int]gxui.KeyboardKey{
+	1:  gxui.KeyHome,
+	2:  gxui.KeyInsert,
+	3:  gxui.KeyDelete,
+	4:  gxui.KeyEnd,
+	5:  gxui.KeyPageUp,
+	6:  gxui.KeyPageDown,
+	7:  gxui.KeyHome,
+	8:  gxui.KeyEnd,
+	11: gxui.KeyF1,
+	12: gxui.KeyF2,
+	13: gxui.KeyF3,
+	14: gxui.KeyF4,
+	15: gxui.KeyF5,
+	17: gxui.KeyF6,
+	18: gxui.KeyF7,
+	19: gxui.KeyF8,
+	20: gxui.KeyF9,
+	21: gxui.KeyF10,
+	23: gxui.KeyF11,
+	24: gxui.KeyF12,
+}
+
+// The keys typed with punctuation characters, which are typed with the shift
+// key for some of them.
+var punctuationKeys = map[rune]gxui.KeyboardKey{
+	' ':  gxui.KeySpace,
+	'\'': gxui.KeyApostrophe,
+	',':  gxui.KeyComma,
+	'-':  gxui.KeyMinus,
+	'.':  gxui.KeyPeriod,
+	'/':  gxui.KeySlash,
+	';':  gxui.KeySemicolon,
+	'=':  gxui.KeyEqual,
+	'[':  gxui.KeyLeftBracket,
+	'\\': gxui.KeyBackslash,
+	']':  gxui.KeyRightBracket,
+	'`':  gxui.KeyGraveAccent,
+}
+
+// charKey returns the key typing the character, and whether it is typed with
+// the shift key. Characters of other keyboard layouts have no key.
+func charKey(r rune) (gxui.KeyboardKey, bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return gxui.KeyA + gxui.KeyboardKey(r-'a'), false
+	case r >= 'A' && r <= 'Z':
+		return gxui.KeyA + gxui.KeyboardKey(r-'A'), true
+	case r >= '0' && r <= '9':
+		return gxui.Key0 + gxui.KeyboardKey(r-'0'), false
+	}
+	if key, found := punctuationKeys[r]; found {
+		return key, false
+	}
+	return gxui.KeyUnknown, false
+}
+
+// xtermModifier returns the modifiers of the parameter of the xterm key
+// sequences, one plus the bits of shift, alt, control and meta.
+func xtermModifier(param int) gxui.KeyboardModifier {
+	bits := param - 1
+	var result gxui.KeyboardModifier
+	if bits&1 != 0 {
+		result |= gxui.ModShift
+	}
+	if bits&2 != 0 {
+		result |= gxui.ModAlt
+	}
+	if bits&4 != 0 {
+		result |= gxui.ModControl
+	}
+	if bits&8 != 0 {
+		result |= gxui.ModSuper
+	}
+	return result
+}
+
+// parseInput returns the events of the bytes read from the terminal, and the
+// bytes of an incomplete sequence ending them, to be parsed again with the
+// bytes read next. When final is true, no bytes follow soon, so an escape
+// ending the bytes is the escape key.
+func parseInput(data []byte, final bool) ([]input, []byte) {
+	var events []input
+	for len(data) > 0 {
+		event, n, ok := parseEvent(data, final)
+		if n == 0 {
+			return events, data
+		}
+		if ok {
+			events = append(events, event)
+		}
+		data = data[n:]
+	}
+	return events, nil
+}
+
+// parseEvent parses the event at the start of data, returning it with the
+// number of bytes it takes. ok is false for sequences which are not events,
+// and n is zero if data ends before the sequence does.
+func parseEvent(data []byte, final bool) (event input, n int, ok bool) {
+	b := data[0]
+	switch {
+	case b == 0x1b:
+		if len(data) == 1 {
+			if final {
+				return input{Key: gxui.KeyEscape}, 1, true
+			}
+			return input{}, 0, false
+		}
+		switch data[1] {
+		case '[', 'O':
+			parse := parseCSI
+			if data[1] == 'O' {
+				parse = parseSS3
+			}
+			// An incomplete sequence ending the bytes is alt with a key,
+			// unless it is pasted text, which ends with its own sequence.
+			if event, n, ok := parse(data); n > 0 || !final || strings.HasPrefix(string(data), pasteStart) {
+				return event, n, ok
+			}
+		case 0x1b:
+			return input{Key: gxui.KeyEscape, Modifier: gxui.ModAlt}, 2, true
+		}
+		// Alt with a key is sent as an escape followed by the key.
+		event, n, ok := parseEvent(data[1:], final)
+		if n == 0 {
+			return input{}, 0, false
+		}
+		event.Modifier |= gxui.ModAlt
+		return event, n + 1, ok
+	case b == '\r', b == '\n':
+		return input{Key: gxui.KeyEnter}, 1, true
+	case b == '\t':
+		return input{Key: gxui.KeyTab}, 1, true
+	case b == 0x7f, b == 0x08:
+		return input{Key: gxui.KeyBackspace}, 1, true
+	case b == 0:
+		return input{Key: gxui.KeySpace, Modifier: gxui.ModControl}, 1, true
+	case b >= 0x01 && b <= 0x1a:
+		return input{Key: gxui.KeyA + gxui.KeyboardKey(b-0x01), Modifier: gxui.ModControl}, 1, true
+	case b < 0x20:
+		return input{}, 1, false
+	}
+
+	if !utf8.FullRune(data) {
+		if final {
+			return input{}, len(data), false
+		}
+		return input{}, 0, false
+	}
+	r, size := utf8.DecodeRune(data)
+	if r == utf8.RuneError {
+		return input{}, size, false
+	}
+	key, shift := charKey(r)
+	event = input{Key: key, Char: r}
+	if shift {
+		event.Modifier = gxui.ModShift
+	}
+	return event, size, true
+}
+
+// parseSS3 parses the single shift sequence at the start of data, sent by the
+// keypad and the first function keys.
+func parseSS3(data []byte) (input, int, bool) {
+	if len(data) < 3 {
+		return input{}, 0, false
+	}
+	key, found := finalKeys[data[2]]
+	return input{Key: key}, 3, found
+}
+
+// parseCSI parses the control sequence at the start of data, made of
+// parameter bytes and a final byte following the escape and bracket.
+func parseCSI(data []byte) (input, int, bool) {
+	if len(data) >= len(pasteStart) && string(data[:len(pasteStart)]) == pasteStart {
+		end := strings.Index(string(data), pasteEnd)
+		if end < 0 {
+			return input{}, 0, false
+		}
+		return input{Kind: inputPaste, Text: string(data[len(pasteStart):end])}, end + len(pasteEnd), true
+	}
+
+	end := 2
+	for end < len(data) && data[end] >= 0x20 && data[end] < 0x40 {
+		end++
+	}
+	if end == len(data) {
+		return input{}, 0, false
+	}
+	params, final, n := string(data[2:end]), data[end], end+1
+
+	if strings.HasPrefix(params, "<") && (final == 'M' || final == 'm') {
+		event, ok := parseSGRMouse(params[1:], final == 'M')
+		return event, n, ok
+	}
+
+	fields := strings.Split(params, ";")
+	number := func(i, fallback int) int {
+		if i >= len(fields) {
+			return fallback
+		}
+		v, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return fallback
+		}
+		return v
+	}
+
+	switch final {
+	case '~':
+		key, found := tildeKeys[number(0, 0)]
+		return input{Key: key, Modifier: xtermModifier(number(1, 1))}, n, found
+	case 'Z':
+		return input{Key: gxui.KeyTab, Modifier: gxui.ModShift}, n, true
+	case 'I':
+		return input{Kind: inputFocus}, n, true
+	case 'O':
+		return input{Kind: inputBlur}, n, true
+	}
+	if key, found := finalKeys[final]; found {
+		return input{Key: key, Modifier: xtermModifier(number(1, 1))}, n, true
+	}
+	return input{}, n, false
+}
+
+// parseSGRMouse parses the parameters of a SGR mouse sequence: the button
+// with the modifiers, and the column and row from one.
+func parseSGRMouse(params string, press bool) (input, bool) {
+	fields := strings.Split(params, ";")
+	if len(fields) != 3 {
+		return input{}, false
+	}
+	var values [3]int
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return input{}, false
+		}
+		values[i] = v
+	}
+	code := values[0]
+	event := input{Col: values[1] - 1, Row: values[2] - 1}
+	if code&4 != 0 {
+		event.Modifier |= gxui.ModShift
+	}
+	if code&8 != 0 {
+		event.Modifier |= gxui.ModAlt
+	}
+	if code&16 != 0 {
+		event.Modifier |= gxui.ModControl
+	}
+
+	button := code & 3
+	switch {
+	case code&64 != 0:
+		event.Kind = inputMouseScroll
+		switch button {
+		case 0:
+			event.ScrollY = 1
+		case 1:
+			event.ScrollY = -1
+		case 2:
+			event.ScrollX = 1
+		case 3:
+			event.ScrollX = -1
+		}
+		return event, true
+	case code&32 != 0:
+		event.Kind = inputMouseMove
+		return event, true
+	case button == 3:
+		return input{}, false
+	case press:
+		event.Kind = inputMouseDown
+	default:
+		event.Kind = inputMouseUp
+	}
+	event.Button = []gxui.MouseButton{gxui.MouseButtonLeft, gxui.MouseButtonMiddle, gxui.MouseButtonRight}[button]
+	return event, true
+}
+
+// isTyped returns true if the key event types its character.
+func (e input) isTyped() bool {
+	return e.Char != 0 && e.Modifier&(gxui.ModControl|gxui.ModAlt|gxui.ModSuper) == 0 && unicode.IsPrint(e.Char)
+}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/test_helper"
+)
+
+func TestParseKeys(t *testing.T) {
+	for _, test := range []struct {
+		data     string
+		expected input
+	}{
+		{"a", input{Key: gxui.KeyA, Char: 'a'}},
+		{"A", input{Key: gxui.KeyA, Char: 'A', Modifier: gxui.ModShift}},
+		{"é", input{Key: gxui.KeyUnknown, Char: 'é'}},
+		{"\r", input{Key: gxui.KeyEnter}},
+		{"\x7f", input{Key: gxui.KeyBackspace}},
+		{"\x03", input{Key: gxui.KeyC, Modifier: gxui.ModControl}},
+		{"\x1bx", input{Key: gxui.KeyX, Char: 'x', Modifier: gxui.ModAlt}},
+		{"\x1b[A", input{Key: gxui.KeyUp}},
+		{"\x1bOD", input{Key: gxui.KeyLeft}},
+		{"\x1b[1;5C", input{Key: gxui.KeyRight, Modifier: gxui.ModControl}},
+		{"\x1b[1;2H", input{Key: gxui.KeyHome, Modifier: gxui.ModShift}},
+		{"\x1b[3~", input{Key: gxui.KeyDelete}},
+		{"\x1b[6;3~", input{Key: gxui.KeyPageDown, Modifier: gxui.ModAlt}},
+		{"\x1bOP", input{Key: gxui.KeyF1}},
+		{"\x1b[24~", input{Key: gxui.KeyF12}},
+		{"\x1b[Z", input{Key: gxui.KeyTab, Modifier: gxui.ModShift}},
+	} {
+		events, rest := parseInput([]byte(test.data), false)
+		test_helper.AssertEquals(t, []input{test.expected}, events)
+		test_helper.AssertEquals(t, 0, len(rest))
+	}
+}
+
+func TestParseMouse(t *testing.T) {
+	events, _ := parseInput([]byte("\x1b[<0;3;5M\x1b[<0;4;5m\x1b[<34;10;2M\x1b[<64;1;1M\x1b[<17;1;1M"), false)
+	test_helper.AssertEquals(t, []input{
+		{Kind: inputMouseDown, Button: gxui.MouseButtonLeft, Col: 2, Row: 4},
+		{Kind: inputMouseUp, Button: gxui.MouseButtonLeft, Col: 3, Row: 4},
+		{Kind: inputMouseMove, Col: 9, Row: 1},
+		{Kind: inputMouseScroll, ScrollY: 1},
+		{Kind: inputMouseDown, Button: gxui.MouseButtonMiddle, Modifier: gxui.ModControl},
+	}, events)
+}
+
+func TestParseIncomplete(t *testing.T) {
+	// The rest of a sequence is waited for, until no bytes follow.
+	events, rest := parseInput([]byte("a\x1b[1;5"), false)
+	test_helper.AssertEquals(t, []input{{Key: gxui.KeyA, Char: 'a'}}, events)
+	test_helper.AssertEquals(t, "\x1b[1;5", string(rest))
+
+	events, rest = parseInput(append(rest, 'A'), false)
+	test_helper.AssertEquals(t, []input{{Key: gxui.KeyUp, Modifier: gxui.ModControl}}, events)
+	test_helper.AssertEquals(t, 0, len(rest))
+
+	events, rest = parseInput([]byte("\x1b"), false)
+	test_helper.AssertEquals(t, 0, len(events))
+	test_helper.AssertEquals(t, "\x1b", string(rest))
+
+	events, rest = parseInput(rest, true)
+	test_helper.AssertEquals(t, []input{{Key: gxui.KeyEscape}}, events)
+	test_helper.AssertEquals(t, 0, len(rest))
+
+	events, _ = parseInput([]byte("\x1b["), true)
+	test_helper.AssertEquals(t, []input{{Key: gxui.KeyLeftBracket, Char: '[', Modifier: gxui.ModAlt}}, events)
+}
+
+func TestParsePaste(t *testing.T) {
+	events, rest := parseInput([]byte("\x1b[200~one\ntwo"), true)
+	test_helper.AssertEquals(t, 0, len(events))
+
+	events, rest = parseInput(append(rest, []byte("\x1b[201~b")...), false)
+	test_helper.AssertEquals(t, []input{
+		{Kind: inputPaste, Text: "one\ntwo"},
+		{Key: gxui.KeyB, Char: 'b'},
+	}, events)
+	test_helper.AssertEquals(t, 0, len(rest))
+}
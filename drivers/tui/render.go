@@ -0,0 +1,546 @@
+package tui
+
+import (
+	"fmt"
+	"image"
+	"unicode"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// The range of the box-drawing runes.
+const (
+	firstBoxRune = '─'
+	lastBoxRune  = '╿'
+)
+
+// The runes of the corners of boxes, square or rounded, and of their sides.
+var (
+	squareCorners  = [4]string{"┌", "┐", "└", "┘"}
+	roundedCorners = [4]string{"╭", "╮", "╰", "╯"}
+)
+
+const (
+	horizontalSide = "─"
+	verticalSide   = "│"
+)
+
+// drawState is the state of the renderer pushed by Push, PushLayer and
+// DrawCanvas.
+type drawState struct {
+	// Transform transforms the DIPs of the canvas into the DIPs of the screen.
+	Transform math.Mat3
+	// Clip is the rectangle drawn into, in the DIPs of the screen.
+	Clip math.Rect
+	// Opacity multiplies the alpha of everything drawn, for the layers.
+	Opacity float32
+}
+
+// renderer draws the commands of display lists into the cells of a screen.
+// The shapes are drawn with the pixels of the cells, the runes in the cells,
+// and the borders of the boxes with box-drawing runes. Shadows, blurs, blend
+// modes, gradients and patterns have no equivalent in a terminal: gradients
+// and patterns are drawn with the color of their brush, and the others are
+// not drawn.
+type renderer struct {
+	screen    *screen
+	stack     []drawState
+	drawCalls int
+}
+
+// render draws the display list into the screen, scaled by scale, and returns
+// the number of shapes drawn.
+func render(s *screen, list *gxui.DisplayList, scale float32) int {
+	r := &renderer{screen: s}
+	r.stack = []drawState{{
+		Transform: math.CreateMat3Scale(scale, scale),
+		Clip:      math.CreateRect(0, 0, s.cols*CellWidth, s.rows*CellHeight),
+		Opacity:   1,
+	}}
+	r.draw(list)
+	return r.drawCalls
+}
+
+func (r *renderer) head() *drawState {
+	return &r.stack[len(r.stack)-1]
+}
+
+func (r *renderer) push() {
+	r.stack = append(r.stack, *r.head())
+}
+
+func (r *renderer) pop() {
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+func (r *renderer) draw(list *gxui.DisplayList) {
+	for _, c := range list.Commands() {
+		switch c.Op {
+		case gxui.OpPush:
+			r.push()
+		case gxui.OpPop, gxui.OpPopLayer:
+			r.pop()
+		case gxui.OpPushLayer:
+			r.push()
+			r.head().Opacity *= c.Opacity
+		case gxui.OpAddClip:
+			head := r.head()
+			head.Clip = head.Clip.Overlap(head.Transform.TransformRect(c.Rect))
+		case gxui.OpTransform:
+			head := r.head()
+			head.Transform = head.Transform.Mul(c.Matrix)
+		case gxui.OpClear:
+			r.clear(c.Color)
+		case gxui.OpDrawCanvas:
+			r.push()
+			head := r.head()
+			head.Transform = head.Transform.Mul(math.CreateMat3Translate(float32(c.Point.X), float32(c.Point.Y)))
+			r.draw(c.Canvas)
+			r.pop()
+		case gxui.OpDrawTexture:
+			r.drawTexture(c.Texture, c.Rect)
+		case gxui.OpDrawRunes:
+			r.drawRunes(c.Runes, c.Points, c.Color)
+		case gxui.OpDrawLines:
+			r.strokePolygon(c.Polygon, false, c.Pen)
+		case gxui.OpDrawPolygon:
+			r.fillPolygon(c.Polygon, c.Brush)
+			r.strokePolygon(c.Polygon, true, c.Pen)
+		case gxui.OpDrawRect:
+			r.fillRect(c.Rect, c.Brush)
+		case gxui.OpDrawRoundedRect:
+			r.fillRect(c.Rect, c.Brush)
+			r.drawBox(c.Rect, c.Radii, c.Pen)
+		case gxui.OpFillPath:
+			r.fillContours(r.transformPath(c.Path), c.Rule, c.Brush)
+		case gxui.OpStrokePath:
+			for _, contour := range r.transformPath(c.Path) {
+				r.strokeLines(contour.Points, contour.Closed, c.Pen)
+			}
+		case gxui.OpDrawShadow, gxui.OpBlurBackdrop:
+		default:
+			panic(fmt.Errorf("unknown display list op %v", c.Op))
+		}
+	}
+}
+
+// color returns c with the opacity of the layers applied.
+func (r *renderer) color(c gxui.Color) gxui.Color {
+	c.A *= r.head().Opacity
+	return c
+}
+
+// blend draws the color over the pixel.
+func blend(dst *gxui.Color, src gxui.Color) {
+	a := math.Clampf(src.A, 0, 1)
+	dst.R = src.R*a + dst.R*(1-a)
+	dst.G = src.G*a + dst.G*(1-a)
+	dst.B = src.B*a + dst.B*(1-a)
+	dst.A = 1
+}
+
+// pixelRange returns the range of the pixels whose centers are within from and
+// to, in DIPs of the screen, along an axis of the pixels size DIPs long. A
+// range thinner than a pixel covers the pixel holding its middle, so that thin
+// shapes, like carets, are drawn.
+func pixelRange(from, to float32, size int) (int, int) {
+	first := int(math32.Round(from / float32(size)))
+	last := int(math32.Round(to / float32(size)))
+	if last <= first && to > from {
+		first = int(math32.Floor((from + to) / 2 / float32(size)))
+		last = first + 1
+	}
+	return first, last
+}
+
+// clipPixels returns the pixels within the clip, from included to to
+// excluded.
+func (r *renderer) clipPixels() (from, to image.Point) {
+	clip := r.head().Clip
+	from.X, to.X = pixelRange(float32(clip.Min.X), float32(clip.Max.X), pixelWidth)
+	from.Y, to.Y = pixelRange(float32(clip.Min.Y), float32(clip.Max.Y), pixelHeight)
+	from.X, from.Y = max(from.X, 0), max(from.Y, 0)
+	to.X, to.Y = min(to.X, r.screen.cols), min(to.Y, 2*r.screen.rows)
+	return from, to
+}
+
+// clear sets the pixels within the clip to the color, and removes their text.
+func (r *renderer) clear(color gxui.Color) {
+	color.A = 1
+	from, to := r.clipPixels()
+	for y := from.Y; y < to.Y; y++ {
+		for x := from.X; x < to.X; x++ {
+			*r.screen.pixel(x, y) = color
+			r.screen.clearText(x, y/2)
+		}
+	}
+}
+
+// fillRect fills the rectangle with the color of the brush. An opaque brush
+// removes the text of the cells it covers, unless the rectangle is a single
+// cell wide, like a caret drawn over the rune it is at.
+func (r *renderer) fillRect(rect math.Rect, brush gxui.Brush) {
+	head := r.head()
+	if brush.IsTransparent() {
+		return
+	}
+	if !head.Transform.IsAxisAligned() {
+		r.fillContours([]gxui.PathContour{r.transformRect(rect)}, gxui.NonZero, brush)
+		return
+	}
+	r.drawCalls++
+
+	bounds := head.Transform.TransformRect(rect)
+	x0, x1 := pixelRange(float32(bounds.Min.X), float32(bounds.Max.X), pixelWidth)
+	y0, y1 := pixelRange(float32(bounds.Min.Y), float32(bounds.Max.Y), pixelHeight)
+	from, to := r.clipPixels()
+	x0, y0 = max(x0, from.X), max(y0, from.Y)
+	x1, y1 = min(x1, to.X), min(y1, to.Y)
+
+	color := r.color(brush.Color)
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			blend(r.screen.pixel(x, y), color)
+		}
+	}
+	if color.A >= 1 && x1-x0 > 1 {
+		for row := (y0 + 1) / 2; row < y1/2; row++ {
+			for col := x0; col < x1; col++ {
+				r.screen.clearText(col, row)
+			}
+		}
+	}
+}
+
+// transformRect returns the corners of the rectangle in the DIPs of the
+// screen.
+func (r *renderer) transformRect(rect math.Rect) gxui.PathContour {
+	m := r.head().Transform
+	return gxui.PathContour{
+		Points: []math.Vec2{
+			m.TransformVec2(rect.TopLeft().Vec2()),
+			m.TransformVec2(rect.TopRight().Vec2()),
+			m.TransformVec2(rect.BottomRight().Vec2()),
+			m.TransformVec2(rect.BottomLeft().Vec2()),
+		},
+		Closed: true,
+	}
+}
+
+// transformPath returns the flattened contours of the path in the DIPs of the
+// screen.
+func (r *renderer) transformPath(path *gxui.Path) []gxui.PathContour {
+	m := r.head().Transform
+	contours := path.Flatten(pixelWidth / 4)
+	for i, contour := range contours {
+		points := make([]math.Vec2, len(contour.Points))
+		for j, p := range contour.Points {
+			points[j] = m.TransformVec2(p)
+		}
+		contours[i].Points = points
+	}
+	return contours
+}
+
+func (r *renderer) fillPolygon(polygon gxui.Polygon, brush gxui.Brush) {
+	if len(polygon) < 3 {
+		return
+	}
+	m := r.head().Transform
+	contour := gxui.PathContour{Points: make([]math.Vec2, len(polygon)), Closed: true}
+	for i, v := range polygon {
+		contour.Points[i] = m.TransformVec2(v.Position.Vec2())
+	}
+	r.fillContours([]gxui.PathContour{contour}, gxui.NonZero, brush)
+}
+
+// fillContours fills the pixels whose centers are inside the contours, in the
+// DIPs of the screen, with the color of the brush.
+func (r *renderer) fillContours(contours []gxui.PathContour, rule gxui.FillRule, brush gxui.Brush) {
+	if brush.IsTransparent() || len(contours) == 0 {
+		return
+	}
+	r.drawCalls++
+
+	bounds := math.Rect{Min: math.Point{X: 1 << 30, Y: 1 << 30}, Max: math.Point{X: -1 << 30, Y: -1 << 30}}
+	for _, contour := range contours {
+		for _, p := range contour.Points {
+			bounds.Min = bounds.Min.Min(math.Point{X: int(math32.Floor(p.X)), Y: int(math32.Floor(p.Y))})
+			bounds.Max = bounds.Max.Max(math.Point{X: int(math32.Ceil(p.X)), Y: int(math32.Ceil(p.Y))})
+		}
+	}
+	from, to := r.clipPixels()
+	x0, y0 := max(bounds.Min.X/pixelWidth, from.X), max(bounds.Min.Y/pixelHeight, from.Y)
+	x1, y1 := min(bounds.Max.X/pixelWidth+1, to.X), min(bounds.Max.Y/pixelHeight+1, to.Y)
+
+	color := r.color(brush.Color)
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			center := math.Vec2{X: (float32(x) + 0.5) * pixelWidth, Y: (float32(y) + 0.5) * pixelHeight}
+			winding := windingNumber(contours, center)
+			if (rule == gxui.EvenOdd && winding%2 != 0) || (rule != gxui.EvenOdd && winding != 0) {
+				blend(r.screen.pixel(x, y), color)
+			}
+		}
+	}
+}
+
+// windingNumber returns the number of times the contours wind around p. The
+// contours are all closed to fill them.
+func windingNumber(contours []gxui.PathContour, p math.Vec2) int {
+	winding := 0
+	for _, contour := range contours {
+		points := contour.Points
+		for i := range points {
+			a, b := points[i], points[(i+1)%len(points)]
+			cross := (b.X-a.X)*(p.Y-a.Y) - (p.X-a.X)*(b.Y-a.Y)
+			switch {
+			case a.Y <= p.Y && b.Y > p.Y && cross > 0:
+				winding++
+			case a.Y > p.Y && b.Y <= p.Y && cross < 0:
+				winding--
+			}
+		}
+	}
+	return winding
+}
+
+func (r *renderer) strokePolygon(polygon gxui.Polygon, closed bool, pen gxui.Pen) {
+	m := r.head().Transform
+	points := make([]math.Vec2, len(polygon))
+	for i, v := range polygon {
+		points[i] = m.TransformVec2(v.Position.Vec2())
+	}
+	r.strokeLines(points, closed, pen)
+}
+
+// strokeLines draws the lines joining the points, in the DIPs of the screen,
+// a pixel wide.
+func (r *renderer) strokeLines(points []math.Vec2, closed bool, pen gxui.Pen) {
+	if pen.Width <= 0 || pen.Color.A <= 0 || len(points) < 2 {
+		return
+	}
+	r.drawCalls++
+
+	color := r.color(pen.Color)
+	from, to := r.clipPixels()
+	plot := func(x, y int) {
+		if x >= from.X && x < to.X && y >= from.Y && y < to.Y {
+			blend(r.screen.pixel(x, y), color)
+		}
+	}
+	pixel := func(p math.Vec2) (int, int) {
+		return int(math32.Floor(p.X / pixelWidth)), int(math32.Floor(p.Y / pixelHeight))
+	}
+
+	count := len(points) - 1
+	if closed {
+		count++
+	}
+	// The pixels shared by the lines are drawn once.
+	drawn := map[image.Point]bool{}
+	for i := 0; i < count; i++ {
+		x0, y0 := pixel(points[i])
+		x1, y1 := pixel(points[(i+1)%len(points)])
+		drawLine(x0, y0, x1, y1, func(x, y int) {
+			if p := image.Pt(x, y); !drawn[p] {
+				drawn[p] = true
+				plot(x, y)
+			}
+		})
+	}
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
+// drawLine plots the pixels of the line from x0, y0 to x1, y1 with the
+// Bresenham algorithm.
+func drawLine(x0, y0, x1, y1 int, plot func(x, y int)) {
+	dx, sx := (x1-x0)*sign(x1-x0), sign(x1-x0)
+	dy, sy := -(y1-y0)*sign(y1-y0), sign(y1-y0)
+	err := dx + dy
+	for {
+		plot(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawBox draws the border of the rectangle with the pen. The border of a
+// rectangle at least three cells wide and tall, leaving cells within it, and
+// drawn without a transform other than a translation, is drawn with
+// box-drawing runes, in the cells along its edges which have no other text.
+// The other borders are drawn with pixels.
+func (r *renderer) drawBox(rect math.Rect, radii [4]float32, pen gxui.Pen) {
+	if pen.Width <= 0 || pen.Color.A <= 0 {
+		return
+	}
+	head := r.head()
+	m := head.Transform
+	bounds := m.TransformRect(rect)
+	col0, col1 := pixelRange(float32(bounds.Min.X), float32(bounds.Max.X), CellWidth)
+	row0, row1 := pixelRange(float32(bounds.Min.Y), float32(bounds.Max.Y), CellHeight)
+	if m[0] != 1 || m[4] != 1 || !m.IsAxisAligned() || col1-col0 < 3 || row1-row0 < 3 {
+		contour := r.transformRect(rect)
+		r.strokeLines(contour.Points, true, pen)
+		return
+	}
+	r.drawCalls++
+
+	corners := squareCorners
+	for _, radius := range radii {
+		if radius >= CellWidth/2 {
+			corners = roundedCorners
+		}
+	}
+	color := r.color(pen.Color)
+	clip := head.Clip
+	put := func(col, row int, text string) {
+		center := math.Point{X: col*CellWidth + CellWidth/2, Y: row*CellHeight + CellHeight/2}
+		if col < 0 || row < 0 || col >= r.screen.cols || row >= r.screen.rows || !clip.Contains(center) {
+			return
+		}
+		c := r.screen.cell(col, row)
+		if c.Covered {
+			return
+		}
+		if t := []rune(c.Text); len(t) == 0 || (t[0] >= firstBoxRune && t[0] <= lastBoxRune) {
+			r.putText(col, row, text, 1, color)
+		}
+	}
+
+	last, bottom := col1-1, row1-1
+	for col := col0 + 1; col < last; col++ {
+		put(col, row0, horizontalSide)
+		put(col, bottom, horizontalSide)
+	}
+	for row := row0 + 1; row < bottom; row++ {
+		put(col0, row, verticalSide)
+		put(last, row, verticalSide)
+	}
+	put(col0, row0, corners[0])
+	put(last, row0, corners[1])
+	put(col0, bottom, corners[2])
+	put(last, bottom, corners[3])
+}
+
+// putText draws the text in the cell at col, row, blending its color with the
+// background of the cell.
+func (r *renderer) putText(col, row int, text string, width int, color gxui.Color) {
+	c := r.screen.cell(col, row)
+	background := c.Top
+	blend(&background, gxui.Color{R: c.Bottom.R, G: c.Bottom.G, B: c.Bottom.B, A: 0.5})
+	blend(&background, color)
+	r.screen.setText(col, row, text, width, background)
+}
+
+// drawRunes draws the runes in the cells holding the middle of their glyphs,
+// at the baseline origins laid out by a font of the driver. The marks are
+// drawn in the cell of the rune they follow.
+func (r *renderer) drawRunes(runes []rune, points []math.Point, color gxui.Color) {
+	if len(runes) != len(points) {
+		panic(fmt.Errorf("there must be the same number of runes to offsets. Got %d runes and %d offsets", len(runes), len(points)))
+	}
+	r.drawCalls++
+
+	head := r.head()
+	color = r.color(color)
+	lastCol, lastRow := -1, -1
+	for i, rn := range runes {
+		width := cells(rn)
+		if width == 0 {
+			if unicode.IsMark(rn) && lastCol >= 0 {
+				c := r.screen.cell(lastCol, lastRow)
+				c.Text += string(rn)
+			}
+			continue
+		}
+		lastCol = -1
+		if unicode.IsSpace(rn) {
+			continue
+		}
+
+		middle := points[i].Vec2().Add(math.Vec2{X: float32(width*CellWidth) / 2, Y: CellHeight/2 - ascentDips})
+		middle = head.Transform.TransformVec2(middle)
+		if !head.Clip.Contains(math.Point{X: int(math32.Floor(middle.X)), Y: int(math32.Floor(middle.Y))}) {
+			continue
+		}
+		col := int(math32.Floor((middle.X - float32((width-1)*CellWidth)/2) / CellWidth))
+		row := int(math32.Floor(middle.Y / CellHeight))
+		if col < 0 || row < 0 || col+width > r.screen.cols || row >= r.screen.rows {
+			continue
+		}
+		r.putText(col, row, string(rn), width, color)
+		lastCol, lastRow = col, row
+	}
+}
+
+// drawTexture draws the image of the texture into the pixels covering the
+// rectangle, each one the average of the texels it covers.
+func (r *renderer) drawTexture(texture gxui.Texture, rect math.Rect) {
+	head := r.head()
+	r.drawCalls++
+
+	bounds := head.Transform.TransformRect(rect)
+	if bounds.Width() <= 0 || bounds.Height() <= 0 {
+		return
+	}
+	x0, x1 := pixelRange(float32(bounds.Min.X), float32(bounds.Max.X), pixelWidth)
+	y0, y1 := pixelRange(float32(bounds.Min.Y), float32(bounds.Max.Y), pixelHeight)
+	from, to := r.clipPixels()
+
+	img := texture.Image()
+	imgBounds := img.Bounds()
+	scaleX := float32(imgBounds.Dx()) / float32(bounds.Width())
+	scaleY := float32(imgBounds.Dy()) / float32(bounds.Height())
+	for y := max(y0, from.Y); y < min(y1, to.Y); y++ {
+		ty0 := int(float32(y*pixelHeight-bounds.Min.Y) * scaleY)
+		ty1 := max(int(float32((y+1)*pixelHeight-bounds.Min.Y)*scaleY), ty0+1)
+		if texture.FlipY() {
+			ty0, ty1 = imgBounds.Dy()-ty1, imgBounds.Dy()-ty0
+		}
+		for x := max(x0, from.X); x < min(x1, to.X); x++ {
+			tx0 := int(float32(x*pixelWidth-bounds.Min.X) * scaleX)
+			tx1 := max(int(float32((x+1)*pixelWidth-bounds.Min.X)*scaleX), tx0+1)
+			texel := average(img, image.Rect(tx0, ty0, tx1, ty1).Add(imgBounds.Min).Intersect(imgBounds))
+			blend(r.screen.pixel(x, y), r.color(texel))
+		}
+	}
+}
+
+// average returns the average color of the pixels of img within rect.
+func average(img image.Image, rect image.Rectangle) gxui.Color {
+	var red, green, blue, alpha float32
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			pr, pg, pb, pa := img.At(x, y).RGBA()
+			red, green, blue, alpha = red+float32(pr), green+float32(pg), blue+float32(pb), alpha+float32(pa)
+		}
+	}
+	if alpha == 0 {
+		return gxui.Transparent
+	}
+	// The colors are premultiplied by alpha.
+	count := float32(rect.Dx() * rect.Dy())
+	return gxui.Color{R: red / alpha, G: green / alpha, B: blue / alpha, A: alpha / count / 0xffff}
+}
@@ -0,0 +1,201 @@
+package tui
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/badu/gxui"
+)
+
+// The size of a cell of the terminal, in DIPs. Each cell holds a rune, or two
+// pixels drawn with the upper half block, so that a pixel is CellWidth DIPs
+// wide and CellHeight/2 DIPs tall.
+const (
+	CellWidth  = 8
+	CellHeight = 16
+)
+
+// The size of a pixel, in DIPs.
+const (
+	pixelWidth  = CellWidth
+	pixelHeight = CellHeight / 2
+)
+
+// The rune drawing the two pixels of a cell, the upper one with the foreground
+// color and the lower one with the background color.
+const upperHalfBlock = "▀"
+
+// rgb is a color of the terminal.
+type rgb struct {
+	R, G, B uint8
+}
+
+func toRGB(c gxui.Color) rgb {
+	c = c.Saturate()
+	return rgb{R: uint8(c.R*0xff + 0.5), G: uint8(c.G*0xff + 0.5), B: uint8(c.B*0xff + 0.5)}
+}
+
+// luminance returns the perceived brightness of c, from 0 to 255.
+func (c rgb) luminance() int {
+	return (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000
+}
+
+// cell is a character cell of the terminal.
+type cell struct {
+	// Top and Bottom are the colors of the pixels of the cell.
+	Top, Bottom gxui.Color
+	// Text is the rune drawn in the cell followed by its marks, or empty to
+	// draw the pixels.
+	Text string
+	// Foreground is the color of Text.
+	Foreground gxui.Color
+	// Wide is true if Text covers the next cell as well.
+	Wide bool
+	// Covered is true if the wide text of the previous cell covers the cell.
+	Covered bool
+}
+
+// appearance returns what the terminal draws in the cell: the text, and its
+// foreground and background colors. The background of text is the average of
+// the pixels, and text too close to it is drawn in black or white.
+func (c *cell) appearance() (text string, fg, bg rgb) {
+	top, bottom := toRGB(c.Top), toRGB(c.Bottom)
+	if c.Text == "" {
+		if top == bottom {
+			return " ", top, bottom
+		}
+		return upperHalfBlock, top, bottom
+	}
+
+	bg = rgb{
+		R: uint8((int(top.R) + int(bottom.R)) / 2),
+		G: uint8((int(top.G) + int(bottom.G)) / 2),
+		B: uint8((int(top.B) + int(bottom.B)) / 2),
+	}
+	fg = toRGB(c.Foreground)
+	if d := fg.luminance() - bg.luminance(); -48 < d && d < 48 {
+		if bg.luminance() < 128 {
+			fg = rgb{R: 0xff, G: 0xff, B: 0xff}
+		} else {
+			fg = rgb{}
+		}
+	}
+	return c.Text, fg, bg
+}
+
+// screen is a grid of cells.
+type screen struct {
+	cols, rows int
+	cells      []cell
+}
+
+func newScreen(cols, rows int) *screen {
+	return &screen{cols: cols, rows: rows, cells: make([]cell, cols*rows)}
+}
+
+func (s *screen) cell(col, row int) *cell {
+	return &s.cells[row*s.cols+col]
+}
+
+// pixel returns the color of the pixel at x, y. There are two rows of pixels
+// per row of cells.
+func (s *screen) pixel(x, y int) *gxui.Color {
+	c := s.cell(x, y/2)
+	if y%2 == 0 {
+		return &c.Top
+	}
+	return &c.Bottom
+}
+
+// setText draws text across the width cells at col, row, in color.
+func (s *screen) setText(col, row int, text string, width int, color gxui.Color) {
+	s.clearText(col, row)
+	c := s.cell(col, row)
+	c.Text, c.Foreground = text, color
+	if width == 2 && col+1 < s.cols {
+		s.clearText(col+1, row)
+		c.Wide = true
+		s.cell(col+1, row).Covered = true
+	}
+}
+
+// clearText removes the text drawn over the cell at col, row.
+func (s *screen) clearText(col, row int) {
+	c := s.cell(col, row)
+	if c.Covered {
+		c.Covered = false
+		if col > 0 {
+			left := s.cell(col-1, row)
+			left.Text, left.Wide = "", false
+		}
+	}
+	if c.Wide {
+		c.Wide = false
+		if col+1 < s.cols {
+			s.cell(col+1, row).Covered = false
+		}
+	}
+	c.Text = ""
+}
+
+// encode writes the escape sequences drawing the cells of the screen that
+// differ from prev, or all of them if prev is nil or of another size.
+func (s *screen) encode(buf *bytes.Buffer, prev *screen) {
+	if prev != nil && (prev.cols != s.cols || prev.rows != s.rows) {
+		prev = nil
+	}
+
+	var fg, bg *rgb
+	cursorCol, cursorRow := -1, -1
+	for row := 0; row < s.rows; row++ {
+		for col := 0; col < s.cols; col++ {
+			c := s.cell(col, row)
+			if c.Covered {
+				continue // Drawn with the wide text of the previous cell.
+			}
+			if prev != nil && *c == *prev.cell(col, row) &&
+				(!c.Wide || col+1 == s.cols || *s.cell(col+1, row) == *prev.cell(col+1, row)) {
+				continue
+			}
+
+			if col != cursorCol || row != cursorRow {
+				buf.WriteString("\x1b[")
+				buf.WriteString(strconv.Itoa(row + 1))
+				buf.WriteByte(';')
+				buf.WriteString(strconv.Itoa(col + 1))
+				buf.WriteByte('H')
+			}
+
+			text, cellFg, cellBg := c.appearance()
+			if bg == nil || *bg != cellBg {
+				writeColor(buf, 48, cellBg)
+				bg = &cellBg
+			}
+			if text != " " && (fg == nil || *fg != cellFg) {
+				writeColor(buf, 38, cellFg)
+				fg = &cellFg
+			}
+			buf.WriteString(text)
+
+			cursorCol, cursorRow = col+1, row
+			if c.Wide {
+				cursorCol++
+			}
+		}
+	}
+	buf.WriteString("\x1b[0m")
+}
+
+// writeColor writes the escape sequence setting the truecolor foreground (38)
+// or background (48) color.
+func writeColor(buf *bytes.Buffer, layer int, c rgb) {
+	buf.WriteString("\x1b[")
+	buf.WriteString(strconv.Itoa(layer))
+	buf.WriteString(";2;")
+	buf.WriteString(strconv.Itoa(int(c.R)))
+	buf.WriteByte(';')
+	buf.WriteString(strconv.Itoa(int(c.G)))
+	buf.WriteByte(';')
+	buf.WriteString(strconv.Itoa(int(c.B)))
+	buf.WriteByte('m')
+}
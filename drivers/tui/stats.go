@@ -0,0 +1,27 @@
+package tui
+
+import "github.com/badu/gxui"
+
+// The number of frames kept in the statistics of a viewport.
+const historySize = 100
+
+// frameHistory holds the statistics of the most recent frames of a viewport.
+type frameHistory struct {
+	frames [historySize]gxui.FrameStats
+	count  int // The number of frames added
+}
+
+func (h *frameHistory) add(frame gxui.FrameStats) {
+	h.frames[h.count%historySize] = frame
+	h.count++
+}
+
+// recent returns the statistics of the most recent frames, oldest first.
+func (h *frameHistory) recent() []gxui.FrameStats {
+	n := min(h.count, historySize)
+	result := make([]gxui.FrameStats, n)
+	for i := range result {
+		result[i] = h.frames[(h.count-n+i)%historySize]
+	}
+	return result
+}
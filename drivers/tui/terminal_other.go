@@ -0,0 +1,29 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package tui
+
+import "os"
+
+// The size of the terminal, which cannot be read.
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
+
+// terminal is the terminal of a file. Raw mode is only supported by the unix
+// terminals, the others being left in the mode they are in.
+type terminal struct{}
+
+func openTerminal(file *os.File) (*terminal, error) {
+	return &terminal{}, nil
+}
+
+// size returns the size of the terminal in cells.
+func (t *terminal) size() (cols, rows int) {
+	return defaultCols, defaultRows
+}
+
+func (t *terminal) restore() {}
+
+// notifyResize does nothing, as the size of the terminal cannot be read.
+func notifyResize(c chan<- os.Signal) {}
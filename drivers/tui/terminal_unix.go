@@ -0,0 +1,64 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/unix"
+)
+
+// The size of the terminal when it cannot be read.
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
+
+// terminal is the terminal of a file, in raw mode.
+type terminal struct {
+	fd    int
+	saved *unix.Termios
+}
+
+// openTerminal puts the terminal of the file in raw mode, keeping the signals
+// of the interrupt keys.
+func openTerminal(file *os.File) (*terminal, error) {
+	fd := int(file.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return &terminal{fd: fd, saved: saved}, nil
+}
+
+// size returns the size of the terminal in cells.
+func (t *terminal) size() (cols, rows int) {
+	ws, err := unix.IoctlGetWinsize(t.fd, unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return defaultCols, defaultRows
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+// restore takes the terminal out of raw mode.
+func (t *terminal) restore() {
+	unix.IoctlSetTermios(t.fd, ioctlSetTermios, t.saved)
+}
+
+// notifyResize relays the signals of the terminal being resized to c.
+func notifyResize(c chan<- os.Signal) {
+	signal.Notify(c, unix.SIGWINCH)
+}
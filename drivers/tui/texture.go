@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"image"
+
+	"github.com/badu/gxui/pkg/math"
+)
+
+// TextureImpl is an image drawn into the pixels of the cells.
+type TextureImpl struct {
+	image        image.Image
+	pixelsPerDip float32
+	flipY        bool
+}
+
+func NewTexture(fromImage image.Image, pixelsPerDip float32) *TextureImpl {
+	return &TextureImpl{image: fromImage, pixelsPerDip: pixelsPerDip}
+}
+
+// gxui.Texture compliance
+func (t *TextureImpl) Image() image.Image {
+	return t.image
+}
+
+func (t *TextureImpl) Size() math.Size {
+	return t.SizePixels().ScaleS(1.0 / t.pixelsPerDip)
+}
+
+func (t *TextureImpl) SizePixels() math.Size {
+	s := t.image.Bounds().Size()
+	return math.Size{Width: s.X, Height: s.Y}
+}
+
+func (t *TextureImpl) FlipY() bool {
+	return t.flipY
+}
+
+func (t *TextureImpl) SetFlipY(flipY bool) {
+	t.flipY = flipY
+}
@@ -0,0 +1,298 @@
+package tui
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// ViewportImpl is a viewport covering the terminal. The canvas of the viewport
+// on top of the others is drawn into the cells of the terminal.
+type ViewportImpl struct {
+	sync.Mutex
+	// Broadcasts to application thread
+	onClose       gxui.Event // ()
+	onResize      gxui.Event // ()
+	onMouseMove   gxui.Event // (gxui.MouseEvent)
+	onMouseEnter  gxui.Event // (gxui.MouseEvent)
+	onMouseExit   gxui.Event // (gxui.MouseEvent)
+	onMouseDown   gxui.Event // (gxui.MouseEvent)
+	onMouseUp     gxui.Event // (gxui.MouseEvent)
+	onMouseScroll gxui.Event // (gxui.MouseEvent)
+	onKeyDown     gxui.Event // (gxui.KeyboardEvent)
+	onKeyUp       gxui.Event // (gxui.KeyboardEvent)
+	onKeyRepeat   gxui.Event // (gxui.KeyboardEvent)
+	onKeyStroke   gxui.Event // (gxui.KeyStrokeEvent)
+
+	// Broadcasts to driver thread
+	onDestroy        gxui.Event
+	driver           *DriverImpl
+	canvas           *gxui.DisplayList
+	title            string
+	sizeDipsUnscaled math.Size
+	sizeDips         math.Size
+	position         math.Point
+
+	scaling     float32
+	redrawCount uint32
+	frames      frameHistory
+	layoutTime  time.Duration // The time spent laying out the next canvas
+	paintTime   time.Duration // The time spent painting the next canvas
+
+	fullscreen bool
+	visible    bool
+	destroyed  bool
+}
+
+// NewViewport creates a viewport covering a terminal of cols by rows cells.
+func NewViewport(driver *DriverImpl, cols, rows int, title string, fullscreen bool) *ViewportImpl {
+	result := &ViewportImpl{
+		fullscreen: fullscreen,
+		scaling:    1,
+		title:      title,
+		visible:    true,
+		driver:     driver,
+	}
+
+	result.onClose = driver.createAppEvent(func() {})
+	result.onResize = driver.createAppEvent(func() {})
+
+	result.onMouseMove = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseEnter = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseExit = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseDown = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseUp = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onMouseScroll = driver.createAppEvent(func(gxui.MouseEvent) {})
+	result.onKeyDown = driver.createAppEvent(func(gxui.KeyboardEvent) {})
+	result.onKeyUp = driver.createAppEvent(func(gxui.KeyboardEvent) {})
+	result.onKeyRepeat = driver.createAppEvent(func(gxui.KeyboardEvent) {})
+	result.onKeyStroke = driver.createAppEvent(func(gxui.KeyStrokeEvent) {})
+	result.onDestroy = driver.createDriverEvent(func() {})
+
+	result.sizeDipsUnscaled = math.Size{Width: cols * CellWidth, Height: rows * CellHeight}
+	result.sizeDips = result.sizeDipsUnscaled.ScaleS(1 / result.scaling)
+
+	return result
+}
+
+// Driver methods
+// These methods are all called on the driver routine
+func (v *ViewportImpl) resize(cols, rows int) {
+	v.Lock()
+	v.sizeDipsUnscaled = math.Size{Width: cols * CellWidth, Height: rows * CellHeight}
+	v.sizeDips = v.sizeDipsUnscaled.ScaleS(1 / v.scaling)
+	v.Unlock()
+	v.onResize.Emit()
+}
+
+// IsVisible returns true if the viewport is shown.
+func (v *ViewportImpl) IsVisible() bool {
+	v.Lock()
+	defer v.Unlock()
+	return v.visible
+}
+
+// gxui.Viewport compliance
+// These methods are all called on the application routine
+func (v *ViewportImpl) SetCanvas(newCanvas gxui.Canvas) {
+	cnt := atomic.AddUint32(&v.redrawCount, 1)
+	var childCanvas *gxui.DisplayList
+	if newCanvas != nil {
+		childCanvas = newCanvas.(*gxui.DisplayList)
+	}
+	v.driver.asyncDriver(func() {
+		// Only use the canvas of the most recent SetCanvas call.
+		if atomic.LoadUint32(&v.redrawCount) == cnt && !v.destroyed {
+			v.canvas = childCanvas
+			v.driver.display(v)
+		}
+	})
+}
+
+// SetCanvasDamage sets the canvas like SetCanvas. Only the cells which changed
+// are written to the terminal, whatever the damage.
+func (v *ViewportImpl) SetCanvasDamage(newCanvas gxui.Canvas, damage []math.Rect) {
+	v.SetCanvas(newCanvas)
+}
+
+func (v *ViewportImpl) RecordFrameTimes(layout, paint time.Duration) {
+	v.Lock()
+	defer v.Unlock()
+	v.layoutTime += layout
+	v.paintTime += paint
+}
+
+func (v *ViewportImpl) Stats() gxui.ViewportStats {
+	var result gxui.ViewportStats
+	v.driver.syncDriver(func() {
+		result = gxui.ViewportStats{Frames: v.frames.recent(), FrameCount: v.frames.count}
+	})
+	return result
+}
+
+// addFrame adds the statistics of a frame drawn, along with the times taken to
+// lay out and paint its canvas.
+func (v *ViewportImpl) addFrame(frame gxui.FrameStats) {
+	v.Lock()
+	frame.Layout, frame.Paint = v.layoutTime, v.paintTime
+	v.layoutTime, v.paintTime = 0, 0
+	v.Unlock()
+	v.frames.add(frame)
+}
+
+func (v *ViewportImpl) Scale() float32 {
+	v.Lock()
+	defer v.Unlock()
+	return v.scaling
+}
+
+func (v *ViewportImpl) SetScale(newScale float32) {
+	v.Lock()
+	defer v.Unlock()
+	if newScale != v.scaling {
+		v.scaling = newScale
+		v.sizeDips = v.sizeDipsUnscaled.ScaleS(1 / newScale)
+		v.onResize.Emit()
+	}
+}
+
+func (v *ViewportImpl) SizeDips() math.Size {
+	v.Lock()
+	defer v.Unlock()
+	return v.sizeDips
+}
+
+// SetSizeDips does nothing, as the viewport covers the terminal.
+func (v *ViewportImpl) SetSizeDips(size math.Size) {}
+
+// SizePixels returns the size of the terminal in DIPs, as the pixels drawn by
+// the terminal are not known.
+func (v *ViewportImpl) SizePixels() math.Size {
+	v.Lock()
+	defer v.Unlock()
+	return v.sizeDipsUnscaled
+}
+
+func (v *ViewportImpl) Title() string {
+	v.Lock()
+	defer v.Unlock()
+	return v.title
+}
+
+// SetTitle sets the title of the viewport, which is the title of the terminal
+// while the viewport is on top.
+func (v *ViewportImpl) SetTitle(title string) {
+	v.Lock()
+	v.title = title
+	v.Unlock()
+	v.driver.asyncDriver(func() {
+		if v.driver.top() == v {
+			v.driver.write(titleSequence(title))
+		}
+	})
+}
+
+func (v *ViewportImpl) Position() math.Point {
+	v.Lock()
+	defer v.Unlock()
+	return v.position
+}
+
+func (v *ViewportImpl) SetPosition(newPosition math.Point) {
+	v.Lock()
+	v.position = newPosition
+	v.Unlock()
+}
+
+func (v *ViewportImpl) Fullscreen() bool {
+	return v.fullscreen
+}
+
+// Show shows the viewport on top of the others.
+func (v *ViewportImpl) Show() {
+	v.Lock()
+	v.visible = true
+	v.Unlock()
+	v.driver.asyncDriver(func() {
+		if !v.destroyed {
+			v.driver.raise(v)
+		}
+	})
+}
+
+func (v *ViewportImpl) Hide() {
+	v.Lock()
+	v.visible = false
+	v.Unlock()
+	v.driver.asyncDriver(func() {
+		if !v.destroyed {
+			v.driver.redraw()
+		}
+	})
+}
+
+func (v *ViewportImpl) Close() {
+	v.onClose.Emit()
+	v.Destroy()
+}
+
+func (v *ViewportImpl) OnResize(f func()) gxui.EventSubscription {
+	return v.onResize.Listen(f)
+}
+
+func (v *ViewportImpl) OnClose(f func()) gxui.EventSubscription {
+	return v.onClose.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseMove(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseMove.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseEnter(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseEnter.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseExit(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseExit.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseDown(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseDown.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseUp(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseUp.Listen(f)
+}
+
+func (v *ViewportImpl) OnMouseScroll(f func(gxui.MouseEvent)) gxui.EventSubscription {
+	return v.onMouseScroll.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyDown(f func(gxui.KeyboardEvent)) gxui.EventSubscription {
+	return v.onKeyDown.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyUp(f func(gxui.KeyboardEvent)) gxui.EventSubscription {
+	return v.onKeyUp.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyRepeat(f func(gxui.KeyboardEvent)) gxui.EventSubscription {
+	return v.onKeyRepeat.Listen(f)
+}
+
+func (v *ViewportImpl) OnKeyStroke(f func(gxui.KeyStrokeEvent)) gxui.EventSubscription {
+	return v.onKeyStroke.Listen(f)
+}
+
+func (v *ViewportImpl) Destroy() {
+	v.driver.asyncDriver(func() {
+		if !v.destroyed {
+			v.canvas = nil
+			v.onDestroy.Emit()
+			v.destroyed = true
+		}
+	})
+}
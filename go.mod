@@ -3,6 +3,7 @@ module github.com/badu/gxui
 go 1.24.0
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/chewxy/math32 v1.11.1
 	github.com/ebitengine/purego v0.9.1
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20240506104042-037f3cc74f2a
@@ -11,6 +12,7 @@ require (
 	github.com/goxjs/glfw v0.0.0-20230704040236-622eb27e272a
 	golang.org/x/image v0.33.0
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c
+	golang.org/x/text v0.31.0
 )
 
 require (
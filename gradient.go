@@ -0,0 +1,172 @@
+package gxui
+
+import (
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+type GradientKind int
+
+const (
+	// LinearGradient varies the color along a direction.
+	LinearGradient GradientKind = iota
+	// RadialGradient varies the color with the distance to a center.
+	RadialGradient
+)
+
+// GradientStop is the color of a gradient at Offset, between 0 and 1.
+type GradientStop struct {
+	Offset float32
+	Color  Color
+}
+
+// Gradient describes how the color of a Brush varies across the bounding box
+// of the shape it fills. Brushes refer to gradients by pointer so that they
+// can still be compared, which means that a gradient must not be modified once
+// it is used by a Brush.
+type Gradient struct {
+	Kind GradientKind
+	// Angle is the direction of a linear gradient in radians, clockwise from
+	// the x axis. Offset 0 and 1 are at the opposite corners or sides of the
+	// bounding box along that direction.
+	Angle float32
+	// Center and Radius place a radial gradient, as fractions of the width and
+	// height of the bounding box. Offset 0 is at the center, and 1 is on the
+	// ellipse of the given radius.
+	Center math.Vec2
+	Radius float32
+	// Stops are the colors of the gradient, sorted by offset. The color
+	// before the first stop and after the last one is the color of that stop.
+	Stops []GradientStop
+}
+
+func sortedStops(stops []GradientStop) []GradientStop {
+	result := append([]GradientStop{}, stops...)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Offset < result[j].Offset })
+	return result
+}
+
+// CreateLinearGradientBrush returns a brush varying between the stops along
+// the direction angle, in radians clockwise from the x axis.
+func CreateLinearGradientBrush(angle float32, stops ...GradientStop) Brush {
+	return createGradientBrush(&Gradient{Kind: LinearGradient, Angle: angle, Stops: sortedStops(stops)})
+}
+
+// CreateRadialGradientBrush returns a brush varying between the stops from
+// center to radius, both fractions of the size of the filled shape.
+func CreateRadialGradientBrush(center math.Vec2, radius float32, stops ...GradientStop) Brush {
+	return createGradientBrush(&Gradient{Kind: RadialGradient, Center: center, Radius: radius, Stops: sortedStops(stops)})
+}
+
+func createGradientBrush(gradient *Gradient) Brush {
+	return Brush{Color: gradient.At(0.5), Gradient: gradient}
+}
+
+// At returns the color of the gradient at offset, interpolating linearly
+// between the surrounding stops.
+func (g *Gradient) At(offset float32) Color {
+	stops := g.Stops
+	if len(stops) == 0 {
+		return Transparent
+	}
+	i := sort.Search(len(stops), func(i int) bool { return stops[i].Offset > offset })
+	if i == 0 {
+		return stops[0].Color
+	}
+	if i == len(stops) {
+		return stops[i-1].Color
+	}
+	a, b := stops[i-1], stops[i]
+	s := (offset - a.Offset) / (b.Offset - a.Offset)
+	return Color{
+		R: math.Lerpf(a.Color.R, b.Color.R, s),
+		G: math.Lerpf(a.Color.G, b.Color.G, s),
+		B: math.Lerpf(a.Color.B, b.Color.B, s),
+		A: math.Lerpf(a.Color.A, b.Color.A, s),
+	}
+}
+
+// IsTransparent returns true if every stop of the gradient is transparent.
+func (g *Gradient) IsTransparent() bool {
+	for _, s := range g.Stops {
+		if s.Color.A > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Axes returns the gradient space of a shape with the given bounds. A point p
+// has the gradient coordinates ((p-origin)·u, (p-origin)·v). The offset of a
+// linear gradient is the first coordinate, and the offset of a radial gradient
+// is the distance between the gradient coordinates and zero.
+// As the space is affine, bounds may be in any unit as long as p is too.
+func (g *Gradient) Axes(bounds math.Rect) (origin, u, v math.Vec2) {
+	w, h := float32(bounds.Width()), float32(bounds.Height())
+	topLeft := bounds.Min.Vec2()
+	switch g.Kind {
+	case RadialGradient:
+		origin = topLeft.Add(math.Vec2{X: g.Center.X * w, Y: g.Center.Y * h})
+		if rw, rh := g.Radius*w, g.Radius*h; rw > 0 && rh > 0 {
+			u, v = math.Vec2{X: 1 / rw}, math.Vec2{Y: 1 / rh}
+		}
+	default:
+		direction := math.Vec2{X: math32.Cos(g.Angle), Y: math32.Sin(g.Angle)}
+		// The length of the bounding box projected onto the direction.
+		length := math32.Abs(w*direction.X) + math32.Abs(h*direction.Y)
+		origin = topLeft.Add(math.Vec2{X: w / 2, Y: h / 2}).Sub(direction.MulS(length / 2))
+		if length > 0 {
+			u = direction.MulS(1 / length)
+			v = u.Tangent()
+		}
+	}
+	return origin, u, v
+}
+
+// Transform returns the affine transform [a b c d e f] from the gradient space
+// to the space of bounds, mapping (x, y) to (a*x + c*y + e, b*x + d*y + f) as
+// SVG and PDF transforms do. It returns false if the space is degenerate.
+func (g *Gradient) Transform(bounds math.Rect) ([6]float32, bool) {
+	origin, u, v := g.Axes(bounds)
+	det := u.X*v.Y - u.Y*v.X
+	if det == 0 {
+		return [6]float32{}, false
+	}
+	return [6]float32{v.Y / det, -v.X / det, -u.Y / det, u.X / det, origin.X, origin.Y}, true
+}
+
+// OffsetAt returns the offset of the gradient at p, for a shape with the given
+// bounds.
+func (g *Gradient) OffsetAt(p math.Vec2, bounds math.Rect) float32 {
+	origin, u, v := g.Axes(bounds)
+	d := p.Sub(origin)
+	if g.Kind == RadialGradient {
+		return math.Vec2{X: d.Dot(u), Y: d.Dot(v)}.Len()
+	}
+	return d.Dot(u)
+}
+
+// Ramp returns an image, one pixel high, of the gradient sampled at size evenly
+// spaced offsets from 0 to 1. The colors are premultiplied by their alpha, so
+// that the image can be filtered as a lookup texture.
+func (g *Gradient) Ramp(size int) *image.RGBA {
+	result := image.NewRGBA(image.Rect(0, 0, size, 1))
+	for x := 0; x < size; x++ {
+		offset := float32(0)
+		if size > 1 {
+			offset = float32(x) / float32(size-1)
+		}
+		c := g.At(offset).Saturate()
+		result.SetRGBA(x, 0, color.RGBA{
+			R: uint8(c.R*c.A*0xff + 0.5),
+			G: uint8(c.G*c.A*0xff + 0.5),
+			B: uint8(c.B*c.A*0xff + 0.5),
+			A: uint8(c.A*0xff + 0.5),
+		})
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+	"github.com/chewxy/math32"
+)
+
+func TestGradientAt(t *testing.T) {
+	brush := CreateLinearGradientBrush(0,
+		GradientStop{Offset: 1, Color: White},
+		GradientStop{Offset: 0.5, Color: Black},
+	)
+	g := brush.Gradient
+	test_helper.AssertEquals(t, float32(0.5), g.Stops[0].Offset)
+	test_helper.AssertEquals(t, Black, g.At(0))
+	test_helper.AssertEquals(t, Color{R: 0.5, G: 0.5, B: 0.5, A: 1}, g.At(0.75))
+	test_helper.AssertEquals(t, White, g.At(2))
+	test_helper.AssertEquals(t, Black, brush.Color)
+	test_helper.AssertEquals(t, false, brush.IsTransparent())
+	test_helper.AssertEquals(t, true, CreateLinearGradientBrush(0, GradientStop{Color: Transparent}).IsTransparent())
+}
+
+func TestGradientOffsetAt(t *testing.T) {
+	bounds := math.CreateRect(10, 20, 110, 70)
+	for _, test := range []struct {
+		name     string
+		gradient *Gradient
+		point    math.Vec2
+		expected float32
+	}{
+		{"linear left", &Gradient{Kind: LinearGradient}, math.Vec2{X: 10, Y: 20}, 0},
+		{"linear right", &Gradient{Kind: LinearGradient}, math.Vec2{X: 110, Y: 70}, 1},
+		{"linear middle", &Gradient{Kind: LinearGradient}, math.Vec2{X: 60, Y: 0}, 0.5},
+		{"linear down top", &Gradient{Kind: LinearGradient, Angle: math.Pi / 2}, math.Vec2{X: 60, Y: 20}, 0},
+		{"linear down bottom", &Gradient{Kind: LinearGradient, Angle: math.Pi / 2}, math.Vec2{X: 60, Y: 70}, 1},
+		{"diagonal corner", &Gradient{Kind: LinearGradient, Angle: math.Pi / 4}, math.Vec2{X: 110, Y: 70}, 1},
+		{"radial center", &Gradient{Kind: RadialGradient, Center: math.Vec2{X: 0.5, Y: 0.5}, Radius: 0.5}, math.Vec2{X: 60, Y: 45}, 0},
+		{"radial side", &Gradient{Kind: RadialGradient, Center: math.Vec2{X: 0.5, Y: 0.5}, Radius: 0.5}, math.Vec2{X: 110, Y: 45}, 1},
+		{"radial top", &Gradient{Kind: RadialGradient, Center: math.Vec2{X: 0.5, Y: 0.5}, Radius: 0.5}, math.Vec2{X: 60, Y: 20}, 1},
+	} {
+		if got := test.gradient.OffsetAt(test.point, bounds); math32.Abs(got-test.expected) > 1e-5 {
+			t.Errorf("%s: expected offset %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestGradientTransform(t *testing.T) {
+	bounds := math.CreateRect(10, 20, 110, 70)
+	g := &Gradient{Kind: LinearGradient, Angle: 0.3}
+	matrix, ok := g.Transform(bounds)
+	test_helper.AssertEquals(t, true, ok)
+
+	// The gradient coordinates (1, 0) are at offset 1.
+	p := math.Vec2{X: matrix[0] + matrix[4], Y: matrix[1] + matrix[5]}
+	if got := g.OffsetAt(p, bounds); math32.Abs(got-1) > 1e-5 {
+		t.Errorf("Expected offset 1, got %v", got)
+	}
+
+	_, ok = (&Gradient{Kind: RadialGradient}).Transform(bounds)
+	test_helper.AssertEquals(t, false, ok)
+}
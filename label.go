@@ -5,25 +5,28 @@
 package gxui
 
 import (
-	"strings"
-
 	"github.com/badu/gxui/pkg/math"
 )
 
 type Label struct {
 	ControlBase
 	parent              ControlBaseParent
+	driver              Driver
 	font                Font
 	Text                string
+	attributedText      AttributedText
 	horizontalAlignment HAlign
 	verticalAlignment   VAlign
 	color               Color
 	multiline           bool
+	wrap                TextWrap
+	truncation          TextTruncation
 }
 
 func (l *Label) Init(parent ControlBaseParent, driver Driver, styles *StyleDefs) {
 	l.ControlBase.Init(parent, driver)
 	l.parent = parent
+	l.driver = driver
 	l.font = styles.DefaultFont
 	l.color = styles.LabelStyle.FontColor
 	l.horizontalAlignment = styles.LabelStyle.HAlign
@@ -31,11 +34,27 @@ func (l *Label) Init(parent ControlBaseParent, driver Driver, styles *StyleDefs)
 }
 
 func (l *Label) SetText(text string) {
-	if l.Text == text {
+	if l.Text == text && l.attributedText == nil {
 		return
 	}
 
 	l.Text = text
+	l.attributedText = nil
+	l.parent.ReLayout()
+}
+
+// AttributedText returns the spans of the text set with SetAttributedText, or
+// nil when the text was set with SetText.
+func (l *Label) AttributedText() AttributedText {
+	return append(AttributedText(nil), l.attributedText...)
+}
+
+// SetAttributedText sets the text of the label to the spans, drawn with their
+// own attributes. The attributes the spans leave to their zero value are those
+// of the label.
+func (l *Label) SetAttributedText(text AttributedText) {
+	l.attributedText = append(AttributedText{}, text...)
+	l.Text = text.String()
 	l.parent.ReLayout()
 }
 
@@ -78,13 +97,51 @@ func (l *Label) SetMultiline(multiline bool) {
 	l.parent.ReLayout()
 }
 
+// Wrap returns how the lines of the label wider than it are broken.
+func (l *Label) Wrap() TextWrap {
+	return l.wrap
+}
+
+// SetWrap sets how the lines of the label wider than it are broken. The
+// wrapped lines are drawn whether the label is multiline or not.
+func (l *Label) SetWrap(wrap TextWrap) {
+	if l.wrap == wrap {
+		return
+	}
+
+	l.wrap = wrap
+	l.parent.ReLayout()
+}
+
+// Truncation returns how the lines of the label wider than it are shortened.
+func (l *Label) Truncation() TextTruncation {
+	return l.truncation
+}
+
+// SetTruncation sets how the lines of the label wider than it are shortened,
+// once wrapped.
+func (l *Label) SetTruncation(truncation TextTruncation) {
+	if l.truncation == truncation {
+		return
+	}
+
+	l.truncation = truncation
+	l.parent.ReLayout()
+}
+
 func (l *Label) DesiredSize(min, max math.Size) math.Size {
-	text := l.Text
-	if !l.multiline {
-		text = strings.Replace(text, "\n", " ", -1)
+	return l.layoutText(max.Width).measure().Clamp(min, max)
+}
+
+// layoutText returns the layout of the text of the label, fitted to width.
+func (l *Label) layoutText(width int) *attributedLayout {
+	spans := l.attributedText
+	if spans == nil {
+		spans = AttributedText{{Text: l.Text}}
 	}
-	size := l.font.Measure(&TextBlock{Runes: []rune(text)})
-	return size.Clamp(min, max)
+	layout := layoutAttributedText(l.driver, l.font, l.color, spans, l.multiline)
+	layout.fit(width, l.wrap, l.truncation)
+	return layout
 }
 
 func (l *Label) SetHorizontalAlignment(horizontalAlignment HAlign) {
@@ -115,14 +172,7 @@ func (l *Label) VerticalAlignment() VAlign {
 // parts.DrawPaintPart overrides
 func (l *Label) Paint(canvas Canvas) {
 	rect := l.parent.Size().Rect()
-	text := l.Text
-	if !l.multiline {
-		text = strings.Replace(text, "\n", " ", -1)
-	}
-
-	runes := []rune(text)
-	offsets := l.font.Layout(
-		&TextBlock{Runes: runes, AlignRect: rect, H: l.horizontalAlignment, V: l.verticalAlignment},
-	)
-	canvas.DrawRunes(l.font, runes, offsets, l.color)
+	layout := l.layoutText(rect.Width())
+	layout.layout(rect, l.horizontalAlignment, l.verticalAlignment)
+	layout.paint(canvas)
 }
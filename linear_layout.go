@@ -32,8 +32,17 @@ func (l *LinearLayoutPart) LayoutChildren() {
 	children := l.parent.Children()
 	major := 0
 
-	if l.direction.RightToLeft() || l.direction.BottomToTop() {
-		if l.direction.RightToLeft() {
+	// Horizontal layouts and alignments are mirrored in right to left windows.
+	direction, horizontalAlignment := l.direction, l.horizontalAlignment
+	if IsRightToLeft(l.parent) {
+		if direction.Orientation().Horizontal() {
+			direction = direction.Flip()
+		}
+		horizontalAlignment = horizontalAlignment.Flip()
+	}
+
+	if direction.RightToLeft() || direction.BottomToTop() {
+		if direction.RightToLeft() {
 			major = size.Width
 		} else {
 			major = size.Height
@@ -47,7 +56,7 @@ func (l *LinearLayoutPart) LayoutChildren() {
 
 		// Calculate minor-axis alignment
 		var minor int
-		switch l.direction.Orientation() {
+		switch direction.Orientation() {
 		case Horizontal:
 			switch l.verticalAlignment {
 			case AlignTop:
@@ -58,7 +67,7 @@ func (l *LinearLayoutPart) LayoutChildren() {
 				minor = size.Height - childSize.Height
 			}
 		case Vertical:
-			switch l.horizontalAlignment {
+			switch horizontalAlignment {
 			case AlignLeft:
 				minor = childMargin.Left
 			case AlignCenter:
@@ -69,7 +78,7 @@ func (l *LinearLayoutPart) LayoutChildren() {
 		}
 
 		// Perform layout
-		switch l.direction {
+		switch direction {
 		case LeftToRight:
 			major += childMargin.Left
 			child.Offset = math.Point{X: major, Y: minor}.Add(offset)
@@ -108,9 +117,12 @@ func (l *LinearLayoutPart) DesiredSize(min, max math.Size) math.Size {
 
 	horizontal := l.direction.Orientation().Horizontal()
 	offset := math.Point{X: 0, Y: 0}
+	// Children are measured in the size they are laid out in, as those wrapping
+	// their content are taller when narrower.
+	available := max.Contract(l.parent.Padding())
 	for _, child := range children {
-		childSize := child.Control.DesiredSize(math.ZeroSize, max)
 		childMargin := child.Control.Margin()
+		childSize := child.Control.DesiredSize(math.ZeroSize, available.Contract(childMargin).Max(math.ZeroSize))
 		childBounds := childSize.Expand(childMargin).Rect().Offset(offset)
 		if horizontal {
 			offset.X += childBounds.Width()
@@ -160,7 +160,7 @@ func (l *ListImpl) LayoutChildren() {
 		details, found := l.details[item]
 		if found {
 			if details.mark == mark {
-				panic(fmt.Errorf("adapter for control '%s' returned duplicate item (%v) for indices %v and %v", Path(l.parent), item, details.index, idx))
+				panic(fmt.Errorf("adapter for control '%s' returned duplicate item (%v) for indices %v and %v", ControlPath(l.parent), item, details.index, idx))
 			}
 		} else {
 			control := l.adapter.Create(l.driver, l.styles, idx)
@@ -203,6 +203,8 @@ func (l *ListImpl) LayoutChildren() {
 		scrollSize := l.scrollBar.DesiredSize(math.ZeroSize, size)
 		if l.Orientation().Horizontal() {
 			l.scrollBarChild.Layout(math.CreateRect(0, size.Height-scrollSize.Height, size.Width, size.Height).Canon().Offset(offset))
+		} else if IsRightToLeft(l.parent) {
+			l.scrollBarChild.Layout(math.CreateRect(0, 0, scrollSize.Width, size.Height).Canon().Offset(offset))
 		} else {
 			l.scrollBarChild.Layout(math.CreateRect(size.Width-scrollSize.Width, 0, size.Width, size.Height).Canon().Offset(offset))
 		}
@@ -321,13 +323,24 @@ func (l *ListImpl) VisibleItemRange(includePartiallyVisible bool) (int, int) {
 }
 
 func (l *ListImpl) SizeChanged() {
+	l.updateItemSize()
+	l.parent.ReLayout()
+}
+
+func (l *ListImpl) updateItemSize() {
 	l.itemSize = l.adapter.Size(l.styles)
 	l.scrollBar.SetScrollLimit(l.itemCount * l.MajorAxisItemSize())
 	l.SetScrollOffset(l.scrollOffset)
-	l.parent.ReLayout()
 }
 
 func (l *ListImpl) DataChanged(recreateControls bool) {
+	l.updateItems(recreateControls)
+	l.parent.ReLayout()
+}
+
+// updateItems updates the items from the adapter, recreating their controls if
+// recreateControls is true, without laying out the list again.
+func (l *ListImpl) updateItems(recreateControls bool) {
 	if recreateControls {
 		for item, details := range l.details {
 			details.onClickSubscription.Forget()
@@ -337,7 +350,7 @@ func (l *ListImpl) DataChanged(recreateControls bool) {
 	}
 
 	l.itemCount = l.adapter.Count()
-	l.SizeChanged()
+	l.updateItemSize()
 }
 
 func (l *ListImpl) DataReplaced() {
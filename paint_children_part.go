@@ -4,11 +4,22 @@
 
 package gxui
 
+import "github.com/badu/gxui/pkg/math"
+
 type PaintChildrenParent interface {
 	Children() Children
 	PaintChild(canvas Canvas, child *Child, idx int)
 }
 
+// ShadowPainter is implemented by the controls casting a shadow outside of their
+// bounds, which their parent paints before clipping to the control.
+type ShadowPainter interface {
+	PaintShadow(canvas Canvas, rect math.Rect)
+	// ShadowBounds returns the rectangle covering rect and the shadows it casts,
+	// both the one painted last and the one painted next.
+	ShadowBounds(rect math.Rect) math.Rect
+}
+
 type PaintChildrenPart struct {
 	parent PaintChildrenParent
 }
@@ -24,6 +35,13 @@ func (p *PaintChildrenPart) Paint(canvas Canvas) {
 		}
 
 		canvas.Push()
+		if m, ok := childTransform(p.parent, v); ok {
+			// Transform the child around its offset, where it is drawn.
+			canvas.Transform(m.Mul(math.CreateMat3Translate(-float32(v.Offset.X), -float32(v.Offset.Y))))
+		}
+		if shadowed, ok := v.Control.(ShadowPainter); ok {
+			shadowed.PaintShadow(canvas, v.Control.Size().Rect().Offset(v.Offset))
+		}
 		canvas.AddClip(v.Control.Size().Rect().Offset(v.Offset))
 		p.parent.PaintChild(canvas, v, i)
 		canvas.Pop()
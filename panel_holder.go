@@ -65,11 +65,16 @@ func insertIndex(holder PanelHolder, at math.Point) int {
 			bestScore = score
 		}
 	}
+	// Tabs run from the right in right to left windows.
+	rightToLeft := IsRightToLeft(holder.Parent())
 	for i := 0; i < holder.PanelCount(); i++ {
 		tab := holder.Tab(i)
 		size := tab.Size()
 		ml := math.Point{Y: size.Height / 2}
 		mr := math.Point{Y: size.Height / 2, X: size.Width}
+		if rightToLeft {
+			ml, mr = mr, ml
+		}
 		score(TransformCoordinate(ml, tab, holder), i)
 		score(TransformCoordinate(mr, tab, holder), i+1)
 	}
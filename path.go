@@ -0,0 +1,269 @@
+package gxui
+
+import (
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// DefaultPathTolerance is the largest distance, in DIPs, between a curve and
+// the lines approximating it when a path is drawn.
+const DefaultPathTolerance = 0.1
+
+// FillRule decides which areas enclosed by the contours of a path are filled.
+type FillRule int
+
+const (
+	// NonZero fills the areas around which the contours wind a non-zero number
+	// of times, counting clockwise turns as +1 and counter-clockwise as -1.
+	NonZero FillRule = iota
+	// EvenOdd fills the areas enclosed by an odd number of contours.
+	EvenOdd
+)
+
+// PathOp is the kind of a PathSegment.
+type PathOp int
+
+const (
+	// PathMoveTo starts a new sub-path.
+	PathMoveTo PathOp = iota
+	// PathLineTo is a straight line.
+	PathLineTo
+	// PathQuadTo is a quadratic Bézier curve.
+	PathQuadTo
+	// PathCubicTo is a cubic Bézier curve.
+	PathCubicTo
+	// PathClose joins the sub-path back to its start, and ends it.
+	PathClose
+)
+
+// PathSegment is a single operation of a Path. Points holds the control points
+// followed by the end point: one point for PathMoveTo and PathLineTo, two for
+// PathQuadTo, three for PathCubicTo and none for PathClose.
+type PathSegment struct {
+	Op     PathOp
+	Points [3]math.Vec2
+}
+
+// PathContour is a flattened sub-path: a line through Points, joined back to
+// the first point if Closed.
+type PathContour struct {
+	Points []math.Vec2
+	Closed bool
+}
+
+// Path is a shape made of sub-paths of lines and curves, in DIPs. A sub-path
+// starts with MoveTo, or at the current point after Close or on the first
+// operation of an empty path. The zero value is an empty path.
+// Canvases flatten paths when they are drawn, so a path may be reused and
+// modified afterwards.
+type Path struct {
+	Segments []PathSegment
+}
+
+// Current returns the point the next segment starts from.
+func (p *Path) Current() math.Vec2 {
+	start, current := math.Vec2{}, math.Vec2{}
+	for _, s := range p.Segments {
+		switch s.Op {
+		case PathMoveTo:
+			start, current = s.Points[0], s.Points[0]
+		case PathLineTo:
+			current = s.Points[0]
+		case PathQuadTo:
+			current = s.Points[1]
+		case PathCubicTo:
+			current = s.Points[2]
+		case PathClose:
+			current = start
+		}
+	}
+	return current
+}
+
+// MoveTo starts a new sub-path at to.
+func (p *Path) MoveTo(to math.Vec2) {
+	p.Segments = append(p.Segments, PathSegment{Op: PathMoveTo, Points: [3]math.Vec2{to}})
+}
+
+// LineTo adds a straight line from the current point to to.
+func (p *Path) LineTo(to math.Vec2) {
+	p.Segments = append(p.Segments, PathSegment{Op: PathLineTo, Points: [3]math.Vec2{to}})
+}
+
+// QuadTo adds a quadratic Bézier curve from the current point to to.
+func (p *Path) QuadTo(control, to math.Vec2) {
+	p.Segments = append(p.Segments, PathSegment{Op: PathQuadTo, Points: [3]math.Vec2{control, to}})
+}
+
+// CubicTo adds a cubic Bézier curve from the current point to to.
+func (p *Path) CubicTo(control1, control2, to math.Vec2) {
+	p.Segments = append(p.Segments, PathSegment{Op: PathCubicTo, Points: [3]math.Vec2{control1, control2, to}})
+}
+
+// Close adds a straight line back to the start of the sub-path, and ends it.
+func (p *Path) Close() {
+	p.Segments = append(p.Segments, PathSegment{Op: PathClose})
+}
+
+// ArcTo adds an elliptical arc from the current point to to, as the SVG arc
+// command does. The ellipse has the given radii and is rotated clockwise by
+// rotation radians. Of the four arcs joining the points, largeArc picks one of
+// the two longer ones, and sweep one of the two going clockwise. The radii are
+// scaled up if the ellipse is too small to join the points. The arc is stored
+// as cubic Bézier curves.
+func (p *Path) ArcTo(radius math.Vec2, rotation float32, largeArc, sweep bool, to math.Vec2) {
+	from := p.Current()
+	rx, ry := math32.Abs(radius.X), math32.Abs(radius.Y)
+	if from == to {
+		return
+	}
+	if rx == 0 || ry == 0 {
+		p.LineTo(to)
+		return
+	}
+
+	// The conversion from endpoints to center, from the SVG specification.
+	sin, cos := math32.Sincos(rotation)
+	half := from.Sub(to).MulS(0.5)
+	x1, y1 := cos*half.X+sin*half.Y, -sin*half.X+cos*half.Y
+	if scale := x1*x1/(rx*rx) + y1*y1/(ry*ry); scale > 1 {
+		rx, ry = rx*math32.Sqrt(scale), ry*math32.Sqrt(scale)
+	}
+	numerator := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	factor := math32.Sqrt(max(numerator, 0) / (rx*rx*y1*y1 + ry*ry*x1*x1))
+	if largeArc == sweep {
+		factor = -factor
+	}
+	cx, cy := factor*rx*y1/ry, -factor*ry*x1/rx
+	middle := from.Add(to).MulS(0.5)
+	center := math.Vec2{X: cos*cx - sin*cy + middle.X, Y: sin*cx + cos*cy + middle.Y}
+
+	angle := func(x, y float32) float32 { return math32.Atan2(y, x) }
+	start := angle((x1-cx)/rx, (y1-cy)/ry)
+	sweepAngle := angle((-x1-cx)/rx, (-y1-cy)/ry) - start
+	if sweep && sweepAngle < 0 {
+		sweepAngle += 2 * math.Pi
+	} else if !sweep && sweepAngle > 0 {
+		sweepAngle -= 2 * math.Pi
+	}
+
+	// Each quarter of the ellipse or less is approximated by a cubic curve.
+	point := func(a float32) math.Vec2 {
+		s, c := math32.Sincos(a)
+		x, y := rx*c, ry*s
+		return math.Vec2{X: cos*x - sin*y + center.X, Y: sin*x + cos*y + center.Y}
+	}
+	derivative := func(a float32) math.Vec2 {
+		s, c := math32.Sincos(a)
+		x, y := -rx*s, ry*c
+		return math.Vec2{X: cos*x - sin*y, Y: sin*x + cos*y}
+	}
+	count := int(math32.Ceil(math32.Abs(sweepAngle)/(math.Pi/2) - 0.001))
+	step := sweepAngle / float32(count)
+	k := 4 / 3.0 * math32.Tan(step/4)
+	for i := 0; i < count; i++ {
+		a, b := start+step*float32(i), start+step*float32(i+1)
+		end := point(b)
+		if i == count-1 {
+			end = to
+		}
+		p.CubicTo(point(a).Add(derivative(a).MulS(k)), point(b).Sub(derivative(b).MulS(k)), end)
+	}
+}
+
+// Flatten returns the sub-paths of the path, with the curves approximated by
+// lines no further than tolerance from them. Sub-paths without any segment are
+// dropped.
+func (p *Path) Flatten(tolerance float32) []PathContour {
+	var result []PathContour
+	var current PathContour
+	start, last := math.Vec2{}, math.Vec2{}
+	end := func() {
+		if len(current.Points) > 1 {
+			result = append(result, current)
+		}
+		current = PathContour{}
+	}
+	for _, s := range p.Segments {
+		if s.Op == PathMoveTo {
+			end()
+			start, last = s.Points[0], s.Points[0]
+			continue
+		}
+		if s.Op == PathClose {
+			current.Closed = true
+			end()
+			last = start
+			continue
+		}
+		if len(current.Points) == 0 {
+			start = last
+			current.Points = []math.Vec2{last}
+		}
+		switch s.Op {
+		case PathLineTo:
+			current.Points = append(current.Points, s.Points[0])
+			last = s.Points[0]
+		case PathQuadTo:
+			current.Points = flattenQuad(current.Points, last, s.Points[0], s.Points[1], tolerance)
+			last = s.Points[1]
+		case PathCubicTo:
+			current.Points = flattenCubic(current.Points, last, s.Points[0], s.Points[1], s.Points[2], tolerance)
+			last = s.Points[2]
+		}
+	}
+	end()
+	return result
+}
+
+// The number of lines approximating a curve is chosen from the bound of the
+// distance between a curve and its chord, h²/8 of the largest second
+// derivative for a parameter step h.
+func flattenSteps(secondDerivative, tolerance float32) int {
+	if tolerance <= 0 {
+		tolerance = DefaultPathTolerance
+	}
+	return max(1, int(math32.Ceil(math32.Sqrt(secondDerivative/(8*tolerance)))))
+}
+
+func flattenQuad(points []math.Vec2, p0, p1, p2 math.Vec2, tolerance float32) []math.Vec2 {
+	steps := flattenSteps(2*p0.Sub(p1.MulS(2)).Add(p2).Len(), tolerance)
+	for i := 1; i <= steps; i++ {
+		t := float32(i) / float32(steps)
+		u := 1 - t
+		points = append(points, p0.MulS(u*u).Add(p1.MulS(2*u*t)).Add(p2.MulS(t*t)))
+	}
+	return points
+}
+
+func flattenCubic(points []math.Vec2, p0, p1, p2, p3 math.Vec2, tolerance float32) []math.Vec2 {
+	d0 := p0.Sub(p1.MulS(2)).Add(p2).Len()
+	d1 := p1.Sub(p2.MulS(2)).Add(p3).Len()
+	steps := flattenSteps(6*max(d0, d1), tolerance)
+	for i := 1; i <= steps; i++ {
+		t := float32(i) / float32(steps)
+		u := 1 - t
+		points = append(points, p0.MulS(u*u*u).Add(p1.MulS(3*u*u*t)).Add(p2.MulS(3*u*t*t)).Add(p3.MulS(t*t*t)))
+	}
+	return points
+}
+
+// Bounds returns the smallest rectangle containing the path, flattened with
+// DefaultPathTolerance.
+func (p *Path) Bounds() math.Rect {
+	var min, max math.Vec2
+	first := true
+	for _, c := range p.Flatten(DefaultPathTolerance) {
+		for _, v := range c.Points {
+			if first {
+				min, max, first = v, v, false
+			}
+			min = math.Vec2{X: math32.Min(min.X, v.X), Y: math32.Min(min.Y, v.Y)}
+			max = math.Vec2{X: math32.Max(max.X, v.X), Y: math32.Max(max.Y, v.Y)}
+		}
+	}
+	return math.Rect{
+		Min: math.Point{X: int(math32.Floor(min.X)), Y: int(math32.Floor(min.Y))},
+		Max: math.Point{X: int(math32.Ceil(max.X)), Y: int(math32.Ceil(max.Y))},
+	}
+}
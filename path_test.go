@@ -0,0 +1,86 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+func TestPathFlatten(t *testing.T) {
+	path := &Path{}
+	path.MoveTo(math.Vec2{X: 0, Y: 0})
+	path.LineTo(math.Vec2{X: 10, Y: 0})
+	path.LineTo(math.Vec2{X: 10, Y: 10})
+	path.Close()
+	path.LineTo(math.Vec2{X: -5, Y: 0})
+	path.MoveTo(math.Vec2{X: 20, Y: 20})
+
+	test_helper.AssertEquals(t, []PathContour{
+		{Points: []math.Vec2{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}, Closed: true},
+		// After Close, a sub-path starts at the start of the closed one.
+		{Points: []math.Vec2{{X: 0, Y: 0}, {X: -5, Y: 0}}},
+	}, path.Flatten(DefaultPathTolerance))
+	test_helper.AssertEquals(t, math.CreateRect(-5, 0, 10, 10), path.Bounds())
+}
+
+func TestPathFlattenCurves(t *testing.T) {
+	path := &Path{}
+	path.MoveTo(math.Vec2{X: 0, Y: 0})
+	path.QuadTo(math.Vec2{X: 50, Y: 100}, math.Vec2{X: 100, Y: 0})
+	contours := path.Flatten(0.5)
+	test_helper.AssertEquals(t, 1, len(contours))
+
+	// The middle of each line is within the tolerance of the curve, which is
+	// y = 2x - x²/50, and as x is linear in the parameter the distance
+	// between points of the same parameter is vertical.
+	points := contours[0].Points
+	if len(points) < 4 || len(points) > 20 {
+		t.Errorf("Expected a few lines, got %d", len(points)-1)
+	}
+	for i := 1; i < len(points); i++ {
+		m := points[i-1].Add(points[i]).MulS(0.5)
+		if d := 2*m.X - m.X*m.X/50 - m.Y; d < 0 || d > 0.5 {
+			t.Errorf("Expected line %d to be within 0.5 of the curve, got %v", i, d)
+		}
+	}
+	test_helper.AssertEquals(t, math.Vec2{X: 100, Y: 0}, points[len(points)-1])
+
+	// A finer tolerance uses more lines.
+	if finer := path.Flatten(0.05)[0].Points; len(finer) <= len(points) {
+		t.Errorf("Expected more than %d points with a finer tolerance, got %d", len(points), len(finer))
+	}
+}
+
+func TestPathArcTo(t *testing.T) {
+	center := math.Vec2{X: 5, Y: 0}
+	for _, test := range []struct {
+		sweep bool
+		y     float32
+	}{
+		{sweep: true, y: -5},
+		{sweep: false, y: 5},
+	} {
+		path := &Path{}
+		path.MoveTo(math.Vec2{X: 0, Y: 0})
+		path.ArcTo(math.Vec2{X: 5, Y: 5}, 0, false, test.sweep, math.Vec2{X: 10, Y: 0})
+		test_helper.AssertEquals(t, math.Vec2{X: 10, Y: 0}, path.Current())
+
+		found := false
+		for _, p := range path.Flatten(0.01)[0].Points {
+			if d := p.Sub(center).Len(); d < 4.98 || d > 5.02 {
+				t.Errorf("Expected %v to be on the circle, at %v from its center", p, d)
+			}
+			found = found || p.Sub(math.Vec2{X: 5, Y: test.y}).Len() < 0.1
+		}
+		if !found {
+			t.Errorf("Expected the arc with sweep %v to go through (5, %v)", test.sweep, test.y)
+		}
+	}
+
+	// Radii too small to join the points are scaled up.
+	path := &Path{}
+	path.MoveTo(math.Vec2{X: 0, Y: 0})
+	path.ArcTo(math.Vec2{X: 1, Y: 1}, 0, false, true, math.Vec2{X: 10, Y: 0})
+	test_helper.AssertEquals(t, math.CreateRect(0, -5, 10, 0), path.Bounds())
+}
@@ -0,0 +1,156 @@
+package gxui
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// WrapMode is how a pattern is extended beyond the bounds of its texture.
+type WrapMode int
+
+const (
+	// WrapRepeat tiles the texture.
+	WrapRepeat WrapMode = iota
+	// WrapClamp extends the edge pixels of the texture.
+	WrapClamp
+	// WrapMirror tiles the texture, flipping every other tile.
+	WrapMirror
+)
+
+// Pattern describes a texture filling a shape. Like gradients, brushes refer to
+// patterns by pointer, so a pattern must not be modified once it is used.
+type Pattern struct {
+	Texture Texture
+	Wrap    WrapMode
+	// Transform places the texture, at its size in DIPs, relative to the
+	// top-left corner of the bounding box of the filled shape.
+	Transform math.Mat3
+}
+
+// CreatePatternBrush returns a brush filling shapes with texture, placed by
+// transform and extended by wrap.
+func CreatePatternBrush(texture Texture, wrap WrapMode, transform math.Mat3) Brush {
+	return Brush{
+		Color:   averageColor(texture.Image()),
+		Pattern: &Pattern{Texture: texture, Wrap: wrap, Transform: transform},
+	}
+}
+
+// CreateImageBrush returns a brush stretching texture over a shape whose
+// bounding box has the given size, such as a photo clipped by a rounded rect.
+func CreateImageBrush(texture Texture, size math.Size) Brush {
+	textureSize := texture.Size()
+	scale := math.CreateMat3Scale(
+		float32(size.Width)/float32(textureSize.Width),
+		float32(size.Height)/float32(textureSize.Height),
+	)
+	return CreatePatternBrush(texture, WrapClamp, scale)
+}
+
+// Matrix returns the transform from the pattern space, where the texture
+// covers the rectangle from zero to its size in DIPs, to the space of bounds.
+func (p *Pattern) Matrix(bounds math.Rect) math.Mat3 {
+	return math.CreateMat3Translate(float32(bounds.Min.X), float32(bounds.Min.Y)).Mul(p.Transform)
+}
+
+// TexCoords returns the transform from the space of bounds to the texture
+// coordinates, which go from 0 to 1 across the texture before wrapping. It
+// returns false if the pattern transform is degenerate.
+func (p *Pattern) TexCoords(bounds math.Rect) (math.Mat3, bool) {
+	inverse, ok := p.Matrix(bounds).Invert()
+	if !ok {
+		return math.Mat3{}, false
+	}
+	size := p.Texture.Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return math.Mat3{}, false
+	}
+	return math.CreateMat3Scale(1/float32(size.Width), 1/float32(size.Height)).Mul(inverse), true
+}
+
+// Tile returns the size of the tile repeated by the pattern, in the pattern
+// space, and the transforms placing the texture in the tile. The transforms
+// are applied to the texture drawn from zero to its size in DIPs, with its
+// first row at the top. Mirrored tiles hold the texture four times, flipped.
+func (p *Pattern) Tile() (math.Vec2, []math.Mat3) {
+	size := p.Texture.Size()
+	w, h := float32(size.Width), float32(size.Height)
+	place := func(x, y float32, flipX, flipY bool) math.Mat3 {
+		m := math.CreateMat3Translate(x, y)
+		if flipX {
+			m = m.Mul(math.CreateMat3Translate(w, 0)).Mul(math.CreateMat3Scale(-1, 1))
+		}
+		if flipY != p.Texture.FlipY() {
+			m = m.Mul(math.CreateMat3Translate(0, h)).Mul(math.CreateMat3Scale(1, -1))
+		}
+		return m
+	}
+	if p.Wrap != WrapMirror {
+		return math.Vec2{X: w, Y: h}, []math.Mat3{place(0, 0, false, false)}
+	}
+	return math.Vec2{X: 2 * w, Y: 2 * h}, []math.Mat3{
+		place(0, 0, false, false),
+		place(w, 0, true, false),
+		place(0, h, false, true),
+		place(w, h, true, true),
+	}
+}
+
+// Extent returns the corners of the rectangle of the pattern space covering
+// both the texture and a shape with the given bounds. This is the single tile
+// needed to draw a clamped pattern without extending its edges.
+func (p *Pattern) Extent(bounds math.Rect) (topLeft, bottomRight math.Vec2) {
+	size := p.Texture.Size()
+	bottomRight = math.Vec2{X: float32(size.Width), Y: float32(size.Height)}
+	inverse, ok := p.Matrix(bounds).Invert()
+	if !ok {
+		return topLeft, bottomRight
+	}
+	for _, corner := range []math.Point{bounds.TopLeft(), bounds.TopRight(), bounds.BottomLeft(), bounds.BottomRight()} {
+		v := inverse.TransformVec2(corner.Vec2())
+		topLeft = math.Vec2{X: min(topLeft.X, v.X), Y: min(topLeft.Y, v.Y)}
+		bottomRight = math.Vec2{X: max(bottomRight.X, v.X), Y: max(bottomRight.Y, v.Y)}
+	}
+	return topLeft, bottomRight
+}
+
+// Apply returns the texture coordinate t, in texture sizes, wrapped into [0, 1]
+// by the mode.
+func (w WrapMode) Apply(t float32) float32 {
+	switch w {
+	case WrapClamp:
+		return math.Saturate(t)
+	case WrapMirror:
+		t = math32.Abs(math32.Mod(t, 2))
+		if t > 1 {
+			t = 2 - t
+		}
+		return t
+	default:
+		return t - math32.Floor(t)
+	}
+}
+
+// averageColor returns the mean color of img, used as the solid color of
+// pattern brushes.
+func averageColor(img image.Image) Color {
+	bounds := img.Bounds()
+	var r, g, b, a float32
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			alpha := float32(c.A) / 0xff
+			r += float32(c.R) / 0xff * alpha
+			g += float32(c.G) / 0xff * alpha
+			b += float32(c.B) / 0xff * alpha
+			a += alpha
+		}
+	}
+	if a == 0 {
+		return Transparent
+	}
+	return Color{R: r / a, G: g / a, B: b / a, A: a / float32(bounds.Dx()*bounds.Dy())}
+}
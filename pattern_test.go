@@ -0,0 +1,45 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+func TestWrapModeApply(t *testing.T) {
+	for _, test := range []struct {
+		wrap     WrapMode
+		t        float32
+		expected float32
+	}{
+		{WrapRepeat, 0.25, 0.25},
+		{WrapRepeat, 1.25, 0.25},
+		{WrapRepeat, -0.25, 0.75},
+		{WrapClamp, 1.25, 1},
+		{WrapClamp, -0.25, 0},
+		{WrapMirror, 1.25, 0.75},
+		{WrapMirror, 2.25, 0.25},
+		{WrapMirror, -0.25, 0.25},
+	} {
+		if got := test.wrap.Apply(test.t); got != test.expected {
+			t.Errorf("%s.Apply(%v): expected %v, got %v", wrapModeNames[test.wrap], test.t, test.expected, got)
+		}
+	}
+}
+
+func TestPatternTexCoords(t *testing.T) {
+	brush := CreatePatternBrush(createTestTexture(), WrapRepeat, math.CreateMat3Scale(2, 4))
+	// The only opaque pixel of the test texture is red.
+	test_helper.AssertEquals(t, Color{R: 1, A: 0.25}, brush.Color)
+
+	texCoords, ok := brush.Pattern.TexCoords(math.CreateRect(10, 20, 30, 40))
+	test_helper.AssertEquals(t, true, ok)
+	// The texture is 2x2 pixels, scaled to 4x8 from the top-left corner of the bounds.
+	test_helper.AssertEquals(t, math.Vec2{}, texCoords.TransformVec2(math.Vec2{X: 10, Y: 20}))
+	test_helper.AssertEquals(t, math.Vec2{X: 1, Y: 0.5}, texCoords.TransformVec2(math.Vec2{X: 14, Y: 24}))
+
+	brush = CreatePatternBrush(createTestTexture(), WrapRepeat, math.CreateMat3Scale(0, 1))
+	_, ok = brush.Pattern.TexCoords(math.CreateRect(0, 0, 1, 1))
+	test_helper.AssertEquals(t, false, ok)
+}
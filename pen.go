@@ -8,11 +8,73 @@ var DefaultPen Pen = CreatePen(1.0, Black)
 var TransparentPen Pen = CreatePen(0.0, Transparent)
 var WhitePen Pen = CreatePen(1.0, White)
 
+// DefaultMiterLimit is the miter limit of pens that do not set one.
+const DefaultMiterLimit = 4
+
+// LineCap is the shape of the ends of open strokes and dashes.
+type LineCap int
+
+const (
+	// ButtCap ends the stroke at the end points.
+	ButtCap LineCap = iota
+	// RoundCap ends the stroke with half circles.
+	RoundCap
+	// SquareCap extends the stroke by half its width past the end points.
+	SquareCap
+)
+
+// LineJoin is the shape of the outer corner of a stroke where two lines meet.
+type LineJoin int
+
+const (
+	// MiterJoin extends the outer edges of the lines until they meet.
+	MiterJoin LineJoin = iota
+	// RoundJoin rounds the corner with a circular arc.
+	RoundJoin
+	// BevelJoin cuts the corner with a straight line.
+	BevelJoin
+)
+
+// Dash breaks a stroke into dashes. Like gradients, pens refer to dashes by
+// pointer so that they can still be compared, which means that a dash must not
+// be modified once it is used by a Pen.
+type Dash struct {
+	// Lengths alternate between the lengths of the dashes and of the gaps, in
+	// DIPs, starting with a dash. An odd number of lengths is repeated to make
+	// an even number, so {4} draws 4 DIPs dashes separated by 4 DIPs gaps.
+	// Zero length dashes are drawn as dots by round and square caps.
+	Lengths []float32
+	// Offset is the distance into the lengths at which the stroke starts.
+	Offset float32
+}
+
 type Pen struct {
 	Width float32
 	Color Color
+	Cap   LineCap
+	Join  LineJoin
+	// MiterLimit is the longest a miter join can be, as a multiple of Width,
+	// before it is drawn as a bevel join. Zero is DefaultMiterLimit.
+	MiterLimit float32
+	// Dash, if not nil, breaks the stroke into dashes.
+	Dash *Dash
 }
 
 func CreatePen(width float32, color Color) Pen {
 	return Pen{Width: width, Color: Color{A: color.A, R: color.R, G: color.G, B: color.B}}
 }
+
+// CreateDashedPen returns a pen drawing dashes separated by gaps, alternating
+// between the lengths, starting at offset into them.
+func CreateDashedPen(width float32, color Color, offset float32, lengths ...float32) Pen {
+	pen := CreatePen(width, color)
+	pen.Dash = &Dash{Lengths: append([]float32{}, lengths...), Offset: offset}
+	return pen
+}
+
+// IsStyled returns true if the pen has dashes, caps, joins or a miter limit
+// other than the zero values. Pens that are not styled are drawn as solid
+// strokes with mitered corners and butt ends.
+func (p Pen) IsStyled() bool {
+	return p.Dash != nil || p.Cap != ButtCap || p.Join != MiterJoin || p.MiterLimit != 0
+}
@@ -0,0 +1,538 @@
+// Package bidi implements the Unicode Bidirectional Algorithm (UAX #9) for
+// single lines of text: it resolves the embedding level of each rune, reorders
+// the runes for display and maps carets between logical and visual order.
+//
+// Explicit embeddings, overrides and isolates are not supported, and their
+// formatting characters are resolved as neutrals. Mirror returns the mirrored
+// form of the common paired punctuation, which is also the punctuation paired
+// by rule N0.
+package bidi
+
+import (
+	"sort"
+
+	unicodeBidi "golang.org/x/text/unicode/bidi"
+)
+
+// Direction is the base direction of a line.
+type Direction int
+
+const (
+	// Auto takes the direction of the first strong character of the line, or
+	// left to right if there is none.
+	Auto Direction = iota
+	LeftToRight
+	RightToLeft
+)
+
+// Run is a sequence of runes, from Start up to End in logical order, resolved
+// to the same level. Runs at an odd level are displayed right to left.
+type Run struct {
+	Start, End int
+	Level      uint8
+}
+
+// RightToLeft returns true if the runes of the run are displayed right to left.
+func (r Run) RightToLeft() bool {
+	return r.Level&1 != 0
+}
+
+// Line holds the resolved levels and the display order of a line of text.
+type Line struct {
+	level  uint8
+	levels []uint8
+	// order holds the logical index of the rune at each visual position, and
+	// visual the visual position of each rune.
+	order  []int
+	visual []int
+}
+
+// NewLine resolves the levels of the runes of a line in the direction.
+func NewLine(runes []rune, direction Direction) *Line {
+	classes := make([]unicodeBidi.Class, len(runes))
+	for i, r := range runes {
+		properties, _ := unicodeBidi.LookupRune(r)
+		classes[i] = properties.Class()
+	}
+
+	var level uint8
+	switch direction {
+	case RightToLeft:
+		level = 1
+	case Auto:
+		level = paragraphLevel(classes)
+	}
+
+	l := &Line{level: level, levels: resolveLevels(runes, classes, level)}
+	l.reorder()
+	return l
+}
+
+// Len returns the number of runes of the line.
+func (l *Line) Len() int {
+	return len(l.levels)
+}
+
+// RightToLeft returns true if the base direction of the line is right to left.
+func (l *Line) RightToLeft() bool {
+	return l.level&1 != 0
+}
+
+// Level returns the resolved level of the rune at i.
+func (l *Line) Level(i int) uint8 {
+	return l.levels[i]
+}
+
+// Visual returns the visual position of the rune at the logical index i,
+// counting from the left.
+func (l *Line) Visual(i int) int {
+	return l.visual[i]
+}
+
+// Logical returns the logical index of the rune at the visual position v.
+func (l *Line) Logical(v int) int {
+	return l.order[v]
+}
+
+// Runs returns the runs of the line, from left to right.
+func (l *Line) Runs() []Run {
+	var runs []Run
+	for v := 0; v < len(l.order); {
+		i := l.order[v]
+		run := Run{Start: i, End: i + 1, Level: l.levels[i]}
+		for v++; v < len(l.order) && l.levels[l.order[v]] == run.Level; v++ {
+			run.Start = min(run.Start, l.order[v])
+			run.End = max(run.End, l.order[v]+1)
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// VisualCaret returns the visual position of the caret before the rune at the
+// logical index caret, from 0 at the left of the line to Len at its right.
+// Where the runes around the caret are displayed apart, the caret is placed
+// next to the one at the higher level, or else next to the rune after it.
+func (l *Line) VisualCaret(caret int) int {
+	n := len(l.levels)
+	switch {
+	case n == 0:
+		return 0
+	case caret >= n || (caret > 0 && l.levels[caret-1] > l.levels[caret]):
+		return l.edge(min(caret, n)-1, true)
+	default:
+		return l.edge(caret, false)
+	}
+}
+
+// edge returns the visual position of the logical end of the rune at i if end
+// is true, or of its logical start.
+func (l *Line) edge(i int, end bool) int {
+	if (l.levels[i]&1 != 0) != end {
+		return l.visual[i] + 1
+	}
+	return l.visual[i]
+}
+
+// LogicalCaret returns the logical index of a caret at the visual position,
+// after the rune left of it or before the rune right of it. The caret before
+// the rune right of it is preferred where both are displayed at the position.
+func (l *Line) LogicalCaret(position int) int {
+	n := len(l.levels)
+	if n == 0 {
+		return 0
+	}
+	position = min(max(position, 0), n)
+
+	var candidates []int
+	if position < n {
+		i := l.order[position]
+		if l.levels[i]&1 != 0 {
+			i++
+		}
+		candidates = append(candidates, i)
+	}
+	if position > 0 {
+		i := l.order[position-1]
+		if l.levels[i]&1 == 0 {
+			i++
+		}
+		candidates = append(candidates, i)
+	}
+	for _, caret := range candidates {
+		if l.VisualCaret(caret) == position {
+			return caret
+		}
+	}
+	return candidates[0]
+}
+
+// CaretLeft returns the caret displayed closest to the left of the caret, or
+// false if it is at the left of the line.
+func (l *Line) CaretLeft(caret int) (int, bool) {
+	for position := l.VisualCaret(caret) - 1; position >= 0; position-- {
+		if left := l.LogicalCaret(position); l.VisualCaret(left) == position {
+			return left, true
+		}
+	}
+	return caret, false
+}
+
+// CaretRight returns the caret displayed closest to the right of the caret, or
+// false if it is at the right of the line.
+func (l *Line) CaretRight(caret int) (int, bool) {
+	for position := l.VisualCaret(caret) + 1; position <= len(l.levels); position++ {
+		if right := l.LogicalCaret(position); l.VisualCaret(right) == position {
+			return right, true
+		}
+	}
+	return caret, false
+}
+
+// reorder computes the display order of the runes (rule L2), reversing the
+// runes at each level or higher, from the highest level down to the lowest
+// odd level.
+func (l *Line) reorder() {
+	n := len(l.levels)
+	l.order = make([]int, n)
+	l.visual = make([]int, n)
+	highest, lowestOdd := uint8(0), uint8(255)
+	for i, level := range l.levels {
+		l.order[i] = i
+		highest = max(highest, level)
+		if level&1 != 0 {
+			lowestOdd = min(lowestOdd, level)
+		}
+	}
+
+	for level := highest; level >= lowestOdd && level > 0; level-- {
+		for start := 0; start < n; {
+			if l.levels[l.order[start]] < level {
+				start++
+				continue
+			}
+			end := start
+			for end < n && l.levels[l.order[end]] >= level {
+				end++
+			}
+			for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+				l.order[i], l.order[j] = l.order[j], l.order[i]
+			}
+			start = end
+		}
+	}
+
+	for v, i := range l.order {
+		l.visual[i] = v
+	}
+}
+
+// paragraphLevel returns the level of the first strong character (rules P2
+// and P3).
+func paragraphLevel(classes []unicodeBidi.Class) uint8 {
+	for _, c := range classes {
+		switch c {
+		case unicodeBidi.L:
+			return 0
+		case unicodeBidi.R, unicodeBidi.AL:
+			return 1
+		}
+	}
+	return 0
+}
+
+// resolveLevels resolves the weak and neutral types (rules W1 to W7 and N0 to
+// N2) and the implicit levels (rules I1 and I2) of a single level run at the
+// paragraph level, then resets the separators and trailing whitespace (rule
+// L1).
+func resolveLevels(runes []rune, original []unicodeBidi.Class, level uint8) []uint8 {
+	n := len(original)
+	classes := make([]unicodeBidi.Class, n)
+	for i, c := range original {
+		if isFormatting(c) {
+			c = unicodeBidi.ON
+		}
+		classes[i] = c
+	}
+	sos := unicodeBidi.L
+	if level&1 != 0 {
+		sos = unicodeBidi.R
+	}
+
+	// W1: marks take the type of the character before them.
+	for i, c := range classes {
+		if c == unicodeBidi.NSM {
+			if i == 0 {
+				classes[i] = sos
+			} else {
+				classes[i] = classes[i-1]
+			}
+		}
+	}
+
+	// W2 and W3: European numbers after Arabic letters are Arabic numbers,
+	// and Arabic letters are right to left.
+	strong := sos
+	for i, c := range classes {
+		switch c {
+		case unicodeBidi.L, unicodeBidi.R, unicodeBidi.AL:
+			strong = c
+		case unicodeBidi.EN:
+			if strong == unicodeBidi.AL {
+				classes[i] = unicodeBidi.AN
+			}
+		}
+	}
+	for i, c := range classes {
+		if c == unicodeBidi.AL {
+			classes[i] = unicodeBidi.R
+		}
+	}
+
+	// W4: a single separator between two numbers of the same type joins them.
+	for i := 1; i < n-1; i++ {
+		before, after := classes[i-1], classes[i+1]
+		switch classes[i] {
+		case unicodeBidi.ES:
+			if before == unicodeBidi.EN && after == unicodeBidi.EN {
+				classes[i] = unicodeBidi.EN
+			}
+		case unicodeBidi.CS:
+			if before == after && (before == unicodeBidi.EN || before == unicodeBidi.AN) {
+				classes[i] = before
+			}
+		}
+	}
+
+	// W5: terminators next to European numbers are European numbers.
+	for i := 0; i < n; {
+		if classes[i] != unicodeBidi.ET {
+			i++
+			continue
+		}
+		end := i
+		for end < n && classes[end] == unicodeBidi.ET {
+			end++
+		}
+		if (i > 0 && classes[i-1] == unicodeBidi.EN) || (end < n && classes[end] == unicodeBidi.EN) {
+			for j := i; j < end; j++ {
+				classes[j] = unicodeBidi.EN
+			}
+		}
+		i = end
+	}
+
+	// W6: the remaining separators and terminators are neutral.
+	for i, c := range classes {
+		switch c {
+		case unicodeBidi.ES, unicodeBidi.ET, unicodeBidi.CS:
+			classes[i] = unicodeBidi.ON
+		}
+	}
+
+	// W7: European numbers after left to right text are left to right.
+	strong = sos
+	for i, c := range classes {
+		switch c {
+		case unicodeBidi.L, unicodeBidi.R:
+			strong = c
+		case unicodeBidi.EN:
+			if strong == unicodeBidi.L {
+				classes[i] = unicodeBidi.L
+			}
+		}
+	}
+
+	// N0: paired brackets take the direction of the text between them.
+	for _, pair := range bracketPairs(runes, classes) {
+		resolveBrackets(classes, original, pair, sos)
+	}
+
+	// N1 and N2: neutrals between characters of the same direction take that
+	// direction, numbers counting as right to left, and other neutrals take
+	// the direction of the paragraph.
+	for i := 0; i < n; {
+		if !isNeutral(classes[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < n && isNeutral(classes[end]) {
+			end++
+		}
+		before, after := sos, sos
+		if i > 0 {
+			before = strongDirection(classes[i-1])
+		}
+		if end < n {
+			after = strongDirection(classes[end])
+		}
+		direction := sos
+		if before == after {
+			direction = before
+		}
+		for j := i; j < end; j++ {
+			classes[j] = direction
+		}
+		i = end
+	}
+
+	// I1 and I2: the implicit levels.
+	levels := make([]uint8, n)
+	for i, c := range classes {
+		levels[i] = level
+		switch {
+		case level&1 == 0 && c == unicodeBidi.R:
+			levels[i]++
+		case level&1 == 0 && (c == unicodeBidi.AN || c == unicodeBidi.EN):
+			levels[i] += 2
+		case level&1 != 0 && (c == unicodeBidi.L || c == unicodeBidi.AN || c == unicodeBidi.EN):
+			levels[i]++
+		}
+	}
+
+	// L1: separators, and the whitespace before them or at the end of the
+	// line, are at the paragraph level.
+	trailing := true
+	for i := n - 1; i >= 0; i-- {
+		switch c := original[i]; {
+		case c == unicodeBidi.B || c == unicodeBidi.S:
+			levels[i] = level
+			trailing = true
+		case trailing && (c == unicodeBidi.WS || c == unicodeBidi.BN || isFormatting(c)):
+			levels[i] = level
+		default:
+			trailing = false
+		}
+	}
+	return levels
+}
+
+// maxBracketDepth is the number of brackets left open before pairing stops
+// (rule BD16).
+const maxBracketDepth = 63
+
+// bracketPairs returns the positions of the paired brackets of the runes that
+// are neutral, sorted by opening bracket (rule BD16).
+func bracketPairs(runes []rune, classes []unicodeBidi.Class) [][2]int {
+	type opening struct {
+		closing  rune
+		position int
+	}
+	var stack []opening
+	var pairs [][2]int
+	for i, r := range runes {
+		properties, _ := unicodeBidi.LookupRune(r)
+		if classes[i] != unicodeBidi.ON || !properties.IsBracket() {
+			continue
+		}
+		if properties.IsOpeningBracket() {
+			if len(stack) == maxBracketDepth {
+				break
+			}
+			stack = append(stack, opening{closing: Mirror(r), position: i})
+			continue
+		}
+		for j := len(stack) - 1; j >= 0; j-- {
+			if stack[j].closing == r {
+				pairs = append(pairs, [2]int{stack[j].position, i})
+				stack = stack[:j]
+				break
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// resolveBrackets resolves a pair of brackets to the direction of the
+// paragraph if the text between them has any of that direction, or else to
+// the opposite direction if the text between them and the text before them
+// have it. Marks after the brackets take their direction.
+func resolveBrackets(classes, original []unicodeBidi.Class, pair [2]int, sos unicodeBidi.Class) {
+	opposite := unicodeBidi.L
+	if sos == unicodeBidi.L {
+		opposite = unicodeBidi.R
+	}
+
+	direction := unicodeBidi.ON
+	for i := pair[0] + 1; i < pair[1]; i++ {
+		if c := classes[i]; !isNeutral(c) {
+			if strongDirection(c) == sos {
+				direction = sos
+				break
+			}
+			direction = opposite
+		}
+	}
+	if direction == unicodeBidi.ON {
+		return
+	}
+	if direction == opposite {
+		before := sos
+		for i := pair[0] - 1; i >= 0; i-- {
+			if c := classes[i]; !isNeutral(c) {
+				before = strongDirection(c)
+				break
+			}
+		}
+		if before != opposite {
+			direction = sos
+		}
+	}
+
+	for _, position := range pair {
+		classes[position] = direction
+		for i := position + 1; i < len(classes) && original[i] == unicodeBidi.NSM; i++ {
+			classes[i] = direction
+		}
+	}
+}
+
+func isFormatting(c unicodeBidi.Class) bool {
+	return c >= unicodeBidi.Control
+}
+
+func isNeutral(c unicodeBidi.Class) bool {
+	switch c {
+	case unicodeBidi.B, unicodeBidi.S, unicodeBidi.WS, unicodeBidi.ON, unicodeBidi.BN:
+		return true
+	}
+	return false
+}
+
+// strongDirection returns the direction of a resolved type for rule N1.
+func strongDirection(c unicodeBidi.Class) unicodeBidi.Class {
+	if c == unicodeBidi.L {
+		return unicodeBidi.L
+	}
+	return unicodeBidi.R
+}
+
+// mirrors holds the paired punctuation displayed mirrored right to left.
+var mirrors = map[rune]rune{
+	'(': ')', ')': '(',
+	'<': '>', '>': '<',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'«': '»', '»': '«',
+	'‹': '›', '›': '‹',
+	'⁅': '⁆', '⁆': '⁅',
+	'⁽': '⁾', '⁾': '⁽',
+	'₍': '₎', '₎': '₍',
+	'≤': '≥', '≥': '≤',
+	'〈': '〉', '〉': '〈',
+	'《': '》', '》': '《',
+	'「': '」', '」': '「',
+	'『': '』', '』': '『',
+	'【': '】', '】': '【',
+}
+
+// Mirror returns the rune displayed in place of r in right to left text (rule
+// L4), which is r itself unless it is paired punctuation.
+func Mirror(r rune) rune {
+	if m, found := mirrors[r]; found {
+		return m
+	}
+	return r
+}
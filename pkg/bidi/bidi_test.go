@@ -0,0 +1,115 @@
+package bidi
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/test_helper"
+)
+
+// display returns the runes of the text in visual order, mirrored right to left.
+func display(text string, direction Direction) string {
+	runes := []rune(text)
+	l := NewLine(runes, direction)
+	result := make([]rune, len(runes))
+	for v := range result {
+		i := l.Logical(v)
+		result[v] = runes[i]
+		if l.Level(i)&1 != 0 {
+			result[v] = Mirror(runes[i])
+		}
+	}
+	return string(result)
+}
+
+func TestLineOrder(t *testing.T) {
+	for _, test := range []struct {
+		text      string
+		direction Direction
+		expected  string
+	}{
+		{"abc", Auto, "abc"},
+		{"אבג", Auto, "גבא"},
+		{"abc אבג def", Auto, "abc גבא def"},
+		{"אבג abc דהו", Auto, "והד abc גבא"},
+		{"אבג 123 דהו", Auto, "והד 123 גבא"},
+		{"abc אבג 123", Auto, "abc 123 גבא"},
+		{"(אבג)", Auto, "(גבא)"},
+		{"abc אב (גד) ef", Auto, "abc (דג) בא ef"},
+		{"אב (cd)", Auto, "(cd) בא"},
+		{"abc (אב)", Auto, "abc (בא)"},
+		{"אב [cd] (ef", Auto, "ef) [cd] בא"},
+		{"אבג ", Auto, " גבא"},
+		{"abc", RightToLeft, "abc"},
+		{"abc אבג", RightToLeft, "גבא abc"},
+		{"אבג!", LeftToRight, "גבא!"},
+		{"אבג!", Auto, "!גבא"},
+		{"!?", RightToLeft, "?!"},
+		{"سلام 12", Auto, "12 مالس"},
+	} {
+		test_helper.AssertEquals(t, test.expected, display(test.text, test.direction))
+	}
+}
+
+func TestLineDirection(t *testing.T) {
+	test_helper.AssertEquals(t, false, NewLine([]rune("123 abc אבג"), Auto).RightToLeft())
+	test_helper.AssertEquals(t, true, NewLine([]rune("123 אבג abc"), Auto).RightToLeft())
+	test_helper.AssertEquals(t, false, NewLine([]rune("123"), Auto).RightToLeft())
+	test_helper.AssertEquals(t, true, NewLine([]rune("abc"), RightToLeft).RightToLeft())
+}
+
+func TestLineRuns(t *testing.T) {
+	l := NewLine([]rune("ab אבג cd"), Auto)
+	test_helper.AssertEquals(t, []Run{{0, 3, 0}, {3, 6, 1}, {6, 9, 0}}, l.Runs())
+
+	l = NewLine([]rune("אב cd הו"), Auto)
+	test_helper.AssertEquals(t, []Run{{5, 8, 1}, {3, 5, 2}, {0, 3, 1}}, l.Runs())
+	test_helper.AssertEquals(t, true, l.Runs()[0].RightToLeft())
+	test_helper.AssertEquals(t, false, l.Runs()[1].RightToLeft())
+}
+
+func TestLineCarets(t *testing.T) {
+	for _, text := range []string{"", "abc", "אבג", "ab אב", "אב cd הו 12", "אבג 12 34 def"} {
+		l := NewLine([]rune(text), Auto)
+		for caret := 0; caret <= l.Len(); caret++ {
+			test_helper.AssertEquals(t, caret, l.LogicalCaret(l.VisualCaret(caret)))
+		}
+	}
+
+	l := NewLine([]rune("ab אב"), Auto)
+	test_helper.AssertEquals(t, 0, l.VisualCaret(0))
+	test_helper.AssertEquals(t, 5, l.VisualCaret(3))
+	test_helper.AssertEquals(t, 3, l.VisualCaret(5))
+	test_helper.AssertEquals(t, 5, l.LogicalCaret(3))
+
+	l = NewLine([]rune("אבג"), Auto)
+	test_helper.AssertEquals(t, 3, l.VisualCaret(0))
+	test_helper.AssertEquals(t, 0, l.VisualCaret(3))
+}
+
+func TestLineCaretMovement(t *testing.T) {
+	// Visits the carets of the line from left to right and back.
+	visit := func(text string) (rightwards, leftwards []int) {
+		l := NewLine([]rune(text), Auto)
+		caret, moved := l.LogicalCaret(0), true
+		for moved {
+			rightwards = append(rightwards, caret)
+			caret, moved = l.CaretRight(caret)
+		}
+		for moved = true; moved; caret, moved = l.CaretLeft(caret) {
+			leftwards = append(leftwards, caret)
+		}
+		return rightwards, leftwards
+	}
+
+	right, left := visit("ab אב")
+	test_helper.AssertEquals(t, []int{0, 1, 2, 5, 4, 3}, right)
+	test_helper.AssertEquals(t, []int{3, 4, 5, 2, 1, 0}, left)
+
+	right, left = visit("אבג")
+	test_helper.AssertEquals(t, []int{3, 2, 1, 0}, right)
+	test_helper.AssertEquals(t, []int{0, 1, 2, 3}, left)
+
+	right, left = visit("אב cd הו 12")
+	test_helper.AssertEquals(t, []int{9, 10, 11, 8, 7, 6, 3, 4, 5, 2, 1, 0}, right)
+	test_helper.AssertEquals(t, []int{0, 1, 2, 5, 4, 3, 6, 7, 8, 11, 10, 9}, left)
+}
@@ -0,0 +1,167 @@
+package font
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fontconfig holds the elements of a fontconfig configuration file read by
+// the registry. Match and select rules are ignored.
+type fontconfig struct {
+	Dirs     []configPath  `xml:"dir"`
+	Includes []configPath  `xml:"include"`
+	Aliases  []configAlias `xml:"alias"`
+}
+
+type configPath struct {
+	Prefix string `xml:"prefix,attr"`
+	Path   string `xml:",chardata"`
+}
+
+type configAlias struct {
+	Family  string   `xml:"family"`
+	Prefer  []string `xml:"prefer>family"`
+	Accept  []string `xml:"accept>family"`
+	Default []string `xml:"default>family"`
+}
+
+// ScanConfig reads the fontconfig configuration file at path and the files it
+// includes, indexing the fonts of the directories they list and adding the
+// family aliases they define. An included directory has its .conf files read
+// in name order, as fontconfig does, and missing includes are skipped. The
+// errors of included files are returned once all of them have been read.
+func (r *Registry) ScanConfig(path string) error {
+	path = filepath.Clean(path)
+	if r.configs[path] {
+		return nil
+	}
+	r.configs[path] = true
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config fontconfig
+	if err := xml.Unmarshal(data, &config); err != nil {
+		return &os.PathError{Op: "parse", Path: path, Err: err}
+	}
+
+	var errs []error
+	dir := filepath.Dir(path)
+	for _, d := range config.Dirs {
+		if err := r.ScanDir(resolveConfigPath(d, dir, dataHome())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	for _, a := range config.Aliases {
+		family := strings.TrimSpace(a.Family)
+		if family == "" {
+			continue
+		}
+		alias := r.alias(family)
+		alias.prefer = append(alias.prefer, trimAll(a.Prefer)...)
+		alias.accept = append(alias.accept, trimAll(a.Accept)...)
+		alias.fallback = append(alias.fallback, trimAll(a.Default)...)
+	}
+	for _, include := range config.Includes {
+		if err := r.include(resolveConfigPath(include, dir, configHome())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// include reads the configuration file at path, or the .conf files of the
+// directory at path.
+func (r *Registry) include(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return r.ScanConfig(path)
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*.conf"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	var errs []error
+	for _, file := range files {
+		if err := r.ScanConfig(file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveConfigPath returns the path of a dir or include element of the file
+// in dir. Paths with the xdg prefix are relative to xdg, paths starting with ~
+// are relative to the home directory, and other relative paths are relative to
+// the configuration file.
+func resolveConfigPath(p configPath, dir, xdg string) string {
+	path := strings.TrimSpace(p.Path)
+	switch {
+	case p.Prefix == "xdg":
+		return filepath.Join(xdg, path)
+	case path == "~" || strings.HasPrefix(path, "~/"):
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	case p.Prefix == "cwd" || p.Prefix == "default":
+		return path
+	case !filepath.IsAbs(path):
+		return filepath.Join(dir, path)
+	}
+	return path
+}
+
+func trimAll(names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// systemConfigs returns the fontconfig configuration files of the system and of
+// the user, the system file being overridden by $FONTCONFIG_FILE.
+func systemConfigs() []string {
+	system := os.Getenv("FONTCONFIG_FILE")
+	if system == "" {
+		system = "/etc/fonts/fonts.conf"
+	}
+	configs := []string{system}
+	if config := configHome(); config != "" {
+		configs = append(configs, filepath.Join(config, "fontconfig", "fonts.conf"))
+	}
+	return configs
+}
+
+// dataHome returns $XDG_DATA_HOME, by default ~/.local/share.
+func dataHome() string {
+	return xdgHome("XDG_DATA_HOME", ".local/share")
+}
+
+// configHome returns $XDG_CONFIG_HOME, by default ~/.config.
+func configHome() string {
+	return xdgHome("XDG_CONFIG_HOME", ".config")
+}
+
+func xdgHome(variable, fallback string) string {
+	if dir := os.Getenv(variable); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, fallback)
+}
@@ -0,0 +1,373 @@
+package font
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/badu/gxui"
+)
+
+// Weight is the weight of a font face, from 100 (thin) to 900 (black) as in
+// the OS/2 table of the font.
+type Weight int
+
+const (
+	Thin       Weight = 100
+	ExtraLight Weight = 200
+	Light      Weight = 300
+	Regular    Weight = 400
+	Medium     Weight = 500
+	SemiBold   Weight = 600
+	Bold       Weight = 700
+	ExtraBold  Weight = 800
+	Black      Weight = 900
+)
+
+// Stretch is the width of a font face, from 1 (ultra condensed) to 9 (ultra
+// expanded) as in the OS/2 table of the font.
+type Stretch int
+
+const (
+	UltraCondensed Stretch = iota + 1
+	ExtraCondensed
+	Condensed
+	SemiCondensed
+	NormalStretch
+	SemiExpanded
+	Expanded
+	ExtraExpanded
+	UltraExpanded
+)
+
+// Face describes a font face indexed by a Registry.
+type Face struct {
+	// Family is the name of the family of the face, such as "DejaVu Sans", and
+	// Style the name of the face within its family, such as "Bold Oblique".
+	Family  string
+	Style   string
+	Weight  Weight
+	Stretch Stretch
+	// Italic is true for italic and oblique faces.
+	Italic bool
+	// Path is the file the face was read from, empty for faces added from
+	// memory, and Index the index of the face in its font collection.
+	Path  string
+	Index int
+}
+
+// ErrNotFound is returned when no indexed font matches a lookup.
+var ErrNotFound = errors.New("font: no matching font found")
+
+// entry is an indexed face, with the TrueType data of the faces added from
+// memory.
+type entry struct {
+	Face
+	data []byte
+}
+
+type fontKey struct {
+	entry *entry
+	size  int
+}
+
+// aliases are the families tried for a family name, before and after the
+// family itself, as listed by fontconfig alias elements. The embedded families
+// are tried last.
+type aliases struct {
+	prefer, accept, fallback, embedded []string
+}
+
+// Registry indexes the fonts found on the system by family, weight, style and
+// stretch, and creates the fonts looked up with its driver. The fonts embedded
+// in this package are always indexed, and are the defaults of the sans-serif
+// and monospace families.
+type Registry struct {
+	driver   gxui.Driver
+	entries  []*entry
+	families map[string][]*entry
+	paths    map[string]bool
+	configs  map[string]bool
+	aliases  map[string]*aliases
+	fonts    map[fontKey]gxui.Font
+}
+
+// NewRegistry returns a registry creating fonts with driver, indexing the fonts
+// embedded in this package.
+func NewRegistry(driver gxui.Driver) *Registry {
+	r := &Registry{
+		driver:   driver,
+		families: make(map[string][]*entry),
+		paths:    make(map[string]bool),
+		configs:  make(map[string]bool),
+		aliases:  make(map[string]*aliases),
+		fonts:    make(map[fontKey]gxui.Font),
+	}
+	for _, embedded := range []struct {
+		family string
+		data   []byte
+	}{{"sans-serif", Default}, {"monospace", Monospace}} {
+		faces, err := r.Add(embedded.data)
+		if err != nil {
+			panic(err)
+		}
+		r.alias(embedded.family).embedded = []string{faces[0].Family}
+	}
+	return r
+}
+
+// Add indexes the faces of the TrueType or OpenType font, or font collection,
+// data, returning them.
+func (r *Registry) Add(data []byte) ([]Face, error) {
+	faces, err := readFaces(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for _, face := range faces {
+		r.add(&entry{Face: face, data: data})
+	}
+	return faces, nil
+}
+
+// ParseFace returns the face of the TrueType or OpenType font data, such as the
+// data of a gxui.Font.
+func ParseFace(data []byte) (Face, error) {
+	return readFace(bytes.NewReader(data), 0)
+}
+
+func (r *Registry) add(e *entry) {
+	key := strings.ToLower(e.Family)
+	r.entries = append(r.entries, e)
+	r.families[key] = append(r.families[key], e)
+}
+
+// ScanDir indexes the TrueType and OpenType fonts and font collections found in
+// dir and its subdirectories. Other files are skipped, as are files already
+// indexed.
+func (r *Registry) ScanDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() || r.paths[path] {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ttf", ".otf", ".ttc", ".otc":
+		default:
+			return nil
+		}
+		r.paths[path] = true
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+		faces, _ := readFaces(file)
+		for _, face := range faces {
+			face.Path = path
+			r.add(&entry{Face: face})
+		}
+		return nil
+	})
+}
+
+// ScanSystem indexes the fonts of the standard font directories, and reads the
+// fontconfig configuration for the directories and aliases it lists. Missing
+// directories and configuration files are ignored.
+func (r *Registry) ScanSystem() error {
+	var errs []error
+	for _, dir := range systemDirs() {
+		if err := r.ScanDir(dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	for _, path := range systemConfigs() {
+		if err := r.ScanConfig(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Families returns the names of the families indexed, sorted.
+func (r *Registry) Families() []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, e := range r.entries {
+		if !seen[e.Family] {
+			seen[e.Family] = true
+			result = append(result, e.Family)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Faces returns the faces indexed of the family, which is matched ignoring
+// case.
+func (r *Registry) Faces(family string) []Face {
+	entries := r.families[strings.ToLower(family)]
+	result := make([]Face, len(entries))
+	for i, e := range entries {
+		result[i] = e.Face
+	}
+	return result
+}
+
+// Match returns the face closest to the weight and slope, of normal stretch
+// where possible, of the first family found among the aliases preferred to the
+// family, the family itself and its other aliases.
+func (r *Registry) Match(family string, weight Weight, italic bool) (Face, bool) {
+	if e := r.match(family, weight, italic); e != nil {
+		return e.Face, true
+	}
+	return Face{}, false
+}
+
+func (r *Registry) match(family string, weight Weight, italic bool) *entry {
+	for _, name := range r.candidates(family, make(map[string]bool)) {
+		entries := r.families[name]
+		if len(entries) == 0 {
+			continue
+		}
+		best := entries[0]
+		for _, e := range entries[1:] {
+			if better(e.Face, best.Face, weight, italic) {
+				best = e
+			}
+		}
+		return best
+	}
+	return nil
+}
+
+// candidates returns the families tried for family, in order, expanding the
+// aliases of aliases. Families already visited are skipped.
+func (r *Registry) candidates(family string, visited map[string]bool) []string {
+	key := strings.ToLower(family)
+	if visited[key] {
+		return nil
+	}
+	visited[key] = true
+
+	a := r.aliases[key]
+	if a == nil {
+		return []string{key}
+	}
+	var result []string
+	for _, name := range a.prefer {
+		result = append(result, r.candidates(name, visited)...)
+	}
+	result = append(result, key)
+	for _, list := range [][]string{a.accept, a.fallback, a.embedded} {
+		for _, name := range list {
+			result = append(result, r.candidates(name, visited)...)
+		}
+	}
+	return result
+}
+
+// better returns true if face a is closer than face b to the weight and slope.
+// The stretch is matched first, then the slope, then the weight.
+func better(a, b Face, weight Weight, italic bool) bool {
+	if da, db := stretchDistance(a.Stretch), stretchDistance(b.Stretch); da != db {
+		return da < db
+	}
+	if (a.Italic == italic) != (b.Italic == italic) {
+		return a.Italic == italic
+	}
+	return weightDistance(a.Weight, weight) < weightDistance(b.Weight, weight)
+}
+
+func stretchDistance(s Stretch) int {
+	if s < NormalStretch {
+		return int(NormalStretch-s) * 2
+	}
+	// Wider faces are preferred to narrower ones at the same distance.
+	return int(s-NormalStretch)*2 - 1
+}
+
+// weightDistance returns how far weight w is from the wanted weight. As in CSS,
+// lighter faces are preferred for light weights and heavier ones for bold
+// weights, and regular and medium faces are preferred to each other.
+func weightDistance(w, wanted Weight) int {
+	distance := int(w - wanted)
+	switch {
+	case wanted >= Regular && wanted <= Medium && w >= Regular && w <= Medium:
+		if distance < 0 {
+			distance = -distance
+		}
+		return distance
+	case wanted <= Medium:
+		if distance <= 0 {
+			return -distance * 2
+		}
+		return distance*2 + 1000
+	default:
+		if distance >= 0 {
+			return distance * 2
+		}
+		return -distance*2 + 1000
+	}
+}
+
+// LookupFont returns the font of the size closest to the weight and slope in
+// the family, as matched by Match, created by the driver of the registry. The
+// fonts created are kept, and returned by later lookups of the same face and
+// size.
+func (r *Registry) LookupFont(family string, weight Weight, italic bool, size int) (gxui.Font, error) {
+	e := r.match(family, weight, italic)
+	if e == nil {
+		return nil, fmt.Errorf("%w for family %q", ErrNotFound, family)
+	}
+	key := fontKey{entry: e, size: size}
+	if font, found := r.fonts[key]; found {
+		return font, nil
+	}
+
+	data := e.data
+	if data == nil {
+		var err error
+		if data, err = os.ReadFile(e.Path); err != nil {
+			return nil, err
+		}
+	}
+	font, err := r.driver.CreateCollectionFont(data, e.Index, size)
+	if err != nil {
+		return nil, fmt.Errorf("font: loading %s %s: %w", e.Family, e.Style, err)
+	}
+	r.fonts[key] = font
+	return font, nil
+}
+
+func (r *Registry) alias(family string) *aliases {
+	key := strings.ToLower(family)
+	a, found := r.aliases[key]
+	if !found {
+		a = &aliases{}
+		r.aliases[key] = a
+	}
+	return a
+}
+
+// systemDirs returns the standard font directories of Linux systems.
+func systemDirs() []string {
+	dirs := []string{"/usr/share/fonts", "/usr/local/share/fonts"}
+	if data := dataHome(); data != "" {
+		dirs = append(dirs, filepath.Join(data, "fonts"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".fonts"))
+	}
+	return dirs
+}
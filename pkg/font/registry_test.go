@@ -0,0 +1,117 @@
+package font
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/badu/gxui/drivers/soft"
+	"github.com/badu/gxui/test_helper"
+)
+
+func TestReadFace(t *testing.T) {
+	registry := NewRegistry(nil)
+	test_helper.AssertEquals(t, []string{"Droid Sans Mono", "Roboto"}, registry.Families())
+	test_helper.AssertEquals(t, []Face{{Family: "Roboto", Style: "Regular", Weight: Regular, Stretch: NormalStretch}}, registry.Faces("roboto"))
+}
+
+func TestRegistryMatch(t *testing.T) {
+	registry := NewRegistry(nil)
+	for _, face := range []Face{
+		{Family: "Test", Style: "Light", Weight: Light, Stretch: NormalStretch},
+		{Family: "Test", Style: "Regular", Weight: Regular, Stretch: NormalStretch},
+		{Family: "Test", Style: "Bold", Weight: Bold, Stretch: NormalStretch},
+		{Family: "Test", Style: "Italic", Weight: Regular, Stretch: NormalStretch, Italic: true},
+		{Family: "Test", Style: "Condensed Black", Weight: Black, Stretch: Condensed},
+	} {
+		registry.add(&entry{Face: face})
+	}
+
+	style := func(family string, weight Weight, italic bool) string {
+		face, found := registry.Match(family, weight, italic)
+		if !found {
+			return ""
+		}
+		return face.Style
+	}
+	test_helper.AssertEquals(t, "Regular", style("test", Regular, false))
+	test_helper.AssertEquals(t, "Regular", style("Test", Medium, false))
+	test_helper.AssertEquals(t, "Light", style("Test", Thin, false))
+	test_helper.AssertEquals(t, "Bold", style("Test", Black, false))
+	test_helper.AssertEquals(t, "Bold", style("Test", SemiBold, false))
+	test_helper.AssertEquals(t, "Italic", style("Test", Bold, true))
+	test_helper.AssertEquals(t, "", style("Missing", Regular, false))
+
+	// The generic families default to the embedded fonts.
+	test_helper.AssertEquals(t, "Regular", style("sans-serif", Regular, false))
+	face, _ := registry.Match("monospace", Regular, false)
+	test_helper.AssertEquals(t, "Droid Sans Mono", face.Family)
+}
+
+func TestRegistryScanConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path string, data []byte) {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("fonts/mono/mono.ttf", Monospace)
+	write("fonts/readme.txt", []byte("not a font"))
+	write("fonts.conf", []byte(`<?xml version="1.0"?>
+<!DOCTYPE fontconfig SYSTEM "urn:fontconfig:fonts.dtd">
+<fontconfig>
+	<dir>fonts</dir>
+	<include ignore_missing="yes">missing.conf</include>
+	<include>conf.d</include>
+</fontconfig>`))
+	write("conf.d/50-code.conf", []byte(`<fontconfig>
+	<alias>
+		<family>code</family>
+		<prefer><family>Missing Mono</family><family>Droid Sans Mono</family></prefer>
+		<default><family>sans-serif</family></default>
+	</alias>
+</fontconfig>`))
+
+	registry := NewRegistry(nil)
+	if err := registry.ScanConfig(filepath.Join(dir, "fonts.conf")); err != nil {
+		t.Fatal(err)
+	}
+	faces := registry.Faces("Droid Sans Mono")
+	test_helper.AssertEquals(t, 2, len(faces))
+	test_helper.AssertEquals(t, filepath.Join(dir, "fonts/mono/mono.ttf"), faces[1].Path)
+
+	face, _ := registry.Match("code", Regular, false)
+	test_helper.AssertEquals(t, "Droid Sans Mono", face.Family)
+
+	write("broken.conf", []byte("<fontconfig>"))
+	if err := registry.ScanConfig(filepath.Join(dir, "broken.conf")); err == nil {
+		t.Errorf("Expected an error reading a broken configuration")
+	}
+}
+
+func TestRegistryLookupFont(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	registry := NewRegistry(driver)
+	font, err := registry.LookupFont("monospace", Regular, false, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, "Droid Sans Mono", font.Name())
+	test_helper.AssertEquals(t, 16, font.Size())
+
+	again, _ := registry.LookupFont("Droid Sans Mono", Bold, false, 16)
+	if again != font {
+		t.Errorf("Expected the font to be created once")
+	}
+
+	if _, err := registry.LookupFont("Missing", Regular, false, 16); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
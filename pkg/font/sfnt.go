@@ -0,0 +1,184 @@
+package font
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+)
+
+// Versions of the sfnt fonts, and the tag of font collections.
+const (
+	trueTypeVersion = 0x00010000
+	appleVersion    = 0x74727565 // "true"
+	openTypeVersion = 0x4f54544f // "OTTO"
+	collectionTag   = 0x74746366 // "ttcf"
+)
+
+// IDs of the strings of the name table.
+const (
+	familyNameID            = 1
+	subfamilyNameID         = 2
+	typographicFamilyNameID = 16
+	typographicSubfamilyID  = 17
+)
+
+var errNotSfnt = errors.New("font: not a TrueType or OpenType font")
+
+// Largest number of fonts read from a font collection.
+const maxCollectionFonts = 256
+
+// readFaces reads the faces of the TrueType or OpenType font, or font
+// collection, read by r.
+func readFaces(r io.ReaderAt) ([]Face, error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint32(header) != collectionTag {
+		face, err := readFace(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []Face{face}, nil
+	}
+
+	count := int(binary.BigEndian.Uint32(header[8:]))
+	if count > maxCollectionFonts {
+		return nil, errors.New("font: too many fonts in the collection")
+	}
+	offsets := make([]byte, 4*count)
+	if _, err := r.ReadAt(offsets, 12); err != nil {
+		return nil, err
+	}
+	faces := make([]Face, 0, count)
+	for i := 0; i < count; i++ {
+		face, err := readFace(r, int64(binary.BigEndian.Uint32(offsets[4*i:])))
+		if err != nil {
+			return nil, err
+		}
+		face.Index = i
+		faces = append(faces, face)
+	}
+	return faces, nil
+}
+
+// readFace reads the names, weight, width and slope of the font whose table
+// directory is at offset. Only the table directory and the name, OS/2 and
+// head tables are read.
+func readFace(r io.ReaderAt, offset int64) (Face, error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return Face{}, err
+	}
+	switch binary.BigEndian.Uint32(header) {
+	case trueTypeVersion, appleVersion, openTypeVersion:
+	default:
+		return Face{}, errNotSfnt
+	}
+
+	count := int(binary.BigEndian.Uint16(header[4:]))
+	directory := make([]byte, 16*count)
+	if _, err := r.ReadAt(directory, offset+12); err != nil {
+		return Face{}, err
+	}
+	tables := make(map[string][]byte)
+	for i := 0; i < count; i++ {
+		record := directory[16*i:]
+		tag := string(record[:4])
+		if tag != "name" && tag != "OS/2" && tag != "head" {
+			continue
+		}
+		start, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+		if length > 1<<20 {
+			return Face{}, errors.New("font: " + tag + " table is too large")
+		}
+		table := make([]byte, length)
+		if _, err := r.ReadAt(table, int64(start)); err != nil {
+			return Face{}, err
+		}
+		tables[tag] = table
+	}
+
+	names := readNames(tables["name"])
+	face := Face{
+		Family:  names[typographicFamilyNameID],
+		Style:   names[typographicSubfamilyID],
+		Weight:  Regular,
+		Stretch: NormalStretch,
+	}
+	if face.Family == "" {
+		face.Family, face.Style = names[familyNameID], names[subfamilyNameID]
+	}
+	if face.Family == "" {
+		return Face{}, errors.New("font: the font has no family name")
+	}
+
+	if os2 := tables["OS/2"]; len(os2) >= 64 {
+		if weight := Weight(binary.BigEndian.Uint16(os2[4:])); weight >= Thin && weight <= 1000 {
+			face.Weight = weight
+		}
+		if stretch := Stretch(binary.BigEndian.Uint16(os2[6:])); stretch >= UltraCondensed && stretch <= UltraExpanded {
+			face.Stretch = stretch
+		}
+		// The italic and oblique bits of fsSelection.
+		face.Italic = binary.BigEndian.Uint16(os2[62:])&0x0201 != 0
+	} else if head := tables["head"]; len(head) >= 46 {
+		macStyle := binary.BigEndian.Uint16(head[44:])
+		if macStyle&0x1 != 0 {
+			face.Weight = Bold
+		}
+		face.Italic = macStyle&0x2 != 0
+	}
+	return face, nil
+}
+
+// readNames returns the strings of the name table by ID, preferring the
+// English Windows strings to the Unicode and Macintosh ones.
+func readNames(table []byte) map[int]string {
+	names := make(map[int]string)
+	if len(table) < 6 {
+		return names
+	}
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	storage := int(binary.BigEndian.Uint16(table[4:]))
+	ranks := make(map[int]int)
+	for i := 0; i < count && 6+12*(i+1) <= len(table); i++ {
+		record := table[6+12*i:]
+		platform, encoding := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[2:])
+		language, id := binary.BigEndian.Uint16(record[4:]), int(binary.BigEndian.Uint16(record[6:]))
+		length, offset := int(binary.BigEndian.Uint16(record[8:])), int(binary.BigEndian.Uint16(record[10:]))
+		start := storage + offset
+		if start+length > len(table) {
+			continue
+		}
+
+		var rank int
+		switch {
+		case platform == 3 && (encoding == 1 || encoding == 10) && language == 0x409:
+			rank = 3
+		case platform == 0:
+			rank = 2
+		case platform == 1 && encoding == 0 && language == 0:
+			rank = 1
+		default:
+			continue
+		}
+		if rank <= ranks[id] {
+			continue
+		}
+
+		data := table[start : start+length]
+		if platform == 1 {
+			names[id] = string(data)
+		} else {
+			units := make([]uint16, len(data)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(data[2*j:])
+			}
+			names[id] = string(utf16.Decode(units))
+		}
+		ranks[id] = rank
+	}
+	return names
+}
@@ -0,0 +1,244 @@
+package geometry
+
+import (
+	"sort"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// ring is a closed contour of a filled path.
+type ring struct {
+	points []math.Vec2
+	area   float32
+}
+
+// contains returns true if p is inside the ring.
+func (r ring) contains(p math.Vec2) bool {
+	inside := false
+	for i, a := range r.points {
+		b := r.points[(i+1)%len(r.points)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// FillPolygons returns simple polygons, clockwise on screen, covering the
+// areas of the contours filled with the rule. The contours are closed, and must
+// not cross each other or themselves. Holes are joined to the polygon around
+// them by a bridge, so that the polygons can be triangulated.
+func FillPolygons(contours []gxui.PathContour, rule gxui.FillRule) [][]math.Vec2 {
+	var rings []ring
+	for _, c := range contours {
+		points := pruneEdgeDuplicates(c.Points)
+		if len(points) > 1 && points[0].Sub(points[len(points)-1]).Len() <= 0.0001 {
+			points = points[:len(points)-1]
+		}
+		if len(points) < 3 {
+			continue
+		}
+		if area := SignedArea(points); math32.Abs(area) > Epsilon {
+			rings = append(rings, ring{points: points, area: area})
+		}
+	}
+
+	// As the rings do not cross, a ring is either inside or outside another,
+	// and any of its points tells which. The winding just outside a ring is
+	// the sum of the windings of the rings containing it.
+	parents := make([][]int, len(rings))
+	filled := func(i int, inside bool) bool {
+		count, winding := 0, 0
+		for _, j := range append(parents[i], i) {
+			if j == i && !inside {
+				continue
+			}
+			count++
+			if rings[j].area > 0 {
+				winding++
+			} else {
+				winding--
+			}
+		}
+		if rule == gxui.EvenOdd {
+			return count%2 == 1
+		}
+		return winding != 0
+	}
+	for i := range rings {
+		for j := range rings {
+			if i != j && math32.Abs(rings[j].area) > math32.Abs(rings[i].area) && rings[j].contains(rings[i].points[0]) {
+				parents[i] = append(parents[i], j)
+			}
+		}
+	}
+
+	// Only the rings between a filled and an empty area are edges of the
+	// fill. The edges with the fill inside are outlines, the others are holes
+	// in the smallest outline around them.
+	outlines := map[int][]int{}
+	var order []int
+	for i := range rings {
+		in, out := filled(i, true), filled(i, false)
+		switch {
+		case in && !out:
+			if _, found := outlines[i]; !found {
+				outlines[i] = nil
+				order = append(order, i)
+			}
+		case out && !in:
+			parent := -1
+			for _, j := range parents[i] {
+				if filled(j, true) != filled(j, false) && (parent < 0 || math32.Abs(rings[j].area) < math32.Abs(rings[parent].area)) {
+					parent = j
+				}
+			}
+			if parent >= 0 {
+				if _, found := outlines[parent]; !found {
+					order = append(order, parent)
+				}
+				outlines[parent] = append(outlines[parent], i)
+			}
+		}
+	}
+
+	var result [][]math.Vec2
+	for _, i := range order {
+		polygon := oriented(rings[i], true)
+		holes := outlines[i]
+		// Holes are bridged from their rightmost point, rightmost first, so
+		// that the bridges never cross.
+		right := func(h int) float32 {
+			x := rings[h].points[0].X
+			for _, p := range rings[h].points {
+				x = max(x, p.X)
+			}
+			return x
+		}
+		sort.SliceStable(holes, func(a, b int) bool { return right(holes[a]) > right(holes[b]) })
+		for _, h := range holes {
+			polygon = bridge(polygon, oriented(rings[h], false))
+		}
+		result = append(result, polygon)
+	}
+	return result
+}
+
+// oriented returns the points of the ring, clockwise on screen if clockwise is
+// true, or counter-clockwise otherwise.
+func oriented(r ring, clockwise bool) []math.Vec2 {
+	if (r.area > 0) == clockwise {
+		return r.points
+	}
+	result := make([]math.Vec2, len(r.points))
+	for i, p := range r.points {
+		result[len(result)-1-i] = p
+	}
+	return result
+}
+
+// bridge joins the hole to the outline around it, with an edge going from the
+// rightmost point of the hole to a point of the outline it can see, and back.
+func bridge(outline, hole []math.Vec2) []math.Vec2 {
+	m := 0
+	for i, p := range hole {
+		if p.X > hole[m].X {
+			m = i
+		}
+	}
+	from := hole[m]
+
+	// Find the closest edge of the outline on the right of the point.
+	edge, closest := -1, float32(0)
+	for i, a := range outline {
+		b := outline[(i+1)%len(outline)]
+		if (a.Y > from.Y) == (b.Y > from.Y) {
+			continue
+		}
+		x := a.X + (from.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+		if x >= from.X && (edge < 0 || x < closest) {
+			edge, closest = i, x
+		}
+	}
+	if edge < 0 {
+		return outline
+	}
+
+	// The end of the edge furthest right is visible, unless other points of
+	// the outline are in the triangle between the ray and that end. In that
+	// case the one closest in angle to the ray is.
+	hit := math.Vec2{X: closest, Y: from.Y}
+	to := edge
+	if next := (edge + 1) % len(outline); outline[next].X > outline[edge].X {
+		to = next
+	}
+	best := float32(-1)
+	for i, p := range outline {
+		if i == to || p.X < from.X || !InTriangle(p, from, hit, outline[to]) || !locallyInside(outline, i, from) {
+			continue
+		}
+		d := p.Sub(from)
+		cos := d.X / d.Len()
+		if cos > best || cos == best && d.Len() < outline[to].Sub(from).Len() {
+			to, best = i, cos
+		}
+	}
+
+	result := make([]math.Vec2, 0, len(outline)+len(hole)+2)
+	result = append(result, outline[:to+1]...)
+	result = append(result, hole[m:]...)
+	result = append(result, hole[:m+1]...)
+	result = append(result, outline[to:]...)
+	return result
+}
+
+// locallyInside returns true if the line from the point i of the clockwise
+// polygon towards p starts inside the polygon. Points visited twice by bridges
+// are only inside on one of the visits.
+func locallyInside(polygon []math.Vec2, i int, p math.Vec2) bool {
+	a, b, c := polygon[(i+len(polygon)-1)%len(polygon)], polygon[i], polygon[(i+1)%len(polygon)]
+	d := p.Sub(b)
+	in, out := b.Sub(a).Cross(d), c.Sub(b).Cross(d)
+	if b.Sub(a).Cross(c.Sub(b)) >= 0 {
+		return in >= 0 && out >= 0
+	}
+	return in >= 0 || out >= 0
+}
+
+// InTriangle returns true if p is inside the triangle abc, or on its edges.
+func InTriangle(p, a, b, c math.Vec2) bool {
+	d0 := b.Sub(a).Cross(p.Sub(a))
+	d1 := c.Sub(b).Cross(p.Sub(b))
+	d2 := a.Sub(c).Cross(p.Sub(c))
+	negative := d0 < 0 || d1 < 0 || d2 < 0
+	positive := d0 > 0 || d1 > 0 || d2 > 0
+	return !(negative && positive)
+}
+
+// FillPathPolygons returns the simple polygons covering the area of the path
+// filled with the rule, as FillPolygons does. Open sub-paths are closed.
+func FillPathPolygons(path *gxui.Path, rule gxui.FillRule) [][]math.Vec2 {
+	return FillPolygons(path.Flatten(gxui.DefaultPathTolerance), rule)
+}
+
+// FillPathTriangles returns the triangles covering the area of the path filled
+// with the rule. Open sub-paths are closed.
+func FillPathTriangles(path *gxui.Path, rule gxui.FillRule) []math.Vec2 {
+	var triangles []math.Vec2
+	for _, polygon := range FillPathPolygons(path, rule) {
+		triangles = append(triangles, Triangulate(polygon)...)
+	}
+	return triangles
+}
+
+// StrokePath returns the triangles covering the stroke of the path, centered
+// on it.
+func StrokePath(path *gxui.Path, pen gxui.Pen) []math.Vec2 {
+	var triangles []math.Vec2
+	for _, c := range path.Flatten(gxui.DefaultPathTolerance) {
+		triangles = Stroke(Polyline{Points: c.Points, Closed: c.Closed}, pen, triangles)
+	}
+	return triangles
+}
@@ -0,0 +1,90 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+// square adds a square sub-path, clockwise on screen unless reversed.
+func square(path *gxui.Path, x, y, size float32, reversed bool) {
+	corners := []math.Vec2{v(x, y), v(x+size, y), v(x+size, y+size), v(x, y+size)}
+	if reversed {
+		corners[1], corners[3] = corners[3], corners[1]
+	}
+	path.MoveTo(corners[0])
+	for _, c := range corners[1:] {
+		path.LineTo(c)
+	}
+	path.Close()
+}
+
+func TestFillPathRules(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		reversed bool
+		rule     gxui.FillRule
+		area     float32
+	}{
+		{"non-zero, same direction", false, gxui.NonZero, 100},
+		{"non-zero, opposite direction", true, gxui.NonZero, 84},
+		{"even-odd, same direction", false, gxui.EvenOdd, 84},
+		{"even-odd, opposite direction", true, gxui.EvenOdd, 84},
+	} {
+		path := &gxui.Path{}
+		square(path, 0, 0, 10, false)
+		square(path, 3, 3, 4, test.reversed)
+		if got := area(FillPathTriangles(path, test.rule)); got < test.area-0.001 || got > test.area+0.001 {
+			t.Errorf("%s: expected an area of %v, got %v", test.name, test.area, got)
+		}
+	}
+}
+
+func TestFillPathNestedHoles(t *testing.T) {
+	// An island in a hole, next to another hole.
+	path := &gxui.Path{}
+	square(path, 0, 0, 20, false)
+	square(path, 2, 2, 8, true)
+	square(path, 4, 4, 4, false)
+	square(path, 12, 2, 6, false)
+
+	polygons := FillPathPolygons(path, gxui.EvenOdd)
+	test_helper.AssertEquals(t, 2, len(polygons))
+	for _, p := range polygons {
+		if SignedArea(p) <= 0 {
+			t.Errorf("Expected the polygons to be clockwise, got %v", p)
+		}
+	}
+	if got := area(FillPathTriangles(path, gxui.EvenOdd)); got < 400-64+16-36-0.001 || got > 400-64+16-36+0.001 {
+		t.Errorf("Expected an area of %v, got %v", 400-64+16-36, got)
+	}
+}
+
+func TestFillPathCurvedHole(t *testing.T) {
+	circle := func(path *gxui.Path, r float32) {
+		path.MoveTo(v(-r, 0))
+		path.ArcTo(v(r, r), 0, false, true, v(r, 0))
+		path.ArcTo(v(r, r), 0, false, true, v(-r, 0))
+		path.Close()
+	}
+	path := &gxui.Path{}
+	circle(path, 10)
+	circle(path, 5)
+	want := math.Pi * (10*10 - 5*5)
+	if got := area(FillPathTriangles(path, gxui.EvenOdd)); got < want*0.98 || got > want {
+		t.Errorf("Expected an area close to %v, got %v", want, got)
+	}
+}
+
+func TestStrokePath(t *testing.T) {
+	path := &gxui.Path{}
+	path.MoveTo(v(0, 0))
+	path.LineTo(v(10, 0))
+	path.MoveTo(v(0, 10))
+	path.LineTo(v(10, 10))
+	// The stroke is centered on each sub-path.
+	test_helper.AssertEquals(t, float32(2*20), area(StrokePath(path, gxui.CreatePen(2, gxui.White))))
+	test_helper.AssertEquals(t, true, hasVertex(StrokePath(path, gxui.CreatePen(2, gxui.White)), v(0, 11)))
+}
@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package gl
+package geometry
 
 import (
 	"github.com/badu/gxui"
@@ -17,6 +17,8 @@ func appendVec2(arr []float32, vecs ...math.Vec2) []float32 {
 	return arr
 }
 
+// pruneDuplicates returns the polygon without the vertices at the position of
+// the vertex before them.
 func pruneDuplicates(p gxui.Polygon) gxui.Polygon {
 	pruned := make(gxui.Polygon, 0, len(p))
 	var last gxui.PolygonVertex
@@ -144,8 +146,58 @@ func segment(penWidth, r float32, a, b, c math.Vec2, aIsLast bool, vsEdgePos []f
 	return vsEdgePos, fillEdge
 }
 
-func closedPolyToShape(p gxui.Polygon, penWidth float32) (fillShape, edgeShape *shape) {
+// outline returns the points along the edges of the polygon, following its
+// rounded corners.
+func outline(p gxui.Polygon, closed bool) []math.Vec2 {
+	points := []math.Vec2{}
+	for i, cnt := 0, len(p); i < cnt; i++ {
+		a := p[i].Position.Vec2()
+		if !closed && (i == 0 || i == cnt-1) {
+			points = append(points, a)
+			continue
+		}
+		b := p[(i+cnt-1)%cnt].Position.Vec2()
+		c := p[(i+1)%cnt].Position.Vec2()
+		_, points = segment(0, p[i].RoundedRadius, a, b, c, false, nil, points)
+	}
+	return points
+}
+
+// StripToTriangles returns the triangles of a triangle strip, given as pairs of
+// coordinates.
+func StripToTriangles(strip []float32) []math.Vec2 {
+	var triangles []math.Vec2
+	for i := 0; i+6 <= len(strip); i += 2 {
+		triangles = append(triangles,
+			math.Vec2{X: strip[i], Y: strip[i+1]},
+			math.Vec2{X: strip[i+2], Y: strip[i+3]},
+			math.Vec2{X: strip[i+4], Y: strip[i+5]},
+		)
+	}
+	return triangles
+}
+
+// ClosedPolygon returns the outline of the area filled inside the polygon,
+// following its rounded corners, and the triangles of its edge drawn with the
+// pen inside the outline. fill is nil if the polygon has no area, and edge if
+// the pen has no width.
+func ClosedPolygon(p gxui.Polygon, pen gxui.Pen) (fill, edge []math.Vec2) {
 	p = pruneDuplicates(p)
+	if pen.IsStyled() && pen.Width > 0 {
+		// The whole outline is filled, and stroked inside with the styled pen,
+		// as the plain pens are.
+		edges := outline(p, true)
+		if len(edges) < 3 {
+			return nil, nil
+		}
+		inset := pen.Width / 2
+		if SignedArea(edges) < 0 {
+			inset = -inset
+		}
+		center := OffsetPolyline(Polyline{Points: edges, Closed: true}, inset)
+		return edges, Stroke(Polyline{Points: center, Closed: true}, pen, nil)
+	}
+	penWidth := pen.Width
 
 	// Note : replacing declarations with `var fillEdge []math.Vec2` will cause malfunction in filling shapes
 	fillEdge := []math.Vec2{}
@@ -164,32 +216,29 @@ func closedPolyToShape(p gxui.Polygon, penWidth float32) (fillShape, edgeShape *
 		vsEdgePos = append(vsEdgePos, vsEdgePos[:4]...)
 	}
 
-	fillTris := triangulate(fillEdge)
-	if len(fillTris) > 0 {
-		fillPos := make([]float32, len(fillTris)*2)
-		for i, t := range fillTris {
-			fillPos[i*2+0] = t.X
-			fillPos[i*2+1] = t.Y
-		}
-		fillShape = newShape(newVertexBuffer(
-			newVertexStream("aPosition", stFloatVec2, fillPos),
-		), nil, dmTriangles)
+	if len(fillEdge) >= 3 {
+		fill = fillEdge
 	}
-
-	if len(vsEdgePos) > 0 {
-		edgeShape = newShape(newVertexBuffer(
-			newVertexStream("aPosition", stFloatVec2, vsEdgePos),
-		), nil, dmTriangleStrip)
+	if penWidth > 0 {
+		edge = StripToTriangles(vsEdgePos)
 	}
-
-	return fillShape, edgeShape
+	return fill, edge
 }
 
-func openPolyToShape(p gxui.Polygon, penWidth float32) *shape {
+// OpenPolygon returns the triangles of the lines joining the points of the
+// polygon, drawn with the pen on the side of the tangent of their direction.
+func OpenPolygon(p gxui.Polygon, pen gxui.Pen) []math.Vec2 {
 	p = pruneDuplicates(p)
 	if len(p) < 2 {
 		return nil
 	}
+	penWidth := pen.Width
+	if pen.IsStyled() && penWidth > 0 {
+		// Plain lines are drawn on the side of the tangent of their direction.
+		line := Polyline{Points: outline(p, false)}
+		center := OffsetPolyline(line, penWidth/2)
+		return Stroke(Polyline{Points: center}, pen, nil)
+	}
 
 	var vsEdgePos []float32
 
@@ -213,10 +262,26 @@ func openPolyToShape(p gxui.Polygon, penWidth float32) *shape {
 		inner := c.Sub(caDir.Tangent().MulS(penWidth))
 		vsEdgePos = appendVec2(vsEdgePos, c, inner)
 	}
-	if len(vsEdgePos) > 0 {
-		return newShape(newVertexBuffer(
-			newVertexStream("aPosition", stFloatVec2, vsEdgePos),
-		), nil, dmTriangleStrip)
+	return StripToTriangles(vsEdgePos)
+}
+
+// ShadowBox returns the rounded rectangle casting the shadow, moved by the
+// offset of the shadow and grown by its spread, with the radii of its corners
+// in the order of radii. It returns false if the rectangle is empty.
+func ShadowBox(rect math.Rect, radii [4]float32, shadow gxui.Shadow) (min, max math.Vec2, corners [4]float32, ok bool) {
+	offset := shadow.Offset.Vec2()
+	spread := math.Vec2{X: shadow.Spread, Y: shadow.Spread}
+	min = rect.Min.Vec2().Add(offset).Sub(spread)
+	max = rect.Max.Vec2().Add(offset).Add(spread)
+	if max.X <= min.X || max.Y <= min.Y {
+		return min, max, corners, false
+	}
+
+	limit := math32.Min(max.X-min.X, max.Y-min.Y) / 2
+	for i, r := range radii {
+		if r > 0 {
+			corners[i] = math.Clampf(r+shadow.Spread, 0, limit)
+		}
 	}
-	return nil
+	return min, max, corners, true
 }
@@ -0,0 +1,30 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+func TestStripToTriangles(t *testing.T) {
+	test_helper.AssertEquals(t, []math.Vec2{
+		v(0, 0), v(0, 1), v(1, 0),
+		v(0, 1), v(1, 0), v(1, 1),
+	}, StripToTriangles([]float32{0, 0, 0, 1, 1, 0, 1, 1}))
+}
+
+func TestShadowBox(t *testing.T) {
+	shadow := gxui.Shadow{Offset: math.Point{X: 1, Y: 2}, Spread: 2, Color: gxui.Black}
+	min, max, radii, ok := ShadowBox(math.CreateRect(0, 0, 10, 6), [4]float32{0, 2, 8, 1}, shadow)
+	test_helper.AssertEquals(t, true, ok)
+	test_helper.AssertEquals(t, v(-1, 0), min)
+	test_helper.AssertEquals(t, v(13, 10), max)
+	// Rounded corners grow with the spread, up to half of the shortest side.
+	test_helper.AssertEquals(t, [4]float32{0, 4, 5, 3}, radii)
+
+	shadow.Spread = -4
+	_, _, _, ok = ShadowBox(math.CreateRect(0, 0, 10, 6), [4]float32{}, shadow)
+	test_helper.AssertEquals(t, false, ok)
+}
@@ -0,0 +1,293 @@
+// Package geometry builds the triangles and polygons the drivers draw: the
+// strokes of styled pens, the areas of filled paths, and the outlines and
+// borders of rounded polygons.
+package geometry
+
+import (
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// Epsilon is the distance below which points are considered equal, and the
+// area below which triangles are considered degenerate.
+const Epsilon = 0.00001
+
+// Length of the stroke drawn for zero length dashes, so that their caps have
+// a direction.
+const dotLength = 0.001
+
+// Polyline is a list of points, joined back to the first one if Closed.
+type Polyline struct {
+	Points []math.Vec2
+	Closed bool
+}
+
+// Stroke returns the triangles covering the stroke of the polyline with a
+// styled pen, appended to triangles. The stroke is centered on the points.
+func Stroke(line Polyline, pen gxui.Pen, triangles []math.Vec2) []math.Vec2 {
+	for _, dash := range dashes(line, pen) {
+		triangles = strokePolyline(dash, pen, triangles)
+	}
+	return triangles
+}
+
+// dashes breaks the polyline into the dashes of the pen. A closed polyline is
+// only kept closed if the pen has no dashes.
+func dashes(line Polyline, pen gxui.Pen) []Polyline {
+	if pen.Dash == nil {
+		return []Polyline{line}
+	}
+	lengths := pen.Dash.Lengths
+	if len(lengths)%2 == 1 {
+		lengths = append(append([]float32{}, lengths...), lengths...)
+	}
+	total := float32(0)
+	for _, l := range lengths {
+		if l < 0 {
+			return []Polyline{line}
+		}
+		total += l
+	}
+	if total <= 0 {
+		return []Polyline{line}
+	}
+
+	// Find the dash or gap the stroke starts in.
+	i := 0
+	phase := math32.Mod(pen.Dash.Offset, total)
+	if phase < 0 {
+		phase += total
+	}
+	for phase > lengths[i] || phase == lengths[i] && lengths[i] > 0 {
+		phase -= lengths[i]
+		i = (i + 1) % len(lengths)
+	}
+	left := lengths[i] - phase
+	on := i%2 == 0
+	startsOn := on
+
+	points := line.Points
+	if line.Closed && len(points) > 0 {
+		points = append(append([]math.Vec2{}, points...), points[0])
+	}
+	if len(points) < 2 {
+		return nil
+	}
+
+	var result []Polyline
+	var current []math.Vec2
+	if on {
+		current = []math.Vec2{points[0]}
+	}
+	for j := 1; j < len(points); j++ {
+		a, b := points[j-1], points[j]
+		length := b.Sub(a).Len()
+		if length == 0 {
+			continue
+		}
+		direction := b.Sub(a).DivS(length)
+		t := float32(0)
+		for length-t > left {
+			t += left
+			p := a.Add(direction.MulS(t))
+			if on {
+				if len(current) > 1 || current[0] != p {
+					if current[len(current)-1] != p {
+						current = append(current, p)
+					}
+					result = append(result, Polyline{Points: current})
+				} else if pen.Cap != gxui.ButtCap {
+					// A dot, which only needs a direction for its caps.
+					result = append(result, Polyline{Points: []math.Vec2{p, p.Add(direction.MulS(dotLength))}})
+				}
+				current = nil
+			} else {
+				current = []math.Vec2{p}
+			}
+			on = !on
+			i = (i + 1) % len(lengths)
+			left = lengths[i]
+		}
+		left -= length - t
+		if on {
+			current = append(current, b)
+		}
+	}
+	if on && len(current) > 1 {
+		if line.Closed && startsOn && len(result) > 0 {
+			// The last dash runs through the first point, into the first dash.
+			result[0].Points = append(current, result[0].Points[1:]...)
+		} else if line.Closed && len(result) == 0 {
+			return []Polyline{line}
+		} else {
+			result = append(result, Polyline{Points: current})
+		}
+	}
+	return result
+}
+
+// strokePolyline appends the triangles of a solid stroke of the polyline to
+// triangles: a quad per segment, a join between segments and a cap at each end
+// of an open polyline.
+func strokePolyline(line Polyline, pen gxui.Pen, triangles []math.Vec2) []math.Vec2 {
+	points := make([]math.Vec2, 0, len(line.Points))
+	for _, p := range line.Points {
+		if len(points) == 0 || p.Sub(points[len(points)-1]).Len() > Epsilon {
+			points = append(points, p)
+		}
+	}
+	if line.Closed && len(points) > 1 && points[0].Sub(points[len(points)-1]).Len() <= Epsilon {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 2 {
+		return triangles
+	}
+
+	h := pen.Width / 2
+	count := len(points) - 1
+	if line.Closed {
+		count = len(points)
+	}
+	direction := func(i int) math.Vec2 {
+		return points[(i+1)%len(points)].Sub(points[i]).Normalize()
+	}
+
+	for i := 0; i < count; i++ {
+		a, b := points[i], points[(i+1)%len(points)]
+		n := direction(i).Tangent().MulS(h)
+		triangles = append(triangles,
+			a.Add(n), b.Add(n), b.Sub(n),
+			a.Add(n), b.Sub(n), a.Sub(n),
+		)
+	}
+
+	for i := 0; i < len(points); i++ {
+		if !line.Closed && (i == 0 || i == len(points)-1) {
+			continue
+		}
+		in, out := direction((i+len(points)-1)%len(points)), direction(i)
+		triangles = join(points[i], in, out, pen, triangles)
+	}
+
+	if !line.Closed {
+		triangles = lineCap(points[0], direction(0).MulS(-1), pen, triangles)
+		triangles = lineCap(points[len(points)-1], direction(len(points)-2), pen, triangles)
+	}
+	return triangles
+}
+
+// join appends the triangles filling the outer corner between the quads of the
+// segments arriving at p along in and leaving along out.
+func join(p, in, out math.Vec2, pen gxui.Pen, triangles []math.Vec2) []math.Vec2 {
+	turn := in.Cross(out)
+	if math32.Abs(turn) < Epsilon && in.Dot(out) > 0 {
+		return triangles // Straight
+	}
+	h := pen.Width / 2
+	// The outer side is on the left of a clockwise turn.
+	side := float32(1)
+	if turn > 0 {
+		side = -1
+	}
+	n0, n1 := in.Tangent().MulS(side), out.Tangent().MulS(side)
+	o0, o1 := p.Add(n0.MulS(h)), p.Add(n1.MulS(h))
+
+	switch pen.Join {
+	case gxui.RoundJoin:
+		return arc(p, n0, n1, h, triangles)
+	case gxui.MiterJoin:
+		limit := pen.MiterLimit
+		if limit <= 0 {
+			limit = gxui.DefaultMiterLimit
+		}
+		bisector := n0.Add(n1).Normalize()
+		// The ratio between the length of the miter and the width of the
+		// stroke is 1/sin(θ/2) for lines meeting at θ, which is 1/cos of the
+		// angle between the bisector and the normals.
+		if cos := bisector.Dot(n0); cos > 0 && 1/cos <= limit {
+			m := p.Add(bisector.MulS(h / cos))
+			return append(triangles, p, o0, m, p, m, o1)
+		}
+	}
+	return append(triangles, p, o0, o1)
+}
+
+// lineCap appends the triangles of the cap at the end p of a stroke, where
+// outward points away from the stroke.
+func lineCap(p, outward math.Vec2, pen gxui.Pen, triangles []math.Vec2) []math.Vec2 {
+	h := pen.Width / 2
+	n := outward.Tangent().MulS(h)
+	switch pen.Cap {
+	case gxui.SquareCap:
+		e := outward.MulS(h)
+		return append(triangles,
+			p.Add(n), p.Add(n).Add(e), p.Sub(n).Add(e),
+			p.Add(n), p.Sub(n).Add(e), p.Sub(n),
+		)
+	case gxui.RoundCap:
+		unit := outward.Tangent()
+		triangles = arc(p, unit, outward, h, triangles)
+		return arc(p, outward, unit.MulS(-1), h, triangles)
+	}
+	return triangles
+}
+
+// arc appends a fan of triangles around center, of radius r, sweeping the
+// shortest way from the unit vector from to the unit vector to.
+func arc(center, from, to math.Vec2, r float32, triangles []math.Vec2) []math.Vec2 {
+	angle := math32.Acos(math.Clampf(from.Dot(to), -1, 1))
+	if from.Cross(to) < 0 {
+		angle = -angle
+	}
+	// Keep the chords within a quarter of a pixel from the arc.
+	step := 2 * math32.Acos(max(1-0.25/r, 0))
+	steps := 1 + int(math32.Abs(angle)/step)
+	last := center.Add(from.MulS(r))
+	for i := 1; i <= steps; i++ {
+		sin, cos := math32.Sincos(angle * float32(i) / float32(steps))
+		direction := math.Vec2{X: from.X*cos - from.Y*sin, Y: from.X*sin + from.Y*cos}
+		next := center.Add(direction.MulS(r))
+		triangles = append(triangles, center, last, next)
+		last = next
+	}
+	return triangles
+}
+
+// OffsetPolyline returns the points moved by distance along the normals
+// (tangents) of the segments, mitering the corners so that the segments stay
+// parallel to the original ones.
+func OffsetPolyline(line Polyline, distance float32) []math.Vec2 {
+	points := line.Points
+	result := make([]math.Vec2, len(points))
+	for i, p := range points {
+		var normals []math.Vec2
+		if i > 0 || line.Closed {
+			prev := points[(i+len(points)-1)%len(points)]
+			normals = append(normals, p.Sub(prev).Normalize().Tangent())
+		}
+		if i < len(points)-1 || line.Closed {
+			next := points[(i+1)%len(points)]
+			normals = append(normals, next.Sub(p).Normalize().Tangent())
+		}
+		offset := math.Vec2{}
+		for _, n := range normals {
+			offset = offset.Add(n)
+		}
+		offset = offset.Normalize()
+		// Keep the miter of sharp corners within a few times the distance.
+		cos := max(offset.Dot(normals[0]), 0.25)
+		result[i] = p.Add(offset.MulS(distance / cos))
+	}
+	return result
+}
+
+// SignedArea returns the area of the closed polygon, positive if its points
+// go clockwise on screen.
+func SignedArea(points []math.Vec2) float32 {
+	area := float32(0)
+	for i, p := range points {
+		area += p.Cross(points[(i+1)%len(points)])
+	}
+	return area / 2
+}
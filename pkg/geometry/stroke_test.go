@@ -0,0 +1,143 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+	"github.com/chewxy/math32"
+)
+
+func dashedPen(cap gxui.LineCap, offset float32, lengths ...float32) gxui.Pen {
+	pen := gxui.CreateDashedPen(2, gxui.White, offset, lengths...)
+	pen.Cap = cap
+	return pen
+}
+
+func hasVertex(triangles []math.Vec2, want math.Vec2) bool {
+	for _, p := range triangles {
+		if p.Sub(want).Len() < 0.001 {
+			return true
+		}
+	}
+	return false
+}
+
+func maxX(triangles []math.Vec2) float32 {
+	result := triangles[0].X
+	for _, p := range triangles {
+		result = max(result, p.X)
+	}
+	return result
+}
+
+func area(triangles []math.Vec2) float32 {
+	total := float32(0)
+	for i := 0; i+3 <= len(triangles); i += 3 {
+		a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+		total += math32.Abs(b.Sub(a).Cross(c.Sub(a))) / 2
+	}
+	return total
+}
+
+func TestDashes(t *testing.T) {
+	line := Polyline{Points: []math.Vec2{v(0, 0), v(10, 0)}}
+
+	test_helper.AssertEquals(t, []Polyline{
+		{Points: []math.Vec2{v(0, 0), v(3, 0)}},
+		{Points: []math.Vec2{v(5, 0), v(8, 0)}},
+	}, dashes(line, dashedPen(gxui.ButtCap, 0, 3, 2)))
+
+	test_helper.AssertEquals(t, []Polyline{
+		{Points: []math.Vec2{v(0, 0), v(2, 0)}},
+		{Points: []math.Vec2{v(4, 0), v(7, 0)}},
+		{Points: []math.Vec2{v(9, 0), v(10, 0)}},
+	}, dashes(line, dashedPen(gxui.ButtCap, 1, 3, 2)))
+
+	// An odd number of lengths alternates between dashes and gaps.
+	test_helper.AssertEquals(t, []Polyline{
+		{Points: []math.Vec2{v(0, 0), v(4, 0)}},
+		{Points: []math.Vec2{v(8, 0), v(10, 0)}},
+	}, dashes(line, dashedPen(gxui.ButtCap, 0, 4)))
+
+	// Zero length dashes are dots, dropped with butt caps.
+	test_helper.AssertEquals(t, 3, len(dashes(line, dashedPen(gxui.RoundCap, 0, 0, 4))))
+	test_helper.AssertEquals(t, 0, len(dashes(line, dashedPen(gxui.ButtCap, 0, 0, 4))))
+
+	// Invalid patterns are solid.
+	test_helper.AssertEquals(t, []Polyline{line}, dashes(line, dashedPen(gxui.ButtCap, 0, 0, 0)))
+}
+
+func TestDashesClosed(t *testing.T) {
+	square := Polyline{Points: []math.Vec2{v(0, 0), v(10, 0), v(10, 10), v(0, 10)}, Closed: true}
+
+	result := dashes(square, dashedPen(gxui.ButtCap, 2, 5, 5))
+	test_helper.AssertEquals(t, 4, len(result))
+	// The dash crossing the first point is kept in one piece.
+	test_helper.AssertEquals(t, []math.Vec2{v(0, 2), v(0, 0), v(3, 0)}, result[0].Points)
+	test_helper.AssertEquals(t, []math.Vec2{v(8, 0), v(10, 0), v(10, 3)}, result[1].Points)
+
+	// Dashes ending on a corner do not become dots.
+	result = dashes(square, dashedPen(gxui.RoundCap, 0, 10, 10))
+	test_helper.AssertEquals(t, []Polyline{
+		{Points: []math.Vec2{v(0, 0), v(10, 0)}},
+		{Points: []math.Vec2{v(10, 10), v(0, 10)}},
+	}, result)
+}
+
+func TestStrokeCaps(t *testing.T) {
+	line := Polyline{Points: []math.Vec2{v(0, 0), v(10, 0)}}
+	pen := gxui.Pen{Width: 2, Color: gxui.White}
+
+	pen.Cap = gxui.ButtCap
+	test_helper.AssertEquals(t, float32(20), area(Stroke(line, pen, nil)))
+
+	pen.Cap = gxui.SquareCap
+	test_helper.AssertEquals(t, float32(24), area(Stroke(line, pen, nil)))
+	test_helper.AssertEquals(t, true, hasVertex(Stroke(line, pen, nil), v(11, 1)))
+
+	pen.Cap = gxui.RoundCap
+	round := Stroke(line, pen, nil)
+	if a := area(round); a <= 20+math.Pi*0.85 || a > 20+math.Pi {
+		t.Errorf("Expected the round caps to add about π, got %v", a-20)
+	}
+	test_helper.AssertEquals(t, true, hasVertex(round, v(-1, 0)))
+	test_helper.AssertEquals(t, true, hasVertex(round, v(11, 0)))
+}
+
+func TestStrokeJoins(t *testing.T) {
+	corner := Polyline{Points: []math.Vec2{v(0, 0), v(10, 0), v(10, 10)}}
+	pen := gxui.Pen{Width: 2, Color: gxui.White}
+
+	pen.Join = gxui.MiterJoin
+	test_helper.AssertEquals(t, true, hasVertex(Stroke(corner, pen, nil), v(11, -1)))
+
+	pen.Join = gxui.BevelJoin
+	bevel := Stroke(corner, pen, nil)
+	test_helper.AssertEquals(t, false, hasVertex(bevel, v(11, -1)))
+	test_helper.AssertEquals(t, float32(40+0.5), area(bevel))
+
+	pen.Join = gxui.RoundJoin
+	test_helper.AssertEquals(t, true, hasVertex(Stroke(corner, pen, nil), v(10+math32.Sqrt2/2, -math32.Sqrt2/2)))
+
+	// The miter of a sharp corner is longer than the limit.
+	sharp := Polyline{Points: []math.Vec2{v(0, 0), v(10, 0), v(0, 1)}}
+	pen.Join = gxui.MiterJoin
+	if right := maxX(Stroke(sharp, pen, nil)); right > 11 {
+		t.Errorf("Expected the sharp corner to be beveled, got a miter reaching %v", right)
+	}
+	pen.MiterLimit = 100
+	if right := maxX(Stroke(sharp, pen, nil)); right < 20 {
+		t.Errorf("Expected a long miter with a high limit, got one reaching %v", right)
+	}
+}
+
+func TestOffsetPolyline(t *testing.T) {
+	line := Polyline{Points: []math.Vec2{v(0, 0), v(10, 0), v(10, 10)}}
+	for i, want := range []math.Vec2{v(0, 1), v(9, 1), v(9, 10)} {
+		if got := OffsetPolyline(line, 1)[i]; got.Sub(want).Len() > 0.001 {
+			t.Errorf("Expected point %d to be offset to %v, got %v", i, want, got)
+		}
+	}
+}
@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package gl
+package geometry
 
 import (
 	"fmt"
@@ -12,10 +12,9 @@ import (
 )
 
 const debugTriangulate = false
-const epsilon = 0.00001
 
 func isConcave(edges []math.Vec2, a, b, c int) bool {
-	return edges[b].Sub(edges[a]).Cross(edges[b].Sub(edges[c])) > -epsilon
+	return edges[b].Sub(edges[a]).Cross(edges[b].Sub(edges[c])) > -Epsilon
 }
 
 func isEar(edges []math.Vec2, a, b, c int) bool {
@@ -38,11 +37,15 @@ func isEar(edges []math.Vec2, a, b, c int) bool {
 		if i == a || i == b || i == c {
 			continue
 		}
+		// Bridges to holes go through some points twice.
+		if edges[i] == edges[a] || edges[i] == edges[b] || edges[i] == edges[c] {
+			continue
+		}
 		v := edges[i].Vec3(1)
 
-		if v.Dot(plane[0]) > -epsilon &&
-			v.Dot(plane[1]) > -epsilon &&
-			v.Dot(plane[2]) > -epsilon {
+		if v.Dot(plane[0]) > -Epsilon &&
+			v.Dot(plane[1]) > -Epsilon &&
+			v.Dot(plane[2]) > -Epsilon {
 			if debugTriangulate {
 				fmt.Printf("non-ear: %c, %c, %c (%c %f:%f:%f)\n",
 					'A'+a, 'A'+b, 'A'+c,
@@ -73,7 +76,9 @@ func pruneEdgeDuplicates(edges []math.Vec2) []math.Vec2 {
 	return pruned
 }
 
-func triangulate(edges []math.Vec2) []math.Vec2 {
+// Triangulate returns the triangles covering the simple polygon, by clipping
+// its ears.
+func Triangulate(edges []math.Vec2) []math.Vec2 {
 	if debugTriangulate {
 		fmt.Printf("triangulate()\n")
 	}
@@ -93,10 +98,12 @@ func triangulate(edges []math.Vec2) []math.Vec2 {
 	for pruned {
 		pruned = false
 		for a := l.Front(); a != nil; {
+
 			b := a.Next()
 			if b == nil {
 				b = l.Front()
 			}
+
 			c := b.Next()
 			if c == nil {
 				c = l.Front()
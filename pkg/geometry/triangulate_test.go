@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package gl
+package geometry
 
 import (
 	"testing"
@@ -41,7 +41,7 @@ func TestTriangluate3Verts(t *testing.T) {
 	B := v(0, 0)
 	C := v(1, 1)
 	edges := []math.Vec2{A, B, C}
-	test_helper.AssertEquals(t, edges, triangulate(edges))
+	test_helper.AssertEquals(t, edges, Triangulate(edges))
 }
 
 func TestTriangluateQuad(t *testing.T) {
@@ -58,7 +58,7 @@ func TestTriangluateQuad(t *testing.T) {
 		A, B, C,
 		A, C, D,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
 
 func TestTriangluateDupeVertex(t *testing.T) {
@@ -75,7 +75,7 @@ func TestTriangluateDupeVertex(t *testing.T) {
 		A, B, C,
 		A, C, D,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
 func TestTriangluateConcave(t *testing.T) {
 	/*
@@ -104,7 +104,7 @@ func TestTriangluateConcave(t *testing.T) {
 		A, F, G,
 		A, G, H,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
 
 func TestTriangluateConvex(t *testing.T) {
@@ -133,7 +133,7 @@ func TestTriangluateConvex(t *testing.T) {
 		G, A, C,
 		G, C, E,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
 
 func TestTriangluateConvex2(t *testing.T) {
@@ -160,7 +160,7 @@ func TestTriangluateConvex2(t *testing.T) {
 		E, F, A,
 		E, A, C,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
 
 func TestTriangluateConvex3(t *testing.T) {
@@ -195,7 +195,7 @@ func TestTriangluateConvex3(t *testing.T) {
 		A, D, F,
 		A, F, G,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
 
 func TestTriangluateConvex4(t *testing.T) {
@@ -230,7 +230,7 @@ func TestTriangluateConvex4(t *testing.T) {
 		J, A, D,
 		J, D, E,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
 
 func TestTriangulateChevron(t *testing.T) {
@@ -255,5 +255,5 @@ func TestTriangulateChevron(t *testing.T) {
 		C, E, F,
 		C, F, A,
 	}
-	test_helper.AssertEquals(t, tris, triangulate(edges))
+	test_helper.AssertEquals(t, tris, Triangulate(edges))
 }
@@ -6,6 +6,8 @@ package math
 
 import (
 	"fmt"
+
+	"github.com/chewxy/math32"
 )
 
 // A 3x3 matrix:
@@ -50,3 +52,131 @@ func (m Mat3) String() string {
 		p, p, p,
 	)
 }
+
+// Mat3Ident is the identity matrix.
+var Mat3Ident = CreateMat3(
+	1, 0, 0,
+	0, 1, 0,
+	0, 0, 1,
+)
+
+// The matrices transform the column vector (x, y, 1), so M₆ and M₇ hold the
+// translation, as they do in the matrices passed to the GL shaders.
+
+// CreateMat3Translate returns a matrix translating points by (x, y).
+func CreateMat3Translate(x, y float32) Mat3 {
+	return CreateMat3(
+		1, 0, 0,
+		0, 1, 0,
+		x, y, 1,
+	)
+}
+
+// CreateMat3Scale returns a matrix scaling points by (x, y).
+func CreateMat3Scale(x, y float32) Mat3 {
+	return CreateMat3(
+		x, 0, 0,
+		0, y, 0,
+		0, 0, 1,
+	)
+}
+
+// Mul returns the matrix applying n, then m.
+func (m Mat3) Mul(n Mat3) Mat3 {
+	var result Mat3
+	for c := 0; c < 3; c++ {
+		for r := 0; r < 3; r++ {
+			result[c*3+r] = m[r]*n[c*3] + m[3+r]*n[c*3+1] + m[6+r]*n[c*3+2]
+		}
+	}
+	return result
+}
+
+// Det returns the determinant of m.
+func (m Mat3) Det() float32 {
+	return m[0]*(m[4]*m[8]-m[5]*m[7]) -
+		m[3]*(m[1]*m[8]-m[2]*m[7]) +
+		m[6]*(m[1]*m[5]-m[2]*m[4])
+}
+
+// Invert returns the inverse of m, or false if m cannot be inverted.
+func (m Mat3) Invert() (Mat3, bool) {
+	det := m.Det()
+	if det == 0 {
+		return Mat3{}, false
+	}
+	d := 1 / det
+	return CreateMat3(
+		(m[4]*m[8]-m[5]*m[7])*d, (m[2]*m[7]-m[1]*m[8])*d, (m[1]*m[5]-m[2]*m[4])*d,
+		(m[5]*m[6]-m[3]*m[8])*d, (m[0]*m[8]-m[2]*m[6])*d, (m[2]*m[3]-m[0]*m[5])*d,
+		(m[3]*m[7]-m[4]*m[6])*d, (m[1]*m[6]-m[0]*m[7])*d, (m[0]*m[4]-m[1]*m[3])*d,
+	), true
+}
+
+// TransformVec2 returns the point v transformed by m.
+func (m Mat3) TransformVec2(v Vec2) Vec2 {
+	return Vec2{
+		X: m[0]*v.X + m[3]*v.Y + m[6],
+		Y: m[1]*v.X + m[4]*v.Y + m[7],
+	}
+}
+
+// CreateMat3Rotate returns a matrix rotating points by angle radians around the
+// origin, clockwise on screen.
+func CreateMat3Rotate(angle float32) Mat3 {
+	sin, cos := math32.Sincos(angle)
+	return CreateMat3(
+		cos, sin, 0,
+		-sin, cos, 0,
+		0, 0, 1,
+	)
+}
+
+// CreateMat3Skew returns a matrix skewing points along the X axis by x radians,
+// and along the Y axis by y radians.
+func CreateMat3Skew(x, y float32) Mat3 {
+	return CreateMat3(
+		1, math32.Tan(y), 0,
+		math32.Tan(x), 1, 0,
+		0, 0, 1,
+	)
+}
+
+// IsAxisAligned returns true if m only translates and scales points, so that
+// rectangles stay aligned with the axes.
+func (m Mat3) IsAxisAligned() bool {
+	return m[1] == 0 && m[3] == 0
+}
+
+// Scale returns the square root of the factor by which m scales areas. This is
+// the scale of m if it scales both axes equally.
+func (m Mat3) Scale() float32 {
+	return math32.Sqrt(math32.Abs(m[0]*m[4] - m[1]*m[3]))
+}
+
+// TransformPoint returns the point p transformed by m, rounded to the closest
+// point.
+func (m Mat3) TransformPoint(p Point) Point {
+	v := m.TransformVec2(p.Vec2())
+	return Point{X: Round(v.X), Y: Round(v.Y)}
+}
+
+// TransformRect returns the smallest rectangle containing the rectangle r
+// transformed by m.
+func (m Mat3) TransformRect(r Rect) Rect {
+	corners := [4]Vec2{
+		m.TransformVec2(r.TopLeft().Vec2()),
+		m.TransformVec2(r.TopRight().Vec2()),
+		m.TransformVec2(r.BottomLeft().Vec2()),
+		m.TransformVec2(r.BottomRight().Vec2()),
+	}
+	min, max := corners[0], corners[0]
+	for _, c := range corners[1:] {
+		min = Vec2{X: math32.Min(min.X, c.X), Y: math32.Min(min.Y, c.Y)}
+		max = Vec2{X: math32.Max(max.X, c.X), Y: math32.Max(max.Y, c.Y)}
+	}
+	return CreateRect(
+		int(math32.Floor(min.X+0.001)), int(math32.Floor(min.Y+0.001)),
+		int(math32.Ceil(max.X-0.001)), int(math32.Ceil(max.Y-0.001)),
+	)
+}
@@ -0,0 +1,74 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/chewxy/math32"
+)
+
+func TestMat3Mul(t *testing.T) {
+	m := CreateMat3Translate(10, 20).Mul(CreateMat3Scale(2, 3))
+	if got, expected := m.TransformVec2(Vec2{X: 1, Y: 1}), (Vec2{X: 12, Y: 23}); got != expected {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := m.Mul(Mat3Ident); got != m {
+		t.Errorf("Expected the identity to leave the matrix unchanged, got %v", got)
+	}
+}
+
+func TestMat3Invert(t *testing.T) {
+	m := CreateMat3(
+		2, 1, 0,
+		-1, 3, 0,
+		5, 7, 1,
+	)
+	inverse, ok := m.Invert()
+	if !ok {
+		t.Fatal("Expected the matrix to be invertible")
+	}
+	for i, v := range m.Mul(inverse) {
+		if math32.Abs(v-Mat3Ident[i]) > 1e-6 {
+			t.Fatalf("Expected the product with the inverse to be the identity, got %v", m.Mul(inverse))
+		}
+	}
+	if _, ok := CreateMat3Scale(0, 1).Invert(); ok {
+		t.Error("Expected a degenerate matrix not to be invertible")
+	}
+}
+
+func TestMat3Rotate(t *testing.T) {
+	// A quarter turn clockwise on screen takes the X axis to the Y axis.
+	m := CreateMat3Rotate(Pi / 2)
+	if got := m.TransformVec2(Vec2{X: 1, Y: 0}); got.Sub(Vec2{X: 0, Y: 1}).Len() > 1e-6 {
+		t.Errorf("Expected (0, 1), got %v", got)
+	}
+	if got := m.TransformPoint(Point{X: 3, Y: 2}); got != (Point{X: -2, Y: 3}) {
+		t.Errorf("Expected (-2, 3), got %v", got)
+	}
+	if m.IsAxisAligned() {
+		t.Error("Expected a rotation not to be axis aligned")
+	}
+	if got := m.Scale(); math32.Abs(got-1) > 1e-6 {
+		t.Errorf("Expected a rotation to keep the scale, got %v", got)
+	}
+}
+
+func TestMat3Skew(t *testing.T) {
+	m := CreateMat3Skew(Pi/4, 0)
+	if got := m.TransformVec2(Vec2{X: 0, Y: 2}); got.Sub(Vec2{X: 2, Y: 2}).Len() > 1e-6 {
+		t.Errorf("Expected (2, 2), got %v", got)
+	}
+}
+
+func TestMat3TransformRect(t *testing.T) {
+	m := CreateMat3Translate(10, 0).Mul(CreateMat3Scale(2, 2))
+	if !m.IsAxisAligned() {
+		t.Error("Expected a translation and scale to be axis aligned")
+	}
+	if got, expected := m.TransformRect(CreateRect(1, 2, 3, 4)), CreateRect(12, 4, 16, 8); got != expected {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got, expected := CreateMat3Rotate(Pi/4).TransformRect(CreateRect(0, 0, 10, 10)), CreateRect(-8, 0, 8, 15); got != expected {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
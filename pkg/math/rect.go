@@ -137,6 +137,18 @@ func (r Rect) Intersect(rect Rect) Rect {
 	}.Canon()
 }
 
+// Overlap returns the rectangle common to r and rect. Unlike Intersect, the
+// result is empty, at the nearest corner of the gap between them, if they do
+// not overlap.
+func (r Rect) Overlap(rect Rect) Rect {
+	r, rect = r.Canon(), rect.Canon()
+	min := r.Min.Max(rect.Min)
+	return Rect{
+		Min: min,
+		Max: r.Max.Min(rect.Max).Max(min),
+	}
+}
+
 func (r Rect) Constrain(rect Rect) Rect {
 	overflowMin := rect.Min.Sub(r.Min).Max(ZeroPoint)
 	overflowMax := rect.Max.Sub(r.Max).Min(ZeroPoint)
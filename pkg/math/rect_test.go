@@ -27,3 +27,13 @@ func TestRectConstrainOutMax(t *testing.T) {
 	r2 := CreateRect(80, 80, 120, 120)
 	test_helper.AssertEquals(t, CreateRect(60, 60, 100, 100), r2.Constrain(r1))
 }
+
+func TestRectOverlap(t *testing.T) {
+	r1 := CreateRect(0, 0, 100, 100)
+	test_helper.AssertEquals(t, CreateRect(40, 50, 100, 100), r1.Overlap(CreateRect(40, 50, 120, 130)))
+	test_helper.AssertEquals(t, CreateRect(0, 0, 10, 10), CreateRect(10, 10, 0, 0).Overlap(r1))
+	// Rectangles not overlapping have an empty overlap.
+	test_helper.AssertEquals(t, CreateRect(120, 50, 120, 60), r1.Overlap(CreateRect(120, 50, 140, 60)))
+	// Intersect keeps returning the canonical rectangle between them.
+	test_helper.AssertEquals(t, CreateRect(100, 50, 120, 60), r1.Intersect(CreateRect(120, 50, 140, 60)))
+}
@@ -0,0 +1,708 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	m "math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// Object numbers of the objects every document starts with.
+const (
+	catalogObject   = 1
+	pagesObject     = 2
+	resourcesObject = 3
+)
+
+// formBBox bounds the nested canvases. Canvases are not clipped to their size
+// when drawn, so this is only there because PDF requires a bounding box.
+const formBBox = "[-32768 -32768 32768 32768]"
+
+type encoder struct {
+	objects        [][]byte
+	forms          map[*gxui.DisplayList]string
+	images         map[gxui.Texture]string
+	fonts          map[gxui.Font]*embeddedFont
+	fontData       map[*byte]*embeddedFont
+	states         map[graphicsState]string
+	layers         int
+	xObjects       map[string]int
+	shadings       map[*gxui.Gradient]string
+	shadingObjects map[string]int
+	patterns       map[string]int
+}
+
+func newEncoder() *encoder {
+	e := &encoder{
+		forms:          make(map[*gxui.DisplayList]string),
+		images:         make(map[gxui.Texture]string),
+		fonts:          make(map[gxui.Font]*embeddedFont),
+		fontData:       make(map[*byte]*embeddedFont),
+		states:         make(map[graphicsState]string),
+		xObjects:       make(map[string]int),
+		shadings:       make(map[*gxui.Gradient]string),
+		shadingObjects: make(map[string]int),
+		patterns:       make(map[string]int),
+	}
+	for i := 0; i < resourcesObject; i++ {
+		e.alloc()
+	}
+	return e
+}
+
+// alloc reserves an object number, whose content is set later with set.
+func (e *encoder) alloc() int {
+	e.objects = append(e.objects, nil)
+	return len(e.objects)
+}
+
+func (e *encoder) set(object int, format string, args ...interface{}) {
+	e.objects[object-1] = []byte(fmt.Sprintf(format, args...))
+}
+
+// stream sets object to a compressed stream with the extra dictionary entries.
+func (e *encoder) stream(object int, entries string, data []byte) {
+	compressed := &bytes.Buffer{}
+	writer := zlib.NewWriter(compressed)
+	writer.Write(data)
+	writer.Close()
+
+	e.set(object, "<< %s /Length %d >>\nstream\n%s\nendstream",
+		strings.TrimSpace(entries+" /Filter /FlateDecode"), compressed.Len(), compressed.Bytes())
+}
+
+func (e *encoder) encode(w io.Writer, pages []page) error {
+	var kids []string
+	for _, p := range pages {
+		content := &bytes.Buffer{}
+		// Flip the page so that y grows down, as it does on a canvas.
+		fmt.Fprintf(content, "1 0 0 -1 0 %d cm\n", p.size.Height)
+		for _, placement := range p.placements {
+			form, err := e.form(placement.canvas)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(content, "q %s re W n 1 0 0 1 %d %d cm /%s Do Q\n",
+				rect(placement.clip), placement.offset.X, placement.offset.Y, form)
+		}
+
+		contentObject, pageObject := e.alloc(), e.alloc()
+		e.stream(contentObject, "", content.Bytes())
+		e.set(pageObject, "<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources %d 0 R /Contents %d 0 R >>",
+			pagesObject, p.size.Width, p.size.Height, resourcesObject, contentObject)
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+	}
+
+	embedded := make([]*embeddedFont, 0, len(e.fontData))
+	for _, font := range e.fontData {
+		embedded = append(embedded, font)
+	}
+	sort.Slice(embedded, func(i, j int) bool { return embedded[i].number < embedded[j].number })
+
+	fonts := &strings.Builder{}
+	for _, font := range embedded {
+		object, err := e.embed(font)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(fonts, " /%s %d 0 R", font.name, object)
+	}
+
+	xObjects := &strings.Builder{}
+	for _, name := range sortedKeys(e.xObjects) {
+		fmt.Fprintf(xObjects, " /%s %d 0 R", name, e.xObjects[name])
+	}
+
+	named := make(map[string]graphicsState, len(e.states))
+	for state, name := range e.states {
+		named[name] = state
+	}
+	states := &strings.Builder{}
+	for _, name := range sortedKeys(named) {
+		state := named[name]
+		fmt.Fprintf(states, " /%s << /ca %s /CA %s", name, number(state.alpha), number(state.alpha))
+		if state.blend != gxui.BlendNormal {
+			fmt.Fprintf(states, " /BM /%s", blendModes[state.blend])
+		}
+		states.WriteString(" >>")
+	}
+
+	shadings := &strings.Builder{}
+	for _, name := range sortedKeys(e.shadingObjects) {
+		fmt.Fprintf(shadings, " /%s %d 0 R", name, e.shadingObjects[name])
+	}
+
+	patterns := &strings.Builder{}
+	for _, name := range sortedKeys(e.patterns) {
+		fmt.Fprintf(patterns, " /%s %d 0 R", name, e.patterns[name])
+	}
+
+	e.set(catalogObject, "<< /Type /Catalog /Pages %d 0 R >>", pagesObject)
+	e.set(pagesObject, "<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+	e.set(resourcesObject, "<< /ProcSet [/PDF /Text /ImageC] /Font <<%s >> /XObject <<%s >> /ExtGState <<%s >> /Shading <<%s >> /Pattern <<%s >> >>",
+		fonts.String(), xObjects.String(), states.String(), shadings.String(), patterns.String())
+
+	return e.write(w)
+}
+
+// write serializes the objects, followed by the cross-reference table.
+func (e *encoder) write(w io.Writer) error {
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(e.objects))
+	for i, object := range e.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n", i+1)
+		out.Write(object)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(e.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(e.objects)+1, catalogObject, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// form returns the resource name of the form XObject drawing list, encoding it
+// and the canvases it draws the first time.
+func (e *encoder) form(list *gxui.DisplayList) (string, error) {
+	if name, found := e.forms[list]; found {
+		return name, nil
+	}
+	name := "X" + strconv.Itoa(len(e.forms)+1)
+	object := e.alloc()
+	e.forms[list] = name
+	e.xObjects[name] = object
+
+	content, err := e.content(list)
+	if err != nil {
+		return "", err
+	}
+	e.stream(object, fmt.Sprintf("/Type /XObject /Subtype /Form /BBox %s /Resources %d 0 R", formBBox, resourcesObject), content)
+	return name, nil
+}
+
+// layer returns the resource name of a new transparency group drawing content.
+func (e *encoder) layer(content []byte) string {
+	e.layers++
+	name := "L" + strconv.Itoa(e.layers)
+	object := e.alloc()
+	e.xObjects[name] = object
+	e.stream(object, fmt.Sprintf("/Type /XObject /Subtype /Form /BBox %s /Group << /S /Transparency >> /Resources %d 0 R", formBBox, resourcesObject), content)
+	return name
+}
+
+// content returns the content stream drawing the commands of list. Push and Pop
+// save and restore the graphics state, so clips last until the matching Pop.
+// Layers are drawn as transparency groups, composited when popped.
+func (e *encoder) content(list *gxui.DisplayList) ([]byte, error) {
+	out := &bytes.Buffer{}
+	// The parent content and graphics state of each layer being drawn.
+	type pushedLayer struct {
+		out   *bytes.Buffer
+		state string
+	}
+	var layers []pushedLayer
+	for _, c := range list.Commands() {
+		switch c.Op {
+		case gxui.OpPush:
+			out.WriteString("q\n")
+		case gxui.OpPop:
+			out.WriteString("Q\n")
+		case gxui.OpPushLayer:
+			state := e.state(graphicsState{alpha: math.Saturate(c.Opacity), blend: c.Blend})
+			layers = append(layers, pushedLayer{out: out, state: state})
+			out = &bytes.Buffer{}
+		case gxui.OpPopLayer:
+			l := layers[len(layers)-1]
+			layers = layers[:len(layers)-1]
+			fmt.Fprintf(l.out, "q %s gs /%s Do Q\n", l.state, e.layer(out.Bytes()))
+			out = l.out
+		case gxui.OpAddClip:
+			fmt.Fprintf(out, "%s re W n\n", rect(c.Rect))
+		case gxui.OpTransform:
+			m := c.Matrix
+			fmt.Fprintf(out, "%s %s %s %s %s %s cm\n",
+				number(m[0]), number(m[1]), number(m[3]), number(m[4]), number(m[6]), number(m[7]))
+		case gxui.OpClear:
+			fmt.Fprintf(out, "q %s%s re f Q\n", e.fill(c.Color), rect(list.Size().Rect()))
+		case gxui.OpDrawCanvas:
+			form, err := e.form(c.Canvas)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(out, "q 1 0 0 1 %d %d cm /%s Do Q\n", c.Point.X, c.Point.Y, form)
+		case gxui.OpDrawTexture:
+			e.texture(out, c.Texture, c.Rect)
+		case gxui.OpDrawRunes:
+			if err := e.runes(out, c.Font, c.Runes, c.Points, c.Color); err != nil {
+				return nil, err
+			}
+		case gxui.OpDrawLines:
+			e.lines(out, c.Polygon, c.Pen)
+		case gxui.OpDrawPolygon:
+			e.polygon(out, c.Polygon, c.Pen, c.Brush)
+		case gxui.OpDrawRect:
+			if !c.Brush.IsTransparent() {
+				e.area(out, rect(c.Rect)+" re", gxui.NonZero, c.Brush, c.Rect)
+			}
+		case gxui.OpDrawRoundedRect:
+			r := c.Rect
+			e.polygon(out, gxui.Polygon{
+				{Position: r.TopLeft(), RoundedRadius: c.Radii[0]},
+				{Position: r.TopRight(), RoundedRadius: c.Radii[1]},
+				{Position: r.BottomRight(), RoundedRadius: c.Radii[3]},
+				{Position: r.BottomLeft(), RoundedRadius: c.Radii[2]},
+			}, c.Pen, c.Brush)
+		case gxui.OpFillPath:
+			if path := curveData(c.Path); path != "" && !c.Brush.IsTransparent() {
+				e.area(out, path, c.Rule, c.Brush, c.Path.Bounds())
+			}
+		case gxui.OpStrokePath:
+			if path := curveData(c.Path); path != "" && c.Pen.Width > 0 && c.Pen.Color.A > 0 {
+				fmt.Fprintf(out, "q %s%s S Q\n", e.stroke(c.Pen, c.Pen.Width), path)
+			}
+		case gxui.OpDrawShadow:
+			e.shadow(out, c.Rect, c.Radii, c.Shadow)
+		case gxui.OpBlurBackdrop:
+			// PDF cannot filter what is drawn, so the backdrop is left sharp.
+		default:
+			return nil, fmt.Errorf("pdf: unknown display list op %v", c.Op)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// graphicsState is the constant alpha and blend mode of an ExtGState resource.
+type graphicsState struct {
+	alpha float32
+	blend gxui.BlendMode
+}
+
+// blendModes are the PDF names of the blend modes. PDF has no additive blend
+// mode, so BlendAdd is drawn with Screen, its closest match.
+var blendModes = map[gxui.BlendMode]string{
+	gxui.BlendNormal:   "Normal",
+	gxui.BlendMultiply: "Multiply",
+	gxui.BlendScreen:   "Screen",
+	gxui.BlendAdd:      "Screen",
+}
+
+// state returns the resource name of the ExtGState.
+func (e *encoder) state(state graphicsState) string {
+	name, found := e.states[state]
+	if !found {
+		name = "GS" + strconv.Itoa(len(e.states)+1)
+		e.states[state] = name
+	}
+	return "/" + name
+}
+
+// alpha returns the operator selecting the graphics state with the given
+// constant alpha, or nothing for opaque colors.
+func (e *encoder) alpha(alpha float32) string {
+	if alpha >= 1 {
+		return ""
+	}
+	return e.state(graphicsState{alpha: alpha}) + " gs "
+}
+
+func (e *encoder) fill(c gxui.Color) string {
+	c = c.Saturate()
+	return fmt.Sprintf("%s%s %s %s rg ", e.alpha(c.A), number(c.R), number(c.G), number(c.B))
+}
+
+// area fills the path with brush. Gradients are drawn as shadings clipped to
+// the path, with the alpha of the brush color applied to the whole shading.
+func (e *encoder) area(out *bytes.Buffer, path string, rule gxui.FillRule, brush gxui.Brush, bounds math.Rect) {
+	fill, clip := "f", "W"
+	if rule == gxui.EvenOdd {
+		fill, clip = "f*", "W*"
+	}
+	if brush.Pattern != nil {
+		if name, ok := e.pattern(brush.Pattern, bounds); ok {
+			fmt.Fprintf(out, "q /Pattern cs /%s scn %s %s Q\n", name, path, fill)
+			return
+		}
+	}
+	if brush.Gradient != nil {
+		if matrix, ok := brush.Gradient.Transform(bounds); ok {
+			fmt.Fprintf(out, "q %s %s n %s", path, clip, e.alpha(brush.Color.Saturate().A))
+			for _, v := range matrix {
+				fmt.Fprintf(out, "%s ", number(v))
+			}
+			fmt.Fprintf(out, "cm /%s sh Q\n", e.shading(brush.Gradient))
+			return
+		}
+	}
+	fmt.Fprintf(out, "q %s%s %s Q\n", e.fill(brush.Color), path, fill)
+}
+
+// shadowSteps is the number of outlines approximating a blurred shadow.
+const shadowSteps = 8
+
+// shadow draws the shadow as a transparency group, as PDF has no blur. Blurred
+// shadows are approximated by outlines stepping from the blur radius outside
+// the edge to the blur radius inside, whose alphas ramp the coverage up
+// linearly.
+func (e *encoder) shadow(out *bytes.Buffer, rect math.Rect, radii [4]float32, shadow gxui.Shadow) {
+	if !shadow.IsVisible() {
+		return
+	}
+	steps := 1
+	if shadow.Blur > 0 {
+		steps = shadowSteps
+	}
+	color := shadow.Color.Saturate()
+	opacity := color.A
+	color.A = 1
+
+	group := &bytes.Buffer{}
+	for i := 0; i < steps; i++ {
+		step := shadow
+		if steps > 1 {
+			step.Spread += shadow.Blur * (1 - 2*(float32(i)+0.5)/float32(steps))
+		}
+		if path := step.Path(rect, radii[0], radii[1], radii[2], radii[3]); path != nil {
+			// Over the i steps drawn before, the coverage reaches (i+1)/steps.
+			color.A = 1 / float32(steps-i)
+			e.area(group, curveData(path), gxui.NonZero, gxui.CreateBrush(color), path.Bounds())
+		}
+	}
+	if group.Len() > 0 {
+		fmt.Fprintf(out, "q %s gs /%s Do Q\n", e.state(graphicsState{alpha: opacity}), e.layer(group.Bytes()))
+	}
+}
+
+// shading returns the resource name of the shading drawing gradient in its own
+// space, where a linear gradient goes from (0, 0) to (1, 0) and a radial one
+// from the origin to the unit circle.
+func (e *encoder) shading(gradient *gxui.Gradient) string {
+	if name, found := e.shadings[gradient]; found {
+		return name
+	}
+	name := "Sh" + strconv.Itoa(len(e.shadings)+1)
+	object := e.alloc()
+	e.shadings[gradient] = name
+	e.shadingObjects[name] = object
+
+	geometry := "/ShadingType 2 /Coords [0 0 1 0]"
+	if gradient.Kind == gxui.RadialGradient {
+		geometry = "/ShadingType 3 /Coords [0 0 0 0 0 1]"
+	}
+	e.set(object, "<< %s /ColorSpace /DeviceRGB /Extend [true true] /Function %s >>", geometry, stopsFunction(gradient.Stops))
+	return name
+}
+
+// pattern returns the resource name of a tiling pattern placing the pattern in
+// bounds. As the matrix of a pattern is part of its dictionary, every shape
+// needs its own pattern, sharing the image of the texture. A clamped pattern
+// has a single tile covering the shape, transparent beyond the texture.
+func (e *encoder) pattern(pattern *gxui.Pattern, bounds math.Rect) (string, bool) {
+	matrix := pattern.Matrix(bounds)
+	if _, ok := matrix.Invert(); !ok {
+		return "", false
+	}
+	image := e.imageName(pattern.Texture)
+	size, placements := pattern.Tile()
+	topLeft := math.Vec2{}
+	if pattern.Wrap == gxui.WrapClamp {
+		var bottomRight math.Vec2
+		topLeft, bottomRight = pattern.Extent(bounds)
+		size = bottomRight.Sub(topLeft)
+	}
+
+	// The image is drawn in the unit square, with its first row at the top.
+	texture := pattern.Texture.Size()
+	unit := math.CreateMat3(
+		float32(texture.Width), 0, 0,
+		0, -float32(texture.Height), 0,
+		0, float32(texture.Height), 1,
+	)
+	content := &bytes.Buffer{}
+	for _, t := range placements {
+		fmt.Fprintf(content, "q %s cm /%s Do Q\n", matrixValues(t.Mul(unit)), image)
+	}
+
+	name := "P" + strconv.Itoa(len(e.patterns)+1)
+	object := e.alloc()
+	e.patterns[name] = object
+	e.stream(object, fmt.Sprintf("/Type /Pattern /PatternType 1 /PaintType 1 /TilingType 1 /BBox [%s %s %s %s] "+
+		"/XStep %s /YStep %s /Resources %d 0 R /Matrix [%s]",
+		number(topLeft.X), number(topLeft.Y), number(topLeft.X+size.X), number(topLeft.Y+size.Y),
+		number(size.X), number(size.Y), resourcesObject, matrixValues(matrix)), content.Bytes())
+	return name, true
+}
+
+// stopsFunction returns a function interpolating the stop colors over [0, 1],
+// stitching one exponential function per pair of stops.
+func stopsFunction(stops []gxui.GradientStop) string {
+	if len(stops) == 0 {
+		stops = []gxui.GradientStop{{Color: gxui.Transparent}}
+	}
+	padded := make([]gxui.GradientStop, 0, len(stops)+2)
+	if stops[0].Offset > 0 {
+		padded = append(padded, gxui.GradientStop{Offset: 0, Color: stops[0].Color})
+	}
+	for _, stop := range stops {
+		stop.Offset = math.Clampf(stop.Offset, 0, 1)
+		padded = append(padded, stop)
+	}
+	if last := stops[len(stops)-1]; last.Offset < 1 {
+		padded = append(padded, gxui.GradientStop{Offset: 1, Color: last.Color})
+	}
+	if len(padded) == 1 {
+		return interpolation(padded[0].Color, padded[0].Color)
+	}
+
+	functions, bounds, encode := &strings.Builder{}, &strings.Builder{}, &strings.Builder{}
+	for i := 1; i < len(padded); i++ {
+		fmt.Fprintf(functions, " %s", interpolation(padded[i-1].Color, padded[i].Color))
+		fmt.Fprint(encode, " 0 1")
+		if i < len(padded)-1 {
+			fmt.Fprintf(bounds, " %s", number(padded[i].Offset))
+		}
+	}
+	return fmt.Sprintf("<< /FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s] >>",
+		strings.TrimSpace(functions.String()), strings.TrimSpace(bounds.String()), strings.TrimSpace(encode.String()))
+}
+
+func interpolation(from, to gxui.Color) string {
+	from, to = from.Saturate(), to.Saturate()
+	return fmt.Sprintf("<< /FunctionType 2 /Domain [0 1] /C0 [%s %s %s] /C1 [%s %s %s] /N 1 >>",
+		number(from.R), number(from.G), number(from.B), number(to.R), number(to.G), number(to.B))
+}
+
+func (e *encoder) stroke(pen gxui.Pen, width float32) string {
+	c := pen.Color.Saturate()
+	result := fmt.Sprintf("%s%s %s %s RG %s w ", e.alpha(c.A), number(c.R), number(c.G), number(c.B), number(width))
+	if !pen.IsStyled() {
+		return result
+	}
+	// The caps and joins are numbered as in PDF, whose miter limit defaults
+	// to 10 rather than 4.
+	limit := pen.MiterLimit
+	if limit <= 0 {
+		limit = gxui.DefaultMiterLimit
+	}
+	result += fmt.Sprintf("%d J %d j %s M ", pen.Cap, pen.Join, number(limit))
+	if pen.Dash != nil {
+		total := float32(0)
+		lengths := make([]string, len(pen.Dash.Lengths))
+		for i, l := range pen.Dash.Lengths {
+			if l < 0 {
+				return result
+			}
+			total += l
+			lengths[i] = number(l)
+		}
+		if total > 0 {
+			result += fmt.Sprintf("[%s] %s d ", strings.Join(lengths, " "), number(pen.Dash.Offset))
+		}
+	}
+	return result
+}
+
+func (e *encoder) lines(out *bytes.Buffer, polygon gxui.Polygon, pen gxui.Pen) {
+	if len(polygon) < 2 || pen.Width <= 0 || pen.Color.A <= 0 {
+		return
+	}
+	out.WriteString("q ")
+	out.WriteString(e.stroke(pen, pen.Width))
+	for i, v := range polygon {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(out, "%d %d %s ", v.Position.X, v.Position.Y, op)
+	}
+	out.WriteString("S Q\n")
+}
+
+// polygon draws a filled polygon. As the drivers draw the pen inside the
+// polygon's edge, the stroke is twice the pen width and clipped to the polygon.
+func (e *encoder) polygon(out *bytes.Buffer, polygon gxui.Polygon, pen gxui.Pen, brush gxui.Brush) {
+	path := pathData(polygon)
+	if path == "" {
+		return
+	}
+	if !brush.IsTransparent() {
+		e.area(out, path, gxui.NonZero, brush, polygon.Bounds())
+	}
+	if pen.Width > 0 && pen.Color.A > 0 {
+		fmt.Fprintf(out, "q %s W n %s%s S Q\n", path, e.stroke(pen, 2*pen.Width), path)
+	}
+}
+
+// pathData returns the path operators of the polygon outline, with the rounded
+// corners approximated by cubic Bézier curves.
+func pathData(polygon gxui.Polygon) string {
+	corners := polygon.Corners()
+	if len(corners) < 3 {
+		return ""
+	}
+	d := &strings.Builder{}
+	for i, c := range corners {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(d, "%s %s %s ", number(c.Start.X), number(c.Start.Y), op)
+		if c.Radius > 0 {
+			in := c.Vertex.Sub(c.Start).Normalize()
+			out := c.End.Sub(c.Vertex).Normalize()
+			// Scale the quarter circle constant to the angle turned by the arc.
+			θ := float32(m.Acos(float64(max(-1, min(1, in.Dot(out))))))
+			k := c.Radius * float32(4.0/3.0*m.Tan(float64(θ)/4))
+			c1, c2 := c.Start.Add(in.MulS(k)), c.End.Sub(out.MulS(k))
+			fmt.Fprintf(d, "%s %s %s %s %s %s c ",
+				number(c1.X), number(c1.Y), number(c2.X), number(c2.Y), number(c.End.X), number(c.End.Y))
+		}
+	}
+	d.WriteString("h")
+	return d.String()
+}
+
+// curveData returns the path operators of the path, with the quadratic curves
+// raised to cubic ones.
+func curveData(path *gxui.Path) string {
+	d := &strings.Builder{}
+	point := func(p math.Vec2) string { return number(p.X) + " " + number(p.Y) }
+	start, current, open := math.Vec2{}, math.Vec2{}, false
+	for _, s := range path.Segments {
+		if s.Op != gxui.PathMoveTo && s.Op != gxui.PathClose && !open {
+			fmt.Fprintf(d, "%s m ", point(current))
+			start = current
+		}
+		open = s.Op != gxui.PathClose
+		switch s.Op {
+		case gxui.PathMoveTo:
+			fmt.Fprintf(d, "%s m ", point(s.Points[0]))
+			start, current = s.Points[0], s.Points[0]
+		case gxui.PathLineTo:
+			fmt.Fprintf(d, "%s l ", point(s.Points[0]))
+			current = s.Points[0]
+		case gxui.PathQuadTo:
+			c1 := current.Add(s.Points[0].Sub(current).MulS(2.0 / 3))
+			c2 := s.Points[1].Add(s.Points[0].Sub(s.Points[1]).MulS(2.0 / 3))
+			fmt.Fprintf(d, "%s %s %s c ", point(c1), point(c2), point(s.Points[1]))
+			current = s.Points[1]
+		case gxui.PathCubicTo:
+			fmt.Fprintf(d, "%s %s %s c ", point(s.Points[0]), point(s.Points[1]), point(s.Points[2]))
+			current = s.Points[2]
+		case gxui.PathClose:
+			d.WriteString("h ")
+			current = start
+		}
+	}
+	return strings.TrimSuffix(d.String(), " ")
+}
+
+// imageName returns the resource name of the image XObject of the texture.
+func (e *encoder) imageName(texture gxui.Texture) string {
+	name, found := e.images[texture]
+	if !found {
+		name = "I" + strconv.Itoa(len(e.images)+1)
+		e.images[texture] = name
+		e.xObjects[name] = e.image(texture.Image())
+	}
+	return name
+}
+
+func (e *encoder) texture(out *bytes.Buffer, texture gxui.Texture, r math.Rect) {
+	name := e.imageName(texture)
+
+	// Images are drawn in a unit square, with the first row at the top.
+	if texture.FlipY() {
+		fmt.Fprintf(out, "q %d 0 0 %d %d %d cm /%s Do Q\n", r.Width(), r.Height(), r.Min.X, r.Min.Y, name)
+	} else {
+		fmt.Fprintf(out, "q %d 0 0 %d %d %d cm /%s Do Q\n", r.Width(), -r.Height(), r.Min.X, r.Max.Y, name)
+	}
+}
+
+// image writes img as an RGB image XObject, with a soft mask for its alpha.
+func (e *encoder) image(img image.Image) int {
+	bounds := img.Bounds()
+	rgb := make([]byte, 0, 3*bounds.Dx()*bounds.Dy())
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			opaque = opaque && c.A == 0xff
+		}
+	}
+
+	entries := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8", bounds.Dx(), bounds.Dy())
+	object := e.alloc()
+	if opaque {
+		e.stream(object, entries+" /ColorSpace /DeviceRGB", rgb)
+	} else {
+		mask := e.alloc()
+		e.stream(mask, entries+" /ColorSpace /DeviceGray", alpha)
+		e.stream(object, fmt.Sprintf("%s /ColorSpace /DeviceRGB /SMask %d 0 R", entries, mask), rgb)
+	}
+	return object
+}
+
+func (e *encoder) runes(out *bytes.Buffer, font gxui.Font, runes []rune, points []math.Point, c gxui.Color) error {
+	if len(runes) == 0 {
+		return nil
+	}
+	embedded, err := e.font(font)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "q %sBT /%s %d Tf\n", e.fill(c), embedded.name, font.Size())
+	for i, r := range runes {
+		p := points[i]
+		// The text matrix flips the glyphs back up, as the page is flipped.
+		fmt.Fprintf(out, "1 0 0 -1 %d %d Tm <%04x> Tj\n", p.X, p.Y, embedded.glyph(r))
+	}
+	out.WriteString("ET Q\n")
+	return nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matrixValues returns the six values of the PDF matrix of m.
+func matrixValues(m math.Mat3) string {
+	return strings.Join([]string{number(m[0]), number(m[1]), number(m[3]), number(m[4]), number(m[6]), number(m[7])}, " ")
+}
+
+func rect(r math.Rect) string {
+	return fmt.Sprintf("%d %d %d %d", r.Min.X, r.Min.Y, r.Width(), r.Height())
+}
+
+// number formats v with at most 3 decimals, which hides float32 noise.
+func number(v float32) string {
+	rounded := m.Round(float64(v)*1000) / 1000
+	if rounded == 0 {
+		rounded = 0 // Drop the sign of negative zero.
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/badu/gxui"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+)
+
+// PDF glyph metrics are in thousandths of the font size.
+const glyphSpace = fixed.Int26_6(1000)
+
+// Number of mappings allowed in a single bfchar block of a ToUnicode CMap.
+const cmapBlockSize = 100
+
+// embeddedFont is a TrueType font written to the document as a composite font,
+// with the glyphs addressed by their two byte index.
+type embeddedFont struct {
+	name   string
+	number int
+	data   []byte
+	ttf    *truetype.Font
+	glyphs map[uint16]rune
+}
+
+// font returns the embedded font for f. Fonts of different sizes loaded from the
+// same data share a single embedded font.
+func (e *encoder) font(f gxui.Font) (*embeddedFont, error) {
+	if result, found := e.fonts[f]; found {
+		return result, nil
+	}
+	data := f.Data()
+	if len(data) == 0 {
+		return nil, fmt.Errorf("pdf: font %q has no TrueType data to embed", f.Name())
+	}
+
+	result, found := e.fontData[&data[0]]
+	if !found {
+		ttf, err := truetype.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("pdf: font %q: %v", f.Name(), err)
+		}
+		number := len(e.fontData) + 1
+		result = &embeddedFont{
+			name:   fmt.Sprintf("F%d", number),
+			number: number,
+			data:   data,
+			ttf:    ttf,
+			glyphs: make(map[uint16]rune),
+		}
+		e.fontData[&data[0]] = result
+	}
+	e.fonts[f] = result
+	return result, nil
+}
+
+// glyph returns the index of the glyph drawing r, marking it as used.
+func (f *embeddedFont) glyph(r rune) uint16 {
+	index := uint16(f.ttf.Index(r))
+	if _, found := f.glyphs[index]; !found {
+		f.glyphs[index] = r
+	}
+	return index
+}
+
+// used returns the indices of the glyphs drawn, in ascending order.
+func (f *embeddedFont) used() []uint16 {
+	result := make([]uint16, 0, len(f.glyphs))
+	for index := range f.glyphs {
+		result = append(result, index)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// baseName returns the PostScript name of the font, prefixed by a subset tag
+// derived from the font number, as the names of subset fonts must be.
+func (f *embeddedFont) baseName() string {
+	name := f.ttf.Name(truetype.NameIDPostscriptName)
+	if name == "" {
+		name = f.ttf.Name(truetype.NameIDFontFullName)
+	}
+	name = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Font"
+	}
+
+	tag := []byte("AAAAAA")
+	for i, n := len(tag)-1, f.number; n > 0 && i >= 0; i, n = i-1, n/26 {
+		tag[i] = 'A' + byte(n%26)
+	}
+	return string(tag) + "+" + name
+}
+
+// embed writes the font objects, returning the object number of the Type0 font.
+func (e *encoder) embed(f *embeddedFont) (int, error) {
+	used := f.used()
+	keep := make(map[uint16]bool, len(used))
+	for _, index := range used {
+		keep[index] = true
+	}
+	subset, err := subsetFont(f.data, keep)
+	if err != nil {
+		return 0, fmt.Errorf("pdf: subsetting %q: %v", f.ttf.Name(truetype.NameIDFontFullName), err)
+	}
+
+	fontObject, cidObject, descriptorObject, fileObject, unicodeObject := e.alloc(), e.alloc(), e.alloc(), e.alloc(), e.alloc()
+	baseName := f.baseName()
+
+	e.stream(fileObject, fmt.Sprintf("/Length1 %d", len(subset)), subset)
+	e.stream(unicodeObject, "", toUnicode(f.glyphs, used))
+
+	b := f.ttf.Bounds(glyphSpace)
+	e.set(descriptorObject, "<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseName, b.Min.X, b.Min.Y, b.Max.X, b.Max.Y, b.Max.Y, b.Min.Y, b.Max.Y, fileObject)
+
+	widths := &strings.Builder{}
+	for _, index := range used {
+		metric := f.ttf.HMetric(glyphSpace, truetype.Index(index))
+		fmt.Fprintf(widths, "%d [%d] ", index, metric.AdvanceWidth)
+	}
+	e.set(cidObject, "<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+		baseName, descriptorObject, strings.TrimSpace(widths.String()))
+
+	e.set(fontObject, "<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseName, cidObject, unicodeObject)
+	return fontObject, nil
+}
+
+// toUnicode returns the CMap mapping the used glyphs back to their runes, so
+// that the text of the document can be searched and copied.
+func toUnicode(glyphs map[uint16]rune, used []uint16) []byte {
+	out := &bytes.Buffer{}
+	out.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(used); start += cmapBlockSize {
+		end := min(start+cmapBlockSize, len(used))
+		fmt.Fprintf(out, "%d beginbfchar\n", end-start)
+		for _, index := range used[start:end] {
+			fmt.Fprintf(out, "<%04X> <%s>\n", index, utf16Hex(glyphs[index]))
+		}
+		out.WriteString("endbfchar\n")
+	}
+	out.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return out.Bytes()
+}
+
+func utf16Hex(r rune) string {
+	if r >= 0x10000 {
+		r -= 0x10000
+		return fmt.Sprintf("%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+	}
+	return fmt.Sprintf("%04X", r)
+}
@@ -0,0 +1,193 @@
+// Package pdf prints canvases and control trees to multi-page PDF documents.
+//
+// Only display lists can be printed, as canvases created by the GL drivers
+// cannot be inspected. Controls must be created with a gxui.RecordingDriver.
+// One DIP is printed as one point (1/72 inch), and text is written with
+// subsets of the TrueType fonts the controls were created with.
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// ErrNotDisplayList is returned when a printed canvas is not a *gxui.DisplayList.
+var ErrNotDisplayList = errors.New("pdf: canvas is not a display list")
+
+// Standard page sizes, in points.
+var (
+	A4     = math.Size{Width: 595, Height: 842}
+	Letter = math.Size{Width: 612, Height: 792}
+)
+
+// PageLayout describes the pages a control is printed on.
+type PageLayout struct {
+	// Size is the size of the page in points.
+	Size math.Size
+	// Margins are the space around the printed content, in points.
+	Margins math.Spacing
+}
+
+// placement is a canvas drawn at offset on a page, clipped to clip.
+type placement struct {
+	canvas *gxui.DisplayList
+	offset math.Point
+	clip   math.Rect
+}
+
+type page struct {
+	size       math.Size
+	placements []placement
+}
+
+// Document is a list of pages to be encoded as a PDF file.
+type Document struct {
+	pages []page
+}
+
+func CreateDocument() *Document {
+	return &Document{}
+}
+
+// PageCount returns the number of pages added to the document.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// AddPage adds a page of the given size in points, with the completed canvas
+// drawn at its top-left corner.
+func (d *Document) AddPage(size math.Size, canvas gxui.Canvas) error {
+	list, ok := canvas.(*gxui.DisplayList)
+	if !ok {
+		return ErrNotDisplayList
+	}
+	if !list.IsComplete() {
+		return errors.New("pdf: canvas is not complete")
+	}
+	d.pages = append(d.pages, page{size: size, placements: []placement{{canvas: list, clip: size.Rect()}}})
+	return nil
+}
+
+// AddControl lays out control at the width of the area inside the page
+// margins, and adds as many pages as required to print all of its height.
+// Pages are broken above any line of text that would otherwise be cut.
+// A detached control is attached for printing, then detached again.
+// AddControl must be called on the UI go-routine.
+func (d *Document) AddControl(control gxui.Control, layout PageLayout) error {
+	content := layout.Size.Contract(layout.Margins)
+	if content.Width <= 0 || content.Height <= 0 {
+		return fmt.Errorf("pdf: the margins leave no space on pages of size %v", layout.Size)
+	}
+
+	if !control.Attached() {
+		control.Attach()
+		defer control.Detach()
+	}
+
+	size := control.DesiredSize(
+		math.Size{Width: content.Width},
+		math.Size{Width: content.Width, Height: math.MaxSize.Height},
+	)
+	control.SetSize(size)
+	canvas := control.Draw()
+	if canvas == nil {
+		return errors.New("pdf: control has no area to print")
+	}
+	list, ok := canvas.(*gxui.DisplayList)
+	if !ok {
+		return ErrNotDisplayList
+	}
+
+	origin := layout.Margins.TopLeft()
+	for _, b := range pageBreaks(list, content.Height) {
+		d.pages = append(d.pages, page{
+			size: layout.Size,
+			placements: []placement{{
+				canvas: list,
+				offset: origin.Sub(math.Point{Y: b.start}),
+				clip:   math.CreateRect(origin.X, origin.Y, origin.X+content.Width, origin.Y+b.end-b.start),
+			}},
+		})
+	}
+	return nil
+}
+
+// Encode writes the document to w.
+func (d *Document) Encode(w io.Writer) error {
+	if len(d.pages) == 0 {
+		return errors.New("pdf: document has no pages")
+	}
+	e := newEncoder()
+	return e.encode(w, d.pages)
+}
+
+// Print writes control to w as a PDF document. See Document.AddControl.
+func Print(w io.Writer, control gxui.Control, layout PageLayout) error {
+	document := CreateDocument()
+	if err := document.AddControl(control, layout); err != nil {
+		return err
+	}
+	return document.Encode(w)
+}
+
+// span is a vertical range [start, end) of a canvas.
+type span struct {
+	start, end int
+}
+
+// textSpans appends the vertical extent of every text run drawn by list, in
+// the coordinates of the root canvas.
+func textSpans(list *gxui.DisplayList, offset math.Point, ascents map[gxui.Font]int, spans []span) []span {
+	for _, c := range list.Commands() {
+		switch c.Op {
+		case gxui.OpDrawCanvas:
+			spans = textSpans(c.Canvas, offset.Add(c.Point), ascents, spans)
+		case gxui.OpDrawRunes:
+			ascent, found := ascents[c.Font]
+			if !found {
+				// The offset of a top aligned rune is the ascent of the font.
+				ascent = c.Font.Layout(&gxui.TextBlock{Runes: []rune{'X'}, V: gxui.AlignTop})[0].Y
+				ascents[c.Font] = ascent
+			}
+			height := c.Font.GlyphMaxSize().Height
+			for _, p := range c.Points {
+				top := p.Y + offset.Y - ascent
+				spans = append(spans, span{start: top, end: top + height})
+			}
+		}
+	}
+	return spans
+}
+
+// pageBreaks splits the height of list into pages no taller than pageHeight,
+// moving each break up to the top of any text it would cut.
+func pageBreaks(list *gxui.DisplayList, pageHeight int) []span {
+	spans := textSpans(list, math.ZeroPoint, make(map[gxui.Font]int), nil)
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var result []span
+	height := list.Size().Height
+	for start := 0; start < height; {
+		end := start + pageHeight
+		if end < height {
+			for moved := true; moved; {
+				moved = false
+				for _, s := range spans {
+					if s.start > start && s.start < end && s.end > end {
+						end, moved = s.start, true
+					}
+				}
+			}
+		} else {
+			end = height
+		}
+		result = append(result, span{start: start, end: end})
+		start = end
+	}
+	return result
+}
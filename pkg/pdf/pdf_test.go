@@ -0,0 +1,420 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/drivers/soft"
+	"github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_golden"
+)
+
+var report = PageLayout{
+	Size:    math.Size{Width: 200, Height: 150},
+	Margins: math.Spacing{Left: 10, Top: 20, Right: 10, Bottom: 20},
+}
+
+func printLabels(t *testing.T, count int) *Document {
+	inner := soft.NewDriver()
+	defer inner.Terminate()
+	driver := gxui.CreateRecordingDriver(inner, nil)
+
+	document := CreateDocument()
+	driver.CallSync(func() {
+		styles := test_golden.DarkTheme(driver)
+		layout := gxui.CreateLinearLayout(driver, styles)
+		for i := 0; i < count; i++ {
+			label := gxui.CreateLabel(driver, styles)
+			label.SetText(fmt.Sprintf("Line %d of the report", i))
+			layout.AddChild(label)
+		}
+		if err := document.AddControl(layout, report); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return document
+}
+
+func TestAddControlBreaksPages(t *testing.T) {
+	document := printLabels(t, 40)
+	if document.PageCount() < 2 {
+		t.Fatalf("Expected several pages, got %d", document.PageCount())
+	}
+
+	list := document.pages[0].placements[0].canvas
+	spans := textSpans(list, math.ZeroPoint, make(map[gxui.Font]int), nil)
+	content := report.Size.Contract(report.Margins)
+	next := 0
+	for i, p := range document.pages {
+		placement := p.placements[0]
+		start := -placement.offset.Y + report.Margins.Top
+		end := start + placement.clip.Height()
+		if start != next {
+			t.Errorf("Page %d starts at %d, expected %d", i, start, next)
+		}
+		if end-start > content.Height {
+			t.Errorf("Page %d is %d high, more than the %d available", i, end-start, content.Height)
+		}
+		for _, s := range spans {
+			if s.start < end && s.end > end && end < list.Size().Height {
+				t.Errorf("Page %d ends at %d, cutting text spanning [%d, %d)", i, end, s.start, s.end)
+			}
+		}
+		next = end
+	}
+	if next != list.Size().Height {
+		t.Errorf("Pages end at %d, expected %d", next, list.Size().Height)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	document := printLabels(t, 40)
+	buffer := &bytes.Buffer{}
+	if err := document.Encode(buffer); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.Bytes()
+
+	for _, want := range []string{
+		"%PDF-1.7",
+		"/Type /Pages /Kids [",
+		fmt.Sprintf("/Count %d", document.PageCount()),
+		"/MediaBox [0 0 200 150]",
+		"/Subtype /Type0",
+		"/FontFile2",
+		"/ToUnicode",
+		"%%EOF",
+	} {
+		if !bytes.Contains(doc, []byte(want)) {
+			t.Errorf("Expected the PDF to contain %q", want)
+		}
+	}
+
+	// Every cross-reference entry must point at the start of its object.
+	xref := bytes.LastIndex(doc, []byte("startxref\n"))
+	start, err := strconv.Atoi(strings.Fields(string(doc[xref+len("startxref\n"):]))[0])
+	if err != nil || !bytes.HasPrefix(doc[start:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at the cross-reference table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(doc[start:], -1)
+	if len(entries) == 0 {
+		t.Fatal("The cross-reference table is empty")
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(doc[offset:], []byte(want)) {
+			t.Errorf("Cross-reference entry %d does not point at %q", i+1, want)
+		}
+	}
+}
+
+func TestEncodeShapes(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.Push()
+	list.AddClip(math.CreateRect(0, 0, 40, 40))
+	list.DrawRoundedRect(math.CreateRect(0, 0, 20, 10), 5, 5, 0, 0, gxui.CreatePen(1, gxui.Red), gxui.CreateBrush(gxui.Color{R: 1, A: 0.5}))
+	list.Pop()
+	list.Complete()
+
+	e := newEncoder()
+	content, err := e.content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"q\n0 0 40 40 re W n\n",
+		"/GS1 gs 1 0 0 rg 0 5 m 0 2.239 2.239 0 5 0 c 15 0 l 17.761 0 20 2.239 20 5 c 20 10 l 0 10 l h f Q",
+		"2 w ",
+	} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("Expected the content to contain %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestEncodeStyledPens(t *testing.T) {
+	dashed := gxui.CreateDashedPen(2, gxui.Red, 1, 3, 1.5)
+	dashed.Cap = gxui.SquareCap
+	beveled := gxui.CreatePen(1, gxui.Blue)
+	beveled.Join = gxui.BevelJoin
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.DrawLines(gxui.Polygon{{Position: math.Point{X: 1, Y: 1}}, {Position: math.Point{X: 5, Y: 9}}}, dashed)
+	list.DrawRoundedRect(math.CreateRect(10, 10, 20, 20), 0, 0, 0, 0, beveled, gxui.TransparentBrush)
+	list.DrawLines(gxui.Polygon{{Position: math.Point{X: 1, Y: 1}}, {Position: math.Point{X: 5, Y: 9}}}, gxui.DefaultPen)
+	list.Complete()
+
+	e := newEncoder()
+	content, err := e.content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"2 w 2 J 0 j 4 M [3 1.5] 1 d 1 1 m 5 9 l S Q",
+		"2 w 0 J 2 j 4 M 10 10 m",
+		"0 0 0 RG 1 w 1 1 m 5 9 l S Q",
+	} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("Expected the content to contain %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestEncodePaths(t *testing.T) {
+	path := &gxui.Path{}
+	path.MoveTo(math.Vec2{X: 0, Y: 0})
+	path.LineTo(math.Vec2{X: 9, Y: 0})
+	path.QuadTo(math.Vec2{X: 18, Y: 6}, math.Vec2{X: 9, Y: 12})
+	path.Close()
+	path.LineTo(math.Vec2{X: 0, Y: 20})
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.FillPath(path, gxui.EvenOdd, gxui.CreateBrush(gxui.Red))
+	list.StrokePath(path, gxui.CreatePen(1, gxui.Blue))
+	list.Complete()
+
+	e := newEncoder()
+	content, err := e.content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The quadratic curve is raised to a cubic one, and a new sub-path
+	// starts where the closed one did.
+	d := "0 0 m 9 0 l 15 4 15 8 9 12 c h 0 0 m 0 20 l"
+	for _, want := range []string{
+		"q 1 0 0 rg " + d + " f* Q",
+		"q 0 0 1 RG 1 w " + d + " S Q",
+	} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("Expected the content to contain %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestEncodeTransforms(t *testing.T) {
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.Push()
+	list.Transform(math.CreateMat3Translate(10, 20).Mul(math.CreateMat3Scale(2, 3)))
+	list.DrawRect(math.CreateRect(0, 0, 5, 5), gxui.CreateBrush(gxui.Red))
+	list.Pop()
+	list.Complete()
+
+	e := newEncoder()
+	content, err := e.content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "q\n2 0 0 3 10 20 cm\n"
+	if !bytes.Contains(content, []byte(want)) {
+		t.Errorf("Expected the content to contain %q:\n%s", want, content)
+	}
+}
+
+func TestEncodeLayers(t *testing.T) {
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.PushLayer(0.5, gxui.BlendMultiply)
+	list.DrawRect(math.CreateRect(0, 0, 5, 5), gxui.CreateBrush(gxui.Red))
+	list.PopLayer()
+	list.Complete()
+
+	out := &bytes.Buffer{}
+	doc := CreateDocument()
+	if err := doc.AddPage(list.Size(), list); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Encode(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"/GS1 << /ca 0.5 /CA 0.5 /BM /Multiply >>",
+		"/L1 ",
+		"/Group << /S /Transparency >>",
+	} {
+		if !bytes.Contains(out.Bytes(), []byte(want)) {
+			t.Errorf("Expected the document to contain %q", want)
+		}
+	}
+
+	content, err := newEncoder().content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "q /GS1 gs /L1 Do Q\n"
+	if string(content) != want {
+		t.Errorf("Expected the content %q, got %q", want, content)
+	}
+}
+
+func TestEncodeShadows(t *testing.T) {
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.BlurBackdrop(math.CreateRect(0, 0, 20, 20), 4)
+	list.DrawShadow(math.CreateRect(10, 10, 30, 20), 4, 4, 4, 4, gxui.Shadow{Blur: 4, Color: gxui.Color{A: 0.5}})
+	list.DrawShadow(math.CreateRect(10, 10, 30, 20), 0, 0, 0, 0, gxui.Shadow{Spread: -10, Color: gxui.Black})
+	list.Complete()
+
+	e := newEncoder()
+	content, err := e.content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The shadow shrunk to nothing by its spread is not drawn.
+	want := fmt.Sprintf("q %s gs /L1 Do Q\n", e.state(graphicsState{alpha: 0.5}))
+	if string(content) != want {
+		t.Errorf("Expected the content %q, got %q", want, content)
+	}
+
+	object := e.objects[e.xObjects["L1"]-1]
+	start := bytes.Index(object, []byte("stream\n")) + len("stream\n")
+	reader, err := zlib.NewReader(bytes.NewReader(object[start:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fills := strings.Count(string(group), " f Q"); fills != shadowSteps {
+		t.Errorf("Expected the blur to be drawn with %d outlines, got %d:\n%s", shadowSteps, fills, group)
+	}
+}
+
+func TestEncodeGradients(t *testing.T) {
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.DrawRect(math.CreateRect(10, 0, 30, 10), gxui.CreateLinearGradientBrush(0,
+		gxui.GradientStop{Offset: 0.25, Color: gxui.Red},
+		gxui.GradientStop{Offset: 0.5, Color: gxui.Green},
+		gxui.GradientStop{Offset: 1, Color: gxui.Blue}))
+	list.DrawPolygon(gxui.Polygon{
+		{Position: math.Point{X: 0, Y: 20}},
+		{Position: math.Point{X: 40, Y: 20}},
+		{Position: math.Point{X: 40, Y: 40}},
+	}, gxui.TransparentPen, gxui.CreateRadialGradientBrush(math.Vec2{X: 0.5, Y: 0.5}, 0.5,
+		gxui.GradientStop{Offset: 0, Color: gxui.White},
+		gxui.GradientStop{Offset: 1, Color: gxui.Black}))
+	list.Complete()
+
+	e := newEncoder()
+	content, err := e.content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"q 10 0 20 10 re W n 20 0 0 20 10 5 cm /Sh1 sh Q",
+		"W n 20 0 0 10 20 30 cm /Sh2 sh Q",
+	} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("Expected the content to contain %q:\n%s", want, content)
+		}
+	}
+
+	shadings := map[int]string{1: "/ShadingType 2 /Coords [0 0 1 0]", 2: "/ShadingType 3 /Coords [0 0 0 0 0 1]"}
+	for i, want := range shadings {
+		object := e.objects[e.shadingObjects[fmt.Sprintf("Sh%d", i)]-1]
+		if !bytes.Contains(object, []byte(want)) {
+			t.Errorf("Expected shading %d to contain %q, got %s", i, want, object)
+		}
+	}
+	// The first stop is padded back to offset 0.
+	if object := string(e.objects[e.shadingObjects["Sh1"]-1]); !strings.Contains(object, "/Bounds [0.25 0.5] /Encode [0 1 0 1 0 1]") {
+		t.Errorf("Expected the stops to be stitched at 0.25 and 0.5, got %s", object)
+	}
+}
+
+func TestEncodePatterns(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	texture := driver.CreateTexture(image.NewRGBA(image.Rect(0, 0, 4, 2)), 1)
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.DrawRect(math.CreateRect(10, 0, 30, 10), gxui.CreatePatternBrush(texture, gxui.WrapRepeat, math.Mat3Ident))
+	list.DrawRoundedRect(math.CreateRect(0, 30, 20, 50), 5, 5, 5, 5, gxui.TransparentPen,
+		gxui.CreatePatternBrush(texture, gxui.WrapMirror, math.CreateMat3Scale(2, 2)))
+	list.DrawRect(math.CreateRect(30, 30, 50, 50), gxui.CreatePatternBrush(texture, gxui.WrapClamp, math.Mat3Ident))
+	list.Complete()
+
+	e := newEncoder()
+	content, err := e.content(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "q /Pattern cs /P1 scn 10 0 20 10 re f Q"; !bytes.Contains(content, []byte(want)) {
+		t.Errorf("Expected the content to contain %q:\n%s", want, content)
+	}
+	if len(e.images) != 1 {
+		t.Errorf("Expected the patterns to share one image, got %d", len(e.images))
+	}
+
+	for name, want := range map[string]string{
+		"P1": "/BBox [0 0 4 2] /XStep 4 /YStep 2 /Resources 3 0 R /Matrix [1 0 0 1 10 0]",
+		"P2": "/BBox [0 0 8 4] /XStep 8 /YStep 4 /Resources 3 0 R /Matrix [2 0 0 2 0 30]",
+		"P3": "/BBox [0 0 20 20] /XStep 20 /YStep 20 /Resources 3 0 R /Matrix [1 0 0 1 30 30]",
+	} {
+		if object := e.objects[e.patterns[name]-1]; !bytes.Contains(object, []byte(want)) {
+			t.Errorf("Expected pattern %s to contain %q, got %s", name, want, object)
+		}
+	}
+}
+
+func TestSubsetFont(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+	f, err := driver.CreateFont(font.Default, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEncoder()
+	embedded, err := e.font(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, unused := embedded.glyph('A'), uint16(embedded.ttf.Index('B'))
+	subset, err := subsetFont(embedded.data, map[uint16]bool{used: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subset) >= len(embedded.data) {
+		t.Errorf("Expected the subset to be smaller than the %d bytes font, got %d", len(embedded.data), len(subset))
+	}
+	if sum := checksum(subset); sum != 0xB1B0AFBA {
+		t.Errorf("Expected the font checksum to be 0xB1B0AFBA, got %#x", sum)
+	}
+
+	tables, err := parseTables(subset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offsets, err := glyphOffsets(tables["head"], tables["maxp"], tables["loca"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offsets[used] == offsets[used+1] {
+		t.Errorf("Expected the outline of the used glyph %d to be kept", used)
+	}
+	if offsets[unused] != offsets[unused+1] {
+		t.Errorf("Expected the outline of the unused glyph %d to be dropped", unused)
+	}
+}
+
+func TestAddPageRequiresDisplayList(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	canvas := driver.CreateCanvas(math.Size{Width: 1, Height: 1})
+	canvas.Complete()
+	if err := CreateDocument().AddPage(A4, canvas); err != ErrNotDisplayList {
+		t.Errorf("Expected ErrNotDisplayList, got %v", err)
+	}
+}
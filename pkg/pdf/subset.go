@@ -0,0 +1,251 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The tables kept in a subset font, sorted by tag as the table directory must
+// be. The cmap is dropped, as the PDF addresses glyphs by index.
+var subsetTables = []string{"OS/2", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// Flags of the components of composite glyphs.
+const (
+	argsAreWords    = 0x0001
+	haveScale       = 0x0008
+	moreComponents  = 0x0020
+	haveXYScale     = 0x0040
+	haveTwoByTwo    = 0x0080
+	compositeHeader = 10
+)
+
+type sfntTable struct {
+	tag  string
+	data []byte
+}
+
+func parseTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("font is too short")
+	}
+	if tag := string(data[:4]); tag == "ttcf" || tag == "OTTO" {
+		return nil, fmt.Errorf("cannot subset %q fonts", tag)
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errors.New("font table directory is truncated")
+	}
+
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		tag := string(record[:4])
+		offset := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("font table %q is out of bounds", tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// glyphOffsets returns the start of each glyph in the glyf table, plus the end
+// of the last one.
+func glyphOffsets(head, maxp, loca []byte) ([]int, error) {
+	if len(head) < 54 || len(maxp) < 6 {
+		return nil, errors.New("font head or maxp table is truncated")
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	longOffsets := binary.BigEndian.Uint16(head[50:]) != 0
+
+	offsets := make([]int, numGlyphs+1)
+	for i := range offsets {
+		if longOffsets {
+			if len(loca) < 4*(i+1) {
+				return nil, errors.New("font loca table is truncated")
+			}
+			offsets[i] = int(binary.BigEndian.Uint32(loca[4*i:]))
+		} else {
+			if len(loca) < 2*(i+1) {
+				return nil, errors.New("font loca table is truncated")
+			}
+			offsets[i] = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+	}
+	return offsets, nil
+}
+
+// components returns the glyphs a composite glyph is built from.
+func components(glyph []byte) []uint16 {
+	if len(glyph) < compositeHeader || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+
+	var result []uint16
+	for offset := compositeHeader; offset+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[offset:])
+		result = append(result, binary.BigEndian.Uint16(glyph[offset+2:]))
+		offset += 4
+		if flags&argsAreWords != 0 {
+			offset += 4
+		} else {
+			offset += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			offset += 2
+		case flags&haveXYScale != 0:
+			offset += 4
+		case flags&haveTwoByTwo != 0:
+			offset += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return result
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// subsetFont returns a copy of the TrueType font data keeping only the
+// outlines of the glyphs used, and those they are composed of. Glyph indices
+// are preserved, so unused glyphs are left empty rather than removed.
+func subsetFont(data []byte, used map[uint16]bool) ([]byte, error) {
+	tables, err := parseTables(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range []string{"head", "maxp", "loca", "glyf", "hhea", "hmtx"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("font has no %q table", tag)
+		}
+	}
+
+	offsets, err := glyphOffsets(tables["head"], tables["maxp"], tables["loca"])
+	if err != nil {
+		return nil, err
+	}
+	glyf := tables["glyf"]
+	glyph := func(index uint16) []byte {
+		if int(index)+1 >= len(offsets) {
+			return nil
+		}
+		start, end := offsets[index], offsets[index+1]
+		if start >= end || end > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	// Glyph 0 is the missing glyph, and must always be present.
+	keep := map[uint16]bool{0: true}
+	pending := []uint16{0}
+	for index := range used {
+		if !keep[index] {
+			keep[index] = true
+			pending = append(pending, index)
+		}
+	}
+	for len(pending) > 0 {
+		index := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, component := range components(glyph(index)) {
+			if !keep[component] {
+				keep[component] = true
+				pending = append(pending, component)
+			}
+		}
+	}
+
+	numGlyphs := len(offsets) - 1
+	newGlyf := []byte{}
+	newLoca := make([]byte, 4*(numGlyphs+1))
+	for i := 0; i < numGlyphs; i++ {
+		binary.BigEndian.PutUint32(newLoca[4*i:], uint32(len(newGlyf)))
+		if keep[uint16(i)] {
+			newGlyf = append(newGlyf, glyph(uint16(i))...)
+			for len(newGlyf)%4 != 0 {
+				newGlyf = append(newGlyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*numGlyphs:], uint32(len(newGlyf)))
+
+	head := append([]byte{}, tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment
+	binary.BigEndian.PutUint16(head[50:], 1) // indexToLocFormat: long
+
+	var out []sfntTable
+	for _, tag := range subsetTables {
+		switch tag {
+		case "glyf":
+			out = append(out, sfntTable{tag, newGlyf})
+		case "loca":
+			out = append(out, sfntTable{tag, newLoca})
+		case "head":
+			out = append(out, sfntTable{tag, head})
+		default:
+			if table, found := tables[tag]; found {
+				out = append(out, sfntTable{tag, table})
+			}
+		}
+	}
+	return writeFont(out), nil
+}
+
+// writeFont serializes the tables into a TrueType font file.
+func writeFont(tables []sfntTable) []byte {
+	numTables := len(tables)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= numTables {
+		searchRange *= 2
+		entrySelector++
+	}
+	searchRange *= 16
+
+	header := make([]byte, 12+16*numTables)
+	binary.BigEndian.PutUint32(header[0:], 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(numTables*16-searchRange))
+
+	offset, headOffset := len(header), -1
+	for i, table := range tables {
+		record := header[12+16*i:]
+		copy(record, table.tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(table.data))
+		binary.BigEndian.PutUint32(record[8:], uint32(offset))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table.data)))
+		if table.tag == "head" {
+			headOffset = offset
+		}
+		offset += (len(table.data) + 3) &^ 3
+	}
+
+	result := make([]byte, 0, offset)
+	result = append(result, header...)
+	for _, table := range tables {
+		result = append(result, table.data...)
+		for len(result)%4 != 0 {
+			result = append(result, 0)
+		}
+	}
+
+	if headOffset >= 0 {
+		binary.BigEndian.PutUint32(result[headOffset+8:], 0xB1B0AFBA-checksum(result))
+	}
+	return result
+}
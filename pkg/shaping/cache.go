@@ -0,0 +1,42 @@
+package shaping
+
+import "sync"
+
+// Cache holds the glyphs of the runs shaped recently, by font and run. It is
+// safe for concurrent use.
+type Cache struct {
+	mutex sync.Mutex
+	limit int
+	runs  map[cacheKey][]Glyph
+}
+
+type cacheKey struct {
+	font *Font
+	run  string
+}
+
+// NewCache returns a cache holding up to limit runs. Once full, the cache is
+// emptied before adding more runs.
+func NewCache(limit int) *Cache {
+	return &Cache{limit: limit, runs: make(map[cacheKey][]Glyph)}
+}
+
+// Shape returns the glyphs of runes shaped with f, which must not be modified.
+func (c *Cache) Shape(f *Font, runes []rune) []Glyph {
+	key := cacheKey{font: f, run: string(runes)}
+	c.mutex.Lock()
+	glyphs, found := c.runs[key]
+	c.mutex.Unlock()
+	if found {
+		return glyphs
+	}
+
+	glyphs = f.Shape(runes)
+	c.mutex.Lock()
+	if len(c.runs) >= c.limit {
+		clear(c.runs)
+	}
+	c.runs[key] = glyphs
+	c.mutex.Unlock()
+	return glyphs
+}
@@ -0,0 +1,196 @@
+package shaping
+
+import (
+	"image"
+	"math"
+
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// The masks the distance fields are computed from are rasterized this many
+// times larger than the fields, in each direction.
+const distanceFieldOversampling = 4
+
+// DistanceFieldFace draws the glyphs of a font as signed distance fields, which
+// can be scaled to any size and still have sharp edges once thresholded. The
+// distance to the outline of the glyph is mapped to the alpha of the field,
+// half alpha being on the outline, full alpha spread pixels inside it and zero
+// alpha spread pixels outside. The fields are padded by spread pixels.
+type DistanceFieldFace struct {
+	face   *Face
+	spread int
+	glyphs map[GlyphIndex]*rasterizedGlyph
+}
+
+// NewDistanceFieldFace returns a face drawing the fields of the glyphs of the
+// font at size pixels per em. The glyphs are not hinted, as they are drawn at
+// any size.
+func NewDistanceFieldFace(font *Font, size, spread int) *DistanceFieldFace {
+	opts := &truetype.Options{
+		Size:              float64(size * distanceFieldOversampling),
+		Hinting:           imageFont.HintingNone,
+		GlyphCacheEntries: 1,
+	}
+	return &DistanceFieldFace{
+		face:   NewFace(font, opts),
+		spread: spread,
+		glyphs: make(map[GlyphIndex]*rasterizedGlyph),
+	}
+}
+
+// Glyph returns the field of the glyph drawn with its origin at dot, rounded
+// to the nearest pixel, like Face.Glyph.
+func (f *DistanceFieldFace) Glyph(dot fixed.Point26_6, index GlyphIndex) (dr image.Rectangle, mask *image.Alpha, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	g, ok := f.field(index)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	origin := image.Point{X: int((dot.X + 32) >> 6), Y: int((dot.Y + 32) >> 6)}
+	dr = g.mask.Rect.Add(origin.Add(g.offset))
+	return dr, g.mask, image.Point{}, g.advance, true
+}
+
+// GlyphBounds returns the bounds of the field of the glyph drawn with its
+// origin at the origin, like Face.GlyphBounds.
+func (f *DistanceFieldFace) GlyphBounds(index GlyphIndex) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	g, ok := f.field(index)
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	r := g.mask.Rect.Add(g.offset)
+	return fixed.R(r.Min.X, r.Min.Y, r.Max.X, r.Max.Y), g.advance, true
+}
+
+func (f *DistanceFieldFace) field(index GlyphIndex) (*rasterizedGlyph, bool) {
+	if g, found := f.glyphs[index]; found {
+		return g, true
+	}
+	dr, mask, _, advance, ok := f.face.Glyph(fixed.Point26_6{}, index)
+	if !ok {
+		return nil, false
+	}
+
+	// The mask is padded by the spread, and to whole pixels of the field.
+	const n = distanceFieldOversampling
+	pad := f.spread * n
+	min := image.Point{X: floorDiv(dr.Min.X, n)*n - pad, Y: floorDiv(dr.Min.Y, n)*n - pad}
+	max := image.Point{X: -floorDiv(-dr.Max.X, n)*n + pad, Y: -floorDiv(-dr.Max.Y, n)*n + pad}
+	width, height := max.X-min.X, max.Y-min.Y
+
+	toOutside, toInside := make([]float64, width*height), make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := image.Point{X: min.X + x, Y: min.Y + y}
+			if p.In(dr) && mask.AlphaAt(p.X-dr.Min.X, p.Y-dr.Min.Y).A >= 0x80 {
+				toOutside[y*width+x] = infiniteDistance
+			} else {
+				toInside[y*width+x] = infiniteDistance
+			}
+		}
+	}
+	distanceTransform(toOutside, width, height)
+	distanceTransform(toInside, width, height)
+
+	// The distance of each pixel of the field is the mean of the signed
+	// distances of the pixels of the mask it covers, measured from the edges of
+	// the pixels rather than their centers.
+	field := image.NewAlpha(image.Rect(0, 0, width/n, height/n))
+	for y := 0; y < field.Rect.Dy(); y++ {
+		for x := 0; x < field.Rect.Dx(); x++ {
+			var sum float64
+			for j := y * n; j < (y+1)*n; j++ {
+				for i := x * n; i < (x+1)*n; i++ {
+					if outside := toOutside[j*width+i]; outside > 0 {
+						sum += math.Sqrt(outside) - 0.5
+					} else {
+						sum -= math.Sqrt(toInside[j*width+i]) - 0.5
+					}
+				}
+			}
+			distance := sum / (n * n * n)
+			alpha := 0.5 + distance/float64(2*f.spread)
+			field.Pix[y*field.Stride+x] = uint8(math.Round(255 * math.Max(0, math.Min(1, alpha))))
+		}
+	}
+
+	g := &rasterizedGlyph{
+		mask:    field,
+		offset:  image.Point{X: min.X / n, Y: min.Y / n},
+		advance: advance / n,
+	}
+	f.glyphs[index] = g
+	return g, true
+}
+
+// A distance larger than any distance of a field.
+const infiniteDistance = 1e20
+
+// distanceTransform replaces each element of the grid with the squared
+// distance to the nearest zero element, transforming the columns then the
+// rows, as described by Felzenszwalb and Huttenlocher in "Distance Transforms
+// of Sampled Functions".
+func distanceTransform(grid []float64, width, height int) {
+	size := width
+	if height > size {
+		size = height
+	}
+	f, d := make([]float64, size), make([]float64, size)
+	v, z := make([]int, size), make([]float64, size+1)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			f[y] = grid[y*width+x]
+		}
+		transform1D(f[:height], d, v, z)
+		for y := 0; y < height; y++ {
+			grid[y*width+x] = d[y]
+		}
+	}
+	for y := 0; y < height; y++ {
+		copy(f, grid[y*width:(y+1)*width])
+		transform1D(f[:width], d, v, z)
+		copy(grid[y*width:(y+1)*width], d[:width])
+	}
+}
+
+// transform1D writes to d the squared distance transform of f, finding the
+// lower envelope of the parabolas rooted at each element of f. v holds the
+// roots of the parabolas of the envelope, and z the boundaries between them.
+func transform1D(f, d []float64, v []int, z []float64) {
+	k := 0
+	v[0], z[0], z[1] = 0, -infiniteDistance, infiniteDistance
+	for q := 1; q < len(f); q++ {
+		s := envelopeBoundary(f, q, v[k])
+		for s <= z[k] {
+			k--
+			s = envelopeBoundary(f, q, v[k])
+		}
+		k++
+		v[k], z[k], z[k+1] = q, s, infiniteDistance
+	}
+
+	k = 0
+	for q := range f {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		r := v[k]
+		d[q] = float64((q-r)*(q-r)) + f[r]
+	}
+}
+
+// envelopeBoundary returns where the parabola rooted at q starts being lower
+// than the one rooted at r.
+func envelopeBoundary(f []float64, q, r int) float64 {
+	return ((f[q] + float64(q*q)) - (f[r] + float64(r*r))) / float64(2*q-2*r)
+}
+
+// floorDiv returns a divided by b, rounded down.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,92 @@
+package shaping
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/test_helper"
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestDistanceTransform(t *testing.T) {
+	const width, height = 7, 5
+	zeros := []image.Point{{1, 1}, {5, 3}, {6, 0}}
+	grid := make([]float64, width*height)
+	for i := range grid {
+		grid[i] = infiniteDistance
+	}
+	for _, p := range zeros {
+		grid[p.Y*width+p.X] = 0
+	}
+	distanceTransform(grid, width, height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			nearest := math.Inf(1)
+			for _, p := range zeros {
+				dx, dy := float64(x-p.X), float64(y-p.Y)
+				nearest = math.Min(nearest, dx*dx+dy*dy)
+			}
+			if grid[y*width+x] != nearest {
+				t.Errorf("(%d, %d): expected %v, got %v", x, y, nearest, grid[y*width+x])
+			}
+		}
+	}
+}
+
+func TestDistanceFieldFace(t *testing.T) {
+	f, err := Parse(font.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const size, spread = 32, 4
+	fields := NewDistanceFieldFace(f, size, spread)
+	masks := NewFace(f, &truetype.Options{Size: size, Hinting: imageFont.HintingNone})
+
+	for _, r := range "gxO" {
+		index := f.Index(r)
+		dot := fixed.P(10, 40)
+		dr, field, maskp, advance, ok := fields.Glyph(dot, index)
+		if !ok {
+			t.Fatalf("%q: expected a field", r)
+		}
+		test_helper.AssertEquals(t, image.Point{}, maskp)
+		bounds, boundsAdvance, _ := fields.GlyphBounds(index)
+		test_helper.AssertEquals(t, advance, boundsAdvance)
+		test_helper.AssertEquals(t, dr.Sub(image.Pt(10, 40)), image.Rect(bounds.Min.X.Floor(), bounds.Min.Y.Floor(), bounds.Max.X.Floor(), bounds.Max.Y.Floor()))
+
+		mr, mask, _, maskAdvance, _ := masks.Glyph(dot, index)
+		if d := advance - maskAdvance; d < -1 || d > 1 {
+			t.Errorf("%q: expected the advance %v, got %v", r, maskAdvance, advance)
+		}
+		if !mr.Inset(-spread).In(dr) {
+			t.Errorf("%q: expected the field %v to pad the mask %v", r, dr, mr)
+		}
+
+		// The field thresholded at half alpha covers the pixels mostly covered
+		// by the glyph, give or take those on its outline.
+		differences, covered := 0, 0
+		for y := dr.Min.Y; y < dr.Max.Y; y++ {
+			for x := dr.Min.X; x < dr.Max.X; x++ {
+				inside := field.AlphaAt(x-dr.Min.X, y-dr.Min.Y).A >= 0x80
+				expected := image.Pt(x, y).In(mr) && mask.AlphaAt(x-mr.Min.X, y-mr.Min.Y).A >= 0x80
+				if expected {
+					covered++
+				}
+				if inside != expected {
+					differences++
+				}
+				if (x == dr.Min.X || x == dr.Max.X-1) && field.AlphaAt(x-dr.Min.X, y-dr.Min.Y).A > 0x20 {
+					t.Errorf("%q: expected almost no alpha at the edge of the field at (%d, %d)", r, x, y)
+				}
+			}
+		}
+		if covered == 0 || differences > covered/10 {
+			t.Errorf("%q: %d of the %d covered pixels differ", r, differences, covered)
+		}
+	}
+}
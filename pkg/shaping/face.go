@@ -0,0 +1,221 @@
+package shaping
+
+import (
+	"image"
+
+	"github.com/golang/freetype/raster"
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Face rasterizes the glyphs of a font by index, as shaped glyphs such as
+// ligatures may not be mapped from any rune. Glyphs are rasterized at whole
+// pixels, as by the faces of the truetype package for TrueType outlines. CFF
+// outlines are not hinted.
+type Face struct {
+	font    *Font
+	scale   fixed.Int26_6
+	hinting imageFont.Hinting
+	buffer  truetype.GlyphBuf
+	outline sfnt.Buffer
+	r       raster.Rasterizer
+	glyphs  map[GlyphIndex]*rasterizedGlyph
+	limit   int
+}
+
+type rasterizedGlyph struct {
+	mask    *image.Alpha
+	offset  image.Point
+	advance fixed.Int26_6
+}
+
+// NewFace returns a face for the font with the size, DPI and hinting of the
+// options, which caches up to opts.GlyphCacheEntries glyphs.
+func NewFace(font *Font, opts *truetype.Options) *Face {
+	limit := opts.GlyphCacheEntries
+	if limit <= 0 {
+		limit = 512
+	}
+	dpi := opts.DPI
+	if dpi <= 0 {
+		dpi = 72
+	}
+	f := &Face{
+		font:    font,
+		scale:   fixed.Int26_6(0.5 + opts.Size*dpi*64/72),
+		hinting: opts.Hinting,
+		glyphs:  make(map[GlyphIndex]*rasterizedGlyph),
+		limit:   limit,
+	}
+
+	// The rasterizer splits curves depending on its bounds, which are those
+	// of the largest glyph as in the truetype faces.
+	b := font.Bounds(f.scale)
+	f.r.SetBounds(int(b.Max.X+63)>>6-int(b.Min.X)>>6, int(-b.Min.Y+63)>>6-int(-b.Max.Y)>>6)
+	return f
+}
+
+// Glyph returns the mask of the glyph drawn with its origin at dot, rounded
+// to the nearest pixel, like font.Face.Glyph.
+func (f *Face) Glyph(dot fixed.Point26_6, index GlyphIndex) (dr image.Rectangle, mask *image.Alpha, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	g, found := f.glyphs[index]
+	if !found {
+		if g, ok = f.rasterize(index); !ok {
+			return image.Rectangle{}, nil, image.Point{}, 0, false
+		}
+		if len(f.glyphs) >= f.limit {
+			clear(f.glyphs)
+		}
+		f.glyphs[index] = g
+	}
+
+	origin := image.Point{X: int((dot.X + 32) >> 6), Y: int((dot.Y + 32) >> 6)}
+	dr = g.mask.Rect.Add(origin.Add(g.offset))
+	return dr, g.mask, image.Point{}, g.advance, true
+}
+
+// GlyphBounds returns the bounds of the glyph drawn with its origin at the
+// origin, like font.Face.GlyphBounds.
+func (f *Face) GlyphBounds(index GlyphIndex) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	if !f.font.TrueType() {
+		bounds, advance, err := f.font.sfnt.GlyphBounds(&f.outline, sfnt.GlyphIndex(index), f.scale, imageFont.HintingNone)
+		if err != nil || bounds.Min.X > bounds.Max.X || bounds.Min.Y > bounds.Max.Y {
+			return fixed.Rectangle26_6{}, 0, false
+		}
+		return bounds, advance, true
+	}
+	if err := f.buffer.Load(f.font.ttf, f.scale, truetype.Index(index), f.hinting); err != nil {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	b := f.buffer.Bounds
+	if b.Min.X > b.Max.X || b.Min.Y > b.Max.Y {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: b.Min.X, Y: -b.Max.Y},
+		Max: fixed.Point26_6{X: b.Max.X, Y: -b.Min.Y},
+	}, f.buffer.AdvanceWidth, true
+}
+
+func (f *Face) rasterize(index GlyphIndex) (*rasterizedGlyph, bool) {
+	if !f.font.TrueType() {
+		return f.rasterizeOutline(index)
+	}
+	if err := f.buffer.Load(f.font.ttf, f.scale, truetype.Index(index), f.hinting); err != nil {
+		return nil, false
+	}
+	b := f.buffer.Bounds
+	xmin, ymin := int(b.Min.X)>>6, int(-b.Max.Y)>>6
+	xmax, ymax := int(b.Max.X+0x3f)>>6, int(-b.Min.Y+0x3f)>>6
+	if xmin > xmax || ymin > ymax {
+		return nil, false
+	}
+
+	// The rasterizer clips what is left of or above its origin, so the glyph
+	// is moved by the pixel offset of its top-left corner.
+	mask := image.NewAlpha(image.Rect(0, 0, xmax-xmin, ymax-ymin))
+	dx, dy := -fixed.Int26_6(xmin<<6), -fixed.Int26_6(ymin<<6)
+	f.r.Clear()
+	start := 0
+	for _, end := range f.buffer.Ends {
+		drawContour(&f.r, f.buffer.Points[start:end], dx, dy)
+		start = end
+	}
+	f.r.Rasterize(raster.NewAlphaSrcPainter(mask))
+	return &rasterizedGlyph{
+		mask:    mask,
+		offset:  image.Point{X: xmin, Y: ymin},
+		advance: f.buffer.AdvanceWidth,
+	}, true
+}
+
+// rasterizeOutline rasterizes a glyph of a font with CFF outlines, whose
+// segments are loaded by the sfnt package with Y going down.
+func (f *Face) rasterizeOutline(index GlyphIndex) (*rasterizedGlyph, bool) {
+	segments, err := f.font.sfnt.LoadGlyph(&f.outline, sfnt.GlyphIndex(index), f.scale, nil)
+	if err != nil {
+		return nil, false
+	}
+	advance, err := f.font.sfnt.GlyphAdvance(&f.outline, sfnt.GlyphIndex(index), f.scale, imageFont.HintingNone)
+	if err != nil {
+		return nil, false
+	}
+	b := segments.Bounds()
+	xmin, ymin := int(b.Min.X)>>6, int(b.Min.Y)>>6
+	xmax, ymax := int(b.Max.X+0x3f)>>6, int(b.Max.Y+0x3f)>>6
+	if xmin > xmax || ymin > ymax {
+		return nil, false
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, xmax-xmin, ymax-ymin))
+	d := fixed.Point26_6{X: -fixed.Int26_6(xmin << 6), Y: -fixed.Int26_6(ymin << 6)}
+	f.r.Clear()
+	for _, segment := range segments {
+		a := segment.Args
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			f.r.Start(a[0].Add(d))
+		case sfnt.SegmentOpLineTo:
+			f.r.Add1(a[0].Add(d))
+		case sfnt.SegmentOpQuadTo:
+			f.r.Add2(a[0].Add(d), a[1].Add(d))
+		case sfnt.SegmentOpCubeTo:
+			f.r.Add3(a[0].Add(d), a[1].Add(d), a[2].Add(d))
+		}
+	}
+	f.r.Rasterize(raster.NewAlphaSrcPainter(mask))
+	return &rasterizedGlyph{
+		mask:    mask,
+		offset:  image.Point{X: xmin, Y: ymin},
+		advance: advance,
+	}, true
+}
+
+// drawContour adds a closed contour of quadratic curves to the rasterizer,
+// flipping Y down and moving it by (dx, dy). The low bit of the flags of each
+// point is set for points on the curve, and an on-curve point is implied
+// between consecutive off-curve points.
+func drawContour(r *raster.Rasterizer, points []truetype.Point, dx, dy fixed.Int26_6) {
+	if len(points) == 0 {
+		return
+	}
+	at := func(p truetype.Point) fixed.Point26_6 {
+		return fixed.Point26_6{X: dx + p.X, Y: dy - p.Y}
+	}
+	onCurve := func(p truetype.Point) bool {
+		return p.Flags&0x01 != 0
+	}
+
+	first, last := points[0], points[len(points)-1]
+	start, others := at(first), points[1:]
+	if !onCurve(first) {
+		if onCurve(last) {
+			start, others = at(last), points[:len(points)-1]
+		} else {
+			start = fixed.Point26_6{X: (start.X + at(last).X) / 2, Y: (start.Y + at(last).Y) / 2}
+			others = points
+		}
+	}
+
+	r.Start(start)
+	previous, previousOn := start, true
+	for _, p := range others {
+		q, on := at(p), onCurve(p)
+		switch {
+		case on && previousOn:
+			r.Add1(q)
+		case on:
+			r.Add2(previous, q)
+		case !previousOn:
+			r.Add2(previous, fixed.Point26_6{X: (previous.X + q.X) / 2, Y: (previous.Y + q.Y) / 2})
+		}
+		previous, previousOn = q, on
+	}
+	if previousOn {
+		r.Add1(start)
+	} else {
+		r.Add2(previous, start)
+	}
+}
@@ -0,0 +1,46 @@
+package shaping
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/badu/gxui/pkg/font"
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestFaceMatchesTruetype(t *testing.T) {
+	ttf, err := truetype.Parse(font.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &truetype.Options{Size: 12, DPI: 96, Hinting: imageFont.HintingFull, SubPixelsX: 1, SubPixelsY: 1}
+	f, err := Parse(font.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, actual := truetype.NewFace(ttf, opts), NewFace(f, opts)
+
+	dot := fixed.P(10, 20)
+	for r := rune('!'); r <= '~'; r++ {
+		dr, mask, maskp, advance, _ := expected.Glyph(dot, r)
+		gotRect, gotMask, gotMaskp, gotAdvance, ok := actual.Glyph(dot, GlyphIndex(ttf.Index(r)))
+		if !ok || gotRect != dr || gotAdvance != advance {
+			t.Errorf("%q: expected %v advancing %v, got %v advancing %v", r, dr, advance, gotRect, gotAdvance)
+			continue
+		}
+		for y := 0; y < dr.Dy(); y++ {
+			row := func(m image.Image, p image.Point) []byte {
+				alpha := m.(*image.Alpha)
+				start := alpha.PixOffset(p.X, p.Y+y)
+				return alpha.Pix[start : start+dr.Dx()]
+			}
+			if !bytes.Equal(row(mask, maskp), row(gotMask, gotMaskp)) {
+				t.Errorf("%q: row %d differs", r, y)
+				break
+			}
+		}
+	}
+}
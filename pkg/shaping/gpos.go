@@ -0,0 +1,152 @@
+package shaping
+
+import "math/bits"
+
+// GPOS lookup types.
+const (
+	pairAdjustment       = 2
+	markToBaseAttachment = 4
+	extensionPositioning = 9
+)
+
+// Bits of a value format, selecting the fields of a value record.
+const (
+	xPlacement = 0x1
+	yPlacement = 0x2
+	xAdvance   = 0x4
+)
+
+// position applies a GPOS lookup to the glyphs.
+func (f *Font) position(glyphs []Glyph, index int) {
+	kind, flag, subtables := lookup(f.gpos, index, extensionPositioning)
+	for i := 0; i < len(glyphs); i++ {
+		if f.skips(flag, glyphs[i].Index) {
+			continue
+		}
+		for _, subtable := range subtables {
+			var applied bool
+			switch kind {
+			case pairAdjustment:
+				applied = f.adjustPair(subtable, glyphs, i, flag)
+			case markToBaseAttachment:
+				applied = f.attachMark(subtable, glyphs, i)
+			}
+			if applied {
+				break
+			}
+		}
+	}
+}
+
+// valueRecord adds the value record at offset to g.
+func valueRecord(t table, offset int, format uint16, g *Glyph) {
+	field := offset
+	if format&xPlacement != 0 {
+		g.X += t.i16(field)
+		field += 2
+	}
+	if format&yPlacement != 0 {
+		g.Y += t.i16(field)
+		field += 2
+	}
+	if format&xAdvance != 0 {
+		g.Advance += t.i16(field)
+	}
+}
+
+// valueRecordSize returns the size of the value records with the format. The
+// fields after the X advance, the Y advance and device table offsets, are
+// ignored.
+func valueRecordSize(format uint16) int {
+	return 2 * bits.OnesCount16(format&0xff)
+}
+
+// adjustPair applies a pair adjustment to the glyph at i and the glyph
+// following it.
+func (f *Font) adjustPair(t table, glyphs []Glyph, i int, flag uint16) bool {
+	c := coverage(t.sub(int(t.u16(2))), glyphs[i].Index)
+	j := f.next(glyphs, i, flag)
+	if c < 0 || j < 0 {
+		return false
+	}
+	format1, format2 := t.u16(4), t.u16(6)
+	size1, size2 := valueRecordSize(format1), valueRecordSize(format2)
+	second := glyphs[j].Index
+
+	switch t.u16(0) {
+	case 1:
+		if c >= int(t.u16(8)) {
+			return false
+		}
+		set := t.sub(int(t.u16(10 + 2*c)))
+		recordSize := 2 + size1 + size2
+		low, high := 0, int(set.u16(0))
+		for low < high {
+			middle := (low + high) / 2
+			record := 2 + middle*recordSize
+			switch g := GlyphIndex(set.u16(record)); {
+			case g < second:
+				low = middle + 1
+			case g > second:
+				high = middle
+			default:
+				valueRecord(set, record+2, format1, &glyphs[i])
+				valueRecord(set, record+2+size1, format2, &glyphs[j])
+				return true
+			}
+		}
+	case 2:
+		class1 := classDef(t.sub(int(t.u16(8))), glyphs[i].Index)
+		class2 := classDef(t.sub(int(t.u16(10))), second)
+		class1Count, class2Count := int(t.u16(12)), int(t.u16(14))
+		if class1 >= class1Count || class2 >= class2Count {
+			return false
+		}
+		record := 16 + (class1*class2Count+class2)*(size1+size2)
+		valueRecord(t, record, format1, &glyphs[i])
+		valueRecord(t, record+size1, format2, &glyphs[j])
+		return true
+	}
+	return false
+}
+
+// attachMark attaches the mark at i to the closest base glyph before it, so
+// that their anchors meet.
+func (f *Font) attachMark(t table, glyphs []Glyph, i int) bool {
+	if t.u16(0) != 1 {
+		return false
+	}
+	mark := coverage(t.sub(int(t.u16(2))), glyphs[i].Index)
+	if mark < 0 {
+		return false
+	}
+	base := i - 1
+	for base >= 0 && f.glyphClass(glyphs[base].Index) == markGlyph {
+		base--
+	}
+	if base < 0 {
+		return false
+	}
+	baseCoverage := coverage(t.sub(int(t.u16(4))), glyphs[base].Index)
+	if baseCoverage < 0 {
+		return false
+	}
+
+	classCount := int(t.u16(6))
+	marks, bases := t.sub(int(t.u16(8))), t.sub(int(t.u16(10)))
+	class := int(marks.u16(2 + 4*mark))
+	if mark >= int(marks.u16(0)) || baseCoverage >= int(bases.u16(0)) || class >= classCount {
+		return false
+	}
+	markAnchor := marks.sub(int(marks.u16(2 + 4*mark + 2)))
+	baseAnchor := bases.sub(int(bases.u16(2 + 2*(baseCoverage*classCount+class))))
+	if markAnchor == nil || baseAnchor == nil {
+		return false
+	}
+
+	g := &glyphs[i]
+	g.Attached, g.Base = true, base
+	g.X = baseAnchor.i16(2) - markAnchor.i16(2)
+	g.Y = baseAnchor.i16(4) - markAnchor.i16(4)
+	return true
+}
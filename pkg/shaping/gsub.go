@@ -0,0 +1,87 @@
+package shaping
+
+// GSUB lookup types.
+const (
+	singleSubstitution    = 1
+	ligatureSubstitution  = 4
+	extensionSubstitution = 7
+)
+
+// substitute applies a GSUB lookup to the glyphs, returning the glyphs left.
+// The lookups of joining form features only apply to the glyphs of the form.
+func (f *Font) substitute(glyphs []Glyph, index int) []Glyph {
+	kind, flag, subtables := lookup(f.gsub, index, extensionSubstitution)
+	form, formed := f.forms[index]
+	for i := 0; i < len(glyphs); i++ {
+		if f.skips(flag, glyphs[i].Index) || (formed && glyphs[i].form != form) {
+			continue
+		}
+		for _, subtable := range subtables {
+			var applied bool
+			switch kind {
+			case singleSubstitution:
+				applied = substituteSingle(subtable, &glyphs[i])
+			case ligatureSubstitution:
+				glyphs, applied = f.substituteLigature(subtable, glyphs, i, flag)
+			}
+			if applied {
+				break
+			}
+		}
+	}
+	return glyphs
+}
+
+func substituteSingle(t table, g *Glyph) bool {
+	i := coverage(t.sub(int(t.u16(2))), g.Index)
+	if i < 0 {
+		return false
+	}
+	switch t.u16(0) {
+	case 1:
+		g.Index += GlyphIndex(t.u16(4))
+	case 2:
+		if i >= int(t.u16(4)) {
+			return false
+		}
+		g.Index = GlyphIndex(t.u16(6 + 2*i))
+	default:
+		return false
+	}
+	return true
+}
+
+// substituteLigature replaces the glyph at i and the components following it
+// with the first ligature of the subtable they match. Glyphs skipped by the
+// lookup stay after the ligature.
+func (f *Font) substituteLigature(t table, glyphs []Glyph, i int, flag uint16) ([]Glyph, bool) {
+	c := coverage(t.sub(int(t.u16(2))), glyphs[i].Index)
+	if t.u16(0) != 1 || c < 0 || c >= int(t.u16(4)) {
+		return glyphs, false
+	}
+	set := t.sub(int(t.u16(6 + 2*c)))
+	for l := 0; l < int(set.u16(0)); l++ {
+		ligature := set.sub(int(set.u16(2 + 2*l)))
+		components := []int{i}
+		for k := 1; k < int(ligature.u16(2)); k++ {
+			j := f.next(glyphs, components[len(components)-1], flag)
+			if j < 0 || glyphs[j].Index != GlyphIndex(ligature.u16(4+2*(k-1))) {
+				components = nil
+				break
+			}
+			components = append(components, j)
+		}
+		if components == nil {
+			continue
+		}
+
+		last := glyphs[components[len(components)-1]]
+		glyphs[i].Index = GlyphIndex(ligature.u16(0))
+		glyphs[i].Runes = last.Cluster + last.Runes - glyphs[i].Cluster
+		for k := len(components) - 1; k > 0; k-- {
+			glyphs = append(glyphs[:components[k]], glyphs[components[k]+1:]...)
+		}
+		return glyphs, true
+	}
+	return glyphs, false
+}
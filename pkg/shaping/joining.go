@@ -0,0 +1,113 @@
+package shaping
+
+import "unicode"
+
+// Joining forms of a glyph, selecting the GSUB features of the same names.
+const (
+	noForm = iota
+	isolatedForm
+	finalForm
+	medialForm
+	initialForm
+)
+
+// formFeatures holds the GSUB features of the joining forms, by form.
+var formFeatures = []string{
+	isolatedForm: "isol",
+	finalForm:    "fina",
+	medialForm:   "medi",
+	initialForm:  "init",
+}
+
+// Joining types of the Arabic script.
+const (
+	nonJoining = iota
+	rightJoining
+	dualJoining
+	joinCausing
+	transparent
+)
+
+// rightJoiningLetters holds the Arabic letters joined only to the letter
+// before them, and nonJoiningLetters those joined to neither.
+var (
+	rightJoiningLetters = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x0622, Hi: 0x0625, Stride: 1},
+		{Lo: 0x0627, Hi: 0x0629, Stride: 2},
+		{Lo: 0x062f, Hi: 0x0632, Stride: 1},
+		{Lo: 0x0648, Hi: 0x0648, Stride: 1},
+		{Lo: 0x0671, Hi: 0x0673, Stride: 1},
+		{Lo: 0x0675, Hi: 0x0677, Stride: 1},
+		{Lo: 0x0688, Hi: 0x0699, Stride: 1},
+		{Lo: 0x06c0, Hi: 0x06c0, Stride: 1},
+		{Lo: 0x06c3, Hi: 0x06cb, Stride: 1},
+		{Lo: 0x06cd, Hi: 0x06cf, Stride: 2},
+		{Lo: 0x06d2, Hi: 0x06d3, Stride: 1},
+		{Lo: 0x06d5, Hi: 0x06d5, Stride: 1},
+		{Lo: 0x06ee, Hi: 0x06ef, Stride: 1},
+	}}
+	nonJoiningLetters = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x0621, Hi: 0x0621, Stride: 1},
+		{Lo: 0x0674, Hi: 0x0674, Stride: 1},
+	}}
+)
+
+// joiningType returns the joining type of r. Arabic letters not listed as
+// joined on one side or none are joined on both sides.
+func joiningType(r rune) int {
+	switch {
+	case r == 0x0640 || r == 0x200d:
+		// Tatweel and the zero width joiner.
+		return joinCausing
+	case r == 0x200c:
+		// The zero width non-joiner.
+		return nonJoining
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return transparent
+	case unicode.Is(rightJoiningLetters, r):
+		return rightJoining
+	case unicode.Is(nonJoiningLetters, r):
+		return nonJoining
+	case unicode.Is(unicode.Arabic, r) && unicode.IsLetter(r):
+		return dualJoining
+	}
+	return nonJoining
+}
+
+// joiningForms sets the joining form of each glyph of the runes, which are
+// shaped one glyph per rune. Transparent runes, such as marks, are skipped
+// when looking for the letters either side.
+func joiningForms(runes []rune, glyphs []Glyph) {
+	types := make([]int, len(runes))
+	for i, r := range runes {
+		types[i] = joiningType(r)
+	}
+
+	previous := -1
+	for i, t := range types {
+		if t == transparent {
+			continue
+		}
+		next := i + 1
+		for next < len(types) && types[next] == transparent {
+			next++
+		}
+
+		joinsPrevious := (t == rightJoining || t == dualJoining || t == joinCausing) &&
+			previous >= 0 && (types[previous] == dualJoining || types[previous] == joinCausing)
+		joinsNext := (t == dualJoining || t == joinCausing) &&
+			next < len(types) && types[next] != nonJoining
+		switch {
+		case t == nonJoining:
+		case joinsPrevious && joinsNext:
+			glyphs[i].form = medialForm
+		case joinsPrevious:
+			glyphs[i].form = finalForm
+		case joinsNext:
+			glyphs[i].form = initialForm
+		default:
+			glyphs[i].form = isolatedForm
+		}
+		previous = i
+	}
+}
@@ -0,0 +1,82 @@
+package shaping
+
+import (
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Data returns the data of the font as a single TrueType or OpenType font,
+// decompressed and taken out of its collection.
+func (f *Font) Data() []byte {
+	return f.data
+}
+
+// TrueType returns true if the font has TrueType outlines, and false if it has
+// CFF outlines.
+func (f *Font) TrueType() bool {
+	return f.ttf != nil
+}
+
+// Name returns the full name of the font.
+func (f *Font) Name() string {
+	if f.ttf != nil {
+		return f.ttf.Name(truetype.NameIDFontFullName)
+	}
+	var buffer sfnt.Buffer
+	name, err := f.sfnt.Name(&buffer, sfnt.NameIDFull)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// Index returns the index of the glyph of r, or 0 if the font has none.
+func (f *Font) Index(r rune) GlyphIndex {
+	if f.ttf != nil {
+		return GlyphIndex(f.ttf.Index(r))
+	}
+	var buffer sfnt.Buffer
+	index, err := f.sfnt.GlyphIndex(&buffer, r)
+	if err != nil {
+		return 0
+	}
+	return GlyphIndex(index)
+}
+
+// UnitsPerEm returns the number of font units in the em square.
+func (f *Font) UnitsPerEm() int {
+	return int(f.sfnt.UnitsPerEm())
+}
+
+// Bounds returns the union of the bounds of the glyphs scaled to scale pixels
+// per em, with Y going up, like truetype.Font.Bounds.
+func (f *Font) Bounds(scale fixed.Int26_6) fixed.Rectangle26_6 {
+	if f.ttf != nil {
+		return f.ttf.Bounds(scale)
+	}
+	var buffer sfnt.Buffer
+	b, err := f.sfnt.Bounds(&buffer, scale, imageFont.HintingNone)
+	if err != nil {
+		return fixed.Rectangle26_6{}
+	}
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: b.Min.X, Y: -b.Max.Y},
+		Max: fixed.Point26_6{X: b.Max.X, Y: -b.Min.Y},
+	}
+}
+
+// Advance returns the advance of the glyph scaled to scale pixels per em,
+// hinted for fonts with TrueType outlines.
+func (f *Font) Advance(index GlyphIndex, scale fixed.Int26_6) (fixed.Int26_6, error) {
+	if f.ttf != nil {
+		var buffer truetype.GlyphBuf
+		if err := buffer.Load(f.ttf, scale, truetype.Index(index), imageFont.HintingFull); err != nil {
+			return 0, err
+		}
+		return buffer.AdvanceWidth, nil
+	}
+	var buffer sfnt.Buffer
+	return f.sfnt.GlyphAdvance(&buffer, sfnt.GlyphIndex(index), scale, imageFont.HintingNone)
+}
@@ -0,0 +1,185 @@
+package shaping
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// Signatures of the font files accepted by Parse.
+const (
+	trueTypeSignature   = "\x00\x01\x00\x00"
+	appleSignature      = "true"
+	openTypeSignature   = "OTTO"
+	collectionSignature = "ttcf"
+	woffSignature       = "wOFF"
+	woff2Signature      = "wOF2"
+)
+
+// sfntTable is a table of an sfnt font.
+type sfntTable struct {
+	tag      string
+	checksum uint32
+	data     []byte
+}
+
+// FaceCount returns the number of fonts in the font data: the number of fonts
+// of a font collection, or of a WOFF2 file holding one, or 1.
+func FaceCount(data []byte) (int, error) {
+	if len(data) < 12 {
+		return 0, errors.New("shaping: font too short")
+	}
+	switch string(data[:4]) {
+	case collectionSignature:
+		return int(binary.BigEndian.Uint32(data[8:])), nil
+	case woff2Signature:
+		file, err := parseWOFF2(data)
+		if err != nil {
+			return 0, err
+		}
+		return len(file.fonts), nil
+	}
+	return 1, nil
+}
+
+// sfntData returns the data of the font at index of the font data, a single
+// TrueType or OpenType font, a font collection, or a WOFF or WOFF2 file. Fonts
+// of collections and WOFF files are written as single fonts.
+func sfntData(data []byte, index int) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("shaping: font too short")
+	}
+	signature := string(data[:4])
+	if signature != collectionSignature && signature != woff2Signature && index != 0 {
+		return nil, fmt.Errorf("shaping: font %d requested from a single font", index)
+	}
+	switch signature {
+	case trueTypeSignature, appleSignature, openTypeSignature:
+		return data, nil
+	case collectionSignature:
+		return collectionFont(data, index)
+	case woffSignature:
+		return decodeWOFF(data)
+	case woff2Signature:
+		return decodeWOFF2(data, index)
+	}
+	return nil, errors.New("shaping: unknown font format")
+}
+
+// collectionFont returns the font at index of a font collection. The tables
+// of the fonts of a collection are shared, and addressed from the start of the
+// collection.
+func collectionFont(data []byte, index int) ([]byte, error) {
+	count, _ := FaceCount(data)
+	if index < 0 || index >= count || len(data) < 12+4*count {
+		return nil, fmt.Errorf("shaping: font %d requested from a collection of %d", index, count)
+	}
+	offset := int(binary.BigEndian.Uint32(data[12+4*index:]))
+	if offset+12 > len(data) {
+		return nil, errors.New("shaping: invalid font collection")
+	}
+	directory := data[offset:]
+	tableCount := int(binary.BigEndian.Uint16(directory[4:]))
+	if offset+12+16*tableCount > len(data) {
+		return nil, errors.New("shaping: invalid font collection")
+	}
+
+	tables := make([]sfntTable, tableCount)
+	for i := range tables {
+		record := directory[12+16*i:]
+		start, length := int(binary.BigEndian.Uint32(record[8:])), int(binary.BigEndian.Uint32(record[12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, errors.New("shaping: table out of bounds")
+		}
+		tables[i] = sfntTable{
+			tag:      string(record[:4]),
+			checksum: binary.BigEndian.Uint32(record[4:]),
+			data:     data[start : start+length],
+		}
+	}
+	return writeSfnt(binary.BigEndian.Uint32(directory), tables), nil
+}
+
+// decodeWOFF returns the font of a WOFF file, whose tables are each
+// compressed with zlib when that makes them smaller.
+func decodeWOFF(data []byte) ([]byte, error) {
+	if len(data) < 44 {
+		return nil, errors.New("shaping: WOFF file too short")
+	}
+	tableCount := int(binary.BigEndian.Uint16(data[12:]))
+	if len(data) < 44+20*tableCount {
+		return nil, errors.New("shaping: invalid WOFF table directory")
+	}
+
+	tables := make([]sfntTable, tableCount)
+	for i := range tables {
+		record := data[44+20*i:]
+		offset := int(binary.BigEndian.Uint32(record[4:]))
+		compressed := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if offset < 0 || compressed < 0 || offset+compressed > len(data) || compressed > length {
+			return nil, errors.New("shaping: WOFF table out of bounds")
+		}
+
+		table := data[offset : offset+compressed]
+		if compressed < length {
+			reader, err := zlib.NewReader(bytes.NewReader(table))
+			if err != nil {
+				return nil, fmt.Errorf("shaping: WOFF table %q: %w", record[:4], err)
+			}
+			table = make([]byte, length)
+			if _, err := io.ReadFull(reader, table); err != nil {
+				return nil, fmt.Errorf("shaping: WOFF table %q: %w", record[:4], err)
+			}
+		}
+		tables[i] = sfntTable{
+			tag:      string(record[:4]),
+			checksum: binary.BigEndian.Uint32(record[16:]),
+			data:     table,
+		}
+	}
+	return writeSfnt(binary.BigEndian.Uint32(data[4:]), tables), nil
+}
+
+// writeSfnt returns a single font of the version holding the tables, sorted by
+// tag and each aligned to four bytes.
+func writeSfnt(version uint32, tables []sfntTable) []byte {
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+
+	size := 12 + 16*len(tables)
+	for _, t := range tables {
+		size += (len(t.data) + 3) &^ 3
+	}
+	result := make([]byte, 12+16*len(tables), size)
+
+	// The search range is the largest power of two not above the number of
+	// tables, times 16.
+	entrySelector := 0
+	if len(tables) > 0 {
+		entrySelector = bits.Len(uint(len(tables))) - 1
+	}
+	searchRange := 16 << entrySelector
+	binary.BigEndian.PutUint32(result, version)
+	binary.BigEndian.PutUint16(result[4:], uint16(len(tables)))
+	binary.BigEndian.PutUint16(result[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(result[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(result[10:], uint16(16*len(tables)-searchRange))
+
+	for i, t := range tables {
+		record := result[12+16*i:]
+		copy(record, t.tag)
+		binary.BigEndian.PutUint32(record[4:], t.checksum)
+		binary.BigEndian.PutUint32(record[8:], uint32(len(result)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(t.data)))
+		result = append(result, t.data...)
+		for len(result)%4 != 0 {
+			result = append(result, 0)
+		}
+	}
+	return result
+}
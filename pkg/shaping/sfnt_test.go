@@ -0,0 +1,328 @@
+package shaping
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/test_helper"
+	"github.com/golang/freetype/truetype"
+	imageFont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// tables returns the tables of the single font data.
+func tables(t *testing.T, data []byte) []sfntTable {
+	var result []sfntTable
+	for i := 0; i < int(binary.BigEndian.Uint16(data[4:])); i++ {
+		record := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+		result = append(result, sfntTable{
+			tag:      string(record[:4]),
+			checksum: binary.BigEndian.Uint32(record[4:]),
+			data:     data[offset : offset+length],
+		})
+	}
+	if len(result) == 0 {
+		t.Fatal("font without tables")
+	}
+	return result
+}
+
+// collection returns a font collection of the fonts, each followed by its
+// tables.
+func collection(fonts ...[]byte) []byte {
+	result := make([]byte, 12+4*len(fonts))
+	copy(result, collectionSignature)
+	binary.BigEndian.PutUint32(result[4:], 0x00010000)
+	binary.BigEndian.PutUint32(result[8:], uint32(len(fonts)))
+	for i, data := range fonts {
+		start := len(result)
+		binary.BigEndian.PutUint32(result[12+4*i:], uint32(start))
+		result = append(result, data...)
+		for j := 0; j < int(binary.BigEndian.Uint16(data[4:])); j++ {
+			record := result[start+12+16*j:]
+			binary.BigEndian.PutUint32(record[8:], binary.BigEndian.Uint32(record[8:])+uint32(start))
+		}
+	}
+	return result
+}
+
+// woff returns the WOFF file of the font, with its tables compressed where
+// that makes them smaller.
+func woff(t *testing.T, data []byte) []byte {
+	fontTables := tables(t, data)
+	result := make([]byte, 44+20*len(fontTables))
+	copy(result, woffSignature)
+	copy(result[4:], data[:4])
+	binary.BigEndian.PutUint16(result[12:], uint16(len(fontTables)))
+	for i, table := range fontTables {
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		w.Write(table.data)
+		w.Close()
+		if compressed.Len() >= len(table.data) {
+			compressed.Reset()
+			compressed.Write(table.data)
+		}
+
+		record := result[44+20*i:]
+		copy(record, table.tag)
+		binary.BigEndian.PutUint32(record[4:], uint32(len(result)))
+		binary.BigEndian.PutUint32(record[8:], uint32(compressed.Len()))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table.data)))
+		binary.BigEndian.PutUint32(record[16:], table.checksum)
+		result = append(result, compressed.Bytes()...)
+	}
+	binary.BigEndian.PutUint32(result[8:], uint32(len(result)))
+	return result
+}
+
+func TestParseCollection(t *testing.T) {
+	data := collection(font.Default, font.Monospace)
+	count, err := FaceCount(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, 2, count)
+
+	for index, expected := range [][]byte{font.Default, font.Monospace} {
+		f, err := ParseIndex(data, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, _ := Parse(expected)
+		test_helper.AssertEquals(t, e.Name(), f.Name())
+		test_helper.AssertEquals(t, e.Index('x'), f.Index('x'))
+		test_helper.AssertEquals(t, e.Bounds(fixed.I(16)), f.Bounds(fixed.I(16)))
+		if _, err := truetype.Parse(f.Data()); err != nil {
+			t.Errorf("Expected the font data to be a single font, got %v", err)
+		}
+	}
+
+	if _, err := ParseIndex(data, 2); err == nil {
+		t.Errorf("Expected an error parsing a font beyond the collection")
+	}
+	if _, err := ParseIndex(font.Default, 1); err == nil {
+		t.Errorf("Expected an error parsing a second font of a single font")
+	}
+}
+
+func TestParseWOFF(t *testing.T) {
+	f, err := Parse(woff(t, font.Monospace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := Parse(font.Monospace)
+	test_helper.AssertEquals(t, expected.Name(), f.Name())
+	test_helper.AssertEquals(t, expected.Shape([]rune("gxui")), f.Shape([]rune("gxui")))
+	test_helper.AssertEquals(t, true, f.TrueType())
+}
+
+// woff2 returns the WOFF2 file of the single fonts, a font collection when
+// there are several, with their tables untransformed.
+func woff2(t *testing.T, fonts ...[]byte) []byte {
+	appendBase128 := func(b []byte, v int) []byte {
+		for shift := 28; shift > 0; shift -= 7 {
+			if v>>shift != 0 {
+				b = append(b, byte(v>>shift)|0x80)
+			}
+		}
+		return append(b, byte(v&0x7f))
+	}
+	appendUint255 := func(b []byte, v int) []byte {
+		if v < 253 {
+			return append(b, byte(v))
+		}
+		return binary.BigEndian.AppendUint16(append(b, 253), uint16(v))
+	}
+
+	var directory, fontDirectory, tableData []byte
+	tableCount := 0
+	for _, data := range fonts {
+		fontTables := tables(t, data)
+		fontDirectory = appendUint255(fontDirectory, len(fontTables))
+		fontDirectory = append(fontDirectory, data[:4]...)
+		for _, table := range fontTables {
+			switch table.tag {
+			case "glyf", "loca":
+				// The null transform of glyf and loca is 3.
+				directory = append(directory, 3<<6|0x3f)
+			default:
+				directory = append(directory, 0x3f)
+			}
+			directory = append(directory, table.tag...)
+			directory = appendBase128(directory, len(table.data))
+			fontDirectory = appendUint255(fontDirectory, tableCount)
+			tableData = append(tableData, table.data...)
+			tableCount++
+		}
+	}
+	if len(fonts) > 1 {
+		directory = binary.BigEndian.AppendUint32(directory, 0x00010000)
+		directory = appendUint255(directory, len(fonts))
+		directory = append(directory, fontDirectory...)
+	}
+
+	var compressed bytes.Buffer
+	w := brotli.NewWriter(&compressed)
+	w.Write(tableData)
+	w.Close()
+
+	result := make([]byte, 48)
+	copy(result, woff2Signature)
+	copy(result[4:], fonts[0][:4])
+	if len(fonts) > 1 {
+		copy(result[4:], collectionSignature)
+	}
+	binary.BigEndian.PutUint16(result[12:], uint16(tableCount))
+	binary.BigEndian.PutUint32(result[20:], uint32(compressed.Len()))
+	result = append(result, directory...)
+	result = append(result, compressed.Bytes()...)
+	binary.BigEndian.PutUint32(result[8:], uint32(len(result)))
+	return result
+}
+
+func TestParseWOFF2(t *testing.T) {
+	data, err := os.ReadFile("testdata/OpenSans-Regular.woff2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, "Open Sans Regular", f.Name())
+	test_helper.AssertEquals(t, true, f.TrueType())
+	test_helper.AssertEquals(t, uint32(0xb1b0afba), sfntChecksum(f.Data()))
+
+	// The glyphs and their hinting instructions are reconstructed from the
+	// transformed glyf table.
+	ttf, err := truetype.Parse(f.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var glyph truetype.GlyphBuf
+	for _, r := range "gxuiÉ" {
+		index := ttf.Index(r)
+		if index == 0 {
+			t.Fatalf("Expected a glyph for %c", r)
+		}
+		if err := glyph.Load(ttf, fixed.I(32), index, imageFont.HintingFull); err != nil {
+			t.Fatalf("Expected the glyph of %c to load, got %v", r, err)
+		}
+		bounds := ttf.Bounds(fixed.I(32))
+		if len(glyph.Points) == 0 || glyph.Bounds.Max.X > bounds.Max.X || glyph.Bounds.Min.Y < bounds.Min.Y {
+			t.Errorf("Expected the glyph of %c within %v, got %v", r, bounds, glyph.Bounds)
+		}
+	}
+
+	if _, err := Parse(data[:len(data)-100]); err == nil {
+		t.Errorf("Expected an error parsing a truncated WOFF2 file")
+	}
+}
+
+func TestParseWOFF2Collection(t *testing.T) {
+	data := woff2(t, font.Default, font.Monospace)
+	count, err := FaceCount(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, 2, count)
+
+	for index, expected := range [][]byte{font.Default, font.Monospace} {
+		f, err := ParseIndex(data, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, _ := Parse(expected)
+		test_helper.AssertEquals(t, e.Name(), f.Name())
+		test_helper.AssertEquals(t, e.Shape([]rune("gxui")), f.Shape([]rune("gxui")))
+
+		// The tables are decoded as they were, but for the checksum adjustment
+		// of head.
+		for _, table := range tables(t, f.Data()) {
+			for _, e := range tables(t, expected) {
+				if table.tag == e.tag && table.tag != "head" && !bytes.Equal(table.data, e.data) {
+					t.Errorf("Expected table %q of font %d to be decoded as it was", table.tag, index)
+				}
+			}
+		}
+	}
+	if _, err := ParseIndex(data, 2); err == nil {
+		t.Errorf("Expected an error parsing a font beyond the collection")
+	}
+}
+
+func TestReconstructHmtx(t *testing.T) {
+	xMins := []int16{-1, 2, -3, 4}
+	for _, test := range []struct {
+		flags    byte
+		bearings []int16
+		expected []int16
+	}{
+		{1, []int16{5, 6}, []int16{10, -1, 20, 2, 5, 6}},
+		{2, []int16{5, 6}, []int16{10, 5, 20, 6, -3, 4}},
+		{3, nil, []int16{10, -1, 20, 2, -3, 4}},
+	} {
+		data := []byte{test.flags, 0, 10, 0, 20}
+		for _, v := range test.bearings {
+			data = binary.BigEndian.AppendUint16(data, uint16(v))
+		}
+		var expected []byte
+		for _, v := range test.expected {
+			expected = binary.BigEndian.AppendUint16(expected, uint16(v))
+		}
+		hmtx, err := reconstructHmtx(data, 2, xMins)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test_helper.AssertEquals(t, expected, hmtx)
+	}
+
+	if _, err := reconstructHmtx([]byte{0, 0, 10, 0, 20}, 2, xMins); err == nil {
+		t.Errorf("Expected an error reconstructing hmtx without bearings left out")
+	}
+}
+
+func TestParseCFF(t *testing.T) {
+	data, err := os.ReadFile("testdata/CFFTest.otf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_helper.AssertEquals(t, false, f.TrueType())
+	test_helper.AssertEquals(t, "CFFTest", f.Name())
+	if f.Index('中') == 0 || f.Index('x') != 0 {
+		t.Errorf("Expected a glyph for 中 and none for x")
+	}
+
+	face := NewFace(f, &truetype.Options{Size: 32, Hinting: imageFont.HintingFull})
+	dr, mask, _, advance, ok := face.Glyph(fixed.P(10, 40), f.Index('中'))
+	if !ok || dr.Empty() || advance <= 0 {
+		t.Fatalf("Expected the glyph of 中 to be rasterized, got %v advancing %v", dr, advance)
+	}
+	if expected, err := f.Advance(f.Index('中'), fixed.I(32)); err != nil || expected != advance {
+		t.Errorf("Expected the advance %v, got %v", expected, advance)
+	}
+	bounds, _, _ := face.GlyphBounds(f.Index('中'))
+	if dr.Min.Y > 40+bounds.Min.Y.Floor() || dr.Max.Y < 40+bounds.Max.Y.Floor() {
+		t.Errorf("Expected the glyph within %v, got %v", bounds, dr)
+	}
+	lit := 0
+	for _, alpha := range mask.Pix {
+		if alpha > 0x80 {
+			lit++
+		}
+	}
+	if lit == 0 {
+		t.Errorf("Expected the glyph to cover some pixels")
+	}
+}
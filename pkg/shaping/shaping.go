@@ -0,0 +1,341 @@
+// Package shaping turns runs of runes into positioned glyphs, applying the
+// OpenType layout tables of a font: GSUB ligatures and joining forms, and GPOS
+// kerning and mark positioning. It also measures and rasterizes the glyphs of
+// TrueType and CFF outlines, read from single fonts, font collections and
+// WOFF files.
+//
+// Only the lookups Latin, Greek, Cyrillic, Hebrew and Arabic text depend on
+// are supported: single and ligature substitutions, pair adjustments and
+// marks attached to base glyphs. Arabic letters take the isolated, initial,
+// medial or final form of their joining. Fonts without layout tables are
+// shaped one glyph per rune.
+package shaping
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// GSUB and GPOS features applied by Shape.
+var (
+	substitutionFeatures = []string{"ccmp", "rlig", "liga", "clig"}
+	positioningFeatures  = []string{"kern", "mark"}
+)
+
+// GlyphIndex is the index of a glyph in a font.
+type GlyphIndex uint16
+
+// Glyph is a glyph of a shaped run. Adjustments are in font units, with Y going
+// up.
+type Glyph struct {
+	Index GlyphIndex
+	// Cluster is the index of the first rune the glyph stands for, and Runes
+	// the number of runes. Ligatures stand for more than one rune.
+	Cluster, Runes int
+	// Advance is added to the advance of the glyph.
+	Advance int
+	// X and Y move the glyph away from its position on the baseline, or from
+	// the origin of its base glyph when it is attached.
+	X, Y int
+	// Attached is true for marks positioned on the glyph at index Base, which
+	// do not advance the pen.
+	Attached bool
+	Base     int
+
+	// form is the joining form of the glyph, which the lookups of the joining
+	// form features apply to.
+	form int
+}
+
+// Font holds the outlines, metrics and layout tables of a font. Fonts with
+// TrueType outlines are measured and rasterized with the truetype package, so
+// that they are hinted, and fonts with CFF outlines with the sfnt package.
+type Font struct {
+	data []byte
+	ttf  *truetype.Font
+	sfnt *sfnt.Font
+	gdef table
+	gsub table
+	gpos table
+
+	// The lookups of the features applied, in lookup list order, and the
+	// joining form of the glyphs each lookup of a joining form feature
+	// applies to.
+	substitutions []int
+	positionings  []int
+	forms         map[int]int
+}
+
+// Parse returns the font of the font data, a TrueType or OpenType font, a font
+// collection or a WOFF file. The first font of a collection is returned.
+func Parse(data []byte) (*Font, error) {
+	return ParseIndex(data, 0)
+}
+
+// ParseIndex returns the font at index of the font data, as Parse does. Only
+// collections hold more than the font at index 0.
+func ParseIndex(data []byte, index int) (*Font, error) {
+	data, err := sfntData(data, index)
+	if err != nil {
+		return nil, err
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := tableDirectory(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Font{
+		data: data,
+		sfnt: f,
+		gdef: tables["GDEF"],
+		gsub: tables["GSUB"],
+		gpos: tables["GPOS"],
+	}
+	if _, found := tables["glyf"]; found {
+		if result.ttf, err = truetype.Parse(data); err != nil {
+			return nil, err
+		}
+	}
+	result.substitutions = featureLookups(result.gsub, append(substitutionFeatures, formFeatures[isolatedForm:]...))
+	result.positionings = featureLookups(result.gpos, positioningFeatures)
+	result.forms = map[int]int{}
+	for form := isolatedForm; form < len(formFeatures); form++ {
+		for _, lookup := range featureLookups(result.gsub, formFeatures[form:form+1]) {
+			result.forms[lookup] = form
+		}
+	}
+	for _, lookup := range featureLookups(result.gsub, substitutionFeatures) {
+		delete(result.forms, lookup)
+	}
+	return result, nil
+}
+
+// tableDirectory returns the tables of an sfnt font by tag.
+func tableDirectory(data []byte) (map[string]table, error) {
+	if len(data) < 12 {
+		return nil, errors.New("shaping: font too short")
+	}
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*count {
+		return nil, errors.New("shaping: invalid table directory")
+	}
+
+	tables := make(map[string]table, count)
+	for i := 0; i < count; i++ {
+		record := data[12+16*i:]
+		offset := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, errors.New("shaping: table out of bounds")
+		}
+		tables[string(record[:4])] = table(data[offset : offset+length])
+	}
+	return tables, nil
+}
+
+// Shape returns the glyphs of the runes, in the order they are drawn.
+func (f *Font) Shape(runes []rune) []Glyph {
+	glyphs := make([]Glyph, len(runes))
+	var buffer sfnt.Buffer
+	for i, r := range runes {
+		index, err := f.sfnt.GlyphIndex(&buffer, r)
+		if err != nil {
+			index = 0
+		}
+		glyphs[i] = Glyph{Index: GlyphIndex(index), Cluster: i, Runes: 1}
+	}
+	joiningForms(runes, glyphs)
+
+	for _, lookup := range f.substitutions {
+		glyphs = f.substitute(glyphs, lookup)
+	}
+	for _, lookup := range f.positionings {
+		f.position(glyphs, lookup)
+	}
+	return glyphs
+}
+
+// Glyph classes of the GDEF table.
+const (
+	baseGlyph     = 1
+	ligatureGlyph = 2
+	markGlyph     = 3
+)
+
+// Lookup flags selecting the glyphs a lookup skips.
+const (
+	ignoreBaseGlyphs = 0x2
+	ignoreLigatures  = 0x4
+	ignoreMarks      = 0x8
+)
+
+func (f *Font) glyphClass(g GlyphIndex) int {
+	if f.gdef == nil {
+		return 0
+	}
+	return classDef(f.gdef.sub(int(f.gdef.u16(4))), g)
+}
+
+// skips returns true if a lookup with the flag ignores g.
+func (f *Font) skips(flag uint16, g GlyphIndex) bool {
+	switch f.glyphClass(g) {
+	case baseGlyph:
+		return flag&ignoreBaseGlyphs != 0
+	case ligatureGlyph:
+		return flag&ignoreLigatures != 0
+	case markGlyph:
+		return flag&ignoreMarks != 0
+	}
+	return false
+}
+
+// next returns the index of the glyph after i not skipped by a lookup with the
+// flag, or -1.
+func (f *Font) next(glyphs []Glyph, i int, flag uint16) int {
+	for i++; i < len(glyphs); i++ {
+		if !f.skips(flag, glyphs[i].Index) {
+			return i
+		}
+	}
+	return -1
+}
+
+// table is an OpenType table, or a part of one. Reads beyond its end return 0,
+// so that malformed fonts shape as if the lookups did not match.
+type table []byte
+
+func (t table) u16(offset int) uint16 {
+	if offset < 0 || offset+2 > len(t) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(t[offset:])
+}
+
+func (t table) i16(offset int) int {
+	return int(int16(t.u16(offset)))
+}
+
+func (t table) u32(offset int) uint32 {
+	if offset < 0 || offset+4 > len(t) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(t[offset:])
+}
+
+func (t table) sub(offset int) table {
+	if offset <= 0 || offset >= len(t) {
+		return nil
+	}
+	return t[offset:]
+}
+
+// featureLookups returns the lookups of the features with the given tags, in
+// the default language of any script, sorted in lookup list order.
+func featureLookups(t table, tags []string) []int {
+	if t == nil {
+		return nil
+	}
+	scripts, features := t.sub(int(t.u16(4))), t.sub(int(t.u16(6)))
+
+	enabled := map[int]bool{}
+	for i := 0; i < int(scripts.u16(0)); i++ {
+		script := scripts.sub(int(scripts.u16(2 + 6*i + 4)))
+		language := script.sub(int(script.u16(0)))
+		if language == nil {
+			continue
+		}
+		for j := 0; j < int(language.u16(4)); j++ {
+			enabled[int(language.u16(6+2*j))] = true
+		}
+	}
+
+	lookups := map[int]bool{}
+	for i := range enabled {
+		record := 2 + 6*i
+		if record+6 > len(features) || !hasTag(tags, string(features[record:record+4])) {
+			continue
+		}
+		feature := features.sub(int(features.u16(record + 4)))
+		for j := 0; j < int(feature.u16(2)); j++ {
+			lookups[int(feature.u16(4+2*j))] = true
+		}
+	}
+
+	result := make([]int, 0, len(lookups))
+	for lookup := range lookups {
+		result = append(result, lookup)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the type, flag and subtables of a lookup, with the extension
+// subtables resolved.
+func lookup(t table, index, extensionType int) (kind int, flag uint16, subtables []table) {
+	lookups := t.sub(int(t.u16(8)))
+	l := lookups.sub(int(lookups.u16(2 + 2*index)))
+	kind, flag = int(l.u16(0)), l.u16(2)
+	for i := 0; i < int(l.u16(4)); i++ {
+		subtable := l.sub(int(l.u16(6 + 2*i)))
+		if kind == extensionType {
+			kind = int(subtable.u16(2))
+			subtable = subtable.sub(int(subtable.u32(4)))
+		}
+		subtables = append(subtables, subtable)
+	}
+	return kind, flag, subtables
+}
+
+// coverage returns the index of g in the coverage table, or -1.
+func coverage(t table, g GlyphIndex) int {
+	switch t.u16(0) {
+	case 1:
+		count := int(t.u16(2))
+		i := sort.Search(count, func(i int) bool { return GlyphIndex(t.u16(4+2*i)) >= g })
+		if i < count && GlyphIndex(t.u16(4+2*i)) == g {
+			return i
+		}
+	case 2:
+		count := int(t.u16(2))
+		i := sort.Search(count, func(i int) bool { return GlyphIndex(t.u16(4+6*i+2)) >= g })
+		if i < count && GlyphIndex(t.u16(4+6*i)) <= g {
+			return int(t.u16(4+6*i+4)) + int(g) - int(t.u16(4+6*i))
+		}
+	}
+	return -1
+}
+
+// classDef returns the class of g in the class definition table.
+func classDef(t table, g GlyphIndex) int {
+	switch t.u16(0) {
+	case 1:
+		start := GlyphIndex(t.u16(2))
+		if g >= start && int(g-start) < int(t.u16(4)) {
+			return int(t.u16(6 + 2*int(g-start)))
+		}
+	case 2:
+		count := int(t.u16(2))
+		i := sort.Search(count, func(i int) bool { return GlyphIndex(t.u16(4+6*i+2)) >= g })
+		if i < count && GlyphIndex(t.u16(4+6*i)) <= g {
+			return int(t.u16(4 + 6*i + 4))
+		}
+	}
+	return 0
+}
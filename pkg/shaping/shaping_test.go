@@ -0,0 +1,87 @@
+package shaping
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/test_helper"
+)
+
+func shape(t *testing.T, data []byte, text string) []Glyph {
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Shape([]rune(text))
+}
+
+func TestShapeKerning(t *testing.T) {
+	for _, pair := range []string{"AV", "To"} {
+		glyphs := shape(t, font.Default, pair)
+		test_helper.AssertEquals(t, 2, len(glyphs))
+		if glyphs[0].Advance >= 0 {
+			t.Errorf("Expected %q to be kerned closer, got an adjustment of %d", pair, glyphs[0].Advance)
+		}
+		test_helper.AssertEquals(t, 0, glyphs[1].Advance)
+	}
+
+	// Monospace fonts have no layout tables, and are shaped one glyph per rune.
+	glyphs := shape(t, font.Monospace, "AV")
+	test_helper.AssertEquals(t, 2, len(glyphs))
+	test_helper.AssertEquals(t, 0, glyphs[0].Advance)
+	test_helper.AssertEquals(t, 1, glyphs[1].Cluster)
+}
+
+func TestShapeLigatures(t *testing.T) {
+	glyphs := shape(t, font.Default, "afix")
+	test_helper.AssertEquals(t, 3, len(glyphs))
+	test_helper.AssertEquals(t, 1, glyphs[1].Cluster)
+	test_helper.AssertEquals(t, 2, glyphs[1].Runes)
+	test_helper.AssertEquals(t, 3, glyphs[2].Cluster)
+
+	plain := shape(t, font.Default, "f")
+	if glyphs[1].Index == plain[0].Index {
+		t.Errorf("Expected the fi ligature glyph, got the f glyph %d", plain[0].Index)
+	}
+}
+
+func TestCache(t *testing.T) {
+	f, err := Parse(font.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewCache(2)
+	first := cache.Shape(f, []rune("To"))
+	if &first[0] != &cache.Shape(f, []rune("To"))[0] {
+		t.Errorf("Expected the glyphs of the cached run")
+	}
+
+	// Once full, the cache starts over.
+	cache.Shape(f, []rune("AV"))
+	cache.Shape(f, []rune("fi"))
+	if &first[0] == &cache.Shape(f, []rune("To"))[0] {
+		t.Errorf("Expected the run to be shaped again")
+	}
+	test_helper.AssertEquals(t, first, cache.Shape(f, []rune("To")))
+}
+
+func TestJoiningForms(t *testing.T) {
+	forms := func(text string) []int {
+		runes := []rune(text)
+		glyphs := make([]Glyph, len(runes))
+		joiningForms(runes, glyphs)
+		result := make([]int, len(glyphs))
+		for i, g := range glyphs {
+			result[i] = g.form
+		}
+		return result
+	}
+
+	// Beh joins on both sides, alef only to the letter before it, and marks
+	// are skipped.
+	test_helper.AssertEquals(t, []int{initialForm, medialForm, finalForm}, forms("ببب"))
+	test_helper.AssertEquals(t, []int{initialForm, finalForm, isolatedForm}, forms("باب"))
+	test_helper.AssertEquals(t, []int{initialForm, noForm, finalForm}, forms("بَب"))
+	test_helper.AssertEquals(t, []int{isolatedForm, noForm, isolatedForm}, forms("ب ب"))
+	test_helper.AssertEquals(t, []int{isolatedForm, noForm, isolatedForm}, forms("ب\u200cب"))
+}
@@ -0,0 +1,650 @@
+package shaping
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// woff2Tags are the tags of the tables known to WOFF2 table directories, by
+// their index in the flags of the table entries.
+var woff2Tags = [...]string{
+	"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post", "cvt ",
+	"fpgm", "glyf", "loca", "prep", "CFF ", "VORG", "EBDT", "EBLC", "gasp",
+	"hdmx", "kern", "LTSH", "PCLT", "VDMX", "vhea", "vmtx", "BASE", "GDEF",
+	"GPOS", "GSUB", "EBSC", "JSTF", "MATH", "CBDT", "CBLC", "COLR", "CPAL",
+	"SVG ", "sbix", "acnt", "avar", "bdat", "bloc", "bsln", "cvar", "fdsc",
+	"feat", "fmtx", "fvar", "gvar", "hsty", "just", "lcar", "mort", "morx",
+	"opbd", "prop", "trak", "Zapf", "Silf", "Glat", "Gloc", "Feat", "Sill",
+}
+
+var errWOFF2Truncated = errors.New("shaping: WOFF2 data truncated")
+
+// woff2Table is a table of a WOFF2 file, at offset of its decompressed data.
+type woff2Table struct {
+	tag         string
+	transformed bool
+	length      int // The length of the table, once transformed back.
+	offset      int
+	size        int
+}
+
+// woff2Font is a font of a WOFF2 file, made of some of its tables.
+type woff2Font struct {
+	flavor uint32
+	tables []int
+}
+
+// woff2File is the table directory of a WOFF2 file, and its compressed data.
+type woff2File struct {
+	tables     []woff2Table
+	fonts      []woff2Font
+	size       int // The size of the decompressed data.
+	compressed []byte
+}
+
+// woff2Reader reads the values of WOFF2 data, until the first error.
+type woff2Reader struct {
+	data []byte
+	err  error
+}
+
+func (r *woff2Reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data) {
+		r.data, r.err = nil, errWOFF2Truncated
+		return nil
+	}
+	result := r.data[:n:n]
+	r.data = r.data[n:]
+	return result
+}
+
+func (r *woff2Reader) u8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *woff2Reader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *woff2Reader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// base128 reads a UIntBase128: up to five bytes of seven bits, most
+// significant first, the last one without its top bit set.
+func (r *woff2Reader) base128() int {
+	value := uint32(0)
+	for i := 0; i < 5; i++ {
+		b := r.u8()
+		if r.err != nil {
+			return 0
+		}
+		if (i == 0 && b == 0x80) || value>>25 != 0 {
+			r.data, r.err = nil, errors.New("shaping: invalid WOFF2 UIntBase128")
+			return 0
+		}
+		value = value<<7 | uint32(b&0x7f)
+		if b&0x80 == 0 {
+			return int(value)
+		}
+	}
+	r.data, r.err = nil, errors.New("shaping: invalid WOFF2 UIntBase128")
+	return 0
+}
+
+// uint255 reads a 255UInt16, of one byte below 253, or of the byte after a
+// code for larger values.
+func (r *woff2Reader) uint255() int {
+	switch code := r.u8(); code {
+	case 253:
+		return int(r.u16())
+	case 254:
+		return 2*253 + int(r.u8())
+	case 255:
+		return 253 + int(r.u8())
+	default:
+		return int(code)
+	}
+}
+
+// parseWOFF2 returns the table directory of a WOFF2 file, and of its fonts
+// when it holds a font collection.
+func parseWOFF2(data []byte) (*woff2File, error) {
+	if len(data) < 48 {
+		return nil, errors.New("shaping: WOFF2 file too short")
+	}
+	flavor := binary.BigEndian.Uint32(data[4:])
+	tableCount := int(binary.BigEndian.Uint16(data[12:]))
+	compressedLength := int(binary.BigEndian.Uint32(data[20:]))
+
+	file := &woff2File{tables: make([]woff2Table, tableCount)}
+	r := &woff2Reader{data: data[48:]}
+	for i := range file.tables {
+		flags := r.u8()
+		table := &file.tables[i]
+		if flags&0x3f == 0x3f {
+			table.tag = string(r.bytes(4))
+		} else {
+			table.tag = woff2Tags[flags&0x3f]
+		}
+
+		// The null transform of glyf and loca is 3, that of other tables 0.
+		version := flags >> 6
+		if table.tag == "glyf" || table.tag == "loca" {
+			table.transformed = version != 3
+		} else {
+			table.transformed = version != 0
+		}
+		table.length = r.base128()
+		table.size = table.length
+		if table.transformed {
+			table.size = r.base128()
+		}
+		table.offset = file.size
+		file.size += table.size
+	}
+
+	if string(data[4:8]) == collectionSignature {
+		// Each font, and each of its tables, takes at least a byte of the
+		// directory.
+		r.u32() // The version of the collection.
+		fontCount := r.uint255()
+		if fontCount > len(r.data) {
+			return nil, errors.New("shaping: invalid WOFF2 collection directory")
+		}
+		file.fonts = make([]woff2Font, fontCount)
+		for i := range file.fonts {
+			font := &file.fonts[i]
+			count := r.uint255()
+			if count > len(r.data) {
+				return nil, errors.New("shaping: invalid WOFF2 collection directory")
+			}
+			font.tables = make([]int, count)
+			font.flavor = r.u32()
+			for j := range font.tables {
+				font.tables[j] = r.uint255()
+				if font.tables[j] >= tableCount {
+					return nil, errors.New("shaping: invalid WOFF2 collection directory")
+				}
+			}
+		}
+	} else {
+		font := woff2Font{flavor: flavor, tables: make([]int, tableCount)}
+		for i := range font.tables {
+			font.tables[i] = i
+		}
+		file.fonts = []woff2Font{font}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	offset := len(data) - len(r.data)
+	if compressedLength > len(data)-offset {
+		return nil, errors.New("shaping: WOFF2 compressed data out of bounds")
+	}
+	file.compressed = data[offset : offset+compressedLength]
+	return file, nil
+}
+
+// decodeWOFF2 returns the font at index of a WOFF2 file, whose tables are
+// compressed together with Brotli, and whose glyf, loca and hmtx tables may be
+// transformed to compress better.
+func decodeWOFF2(data []byte, index int) ([]byte, error) {
+	file, err := parseWOFF2(data)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(file.fonts) {
+		return nil, fmt.Errorf("shaping: font %d requested from a collection of %d", index, len(file.fonts))
+	}
+
+	// The decompressed data grows as it is read, rather than to the size the
+	// table directory claims.
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, brotli.NewReader(bytes.NewReader(file.compressed)), int64(file.size)); err != nil {
+		return nil, fmt.Errorf("shaping: WOFF2 data: %w", err)
+	}
+	decompressed := buf.Bytes()
+
+	font := file.fonts[index]
+	tables := make([]sfntTable, len(font.tables))
+	var glyf, loca, hmtx, hhea, head *sfntTable
+	var glyfTable, locaTable, hmtxTable woff2Table
+	for i, t := range font.tables {
+		table := file.tables[t]
+		tables[i] = sfntTable{tag: table.tag, data: decompressed[table.offset : table.offset+table.size]}
+		switch table.tag {
+		case "glyf":
+			glyf, glyfTable = &tables[i], table
+		case "loca":
+			loca, locaTable = &tables[i], table
+		case "hmtx":
+			hmtx, hmtxTable = &tables[i], table
+		case "hhea":
+			hhea = &tables[i]
+		case "head":
+			head = &tables[i]
+		default:
+			if table.transformed {
+				return nil, fmt.Errorf("shaping: WOFF2 table %q transformed", table.tag)
+			}
+		}
+	}
+
+	var xMins []int16
+	if glyfTable.transformed || locaTable.transformed {
+		if glyf == nil || loca == nil || !glyfTable.transformed || !locaTable.transformed {
+			return nil, errors.New("shaping: WOFF2 glyf and loca tables transformed apart")
+		}
+		glyf.data, loca.data, xMins, err = reconstructGlyf(glyf.data)
+		if err != nil {
+			return nil, err
+		}
+		if len(loca.data) != locaTable.length {
+			return nil, errors.New("shaping: invalid WOFF2 loca table length")
+		}
+	}
+	if hmtxTable.transformed {
+		if xMins == nil || hhea == nil || len(hhea.data) < 36 {
+			return nil, errors.New("shaping: WOFF2 hmtx table transformed without glyf")
+		}
+		numHMetrics := int(binary.BigEndian.Uint16(hhea.data[34:]))
+		if hmtx.data, err = reconstructHmtx(hmtx.data, numHMetrics, xMins); err != nil {
+			return nil, err
+		}
+		if len(hmtx.data) != hmtxTable.length {
+			return nil, errors.New("shaping: invalid WOFF2 hmtx table length")
+		}
+	}
+
+	// The checksums of the tables are not kept, and the adjustment of the
+	// checksum of the font is computed once it is written.
+	if head != nil && len(head.data) >= 12 {
+		binary.BigEndian.PutUint32(head.data[8:], 0)
+	}
+	for i := range tables {
+		tables[i].checksum = sfntChecksum(tables[i].data)
+	}
+	result := writeSfnt(font.flavor, tables)
+	for i := range tables {
+		record := result[12+16*i:]
+		if string(record[:4]) == "head" && binary.BigEndian.Uint32(record[12:]) >= 12 {
+			offset := binary.BigEndian.Uint32(record[8:])
+			binary.BigEndian.PutUint32(result[offset+8:], 0xb1b0afba-sfntChecksum(result))
+		}
+	}
+	return result, nil
+}
+
+// sfntChecksum returns the sum of the big endian words of the data, padded
+// with zeros.
+func sfntChecksum(data []byte) uint32 {
+	sum := uint32(0)
+	for len(data) >= 4 {
+		sum += binary.BigEndian.Uint32(data)
+		data = data[4:]
+	}
+	var last [4]byte
+	copy(last[:], data)
+	return sum + binary.BigEndian.Uint32(last[:])
+}
+
+// glyphPoint is a point of the contours of a simple glyph.
+type glyphPoint struct {
+	x, y    int
+	onCurve bool
+}
+
+// Flags of the points and components of glyphs of glyf tables.
+const (
+	glyphOnCurve       = 0x01
+	glyphXShort        = 0x02
+	glyphYShort        = 0x04
+	glyphRepeat        = 0x08
+	glyphXSame         = 0x10
+	glyphYSame         = 0x20
+	glyphOverlapSimple = 0x40
+
+	componentArgsAreWords   = 0x0001
+	componentScale          = 0x0008
+	componentMore           = 0x0020
+	componentXYScale        = 0x0040
+	componentTwoByTwo       = 0x0080
+	componentHasInstruction = 0x0100
+)
+
+// reconstructGlyf returns the glyf and loca tables of a transformed glyf
+// table, whose glyphs are split into streams of contours, points, flags,
+// coordinates, components, bounding boxes and instructions. It also returns
+// the minimum x of each glyph.
+func reconstructGlyf(data []byte) (glyf, loca []byte, xMins []int16, err error) {
+	r := &woff2Reader{data: data}
+	r.u16() // Reserved.
+	options := r.u16()
+	numGlyphs := int(r.u16())
+	indexFormat := r.u16()
+	var sizes [7]int
+	for i := range sizes {
+		sizes[i] = int(r.u32())
+	}
+	var streams [7]woff2Reader
+	for i := range streams {
+		streams[i].data = r.bytes(sizes[i])
+	}
+	contours, points, flags, glyphs, composites, bboxes, instructions := &streams[0], &streams[1], &streams[2], &streams[3], &streams[4], &streams[5], &streams[6]
+	bboxBitmap := bboxes.bytes(4 * ((numGlyphs + 31) / 32))
+	overlapBitmap := make([]byte, (numGlyphs+7)/8)
+	if options&1 != 0 {
+		overlapBitmap = r.bytes(len(overlapBitmap))
+	}
+	if r.err != nil || bboxes.err != nil {
+		return nil, nil, nil, errWOFF2Truncated
+	}
+	if indexFormat > 1 {
+		return nil, nil, nil, errors.New("shaping: invalid WOFF2 loca format")
+	}
+
+	xMins = make([]int16, numGlyphs)
+	appendLoca := func(offset int) error {
+		if indexFormat == 0 {
+			if offset/2 > 0xffff {
+				return errors.New("shaping: WOFF2 glyf table too large for its loca format")
+			}
+			loca = binary.BigEndian.AppendUint16(loca, uint16(offset/2))
+		} else {
+			loca = binary.BigEndian.AppendUint32(loca, uint32(offset))
+		}
+		return nil
+	}
+
+	var glyphPoints []glyphPoint
+	for i := 0; i < numGlyphs; i++ {
+		if err := appendLoca(len(glyf)); err != nil {
+			return nil, nil, nil, err
+		}
+		contourCount := int16(contours.u16())
+		explicitBBox := bboxBitmap[i>>3]&(0x80>>(i&7)) != 0
+		var bbox [4]int16
+		if explicitBBox {
+			for j := range bbox {
+				bbox[j] = int16(bboxes.u16())
+			}
+		}
+
+		switch {
+		case contourCount == 0:
+			if explicitBBox {
+				return nil, nil, nil, errors.New("shaping: WOFF2 empty glyph with a bounding box")
+			}
+			continue
+
+		case contourCount > 0:
+			var endPoints []byte
+			pointCount := 0
+			for j := 0; j < int(contourCount); j++ {
+				pointCount += points.uint255()
+				if pointCount > 0xffff {
+					return nil, nil, nil, errors.New("shaping: WOFF2 glyph with too many points")
+				}
+				endPoints = binary.BigEndian.AppendUint16(endPoints, uint16(pointCount-1))
+			}
+
+			glyphPoints = glyphPoints[:0]
+			x, y := 0, 0
+			for j := 0; j < pointCount && glyphs.err == nil; j++ {
+				flag := flags.u8()
+				dx, dy := tripletDelta(flag&0x7f, glyphs)
+				x, y = x+dx, y+dy
+				glyphPoints = append(glyphPoints, glyphPoint{x, y, flag&0x80 == 0})
+			}
+			if !explicitBBox && len(glyphPoints) > 0 {
+				bbox = [4]int16{int16(x), int16(y), int16(x), int16(y)}
+				for _, p := range glyphPoints {
+					bbox[0], bbox[1] = min(bbox[0], int16(p.x)), min(bbox[1], int16(p.y))
+					bbox[2], bbox[3] = max(bbox[2], int16(p.x)), max(bbox[3], int16(p.y))
+				}
+			}
+			instructionLength := glyphs.uint255()
+
+			glyf = appendGlyphHeader(glyf, contourCount, bbox)
+			glyf = append(glyf, endPoints...)
+			glyf = binary.BigEndian.AppendUint16(glyf, uint16(instructionLength))
+			glyf = append(glyf, instructions.bytes(instructionLength)...)
+			glyf = appendGlyphPoints(glyf, glyphPoints, overlapBitmap[i>>3]&(0x80>>(i&7)) != 0)
+
+		case contourCount == -1:
+			if !explicitBBox {
+				return nil, nil, nil, errors.New("shaping: WOFF2 composite glyph without a bounding box")
+			}
+			components := composites.data
+			hasInstructions := false
+			for more := true; more && composites.err == nil; {
+				flags := composites.u16()
+				size := 2 + 2 // The glyph index and arguments.
+				if flags&componentArgsAreWords != 0 {
+					size += 2
+				}
+				switch {
+				case flags&componentScale != 0:
+					size += 2
+				case flags&componentXYScale != 0:
+					size += 4
+				case flags&componentTwoByTwo != 0:
+					size += 8
+				}
+				composites.bytes(size)
+				hasInstructions = hasInstructions || flags&componentHasInstruction != 0
+				more = flags&componentMore != 0
+			}
+
+			glyf = appendGlyphHeader(glyf, contourCount, bbox)
+			glyf = append(glyf, components[:len(components)-len(composites.data)]...)
+			if hasInstructions {
+				instructionLength := glyphs.uint255()
+				glyf = binary.BigEndian.AppendUint16(glyf, uint16(instructionLength))
+				glyf = append(glyf, instructions.bytes(instructionLength)...)
+			}
+
+		default:
+			return nil, nil, nil, fmt.Errorf("shaping: WOFF2 glyph with %d contours", contourCount)
+		}
+
+		xMins[i] = bbox[0]
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+		for _, stream := range streams {
+			if stream.err != nil {
+				return nil, nil, nil, stream.err
+			}
+		}
+	}
+	if err := appendLoca(len(glyf)); err != nil {
+		return nil, nil, nil, err
+	}
+	return glyf, loca, xMins, nil
+}
+
+// tripletDelta reads from the glyph stream the coordinates of a point relative
+// to the previous one, encoded as selected by the flag of the point.
+func tripletDelta(flag uint8, r *woff2Reader) (dx, dy int) {
+	withSign := func(flag uint8, value int) int {
+		if flag&1 != 0 {
+			return value
+		}
+		return -value
+	}
+
+	f := int(flag)
+	switch {
+	case f < 10:
+		b := r.bytes(1)
+		if b == nil {
+			return 0, 0
+		}
+		return 0, withSign(flag, (f&14)<<7+int(b[0]))
+	case f < 20:
+		b := r.bytes(1)
+		if b == nil {
+			return 0, 0
+		}
+		return withSign(flag, ((f-10)&14)<<7+int(b[0])), 0
+	case f < 84:
+		b := r.bytes(1)
+		if b == nil {
+			return 0, 0
+		}
+		f -= 20
+		return withSign(flag, 1+(f&0x30)+int(b[0]>>4)), withSign(flag>>1, 1+(f&0x0c)<<2+int(b[0]&0x0f))
+	case f < 120:
+		b := r.bytes(2)
+		if b == nil {
+			return 0, 0
+		}
+		f -= 84
+		return withSign(flag, 1+(f/12)<<8+int(b[0])), withSign(flag>>1, 1+((f%12)>>2)<<8+int(b[1]))
+	case f < 124:
+		b := r.bytes(3)
+		if b == nil {
+			return 0, 0
+		}
+		return withSign(flag, int(b[0])<<4+int(b[1]>>4)), withSign(flag>>1, int(b[1]&0x0f)<<8+int(b[2]))
+	default:
+		b := r.bytes(4)
+		if b == nil {
+			return 0, 0
+		}
+		return withSign(flag, int(b[0])<<8+int(b[1])), withSign(flag>>1, int(b[2])<<8+int(b[3]))
+	}
+}
+
+// appendGlyphHeader appends the number of contours and the bounding box of a
+// glyph.
+func appendGlyphHeader(glyf []byte, contourCount int16, bbox [4]int16) []byte {
+	glyf = binary.BigEndian.AppendUint16(glyf, uint16(contourCount))
+	for _, v := range bbox {
+		glyf = binary.BigEndian.AppendUint16(glyf, uint16(v))
+	}
+	return glyf
+}
+
+// appendGlyphPoints appends the flags and coordinates of the points of a
+// simple glyph, repeating flags and storing coordinates in a byte where they
+// can.
+func appendGlyphPoints(glyf []byte, points []glyphPoint, overlap bool) []byte {
+	var xs, ys []byte
+	lastX, lastY, lastFlag, repeats := 0, 0, -1, 0
+	for i, p := range points {
+		flag := 0
+		if p.onCurve {
+			flag |= glyphOnCurve
+		}
+		if overlap && i == 0 {
+			flag |= glyphOverlapSimple
+		}
+
+		dx, dy := p.x-lastX, p.y-lastY
+		switch {
+		case dx == 0:
+			flag |= glyphXSame
+		case dx > -256 && dx < 256:
+			flag |= glyphXShort
+			if dx > 0 {
+				flag |= glyphXSame
+			}
+			xs = append(xs, byte(max(dx, -dx)))
+		default:
+			xs = binary.BigEndian.AppendUint16(xs, uint16(dx))
+		}
+		switch {
+		case dy == 0:
+			flag |= glyphYSame
+		case dy > -256 && dy < 256:
+			flag |= glyphYShort
+			if dy > 0 {
+				flag |= glyphYSame
+			}
+			ys = append(ys, byte(max(dy, -dy)))
+		default:
+			ys = binary.BigEndian.AppendUint16(ys, uint16(dy))
+		}
+
+		if flag == lastFlag && repeats != 255 {
+			glyf[len(glyf)-1] |= glyphRepeat
+			repeats++
+		} else {
+			if repeats != 0 {
+				glyf = append(glyf, byte(repeats))
+			}
+			glyf = append(glyf, byte(flag))
+			repeats = 0
+		}
+		lastX, lastY, lastFlag = p.x, p.y, flag
+	}
+	if repeats != 0 {
+		glyf = append(glyf, byte(repeats))
+	}
+	glyf = append(glyf, xs...)
+	return append(glyf, ys...)
+}
+
+// reconstructHmtx returns the hmtx table of a transformed one, whose left side
+// bearings may be left out where they are the minimum x of their glyphs.
+func reconstructHmtx(data []byte, numHMetrics int, xMins []int16) ([]byte, error) {
+	numGlyphs := len(xMins)
+	if numHMetrics < 1 || numHMetrics > numGlyphs {
+		return nil, errors.New("shaping: invalid WOFF2 hmtx metrics count")
+	}
+	r := &woff2Reader{data: data}
+	flags := r.u8()
+	if flags&3 == 0 || flags&^3 != 0 {
+		return nil, errors.New("shaping: invalid WOFF2 hmtx transform flags")
+	}
+	advances := r.bytes(2 * numHMetrics)
+	var bearings, monospaceBearings []byte
+	if flags&1 == 0 {
+		bearings = r.bytes(2 * numHMetrics)
+	}
+	if flags&2 == 0 {
+		monospaceBearings = r.bytes(2 * (numGlyphs - numHMetrics))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	result := make([]byte, 0, 4*numHMetrics+2*(numGlyphs-numHMetrics))
+	for i := 0; i < numHMetrics; i++ {
+		result = append(result, advances[2*i:2*i+2]...)
+		if bearings != nil {
+			result = append(result, bearings[2*i:2*i+2]...)
+		} else {
+			result = binary.BigEndian.AppendUint16(result, uint16(xMins[i]))
+		}
+	}
+	for i := numHMetrics; i < numGlyphs; i++ {
+		if monospaceBearings != nil {
+			result = append(result, monospaceBearings[2*(i-numHMetrics):2*(i-numHMetrics)+2]...)
+		} else {
+			result = binary.BigEndian.AppendUint16(result, uint16(xMins[i]))
+		}
+	}
+	return result, nil
+}
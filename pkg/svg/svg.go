@@ -0,0 +1,594 @@
+// Package svg exports canvases as SVG documents.
+//
+// Only display lists can be exported, as canvases created by the GL drivers
+// cannot be inspected. To export a control, create it with a
+// gxui.RecordingDriver and pass the canvas returned by its Draw method.
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	m "math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/badu/gxui"
+	gxfont "github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// ErrNotDisplayList is returned when the exported canvas is not a *gxui.DisplayList.
+var ErrNotDisplayList = errors.New("svg: canvas is not a display list")
+
+type encoder struct {
+	defs      bytes.Buffer
+	canvases  map[*gxui.DisplayList]string
+	textures  map[gxui.Texture]string
+	gradients map[*gxui.Gradient]string
+	patterns  map[*gxui.Pattern]string
+	images    map[gxui.Texture]string
+	fonts     map[gxui.Font]string
+	nextID    int
+}
+
+// Encode writes a completed canvas to w as a standalone SVG document, the
+// size of the canvas in DIPs. Nested canvases are written once and referenced
+// everywhere they are drawn, and textures are embedded as base64 PNG images.
+func Encode(w io.Writer, canvas gxui.Canvas) error {
+	list, ok := canvas.(*gxui.DisplayList)
+	if !ok {
+		return ErrNotDisplayList
+	}
+	if !list.IsComplete() {
+		return errors.New("svg: canvas is not complete")
+	}
+
+	e := &encoder{
+		canvases:  make(map[*gxui.DisplayList]string),
+		textures:  make(map[gxui.Texture]string),
+		gradients: make(map[*gxui.Gradient]string),
+		patterns:  make(map[*gxui.Pattern]string),
+		images:    make(map[gxui.Texture]string),
+		fonts:     make(map[gxui.Font]string),
+	}
+	body := &bytes.Buffer{}
+	if err := e.canvas(body, list); err != nil {
+		return err
+	}
+
+	size := list.Size()
+	out := &bytes.Buffer{}
+	out.WriteString(xml.Header)
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" `+
+		`version="1.1" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		size.Width, size.Height, size.Width, size.Height)
+	if e.defs.Len() > 0 {
+		out.WriteString("<defs>\n")
+		out.Write(e.defs.Bytes())
+		out.WriteString("</defs>\n")
+	}
+	out.Write(body.Bytes())
+	out.WriteString("</svg>\n")
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func (e *encoder) id(prefix string) string {
+	e.nextID++
+	return prefix + strconv.Itoa(e.nextID)
+}
+
+// blendModes are the CSS names of the blend modes.
+var blendModes = map[gxui.BlendMode]string{
+	gxui.BlendMultiply: "multiply",
+	gxui.BlendScreen:   "screen",
+	gxui.BlendAdd:      "plus-lighter",
+}
+
+// canvas writes the commands of list to out. AddClip opens a clipped group
+// which is closed by the Pop matching the last Push.
+func (e *encoder) canvas(out *bytes.Buffer, list *gxui.DisplayList) error {
+	open := []int{0}
+	for _, c := range list.Commands() {
+		switch c.Op {
+		case gxui.OpPush:
+			open = append(open, 0)
+		case gxui.OpPop, gxui.OpPopLayer:
+			out.WriteString(strings.Repeat("</g>\n", open[len(open)-1]))
+			open = open[:len(open)-1]
+		case gxui.OpPushLayer:
+			// Group opacity and blending apply to the group as a whole.
+			out.WriteString("<g")
+			if opacity := math.Saturate(c.Opacity); opacity < 1 {
+				fmt.Fprintf(out, ` opacity="%s"`, number(opacity))
+			}
+			if c.Blend != gxui.BlendNormal {
+				fmt.Fprintf(out, ` style="mix-blend-mode:%s"`, blendModes[c.Blend])
+			}
+			out.WriteString(">\n")
+			open = append(open, 1)
+		case gxui.OpAddClip:
+			id := e.id("clip")
+			fmt.Fprintf(&e.defs, `<clipPath id="%s"><rect %s/></clipPath>`+"\n", id, rectAttrs(c.Rect))
+			fmt.Fprintf(out, `<g clip-path="url(#%s)">`+"\n", id)
+			open[len(open)-1]++
+		case gxui.OpTransform:
+			m := c.Matrix
+			fmt.Fprintf(out, `<g transform="matrix(%s %s %s %s %s %s)">`+"\n",
+				number(m[0]), number(m[1]), number(m[3]), number(m[4]), number(m[6]), number(m[7]))
+			open[len(open)-1]++
+		case gxui.OpClear:
+			fmt.Fprintf(out, `<rect %s %s/>`+"\n", rectAttrs(list.Size().Rect()), paint("fill", c.Color))
+		case gxui.OpDrawCanvas:
+			id, err := e.child(c.Canvas)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, `<use xlink:href="#%s" x="%d" y="%d"/>`+"\n", id, c.Point.X, c.Point.Y)
+		case gxui.OpDrawTexture:
+			if err := e.texture(out, c.Texture, c.Rect); err != nil {
+				return err
+			}
+		case gxui.OpDrawRunes:
+			e.runes(out, c.Font, c.Runes, c.Points, c.Color)
+		case gxui.OpDrawLines:
+			e.lines(out, c.Polygon, c.Pen)
+		case gxui.OpDrawPolygon:
+			if err := e.polygon(out, c.Polygon, c.Pen, c.Brush); err != nil {
+				return err
+			}
+		case gxui.OpDrawRect:
+			if !c.Brush.IsTransparent() {
+				fill, err := e.fill(c.Brush, c.Rect)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, `<rect %s %s/>`+"\n", rectAttrs(c.Rect), fill)
+			}
+		case gxui.OpDrawRoundedRect:
+			r := c.Rect
+			err := e.polygon(out, gxui.Polygon{
+				{Position: r.TopLeft(), RoundedRadius: c.Radii[0]},
+				{Position: r.TopRight(), RoundedRadius: c.Radii[1]},
+				{Position: r.BottomRight(), RoundedRadius: c.Radii[3]},
+				{Position: r.BottomLeft(), RoundedRadius: c.Radii[2]},
+			}, c.Pen, c.Brush)
+			if err != nil {
+				return err
+			}
+		case gxui.OpFillPath:
+			if d := curveData(c.Path); d != "" && !c.Brush.IsTransparent() {
+				fill, err := e.fill(c.Brush, c.Path.Bounds())
+				if err != nil {
+					return err
+				}
+				rule := ""
+				if c.Rule == gxui.EvenOdd {
+					rule = ` fill-rule="evenodd"`
+				}
+				fmt.Fprintf(out, `<path d="%s" %s%s/>`+"\n", d, fill, rule)
+			}
+		case gxui.OpStrokePath:
+			if d := curveData(c.Path); d != "" && c.Pen.Width > 0 && c.Pen.Color.A > 0 {
+				fmt.Fprintf(out, `<path d="%s" fill="none" %s stroke-width="%s"%s/>`+"\n",
+					d, paint("stroke", c.Pen.Color), number(c.Pen.Width), strokeStyle(c.Pen))
+			}
+		case gxui.OpDrawShadow:
+			e.shadow(out, c.Rect, c.Radii, c.Shadow)
+		case gxui.OpBlurBackdrop:
+			// SVG has no backdrop filter, so the backdrop is left sharp.
+		default:
+			return fmt.Errorf("svg: unknown display list op %v", c.Op)
+		}
+	}
+	out.WriteString(strings.Repeat("</g>\n", open[0]))
+	return nil
+}
+
+// child returns the id of the group holding the nested canvas, writing it to
+// the definitions the first time it is drawn.
+func (e *encoder) child(list *gxui.DisplayList) (string, error) {
+	if id, found := e.canvases[list]; found {
+		return id, nil
+	}
+	id := e.id("canvas")
+	e.canvases[list] = id
+
+	body := &bytes.Buffer{}
+	if err := e.canvas(body, list); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&e.defs, `<g id="%s">`+"\n", id)
+	e.defs.Write(body.Bytes())
+	e.defs.WriteString("</g>\n")
+	return id, nil
+}
+
+// imageURI returns the data URI of the texture, encoding it the first time.
+func (e *encoder) imageURI(texture gxui.Texture) (string, error) {
+	if uri, found := e.textures[texture]; found {
+		return uri, nil
+	}
+	buffer := &bytes.Buffer{}
+	if err := png.Encode(buffer, texture.Image()); err != nil {
+		return "", err
+	}
+	uri := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes())
+	e.textures[texture] = uri
+	return uri, nil
+}
+
+func (e *encoder) texture(out *bytes.Buffer, texture gxui.Texture, rect math.Rect) error {
+	uri, err := e.imageURI(texture)
+	if err != nil {
+		return err
+	}
+
+	transform := ""
+	if texture.FlipY() {
+		transform = fmt.Sprintf(` transform="matrix(1 0 0 -1 0 %d)"`, rect.Min.Y+rect.Max.Y)
+	}
+	fmt.Fprintf(out, `<image %s preserveAspectRatio="none"%s xlink:href="%s"/>`+"\n", rectAttrs(rect), transform, uri)
+	return nil
+}
+
+func (e *encoder) runes(out *bytes.Buffer, font gxui.Font, runes []rune, points []math.Point, color gxui.Color) {
+	if len(runes) == 0 {
+		return
+	}
+	xs, ys := make([]string, len(points)), make([]string, len(points))
+	for i, p := range points {
+		xs[i], ys[i] = strconv.Itoa(p.X), strconv.Itoa(p.Y)
+	}
+	fmt.Fprintf(out, `<text xml:space="preserve" %s font-size="%d" %s x="%s" y="%s">`,
+		e.fontAttrs(font), font.Size(), paint("fill", color), strings.Join(xs, " "), strings.Join(ys, " "))
+	out.WriteString(escape(string(runes)))
+	out.WriteString("</text>\n")
+}
+
+// fontAttrs returns the font-family, font-weight and font-style attributes of
+// the face of the font. The family of a gxui.FontFamily lists the families of
+// its fonts, in the order they are tried.
+func (e *encoder) fontAttrs(f gxui.Font) string {
+	if attrs, found := e.fonts[f]; found {
+		return attrs
+	}
+	fonts := []gxui.Font{f}
+	if family, ok := f.(gxui.FontFamily); ok && len(family.Fonts()) > 0 {
+		fonts = family.Fonts()
+	}
+	var first gxfont.Face
+	var families []string
+	for i, font := range fonts {
+		face, err := gxfont.ParseFace(font.Data())
+		if err != nil {
+			face = gxfont.Face{Family: font.Name(), Weight: gxfont.Regular}
+		}
+		if i == 0 {
+			first = face
+		}
+		if name := familyName(face.Family); !slices.Contains(families, name) {
+			families = append(families, name)
+		}
+	}
+
+	attrs := fmt.Sprintf(`font-family="%s"`, escape(strings.Join(families, ", ")))
+	if first.Weight != gxfont.Regular {
+		attrs += fmt.Sprintf(` font-weight="%d"`, first.Weight)
+	}
+	if first.Italic {
+		attrs += ` font-style="italic"`
+	}
+	e.fonts[f] = attrs
+	return attrs
+}
+
+// familyName returns the family quoted as a CSS font family name, unless it is
+// a single identifier.
+func familyName(family string) string {
+	for _, r := range family {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "'" + strings.ReplaceAll(family, "'", "\\'") + "'"
+		}
+	}
+	return family
+}
+
+func (e *encoder) lines(out *bytes.Buffer, polygon gxui.Polygon, pen gxui.Pen) {
+	if len(polygon) < 2 || pen.Width <= 0 || pen.Color.A <= 0 {
+		return
+	}
+	points := make([]string, len(polygon))
+	for i, v := range polygon {
+		points[i] = fmt.Sprintf("%d,%d", v.Position.X, v.Position.Y)
+	}
+	fmt.Fprintf(out, `<polyline points="%s" fill="none" %s stroke-width="%s"%s/>`+"\n",
+		strings.Join(points, " "), paint("stroke", pen.Color), number(pen.Width), strokeStyle(pen))
+}
+
+// strokeStyle returns the attributes of the caps, joins and dashes of a styled
+// pen, each preceded by a space.
+func strokeStyle(pen gxui.Pen) string {
+	var result strings.Builder
+	switch pen.Cap {
+	case gxui.RoundCap:
+		result.WriteString(` stroke-linecap="round"`)
+	case gxui.SquareCap:
+		result.WriteString(` stroke-linecap="square"`)
+	}
+	switch pen.Join {
+	case gxui.RoundJoin:
+		result.WriteString(` stroke-linejoin="round"`)
+	case gxui.BevelJoin:
+		result.WriteString(` stroke-linejoin="bevel"`)
+	}
+	if pen.MiterLimit > 0 {
+		fmt.Fprintf(&result, ` stroke-miterlimit="%s"`, number(pen.MiterLimit))
+	}
+	if pen.Dash != nil {
+		total := float32(0)
+		lengths := make([]string, len(pen.Dash.Lengths))
+		for i, l := range pen.Dash.Lengths {
+			if l < 0 {
+				return result.String()
+			}
+			total += l
+			lengths[i] = number(l)
+		}
+		if total > 0 {
+			fmt.Fprintf(&result, ` stroke-dasharray="%s"`, strings.Join(lengths, " "))
+			if pen.Dash.Offset != 0 {
+				fmt.Fprintf(&result, ` stroke-dashoffset="%s"`, number(pen.Dash.Offset))
+			}
+		}
+	}
+	return result.String()
+}
+
+// polygon writes a filled polygon. As the drivers draw the pen inside the
+// polygon's edge, the stroke is twice the pen width and clipped to the polygon.
+func (e *encoder) polygon(out *bytes.Buffer, polygon gxui.Polygon, pen gxui.Pen, brush gxui.Brush) error {
+	d := pathData(polygon)
+	if d == "" {
+		return nil
+	}
+	if !brush.IsTransparent() {
+		fill, err := e.fill(brush, polygon.Bounds())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, `<path d="%s" %s/>`+"\n", d, fill)
+	}
+	if pen.Width > 0 && pen.Color.A > 0 {
+		id := e.id("edge")
+		fmt.Fprintf(&e.defs, `<clipPath id="%s"><path d="%s"/></clipPath>`+"\n", id, d)
+		fmt.Fprintf(out, `<path d="%s" fill="none" %s stroke-width="%s"%s clip-path="url(#%s)"/>`+"\n",
+			d, paint("stroke", pen.Color), number(2*pen.Width), strokeStyle(pen), id)
+	}
+	return nil
+}
+
+// shadow writes the outline of a shadow, blurred by a Gaussian filter covering
+// the bounds of the shadow.
+func (e *encoder) shadow(out *bytes.Buffer, rect math.Rect, radii [4]float32, shadow gxui.Shadow) {
+	path := shadow.Path(rect, radii[0], radii[1], radii[2], radii[3])
+	if path == nil || !shadow.IsVisible() {
+		return
+	}
+	filter := ""
+	if shadow.Blur > 0 {
+		id := e.id("shadow")
+		fmt.Fprintf(&e.defs, `<filter id="%s" filterUnits="userSpaceOnUse" %s><feGaussianBlur stdDeviation="%s"/></filter>`+"\n",
+			id, rectAttrs(shadow.Bounds(rect)), number(shadow.Blur/2))
+		filter = fmt.Sprintf(` filter="url(#%s)"`, id)
+	}
+	fmt.Fprintf(out, `<path d="%s" %s%s/>`+"\n", curveData(path), paint("fill", shadow.Color), filter)
+}
+
+// fill returns the fill attributes for a shape with the given bounds.
+func (e *encoder) fill(brush gxui.Brush, bounds math.Rect) (string, error) {
+	if brush.Pattern != nil {
+		return e.pattern(brush, bounds)
+	}
+	if brush.Gradient == nil {
+		return paint("fill", brush.Color), nil
+	}
+	matrix, ok := brush.Gradient.Transform(bounds)
+	if !ok {
+		return paint("fill", brush.Color), nil
+	}
+
+	// The stops are written once per gradient, and referenced by a gradient
+	// placing them in the bounds of each shape.
+	stops, found := e.gradients[brush.Gradient]
+	if !found {
+		stops = e.id("stops")
+		e.gradients[brush.Gradient] = stops
+		fmt.Fprintf(&e.defs, `<%s id="%s">`, gradientElement(brush.Gradient), stops)
+		for _, stop := range brush.Gradient.Stops {
+			fmt.Fprintf(&e.defs, `<stop offset="%s" %s/>`, number(stop.Offset), paint("stop-color", stop.Color))
+		}
+		fmt.Fprintf(&e.defs, "</%s>\n", gradientElement(brush.Gradient))
+	}
+
+	id := e.id("gradient")
+	geometry := `x1="0" y1="0" x2="1" y2="0"`
+	if brush.Gradient.Kind == gxui.RadialGradient {
+		geometry = `cx="0" cy="0" r="1" fx="0" fy="0"`
+	}
+	values := make([]string, len(matrix))
+	for i, v := range matrix {
+		values[i] = number(v)
+	}
+	fmt.Fprintf(&e.defs, `<%s id="%s" xlink:href="#%s" gradientUnits="userSpaceOnUse" %s gradientTransform="matrix(%s)"/>`+"\n",
+		gradientElement(brush.Gradient), id, stops, geometry, strings.Join(values, " "))
+	return fmt.Sprintf(`fill="url(#%s)"`, id), nil
+}
+
+// pattern returns the fill attribute of a pattern brush. Like gradients, the
+// tile of each pattern is written once, and referenced by a pattern placing it
+// in the bounds of each shape. Mirrored tiles hold the texture four times,
+// flipped. Clamped patterns have a single tile covering the shape, which is
+// transparent beyond the texture as SVG cannot extend its edges.
+func (e *encoder) pattern(brush gxui.Brush, bounds math.Rect) (string, error) {
+	pattern := brush.Pattern
+	matrix := pattern.Matrix(bounds)
+	if _, ok := matrix.Invert(); !ok {
+		return paint("fill", brush.Color), nil
+	}
+	image, err := e.patternImage(pattern.Texture)
+	if err != nil {
+		return "", err
+	}
+
+	size, placements := pattern.Tile()
+	id := e.id("pattern")
+	if pattern.Wrap == gxui.WrapClamp {
+		// The content of a tile is relative to its top-left corner.
+		topLeft, bottomRight := pattern.Extent(bounds)
+		size := bottomRight.Sub(topLeft)
+		fmt.Fprintf(&e.defs, `<pattern id="%s" patternUnits="userSpaceOnUse" width="%s" height="%s" patternTransform="matrix(%s)">`,
+			id, number(size.X), number(size.Y), matrixValues(matrix.Mul(math.CreateMat3Translate(topLeft.X, topLeft.Y))))
+		for _, t := range placements {
+			t = math.CreateMat3Translate(-topLeft.X, -topLeft.Y).Mul(t)
+			fmt.Fprintf(&e.defs, `<use xlink:href="#%s" transform="matrix(%s)"/>`, image, matrixValues(t))
+		}
+		e.defs.WriteString("</pattern>\n")
+		return fmt.Sprintf(`fill="url(#%s)"`, id), nil
+	}
+
+	tile, found := e.patterns[pattern]
+	if !found {
+		tile = e.id("tile")
+		e.patterns[pattern] = tile
+		fmt.Fprintf(&e.defs, `<pattern id="%s" patternUnits="userSpaceOnUse" width="%s" height="%s">`,
+			tile, number(size.X), number(size.Y))
+		for _, t := range placements {
+			fmt.Fprintf(&e.defs, `<use xlink:href="#%s" transform="matrix(%s)"/>`, image, matrixValues(t))
+		}
+		e.defs.WriteString("</pattern>\n")
+	}
+	fmt.Fprintf(&e.defs, `<pattern id="%s" xlink:href="#%s" patternTransform="matrix(%s)"/>`+"\n",
+		id, tile, matrixValues(matrix))
+	return fmt.Sprintf(`fill="url(#%s)"`, id), nil
+}
+
+// patternImage returns the id of the image of the texture at its size in DIPs,
+// written to the definitions the first time it is used by a pattern.
+func (e *encoder) patternImage(texture gxui.Texture) (string, error) {
+	if id, found := e.images[texture]; found {
+		return id, nil
+	}
+	uri, err := e.imageURI(texture)
+	if err != nil {
+		return "", err
+	}
+	id := e.id("image")
+	e.images[texture] = id
+	size := texture.Size()
+	fmt.Fprintf(&e.defs, `<image id="%s" width="%d" height="%d" preserveAspectRatio="none" xlink:href="%s"/>`+"\n",
+		id, size.Width, size.Height, uri)
+	return id, nil
+}
+
+// matrixValues returns the six values of the SVG matrix transform of m.
+func matrixValues(m math.Mat3) string {
+	return strings.Join([]string{number(m[0]), number(m[1]), number(m[3]), number(m[4]), number(m[6]), number(m[7])}, " ")
+}
+
+func gradientElement(gradient *gxui.Gradient) string {
+	if gradient.Kind == gxui.RadialGradient {
+		return "radialGradient"
+	}
+	return "linearGradient"
+}
+
+func pathData(polygon gxui.Polygon) string {
+	corners := polygon.Corners()
+	if len(corners) < 3 {
+		return ""
+	}
+	d := &strings.Builder{}
+	for i, c := range corners {
+		if i == 0 {
+			fmt.Fprintf(d, "M%s %s", number(c.Start.X), number(c.Start.Y))
+		} else {
+			fmt.Fprintf(d, "L%s %s", number(c.Start.X), number(c.Start.Y))
+		}
+		if c.Radius > 0 {
+			sweep := 0
+			if c.Clockwise {
+				sweep = 1
+			}
+			fmt.Fprintf(d, "A%s %s 0 0 %d %s %s", number(c.Radius), number(c.Radius), sweep, number(c.End.X), number(c.End.Y))
+		}
+	}
+	d.WriteString("Z")
+	return d.String()
+}
+
+// curveData returns the path data of the path.
+func curveData(path *gxui.Path) string {
+	d := &strings.Builder{}
+	point := func(p math.Vec2) string { return number(p.X) + " " + number(p.Y) }
+	for i, s := range path.Segments {
+		if i == 0 && s.Op != gxui.PathMoveTo {
+			// Path data must start with a move.
+			d.WriteString("M0 0")
+		}
+		switch s.Op {
+		case gxui.PathMoveTo:
+			fmt.Fprintf(d, "M%s", point(s.Points[0]))
+		case gxui.PathLineTo:
+			fmt.Fprintf(d, "L%s", point(s.Points[0]))
+		case gxui.PathQuadTo:
+			fmt.Fprintf(d, "Q%s %s", point(s.Points[0]), point(s.Points[1]))
+		case gxui.PathCubicTo:
+			fmt.Fprintf(d, "C%s %s %s", point(s.Points[0]), point(s.Points[1]), point(s.Points[2]))
+		case gxui.PathClose:
+			d.WriteString("Z")
+		}
+	}
+	return d.String()
+}
+
+func rectAttrs(r math.Rect) string {
+	return fmt.Sprintf(`x="%d" y="%d" width="%d" height="%d"`, r.Min.X, r.Min.Y, r.Width(), r.Height())
+}
+
+// paint returns the fill, stroke or stop-color attributes for color.
+func paint(attr string, color gxui.Color) string {
+	c := color.Saturate()
+	result := fmt.Sprintf(`%s="#%02x%02x%02x"`, attr, channel(c.R), channel(c.G), channel(c.B))
+	if c.A < 1 {
+		result += fmt.Sprintf(` %s-opacity="%s"`, strings.TrimSuffix(attr, "-color"), number(c.A))
+	}
+	return result
+}
+
+func channel(v float32) int {
+	return int(v*255 + 0.5)
+}
+
+// number formats v with at most 3 decimals, which hides float32 noise.
+func number(v float32) string {
+	rounded := m.Round(float64(v)*1000) / 1000
+	if rounded == 0 {
+		rounded = 0 // Drop the sign of negative zero.
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func escape(s string) string {
+	b := &strings.Builder{}
+	_ = xml.EscapeText(b, []byte(s))
+	return b.String()
+}
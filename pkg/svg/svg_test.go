@@ -0,0 +1,383 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"image"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/drivers/soft"
+	gxfont "github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_golden"
+)
+
+// elements returns the number of each element in the SVG document.
+func elements(t *testing.T, doc []byte) map[string]int {
+	result := make(map[string]int)
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return result
+		}
+		if err != nil {
+			t.Fatalf("Invalid SVG: %v\n%s", err, doc)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			result[start.Name.Local]++
+		}
+	}
+}
+
+func TestEncodeWindow(t *testing.T) {
+	inner := soft.NewDriver()
+	defer inner.Terminate()
+	driver := gxui.CreateRecordingDriver(inner, nil)
+
+	var canvas gxui.Canvas
+	driver.CallSync(func() {
+		styles := test_golden.DarkTheme(driver)
+		window := gxui.CreateWindow(driver, styles, 200, 100, "svg")
+		label := gxui.CreateLabel(driver, styles)
+		label.SetText("a < b")
+		button := gxui.CreateButton(driver, styles)
+		button.SetText("OK")
+		layout := gxui.CreateLinearLayout(driver, styles)
+		layout.AddChild(label)
+		layout.AddChild(button)
+		window.AddChild(layout)
+		window.LayoutChildren()
+		canvas = window.Draw()
+	})
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, canvas); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	counts := elements(t, buffer.Bytes())
+
+	for _, name := range []string{"svg", "text", "rect", "use", "g", "path"} {
+		if counts[name] == 0 {
+			t.Errorf("Expected at least one <%s> element:\n%s", name, doc)
+		}
+	}
+	for _, want := range []string{`width="200" height="100"`, `font-family="Roboto"`, `font-size="14"`, `>a &lt; b</text>`} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+// boldItalic returns a copy of the font data whose OS/2 table describes a bold
+// italic face.
+func boldItalic(data []byte) []byte {
+	result := append([]byte{}, data...)
+	for i := 0; i < int(binary.BigEndian.Uint16(result[4:])); i++ {
+		record := result[12+16*i:]
+		if string(record[:4]) == "OS/2" {
+			os2 := result[binary.BigEndian.Uint32(record[8:]):]
+			binary.BigEndian.PutUint16(os2[4:], 700)
+			binary.BigEndian.PutUint16(os2[62:], 0x0001)
+		}
+	}
+	return result
+}
+
+func TestEncodeFontFaces(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	bold, err := driver.CreateFont(boldItalic(gxfont.Default), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono, err := driver.CreateFont(gxfont.Monospace, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	family, err := driver.CreateFontFamily(bold, mono)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.DrawRunes(mono, []rune("a"), []math.Point{{X: 0, Y: 10}}, gxui.White)
+	list.DrawRunes(family, []rune("b"), []math.Point{{X: 0, Y: 20}}, gxui.White)
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	for _, want := range []string{
+		`<text xml:space="preserve" font-family="&#39;Droid Sans Mono&#39;" font-size="12"`,
+		`<text xml:space="preserve" font-family="Roboto, &#39;Droid Sans Mono&#39;" font-weight="700" font-style="italic" font-size="12"`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+func TestEncodeShapes(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	texture := driver.CreateTexture(image.NewRGBA(image.Rect(0, 0, 4, 4)), 1)
+	texture.SetFlipY(true)
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.Push()
+	list.AddClip(math.CreateRect(0, 0, 40, 40))
+	list.DrawRoundedRect(math.CreateRect(0, 0, 20, 10), 5, 5, 0, 0, gxui.CreatePen(1, gxui.Red), gxui.CreateBrush(gxui.Color{R: 1, A: 0.5}))
+	list.DrawLines(gxui.Polygon{{Position: math.Point{X: 1, Y: 1}}, {Position: math.Point{X: 5, Y: 9}}}, gxui.DefaultPen)
+	list.DrawTexture(texture, math.CreateRect(10, 10, 14, 14))
+	list.Pop()
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	counts := elements(t, buffer.Bytes())
+
+	if counts["clipPath"] != 2 {
+		t.Errorf("Expected 2 clip paths (AddClip and the pen), got %d:\n%s", counts["clipPath"], doc)
+	}
+	for _, want := range []string{
+		`M0 5A5 5 0 0 1 5 0L15 0A5 5 0 0 1 20 5L20 10L0 10Z`,
+		`fill="#ff0000" fill-opacity="0.5"`,
+		`stroke-width="2"`,
+		`<polyline points="1,1 5,9"`,
+		`transform="matrix(1 0 0 -1 0 24)"`,
+		`xlink:href="data:image/png;base64,`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+func TestEncodeStyledPens(t *testing.T) {
+	dashed := gxui.CreateDashedPen(2, gxui.Red, 1, 3, 1.5)
+	dashed.Cap = gxui.RoundCap
+	beveled := gxui.CreatePen(1, gxui.Blue)
+	beveled.Join, beveled.MiterLimit = gxui.BevelJoin, 2
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.DrawLines(gxui.Polygon{{Position: math.Point{X: 1, Y: 1}}, {Position: math.Point{X: 5, Y: 9}}}, dashed)
+	list.DrawRoundedRect(math.CreateRect(10, 10, 20, 20), 0, 0, 0, 0, beveled, gxui.TransparentBrush)
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	elements(t, buffer.Bytes())
+
+	for _, want := range []string{
+		`stroke-width="2" stroke-linecap="round" stroke-dasharray="3 1.5" stroke-dashoffset="1"/>`,
+		`stroke-width="2" stroke-linejoin="bevel" stroke-miterlimit="2" clip-path=`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+func TestEncodePaths(t *testing.T) {
+	path := &gxui.Path{}
+	path.MoveTo(math.Vec2{X: 0, Y: 0})
+	path.LineTo(math.Vec2{X: 10, Y: 0})
+	path.QuadTo(math.Vec2{X: 20, Y: 5}, math.Vec2{X: 10, Y: 10})
+	path.CubicTo(math.Vec2{X: 7.5, Y: 12}, math.Vec2{X: 2.5, Y: 12}, math.Vec2{X: 0, Y: 10})
+	path.Close()
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.FillPath(path, gxui.EvenOdd, gxui.CreateBrush(gxui.Red))
+	list.StrokePath(path, gxui.CreateDashedPen(1, gxui.Blue, 0, 2))
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	elements(t, buffer.Bytes())
+
+	d := `d="M0 0L10 0Q20 5 10 10C7.5 12 2.5 12 0 10Z"`
+	for _, want := range []string{
+		d + ` fill="#ff0000" fill-rule="evenodd"/>`,
+		d + ` fill="none" stroke="#0000ff" stroke-width="1" stroke-dasharray="2"/>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+func TestEncodeTransforms(t *testing.T) {
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.Push()
+	list.Transform(math.CreateMat3Translate(10, 20).Mul(math.CreateMat3Scale(2, 3)))
+	list.DrawRect(math.CreateRect(0, 0, 5, 5), gxui.CreateBrush(gxui.Red))
+	list.Pop()
+	list.DrawRect(math.CreateRect(0, 0, 5, 5), gxui.CreateBrush(gxui.Blue))
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	elements(t, buffer.Bytes())
+
+	// The group of the transform is closed by the Pop.
+	want := `<g transform="matrix(2 0 0 3 10 20)">` + "\n" + `<rect x="0" y="0" width="5" height="5" fill="#ff0000"/>` + "\n</g>\n" +
+		`<rect x="0" y="0" width="5" height="5" fill="#0000ff"/>`
+	if !strings.Contains(doc, want) {
+		t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+	}
+}
+
+func TestEncodeLayers(t *testing.T) {
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.PushLayer(0.5, gxui.BlendScreen)
+	list.DrawRect(math.CreateRect(0, 0, 5, 5), gxui.CreateBrush(gxui.Red))
+	list.PopLayer()
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	elements(t, buffer.Bytes())
+
+	want := `<g opacity="0.5" style="mix-blend-mode:screen">` + "\n" + `<rect x="0" y="0" width="5" height="5" fill="#ff0000"/>` + "\n</g>\n"
+	if !strings.Contains(doc, want) {
+		t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+	}
+}
+
+func TestEncodeShadows(t *testing.T) {
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.BlurBackdrop(math.CreateRect(0, 0, 20, 20), 4)
+	list.DrawShadow(math.CreateRect(10, 10, 30, 20), 0, 0, 0, 0, gxui.Shadow{Offset: math.Point{Y: 2}, Blur: 4, Color: gxui.Black})
+	list.DrawShadow(math.CreateRect(10, 10, 30, 20), 2, 2, 2, 2, gxui.Shadow{Spread: -10, Color: gxui.Black})
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	counts := elements(t, buffer.Bytes())
+
+	// The shadow shrunk to nothing by its spread is not written.
+	if counts["filter"] != 1 || counts["path"] != 1 {
+		t.Errorf("Expected a single blurred shadow, got %d filters and %d paths:\n%s", counts["filter"], counts["path"], doc)
+	}
+	for _, want := range []string{
+		`filterUnits="userSpaceOnUse" x="4" y="6" width="32" height="22"><feGaussianBlur stdDeviation="2"/>`,
+		`<path d="M10 12L30 12L30 22L10 22L10 12Z" fill="#000000" filter="url(#shadow1)"/>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+func TestEncodeGradients(t *testing.T) {
+	linear := gxui.CreateLinearGradientBrush(0, gxui.GradientStop{Offset: 0, Color: gxui.Red}, gxui.GradientStop{Offset: 1, Color: gxui.Blue})
+	radial := gxui.CreateRadialGradientBrush(math.Vec2{X: 0.5, Y: 0.5}, 0.5, gxui.GradientStop{Offset: 0, Color: gxui.White}, gxui.GradientStop{Offset: 1, Color: gxui.Transparent})
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.DrawRect(math.CreateRect(10, 0, 30, 10), linear)
+	list.DrawRect(math.CreateRect(10, 20, 30, 30), linear)
+	list.DrawRoundedRect(math.CreateRect(0, 0, 40, 40), 5, 5, 5, 5, gxui.TransparentPen, radial)
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	counts := elements(t, buffer.Bytes())
+
+	// One gradient holds the stops, and one places them for each shape.
+	if counts["linearGradient"] != 3 || counts["radialGradient"] != 2 {
+		t.Errorf("Expected 3 linear and 2 radial gradients, got %d and %d:\n%s", counts["linearGradient"], counts["radialGradient"], doc)
+	}
+	for _, want := range []string{
+		`<stop offset="1" stop-color="#0000ff"/>`,
+		`<stop offset="1" stop-color="#000000" stop-opacity="0"/>`,
+		`gradientTransform="matrix(20 0 0 20 10 5)"`,
+		`gradientTransform="matrix(20 0 0 20 10 25)"`,
+		`gradientTransform="matrix(20 0 0 20 20 20)"`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+func TestEncodePatterns(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	texture := driver.CreateTexture(image.NewRGBA(image.Rect(0, 0, 4, 2)), 1)
+	repeat := gxui.CreatePatternBrush(texture, gxui.WrapRepeat, math.Mat3Ident)
+	mirror := gxui.CreatePatternBrush(texture, gxui.WrapMirror, math.CreateMat3Scale(2, 2))
+
+	list := gxui.CreateDisplayList(math.Size{Width: 50, Height: 50})
+	list.DrawRect(math.CreateRect(10, 0, 30, 10), repeat)
+	list.DrawRect(math.CreateRect(10, 20, 30, 30), repeat)
+	list.DrawRoundedRect(math.CreateRect(0, 30, 20, 50), 5, 5, 5, 5, gxui.TransparentPen, mirror)
+	list.DrawRoundedRect(math.CreateRect(30, 30, 50, 50), 5, 5, 5, 5, gxui.TransparentPen, gxui.CreateImageBrush(texture, math.Size{Width: 20, Height: 20}))
+	list.Complete()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, list); err != nil {
+		t.Fatal(err)
+	}
+	doc := buffer.String()
+	counts := elements(t, buffer.Bytes())
+
+	// The texture is embedded once, and used by every tile.
+	if counts["image"] != 1 || counts["use"] != 6 {
+		t.Errorf("Expected 1 image used 6 times, got %d and %d:\n%s", counts["image"], counts["use"], doc)
+	}
+	for _, want := range []string{
+		`patternUnits="userSpaceOnUse" width="4" height="2">`,
+		`patternTransform="matrix(1 0 0 1 10 20)"`,
+		`patternUnits="userSpaceOnUse" width="8" height="4">`,
+		`transform="matrix(-1 0 0 1 8 0)"`,
+		`patternTransform="matrix(2 0 0 2 0 30)"`,
+		`patternTransform="matrix(5 0 0 10 30 30)"`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected the SVG to contain %s:\n%s", want, doc)
+		}
+	}
+}
+
+func TestEncodeRequiresDisplayList(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	canvas := driver.CreateCanvas(math.Size{Width: 1, Height: 1})
+	canvas.Complete()
+	if err := Encode(io.Discard, canvas); err != ErrNotDisplayList {
+		t.Errorf("Expected ErrNotDisplayList, got %v", err)
+	}
+}
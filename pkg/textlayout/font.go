@@ -0,0 +1,473 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package textlayout measures and lays out the text of the fonts of the
+// drivers. The runes are shaped by bidirectional run, with the fallbacks of
+// the fonts for the runes they hold no glyph for, and placed on their lines.
+// The drivers only rasterize and draw the glyphs laid out.
+package textlayout
+
+import (
+	"unicode"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/bidi"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/pkg/shaping"
+	"github.com/chewxy/math32"
+	"golang.org/x/image/math/fixed"
+)
+
+// shapes holds the runs recently shaped by the fonts, which are shaped again
+// each time they are measured, laid out and drawn.
+var shapes = shaping.NewCache(1024)
+
+// Face is implemented by the fonts of the drivers, which lay out their text
+// with a Font. The runs of text blocks are laid out with the Font of their
+// font.
+type Face interface {
+	TextLayout() *Font
+}
+
+// Font lays out text with the glyphs of a font at a size. Font implements the
+// measuring and layout methods of gxui.Font, for the fonts of the drivers to
+// embed.
+type Font struct {
+	data             []byte
+	shaper           *shaping.Font
+	glyphAdvanceDips map[shaping.GlyphIndex]int
+	glyphMaxSizeDips math.Size
+	size             int
+	ascentDips       int
+	scale            fixed.Int26_6
+	// fallbacks are the fonts drawing the runes missing from the font, in the
+	// order they are tried.
+	fallbacks []*Font
+	// sizes holds the copies of the font resized to other sizes.
+	sizes map[int]*Font
+}
+
+func point26_6toPoint(point fixed.Point26_6) math.Point {
+	return math.Point{X: int(point.X) >> 6, Y: int(point.Y) >> 6}
+}
+
+func rectangle26_6toRect(point fixed.Rectangle26_6) math.Rect {
+	return math.Rect{Min: point26_6toPoint(point.Min), Max: point26_6toPoint(point.Max)}
+}
+
+// New loads the font at index of the font data, which is only a font
+// collection's when index is not 0.
+func New(data []byte, index, size int) (*Font, error) {
+	shaper, err := shaping.ParseIndex(data, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return newShapedFont(shaper, size), nil
+}
+
+// newShapedFont returns the font laying out the glyphs of the shaper at size.
+func newShapedFont(shaper *shaping.Font, size int) *Font {
+	scale := fixed.Int26_6(size << 6)
+	bounds := rectangle26_6toRect(shaper.Bounds(scale))
+	ascentDips := bounds.Max.Y
+
+	return &Font{
+		data:             shaper.Data(),
+		size:             size,
+		scale:            scale,
+		glyphMaxSizeDips: bounds.Size(),
+		ascentDips:       ascentDips,
+		shaper:           shaper,
+		glyphAdvanceDips: make(map[shaping.GlyphIndex]int),
+	}
+}
+
+// Family returns a copy of f drawing the runes it holds no glyph for with the
+// first of the fallbacks holding them, at the size of f.
+func (f *Font) Family(fallbacks []*Font) *Font {
+	result := *f
+	result.sizes = nil
+	result.fallbacks = make([]*Font, 0, len(fallbacks))
+	for _, fallback := range fallbacks {
+		result.fallbacks = append(result.fallbacks, fallback.Resized(f.size))
+	}
+	return &result
+}
+
+// Resized returns the font at size, with its fallbacks at size too. The fonts
+// resized are kept by size.
+func (f *Font) Resized(size int) *Font {
+	if size == f.size {
+		return f
+	}
+	if result, found := f.sizes[size]; found {
+		return result
+	}
+
+	result := newShapedFont(f.shaper, size)
+	for _, fallback := range f.fallbacks {
+		result.fallbacks = append(result.fallbacks, fallback.Resized(size))
+	}
+	if f.sizes == nil {
+		f.sizes = make(map[int]*Font)
+	}
+	f.sizes[size] = result
+	return result
+}
+
+// TextLayout returns f, so that the fonts of the drivers embedding a Font are
+// Faces.
+func (f *Font) TextLayout() *Font {
+	return f
+}
+
+// Shaper returns the font the glyphs are shaped and rasterized from.
+func (f *Font) Shaper() *shaping.Font {
+	return f.shaper
+}
+
+// Faces returns f and its fallbacks, the fonts drawing the glyphs of f.
+func (f *Font) Faces() []*Font {
+	return append([]*Font{f}, f.fallbacks...)
+}
+
+func (f *Font) advanceDips(index shaping.GlyphIndex) int {
+	if g, found := f.glyphAdvanceDips[index]; found {
+		return g
+	}
+
+	advanceWidth, err := f.shaper.Advance(index, f.scale)
+	if err != nil {
+		panic(err)
+	}
+
+	advance := int((advanceWidth + 0x3f) >> 6)
+	f.glyphAdvanceDips[index] = advance
+	return advance
+}
+
+// unitsToDips converts a distance in font units to DIPs.
+func (f *Font) unitsToDips(units int) int {
+	return int(math32.Round(float32(units*f.size) / float32(f.shaper.UnitsPerEm())))
+}
+
+func (f *Font) align(rect math.Rect, size math.Size, ascent int, horizontalAlignment gxui.HAlign, verticalAlignment gxui.VAlign) math.Point {
+	var origin math.Point
+
+	switch horizontalAlignment {
+	case gxui.AlignLeft:
+		origin.X = rect.Min.X
+	case gxui.AlignCenter:
+		origin.X = rect.Middle().X - (size.Width / 2)
+	case gxui.AlignRight:
+		origin.X = rect.Max.X - size.Width
+	}
+
+	switch verticalAlignment {
+	case gxui.AlignTop:
+		origin.Y = rect.Min.Y + ascent
+	case gxui.AlignMiddle:
+		origin.Y = rect.Middle().Y - (size.Height / 2) + ascent
+	case gxui.AlignBottom:
+		origin.Y = rect.Max.Y - size.Height + ascent
+	}
+
+	return origin
+}
+
+// gxui.Font compliance
+func (f *Font) Data() []byte {
+	return f.data
+}
+
+func (f *Font) Name() string {
+	return f.shaper.Name()
+}
+
+func (f *Font) Size() int {
+	return f.size
+}
+
+func (f *Font) Measure(textBlock *gxui.TextBlock) math.Size {
+	_, size := f.layout(textBlock.Runes, textBlock.Runs)
+	return size.Max(math.Size{Height: f.glyphMaxSizeDips.Height})
+}
+
+func (f *Font) Layout(textBlock *gxui.TextBlock) []math.Point {
+	offsets, sizeDips := f.layout(textBlock.Runes, textBlock.Runs)
+	origin := f.align(textBlock.AlignRect, sizeDips, f.ascentDips, textBlock.H, textBlock.V)
+	for i, p := range offsets {
+		offsets[i] = p.Add(origin)
+	}
+
+	return offsets
+}
+
+// layout returns the offsets of the runes from the origin of the first line,
+// and the size of the lines. The runes of a ligature share its advance, and
+// marks are placed on their base glyph. The runes of the runs are laid out with
+// their fonts, and raised by their baseline offsets.
+func (f *Font) layout(runes []rune, runs []gxui.TextRun) ([]math.Point, math.Size) {
+	fonts, raises := f.runStyles(len(runes), runs)
+	lines := f.lineOrigins(runes, fonts, raises)
+	raise := func(i int) int {
+		if raises == nil {
+			return 0
+		}
+		return raises[i]
+	}
+
+	sizeDips := math.Size{}
+	offsets := make([]math.Point, len(runes))
+	glyphs := f.shape(runes, fonts)
+	origins := make([]math.Point, len(glyphs))
+	offset := math.Point{Y: lines[0].y}
+	line := 0
+	for k, glyph := range glyphs {
+		if glyph.Line != line {
+			line = glyph.Line
+			offset = math.Point{Y: lines[line].y}
+		}
+		if glyph.Attached {
+			continue
+		}
+
+		origins[k] = offset.Add(glyph.placement()).Sub(math.Point{Y: raise(glyph.Cluster)})
+		advance := glyph.Font.advanceDips(glyph.Index) + glyph.Font.unitsToDips(glyph.Advance)
+		for i := 0; i < glyph.Runes; i++ {
+			part := i
+			if glyph.RightToLeft {
+				part = glyph.Runes - 1 - i
+			}
+			offsets[glyph.Cluster+i] = origins[k].Add(math.Point{X: advance * part / glyph.Runes})
+		}
+		offset.X += advance
+		sizeDips = sizeDips.Max(math.Size{Width: offset.X, Height: offset.Y + f.ascentDips + lines[line].descent})
+	}
+
+	// Marks are placed once their base glyph is, which follows them in right
+	// to left runs.
+	for k, glyph := range glyphs {
+		if glyph.Attached {
+			origins[k] = origins[glyph.Base].Add(glyph.placement())
+			offsets[glyph.Cluster] = origins[k]
+		}
+	}
+	return offsets, sizeDips
+}
+
+// runStyles returns the font and baseline offset of each of the count runes
+// laid out in the runs, or nil when there are no runs. The runes following the
+// runs are laid out with f.
+func (f *Font) runStyles(count int, runs []gxui.TextRun) ([]*Font, []int) {
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	fonts, raises := make([]*Font, count), make([]int, count)
+	i := 0
+	for _, run := range runs {
+		face := f
+		if run.Font != nil {
+			face = run.Font.(Face).TextLayout()
+		}
+		for end := min(i+run.Runes, count); i < end; i++ {
+			fonts[i], raises[i] = face, run.BaselineOffset
+		}
+	}
+	for ; i < count; i++ {
+		fonts[i] = f
+	}
+	return fonts, raises
+}
+
+// lineOrigin is the origin of a line of text, from the origin of the first
+// line, and its descent.
+type lineOrigin struct {
+	y       int
+	descent int
+}
+
+// lineOrigins returns the origins of the lines of the runes, which are as tall
+// as the font, or as the fonts of their runes once raised when they are laid
+// out in runs. The fallbacks of the fonts keep the lines of their font.
+func (f *Font) lineOrigins(runes []rune, fonts []*Font, raises []int) []lineOrigin {
+	type extent struct{ ascent, descent int }
+	base := extent{f.ascentDips, f.glyphMaxSizeDips.Height - f.ascentDips}
+	extents := []extent{base}
+	for i, r := range runes {
+		if r == '\n' {
+			extents = append(extents, base)
+			continue
+		}
+		if fonts == nil {
+			continue
+		}
+		e := &extents[len(extents)-1]
+		face := fonts[i]
+		e.ascent = max(e.ascent, face.ascentDips+raises[i])
+		e.descent = max(e.descent, face.glyphMaxSizeDips.Height-face.ascentDips-raises[i])
+	}
+
+	lines := make([]lineOrigin, len(extents))
+	baseline := 0
+	for i, e := range extents {
+		if i > 0 {
+			baseline += extents[i-1].descent + e.ascent
+		} else {
+			baseline = e.ascent
+		}
+		lines[i] = lineOrigin{y: baseline - f.ascentDips, descent: e.descent}
+	}
+	return lines
+}
+
+// Glyph is a glyph of a line of text, laid out in display order.
+type Glyph struct {
+	shaping.Glyph
+	// Font is the font drawing the glyph, Line is the index of the line of the
+	// glyph, and RightToLeft is true for the glyphs of right to left runs.
+	Font        *Font
+	Line        int
+	RightToLeft bool
+}
+
+// placement returns the distance of the glyph from its position on the
+// baseline, or from its base glyph.
+func (g Glyph) placement() math.Point {
+	return math.Point{X: g.Font.unitsToDips(g.X), Y: -g.Font.unitsToDips(g.Y)}
+}
+
+// Origin returns the offset the glyph is drawn at, the leftmost offset of the
+// runes it stands for.
+func (g Glyph) Origin(offsets []math.Point) math.Point {
+	result := offsets[g.Cluster]
+	for _, offset := range offsets[g.Cluster+1 : g.Cluster+g.Runes] {
+		if offset.X < result.X {
+			result = offset
+		}
+	}
+	return result
+}
+
+// Glyphs returns the glyphs drawing the runes, from left to right on each of
+// their lines. The clusters of the glyphs index the runes, which are drawn at
+// the offsets returned by Layout.
+func (f *Font) Glyphs(runes []rune) []Glyph {
+	return f.shape(runes, nil)
+}
+
+// shape shapes each line of the runes by bidirectional run, returning the
+// glyphs of the lines from left to right. The clusters of the glyphs index
+// the runes, and the bases of the marks index the glyphs returned. The runes
+// of right to left runs are mirrored, and the runes missing from the font are
+// shaped with its fallbacks. The runes are shaped with their fonts when fonts is
+// not nil.
+func (f *Font) shape(runes []rune, fonts []*Font) []Glyph {
+	var result []Glyph
+	line := 0
+	for start := 0; start <= len(runes); {
+		end := start
+		for end < len(runes) && runes[end] != '\n' {
+			end++
+		}
+
+		text := runes[start:end]
+		for _, run := range bidi.NewLine(text, bidi.Auto).Runs() {
+			runText := text[run.Start:run.End]
+			if run.RightToLeft() {
+				runText = make([]rune, run.End-run.Start)
+				for i, r := range text[run.Start:run.End] {
+					runText[i] = bidi.Mirror(r)
+				}
+			}
+
+			var runFonts []*Font
+			if fonts != nil {
+				runFonts = fonts[start+run.Start : start+run.End]
+			}
+			glyphs := f.shapeRun(runText, runFonts)
+			first := len(result)
+			for k := range glyphs {
+				glyph := glyphs[k]
+				if run.RightToLeft() {
+					glyph, glyph.RightToLeft = glyphs[len(glyphs)-1-k], true
+					glyph.Base = first + len(glyphs) - 1 - glyph.Base
+				} else {
+					glyph.Base += first
+				}
+				glyph.Line = line
+				glyph.Cluster += start + run.Start
+				result = append(result, glyph)
+			}
+		}
+		start = end + 1
+		line++
+	}
+	return result
+}
+
+// shapeRun shapes the runes of a bidirectional run in logical order, each
+// sequence of runes with the font holding their glyphs: f, or the font of each
+// rune when fonts is not nil, or one of their fallbacks.
+func (f *Font) shapeRun(runes []rune, fonts []*Font) []Glyph {
+	style := func(i int) *Font {
+		if fonts == nil {
+			return f
+		}
+		return fonts[i]
+	}
+
+	var result []Glyph
+	for start := 0; start < len(runes); {
+		primary := style(start)
+		face := primary.fallback(runes[start], nil)
+		end := start + 1
+		for end < len(runes) && style(end) == primary && primary.fallback(runes[end], face) == face {
+			end++
+		}
+
+		first := len(result)
+		for _, glyph := range shapes.Shape(face.shaper, runes[start:end]) {
+			glyph.Cluster += start
+			glyph.Base += first
+			result = append(result, Glyph{Glyph: glyph, Font: face})
+		}
+		start = end
+	}
+	return result
+}
+
+// fallback returns the font drawing r, the first of f and its fallbacks
+// holding a glyph for it, or f when none does. Marks and format characters
+// stay with the current font when it holds them, so that they are shaped with
+// the rune they apply to.
+func (f *Font) fallback(r rune, current *Font) *Font {
+	if current != nil && unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) && current.shaper.Index(r) != 0 {
+		return current
+	}
+	if f.shaper.Index(r) != 0 {
+		return f
+	}
+	for _, fallback := range f.fallbacks {
+		if fallback.shaper.Index(r) != 0 {
+			return fallback
+		}
+	}
+	return f
+}
+
+func (f *Font) LoadGlyphs(first, last rune) {
+	if first > last {
+		first, last = last, first
+	}
+	for r := first; r < last; r++ {
+		face := f.fallback(r, nil)
+		face.advanceDips(face.shaper.Index(r))
+	}
+}
+
+func (f *Font) GlyphMaxSize() math.Size {
+	return f.glyphMaxSizeDips
+}
@@ -0,0 +1,56 @@
+package textlayout
+
+import (
+	"testing"
+
+	gxfont "github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/test_helper"
+)
+
+func newTestFont(t *testing.T, data []byte, size int) *Font {
+	f, err := New(data, 0, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestFamilyGlyphs(t *testing.T) {
+	monospace := newTestFont(t, gxfont.Monospace, 32)
+	roboto := newTestFont(t, gxfont.Default, 12)
+	family := monospace.Family([]*Font{roboto})
+
+	// The fallback is used at the size of the family.
+	faces := family.Faces()
+	test_helper.AssertEquals(t, 2, len(faces))
+	test_helper.AssertEquals(t, 32, faces[1].Size())
+	if faces[1].Shaper() != roboto.Shaper() {
+		t.Errorf("Expected the fallback to shape with the font it was given")
+	}
+
+	// The rupee sign is missing from the monospace font.
+	glyphs := family.Glyphs([]rune("a₹b"))
+	test_helper.AssertEquals(t, 3, len(glyphs))
+	for i, face := range []*Font{family, faces[1], family} {
+		if glyphs[i].Font != face {
+			t.Errorf("Unexpected font of glyph %d", i)
+		}
+	}
+}
+
+func TestResized(t *testing.T) {
+	monospace := newTestFont(t, gxfont.Monospace, 32)
+	family := monospace.Family([]*Font{newTestFont(t, gxfont.Default, 32)})
+
+	if family.Resized(32) != family {
+		t.Errorf("Expected the family at its own size")
+	}
+	resized := family.Resized(16)
+	if family.Resized(16) != resized {
+		t.Errorf("Expected the resized family to be kept")
+	}
+	test_helper.AssertEquals(t, 16, resized.Size())
+	for _, face := range resized.Faces() {
+		test_helper.AssertEquals(t, 16, face.Size())
+	}
+}
@@ -6,6 +6,7 @@ package gxui
 
 import (
 	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
 )
 
 type PolygonVertex struct {
@@ -14,3 +15,74 @@ type PolygonVertex struct {
 }
 
 type Polygon []PolygonVertex
+
+// Bounds returns the smallest rectangle containing all the vertices.
+func (p Polygon) Bounds() math.Rect {
+	if len(p) == 0 {
+		return math.Rect{}
+	}
+	result := math.Rect{Min: p[0].Position, Max: p[0].Position}
+	for _, v := range p[1:] {
+		result = math.Rect{Min: result.Min.Min(v.Position), Max: result.Max.Max(v.Position)}
+	}
+	return result
+}
+
+// PolygonCorner is the outline of a single vertex of a closed Polygon.
+// A rounded vertex is replaced by a circular arc of Radius going from Start to
+// End. A sharp vertex has Start and End at the vertex position and no Radius.
+type PolygonCorner struct {
+	Vertex     math.Vec2
+	Start, End math.Vec2
+	Radius     float32
+	// Clockwise is true if the outline turns clockwise (on screen) at this corner.
+	Clockwise bool
+}
+
+// Corners returns the outline of the closed polygon, one corner per vertex,
+// following the same rounding rules as the drivers: each radius is shrunk so
+// that an arc never uses more than half of its neighbouring edges.
+// Consecutive duplicate vertices are ignored.
+func (p Polygon) Corners() []PolygonCorner {
+	pruned := make([]PolygonVertex, 0, len(p))
+	for _, v := range p {
+		if len(pruned) == 0 || pruned[len(pruned)-1].Position != v.Position {
+			pruned = append(pruned, v)
+		}
+	}
+	if len(pruned) > 1 && pruned[0].Position == pruned[len(pruned)-1].Position {
+		pruned = pruned[:len(pruned)-1]
+	}
+
+	result := make([]PolygonCorner, len(pruned))
+	for i, cnt := 0, len(pruned); i < cnt; i++ {
+		a := pruned[i].Position.Vec2()
+		b := pruned[(i+cnt-1)%cnt].Position.Vec2()
+		c := pruned[(i+1)%cnt].Position.Vec2()
+		result[i] = PolygonCorner{Vertex: a, Start: a, End: a}
+		if cnt < 3 {
+			continue
+		}
+
+		ba, ca := a.Sub(b), a.Sub(c)
+		baLen, caLen := ba.Len(), ca.Len()
+		baDir, caDir := ba.DivS(baLen), ca.DivS(caLen)
+		result[i].Clockwise = baDir.Cross(caDir.MulS(-1)) > 0
+
+		r := pruned[i].RoundedRadius
+		dp := baDir.Dot(caDir)
+		if r <= 0 || dp < -0.99999 {
+			continue
+		}
+
+		// See segment() in the drivers for the geometry.
+		α := math32.Acos(dp) / 2
+		sinα, cosα := math32.Sincos(α)
+		d := min(r/sinα, min(baLen, caLen)/(2*cosα))
+		t := d * cosα
+		result[i].Start = a.Sub(baDir.MulS(t))
+		result[i].End = a.Sub(caDir.MulS(t))
+		result[i].Radius = d * sinα
+	}
+	return result
+}
@@ -8,21 +8,49 @@ import (
 	"github.com/badu/gxui"
 	"github.com/badu/gxui/drivers/purego"
 	"github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
 )
 
 var DefaultScaleFactor float32
 var FlagTheme string
 var FontSize int
+var FontFamily string
+var MonospaceFontFamily string
 
 func init() {
 	flagTheme := flag.String("theme", "dark", "Theme to use {dark|light}.")
 	fontSize := flag.String("fontSize", "24", "Adjust the font size")
+	fontFamily := flag.String("font", "", "Font family installed on the system to use instead of the default font.")
+	monospaceFontFamily := flag.String("monospaceFont", "", "Font family installed on the system to use for code.")
 	defaultScaleFactor := flag.Float64("scaling", 1.0, "Adjusts the scaling of UI rendering")
 	flag.Parse()
 
 	DefaultScaleFactor = float32(*defaultScaleFactor)
 	FlagTheme = *flagTheme
 	FontSize, _ = strconv.Atoi(*fontSize)
+	FontFamily = *fontFamily
+	MonospaceFontFamily = *monospaceFontFamily
+}
+
+var registry *font.Registry
+
+// loadFont returns the font of the family installed on the system, or the font
+// loaded from data when the family is empty or not found.
+func loadFont(driver gxui.Driver, family string, data []byte, fontSize int) (gxui.Font, error) {
+	if family != "" {
+		if registry == nil {
+			registry = font.NewRegistry(driver)
+			if err := registry.ScanSystem(); err != nil {
+				fmt.Printf("Warning: Failed to scan the system fonts - %v\n", err)
+			}
+		}
+		result, err := registry.LookupFont(family, font.Regular, false, fontSize)
+		if err == nil {
+			return result, nil
+		}
+		fmt.Printf("Warning: Failed to load font %q - %v\n", family, err)
+	}
+	return driver.CreateFont(data, fontSize)
 }
 
 // CreateTheme creates and returns the theme specified on the command line.
@@ -35,14 +63,14 @@ func CreateTheme(driver gxui.Driver) *gxui.StyleDefs {
 }
 
 func CreateLightTheme(driver gxui.Driver, fontSize int) *gxui.StyleDefs {
-	defaultFont, err := driver.CreateFont(font.Default, fontSize)
+	defaultFont, err := loadFont(driver, FontFamily, font.Default, fontSize)
 	if err == nil {
 		defaultFont.LoadGlyphs(32, 126)
 	} else {
 		fmt.Printf("Warning: Failed to load default font - %v\n", err)
 	}
 
-	defaultMonospaceFont, err := driver.CreateFont(font.Monospace, fontSize)
+	defaultMonospaceFont, err := loadFont(driver, MonospaceFontFamily, font.Monospace, fontSize)
 	if err == nil {
 		defaultFont.LoadGlyphs(32, 126)
 	} else {
@@ -100,20 +128,21 @@ func CreateLightTheme(driver gxui.Driver, fontSize int) *gxui.StyleDefs {
 		ScreenHeight: h,
 		FontSize:     fontSize,
 	}
+	styles.BubbleOverlayStyle.Shadow = gxui.Shadow{Offset: math.Point{Y: 2}, Blur: 8, Color: gxui.Color{A: 0.3}}
 	styles.LabelStyle.HAlign = gxui.AlignLeft
 	styles.LabelStyle.VAlign = gxui.AlignMiddle
 	return &styles
 }
 
 func CreateDarkTheme(driver gxui.Driver, fontSize int) *gxui.StyleDefs {
-	defaultFont, err := driver.CreateFont(font.Default, fontSize)
+	defaultFont, err := loadFont(driver, FontFamily, font.Default, fontSize)
 	if err == nil {
 		defaultFont.LoadGlyphs(32, 126)
 	} else {
 		fmt.Printf("Warning: Failed to load default font - %v\n", err)
 	}
 
-	defaultMonospaceFont, err := driver.CreateFont(font.Monospace, fontSize)
+	defaultMonospaceFont, err := loadFont(driver, MonospaceFontFamily, font.Monospace, fontSize)
 	if err == nil {
 		defaultFont.LoadGlyphs(32, 126)
 	} else {
@@ -172,6 +201,7 @@ func CreateDarkTheme(driver gxui.Driver, fontSize int) *gxui.StyleDefs {
 		FontSize:     fontSize,
 	}
 
+	styles.BubbleOverlayStyle.Shadow = gxui.Shadow{Offset: math.Point{Y: 2}, Blur: 8, Color: gxui.Color{A: 0.6}}
 	styles.LabelStyle.HAlign = gxui.AlignLeft
 	styles.LabelStyle.VAlign = gxui.AlignMiddle
 
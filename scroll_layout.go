@@ -51,8 +51,16 @@ func (l *ScrollLayoutImpl) LayoutChildren() {
 		sys = l.scrollBarY.Control.DesiredSize(math.ZeroSize, size)
 	}
 
-	l.scrollBarX.Layout(math.CreateRect(0, size.Height-sxs.Height, size.Width-sys.Width, size.Height).Canon().Offset(offset))
-	l.scrollBarY.Layout(math.CreateRect(size.Width-sys.Width, 0, size.Width, size.Height-sxs.Height).Canon().Offset(offset))
+	// The vertical scroll bar is on the left in right to left windows, and the
+	// child right of it.
+	if IsRightToLeft(l.parent) {
+		l.scrollBarX.Layout(math.CreateRect(sys.Width, size.Height-sxs.Height, size.Width, size.Height).Canon().Offset(offset))
+		l.scrollBarY.Layout(math.CreateRect(0, 0, sys.Width, size.Height-sxs.Height).Canon().Offset(offset))
+		offset.X += sys.Width
+	} else {
+		l.scrollBarX.Layout(math.CreateRect(0, size.Height-sxs.Height, size.Width-sys.Width, size.Height).Canon().Offset(offset))
+		l.scrollBarY.Layout(math.CreateRect(size.Width-sys.Width, 0, size.Width, size.Height-sxs.Height).Canon().Offset(offset))
+	}
 
 	l.innerSize = size.Contract(math.Spacing{Right: sys.Width, Bottom: sxs.Height})
 
@@ -0,0 +1,76 @@
+package gxui
+
+import (
+	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
+)
+
+// Shadow is the shadow cast by a rounded rectangle, like a CSS box-shadow.
+type Shadow struct {
+	// Offset moves the shadow away from the rectangle casting it, in DIPs.
+	Offset math.Point
+	// Blur is the radius of the Gaussian blur softening the edges of the
+	// shadow, in DIPs. The standard deviation of the blur is half the radius.
+	Blur float32
+	// Spread grows the shadow on every side before it is blurred, in DIPs, or
+	// shrinks it when negative.
+	Spread float32
+	Color  Color
+}
+
+// NoShadow is the zero Shadow, which draws nothing.
+var NoShadow Shadow
+
+// IsVisible returns true if the shadow draws anything.
+func (s Shadow) IsVisible() bool {
+	return s.Color.A > 0
+}
+
+// Bounds returns the rectangle covering the shadow cast by rect. The blur fades
+// out within three standard deviations of the edges.
+func (s Shadow) Bounds(rect math.Rect) math.Rect {
+	extent := int(math32.Ceil(s.Spread + BlurExtent(s.Blur)))
+	return rect.Offset(s.Offset).ExpandI(max(extent, 0))
+}
+
+// BlurExtent returns how far a Gaussian blur of radius spreads colors, in the
+// units of radius.
+func BlurExtent(radius float32) float32 {
+	return 1.5 * max(radius, 0)
+}
+
+// Path returns the outline of the shadow cast by rect with the corner radii
+// tl, tr, bl and br, before it is blurred: offset, and grown by the spread along
+// with its rounded corners. Path returns nil if a negative spread leaves
+// nothing of the shadow.
+func (s Shadow) Path(rect math.Rect, tl, tr, bl, br float32) *Path {
+	spread := math.Vec2{X: s.Spread, Y: s.Spread}
+	minP := rect.Min.Vec2().Add(s.Offset.Vec2()).Sub(spread)
+	maxP := rect.Max.Vec2().Add(s.Offset.Vec2()).Add(spread)
+	if maxP.X <= minP.X || maxP.Y <= minP.Y {
+		return nil
+	}
+
+	limit := math32.Min(maxP.X-minP.X, maxP.Y-minP.Y) / 2
+	corner := func(r float32) math.Vec2 {
+		if r <= 0 {
+			return math.Vec2{}
+		}
+		r = math.Clampf(r+s.Spread, 0, limit)
+		return math.Vec2{X: r, Y: r}
+	}
+	rtl, rtr, rbl, rbr := corner(tl), corner(tr), corner(bl), corner(br)
+
+	path := &Path{}
+	path.MoveTo(math.Vec2{X: minP.X + rtl.X, Y: minP.Y})
+	path.LineTo(math.Vec2{X: maxP.X - rtr.X, Y: minP.Y})
+	path.ArcTo(rtr, 0, false, true, math.Vec2{X: maxP.X, Y: minP.Y + rtr.Y})
+	path.LineTo(math.Vec2{X: maxP.X, Y: maxP.Y - rbr.Y})
+	path.ArcTo(rbr, 0, false, true, math.Vec2{X: maxP.X - rbr.X, Y: maxP.Y})
+	path.LineTo(math.Vec2{X: minP.X + rbl.X, Y: maxP.Y})
+	path.ArcTo(rbl, 0, false, true, math.Vec2{X: minP.X, Y: maxP.Y - rbl.Y})
+	path.LineTo(math.Vec2{X: minP.X, Y: minP.Y + rtl.Y})
+	path.ArcTo(rtl, 0, false, true, math.Vec2{X: minP.X + rtl.X, Y: minP.Y})
+	path.Close()
+	return path
+}
@@ -0,0 +1,27 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+func TestShadowBounds(t *testing.T) {
+	shadow := Shadow{Offset: math.Point{X: 1, Y: 2}, Blur: 2, Spread: 1.5, Color: Black}
+	// The blur extends 3 DIPs, and the spread 1.5 more.
+	test_helper.AssertEquals(t, math.CreateRect(-4, -3, 16, 13), shadow.Bounds(math.CreateRect(0, 0, 10, 6)))
+	test_helper.AssertEquals(t, false, NoShadow.IsVisible())
+}
+
+func TestShadowPath(t *testing.T) {
+	shadow := Shadow{Offset: math.Point{X: 1, Y: 2}, Spread: 2, Color: Black}
+	path := shadow.Path(math.CreateRect(0, 0, 10, 6), 0, 2, 8, 0)
+	test_helper.AssertEquals(t, math.CreateRect(-1, 0, 13, 10), path.Bounds())
+	// Square corners stay square, and rounded ones grow with the spread.
+	test_helper.AssertEquals(t, math.Vec2{X: -1, Y: 0}, path.Segments[0].Points[0])
+	test_helper.AssertEquals(t, math.Vec2{X: 9, Y: 0}, path.Segments[1].Points[0])
+
+	shadow.Spread = -4
+	test_helper.AssertEquals(t, (*Path)(nil), shadow.Path(math.CreateRect(0, 0, 10, 6), 0, 0, 0, 0))
+}
@@ -0,0 +1,75 @@
+package gxui
+
+import "time"
+
+// FrameStats are the statistics of a frame drawn by a viewport.
+type FrameStats struct {
+	// Layout is the time the window spent laying out its controls.
+	Layout time.Duration
+	// Paint is the time the window spent painting its controls into canvases.
+	Paint time.Duration
+	// Submit is the time the driver spent drawing the canvas, submitting its
+	// draw calls to the GPU.
+	Submit time.Duration
+	// DrawCalls is the number of draw calls of the frame.
+	DrawCalls int
+	// GlyphBatches is the number of batches the glyphs were drawn in.
+	GlyphBatches int
+	// Partial is true if the frame only redrew the damaged regions of the
+	// viewport. The GL drivers still present the whole window.
+	Partial bool
+}
+
+// Total returns the time spent on the frame.
+func (s FrameStats) Total() time.Duration {
+	return s.Layout + s.Paint + s.Submit
+}
+
+// ViewportStats are the statistics of the frames drawn by a viewport, and of
+// the resources they use.
+type ViewportStats struct {
+	// Frames are the statistics of the most recent frames, oldest first.
+	Frames []FrameStats
+	// FrameCount is the number of frames drawn by the viewport.
+	FrameCount int
+	// Textures is the number of textures of the viewport, including its render
+	// targets, and TextureBytes the memory they use.
+	Textures     int
+	TextureBytes int
+	// VertexStreams and IndexBuffers are the numbers of the buffers of the
+	// shapes drawn by the viewport.
+	VertexStreams int
+	IndexBuffers  int
+}
+
+// Average returns the average of the statistics of the recent frames.
+func (s ViewportStats) Average() FrameStats {
+	var result FrameStats
+	n := len(s.Frames)
+	if n == 0 {
+		return result
+	}
+	for _, frame := range s.Frames {
+		result.Layout += frame.Layout
+		result.Paint += frame.Paint
+		result.Submit += frame.Submit
+		result.DrawCalls += frame.DrawCalls
+		result.GlyphBatches += frame.GlyphBatches
+	}
+	result.Layout /= time.Duration(n)
+	result.Paint /= time.Duration(n)
+	result.Submit /= time.Duration(n)
+	result.DrawCalls /= n
+	result.GlyphBatches /= n
+	return result
+}
+
+// DriverStats are the statistics of the resources of a driver, over all its
+// viewports.
+type DriverStats struct {
+	Viewports     int
+	Textures      int
+	TextureBytes  int
+	VertexStreams int
+	IndexBuffers  int
+}
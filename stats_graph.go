@@ -0,0 +1,113 @@
+package gxui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/badu/gxui/pkg/math"
+)
+
+// The time a frame has to be drawn in at 60 frames per second.
+const frameBudget = time.Second / 60
+
+// StatsGraph is an overlay graphing the times of the recent frames of a
+// viewport, with the averages of its statistics.
+type StatsGraph struct {
+	ControlBase
+	BackgroundBorderPainter
+	parent      ControlBaseParent
+	styles      *StyleDefs
+	viewport    Viewport
+	stats       ViewportStats
+	ticker      *time.Ticker
+	done        chan struct{} // Closed on detach, to stop polling the statistics.
+	desiredSize math.Size
+}
+
+func (g *StatsGraph) Init(parent ControlBaseParent, driver Driver, styles *StyleDefs) {
+	g.parent = parent
+	g.styles = styles
+	g.ControlBase.Init(parent, driver)
+	g.BackgroundBorderPainter.Init(parent)
+	g.desiredSize = math.Size{Width: 200, Height: 80}
+}
+
+func (g *StatsGraph) DesiredSize(min, max math.Size) math.Size {
+	return g.desiredSize.Clamp(min, max)
+}
+
+func (g *StatsGraph) SetDesiredSize(size math.Size) {
+	g.desiredSize = size
+	g.ReLayout()
+}
+
+// Viewport returns the viewport whose statistics are graphed.
+func (g *StatsGraph) Viewport() Viewport {
+	return g.viewport
+}
+
+// SetViewport sets the viewport whose statistics are polled while the graph is
+// attached. A nil viewport stops the polling.
+func (g *StatsGraph) SetViewport(viewport Viewport) {
+	g.viewport = viewport
+}
+
+func (g *StatsGraph) Stats() ViewportStats {
+	return g.stats
+}
+
+func (g *StatsGraph) SetStats(stats ViewportStats) {
+	g.stats = stats
+	g.Redraw()
+}
+
+func (g *StatsGraph) poll() {
+	if g.Attached() && g.viewport != nil {
+		g.SetStats(g.viewport.Stats())
+	}
+}
+
+func (g *StatsGraph) Paint(canvas Canvas) {
+	rect := g.parent.Size().Rect()
+	g.PaintBackground(canvas, rect)
+
+	// The bars of the frames are stacked layout, paint then submit times, the
+	// height of the graph being twice the frame budget.
+	graph := rect.Contract(math.CreateSpacing(2))
+	scale := float32(graph.Height()) / float32(2*frameBudget)
+	height := func(d time.Duration) int {
+		return int(float32(d)*scale + 0.5)
+	}
+	colors := [3]Color{Blue70, Green70, Red70}
+	for i, frame := range g.stats.Frames {
+		x := graph.Max.X - (len(g.stats.Frames)-i)*2
+		if x < graph.Min.X {
+			continue
+		}
+		y := graph.Max.Y
+		for j, d := range [3]time.Duration{frame.Layout, frame.Paint, frame.Submit} {
+			top := max(y-height(d), graph.Min.Y)
+			if top < y {
+				canvas.DrawRect(math.CreateRect(x, top, x+2, y), CreateBrush(colors[j]))
+			}
+			y = top
+		}
+	}
+	budget := graph.Max.Y - height(frameBudget)
+	canvas.DrawLines(Polygon{
+		PolygonVertex{Position: math.Point{X: graph.Min.X, Y: budget}},
+		PolygonVertex{Position: math.Point{X: graph.Max.X, Y: budget}},
+	}, CreatePen(1, Gray60))
+
+	if font := g.styles.DefaultFont; font != nil {
+		average := g.stats.Average()
+		text := fmt.Sprintf("%.1fms per frame\n%d draw calls, %d glyph batches\n%d textures, %.1fMB",
+			float64(average.Total())/float64(time.Millisecond), average.DrawCalls, average.GlyphBatches,
+			g.stats.Textures, float64(g.stats.TextureBytes)/(1<<20))
+		runes := []rune(text)
+		offsets := font.Layout(&TextBlock{Runes: runes, AlignRect: graph, H: AlignLeft, V: AlignTop})
+		canvas.DrawRunes(font, runes, offsets, White)
+	}
+
+	g.PaintBorder(canvas, rect)
+}
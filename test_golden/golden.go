@@ -0,0 +1,190 @@
+// Package test_golden renders control trees offscreen with the soft driver
+// and compares the result against golden PNG images stored next to the tests.
+//
+// Goldens are (re)generated by running the tests with -update-goldens:
+//
+//	go test ./... -update-goldens
+package test_golden
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/drivers/soft"
+)
+
+var updateGoldens = flag.Bool("update-goldens", false, "Overwrite the golden images with the rendered ones.")
+
+// DefaultDir is the directory the goldens are read from, relative to the
+// package being tested.
+const DefaultDir = "testdata"
+
+// FailuresDir is the directory, relative to the goldens directory, where the
+// actual and diff images of failing comparisons are written.
+const FailuresDir = "failures"
+
+// Options describe the window a control tree is rendered in.
+type Options struct {
+	// Width and Height are the size of the window, and of the rendered image,
+	// in pixels. The window is Width/Scale by Height/Scale DIPs.
+	Width, Height int
+	// Scale is the DIPs to pixels scaling of the window. Defaults to 1.
+	Scale float32
+	// Theme creates the styles the controls are built with. Defaults to DarkTheme.
+	Theme func(driver gxui.Driver) *gxui.StyleDefs
+	// Tolerance is the maximum difference allowed per color channel, per pixel.
+	Tolerance uint8
+	// Dir is the directory holding the goldens. Defaults to DefaultDir.
+	Dir string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Scale == 0 {
+		o.Scale = 1
+	}
+	if o.Theme == nil {
+		o.Theme = DarkTheme
+	}
+	if o.Dir == "" {
+		o.Dir = DefaultDir
+	}
+	return o
+}
+
+// Builder adds the controls under test to window.
+// Builder is called on the application routine.
+type Builder func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl)
+
+// Render mounts the controls created by build in a window of the size, theme
+// and scale described by options, and returns a single rendered frame.
+func Render(options Options, build Builder) *image.RGBA {
+	options = options.withDefaults()
+
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	var window *gxui.WindowImpl
+	driver.CallSync(func() {
+		styles := options.Theme(driver)
+		window = gxui.CreateWindow(driver, styles, options.Width, options.Height, "golden")
+		window.SetScale(options.Scale)
+		build(driver, styles, window)
+	})
+
+	// Any layout or redraw requested while building has been queued on the
+	// application routine before this call, so this frame is the final one.
+	driver.CallSync(func() {
+		window.LayoutChildren()
+		window.Draw()
+	})
+
+	return window.Viewport().(*soft.ViewportImpl).Image()
+}
+
+// Compare returns the number of pixels of actual that differ from expected by
+// more than tolerance on any channel, along with an image highlighting them in
+// red over a dimmed copy of expected.
+func Compare(expected, actual image.Image, tolerance uint8) (int, *image.RGBA) {
+	bounds := expected.Bounds().Union(actual.Bounds())
+	diff := image.NewRGBA(bounds)
+	mismatches := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Point{X: x, Y: y}
+			if !p.In(expected.Bounds()) || !p.In(actual.Bounds()) {
+				mismatches++
+				diff.SetRGBA(x, y, color.RGBA{R: 0xff, A: 0xff})
+				continue
+			}
+
+			e := color.RGBAModel.Convert(expected.At(x, y)).(color.RGBA)
+			a := color.RGBAModel.Convert(actual.At(x, y)).(color.RGBA)
+			if channelDelta(e.R, a.R) > tolerance || channelDelta(e.G, a.G) > tolerance ||
+				channelDelta(e.B, a.B) > tolerance || channelDelta(e.A, a.A) > tolerance {
+				mismatches++
+				diff.SetRGBA(x, y, color.RGBA{R: 0xff, A: 0xff})
+				continue
+			}
+
+			diff.SetRGBA(x, y, color.RGBA{R: e.R / 4, G: e.G / 4, B: e.B / 4, A: 0xff})
+		}
+	}
+	return mismatches, diff
+}
+
+func channelDelta(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// AssertMatchesGolden renders the controls created by build and compares the
+// frame against the golden <options.Dir>/<name>.png. On mismatch the rendered
+// frame and a diff image are written to <options.Dir>/failures.
+// When the tests are run with -update-goldens the golden is overwritten instead.
+func AssertMatchesGolden(t *testing.T, name string, options Options, build Builder) {
+	t.Helper()
+	options = options.withDefaults()
+	actual := Render(options, build)
+	path := filepath.Join(options.Dir, name+".png")
+
+	if *updateGoldens {
+		if err := writePNG(path, actual); err != nil {
+			t.Fatalf("Failed to update golden %s: %v", path, err)
+		}
+		return
+	}
+
+	expected, err := readPNG(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden %s: %v (run with -update-goldens to create it)", path, err)
+	}
+
+	mismatches, diff := Compare(expected, actual, options.Tolerance)
+	if mismatches == 0 {
+		return
+	}
+
+	failures := filepath.Join(options.Dir, FailuresDir)
+	actualPath := filepath.Join(failures, name+".actual.png")
+	diffPath := filepath.Join(failures, name+".diff.png")
+	if err := writePNG(actualPath, actual); err != nil {
+		t.Errorf("Failed to write %s: %v", actualPath, err)
+	}
+	if err := writePNG(diffPath, diff); err != nil {
+		t.Errorf("Failed to write %s: %v", diffPath, err)
+	}
+	t.Errorf("%s: %d pixels differ from golden %s (tolerance %d).\nActual: %s\nDiff:   %s",
+		name, mismatches, path, options.Tolerance, actualPath, diffPath)
+}
+
+func readPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
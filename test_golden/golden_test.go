@@ -0,0 +1,289 @@
+package test_golden
+
+import (
+	"image"
+	"image/color"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/drivers/soft"
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+type treeNode struct {
+	name     string
+	children []*treeNode
+}
+
+func (n *treeNode) Count() int                     { return len(n.children) }
+func (n *treeNode) NodeAt(index int) gxui.TreeNode { return n.children[index] }
+func (n *treeNode) Item() gxui.AdapterItem         { return n.name }
+
+func (n *treeNode) ItemIndex(item gxui.AdapterItem) int {
+	for i, c := range n.children {
+		if c.name == item || c.ItemIndex(item) >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *treeNode) Create(driver gxui.Driver, styles *gxui.StyleDefs) gxui.Control {
+	label := gxui.CreateLabel(driver, styles)
+	label.SetText(n.name)
+	return label
+}
+
+type treeAdapter struct {
+	gxui.AdapterBase
+	treeNode
+}
+
+func (a *treeAdapter) Size(styles *gxui.StyleDefs) math.Size {
+	return math.Size{Width: 120, Height: styles.FontSize + 4}
+}
+
+func node(name string, children ...*treeNode) *treeNode {
+	return &treeNode{name: name, children: children}
+}
+
+func TestTree(t *testing.T) {
+	AssertMatchesGolden(t, "tree", Options{Width: 200, Height: 160},
+		func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+			adapter := &treeAdapter{}
+			adapter.children = []*treeNode{
+				node("Animals", node("Mammals", node("Cats"), node("Dogs")), node("Birds")),
+				node("Plants", node("Trees")),
+			}
+
+			tree := gxui.CreateTree(driver, styles)
+			tree.SetAdapter(adapter)
+			tree.Show("Cats")
+			tree.Select("Dogs")
+			window.AddChild(tree)
+		},
+	)
+}
+
+func TestPanelHolderTabs(t *testing.T) {
+	for _, theme := range []struct {
+		name  string
+		theme func(gxui.Driver) *gxui.StyleDefs
+	}{
+		{"panel_holder_dark", DarkTheme},
+		{"panel_holder_light", LightTheme},
+	} {
+		AssertMatchesGolden(t, theme.name, Options{Width: 320, Height: 100, Theme: theme.theme},
+			func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+				holder := gxui.CreatePanelHolder(driver, styles)
+				for _, name := range []string{"First", "Second", "Third"} {
+					label := gxui.CreateLabel(driver, styles)
+					label.SetText(name + " content")
+					holder.AddPanel(label, name)
+				}
+				holder.Select(1)
+				window.AddChild(holder)
+			},
+		)
+	}
+}
+
+func TestPanelHolderRightToLeft(t *testing.T) {
+	AssertMatchesGolden(t, "panel_holder_rtl", Options{Width: 320, Height: 100},
+		func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+			window.SetRightToLeft(true)
+			holder := gxui.CreatePanelHolder(driver, styles)
+			for _, name := range []string{"First", "Second", "Third"} {
+				label := gxui.CreateLabel(driver, styles)
+				label.SetText(name + " content")
+				holder.AddPanel(label, name)
+			}
+			holder.Select(1)
+			window.AddChild(holder)
+		},
+	)
+}
+
+func TestDropDownList(t *testing.T) {
+	AssertMatchesGolden(t, "drop_down_list", Options{Width: 200, Height: 160, Scale: 2},
+		func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+			adapter := gxui.CreateDefaultAdapter(80, styles.FontSize+4)
+			adapter.SetItems([]string{"one", "two", "three"})
+
+			overlay := gxui.CreateBubbleOverlay(driver, styles)
+
+			dropList := gxui.CreateDropDownList(driver, styles)
+			dropList.SetAdapter(adapter)
+			dropList.SetBubbleOverlay(overlay)
+			dropList.Select("two")
+
+			window.AddChild(dropList)
+			window.AddChild(overlay)
+		},
+	)
+}
+
+func TestCompareTolerance(t *testing.T) {
+	expected := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	actual := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	expected.SetRGBA(0, 0, color.RGBA{R: 100, A: 0xff})
+	actual.SetRGBA(0, 0, color.RGBA{R: 104, A: 0xff})
+
+	mismatches, _ := Compare(expected, actual, 4)
+	test_helper.AssertEquals(t, 0, mismatches)
+
+	mismatches, diff := Compare(expected, actual, 3)
+	test_helper.AssertEquals(t, 1, mismatches)
+	test_helper.AssertEquals(t, color.RGBA{R: 0xff, A: 0xff}, diff.RGBAAt(0, 0))
+	test_helper.AssertEquals(t, color.RGBA{A: 0xff}, diff.RGBAAt(1, 1))
+}
+
+func TestCompareSizeMismatch(t *testing.T) {
+	expected := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	actual := image.NewRGBA(image.Rect(0, 0, 3, 2))
+
+	mismatches, _ := Compare(expected, actual, 0)
+	test_helper.AssertEquals(t, 2, mismatches)
+}
+
+func TestAttributedLabel(t *testing.T) {
+	AssertMatchesGolden(t, "attributed_label", Options{Width: 320, Height: 100},
+		func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+			label := gxui.CreateLabel(driver, styles)
+			label.SetMultiline(true)
+			label.SetAttributedText(gxui.AttributedText{
+				{Text: "Call "},
+				{Text: "func", Font: styles.DefaultMonospaceFont, Color: gxui.Yellow, Background: gxui.Gray20},
+				{Text: " with a "},
+				{Text: "larger", Size: 24, Underline: true},
+				{Text: " font,\nE = mc"},
+				{Text: "2", Size: 8, BaselineOffset: 6},
+				{Text: " and H"},
+				{Text: "2", Size: 8, BaselineOffset: -3},
+				{Text: "O, "},
+				{Text: "not this", Color: gxui.Red, Strikethrough: true},
+			})
+			window.AddChild(label)
+		},
+	)
+}
+
+func TestLabelWrapAndTruncation(t *testing.T) {
+	AssertMatchesGolden(t, "label_wrap_truncation", Options{Width: 200, Height: 160},
+		func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+			layout := gxui.CreateLinearLayout(driver, styles)
+			wrapped := gxui.CreateLabel(driver, styles)
+			wrapped.SetWrap(gxui.WordWrap)
+			wrapped.SetAttributedText(gxui.AttributedText{
+				{Text: "Long lines are wrapped between words, even "},
+				{Text: "styled", Color: gxui.Yellow, Underline: true},
+				{Text: " ones."},
+			})
+			layout.AddChild(wrapped)
+			for _, truncation := range []gxui.TextTruncation{gxui.TruncateEnd, gxui.TruncateMiddle, gxui.TruncateStart} {
+				label := gxui.CreateLabel(driver, styles)
+				label.SetTruncation(truncation)
+				label.SetText("The start, the middle and the end of a line")
+				layout.AddChild(label)
+			}
+			window.AddChild(layout)
+		},
+	)
+}
+
+func TestTextBoxWrap(t *testing.T) {
+	AssertMatchesGolden(t, "textbox_wrap", Options{Width: 200, Height: 100},
+		func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+			textBox := gxui.CreateTextBox(driver, styles)
+			textBox.SetMultiline(true)
+			textBox.SetWrap(gxui.WordWrap)
+			textBox.SetDesiredWidth(180)
+			textBox.SetText("Soft wrapped lines follow the width of the box.\nShort line\nAnotherveryveryverylongwordbrokenanywhere")
+			window.AddChild(textBox)
+			gxui.SetFocus(textBox)
+			textBox.Select(gxui.TextSelectionList{gxui.CreateTextSelection(10, 30, false)})
+		},
+	)
+}
+
+func TestStatsGraph(t *testing.T) {
+	var frames []gxui.FrameStats
+	for i := 0; i < 40; i++ {
+		ms := time.Duration(i%10) * time.Millisecond
+		frames = append(frames, gxui.FrameStats{Layout: ms, Paint: 2 * ms, Submit: 4 * time.Millisecond, DrawCalls: 12, GlyphBatches: 3})
+	}
+	AssertMatchesGolden(t, "stats_graph", Options{Width: 220, Height: 100},
+		func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+			graph := gxui.CreateStatsGraph(driver, styles)
+			graph.SetStats(gxui.ViewportStats{Frames: frames, FrameCount: 40, Textures: 5, TextureBytes: 3 << 20})
+			window.AddChild(graph)
+		},
+	)
+}
+
+func TestStatsGraphDetach(t *testing.T) {
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	var window *gxui.WindowImpl
+	var graph *gxui.StatsGraph
+	driver.CallSync(func() {
+		styles := DarkTheme(driver)
+		window = gxui.CreateWindow(driver, styles, 220, 100, "stats")
+		graph = gxui.CreateStatsGraph(driver, styles)
+	})
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		driver.CallSync(func() { window.AddChild(graph) })
+		driver.CallSync(func() { window.RemoveChild(graph) })
+	}
+	// The routines polling the statistics stop once the graph is detached.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d routines left running after detaching the graph", after-before)
+	}
+}
+
+func TestPartialRedraw(t *testing.T) {
+	options := Options{Width: 100, Height: 60}.withDefaults()
+	var labels []*gxui.Label
+	build := func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+		layout := gxui.CreateLinearLayout(driver, styles)
+		labels = nil
+		for _, text := range []string{"First", "Second", "Third"} {
+			label := gxui.CreateLabel(driver, styles)
+			label.SetText(text)
+			layout.AddChild(label)
+			labels = append(labels, label)
+		}
+		window.AddChild(layout)
+	}
+	expected := Render(options, func(driver gxui.Driver, styles *gxui.StyleDefs, window *gxui.WindowImpl) {
+		build(driver, styles, window)
+		labels[1].SetColor(gxui.Red)
+	})
+
+	driver := soft.NewDriver()
+	defer driver.Terminate()
+
+	var window *gxui.WindowImpl
+	driver.CallSync(func() {
+		styles := options.Theme(driver)
+		window = gxui.CreateWindow(driver, styles, options.Width, options.Height, "partial")
+		build(driver, styles, window)
+	})
+	// The label redraws only itself over the frame drawn once laid out.
+	driver.CallSync(func() { labels[1].SetColor(gxui.Red) })
+	driver.CallSync(func() {})
+
+	actual := window.Viewport().(*soft.ViewportImpl).Image()
+	if mismatches, _ := Compare(expected, actual, 0); mismatches != 0 {
+		t.Errorf("%d pixels differ between the partial and full redraws", mismatches)
+	}
+}
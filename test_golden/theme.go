@@ -0,0 +1,146 @@
+package test_golden
+
+import (
+	"github.com/badu/gxui"
+	"github.com/badu/gxui/pkg/font"
+	"github.com/badu/gxui/pkg/math"
+)
+
+// FontSize is the size of the fonts used by the themes of this package.
+const FontSize = 14
+
+// The screen size reported by the themes, independent of the host machine.
+const (
+	ScreenWidth  = 1920
+	ScreenHeight = 1080
+)
+
+func createFonts(driver gxui.Driver) (gxui.Font, gxui.Font) {
+	defaultFont, err := driver.CreateFont(font.Default, FontSize)
+	if err != nil {
+		panic(err)
+	}
+	defaultFont.LoadGlyphs(32, 126)
+
+	defaultMonospaceFont, err := driver.CreateFont(font.Monospace, FontSize)
+	if err != nil {
+		panic(err)
+	}
+	defaultMonospaceFont.LoadGlyphs(32, 126)
+
+	return defaultFont, defaultMonospaceFont
+}
+
+// DarkTheme returns the dark theme of the samples, with a fixed font size
+// and screen size so that renders do not depend on the host machine.
+func DarkTheme(driver gxui.Driver) *gxui.StyleDefs {
+	defaultFont, defaultMonospaceFont := createFonts(driver)
+
+	scrollBarRailDefaultBg := gxui.Black
+	scrollBarRailDefaultBg.A = 0.7
+
+	scrollBarRailOverBg := gxui.Gray20
+	scrollBarRailOverBg.A = 0.7
+
+	neonBlue := gxui.ColorFromHex(0xFF5C8CFF)
+	focus := gxui.ColorFromHex(0xA0C4D6FF)
+
+	styles := gxui.StyleDefs{
+		DefaultFont:          defaultFont,
+		DefaultMonospaceFont: defaultMonospaceFont,
+		WindowBackground:     gxui.Black,
+
+		//                                   fontColor    brushColor   penColor
+		BubbleOverlayStyle:        gxui.CreateStyle(gxui.Gray80, gxui.Gray20, gxui.Gray40, 1.0, nil),
+		ButtonDefaultStyle:        gxui.CreateStyle(gxui.Gray80, gxui.Gray10, gxui.Gray20, 1.0, nil),
+		ButtonOverStyle:           gxui.CreateStyle(gxui.Gray90, gxui.Gray15, gxui.Gray50, 1.0, nil),
+		ButtonPressedStyle:        gxui.CreateStyle(gxui.Gray20, gxui.Gray70, gxui.Gray30, 1.0, nil),
+		CodeSuggestionListStyle:   gxui.CreateStyle(gxui.Gray80, gxui.Gray20, gxui.Gray10, 1.0, nil),
+		CodeEditorStyle:           gxui.CreateStyle(gxui.Gray80, gxui.Gray20, gxui.Gray10, 2.0, defaultMonospaceFont),
+		DropDownListDefaultStyle:  gxui.CreateStyle(gxui.Gray80, gxui.Gray10, gxui.Gray20, 1.0, nil),
+		DropDownListOverStyle:     gxui.CreateStyle(gxui.Gray80, gxui.Gray15, gxui.Gray50, 1.0, nil),
+		FocusedStyle:              gxui.CreateStyle(gxui.Gray80, gxui.Transparent, focus, 1.0, nil),
+		HighlightStyle:            gxui.CreateStyle(gxui.Gray80, gxui.Transparent, neonBlue, 2.0, nil),
+		LabelStyle:                gxui.CreateStyle(gxui.Gray80, gxui.Transparent, gxui.Transparent, 0.0, nil),
+		PanelBackgroundStyle:      gxui.CreateStyle(gxui.Gray80, gxui.Gray10, gxui.Gray15, 1.0, nil),
+		ScrollBarBarDefaultStyle:  gxui.CreateStyle(gxui.Gray80, gxui.Gray30, gxui.Gray40, 1.0, nil),
+		ScrollBarBarOverStyle:     gxui.CreateStyle(gxui.Gray80, gxui.Gray50, gxui.Gray60, 1.0, nil),
+		ScrollBarRailDefaultStyle: gxui.CreateStyle(gxui.Gray80, scrollBarRailDefaultBg, gxui.Transparent, 1.0, nil),
+		ScrollBarRailOverStyle:    gxui.CreateStyle(gxui.Gray80, scrollBarRailOverBg, gxui.Gray20, 1.0, nil),
+		SplitterBarDefaultStyle:   gxui.CreateStyle(gxui.Gray80, gxui.Gray10, gxui.Gray10, 1.0, nil),
+		SplitterBarOverStyle:      gxui.CreateStyle(gxui.Gray80, gxui.Gray10, gxui.Gray50, 1.0, nil),
+		TabActiveHighlightStyle:   gxui.CreateStyle(gxui.Gray90, neonBlue, neonBlue, 0.0, nil),
+		TabDefaultStyle:           gxui.CreateStyle(gxui.Gray80, gxui.Gray30, gxui.Gray40, 1.0, nil),
+		TabOverStyle:              gxui.CreateStyle(gxui.Gray90, gxui.Gray30, gxui.Gray50, 1.0, nil),
+		TabPressedStyle:           gxui.CreateStyle(gxui.Gray20, gxui.Gray70, gxui.Gray30, 1.0, nil),
+		TextBoxDefaultStyle:       gxui.CreateStyle(gxui.Gray80, gxui.Gray10, gxui.Gray20, 1.0, nil),
+		TextBoxOverStyle:          gxui.CreateStyle(gxui.Gray80, gxui.Gray10, gxui.Gray50, 1.0, nil),
+
+		ScreenWidth:  ScreenWidth,
+		ScreenHeight: ScreenHeight,
+		FontSize:     FontSize,
+	}
+
+	styles.BubbleOverlayStyle.Shadow = gxui.Shadow{Offset: math.Point{Y: 2}, Blur: 8, Color: gxui.Color{A: 0.6}}
+	styles.LabelStyle.HAlign = gxui.AlignLeft
+	styles.LabelStyle.VAlign = gxui.AlignMiddle
+
+	return &styles
+}
+
+// LightTheme returns the light theme of the samples, with a fixed font size
+// and screen size so that renders do not depend on the host machine.
+func LightTheme(driver gxui.Driver) *gxui.StyleDefs {
+	defaultFont, defaultMonospaceFont := createFonts(driver)
+
+	scrollBarRailDefaultBg := gxui.Black
+	scrollBarRailDefaultBg.A = 0.7
+
+	scrollBarRailOverBg := gxui.Gray20
+	scrollBarRailOverBg.A = 0.7
+
+	neonBlue := gxui.ColorFromHex(0xFF5C8CFF)
+	focus := gxui.ColorFromHex(0xFFC4D6FF)
+
+	styles := gxui.StyleDefs{
+		DefaultFont:          defaultFont,
+		DefaultMonospaceFont: defaultMonospaceFont,
+		WindowBackground:     gxui.White,
+
+		//                                   fontColor    brushColor   penColor
+		BubbleOverlayStyle:        gxui.CreateStyle(gxui.Gray40, gxui.Gray20, gxui.Gray40, 1.0, nil),
+		ButtonDefaultStyle:        gxui.CreateStyle(gxui.Gray40, gxui.White, gxui.Gray40, 1.0, nil),
+		ButtonOverStyle:           gxui.CreateStyle(gxui.Gray40, gxui.Gray90, gxui.Gray40, 1.0, nil),
+		ButtonPressedStyle:        gxui.CreateStyle(gxui.Gray20, gxui.Gray70, gxui.Gray30, 1.0, nil),
+		CodeSuggestionListStyle:   gxui.CreateStyle(gxui.Gray40, gxui.Gray20, gxui.Gray10, 1.0, nil),
+		CodeEditorStyle:           gxui.CreateStyle(gxui.Gray40, gxui.Gray20, gxui.Gray10, 2.0, defaultMonospaceFont),
+		DropDownListDefaultStyle:  gxui.CreateStyle(gxui.Gray40, gxui.White, gxui.Gray20, 1.0, nil),
+		DropDownListOverStyle:     gxui.CreateStyle(gxui.Gray40, gxui.Gray90, gxui.Gray50, 1.0, nil),
+		FocusedStyle:              gxui.CreateStyle(gxui.Gray20, gxui.Transparent, focus, 1.0, nil),
+		HighlightStyle:            gxui.CreateStyle(gxui.Gray40, gxui.Transparent, neonBlue, 2.0, nil),
+		LabelStyle:                gxui.CreateStyle(gxui.Gray40, gxui.Transparent, gxui.Transparent, 0.0, nil),
+		PanelBackgroundStyle:      gxui.CreateStyle(gxui.Gray40, gxui.White, gxui.Gray15, 1.0, nil),
+		ScrollBarBarDefaultStyle:  gxui.CreateStyle(gxui.Gray40, gxui.Gray30, gxui.Gray40, 1.0, nil),
+		ScrollBarBarOverStyle:     gxui.CreateStyle(gxui.Gray40, gxui.Gray50, gxui.Gray60, 1.0, nil),
+		ScrollBarRailDefaultStyle: gxui.CreateStyle(gxui.Gray40, scrollBarRailDefaultBg, gxui.Transparent, 1.0, nil),
+		ScrollBarRailOverStyle:    gxui.CreateStyle(gxui.Gray40, scrollBarRailOverBg, gxui.Gray20, 1.0, nil),
+		SplitterBarDefaultStyle:   gxui.CreateStyle(gxui.Gray40, gxui.Gray80, gxui.Gray40, 1.0, nil),
+		SplitterBarOverStyle:      gxui.CreateStyle(gxui.Gray40, gxui.Gray80, gxui.Gray50, 1.0, nil),
+		TabActiveHighlightStyle:   gxui.CreateStyle(gxui.Gray30, neonBlue, neonBlue, 0.0, nil),
+		TabDefaultStyle:           gxui.CreateStyle(gxui.Gray40, gxui.White, gxui.Gray40, 1.0, nil),
+		TabOverStyle:              gxui.CreateStyle(gxui.Gray30, gxui.Gray90, gxui.Gray50, 1.0, nil),
+		TabPressedStyle:           gxui.CreateStyle(gxui.Gray20, gxui.Gray70, gxui.Gray30, 1.0, nil),
+		TextBoxDefaultStyle:       gxui.CreateStyle(gxui.Gray40, gxui.White, gxui.Gray20, 1.0, nil),
+		TextBoxOverStyle:          gxui.CreateStyle(gxui.Gray40, gxui.White, gxui.Gray50, 1.0, nil),
+
+		ScreenWidth:  ScreenWidth,
+		ScreenHeight: ScreenHeight,
+		FontSize:     FontSize,
+	}
+
+	styles.BubbleOverlayStyle.Shadow = gxui.Shadow{Offset: math.Point{Y: 2}, Blur: 8, Color: gxui.Color{A: 0.3}}
+	styles.LabelStyle.HAlign = gxui.AlignLeft
+	styles.LabelStyle.VAlign = gxui.AlignMiddle
+
+	return &styles
+}
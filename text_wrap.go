@@ -0,0 +1,136 @@
+package gxui
+
+import "unicode"
+
+// TextWrap is how the lines of a text wider than its control are broken.
+type TextWrap int
+
+const (
+	// NoWrap only breaks lines at new lines.
+	NoWrap TextWrap = iota
+	// WordWrap breaks lines between words, or within the words longer than a
+	// line.
+	WordWrap
+	// CharacterWrap breaks lines between any two runes.
+	CharacterWrap
+)
+
+// TextTruncation is how the lines of a text wider than its control are
+// shortened.
+type TextTruncation int
+
+const (
+	// NoTruncation draws the lines whole, past the edges of the control.
+	NoTruncation TextTruncation = iota
+	// TruncateEnd replaces the end of the lines with an ellipsis.
+	TruncateEnd
+	// TruncateMiddle replaces the middle of the lines with an ellipsis.
+	TruncateMiddle
+	// TruncateStart replaces the start of the lines with an ellipsis.
+	TruncateStart
+)
+
+// The rune replacing the truncated runes of a line.
+const ellipsis = '…'
+
+// wrapRunes returns the indices the lines of runes wrapped to width start at,
+// after the first line. measure returns the width of the runes from start to
+// end. Spaces hang past the end of the lines broken after them, and a line
+// always has at least a rune.
+func wrapRunes(runes []rune, width int, mode TextWrap, measure func(start, end int) int) []int {
+	var starts []int
+	start, end := 0, len(runes)
+	for mode != NoWrap && start < end && measure(start, end) > width {
+		// The most runes from start fitting the width.
+		low, high := start+1, end
+		for low < high {
+			mid := (low + high + 1) / 2
+			if measure(start, mid) <= width {
+				low = mid
+			} else {
+				high = mid - 1
+			}
+		}
+
+		next := low
+		if mode == WordWrap {
+			for next < end && unicode.IsSpace(runes[next]) {
+				next++
+			}
+			// The line breaks after its last word, unless it has none.
+			first := start
+			for first < next && unicode.IsSpace(runes[first]) {
+				first++
+			}
+			index := next
+			for index > first && !breaksAfter(runes[index-1]) {
+				index--
+			}
+			if index > first {
+				next = index
+			}
+		}
+		if next == end {
+			break
+		}
+		starts = append(starts, next)
+		start = next
+	}
+	return starts
+}
+
+// breaksAfter returns true if a line can break after r between words.
+func breaksAfter(r rune) bool {
+	return unicode.IsSpace(r) || r == '-'
+}
+
+// truncateRunes returns the runes, and the style of each of them, shortened to
+// width with an ellipsis when they are wider. The ellipsis has the style of the
+// rune it follows, or precedes at the start of the runes. measure returns the
+// width of runes with styles.
+func truncateRunes(runes []rune, styles []int, width int, mode TextTruncation, measure func(runes []rune, styles []int) int) ([]rune, []int) {
+	if mode == NoTruncation || len(runes) == 0 || measure(runes, styles) <= width {
+		return runes, styles
+	}
+
+	// keep returns the runes keeping count of them around the ellipsis.
+	keep := func(count int) ([]rune, []int) {
+		var head, tail int
+		switch mode {
+		case TruncateEnd:
+			head = count
+		case TruncateStart:
+			tail = count
+		default:
+			head, tail = (count+1)/2, count/2
+		}
+		for head > 0 && unicode.IsSpace(runes[head-1]) {
+			head--
+		}
+		for tail > 0 && unicode.IsSpace(runes[len(runes)-tail]) {
+			tail--
+		}
+
+		style := styles[0]
+		if head > 0 {
+			style = styles[head-1]
+		} else if tail > 0 {
+			style = styles[len(styles)-tail]
+		}
+		keptRunes := append(append(append([]rune{}, runes[:head]...), ellipsis), runes[len(runes)-tail:]...)
+		keptStyles := append(append(append([]int{}, styles[:head]...), style), styles[len(styles)-tail:]...)
+		return keptRunes, keptStyles
+	}
+
+	// The ellipsis is kept alone when not even a rune fits with it.
+	low, high := 0, len(runes)-1
+	for low < high {
+		mid := (low + high + 1) / 2
+		if measure(keep(mid)) <= width {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return keep(low)
+}
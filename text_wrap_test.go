@@ -0,0 +1,54 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/test_helper"
+)
+
+// wrapLines returns the lines of text wrapped to width runes.
+func wrapLines(text string, width int, mode TextWrap) []string {
+	runes := []rune(text)
+	starts := wrapRunes(runes, width, mode, func(start, end int) int { return end - start })
+	var lines []string
+	start := 0
+	for _, end := range append(starts, len(runes)) {
+		lines = append(lines, string(runes[start:end]))
+		start = end
+	}
+	return lines
+}
+
+func TestWrapRunes(t *testing.T) {
+	test_helper.AssertEquals(t, []string{"one two three"}, wrapLines("one two three", 20, WordWrap))
+	test_helper.AssertEquals(t, []string{"one two three"}, wrapLines("one two three", 5, NoWrap))
+	test_helper.AssertEquals(t, []string{"one ", "two ", "three"}, wrapLines("one two three", 5, WordWrap))
+	test_helper.AssertEquals(t, []string{"one two   ", "three"}, wrapLines("one two   three", 8, WordWrap))
+	test_helper.AssertEquals(t, []string{"well-", "known"}, wrapLines("well-known", 7, WordWrap))
+	test_helper.AssertEquals(t, []string{"  abc", "def"}, wrapLines("  abcdef", 5, WordWrap))
+	test_helper.AssertEquals(t, []string{"one t", "wo th", "ree"}, wrapLines("one two three", 5, CharacterWrap))
+	test_helper.AssertEquals(t, []string{"a", "b"}, wrapLines("ab", 0, CharacterWrap))
+}
+
+// truncate returns text truncated to width runes.
+func truncate(text string, width int, mode TextTruncation) string {
+	runes := []rune(text)
+	runes, _ = truncateRunes(runes, make([]int, len(runes)), width, mode, func(runes []rune, _ []int) int { return len(runes) })
+	return string(runes)
+}
+
+func TestTruncateRunes(t *testing.T) {
+	test_helper.AssertEquals(t, "abcdefgh", truncate("abcdefgh", 8, TruncateEnd))
+	test_helper.AssertEquals(t, "abcdefgh", truncate("abcdefgh", 4, NoTruncation))
+	test_helper.AssertEquals(t, "abc…", truncate("abcdefgh", 4, TruncateEnd))
+	test_helper.AssertEquals(t, "…fgh", truncate("abcdefgh", 4, TruncateStart))
+	test_helper.AssertEquals(t, "ab…h", truncate("abcdefgh", 4, TruncateMiddle))
+	test_helper.AssertEquals(t, "ab…", truncate("ab cdefgh", 4, TruncateEnd))
+	test_helper.AssertEquals(t, "…", truncate("abcdefgh", 0, TruncateEnd))
+}
+
+func TestTruncateRunesStyles(t *testing.T) {
+	runes, styles := truncateRunes([]rune("aabb"), []int{0, 0, 1, 1}, 3, TruncateStart, func(runes []rune, _ []int) int { return len(runes) })
+	test_helper.AssertEquals(t, "…bb", string(runes))
+	test_helper.AssertEquals(t, []int{1, 1, 1}, styles)
+}
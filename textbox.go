@@ -55,6 +55,8 @@ type TextBox struct {
 	textColor         Color
 	multiline         bool
 	selectionDragging bool
+	wrap              TextWrap
+	wrapWidth         int
 }
 
 func (t *TextBox) lineMouseDown(line TextBoxLine, event MouseEvent) {
@@ -107,7 +109,9 @@ func (t *TextBox) Init(parent TextBoxParent, driver Driver, styles *StyleDefs, f
 	t.controller.OnTextChanged(
 		func([]TextBoxEdit) {
 			t.onRedrawLines.Emit()
-			t.ListImpl.DataChanged(false)
+			// The controls of the visual lines are only created again when
+			// edits move the lines they display.
+			t.ListImpl.DataChanged(t.controller.visualLinesMoved)
 		},
 	)
 
@@ -169,10 +173,69 @@ func (t *TextBox) Font() Font {
 func (t *TextBox) SetFont(font Font) {
 	if t.font != font {
 		t.font = font
+		if t.wrap != NoWrap {
+			t.wrapLines()
+			t.ListImpl.DataChanged(true)
+		}
 		t.ReLayout()
 	}
 }
 
+// Wrap returns how the lines of the text box wider than it are broken.
+func (t *TextBox) Wrap() TextWrap {
+	return t.wrap
+}
+
+// SetWrap sets how the lines of the text box wider than it are broken into
+// visual lines. The text box does not scroll horizontally when its lines are
+// wrapped.
+func (t *TextBox) SetWrap(wrap TextWrap) {
+	if t.wrap != wrap {
+		t.wrap = wrap
+		t.wrapLines()
+		t.ListImpl.DataChanged(true)
+		if wrap != NoWrap {
+			t.SetHorizontalOffset(0)
+		}
+	}
+}
+
+// lineTextWidth returns the width the lines are wrapped to: the width of the
+// text of the displayed lines, or of the text box before they are created.
+func (t *TextBox) lineTextWidth() int {
+	type textWidther interface{ textWidth() int }
+	line := FindControl(t, func(c Control) bool {
+		_, ok := c.(textWidther)
+		return ok
+	})
+	if line != nil {
+		return line.(textWidther).textWidth()
+	}
+
+	width := t.Size().Contract(t.Padding()).Width
+	if t.scrollBarEnabled {
+		width -= t.scrollBar.Size().Width
+	}
+	return width
+}
+
+// wrapLines wraps the lines of the controller to the width of their text.
+func (t *TextBox) wrapLines() {
+	if t.wrap == NoWrap {
+		t.wrapWidth = 0
+		t.controller.SetLineWrapper(nil)
+		return
+	}
+
+	font, wrap, width := t.font, t.wrap, max(t.lineTextWidth(), 1)
+	t.wrapWidth = width
+	t.controller.SetLineWrapper(func(line []rune) []int {
+		return wrapRunes(line, width, wrap, func(start, end int) int {
+			return font.Measure(&TextBlock{Runes: line[start:end]}).Width
+		})
+	})
+}
+
 func (t *TextBox) Multiline() bool {
 	return t.multiline
 }
@@ -249,7 +312,7 @@ func (t *TextBox) LineEnd(line int) int {
 }
 
 func (t *TextBox) ScrollToLine(index int) {
-	t.ListImpl.ScrollTo(index)
+	t.ListImpl.ScrollTo(t.controller.VisualLineIndex(t.controller.LineStart(index)))
 }
 
 func findLineOffset(child *Child) int {
@@ -271,8 +334,11 @@ func (t *TextBox) lineWidthOffset() int {
 }
 
 func (t *TextBox) ScrollToRune(index int) {
-	lineIndex := t.controller.LineIndex(index)
-	t.ScrollToLine(lineIndex)
+	lineIndex := t.controller.VisualLineIndex(index)
+	t.ListImpl.ScrollTo(lineIndex)
+	if t.wrap != NoWrap {
+		return
+	}
 
 	size := t.Size()
 	lineOffset := t.lineWidthOffset()
@@ -529,7 +595,7 @@ type TextBoxAdapter struct {
 }
 
 func (t *TextBoxAdapter) Count() int {
-	return max(t.TextBox.controller.LineCount(), 1)
+	return max(t.TextBox.controller.VisualLineCount(), 1)
 }
 
 func (t *TextBoxAdapter) ItemAt(index int) AdapterItem {
@@ -608,17 +674,26 @@ func (t *TextBox) SetHorizontalOffset(offset int) {
 
 func (t *TextBox) LayoutChildren() {
 	t.ListImpl.LayoutChildren()
+	if t.wrap != NoWrap && t.lineTextWidth() != t.wrapWidth {
+		// The lines are wrapped again to the width they were laid out in.
+		t.wrapLines()
+		t.updateItems(true)
+		t.ListImpl.LayoutChildren()
+	}
 	if t.scrollBarEnabled {
 		size := t.Size().Contract(t.Padding())
 		scrollAreaSize := size
 		scrollAreaSize.Width -= t.scrollBar.Size().Width
 
 		offset := t.Padding().TopLeft()
+		if IsRightToLeft(t.parent) {
+			offset.X += t.scrollBar.Size().Width
+		}
 		barSize := t.horizontalScrollbar.DesiredSize(math.ZeroSize, scrollAreaSize)
 		t.horizontalScrollChild.Layout(math.CreateRect(0, size.Height-barSize.Height, scrollAreaSize.Width, size.Height).Canon().Offset(offset))
 
 		maxLineWidth := t.parent.MaxLineWidth()
-		entireContentVisible := size.Width > maxLineWidth
+		entireContentVisible := t.wrap != NoWrap || size.Width > maxLineWidth
 		t.horizontalScrollbar.SetVisible(!entireContentVisible)
 		if entireContentVisible && t.horizontalOffset != 0 {
 			t.SetHorizontalOffset(0)
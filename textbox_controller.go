@@ -5,14 +5,32 @@
 package gxui
 
 import (
+	"slices"
 	"sort"
 	"strings"
 	"unicode"
 
+	"github.com/badu/gxui/pkg/bidi"
 	"github.com/badu/gxui/pkg/interval"
 	"github.com/badu/gxui/pkg/math"
 )
 
+// CaretOrder is the order carets are moved left and right in.
+type CaretOrder int
+
+const (
+	// VisualCaretOrder moves carets to the position displayed next to them,
+	// following the runs of right to left text.
+	VisualCaretOrder CaretOrder = iota
+	// LogicalCaretOrder moves carets to the previous or next rune of the text,
+	// however it is displayed.
+	LogicalCaretOrder
+)
+
+// LineWrapper returns the indices of the runes of a line its wrapped visual
+// lines start at, after the first one.
+type LineWrapper func(line []rune) []int
+
 type TextBoxEdit struct {
 	At    int
 	Delta int
@@ -24,10 +42,17 @@ type TextBoxController struct {
 	text                        []rune
 	lineStarts                  []int
 	lineEnds                    []int
+	lineWrapper                 LineWrapper
+	visualLineStarts            []int
+	visualLineEnds              []int
+	visualLineLines             []int
+	visualLinesMoved            bool               // Whether the last edit moved lines to other visual lines.
+	bidiLines                   map[int]*bidi.Line // The bidirectional layouts of the lines laid out since the text changed.
 	selections                  TextSelectionList
 	locationHistory             [][]int
 	locationHistoryIndex        int
 	storeCaretLocationsNextEdit bool
+	caretOrder                  CaretOrder
 }
 
 func CreateTextBoxController() *TextBoxController {
@@ -69,12 +94,26 @@ func (t *TextBoxController) updateSelectionsForEdits(edits []TextBoxEdit) {
 	t.selections = selections
 }
 
+// textChange replaces the runes [at, at+removed) of the text by inserted runes.
+type textChange struct {
+	at, removed, inserted int
+}
+
 func (t *TextBoxController) setTextRunesNoEvent(text []rune) {
-	t.text = text
-	t.lineStarts = t.lineStarts[:0]
-	t.lineEnds = t.lineEnds[:0]
+	t.setTextChangesNoEvent(text, nil)
+}
+
+// setTextChangesNoEvent sets the text, made from the current one by the
+// changes, and wraps again only the lines the changes touch. All the lines are
+// wrapped again when there are no changes, or when they do not make the text.
+func (t *TextBoxController) setTextChangesNoEvent(text []rune, changes []textChange) {
+	oldLength, oldLineEnds := len(t.text), t.lineEnds
+	oldStarts, oldEnds, oldLines := t.visualLineStarts, t.visualLineEnds, t.visualLineLines
 
-	t.lineStarts = append(t.lineStarts, 0)
+	t.text = text
+	t.bidiLines = nil
+	t.lineStarts = []int{0}
+	t.lineEnds = nil
 	for index, curRune := range text {
 		if curRune == '\n' {
 			t.lineEnds = append(t.lineEnds, index)
@@ -82,6 +121,93 @@ func (t *TextBoxController) setTextRunesNoEvent(text []rune) {
 		}
 	}
 	t.lineEnds = append(t.lineEnds, len(text))
+
+	delta := 0
+	for _, change := range changes {
+		delta += change.inserted - change.removed
+	}
+	t.visualLineStarts, t.visualLineEnds, t.visualLineLines = nil, nil, nil
+	if len(changes) == 0 || len(oldLineEnds) == 0 || len(text)-oldLength != delta {
+		t.wrapLines()
+	} else {
+		changes = slices.SortedFunc(slices.Values(changes), func(a, b textChange) int { return a.at - b.at })
+		oldLineIndex := func(index int) int {
+			return sort.SearchInts(oldLineEnds, math.Clamp(index, 0, oldLength))
+		}
+
+		// The untouched lines keep their visual lines, moved by the changes before them.
+		runeDelta, lineDelta, copied := 0, 0, 0
+		copyLines := func(end int) {
+			for i := sort.SearchInts(oldLines, copied); i < len(oldLines) && oldLines[i] < end; i++ {
+				t.visualLineStarts = append(t.visualLineStarts, oldStarts[i]+runeDelta)
+				t.visualLineEnds = append(t.visualLineEnds, oldEnds[i]+runeDelta)
+				t.visualLineLines = append(t.visualLineLines, oldLines[i]+lineDelta)
+			}
+		}
+
+		for i := 0; i < len(changes); {
+			// The lines touched by overlapping changes are wrapped again together.
+			first := oldLineIndex(changes[i].at)
+			last, groupDelta := first, 0
+			for ; i < len(changes) && oldLineIndex(changes[i].at) <= last; i++ {
+				last = max(last, oldLineIndex(changes[i].at+changes[i].removed))
+				groupDelta += changes[i].inserted - changes[i].removed
+			}
+
+			copyLines(first)
+			runeDelta += groupDelta
+			newLast := t.LineIndex(oldLineEnds[last] + runeDelta)
+			for line := first + lineDelta; line <= newLast; line++ {
+				t.appendVisualLines(line)
+			}
+			lineDelta, copied = newLast-last, last+1
+		}
+		copyLines(len(oldLineEnds))
+	}
+
+	common := min(len(oldLines), len(t.visualLineLines))
+	t.visualLinesMoved = !slices.Equal(oldLines[:common], t.visualLineLines[:common])
+}
+
+// wrapLines splits the lines into the visual lines they are displayed on.
+func (t *TextBoxController) wrapLines() {
+	t.visualLineStarts = t.visualLineStarts[:0]
+	t.visualLineEnds = t.visualLineEnds[:0]
+	t.visualLineLines = t.visualLineLines[:0]
+	for line := range t.lineStarts {
+		t.appendVisualLines(line)
+	}
+}
+
+// appendVisualLines appends the visual lines the line is wrapped into.
+func (t *TextBoxController) appendVisualLines(line int) {
+	lineStart, end := t.lineStarts[line], t.lineEnds[line]
+	start := lineStart
+	if t.lineWrapper != nil {
+		for _, index := range t.lineWrapper(t.text[lineStart:end]) {
+			t.visualLineStarts = append(t.visualLineStarts, start)
+			t.visualLineEnds = append(t.visualLineEnds, lineStart+index)
+			t.visualLineLines = append(t.visualLineLines, line)
+			start = lineStart + index
+		}
+	}
+	t.visualLineStarts = append(t.visualLineStarts, start)
+	t.visualLineEnds = append(t.visualLineEnds, end)
+	t.visualLineLines = append(t.visualLineLines, line)
+}
+
+// LineWrapper returns the function wrapping the lines into visual lines, or nil
+// when each line is displayed on a single visual line.
+func (t *TextBoxController) LineWrapper() LineWrapper {
+	return t.lineWrapper
+}
+
+// SetLineWrapper sets the function wrapping the lines into visual lines, and
+// wraps them again. Carets move up and down, home and end, along the visual
+// lines.
+func (t *TextBoxController) SetLineWrapper(wrapper LineWrapper) {
+	t.lineWrapper = wrapper
+	t.wrapLines()
 }
 
 func (t *TextBoxController) maybeStoreCaretLocations() {
@@ -208,6 +334,62 @@ func (t *TextBoxController) LineIndex(index int) int {
 	)
 }
 
+// VisualLineCount returns the number of lines the text is displayed on.
+func (t *TextBoxController) VisualLineCount() int {
+	return len(t.visualLineStarts)
+}
+
+// VisualLineRunes returns the runes displayed on the visual line.
+func (t *TextBoxController) VisualLineRunes(visualLine int) []rune {
+	return t.text[t.VisualLineStart(visualLine):t.VisualLineEnd(visualLine)]
+}
+
+// VisualLineStart returns the index of the first rune of the visual line.
+func (t *TextBoxController) VisualLineStart(visualLine int) int {
+	if t.VisualLineCount() == 0 {
+		return 0
+	}
+	return t.visualLineStarts[visualLine]
+}
+
+// VisualLineEnd returns the index after the last rune of the visual line,
+// which starts the next visual line when the line is wrapped there.
+func (t *TextBoxController) VisualLineEnd(visualLine int) int {
+	if t.VisualLineCount() == 0 {
+		return 0
+	}
+	return t.visualLineEnds[visualLine]
+}
+
+// VisualLineLine returns the line wrapped into the visual line.
+func (t *TextBoxController) VisualLineLine(visualLine int) int {
+	if t.VisualLineCount() == 0 {
+		return 0
+	}
+	return t.visualLineLines[visualLine]
+}
+
+// VisualLineIndex returns the visual line the caret at index is displayed on,
+// which is the start of the next visual line where a line is wrapped.
+func (t *TextBoxController) VisualLineIndex(index int) int {
+	return max(sort.Search(
+		len(t.visualLineStarts),
+		func(i int) bool {
+			return index < t.visualLineStarts[i]
+		},
+	)-1, 0)
+}
+
+// visualLineLastCaret returns the last index a caret is displayed at on the
+// visual line, before the last rune of the wrapped lines.
+func (t *TextBoxController) visualLineLastCaret(visualLine int) int {
+	end := t.VisualLineEnd(visualLine)
+	if visualLine < t.VisualLineCount()-1 && t.VisualLineStart(visualLine+1) == end {
+		return end - 1
+	}
+	return end
+}
+
 func (t *TextBoxController) Text() string {
 	return RuneArrayToString(t.text)
 }
@@ -229,8 +411,19 @@ func (t *TextBoxController) SetTextRunes(runes []rune) {
 	t.textEdited([]TextBoxEdit{})
 }
 
+// SetTextEdits sets the text, made from the current one by the edits inserting
+// or removing runes, and wraps again the lines they touch.
 func (t *TextBoxController) SetTextEdits(runes []rune, edits []TextBoxEdit) {
-	t.setTextRunesNoEvent(runes)
+	changes := make([]textChange, len(edits))
+	for i, edit := range edits {
+		if edit.Delta < 0 {
+			// Backspace reports deleted selections at the rune before them.
+			changes[i] = textChange{edit.At, 1 - edit.Delta, 1}
+		} else {
+			changes[i] = textChange{edit.At, 0, edit.Delta}
+		}
+	}
+	t.setTextChangesNoEvent(runes, changes)
 	t.textEdited(edits)
 }
 
@@ -242,14 +435,84 @@ func (t *TextBoxController) IndexLast(index int) int {
 	return len(t.text)
 }
 
+func (t *TextBoxController) CaretOrder() CaretOrder {
+	return t.caretOrder
+}
+
+func (t *TextBoxController) SetCaretOrder(order CaretOrder) {
+	t.caretOrder = order
+}
+
+// bidiLine returns the bidirectional layout of the line, laid out once until
+// the text changes.
+func (t *TextBoxController) bidiLine(lineNo int) *bidi.Line {
+	if line, found := t.bidiLines[lineNo]; found {
+		return line
+	}
+	if t.bidiLines == nil {
+		t.bidiLines = make(map[int]*bidi.Line)
+	}
+	line := bidi.NewLine(t.LineRunes(lineNo), bidi.Auto)
+	t.bidiLines[lineNo] = line
+	return line
+}
+
+// IndexLeft returns the index of the caret left of index. In visual caret
+// order, carets leaving a line move to the line before it when it is left to
+// right, or to the line after it when it is right to left.
 func (t *TextBoxController) IndexLeft(index int) int {
+	if t.caretOrder == VisualCaretOrder {
+		l := t.LineIndex(index)
+		start := t.LineStart(l)
+		line := t.bidiLine(l)
+		if caret, moved := line.CaretLeft(index - start); moved {
+			return start + caret
+		}
+		if line.RightToLeft() {
+			return t.IndexLineAfter(index)
+		}
+		return t.IndexLineBefore(index)
+	}
 	return max(index-1, 0)
 }
 
+// IndexRight returns the index of the caret right of index. In visual caret
+// order, carets leaving a line move to the line after it when it is left to
+// right, or to the line before it when it is right to left.
 func (t *TextBoxController) IndexRight(index int) int {
+	if t.caretOrder == VisualCaretOrder {
+		l := t.LineIndex(index)
+		start := t.LineStart(l)
+		line := t.bidiLine(l)
+		if caret, moved := line.CaretRight(index - start); moved {
+			return start + caret
+		}
+		if line.RightToLeft() {
+			return t.IndexLineBefore(index)
+		}
+		return t.IndexLineAfter(index)
+	}
 	return min(index+1, len(t.text))
 }
 
+// IndexLineBefore returns the end of the line before the line of index, or
+// index on the first line.
+func (t *TextBoxController) IndexLineBefore(index int) int {
+	if l := t.LineIndex(index); l > 0 {
+		return t.LineEnd(l - 1)
+	}
+	return index
+}
+
+// IndexLineAfter returns the start of the line after the line of index, or
+// index on the last line.
+func (t *TextBoxController) IndexLineAfter(index int) int {
+	if l := t.LineIndex(index); l < t.LineCount()-1 {
+		return t.LineStart(l + 1)
+	}
+	return index
+}
+
 func (t *TextBoxController) IndexWordLeft(index int) int {
 	index--
 	if index >= 0 {
@@ -282,30 +545,34 @@ func (t *TextBoxController) IndexWordRight(index int) int {
 }
 
 func (t *TextBoxController) IndexUp(index int) int {
-	l := t.LineIndex(index)
-	x := index - t.LineStart(l)
+	l := t.VisualLineIndex(index)
+	x := index - t.VisualLineStart(l)
 	if l > 0 {
-		return min(t.LineStart(l-1)+x, t.LineEnd(l-1))
+		return min(t.VisualLineStart(l-1)+x, t.visualLineLastCaret(l-1))
 	} else {
 		return 0
 	}
 }
 
 func (t *TextBoxController) IndexDown(index int) int {
-	l := t.LineIndex(index)
-	x := index - t.LineStart(l)
-	if l < t.LineCount()-1 {
-		return min(t.LineStart(l+1)+x, t.LineEnd(l+1))
+	l := t.VisualLineIndex(index)
+	x := index - t.VisualLineStart(l)
+	if l < t.VisualLineCount()-1 {
+		return min(t.VisualLineStart(l+1)+x, t.visualLineLastCaret(l+1))
 	} else {
-		return t.LineEnd(l)
+		return t.VisualLineEnd(l)
 	}
 }
 
 func (t *TextBoxController) IndexHome(index int) int {
-	l := t.LineIndex(index)
-	s := t.LineStart(l)
+	l := t.VisualLineIndex(index)
+	s := t.VisualLineStart(l)
+	if s != t.LineStart(t.VisualLineLine(l)) {
+		// Wrapped lines continue without indentation.
+		return s
+	}
 	x := index - s
-	indent := t.LineIndent(l)
+	indent := t.LineIndent(t.VisualLineLine(l))
 	if x > indent {
 		return s + indent
 	} else {
@@ -314,7 +581,7 @@ func (t *TextBoxController) IndexHome(index int) int {
 }
 
 func (t *TextBoxController) IndexEnd(index int) int {
-	return t.LineEnd(t.LineIndex(index))
+	return t.visualLineLastCaret(t.VisualLineIndex(index))
 }
 
 type SelectionTransform func(int) int
@@ -498,14 +765,17 @@ func (t *TextBoxController) ReplaceRunes(callback func(selection TextSelection)
 	text := t.text
 	edit := TextBoxEdit{}
 	var edits []TextBoxEdit
+	var changes []textChange
 
 	for index := len(t.selections) - 1; index >= 0; index-- {
 		selection := t.selections[index]
-		text, edit = t.ReplaceAt(text, selection.start, selection.end, callback(selection))
+		replacement := callback(selection)
+		text, edit = t.ReplaceAt(text, selection.start, selection.end, replacement)
 		edits = append(edits, edit)
+		changes = append(changes, textChange{selection.start, selection.Length(), len(replacement)})
 	}
 
-	t.setTextRunesNoEvent(text)
+	t.setTextChangesNoEvent(text, changes)
 	t.textEdited(edits)
 }
 
@@ -266,6 +266,58 @@ func TestTBCUnicode(t *testing.T) {
 	assertTBCTextAndSelectionsEqual(t, "1£2|£3", c)
 }
 
+func TestTBCMoveBidi(t *testing.T) {
+	// Carets move across the Hebrew run in the order it is displayed in.
+	c := parseTBC("ab| אבג cd")
+	for _, expected := range []string{"ab אבג| cd", "ab אב|ג cd", "ab א|בג cd", "ab |אבג cd", "ab אבג |cd"} {
+		c.MoveRight()
+		assertTBCTextAndSelectionsEqual(t, expected, c)
+	}
+	c.MoveLeft()
+	assertTBCTextAndSelectionsEqual(t, "ab |אבג cd", c)
+
+	// Right to left lines are left from their end to the line after them.
+	c = parseTBC("אב|ג\nאבג")
+	c.MoveLeft()
+	assertTBCTextAndSelectionsEqual(t, "אבג|\nאבג", c)
+	c.MoveLeft()
+	assertTBCTextAndSelectionsEqual(t, "אבג\n|אבג", c)
+	c.MoveRight()
+	assertTBCTextAndSelectionsEqual(t, "אבג|\nאבג", c)
+
+	// In logical order, carets move to the previous or next rune.
+	c = parseTBC("אב|ג")
+	c.SetCaretOrder(LogicalCaretOrder)
+	c.MoveLeft()
+	assertTBCTextAndSelectionsEqual(t, "א|בג", c)
+}
+
+func TestTBCSelectBidi(t *testing.T) {
+	c := parseTBC("ab| אבג")
+	c.SelectRight()
+	assertTBCTextAndSelectionsEqual(t, "ab{ אבג]", c)
+	c.SelectRight()
+	assertTBCTextAndSelectionsEqual(t, "ab{ אב]ג", c)
+}
+
+func TestTBCBidiLinesCached(t *testing.T) {
+	c := parseTBC("ab| אבג\ncd")
+	c.MoveRight()
+	line := c.bidiLines[0]
+	c.MoveRight()
+	test_helper.AssertEquals(t, true, line == c.bidiLines[0])
+	test_helper.AssertEquals(t, 1, len(c.bidiLines))
+
+	// The lines are laid out again once the text changes.
+	c.ReplaceAll("x")
+	test_helper.AssertEquals(t, 0, len(c.bidiLines))
+	c.SetCaret(6)
+	expected := parseTBC("ab אבx|ג\ncd")
+	expected.MoveLeft()
+	c.MoveLeft()
+	test_helper.AssertEquals(t, expected.selections, c.selections)
+}
+
 func TestTBCIndentSelection(t *testing.T) {
 	c := parseTBC("a{aa\n  b]bb|bb\n    [cc}\nddd\ne{e][e}e\n")
 	c.IndentSelection(2)
@@ -277,3 +329,90 @@ func TestTBCUnindentSelection(t *testing.T) {
 	c.UnindentSelection(2)
 	assertTBCTextAndSelectionsEqual(t, "a{aa\n  b]bb|bb\n    [cc}\nddd\ne{e][e}e\n", c)
 }
+
+// wrapTBC wraps the lines of the controller between words, to width runes.
+func wrapTBC(c *TextBoxController, width int) {
+	c.SetLineWrapper(func(line []rune) []int {
+		return wrapRunes(line, width, WordWrap, func(start, end int) int { return end - start })
+	})
+}
+
+func TestTBCVisualLines(t *testing.T) {
+	c := parseTBC("aaa bbb cc\ndd")
+	wrapTBC(c, 4)
+	test_helper.AssertEquals(t, 4, c.VisualLineCount())
+	test_helper.AssertEquals(t, "bbb ", string(c.VisualLineRunes(1)))
+	test_helper.AssertEquals(t, 0, c.VisualLineLine(2))
+	test_helper.AssertEquals(t, 1, c.VisualLineLine(3))
+	test_helper.AssertEquals(t, 0, c.VisualLineIndex(3))
+	test_helper.AssertEquals(t, 1, c.VisualLineIndex(4))
+	test_helper.AssertEquals(t, 2, c.VisualLineIndex(10))
+	test_helper.AssertEquals(t, 3, c.VisualLineIndex(11))
+
+	c.SetLineWrapper(nil)
+	test_helper.AssertEquals(t, 2, c.VisualLineCount())
+}
+
+func TestTBCMoveUpWrapped(t *testing.T) {
+	c := parseTBC("aaa bbb| c|c\nd|d")
+	wrapTBC(c, 4)
+	c.MoveUp()
+	assertTBCTextAndSelectionsEqual(t, "aaa| b|bb c|c\ndd", c)
+}
+
+func TestTBCMoveDownWrapped(t *testing.T) {
+	c := parseTBC("a|aa bbb| cc\ndd")
+	wrapTBC(c, 4)
+	c.MoveDown()
+	assertTBCTextAndSelectionsEqual(t, "aaa b|bb cc|\ndd", c)
+}
+
+func TestTBCSelectHomeEndWrapped(t *testing.T) {
+	c := parseTBC("  a|a bbb c|c")
+	wrapTBC(c, 5)
+	c.SelectEnd()
+	assertTBCTextAndSelectionsEqual(t, "  a{a] bbb c{c]", c)
+	c = parseTBC("  a|a bbb c|c")
+	wrapTBC(c, 5)
+	c.SelectHome()
+	assertTBCTextAndSelectionsEqual(t, "  [a}a bbb [c}c", c)
+}
+
+func assertTBCWrappedLikeText(t *testing.T, c *TextBoxController) {
+	expected := parseTBC(c.Text())
+	wrapTBC(expected, 4)
+	test_helper.AssertEquals(t, expected.visualLineStarts, c.visualLineStarts)
+	test_helper.AssertEquals(t, expected.visualLineEnds, c.visualLineEnds)
+	test_helper.AssertEquals(t, expected.visualLineLines, c.visualLineLines)
+}
+
+func TestTBCEditsRewrapTouchedLines(t *testing.T) {
+	for _, test := range []struct {
+		markup  string
+		edit    func(c *TextBoxController)
+		wrapped []string
+		moved   bool
+	}{
+		{"aa\nbb b|b\ncc", func(c *TextBoxController) { c.ReplaceAll("bbb ") }, []string{"bb bbbb b"}, true},
+		{"aa\nbb| bb\ncc", func(c *TextBoxController) { c.ReplaceAll("b") }, []string{"bbb bb"}, false},
+		{"aa\nbb |bb\ncc", func(c *TextBoxController) { c.ReplaceWithNewline() }, []string{"bb ", "bb"}, true},
+		{"aa\nbb bb\n|cc", func(c *TextBoxController) { c.Backspace() }, []string{"bb bbcc"}, false},
+		{"aa\nbb bb|\ncc", func(c *TextBoxController) { c.Delete() }, []string{"bb bbcc"}, false},
+		{"a|a\nbb bb\ncc\nd|d", func(c *TextBoxController) { c.ReplaceAll("x") }, []string{"axa", "dxd"}, false},
+		{"aa\nb[b bb\nc}c", func(c *TextBoxController) { c.Backspace() }, []string{"bc"}, false},
+		{"aa\nbb bb\ncc|", func(c *TextBoxController) { c.ReplaceAll("\ndd ee") }, []string{"cc", "dd ee"}, false},
+		{"aa\n|bb bb\ncc", func(c *TextBoxController) { c.IndentSelection(2) }, []string{"  bb bb"}, false},
+	} {
+		c := parseTBC(test.markup)
+		var wrapped []string
+		c.SetLineWrapper(func(line []rune) []int {
+			wrapped = append(wrapped, string(line))
+			return wrapRunes(line, 4, WordWrap, func(start, end int) int { return end - start })
+		})
+		wrapped = nil
+		test.edit(c)
+		test_helper.AssertEquals(t, test.wrapped, wrapped)
+		test_helper.AssertEquals(t, test.moved, c.visualLinesMoved)
+		assertTBCWrappedLikeText(t, c)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/badu/gxui/pkg/math"
+	"github.com/chewxy/math32"
 )
 
 type ParentPoint struct {
@@ -42,7 +43,7 @@ func ValidateHierarchy(p Parent) {
 	for _, c := range p.Children() {
 		if p != c.Control.Parent() {
 			panic(fmt.Errorf("Child's parent is not as expected.\nChild: %s\nExpected parent: %s",
-				Path(c.Control), Path(p)))
+				ControlPath(c.Control), ControlPath(p)))
 		}
 		if cp, ok := c.Control.(Parent); ok {
 			ValidateHierarchy(cp)
@@ -75,12 +76,48 @@ func CommonAncestor(a, b Control) Parent {
 	return nil
 }
 
+// childTransform returns the transform of the child of parent around its
+// offset, if parent is a TransformingParent drawing the child with one.
+func childTransform(parent interface{}, child *Child) (math.Mat3, bool) {
+	if t, ok := parent.(TransformingParent); ok {
+		if m, ok := t.ChildTransform(child); ok {
+			return math.CreateMat3Translate(float32(child.Offset.X), float32(child.Offset.Y)).Mul(m), true
+		}
+	}
+	return math.Mat3{}, false
+}
+
+// ChildToParentPoint returns the point of parent where the point p of its child
+// is drawn.
+func ChildToParentPoint(parent Parent, child *Child, p math.Point) math.Point {
+	if m, ok := childTransform(parent, child); ok {
+		return m.TransformPoint(p)
+	}
+	return p.Add(child.Offset)
+}
+
+// ParentToChildPoint returns the point of the child of parent drawn at the point
+// p of parent. ok is false if the transform of the child cannot be inverted, in
+// which case no point of the child is drawn at p.
+func ParentToChildPoint(parent Parent, child *Child, p math.Point) (cp math.Point, ok bool) {
+	if m, ok := childTransform(parent, child); ok {
+		inverse, ok := m.Invert()
+		if !ok {
+			return math.Point{}, false
+		}
+		// Round down, as the point stands for the pixel on its bottom-right.
+		v := inverse.TransformVec2(p.Vec2())
+		return math.Point{X: int(math32.Floor(v.X)), Y: int(math32.Floor(v.Y))}, true
+	}
+	return p.Sub(child.Offset), true
+}
+
 func TopControlsUnder(p math.Point, c Parent) ControlPointList {
 	children := c.Children()
 	for i := len(children) - 1; i >= 0; i-- {
 		child := children[i]
-		cp := p.Sub(child.Offset)
-		if child.Control.ContainsPoint(cp) {
+		cp, ok := ParentToChildPoint(c, child, p)
+		if ok && child.Control.ContainsPoint(cp) {
 			l := ControlPointList{ControlPoint{child.Control, cp}}
 			if cc, ok := child.Control.(Parent); ok {
 				l = append(l, TopControlsUnder(cp, cc)...)
@@ -99,8 +136,8 @@ func ControlsUnder(p math.Point, c Parent) ControlPointList {
 		p = toVisit[0].Point
 		toVisit = toVisit[1:]
 		for _, child := range c.Children() {
-			cp := p.Sub(child.Offset)
-			if child.Control.ContainsPoint(cp) {
+			cp, ok := ParentToChildPoint(c, child, p)
+			if ok && child.Control.ContainsPoint(cp) {
 				l = append(l, ControlPoint{child.Control, cp})
 				if cc, ok := child.Control.(Parent); ok {
 					toVisit = append(toVisit, ParentPoint{cc, cp})
@@ -123,7 +160,7 @@ func WindowToChild(coord math.Point, to Control) math.Point {
 			Dump(p)
 			panic(fmt.Errorf("Control's parent (%p %T) did not contain control (%p %T).", &p, p, &c, c))
 		}
-		coord = coord.Sub(child.Offset)
+		coord, _ = ParentToChildPoint(p, child, coord)
 
 		switch p.(type) {
 		case *WindowImpl:
@@ -139,7 +176,7 @@ func ChildToParent(coord math.Point, from Control, to Parent) math.Point {
 	for {
 		p := control.Parent()
 		if p == nil {
-			panic(fmt.Errorf("Control detached: %s", Path(control)))
+			panic(fmt.Errorf("Control detached: %s", ControlPath(control)))
 		}
 
 		child := p.Children().Find(control)
@@ -148,7 +185,7 @@ func ChildToParent(coord math.Point, from Control, to Parent) math.Point {
 			panic(fmt.Errorf("Control's parent (%p %T) did not contain control (%p %T).", &p, p, &control, control))
 		}
 
-		coord = coord.Add(child.Offset)
+		coord = ChildToParentPoint(p, child, coord)
 		if p == to {
 			return coord
 		}
@@ -163,7 +200,35 @@ func ChildToParent(coord math.Point, from Control, to Parent) math.Point {
 }
 
 func ParentToChild(coord math.Point, from Parent, to Control) math.Point {
-	return coord.Sub(ChildToParent(math.ZeroPoint, to, from))
+	// Map the point from the top down, through the children between the
+	// controls.
+	var path []Control
+	for control := to; ; {
+		path = append(path, control)
+		p := control.Parent()
+		if p == nil {
+			panic(fmt.Errorf("Control detached: %s", ControlPath(control)))
+		}
+		if p == from {
+			break
+		}
+		var ok bool
+		control, ok = p.(Control)
+		if !ok {
+			panic(fmt.Errorf("ParentToChild (%p %T) -> (%p %T) reached non-control parent (%p %T).", &from, from, &to, to, &p, p))
+		}
+	}
+	parent := from
+	for i := len(path) - 1; i >= 0; i-- {
+		child := parent.Children().Find(path[i])
+		if child == nil {
+			Dump(parent)
+			panic(fmt.Errorf("Control's parent (%p %T) did not contain control (%p %T).", &parent, parent, &path[i], path[i]))
+		}
+		coord, _ = ParentToChildPoint(parent, child, coord)
+		parent, _ = path[i].(Parent)
+	}
+	return coord
 }
 
 func TransformCoordinate(coord math.Point, from, to Control) math.Point {
@@ -173,7 +238,7 @@ func TransformCoordinate(coord math.Point, from, to Control) math.Point {
 
 	ancestor := CommonAncestor(from, to)
 	if ancestor == nil {
-		panic(fmt.Errorf("no common ancestor between %s and %s", Path(from), Path(to)))
+		panic(fmt.Errorf("no common ancestor between %s and %s", ControlPath(from), ControlPath(to)))
 	}
 
 	if parent, ok := ancestor.(Control); !ok || parent != from {
@@ -225,6 +290,23 @@ func WindowContaining(control Control) *WindowImpl {
 	}
 }
 
+// IsRightToLeft returns true if the parent is laid out in a window mirrored
+// from right to left. Parents not attached to a window are laid out left to
+// right.
+func IsRightToLeft(parent Parent) bool {
+	for parent != nil {
+		switch p := parent.(type) {
+		case *WindowImpl:
+			return p.RightToLeft()
+		case Control:
+			parent = p.Parent()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
 func SetFocus(target Focusable) {
 	window := WindowContaining(target)
 	window.SetFocus(target)
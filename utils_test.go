@@ -0,0 +1,63 @@
+package gxui
+
+import (
+	"testing"
+
+	"github.com/badu/gxui/pkg/math"
+	"github.com/badu/gxui/test_helper"
+)
+
+// zoomParent draws its children scaled by zoom around their offsets.
+type zoomParent struct {
+	children Children
+	zoom     float32
+}
+
+func (p *zoomParent) Children() Children { return p.children }
+func (p *zoomParent) ReLayout()          {}
+func (p *zoomParent) Redraw()            {}
+
+func (p *zoomParent) ChildTransform(child *Child) (math.Mat3, bool) {
+	return math.CreateMat3Scale(p.zoom, p.zoom), p.zoom != 1
+}
+
+// boxControl is a control only used for hit-testing.
+type boxControl struct {
+	Control
+	size math.Size
+}
+
+func (c *boxControl) ContainsPoint(p math.Point) bool {
+	return c.size.Rect().Contains(p)
+}
+
+func TestChildPoints(t *testing.T) {
+	child := &Child{Control: &boxControl{size: math.Size{Width: 10, Height: 10}}, Offset: math.Point{X: 20, Y: 30}}
+	parent := &zoomParent{children: Children{child}, zoom: 2}
+
+	test_helper.AssertEquals(t, math.Point{X: 30, Y: 38}, ChildToParentPoint(parent, child, math.Point{X: 5, Y: 4}))
+	cp, ok := ParentToChildPoint(parent, child, math.Point{X: 31, Y: 39})
+	test_helper.AssertEquals(t, true, ok)
+	test_helper.AssertEquals(t, math.Point{X: 5, Y: 4}, cp)
+
+	parent.zoom = 1
+	test_helper.AssertEquals(t, math.Point{X: 25, Y: 34}, ChildToParentPoint(parent, child, math.Point{X: 5, Y: 4}))
+
+	// Nothing is drawn with a degenerate transform.
+	parent.zoom = 0
+	_, ok = ParentToChildPoint(parent, child, math.Point{X: 20, Y: 30})
+	test_helper.AssertEquals(t, false, ok)
+}
+
+func TestControlsUnderTransform(t *testing.T) {
+	child := &Child{Control: &boxControl{size: math.Size{Width: 10, Height: 10}}, Offset: math.Point{X: 20, Y: 30}}
+	parent := &zoomParent{children: Children{child}, zoom: 2}
+
+	// The child covers twice its size once zoomed.
+	under := TopControlsUnder(math.Point{X: 38, Y: 48}, parent)
+	test_helper.AssertEquals(t, ControlPointList{{child.Control, math.Point{X: 9, Y: 9}}}, under)
+	test_helper.AssertEquals(t, 0, len(ControlsUnder(math.Point{X: 41, Y: 30}, parent)))
+
+	parent.zoom = 1
+	test_helper.AssertEquals(t, 0, len(TopControlsUnder(math.Point{X: 38, Y: 48}, parent)))
+}
@@ -5,6 +5,8 @@
 package gxui
 
 import (
+	"time"
+
 	"github.com/badu/gxui/pkg/math"
 )
 
@@ -36,9 +38,13 @@ type WindowImpl struct {
 	focusController       *FocusController
 	viewportSubscriptions []EventSubscription
 	windowedSize          math.Size
+	damage                []math.Rect   // The rectangles changed since the last draw
+	layoutTime            time.Duration // The time spent laying out since the last draw
 	layoutPending         bool
+	redrawAll             bool
 	drawPending           bool
 	updatePending         bool
+	rightToLeft           bool
 }
 
 func (w *WindowImpl) requestUpdate() {
@@ -61,7 +67,8 @@ func (w *WindowImpl) update() {
 	if w.layoutPending {
 		w.layoutPending = false
 		w.drawPending = true
-		w.parent.LayoutChildren()
+		w.redrawAll = true
+		w.layoutChildren()
 	}
 
 	if w.drawPending {
@@ -100,7 +107,7 @@ func (w *WindowImpl) Init(window *WindowImpl, driver Driver, width, height int,
 
 	w.onResize.Listen(
 		func() {
-			w.parent.LayoutChildren()
+			w.layoutChildren()
 			w.Draw()
 		},
 	)
@@ -117,16 +124,32 @@ func (w *WindowImpl) Init(window *WindowImpl, driver Driver, width, height int,
 func (w *WindowImpl) Draw() Canvas {
 	// TODO : the DrawPaintPart has similar functionality, except setting the canvas to the viewport - embed DrawPaintPart in Window
 	if size := w.viewport.SizeDips(); size != math.ZeroSize {
+		start := time.Now()
 		canvas := w.driver.CreateCanvas(size)
 		w.parent.Paint(canvas)
 		canvas.Complete()
-		w.viewport.SetCanvas(canvas)
+		w.viewport.RecordFrameTimes(w.layoutTime, time.Since(start))
+		w.layoutTime = 0
+		if w.redrawAll || len(w.damage) == 0 {
+			w.viewport.SetCanvas(canvas)
+		} else {
+			w.viewport.SetCanvasDamage(canvas, w.damage)
+		}
+		w.redrawAll, w.damage = false, nil
 		return canvas
 	} else {
 		return nil
 	}
 }
 
+// layoutChildren lays out the children of the window, timing it for the
+// statistics of the next frame.
+func (w *WindowImpl) layoutChildren() {
+	start := time.Now()
+	w.parent.LayoutChildren()
+	w.layoutTime += time.Since(start)
+}
+
 func (w *WindowImpl) Paint(canvas Canvas) {
 	w.PaintBackground(canvas, canvas.Size().Rect())
 	w.PaintChildrenPart.Paint(canvas)
@@ -200,6 +223,35 @@ func (w *WindowImpl) SetFullscreen(fullscreen bool) {
 	}
 }
 
+// RightToLeft returns true if the controls of the window are laid out mirrored,
+// from right to left.
+func (w *WindowImpl) RightToLeft() bool {
+	return w.rightToLeft
+}
+
+// SetRightToLeft sets whether the controls of the window are laid out mirrored:
+// horizontal linear layouts and the tabs of panel holders run from the right,
+// and vertical scroll bars are placed on the left.
+func (w *WindowImpl) SetRightToLeft(rightToLeft bool) {
+	if w.rightToLeft == rightToLeft {
+		return
+	}
+	w.rightToLeft = rightToLeft
+
+	// The controls keep their sizes, so each is marked to be laid out again.
+	var reLayout func(parent Parent)
+	reLayout = func(parent Parent) {
+		for _, child := range parent.Children() {
+			if p, ok := child.Control.(Parent); ok {
+				p.ReLayout()
+				reLayout(p)
+			}
+		}
+	}
+	reLayout(w.parent)
+	w.ReLayout()
+}
+
 func (w *WindowImpl) Show() {
 	w.Attach()
 	w.viewport.Show()
@@ -340,6 +392,21 @@ func (w *WindowImpl) ReLayout() {
 }
 
 func (w *WindowImpl) Redraw() {
+	w.redrawAll = true
+	w.drawPending = true
+	w.requestUpdate()
+}
+
+// RedrawRect redraws the window, which only changed within rect. The viewport
+// is only redrawn within the rectangles changed since the last draw.
+func (w *WindowImpl) RedrawRect(rect math.Rect) {
+	rect = rect.Overlap(w.Size().Rect())
+	if rect.Size().Area() == 0 {
+		return
+	}
+	if !w.redrawAll {
+		w.damage = addDamage(w.damage, rect)
+	}
 	w.drawPending = true
 	w.requestUpdate()
 }